@@ -9,8 +9,9 @@ import (
 )
 
 // FaultFunc is a function used to fault in the parent,
-// rules for an ACL given its ID
-type FaultFunc func(id string) (string, string, error)
+// rules for an ACL given its ID. An ACL can be made up of
+// several sets of rules, which will be merged.
+type FaultFunc func(id string) (string, []string, error)
 
 // aclEntry allows us to store the ACL with it's policy ID
 type aclEntry struct {
@@ -82,6 +83,26 @@ func (c *Cache) getPolicy(id, rules string) (*Policy, error) {
 
 }
 
+// GetMergedPolicy is used to get a potentially cached policy set
+// which merges the policies of all the given rules.
+func (c *Cache) GetMergedPolicy(rules []string) (*Policy, error) {
+	if len(rules) == 0 {
+		return c.GetPolicy("")
+	}
+
+	policies := make([]*Policy, 0, len(rules))
+	for _, r := range rules {
+		policy, err := c.GetPolicy(r)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, policy)
+	}
+	merged := MergePolicies(policies)
+	c.ruleCache.Add(merged.ID, merged)
+	return merged, nil
+}
+
 // RuleID is used to generate an ID for a rule
 func RuleID(rules string) string {
 	return fmt.Sprintf("%x", md5.Sum([]byte(rules)))
@@ -110,7 +131,7 @@ func (c *Cache) GetACLPolicy(id string) (string, *Policy, error) {
 	}
 
 	// Get cached
-	policy, err := c.GetPolicy(rules)
+	policy, err := c.GetMergedPolicy(rules)
 	return parent, policy, err
 }
 
@@ -128,7 +149,13 @@ func (c *Cache) GetACL(id string) (ACL, error) {
 	if err != nil {
 		return nil, err
 	}
-	ruleID := RuleID(rules)
+
+	// Get the policy
+	policy, err := c.GetMergedPolicy(rules)
+	if err != nil {
+		return nil, err
+	}
+	ruleID := policy.ID
 
 	// Check for a compiled ACL
 	policyID := c.policyID(parentID, ruleID)
@@ -136,12 +163,6 @@ func (c *Cache) GetACL(id string) (ACL, error) {
 	if raw, ok := c.policyCache.Get(policyID); ok {
 		compiled = raw.(ACL)
 	} else {
-		// Get the policy
-		policy, err := c.getPolicy(ruleID, rules)
-		if err != nil {
-			return nil, err
-		}
-
 		// Get the parent ACL
 		parent := RootACL(parentID)
 		if parent == nil {
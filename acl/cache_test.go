@@ -58,8 +58,8 @@ func TestCache_GetACL(t *testing.T) {
 		"bar": testSimplePolicy2,
 		"baz": testSimplePolicy3,
 	}
-	faultfn := func(id string) (string, string, error) {
-		return "deny", policies[id], nil
+	faultfn := func(id string) (string, []string, error) {
+		return "deny", []string{policies[id]}, nil
 	}
 
 	c, err := NewCache(2, faultfn, nil)
@@ -121,8 +121,8 @@ func TestCache_ClearACL(t *testing.T) {
 		"foo": testSimplePolicy,
 		"bar": testSimplePolicy,
 	}
-	faultfn := func(id string) (string, string, error) {
-		return "deny", policies[id], nil
+	faultfn := func(id string) (string, []string, error) {
+		return "deny", []string{policies[id]}, nil
 	}
 
 	c, err := NewCache(16, faultfn, nil)
@@ -156,8 +156,8 @@ func TestCache_Purge(t *testing.T) {
 		"foo": testSimplePolicy,
 		"bar": testSimplePolicy,
 	}
-	faultfn := func(id string) (string, string, error) {
-		return "deny", policies[id], nil
+	faultfn := func(id string) (string, []string, error) {
+		return "deny", []string{policies[id]}, nil
 	}
 
 	c, err := NewCache(16, faultfn, nil)
@@ -189,8 +189,8 @@ func TestCache_GetACLPolicy(t *testing.T) {
 		"foo": testSimplePolicy,
 		"bar": testSimplePolicy,
 	}
-	faultfn := func(id string) (string, string, error) {
-		return "deny", policies[id], nil
+	faultfn := func(id string) (string, []string, error) {
+		return "deny", []string{policies[id]}, nil
 	}
 	c, err := NewCache(16, faultfn, nil)
 	if err != nil {
@@ -233,16 +233,16 @@ func TestCache_GetACLPolicy(t *testing.T) {
 }
 
 func TestCache_GetACL_Parent(t *testing.T) {
-	faultfn := func(id string) (string, string, error) {
+	faultfn := func(id string) (string, []string, error) {
 		switch id {
 		case "foo":
 			// Foo inherits from bar
-			return "bar", testSimplePolicy, nil
+			return "bar", []string{testSimplePolicy}, nil
 		case "bar":
-			return "deny", testSimplePolicy2, nil
+			return "deny", []string{testSimplePolicy2}, nil
 		}
 		t.Fatalf("bad case")
-		return "", "", nil
+		return "", nil, nil
 	}
 
 	c, err := NewCache(16, faultfn, nil)
@@ -265,15 +265,15 @@ func TestCache_GetACL_Parent(t *testing.T) {
 
 func TestCache_GetACL_ParentCache(t *testing.T) {
 	// Same rules, different parent
-	faultfn := func(id string) (string, string, error) {
+	faultfn := func(id string) (string, []string, error) {
 		switch id {
 		case "foo":
-			return "allow", testSimplePolicy, nil
+			return "allow", []string{testSimplePolicy}, nil
 		case "bar":
-			return "deny", testSimplePolicy, nil
+			return "deny", []string{testSimplePolicy}, nil
 		}
 		t.Fatalf("bad case")
-		return "", "", nil
+		return "", nil, nil
 	}
 
 	c, err := NewCache(16, faultfn, nil)
@@ -326,3 +326,37 @@ key "baz/" {
 	policy = "read"
 }
 `
+
+func TestCache_GetACL_MergedRules(t *testing.T) {
+	faultfn := func(id string) (string, []string, error) {
+		return "deny", []string{testSimplePolicy, testSimplePolicy2}, nil
+	}
+
+	c, err := NewCache(16, faultfn, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	acl, err := c.GetACL("foo")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !acl.KeyRead("foo/test") || !acl.KeyRead("bar/test") {
+		t.Fatalf("should allow")
+	}
+	if acl.KeyRead("baz/test") {
+		t.Fatalf("should not allow")
+	}
+
+	// The merged policy should be served from the cache as well.
+	parent, policy, err := c.GetACLPolicy("foo")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if parent != "deny" || len(policy.Keys) != 2 {
+		t.Fatalf("bad: %s %#v", parent, policy)
+	}
+	if policy.ID == RuleID(testSimplePolicy) || policy.ID == RuleID(testSimplePolicy2) {
+		t.Fatalf("bad: %s", policy.ID)
+	}
+}
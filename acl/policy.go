@@ -2,6 +2,8 @@ package acl
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/hashicorp/consul/sentinel"
 	"github.com/hashicorp/hcl"
@@ -237,3 +239,147 @@ func Parse(rules string, sentinel sentinel.Evaluator) (*Policy, error) {
 
 	return p, nil
 }
+
+// policyRanks orders the basic policies from least to most permissive so that
+// rules can be merged. Deny isn't ranked since it always takes precedence.
+var policyRanks = map[string]int{
+	PolicyRead:  1,
+	PolicyList:  2,
+	PolicyWrite: 3,
+}
+
+// takesPrecedenceOver returns true if the policy a should be used in favor of
+// the policy b when both apply to the same resource.
+func takesPrecedenceOver(a, b string) bool {
+	if a == PolicyDeny {
+		return true
+	}
+	if b == PolicyDeny {
+		return false
+	}
+	return policyRanks[a] > policyRanks[b]
+}
+
+// MergePolicies composes several policies into a single one which can then be
+// compiled with New. When more than one policy has a rule for the same
+// resource, a deny always wins and otherwise the most permissive rule is used.
+// The merged policy gets an ID derived from the IDs of all the given policies
+// so it can be cached like any other policy.
+func MergePolicies(policies []*Policy) *Policy {
+	switch len(policies) {
+	case 0:
+		return &Policy{ID: RuleID("")}
+	case 1:
+		return policies[0]
+	}
+
+	merged := &Policy{}
+	agents := make(map[string]*AgentPolicy)
+	keys := make(map[string]*KeyPolicy)
+	nodes := make(map[string]*NodePolicy)
+	services := make(map[string]*ServicePolicy)
+	sessions := make(map[string]*SessionPolicy)
+	events := make(map[string]*EventPolicy)
+	queries := make(map[string]*PreparedQueryPolicy)
+	ids := make([]string, 0, len(policies))
+	for _, p := range policies {
+		ids = append(ids, p.ID)
+
+		for _, ap := range p.Agents {
+			if existing, ok := agents[ap.Node]; ok {
+				if takesPrecedenceOver(ap.Policy, existing.Policy) {
+					*existing = *ap
+				}
+				continue
+			}
+			rule := *ap
+			agents[ap.Node] = &rule
+			merged.Agents = append(merged.Agents, &rule)
+		}
+
+		for _, kp := range p.Keys {
+			if existing, ok := keys[kp.Prefix]; ok {
+				if takesPrecedenceOver(kp.Policy, existing.Policy) {
+					*existing = *kp
+				}
+				continue
+			}
+			rule := *kp
+			keys[kp.Prefix] = &rule
+			merged.Keys = append(merged.Keys, &rule)
+		}
+
+		for _, np := range p.Nodes {
+			if existing, ok := nodes[np.Name]; ok {
+				if takesPrecedenceOver(np.Policy, existing.Policy) {
+					*existing = *np
+				}
+				continue
+			}
+			rule := *np
+			nodes[np.Name] = &rule
+			merged.Nodes = append(merged.Nodes, &rule)
+		}
+
+		for _, sp := range p.Services {
+			if existing, ok := services[sp.Name]; ok {
+				if takesPrecedenceOver(sp.Policy, existing.Policy) {
+					*existing = *sp
+				}
+				continue
+			}
+			rule := *sp
+			services[sp.Name] = &rule
+			merged.Services = append(merged.Services, &rule)
+		}
+
+		for _, sp := range p.Sessions {
+			if existing, ok := sessions[sp.Node]; ok {
+				if takesPrecedenceOver(sp.Policy, existing.Policy) {
+					*existing = *sp
+				}
+				continue
+			}
+			rule := *sp
+			sessions[sp.Node] = &rule
+			merged.Sessions = append(merged.Sessions, &rule)
+		}
+
+		for _, ep := range p.Events {
+			if existing, ok := events[ep.Event]; ok {
+				if takesPrecedenceOver(ep.Policy, existing.Policy) {
+					*existing = *ep
+				}
+				continue
+			}
+			rule := *ep
+			events[ep.Event] = &rule
+			merged.Events = append(merged.Events, &rule)
+		}
+
+		for _, pq := range p.PreparedQueries {
+			if existing, ok := queries[pq.Prefix]; ok {
+				if takesPrecedenceOver(pq.Policy, existing.Policy) {
+					*existing = *pq
+				}
+				continue
+			}
+			rule := *pq
+			queries[pq.Prefix] = &rule
+			merged.PreparedQueries = append(merged.PreparedQueries, &rule)
+		}
+
+		// The keyring and operator policies are allowed to be empty, in
+		// which case they don't take part in the merge.
+		if p.Keyring != "" && (merged.Keyring == "" || takesPrecedenceOver(p.Keyring, merged.Keyring)) {
+			merged.Keyring = p.Keyring
+		}
+		if p.Operator != "" && (merged.Operator == "" || takesPrecedenceOver(p.Operator, merged.Operator)) {
+			merged.Operator = p.Operator
+		}
+	}
+
+	sort.Strings(ids)
+	merged.ID = RuleID(strings.Join(ids, "\n"))
+	return merged
+}
@@ -409,3 +409,62 @@ func TestACLPolicy_Bad_Policy(t *testing.T) {
 		}
 	}
 }
+
+func TestACLPolicy_Merge(t *testing.T) {
+	p1, err := Parse(`
+key "foo/" { policy = "read" }
+key "bar/" { policy = "write" }
+service "web" { policy = "read" }
+operator = "read"
+`, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	p1.ID = "p1"
+	p2, err := Parse(`
+key "foo/" { policy = "write" }
+key "bar/" { policy = "deny" }
+node "" { policy = "read" }
+keyring = "write"
+operator = "deny"
+`, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	p2.ID = "p2"
+
+	// A single policy is passed through as-is.
+	if merged := MergePolicies([]*Policy{p1}); merged != p1 {
+		t.Fatalf("bad: %#v", merged)
+	}
+
+	merged := MergePolicies([]*Policy{p1, p2})
+	exp := &Policy{
+		ID: RuleID("p1\np2"),
+		Keys: []*KeyPolicy{
+			&KeyPolicy{Prefix: "foo/", Policy: PolicyWrite},
+			&KeyPolicy{Prefix: "bar/", Policy: PolicyDeny},
+		},
+		Nodes: []*NodePolicy{
+			&NodePolicy{Name: "", Policy: PolicyRead},
+		},
+		Services: []*ServicePolicy{
+			&ServicePolicy{Name: "web", Policy: PolicyRead},
+		},
+		Keyring:  PolicyWrite,
+		Operator: PolicyDeny,
+	}
+	if !reflect.DeepEqual(merged, exp) {
+		t.Fatalf("bad: %#v", merged)
+	}
+
+	// The order of the policies doesn't change the outcome.
+	if reversed := MergePolicies([]*Policy{p2, p1}); reversed.ID != merged.ID || reversed.Operator != PolicyDeny {
+		t.Fatalf("bad: %#v", reversed)
+	}
+
+	// The inputs must not be modified by the merge.
+	if p1.Keys[1].Policy != PolicyWrite || p1.Operator != PolicyRead {
+		t.Fatalf("bad: %#v", p1)
+	}
+}
@@ -153,13 +153,14 @@ func (m *aclManager) lookupACL(a *Agent, id string) (acl.ACL, error) {
 	// At this point we might have a stale cached ACL, or none at all, so
 	// try to contact the servers.
 	args := structs.ACLPolicyRequest{
-		Datacenter: a.config.ACLDatacenter,
-		ACL:        id,
+		Datacenter:       a.config.ACLDatacenter,
+		ACL:              id,
+		SourceDatacenter: a.config.Datacenter,
 	}
 	if cached != nil {
 		args.ETag = cached.ETag
 	}
-	var reply structs.ACLPolicy
+	var reply structs.ACLPolicyResponse
 	err := a.RPC("ACL.GetPolicy", &args, &reply)
 	if err != nil {
 		if acl.IsErrDisabled(err) {
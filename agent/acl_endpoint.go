@@ -260,3 +260,268 @@ func (s *HTTPServer) ACLReplicationStatus(resp http.ResponseWriter, req *http.Re
 	}
 	return out, nil
 }
+
+// ACLPolicyCreate is used to create a new ACL policy.
+func (s *HTTPServer) ACLPolicyCreate(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if req.Method != "PUT" {
+		return nil, MethodNotAllowedError{req.Method, []string{"PUT"}}
+	}
+	return s.aclPolicySet(resp, req, "")
+}
+
+// ACLPolicySpecific handles the requests for a particular ACL policy.
+func (s *HTTPServer) ACLPolicySpecific(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	id := strings.TrimPrefix(req.URL.Path, "/v1/acl/policy/")
+	if id == "" {
+		resp.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(resp, "Missing ACL policy ID")
+		return nil, nil
+	}
+
+	switch req.Method {
+	case "GET":
+		return s.aclPolicyGet(id, resp, req)
+
+	case "PUT":
+		return s.aclPolicySet(resp, req, id)
+
+	case "DELETE":
+		return s.aclPolicyDelete(id, resp, req)
+
+	default:
+		return nil, MethodNotAllowedError{req.Method, []string{"GET", "PUT", "DELETE"}}
+	}
+}
+
+// aclPolicyGet returns a single ACL policy.
+func (s *HTTPServer) aclPolicyGet(id string, resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	args := structs.ACLPolicySpecificRequest{
+		Datacenter: s.agent.config.ACLDatacenter,
+		PolicyID:   id,
+	}
+	var dc string
+	if done := s.parse(resp, req, &dc, &args.QueryOptions); done {
+		return nil, nil
+	}
+
+	var out structs.IndexedACLPolicies
+	defer setMeta(resp, &out.QueryMeta)
+	if err := s.agent.RPC("ACL.PolicyGet", &args, &out); err != nil {
+		return nil, err
+	}
+	if len(out.Policies) == 0 {
+		resp.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(resp, "ACL policy not found")
+		return nil, nil
+	}
+	return out.Policies[0], nil
+}
+
+// aclPolicySet creates or updates an ACL policy. The ID is taken from the URL
+// for updates, and generated for new policies.
+func (s *HTTPServer) aclPolicySet(resp http.ResponseWriter, req *http.Request, id string) (interface{}, error) {
+	args := structs.ACLPolicyApplyRequest{
+		Datacenter: s.agent.config.ACLDatacenter,
+		Op:         structs.ACLSet,
+	}
+	s.parseToken(req, &args.Token)
+
+	// Handle optional request body
+	if req.ContentLength > 0 {
+		if err := decodeBody(req, &args.Policy, nil); err != nil {
+			resp.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(resp, "Request decode failed: %v", err)
+			return nil, nil
+		}
+	}
+	args.Policy.ID = id
+
+	var out string
+	if err := s.agent.RPC("ACL.PolicyApply", &args, &out); err != nil {
+		return nil, err
+	}
+	return aclCreateResponse{out}, nil
+}
+
+// aclPolicyDelete deletes an ACL policy.
+func (s *HTTPServer) aclPolicyDelete(id string, resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	args := structs.ACLPolicyApplyRequest{
+		Datacenter: s.agent.config.ACLDatacenter,
+		Op:         structs.ACLDelete,
+		Policy: structs.ACLPolicy{
+			ID: id,
+		},
+	}
+	s.parseToken(req, &args.Token)
+
+	var out string
+	if err := s.agent.RPC("ACL.PolicyApply", &args, &out); err != nil {
+		return nil, err
+	}
+	return true, nil
+}
+
+// ACLPolicyList returns all the ACL policies.
+func (s *HTTPServer) ACLPolicyList(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if req.Method != "GET" {
+		return nil, MethodNotAllowedError{req.Method, []string{"GET"}}
+	}
+
+	args := structs.DCSpecificRequest{
+		Datacenter: s.agent.config.ACLDatacenter,
+	}
+	var dc string
+	if done := s.parse(resp, req, &dc, &args.QueryOptions); done {
+		return nil, nil
+	}
+
+	var out structs.IndexedACLPolicies
+	defer setMeta(resp, &out.QueryMeta)
+	if err := s.agent.RPC("ACL.PolicyList", &args, &out); err != nil {
+		return nil, err
+	}
+
+	// Use empty list instead of nil
+	if out.Policies == nil {
+		out.Policies = make(structs.ACLPolicies, 0)
+	}
+	return out.Policies, nil
+}
+
+// ACLTokenCreate is used to create a new token, which can be linked to
+// policies.
+func (s *HTTPServer) ACLTokenCreate(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if req.Method != "PUT" {
+		return nil, MethodNotAllowedError{req.Method, []string{"PUT"}}
+	}
+	return s.aclTokenSet(resp, req, "")
+}
+
+// ACLTokenSpecific handles the requests for a particular token.
+func (s *HTTPServer) ACLTokenSpecific(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	id := strings.TrimPrefix(req.URL.Path, "/v1/acl/token/")
+	if id == "" {
+		resp.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(resp, "Missing ACL")
+		return nil, nil
+	}
+
+	switch req.Method {
+	case "GET":
+		return s.aclTokenGet(id, resp, req)
+
+	case "PUT":
+		return s.aclTokenSet(resp, req, id)
+
+	case "DELETE":
+		return s.aclTokenDelete(id, resp, req)
+
+	default:
+		return nil, MethodNotAllowedError{req.Method, []string{"GET", "PUT", "DELETE"}}
+	}
+}
+
+// aclTokenGet returns a single token.
+func (s *HTTPServer) aclTokenGet(id string, resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	args := structs.ACLSpecificRequest{
+		Datacenter: s.agent.config.ACLDatacenter,
+		ACL:        id,
+	}
+	var dc string
+	if done := s.parse(resp, req, &dc, &args.QueryOptions); done {
+		return nil, nil
+	}
+
+	var out structs.IndexedACLs
+	defer setMeta(resp, &out.QueryMeta)
+	if err := s.agent.RPC("ACL.Get", &args, &out); err != nil {
+		return nil, err
+	}
+	if len(out.ACLs) == 0 {
+		resp.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(resp, "ACL not found")
+		return nil, nil
+	}
+	return out.ACLs[0], nil
+}
+
+// aclTokenSet creates or updates a token. The ID is taken from the URL for
+// updates, and generated for new tokens. Like with the legacy endpoints, the
+// whole token is replaced on update.
+func (s *HTTPServer) aclTokenSet(resp http.ResponseWriter, req *http.Request, id string) (interface{}, error) {
+	args := structs.ACLRequest{
+		Datacenter: s.agent.config.ACLDatacenter,
+		Op:         structs.ACLSet,
+	}
+	s.parseToken(req, &args.Token)
+
+	// Handle optional request body
+	if req.ContentLength > 0 {
+		if err := decodeBody(req, &args.ACL, nil); err != nil {
+			resp.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(resp, "Request decode failed: %v", err)
+			return nil, nil
+		}
+	}
+	if id != "" {
+		args.ACL.ID = id
+	}
+	if args.ACL.Type == "" {
+		args.ACL.Type = structs.ACLTypeClient
+	}
+
+	var out string
+	if err := s.agent.RPC("ACL.Apply", &args, &out); err != nil {
+		return nil, err
+	}
+	return aclCreateResponse{out}, nil
+}
+
+// aclTokenDelete deletes a token.
+func (s *HTTPServer) aclTokenDelete(id string, resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	args := structs.ACLRequest{
+		Datacenter: s.agent.config.ACLDatacenter,
+		Op:         structs.ACLDelete,
+		ACL: structs.ACL{
+			ID: id,
+		},
+	}
+	s.parseToken(req, &args.Token)
+
+	var out string
+	if err := s.agent.RPC("ACL.Apply", &args, &out); err != nil {
+		return nil, err
+	}
+	return true, nil
+}
+
+// ACLTokenList returns all the tokens, which is the same as ACLList.
+func (s *HTTPServer) ACLTokenList(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	return s.ACLList(resp, req)
+}
+
+// ACLTokenUpgrade upgrades a legacy token so its rules are held by a policy.
+func (s *HTTPServer) ACLTokenUpgrade(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if req.Method != "PUT" {
+		return nil, MethodNotAllowedError{req.Method, []string{"PUT"}}
+	}
+
+	args := structs.ACLTokenUpgradeRequest{
+		Datacenter: s.agent.config.ACLDatacenter,
+	}
+	s.parseToken(req, &args.Token)
+
+	// Pull out the acl id
+	args.ACL = strings.TrimPrefix(req.URL.Path, "/v1/acl/upgrade/")
+	if args.ACL == "" {
+		resp.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(resp, "Missing ACL")
+		return nil, nil
+	}
+
+	var out structs.ACL
+	if err := s.agent.RPC("ACL.TokenUpgrade", &args, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"testing"
 
 	"github.com/hashicorp/consul/acl"
@@ -131,6 +132,52 @@ func TestACL_UpdateUpsert(t *testing.T) {
 	}
 }
 
+func TestACL_CreateUpdate_DefaultType(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t.Name(), TestACLConfig())
+	defer a.Shutdown()
+
+	// Tokens created or updated without a type are client tokens.
+	var id string
+	for _, path := range []string{"/v1/acl/create", "/v1/acl/update"} {
+		body := bytes.NewBuffer(nil)
+		enc := json.NewEncoder(body)
+		raw := map[string]interface{}{
+			"Name":  "User Token",
+			"Rules": "",
+		}
+		if id != "" {
+			raw["ID"] = id
+		}
+		enc.Encode(raw)
+
+		req, _ := http.NewRequest("PUT", path+"?token=root", body)
+		resp := httptest.NewRecorder()
+		var obj interface{}
+		var err error
+		if id == "" {
+			obj, err = a.srv.ACLCreate(resp, req)
+		} else {
+			obj, err = a.srv.ACLUpdate(resp, req)
+		}
+		if err != nil {
+			t.Fatalf("%s: err: %v", path, err)
+		}
+		id = obj.(aclCreateResponse).ID
+
+		req, _ = http.NewRequest("GET", "/v1/acl/info/"+id, nil)
+		resp = httptest.NewRecorder()
+		obj, err = a.srv.ACLGet(resp, req)
+		if err != nil {
+			t.Fatalf("%s: err: %v", path, err)
+		}
+		acls := obj.(structs.ACLs)
+		if len(acls) != 1 || acls[0].Type != structs.ACLTypeClient {
+			t.Fatalf("%s: bad: %v", path, acls)
+		}
+	}
+}
+
 func TestACL_Destroy(t *testing.T) {
 	t.Parallel()
 	a := NewTestAgent(t.Name(), TestACLConfig())
@@ -291,3 +338,212 @@ func TestACLReplicationStatus(t *testing.T) {
 		t.Fatalf("should work")
 	}
 }
+
+func makeTestACLPolicy(t *testing.T, srv *HTTPServer, name, rules string) string {
+	body := bytes.NewBuffer(nil)
+	enc := json.NewEncoder(body)
+	raw := map[string]interface{}{
+		"Name":  name,
+		"Rules": rules,
+	}
+	enc.Encode(raw)
+
+	req, _ := http.NewRequest("PUT", "/v1/acl/policy?token=root", body)
+	resp := httptest.NewRecorder()
+	obj, err := srv.ACLPolicyCreate(resp, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	return obj.(aclCreateResponse).ID
+}
+
+func TestACL_Policy_CRUD(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t.Name(), TestACLConfig())
+	defer a.Shutdown()
+
+	id := makeTestACLPolicy(t, a.srv, "web", `service "web" { policy = "write" }`)
+
+	// Read it back.
+	req, _ := http.NewRequest("GET", "/v1/acl/policy/"+id+"?token=root", nil)
+	resp := httptest.NewRecorder()
+	obj, err := a.srv.ACLPolicySpecific(resp, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	policy, ok := obj.(*structs.ACLPolicy)
+	if !ok {
+		t.Fatalf("bad: %T", obj)
+	}
+	if policy.ID != id || policy.Name != "web" {
+		t.Fatalf("bad: %v", policy)
+	}
+
+	// Update it.
+	body := bytes.NewBuffer(nil)
+	enc := json.NewEncoder(body)
+	enc.Encode(map[string]interface{}{
+		"Name":  "web-2",
+		"Rules": `service "web" { policy = "read" }`,
+	})
+	req, _ = http.NewRequest("PUT", "/v1/acl/policy/"+id+"?token=root", body)
+	resp = httptest.NewRecorder()
+	obj, err = a.srv.ACLPolicySpecific(resp, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if got := obj.(aclCreateResponse).ID; got != id {
+		t.Fatalf("got %q want %q", got, id)
+	}
+
+	// List should show the update.
+	req, _ = http.NewRequest("GET", "/v1/acl/policies?token=root", nil)
+	resp = httptest.NewRecorder()
+	obj, err = a.srv.ACLPolicyList(resp, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	policies, ok := obj.(structs.ACLPolicies)
+	if !ok {
+		t.Fatalf("bad: %T", obj)
+	}
+	if len(policies) != 1 || policies[0].Name != "web-2" {
+		t.Fatalf("bad: %v", policies)
+	}
+
+	// Delete it.
+	req, _ = http.NewRequest("DELETE", "/v1/acl/policy/"+id+"?token=root", nil)
+	resp = httptest.NewRecorder()
+	if _, err := a.srv.ACLPolicySpecific(resp, req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Now it should be gone.
+	req, _ = http.NewRequest("GET", "/v1/acl/policy/"+id+"?token=root", nil)
+	resp = httptest.NewRecorder()
+	obj, err = a.srv.ACLPolicySpecific(resp, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if obj != nil || resp.Code != http.StatusNotFound {
+		t.Fatalf("bad: %d %v", resp.Code, obj)
+	}
+}
+
+func TestACL_Policy_List_Empty(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t.Name(), TestACLConfig())
+	defer a.Shutdown()
+
+	req, _ := http.NewRequest("GET", "/v1/acl/policies?token=root", nil)
+	resp := httptest.NewRecorder()
+	obj, err := a.srv.ACLPolicyList(resp, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	policies, ok := obj.(structs.ACLPolicies)
+	if !ok {
+		t.Fatalf("bad: %T", obj)
+	}
+	if policies == nil || len(policies) != 0 {
+		t.Fatalf("bad: %v", policies)
+	}
+}
+
+func TestACL_Token_CRUD(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t.Name(), TestACLConfig())
+	defer a.Shutdown()
+
+	policyID := makeTestACLPolicy(t, a.srv, "web", `service "web" { policy = "write" }`)
+
+	// Create a token linked to the policy by name.
+	body := bytes.NewBuffer(nil)
+	enc := json.NewEncoder(body)
+	enc.Encode(map[string]interface{}{
+		"Name": "web token",
+		"Policies": []map[string]interface{}{
+			{"Name": "web"},
+		},
+	})
+	req, _ := http.NewRequest("PUT", "/v1/acl/token?token=root", body)
+	resp := httptest.NewRecorder()
+	obj, err := a.srv.ACLTokenCreate(resp, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	id := obj.(aclCreateResponse).ID
+
+	// Read it back and make sure the link was resolved.
+	req, _ = http.NewRequest("GET", "/v1/acl/token/"+id, nil)
+	resp = httptest.NewRecorder()
+	obj, err = a.srv.ACLTokenSpecific(resp, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	token, ok := obj.(*structs.ACL)
+	if !ok {
+		t.Fatalf("bad: %T", obj)
+	}
+	if token.Type != structs.ACLTypeClient {
+		t.Fatalf("bad: %v", token)
+	}
+	want := []structs.ACLTokenPolicyLink{{ID: policyID, Name: "web"}}
+	if !reflect.DeepEqual(token.Policies, want) {
+		t.Fatalf("got %v want %v", token.Policies, want)
+	}
+
+	// Delete it.
+	req, _ = http.NewRequest("DELETE", "/v1/acl/token/"+id+"?token=root", nil)
+	resp = httptest.NewRecorder()
+	if _, err := a.srv.ACLTokenSpecific(resp, req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Now it should be gone.
+	req, _ = http.NewRequest("GET", "/v1/acl/token/"+id, nil)
+	resp = httptest.NewRecorder()
+	obj, err = a.srv.ACLTokenSpecific(resp, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if obj != nil || resp.Code != http.StatusNotFound {
+		t.Fatalf("bad: %d %v", resp.Code, obj)
+	}
+}
+
+func TestACL_Token_Upgrade(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t.Name(), TestACLConfig())
+	defer a.Shutdown()
+
+	// Make a legacy token with some rules.
+	body := bytes.NewBuffer(nil)
+	enc := json.NewEncoder(body)
+	enc.Encode(map[string]interface{}{
+		"Name":  "legacy",
+		"Type":  "client",
+		"Rules": `key "foo/" { policy = "write" }`,
+	})
+	req, _ := http.NewRequest("PUT", "/v1/acl/create?token=root", body)
+	resp := httptest.NewRecorder()
+	obj, err := a.srv.ACLCreate(resp, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	id := obj.(aclCreateResponse).ID
+
+	req, _ = http.NewRequest("PUT", "/v1/acl/upgrade/"+id+"?token=root", nil)
+	resp = httptest.NewRecorder()
+	obj, err = a.srv.ACLTokenUpgrade(resp, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	token, ok := obj.(structs.ACL)
+	if !ok {
+		t.Fatalf("bad: %T", obj)
+	}
+	if token.ID != id || token.Rules != "" || len(token.Policies) != 1 {
+		t.Fatalf("bad: %v", token)
+	}
+}
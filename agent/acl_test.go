@@ -39,10 +39,10 @@ func TestACL_Bad_Config(t *testing.T) {
 }
 
 type MockServer struct {
-	getPolicyFn func(*structs.ACLPolicyRequest, *structs.ACLPolicy) error
+	getPolicyFn func(*structs.ACLPolicyRequest, *structs.ACLPolicyResponse) error
 }
 
-func (m *MockServer) GetPolicy(args *structs.ACLPolicyRequest, reply *structs.ACLPolicy) error {
+func (m *MockServer) GetPolicy(args *structs.ACLPolicyRequest, reply *structs.ACLPolicyResponse) error {
 	if m.getPolicyFn != nil {
 		return m.getPolicyFn(args, reply)
 	}
@@ -59,7 +59,7 @@ func TestACL_Version8(t *testing.T) {
 		defer a.Shutdown()
 
 		m := MockServer{
-			getPolicyFn: func(*structs.ACLPolicyRequest, *structs.ACLPolicy) error {
+			getPolicyFn: func(*structs.ACLPolicyRequest, *structs.ACLPolicyResponse) error {
 				t.Fatalf("should not have called to server")
 				return nil
 			},
@@ -81,7 +81,7 @@ func TestACL_Version8(t *testing.T) {
 
 		var called bool
 		m := MockServer{
-			getPolicyFn: func(*structs.ACLPolicyRequest, *structs.ACLPolicy) error {
+			getPolicyFn: func(*structs.ACLPolicyRequest, *structs.ACLPolicyResponse) error {
 				called = true
 				return fmt.Errorf("token not found")
 			},
@@ -110,7 +110,7 @@ func TestACL_Disabled(t *testing.T) {
 
 	m := MockServer{
 		// Fetch a token without ACLs enabled and make sure the manager sees it.
-		getPolicyFn: func(*structs.ACLPolicyRequest, *structs.ACLPolicy) error {
+		getPolicyFn: func(*structs.ACLPolicyRequest, *structs.ACLPolicyResponse) error {
 			return rawacl.ErrDisabled
 		},
 	}
@@ -130,7 +130,7 @@ func TestACL_Disabled(t *testing.T) {
 
 	// Now turn on ACLs and check right away, it should still think ACLs are
 	// disabled since we don't check again right away.
-	m.getPolicyFn = func(*structs.ACLPolicyRequest, *structs.ACLPolicy) error {
+	m.getPolicyFn = func(*structs.ACLPolicyRequest, *structs.ACLPolicyResponse) error {
 		return rawacl.ErrNotFound
 	}
 	if token, err := a.resolveToken("nope"); token != nil || err != nil {
@@ -164,7 +164,7 @@ func TestACL_Special_IDs(t *testing.T) {
 
 	m := MockServer{
 		// An empty ID should get mapped to the anonymous token.
-		getPolicyFn: func(req *structs.ACLPolicyRequest, reply *structs.ACLPolicy) error {
+		getPolicyFn: func(req *structs.ACLPolicyRequest, reply *structs.ACLPolicyResponse) error {
 			if req.ACL != "anonymous" {
 				t.Fatalf("bad: %#v", *req)
 			}
@@ -180,7 +180,7 @@ func TestACL_Special_IDs(t *testing.T) {
 	}
 
 	// A root ACL request should get rejected and not call the server.
-	m.getPolicyFn = func(*structs.ACLPolicyRequest, *structs.ACLPolicy) error {
+	m.getPolicyFn = func(*structs.ACLPolicyRequest, *structs.ACLPolicyResponse) error {
 		t.Fatalf("should not have called to server")
 		return nil
 	}
@@ -222,7 +222,7 @@ func TestACL_Down_Deny(t *testing.T) {
 
 	m := MockServer{
 		// Resolve with ACLs down.
-		getPolicyFn: func(*structs.ACLPolicyRequest, *structs.ACLPolicy) error {
+		getPolicyFn: func(*structs.ACLPolicyRequest, *structs.ACLPolicyResponse) error {
 			return fmt.Errorf("ACLs are broken")
 		},
 	}
@@ -252,7 +252,7 @@ func TestACL_Down_Allow(t *testing.T) {
 
 	m := MockServer{
 		// Resolve with ACLs down.
-		getPolicyFn: func(*structs.ACLPolicyRequest, *structs.ACLPolicy) error {
+		getPolicyFn: func(*structs.ACLPolicyRequest, *structs.ACLPolicyResponse) error {
 			return fmt.Errorf("ACLs are broken")
 		},
 	}
@@ -282,8 +282,8 @@ func TestACL_Down_Extend(t *testing.T) {
 
 	m := MockServer{
 		// Populate the cache for one of the tokens.
-		getPolicyFn: func(req *structs.ACLPolicyRequest, reply *structs.ACLPolicy) error {
-			*reply = structs.ACLPolicy{
+		getPolicyFn: func(req *structs.ACLPolicyRequest, reply *structs.ACLPolicyResponse) error {
+			*reply = structs.ACLPolicyResponse{
 				Parent: "allow",
 				Policy: &rawacl.Policy{
 					Agents: []*rawacl.AgentPolicy{
@@ -316,7 +316,7 @@ func TestACL_Down_Extend(t *testing.T) {
 	}
 
 	// Now take down ACLs and make sure a new token fails to resolve.
-	m.getPolicyFn = func(*structs.ACLPolicyRequest, *structs.ACLPolicy) error {
+	m.getPolicyFn = func(*structs.ACLPolicyRequest, *structs.ACLPolicyResponse) error {
 		return fmt.Errorf("ACLs are broken")
 	}
 	acl, err = a.resolveToken("nope")
@@ -359,8 +359,8 @@ func TestACL_Cache(t *testing.T) {
 
 	m := MockServer{
 		// Populate the cache for one of the tokens.
-		getPolicyFn: func(req *structs.ACLPolicyRequest, reply *structs.ACLPolicy) error {
-			*reply = structs.ACLPolicy{
+		getPolicyFn: func(req *structs.ACLPolicyRequest, reply *structs.ACLPolicyResponse) error {
+			*reply = structs.ACLPolicyResponse{
 				ETag:   "hash1",
 				Parent: "deny",
 				Policy: &rawacl.Policy{
@@ -398,7 +398,7 @@ func TestACL_Cache(t *testing.T) {
 	}
 
 	// Fetch right away and make sure it uses the cache.
-	m.getPolicyFn = func(*structs.ACLPolicyRequest, *structs.ACLPolicy) error {
+	m.getPolicyFn = func(*structs.ACLPolicyRequest, *structs.ACLPolicyResponse) error {
 		t.Fatalf("should not have called to server")
 		return nil
 	}
@@ -422,7 +422,7 @@ func TestACL_Cache(t *testing.T) {
 	// Wait for the TTL to expire and try again. This time the token will be
 	// gone.
 	time.Sleep(20 * time.Millisecond)
-	m.getPolicyFn = func(req *structs.ACLPolicyRequest, reply *structs.ACLPolicy) error {
+	m.getPolicyFn = func(req *structs.ACLPolicyRequest, reply *structs.ACLPolicyResponse) error {
 		return rawacl.ErrNotFound
 	}
 	_, err = a.resolveToken("yep")
@@ -431,8 +431,8 @@ func TestACL_Cache(t *testing.T) {
 	}
 
 	// Page it back in with a new tag and different policy
-	m.getPolicyFn = func(req *structs.ACLPolicyRequest, reply *structs.ACLPolicy) error {
-		*reply = structs.ACLPolicy{
+	m.getPolicyFn = func(req *structs.ACLPolicyRequest, reply *structs.ACLPolicyResponse) error {
+		*reply = structs.ACLPolicyResponse{
 			ETag:   "hash2",
 			Parent: "deny",
 			Policy: &rawacl.Policy{
@@ -469,8 +469,8 @@ func TestACL_Cache(t *testing.T) {
 	// behavior.
 	time.Sleep(20 * time.Millisecond)
 	var didRefresh bool
-	m.getPolicyFn = func(req *structs.ACLPolicyRequest, reply *structs.ACLPolicy) error {
-		*reply = structs.ACLPolicy{
+	m.getPolicyFn = func(req *structs.ACLPolicyRequest, reply *structs.ACLPolicyResponse) error {
+		*reply = structs.ACLPolicyResponse{
 			ETag: "hash2",
 			TTL:  10 * time.Millisecond,
 		}
@@ -500,7 +500,7 @@ func TestACL_Cache(t *testing.T) {
 
 // catalogPolicy supplies some standard policies to help with testing the
 // catalog-related vet and filter functions.
-func catalogPolicy(req *structs.ACLPolicyRequest, reply *structs.ACLPolicy) error {
+func catalogPolicy(req *structs.ACLPolicyRequest, reply *structs.ACLPolicyResponse) error {
 	reply.Policy = &rawacl.Policy{}
 
 	switch req.ACL {
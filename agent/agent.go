@@ -20,6 +20,8 @@ import (
 
 	"github.com/armon/go-metrics"
 	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/cache"
+	"github.com/hashicorp/consul/agent/cachetype"
 	"github.com/hashicorp/consul/agent/config"
 	"github.com/hashicorp/consul/agent/consul"
 	"github.com/hashicorp/consul/agent/structs"
@@ -29,6 +31,7 @@ import (
 	"github.com/hashicorp/consul/ipaddr"
 	"github.com/hashicorp/consul/lib"
 	"github.com/hashicorp/consul/logger"
+	"github.com/hashicorp/consul/tlsutil"
 	"github.com/hashicorp/consul/types"
 	"github.com/hashicorp/consul/watch"
 	"github.com/hashicorp/go-uuid"
@@ -64,9 +67,11 @@ type delegate interface {
 	LANSegmentMembers(segment string) ([]serf.Member, error)
 	LocalMember() serf.Member
 	JoinLAN(addrs []string) (n int, err error)
+	ReloadConfig(config *consul.Config) error
 	RemoveFailedNode(node string) error
 	RPC(method string, args interface{}, reply interface{}) error
 	SnapshotRPC(args *structs.SnapshotRequest, in io.Reader, out io.Writer, replyFn structs.SnapshotReplyFn) error
+	SubscribeRPC(ctx context.Context, args *structs.SubscribeRequest, fn structs.StreamEventFn) error
 	Shutdown() error
 	Stats() map[string]map[string]string
 }
@@ -98,6 +103,10 @@ type Agent struct {
 	// In-memory sink used for collecting metrics
 	MemSink *metrics.InmemSink
 
+	// Prometheus sink used for exposing metrics in the Prometheus format,
+	// which is nil if it isn't enabled
+	PromSink *lib.PrometheusSink
+
 	// delegate is either a *consul.Server or *consul.Client
 	// depending on the configuration
 	delegate delegate
@@ -122,12 +131,19 @@ type Agent struct {
 	// checkTCPs maps the check ID to an associated TCP check
 	checkTCPs map[types.CheckID]*CheckTCP
 
+	// checkGRPCs maps the check ID to an associated gRPC check
+	checkGRPCs map[types.CheckID]*CheckGRPC
+
 	// checkTTLs maps the check ID to an associated check TTL
 	checkTTLs map[types.CheckID]*CheckTTL
 
 	// checkDockers maps the check ID to an associated Docker Exec based check
 	checkDockers map[types.CheckID]*CheckDocker
 
+	// checkStatusHandlers maps the check ID to the status handler that
+	// sits between an interval based check and the local state
+	checkStatusHandlers map[types.CheckID]*StatusHandler
+
 	// checkLock protects updates to the check* maps
 	checkLock sync.Mutex
 
@@ -182,6 +198,14 @@ type Agent struct {
 	// be updated at runtime, so should always be used instead of going to
 	// the configuration directly.
 	tokens *token.Store
+
+	// cache is the in-memory cache for data the agent requests from the
+	// servers on behalf of HTTP clients.
+	cache *cache.Cache
+
+	// tlsCerts holds the TLS certificate and CAs used by all the TLS
+	// connections, which are loaded again on reload.
+	tlsCerts *tlsutil.CertStore
 }
 
 func New(c *config.RuntimeConfig) (*Agent, error) {
@@ -197,22 +221,24 @@ func New(c *config.RuntimeConfig) (*Agent, error) {
 	}
 
 	a := &Agent{
-		config:          c,
-		acls:            acls,
-		checkReapAfter:  make(map[types.CheckID]time.Duration),
-		checkMonitors:   make(map[types.CheckID]*CheckMonitor),
-		checkTTLs:       make(map[types.CheckID]*CheckTTL),
-		checkHTTPs:      make(map[types.CheckID]*CheckHTTP),
-		checkTCPs:       make(map[types.CheckID]*CheckTCP),
-		checkDockers:    make(map[types.CheckID]*CheckDocker),
-		eventCh:         make(chan serf.UserEvent, 1024),
-		eventBuf:        make([]*UserEvent, 256),
-		joinLANNotifier: &systemd.Notifier{},
-		reloadCh:        make(chan chan error),
-		retryJoinCh:     make(chan error),
-		shutdownCh:      make(chan struct{}),
-		endpoints:       make(map[string]string),
-		tokens:          new(token.Store),
+		config:              c,
+		acls:                acls,
+		checkReapAfter:      make(map[types.CheckID]time.Duration),
+		checkMonitors:       make(map[types.CheckID]*CheckMonitor),
+		checkTTLs:           make(map[types.CheckID]*CheckTTL),
+		checkHTTPs:          make(map[types.CheckID]*CheckHTTP),
+		checkTCPs:           make(map[types.CheckID]*CheckTCP),
+		checkGRPCs:          make(map[types.CheckID]*CheckGRPC),
+		checkDockers:        make(map[types.CheckID]*CheckDocker),
+		checkStatusHandlers: make(map[types.CheckID]*StatusHandler),
+		eventCh:             make(chan serf.UserEvent, 1024),
+		eventBuf:            make([]*UserEvent, 256),
+		joinLANNotifier:     &systemd.Notifier{},
+		reloadCh:            make(chan chan error),
+		retryJoinCh:         make(chan error),
+		shutdownCh:          make(chan struct{}),
+		endpoints:           make(map[string]string),
+		tokens:              new(token.Store),
 	}
 
 	// Set up the initial state of the token store based on the config.
@@ -244,6 +270,11 @@ func (a *Agent) Start() error {
 	// create the local state
 	a.state = NewLocalState(c, a.logger, a.tokens)
 
+	// Load the TLS certificate and CAs.
+	if err := a.loadTLSCerts(c); err != nil {
+		return err
+	}
+
 	// create the config for the rpc server/client
 	consulCfg, err := a.consulConfig()
 	if err != nil {
@@ -272,6 +303,11 @@ func (a *Agent) Start() error {
 		a.state.delegate = client
 	}
 
+	// Setup the cache for reads, which must be done before the HTTP
+	// servers are started.
+	a.cache = cache.New(nil)
+	a.registerCache()
+
 	// Load checks/services/metadata.
 	if err := a.loadServices(c); err != nil {
 		return err
@@ -290,6 +326,9 @@ func (a *Agent) Start() error {
 	// Start handling events.
 	go a.handleEvents()
 
+	// Start watching the TLS certificates.
+	go a.watchTLSCerts()
+
 	// Start sending network coordinate to the server.
 	if !c.DisableCoordinates {
 		go a.sendCoordinate()
@@ -407,7 +446,7 @@ func (a *Agent) listenHTTP() ([]net.Listener, error) {
 				l = &tcpKeepAliveListener{l.(*net.TCPListener)}
 
 				if proto == "https" {
-					tlscfg, err := a.config.IncomingHTTPSConfig()
+					tlscfg, err := a.config.IncomingHTTPSConfig(a.tlsCerts)
 					if err != nil {
 						return err
 					}
@@ -563,11 +602,17 @@ func (a *Agent) reloadWatches(cfg *config.RuntimeConfig) error {
 		} else if hasArgs && !ok {
 			return fmt.Errorf("Watch args must be a list of strings")
 		}
-		if hasHandler && hasArgs {
-			return fmt.Errorf("Cannot define both watch handler and args")
-		}
-		if !hasHandler && !hasArgs {
-			return fmt.Errorf("Must define either watch handler or args")
+		if wp.HandlerType == watch.HandlerTypeHTTP {
+			if hasHandler || hasArgs {
+				return fmt.Errorf("Cannot define watch handler or args with handler type 'http'")
+			}
+		} else {
+			if hasHandler && hasArgs {
+				return fmt.Errorf("Cannot define both watch handler and args")
+			}
+			if !hasHandler && !hasArgs {
+				return fmt.Errorf("Must define either watch handler or args")
+			}
 		}
 
 		// Store the watch plan
@@ -590,13 +635,17 @@ func (a *Agent) reloadWatches(cfg *config.RuntimeConfig) error {
 	for _, wp := range watchPlans {
 		a.watchPlans = append(a.watchPlans, wp)
 		go func(wp *watch.Plan) {
-			var handler interface{}
-			if h, ok := wp.Exempt["handler"]; ok {
-				handler = h
+			if wp.HandlerType == watch.HandlerTypeHTTP {
+				wp.Handler = makeHTTPWatchHandler(a.LogOutput, wp.HTTPHandlerConfig)
 			} else {
-				handler = wp.Exempt["args"]
+				var handler interface{}
+				if h, ok := wp.Exempt["handler"]; ok {
+					handler = h
+				} else {
+					handler = wp.Exempt["args"]
+				}
+				wp.Handler = makeWatchHandler(a.LogOutput, handler)
 			}
-			wp.Handler = makeWatchHandler(a.LogOutput, handler)
 			wp.LogOutput = a.LogOutput
 			if err := wp.Run(addr); err != nil {
 				a.logger.Printf("[ERR] Failed to run watch: %v", err)
@@ -736,6 +785,9 @@ func (a *Agent) consulConfig() (*consul.Config, error) {
 	if a.config.AutopilotUpgradeVersionTag != "" {
 		base.AutopilotConfig.UpgradeVersionTag = a.config.AutopilotUpgradeVersionTag
 	}
+	if len(a.config.NodeMeta) > 0 {
+		base.NodeMeta = a.config.NodeMeta
+	}
 
 	// make sure the advertise address is always set
 	if base.RPCAdvertise == nil {
@@ -743,12 +795,7 @@ func (a *Agent) consulConfig() (*consul.Config, error) {
 	}
 
 	// Rate limiting for RPC calls.
-	if a.config.RPCRateLimit > 0 {
-		base.RPCRate = a.config.RPCRateLimit
-	}
-	if a.config.RPCMaxBurst > 0 {
-		base.RPCMaxBurst = a.config.RPCMaxBurst
-	}
+	setRPCLimits(base, a.config)
 
 	// set the src address for outgoing rpc connections
 	// Use port 0 so that outgoing connections use a random port.
@@ -774,6 +821,7 @@ func (a *Agent) consulConfig() (*consul.Config, error) {
 	base.CAPath = a.config.CAPath
 	base.CertFile = a.config.CertFile
 	base.KeyFile = a.config.KeyFile
+	base.TLSCertStore = a.tlsCerts
 	base.ServerName = a.config.ServerName
 	base.Domain = a.config.DNSDomain
 	base.TLSMinVersion = a.config.TLSMinVersion
@@ -1086,6 +1134,13 @@ func (a *Agent) SnapshotRPC(args *structs.SnapshotRequest, in io.Reader, out io.
 	return a.delegate.SnapshotRPC(args, in, out, replyFn)
 }
 
+// SubscribeRPC subscribes to the change events of the Consul servers, calling
+// fn with each event until the context is done, fn returns an error, or the
+// subscription can't go on.
+func (a *Agent) SubscribeRPC(ctx context.Context, args *structs.SubscribeRequest, fn structs.StreamEventFn) error {
+	return a.delegate.SubscribeRPC(ctx, args, fn)
+}
+
 // Leave is used to prepare the agent for a graceful shutdown
 func (a *Agent) Leave() error {
 	return a.delegate.Leave()
@@ -1118,10 +1173,17 @@ func (a *Agent) ShutdownAgent() error {
 	for _, chk := range a.checkTCPs {
 		chk.Stop()
 	}
+	for _, chk := range a.checkGRPCs {
+		chk.Stop()
+	}
 	for _, chk := range a.checkDockers {
 		chk.Stop()
 	}
 
+	if a.cache != nil {
+		a.cache.Close()
+	}
+
 	var err error
 	if a.delegate != nil {
 		err = a.delegate.Shutdown()
@@ -1481,6 +1543,12 @@ func (a *Agent) AddService(service *structs.NodeService, chkTypes []*structs.Che
 			return fmt.Errorf("Check type is not valid")
 		}
 	}
+	if err := structs.ValidateServiceMetadata(service.Meta, false); err != nil {
+		return fmt.Errorf("Invalid service meta: %v", err)
+	}
+	if err := structs.ValidateWeights(service.Weights); err != nil {
+		return fmt.Errorf("Invalid service weights: %v", err)
+	}
 
 	// Warn if the service name is incompatible with DNS
 	if InvalidDnsRe.MatchString(service.Service) {
@@ -1616,6 +1684,24 @@ func (a *Agent) AddCheck(check *structs.HealthCheck, chkType *structs.CheckType,
 
 	// Check if already registered
 	if chkType != nil {
+		// Interval based checks report through a status handler which
+		// applies their thresholds and flap damping.
+		var notify CheckNotifier = a.state
+		delete(a.checkStatusHandlers, check.CheckID)
+		if !chkType.IsTTL() {
+			handler := &StatusHandler{
+				Notify:                 a.state,
+				Logger:                 a.logger,
+				Status:                 check.Status,
+				SuccessBeforePassing:   chkType.SuccessBeforePassing,
+				FailuresBeforeCritical: chkType.FailuresBeforeCritical,
+				FlapHalfLife:           chkType.FlapHalfLife,
+				FlapSuppressWindow:     chkType.FlapSuppressWindow,
+			}
+			a.checkStatusHandlers[check.CheckID] = handler
+			notify = handler
+		}
+
 		switch {
 
 		case chkType.IsTTL():
@@ -1652,7 +1738,7 @@ func (a *Agent) AddCheck(check *structs.HealthCheck, chkType *structs.CheckType,
 			}
 
 			http := &CheckHTTP{
-				Notify:        a.state,
+				Notify:        notify,
 				CheckID:       check.CheckID,
 				HTTP:          chkType.HTTP,
 				Header:        chkType.Header,
@@ -1677,7 +1763,7 @@ func (a *Agent) AddCheck(check *structs.HealthCheck, chkType *structs.CheckType,
 			}
 
 			tcp := &CheckTCP{
-				Notify:   a.state,
+				Notify:   notify,
 				CheckID:  check.CheckID,
 				TCP:      chkType.TCP,
 				Interval: chkType.Interval,
@@ -1687,6 +1773,30 @@ func (a *Agent) AddCheck(check *structs.HealthCheck, chkType *structs.CheckType,
 			tcp.Start()
 			a.checkTCPs[check.CheckID] = tcp
 
+		case chkType.IsGRPC():
+			if existing, ok := a.checkGRPCs[check.CheckID]; ok {
+				existing.Stop()
+				delete(a.checkGRPCs, check.CheckID)
+			}
+			if chkType.Interval < MinInterval {
+				a.logger.Println(fmt.Sprintf("[WARN] agent: check '%s' has interval below minimum of %v",
+					check.CheckID, MinInterval))
+				chkType.Interval = MinInterval
+			}
+
+			grpc := &CheckGRPC{
+				Notify:        notify,
+				CheckID:       check.CheckID,
+				GRPC:          chkType.GRPC,
+				GRPCUseTLS:    chkType.GRPCUseTLS,
+				Interval:      chkType.Interval,
+				Timeout:       chkType.Timeout,
+				Logger:        a.logger,
+				TLSSkipVerify: chkType.TLSSkipVerify,
+			}
+			grpc.Start()
+			a.checkGRPCs[check.CheckID] = grpc
+
 		case chkType.IsDocker():
 			if existing, ok := a.checkDockers[check.CheckID]; ok {
 				existing.Stop()
@@ -1714,7 +1824,7 @@ func (a *Agent) AddCheck(check *structs.HealthCheck, chkType *structs.CheckType,
 			}
 
 			dockerCheck := &CheckDocker{
-				Notify:            a.state,
+				Notify:            notify,
 				CheckID:           check.CheckID,
 				DockerContainerID: chkType.DockerContainerID,
 				Shell:             chkType.Shell,
@@ -1744,7 +1854,7 @@ func (a *Agent) AddCheck(check *structs.HealthCheck, chkType *structs.CheckType,
 			}
 
 			monitor := &CheckMonitor{
-				Notify:     a.state,
+				Notify:     notify,
 				CheckID:    check.CheckID,
 				Script:     chkType.Script,
 				ScriptArgs: chkType.ScriptArgs,
@@ -1818,6 +1928,7 @@ func (a *Agent) RemoveCheck(checkID types.CheckID, persist bool) error {
 func (a *Agent) cancelCheckMonitors(checkID types.CheckID) {
 	// Stop any monitors
 	delete(a.checkReapAfter, checkID)
+	delete(a.checkStatusHandlers, checkID)
 	if check, ok := a.checkMonitors[checkID]; ok {
 		check.Stop()
 		delete(a.checkMonitors, checkID)
@@ -1830,6 +1941,10 @@ func (a *Agent) cancelCheckMonitors(checkID types.CheckID) {
 		check.Stop()
 		delete(a.checkTCPs, checkID)
 	}
+	if check, ok := a.checkGRPCs[checkID]; ok {
+		check.Stop()
+		delete(a.checkGRPCs, checkID)
+	}
 	if check, ok := a.checkTTLs[checkID]; ok {
 		check.Stop()
 		delete(a.checkTTLs, checkID)
@@ -1976,6 +2091,19 @@ func (a *Agent) Stats() map[string]map[string]string {
 		"version":    a.config.Version,
 		"prerelease": a.config.VersionPrerelease,
 	}
+
+	if a.tlsCerts != nil {
+		tlsStats := make(map[string]string)
+		if t := a.tlsCerts.CertExpiry(); !t.IsZero() {
+			tlsStats["cert_expiry"] = t.Format(time.RFC3339)
+		}
+		if t := a.tlsCerts.CAExpiry(); !t.IsZero() {
+			tlsStats["ca_expiry"] = t.Format(time.RFC3339)
+		}
+		if len(tlsStats) > 0 {
+			stats["tls"] = tlsStats
+		}
+	}
 	return stats
 }
 
@@ -2375,5 +2503,72 @@ func (a *Agent) ReloadConfig(newCfg *config.RuntimeConfig) error {
 	// Update filtered metrics
 	metrics.UpdateFilter(newCfg.TelemetryAllowedPrefixes, newCfg.TelemetryBlockedPrefixes)
 
+	// Update the RPC limits before the TLS certificates, so they still
+	// apply if the certificates are bad.
+	cc := consul.DefaultConfig()
+	setRPCLimits(cc, newCfg)
+	if err := a.delegate.ReloadConfig(cc); err != nil {
+		return fmt.Errorf("Failed reloading RPC limits: %v", err)
+	}
+
+	// Reload the TLS certificate and CAs, which are picked up by new
+	// connections.
+	if err := a.loadTLSCerts(newCfg); err != nil {
+		return fmt.Errorf("Failed reloading TLS certificates: %v", err)
+	}
+
 	return nil
 }
+
+// setRPCLimits copies the limits for the RPCs made and served by the agent
+// from the runtime config to the consul config. Unset limits keep their
+// defaults.
+func setRPCLimits(base *consul.Config, cfg *config.RuntimeConfig) {
+	if cfg.RPCRateLimit > 0 {
+		base.RPCRate = cfg.RPCRateLimit
+	}
+	if cfg.RPCMaxBurst > 0 {
+		base.RPCMaxBurst = cfg.RPCMaxBurst
+	}
+	if cfg.RPCServerReadRate > 0 {
+		base.RPCServerReadRate = cfg.RPCServerReadRate
+	}
+	if cfg.RPCServerReadMaxBurst > 0 {
+		base.RPCServerReadMaxBurst = cfg.RPCServerReadMaxBurst
+	}
+	if cfg.RPCServerWriteRate > 0 {
+		base.RPCServerWriteRate = cfg.RPCServerWriteRate
+	}
+	if cfg.RPCServerWriteMaxBurst > 0 {
+		base.RPCServerWriteMaxBurst = cfg.RPCServerWriteMaxBurst
+	}
+	base.RPCMaxBlockingQueries = cfg.RPCMaxBlockingQueries
+	base.RPCMaxConnsPerClient = cfg.RPCMaxConnsPerClient
+}
+
+// registerCache configures the cache types and their options for the reads
+// that can be answered from the agent cache.
+func (a *Agent) registerCache() {
+	a.cache.RegisterType(cachetype.HealthServicesName, &cachetype.HealthServices{
+		RPC: a,
+	}, &cache.RegisterOptions{
+		// Maintain a blocking query to the servers in the background.
+		Refresh:        true,
+		RefreshTimeout: 10 * time.Minute,
+	})
+
+	a.cache.RegisterType(cachetype.CatalogServicesName, &cachetype.CatalogServices{
+		RPC: a,
+	}, &cache.RegisterOptions{
+		Refresh:        true,
+		RefreshTimeout: 10 * time.Minute,
+	})
+
+	// Prepared queries don't support blocking, so results are kept until
+	// they are older than the max age a client asks for.
+	a.cache.RegisterType(cachetype.PreparedQueryName, &cachetype.PreparedQuery{
+		RPC: a,
+	}, &cache.RegisterOptions{
+		Refresh: false,
+	})
+}
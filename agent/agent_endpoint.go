@@ -91,9 +91,38 @@ func (s *HTTPServer) AgentMetrics(resp http.ResponseWriter, req *http.Request) (
 		return nil, acl.ErrPermissionDenied
 	}
 
+	if wantsPrometheus(req) {
+		if s.agent.PromSink == nil {
+			resp.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(resp, "Prometheus is not enabled since its retention time is not positive")
+			return nil, nil
+		}
+		resp.Header().Set("Content-Type", lib.PrometheusContentType)
+		if _, err := s.agent.PromSink.WriteTo(resp); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
 	return s.agent.MemSink.DisplayMetrics(resp, req)
 }
 
+// wantsPrometheus returns true if the metrics should be rendered in the
+// Prometheus text exposition format, either because it was asked for with
+// the format query parameter or because the client accepts it, which is what
+// the Prometheus scraper does.
+func wantsPrometheus(req *http.Request) bool {
+	if format := req.URL.Query().Get("format"); format != "" {
+		return format == "prometheus"
+	}
+	for _, accept := range strings.Split(req.Header.Get("Accept"), ",") {
+		accept = strings.Replace(accept, " ", "", -1)
+		if strings.HasPrefix(accept, "text/plain;version=0.0.4") {
+			return true
+		}
+	}
+	return false
+}
+
 func (s *HTTPServer) AgentReload(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
 	if req.Method != "PUT" {
 		return nil, MethodNotAllowedError{req.Method, []string{"PUT"}}
@@ -173,7 +202,27 @@ func (s *HTTPServer) AgentChecks(resp http.ResponseWriter, req *http.Request) (i
 		}
 	}
 
-	return checks, nil
+	// Add the state of the status handlers of interval based checks.
+	s.agent.checkLock.Lock()
+	defer s.agent.checkLock.Unlock()
+	out := make(map[types.CheckID]*agentCheck, len(checks))
+	for id, c := range checks {
+		check := &agentCheck{HealthCheck: c}
+		if handler, ok := s.agent.checkStatusHandlers[id]; ok {
+			check.PendingStatus = handler.PendingStatus()
+			check.Suppressed = handler.Suppressed()
+		}
+		out[id] = check
+	}
+	return out, nil
+}
+
+// agentCheck is a health check as returned by the agent checks endpoint,
+// with the state held locally by its status handler.
+type agentCheck struct {
+	*structs.HealthCheck
+	PendingStatus string
+	Suppressed    bool
 }
 
 func (s *HTTPServer) AgentMembers(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
@@ -308,7 +357,7 @@ func (s *HTTPServer) syncChanges() {
 	}
 }
 
-const invalidCheckMessage = "Must provide TTL or Script/DockerContainerID/HTTP/TCP and Interval"
+const invalidCheckMessage = "Must provide TTL or Script/DockerContainerID/HTTP/TCP/GRPC and Interval"
 
 func (s *HTTPServer) AgentRegisterCheck(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
 	if req.Method != "PUT" {
@@ -565,6 +614,20 @@ func (s *HTTPServer) AgentRegisterService(resp http.ResponseWriter, req *http.Re
 		return nil, nil
 	}
 
+	// Verify the service metadata.
+	if err := structs.ValidateServiceMetadata(args.Meta, false); err != nil {
+		resp.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(resp, "Invalid Service Meta: %v", err)
+		return nil, nil
+	}
+
+	// Verify the service weights.
+	if err := structs.ValidateWeights(args.Weights); err != nil {
+		resp.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(resp, "Invalid Service Weights: %v", err)
+		return nil, nil
+	}
+
 	// Get the node service.
 	ns := args.NodeService()
 
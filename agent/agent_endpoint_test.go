@@ -17,6 +17,7 @@ import (
 	"github.com/hashicorp/consul/agent/config"
 	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/lib"
 	"github.com/hashicorp/consul/logger"
 	"github.com/hashicorp/consul/testutil/retry"
 	"github.com/hashicorp/consul/types"
@@ -122,7 +123,7 @@ func TestAgent_Checks(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Err: %v", err)
 	}
-	val := obj.(map[types.CheckID]*structs.HealthCheck)
+	val := obj.(map[types.CheckID]*agentCheck)
 	if len(val) != 1 {
 		t.Fatalf("bad checks: %v", obj)
 	}
@@ -150,7 +151,7 @@ func TestAgent_Checks_ACLFilter(t *testing.T) {
 		if err != nil {
 			t.Fatalf("Err: %v", err)
 		}
-		val := obj.(map[types.CheckID]*structs.HealthCheck)
+		val := obj.(map[types.CheckID]*agentCheck)
 		if len(val) != 0 {
 			t.Fatalf("bad checks: %v", obj)
 		}
@@ -162,7 +163,7 @@ func TestAgent_Checks_ACLFilter(t *testing.T) {
 		if err != nil {
 			t.Fatalf("Err: %v", err)
 		}
-		val := obj.(map[types.CheckID]*structs.HealthCheck)
+		val := obj.(map[types.CheckID]*agentCheck)
 		if len(val) != 1 {
 			t.Fatalf("bad checks: %v", obj)
 		}
@@ -262,6 +263,54 @@ func TestAgent_Metrics_ACLDeny(t *testing.T) {
 	})
 }
 
+func TestAgent_Metrics_Prometheus(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t.Name(), "")
+	defer a.Shutdown()
+
+	t.Run("disabled", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/v1/agent/metrics?format=prometheus", nil)
+		resp := httptest.NewRecorder()
+		if _, err := a.srv.AgentMetrics(resp, req); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if got, want := resp.Code, http.StatusBadRequest; got != want {
+			t.Fatalf("got %d want %d", got, want)
+		}
+	})
+
+	a.PromSink = lib.NewPrometheusSink(time.Minute, "", "dc1")
+	a.PromSink.IncrCounter([]string{"consul", "test"}, 1)
+
+	for _, tt := range []struct {
+		name, url, accept string
+	}{
+		{"format", "/v1/agent/metrics?format=prometheus", ""},
+		{"accept", "/v1/agent/metrics", "application/vnd.google.protobuf;q=0.7,text/plain; version=0.0.4;q=0.3,*/*;q=0.1"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", tt.url, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			resp := httptest.NewRecorder()
+			obj, err := a.srv.AgentMetrics(resp, req)
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			if obj != nil {
+				t.Fatalf("bad: %#v", obj)
+			}
+			if got, want := resp.Header().Get("Content-Type"), lib.PrometheusContentType; got != want {
+				t.Fatalf("got %q want %q", got, want)
+			}
+			if got, want := resp.Body.String(), "# TYPE consul_test counter\nconsul_test{datacenter=\"dc1\"} 1\n"; got != want {
+				t.Fatalf("got %q want %q", got, want)
+			}
+		})
+	}
+}
+
 func TestAgent_Reload(t *testing.T) {
 	t.Parallel()
 	a := NewTestAgent(t.Name(), `
@@ -738,6 +787,39 @@ func TestAgent_RegisterCheck_Passing(t *testing.T) {
 	}
 }
 
+func TestAgent_RegisterCheck_StatusHandler(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t.Name(), "")
+	defer a.Shutdown()
+
+	body := bytes.NewBufferString(`{
+		"Name": "test",
+		"TCP": "127.0.0.1:0",
+		"Interval": "1h",
+		"success_before_passing": 2,
+		"failures_before_critical": 3,
+		"flap_half_life": "30s",
+		"flap_suppress_window": "10m"
+	}`)
+	req, _ := http.NewRequest("PUT", "/v1/agent/check/register", body)
+	if _, err := a.srv.AgentRegisterCheck(nil, req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	a.checkLock.Lock()
+	defer a.checkLock.Unlock()
+	handler, ok := a.checkStatusHandlers["test"]
+	if !ok {
+		t.Fatalf("missing status handler")
+	}
+	if handler.SuccessBeforePassing != 2 || handler.FailuresBeforeCritical != 3 {
+		t.Fatalf("bad: %#v", handler)
+	}
+	if handler.FlapHalfLife != 30*time.Second || handler.FlapSuppressWindow != 10*time.Minute {
+		t.Fatalf("bad: %#v", handler)
+	}
+}
+
 func TestAgent_RegisterCheck_BadStatus(t *testing.T) {
 	t.Parallel()
 	a := NewTestAgent(t.Name(), "")
@@ -1222,6 +1304,75 @@ func TestAgent_RegisterService_InvalidAddress(t *testing.T) {
 	}
 }
 
+func TestAgent_RegisterService_InvalidMeta(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t.Name(), "")
+	defer a.Shutdown()
+
+	args := &structs.ServiceDefinition{
+		Name: "test",
+		Port: 8000,
+		Meta: map[string]string{
+			"consul-reserved": "nope",
+		},
+	}
+	req, _ := http.NewRequest("PUT", "/v1/agent/service/register", jsonReader(args))
+	resp := httptest.NewRecorder()
+	if _, err := a.srv.AgentRegisterService(resp, req); err != nil {
+		t.Fatalf("got error %v want nil", err)
+	}
+	if got, want := resp.Code, 400; got != want {
+		t.Fatalf("got code %d want %d", got, want)
+	}
+	if got := resp.Body.String(); !strings.Contains(got, "Invalid Service Meta") {
+		t.Fatalf("bad body: %q", got)
+	}
+	if _, ok := a.state.Services()["test"]; ok {
+		t.Fatalf("service should not have been registered")
+	}
+}
+
+func TestAgent_RegisterService_Weights(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t.Name(), "")
+	defer a.Shutdown()
+
+	args := &structs.ServiceDefinition{
+		Name:    "test",
+		Port:    8000,
+		Weights: &structs.Weights{Passing: 100, Warning: 3},
+	}
+	req, _ := http.NewRequest("PUT", "/v1/agent/service/register", jsonReader(args))
+	if _, err := a.srv.AgentRegisterService(nil, req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	svc, ok := a.state.Services()["test"]
+	if !ok {
+		t.Fatalf("missing test service")
+	}
+	if !reflect.DeepEqual(svc.Weights, args.Weights) {
+		t.Fatalf("bad: %#v", svc.Weights)
+	}
+
+	// Invalid weights should be rejected.
+	args.Name = "invalid"
+	args.Weights = &structs.Weights{Passing: 0, Warning: 3}
+	req, _ = http.NewRequest("PUT", "/v1/agent/service/register", jsonReader(args))
+	resp := httptest.NewRecorder()
+	if _, err := a.srv.AgentRegisterService(resp, req); err != nil {
+		t.Fatalf("got error %v want nil", err)
+	}
+	if got, want := resp.Code, 400; got != want {
+		t.Fatalf("got code %d want %d", got, want)
+	}
+	if got := resp.Body.String(); !strings.Contains(got, "Invalid Service Weights") {
+		t.Fatalf("bad body: %q", got)
+	}
+	if _, ok := a.state.Services()["invalid"]; ok {
+		t.Fatalf("service should not have been registered")
+	}
+}
+
 func TestAgent_DeregisterService(t *testing.T) {
 	t.Parallel()
 	a := NewTestAgent(t.Name(), "")
@@ -1804,3 +1955,45 @@ func TestAgent_Token(t *testing.T) {
 		}
 	})
 }
+
+func TestAgent_Checks_StatusHandler(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t.Name(), "")
+	defer a.Shutdown()
+
+	chk := &structs.HealthCheck{
+		Node:    a.Config.NodeName,
+		CheckID: "web",
+		Name:    "web",
+		Status:  api.HealthPassing,
+	}
+	chkType := &structs.CheckType{
+		TCP:                    "127.0.0.1:0",
+		Interval:               time.Hour,
+		FailuresBeforeCritical: 2,
+		FlapHalfLife:           time.Minute,
+	}
+	if err := a.AddCheck(chk, chkType, false, ""); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	a.checkLock.Lock()
+	handler := a.checkStatusHandlers["web"]
+	a.checkLock.Unlock()
+	handler.UpdateCheck("web", api.HealthCritical, "")
+
+	req, _ := http.NewRequest("GET", "/v1/agent/checks", nil)
+	obj, err := a.srv.AgentChecks(nil, req)
+	if err != nil {
+		t.Fatalf("Err: %v", err)
+	}
+	val := obj.(map[types.CheckID]*agentCheck)
+	if got, want := val["web"].Status, api.HealthPassing; got != want {
+		t.Fatalf("got status %q want %q", got, want)
+	}
+	if got, want := val["web"].PendingStatus, api.HealthCritical; got != want {
+		t.Fatalf("got pending status %q want %q", got, want)
+	}
+	if val["web"].Suppressed {
+		t.Fatalf("should not be suppressed")
+	}
+}
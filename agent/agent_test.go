@@ -19,6 +19,7 @@ import (
 	"github.com/hashicorp/consul/types"
 	uuid "github.com/hashicorp/go-uuid"
 	"github.com/pascaldekloe/goe/verify"
+	"golang.org/x/time/rate"
 )
 
 func externalIP() (string, error) {
@@ -134,6 +135,39 @@ func TestAgent_ReconnectConfigSettings(t *testing.T) {
 	}()
 }
 
+func TestAgent_RPCLimitsConfig(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t.Name(), `
+		limits {
+			rpc_server_read_rate = 100
+			rpc_server_write_max_burst = 10
+			rpc_max_blocking_queries = 500
+			rpc_max_conns_per_client = 20
+		}
+	`)
+	defer a.Shutdown()
+
+	cfg := a.consulConfig()
+	if got, want := cfg.RPCServerReadRate, rate.Limit(100); got != want {
+		t.Fatalf("got %v want %v", got, want)
+	}
+	if got, want := cfg.RPCServerReadMaxBurst, 1000; got != want {
+		t.Fatalf("got %v want %v", got, want)
+	}
+	if got, want := cfg.RPCServerWriteRate, rate.Inf; got != want {
+		t.Fatalf("got %v want %v", got, want)
+	}
+	if got, want := cfg.RPCServerWriteMaxBurst, 10; got != want {
+		t.Fatalf("got %v want %v", got, want)
+	}
+	if got, want := cfg.RPCMaxBlockingQueries, 500; got != want {
+		t.Fatalf("got %v want %v", got, want)
+	}
+	if got, want := cfg.RPCMaxConnsPerClient, 20; got != want {
+		t.Fatalf("got %v want %v", got, want)
+	}
+}
+
 func TestAgent_setupNodeID(t *testing.T) {
 	t.Parallel()
 	a := NewTestAgent(t.Name(), `
@@ -651,6 +685,44 @@ func TestAgent_AddCheck_MinInterval(t *testing.T) {
 	}
 }
 
+func TestAgent_AddCheck_GRPC(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t.Name(), "")
+	defer a.Shutdown()
+
+	health := &structs.HealthCheck{
+		Node:    "foo",
+		CheckID: "grpchealth",
+		Name:    "grpc health",
+		Status:  api.HealthCritical,
+	}
+	chk := &structs.CheckType{
+		GRPC:     "localhost:12345/package.Service",
+		Interval: 15 * time.Second,
+	}
+	if err := a.AddCheck(health, chk, false, ""); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Ensure we have a check mapping
+	if _, ok := a.state.Checks()["grpchealth"]; !ok {
+		t.Fatalf("missing grpchealth check")
+	}
+
+	// Ensure a gRPC check is set up
+	if _, ok := a.checkGRPCs["grpchealth"]; !ok {
+		t.Fatalf("missing grpchealth runner")
+	}
+
+	// Removing the check should stop and remove the runner
+	if err := a.RemoveCheck("grpchealth", false); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, ok := a.checkGRPCs["grpchealth"]; ok {
+		t.Fatalf("grpchealth runner should be removed")
+	}
+}
+
 func TestAgent_AddCheck_MissingService(t *testing.T) {
 	t.Parallel()
 	a := NewTestAgent(t.Name(), `
@@ -1937,6 +2009,37 @@ func TestAgent_reloadWatches(t *testing.T) {
 		t.Fatalf("bad: %s", err)
 	}
 
+	// An HTTP handler should succeed without args
+	newConf.Watches = []map[string]interface{}{
+		{
+			"type":         "key",
+			"key":          "asdf",
+			"handler_type": "http",
+			"http_handler_config": map[string]interface{}{
+				"path": "http://localhost:8080",
+			},
+		},
+	}
+	if err := a.reloadWatches(&newConf); err != nil {
+		t.Fatalf("bad: %s", err)
+	}
+
+	// But should fail if args are given as well
+	newConf.Watches = []map[string]interface{}{
+		{
+			"type":         "key",
+			"key":          "asdf",
+			"args":         []interface{}{"ls"},
+			"handler_type": "http",
+			"http_handler_config": map[string]interface{}{
+				"path": "http://localhost:8080",
+			},
+		},
+	}
+	if err := a.reloadWatches(&newConf); err == nil || !strings.Contains(err.Error(), "Cannot define watch handler or args with handler type 'http'") {
+		t.Fatalf("bad: %s", err)
+	}
+
 	// Should still succeed with only HTTPS addresses
 	newConf.HTTPSAddrs = newConf.HTTPAddrs
 	newConf.HTTPAddrs = make([]net.Addr, 0)
@@ -1964,3 +2067,42 @@ func TestAgent_reloadWatches(t *testing.T) {
 		t.Fatalf("bad: %s", err)
 	}
 }
+
+func TestAgent_loadTLSCerts(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t.Name(), `
+		ca_file = "../test/ca/root.cer"
+		cert_file = "../test/key/ourdomain.cer"
+		key_file = "../test/key/ourdomain.key"
+	`)
+	defer a.Shutdown()
+
+	verifyExpiry := func(cert, ca string) {
+		t.Helper()
+		stats := a.Stats()["tls"]
+		if got := stats["cert_expiry"]; got != cert {
+			t.Fatalf("got cert expiry %q want %q", got, cert)
+		}
+		if got := stats["ca_expiry"]; got != ca {
+			t.Fatalf("got CA expiry %q want %q", got, ca)
+		}
+	}
+	verifyExpiry("2018-06-06T02:19:19Z", "2024-04-04T19:01:08Z")
+
+	// Reloading picks up the new files.
+	newConf := *a.config
+	newConf.CAFile = "../test/hostname/CertAuth.crt"
+	newConf.CertFile = "../test/hostname/Alice.crt"
+	newConf.KeyFile = "../test/hostname/Alice.key"
+	if err := a.loadTLSCerts(&newConf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	verifyExpiry("2018-05-12T06:15:48Z", "2027-05-10T05:50:36Z")
+
+	// A bad file keeps the loaded certificates.
+	newConf.KeyFile = "../test/key/ourdomain.key"
+	if err := a.loadTLSCerts(&newConf); err == nil {
+		t.Fatalf("should fail")
+	}
+	verifyExpiry("2018-05-12T06:15:48Z", "2027-05-10T05:50:36Z")
+}
@@ -0,0 +1,540 @@
+// Package cache provides caching features for data from a Consul server.
+//
+// Unlike the local state of the agent, which the agent is authoritative
+// for and syncs to the servers, the servers are the authoritative source
+// for cached data, and the cache does its best to balance performance and
+// correctness depending on the type of data being requested.
+//
+// The types of data that can be cached are configured via the Type
+// interface. Types that support blocking queries can be refreshed in the
+// background, so that reads are answered from memory while a single
+// blocking query per entry keeps it up to date.
+package cache
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/armon/go-metrics"
+)
+
+const (
+	// defaultLastGetTTL is the time an entry is kept after it was last
+	// read, if the type doesn't set one.
+	defaultLastGetTTL = 72 * time.Hour
+
+	// defaultRefreshTimeout is the maximum time a background refresh
+	// blocks, if the type doesn't set one.
+	defaultRefreshTimeout = 10 * time.Minute
+
+	// defaultEvictInterval is how often entries are checked for eviction.
+	defaultEvictInterval = time.Minute
+
+	// maxBackoffWait is the longest time to wait before retrying a failed
+	// background refresh.
+	maxBackoffWait = time.Minute
+
+	// defaultQueryTimeout is the timeout of a blocking Get that doesn't set
+	// one, which matches the default of the servers.
+	defaultQueryTimeout = 5 * time.Minute
+)
+
+// Cache is an agent-local cache of Consul data. Create a Cache with New and
+// register the types of data it can hold with RegisterType.
+//
+// The cache keys entries by type, datacenter, ACL token and the key of the
+// request, so a token can never read data fetched with another token.
+type Cache struct {
+	// types stores the list of data types that the cache knows how to
+	// service. These can be dynamically registered with RegisterType.
+	typesLock sync.RWMutex
+	types     map[string]typeEntry
+
+	// entries contains the actual cache data.
+	entriesLock sync.RWMutex
+	entries     map[string]cacheEntry
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+}
+
+// typeEntry is a single type that is registered with a Cache.
+type typeEntry struct {
+	Type Type
+	Opts *RegisterOptions
+}
+
+// cacheEntry stores a single cache entry.
+//
+// Note that this isn't a very optimized structure currently. There are a
+// lot of improvements that can be made here in the long term.
+type cacheEntry struct {
+	// Fields pertaining to the actual value
+	Value interface{}
+	Error error
+	Index uint64
+
+	// Valid is true once a value has been fetched successfully.
+	Valid bool
+
+	// FetchedAt is when the value was last fetched successfully.
+	FetchedAt time.Time
+
+	// Fetching is true while a fetch is in flight, and Waiter is closed
+	// when the entry is updated by it.
+	Fetching bool
+	Waiter   chan struct{}
+
+	// LastGet is when the entry was last read, used for eviction.
+	LastGet time.Time
+}
+
+// Options are options for the Cache.
+type Options struct {
+	// EvictInterval is how often entries that haven't been read for the
+	// LastGetTTL of their type are removed. Defaults to one minute.
+	EvictInterval time.Duration
+}
+
+// RegisterOptions are options that can be associated with a type being
+// registered for the cache. This changes the behavior of the cache for
+// this type.
+type RegisterOptions struct {
+	// Refresh configures whether the data is actively refreshed or if
+	// the data is only refreshed on an explicit Get. The default (false)
+	// is to only request data on explicit Get.
+	Refresh bool
+
+	// RefreshTimer is the time between attempting to refresh data.
+	// If this is zero, then data is refreshed immediately when a fetch
+	// is returned, which is what types supporting blocking queries want.
+	RefreshTimer time.Duration
+
+	// RefreshTimeout determines the maximum query time for a refresh
+	// operation. This is specified as part of the query options and is
+	// expected to be implemented by the Type itself. Defaults to ten
+	// minutes.
+	RefreshTimeout time.Duration
+
+	// LastGetTTL is the time that the values returned by this type remain
+	// in the cache after the last get operation. If a value isn't accessed
+	// within this duration, the value is purged from the cache and
+	// background refreshing will cease. Defaults to three days.
+	LastGetTTL time.Duration
+}
+
+// ResultMeta is returned from Get calls along with the value and can be
+// used to expose information about the cache status for debugging or
+// testing.
+type ResultMeta struct {
+	// Hit indicates whether or not the request was a cache hit.
+	Hit bool
+
+	// Age identifies how "stale" the result is. Its semantics differ
+	// based on whether or not the cache type performs background refresh.
+	// For background refresh types, the value is zero while the
+	// background refresh is healthy, since the blocking query keeps the
+	// value up to date. Otherwise it's the time since the value was last
+	// fetched.
+	Age time.Duration
+
+	// Index is the Raft index of the cached value.
+	Index uint64
+}
+
+// New creates a new cache. The cache runs a background goroutine to evict
+// unused entries until it's closed.
+func New(opts *Options) *Cache {
+	if opts == nil {
+		opts = &Options{}
+	}
+	if opts.EvictInterval == 0 {
+		opts.EvictInterval = defaultEvictInterval
+	}
+
+	c := &Cache{
+		types:   make(map[string]typeEntry),
+		entries: make(map[string]cacheEntry),
+		stopCh:  make(chan struct{}),
+	}
+	go c.runEvictLoop(opts.EvictInterval)
+	return c
+}
+
+// Close stops the background refreshes and eviction of the cache.
+func (c *Cache) Close() {
+	c.stopOnce.Do(func() {
+		close(c.stopCh)
+	})
+}
+
+// RegisterType registers a cacheable type.
+//
+// This makes the type available for Get but does not automatically
+// perform any prefetching. In order to populate the cache, Get must be
+// called.
+func (c *Cache) RegisterType(n string, typ Type, opts *RegisterOptions) {
+	if opts == nil {
+		opts = &RegisterOptions{}
+	}
+	if opts.LastGetTTL == 0 {
+		opts.LastGetTTL = defaultLastGetTTL
+	}
+	if opts.RefreshTimeout == 0 {
+		opts.RefreshTimeout = defaultRefreshTimeout
+	}
+
+	c.typesLock.Lock()
+	defer c.typesLock.Unlock()
+	c.types[n] = typeEntry{Type: typ, Opts: opts}
+}
+
+// Get loads the data for the given type and request. If data satisfying
+// the minimum index is present in the cache, it is returned immediately.
+// Otherwise, this will block until the data is available or the request
+// timeout is reached.
+//
+// Multiple Get calls for the same Request (matching Key) will
+// block on a single network request.
+//
+// The timeout specified by the Request will be the timeout on the cache
+// Get, and does not correspond to the timeout of any background data
+// fetching. If the timeout is reached before data satisfying the minimum
+// index is retrieved, the last known value (maybe nil) is returned. No
+// error is returned on timeout. This matches the behavior of Consul
+// blocking queries.
+func (c *Cache) Get(t string, r Request) (interface{}, ResultMeta, error) {
+	info := r.CacheInfo()
+
+	c.typesLock.RLock()
+	tEntry, ok := c.types[t]
+	c.typesLock.RUnlock()
+	if !ok {
+		// The type must be registered before it can be used.
+		return nil, ResultMeta{}, fmt.Errorf("unknown type in cache: %s", t)
+	}
+
+	if info.Key == "" {
+		metrics.IncrCounter([]string{"consul", "cache", "bypass"}, 1)
+		return c.fetchDirect(tEntry, r, &info)
+	}
+
+	// Get the actual key for our entry
+	key := c.entryKey(t, &info)
+
+	// first is whether this is the first time through the loop, a result
+	// found then is a cache hit.
+	first := true
+
+	// timeoutCh for watching our timeout
+	var timeoutCh <-chan time.Time
+
+RETRY_GET:
+	// Get the current value, marking the entry as read.
+	c.entriesLock.Lock()
+	entry, ok := c.entries[key]
+	if ok {
+		entry.LastGet = time.Now()
+		c.entries[key] = entry
+	}
+	c.entriesLock.Unlock()
+
+	// If we have a current value and the index is greater than the
+	// currently stored index then we return that right away. If the
+	// index is zero and we have something in the cache we accept whatever
+	// we have. The max age only applies to the first pass, since values
+	// fetched after that are newer than the request.
+	if ok && entry.Valid {
+		age := entryAge(tEntry, &entry)
+		if (!first || info.MaxAge == 0 || age <= info.MaxAge) &&
+			(info.MinIndex == 0 || info.MinIndex < entry.Index) {
+			meta := ResultMeta{Index: entry.Index, Age: age}
+			if first {
+				metrics.IncrCounter([]string{"consul", "cache", t, "hit"}, 1)
+				meta.Hit = true
+			}
+			return entry.Value, meta, nil
+		}
+	}
+
+	// If this isn't our first time through and our last value has an error,
+	// then we return the error. This has the behavior that we don't sit in
+	// a retry loop getting the same error for the entire duration of the
+	// timeout. Instead, we make one effort to fetch a new value, and if
+	// there was an error, we return.
+	if !first && entry.Error != nil {
+		return entry.Value, ResultMeta{Index: entry.Index}, entry.Error
+	}
+
+	// A value that is too old must be fetched again without blocking on
+	// its index, but only once.
+	revalidate := first && ok && entry.Valid && info.MaxAge > 0 &&
+		entryAge(tEntry, &entry) > info.MaxAge
+
+	if first {
+		// Record the miss if its our first time through
+		metrics.IncrCounter([]string{"consul", "cache", t, "miss"}, 1)
+
+		// We increment two different counters for cache misses depending
+		// on whether we're missing because we didn't have the data at all,
+		// or if we're missing because we're blocking on a set index.
+		if info.MinIndex == 0 {
+			metrics.IncrCounter([]string{"consul", "cache", t, "miss_new"}, 1)
+		} else {
+			metrics.IncrCounter([]string{"consul", "cache", t, "miss_block"}, 1)
+		}
+	}
+
+	// No longer our first time through
+	first = false
+
+	// Set our timeout channel if we must
+	if info.MinIndex > 0 && timeoutCh == nil {
+		timeout := info.Timeout
+		if timeout == 0 {
+			timeout = defaultQueryTimeout
+		}
+		timeoutCh = time.After(timeout)
+	}
+
+	// At this point, we know we either don't have a value at all or the
+	// value we have is too old. We need to wait for new data.
+	waiterCh := c.fetch(t, key, r, revalidate, 0)
+
+	select {
+	case <-waiterCh:
+		// Our fetch returned, retry the get from the cache
+		goto RETRY_GET
+
+	case <-timeoutCh:
+		// Timeout on the cache read, just return whatever we have.
+		c.entriesLock.RLock()
+		entry = c.entries[key]
+		c.entriesLock.RUnlock()
+		return entry.Value, ResultMeta{Index: entry.Index}, nil
+	}
+}
+
+// entryKey returns the key for the entry in the cache, which starts with
+// the name of the type.
+func (c *Cache) entryKey(t string, r *RequestInfo) string {
+	return fmt.Sprintf("%s/%s/%s/%s", t, r.Datacenter, r.Token, r.Key)
+}
+
+// entryAge returns the age of the value of the entry, which is zero for
+// types refreshed in the background while the refresh is healthy.
+func entryAge(tEntry typeEntry, entry *cacheEntry) time.Duration {
+	if tEntry.Opts.Refresh && entry.Fetching && entry.Error == nil {
+		return 0
+	}
+	return time.Since(entry.FetchedAt)
+}
+
+// fetch triggers a new background fetch for the given Request. If a
+// background fetch is already running for a matching Request, the waiter
+// channel for that request is returned. The effect of this is that there
+// is only ever one blocking query for any matching requests.
+//
+// If revalidate is true then the fetch doesn't block on the index of the
+// current value, since the value is needed right away.
+func (c *Cache) fetch(t, key string, r Request, revalidate bool, attempt uint) <-chan struct{} {
+	// Get the type that we're fetching
+	c.typesLock.RLock()
+	tEntry := c.types[t]
+	c.typesLock.RUnlock()
+
+	// We acquire a write lock because we may have to set Fetching to true.
+	c.entriesLock.Lock()
+	defer c.entriesLock.Unlock()
+	entry, ok := c.entries[key]
+
+	// If we aren't allowing new values and we don't have an existing value,
+	// return immediately. We return an immediately-closed channel so nothing
+	// blocks.
+	if !ok && attempt > 0 {
+		ch := make(chan struct{})
+		close(ch)
+		return ch
+	}
+	if !ok {
+		entry = cacheEntry{
+			Waiter:  make(chan struct{}),
+			LastGet: time.Now(),
+		}
+	}
+
+	// If we already have an entry and it is actively fetching, then return
+	// the currently active waiter.
+	if entry.Fetching {
+		return entry.Waiter
+	}
+
+	// Set that we're fetching to true, which makes it so that future
+	// identical calls to fetch will return the same waiter rather than
+	// perform multiple fetches.
+	entry.Fetching = true
+	c.entries[key] = entry
+
+	minIndex := entry.Index
+	if revalidate {
+		minIndex = 0
+	}
+
+	// The actual Fetch must be performed in a goroutine.
+	go func() {
+		// Start building the new entry by blocking on the fetch.
+		result, err := tEntry.Type.Fetch(FetchOptions{
+			MinIndex: minIndex,
+			Timeout:  tEntry.Opts.RefreshTimeout,
+		}, r)
+
+		if err == nil {
+			metrics.IncrCounter([]string{"consul", "cache", "fetch_success"}, 1)
+			metrics.IncrCounter([]string{"consul", "cache", t, "fetch_success"}, 1)
+		} else {
+			metrics.IncrCounter([]string{"consul", "cache", "fetch_error"}, 1)
+			metrics.IncrCounter([]string{"consul", "cache", t, "fetch_error"}, 1)
+		}
+
+		// Update the entry. It may have been read or evicted while we
+		// were fetching, so start from what's in the cache now.
+		c.entriesLock.Lock()
+		newEntry, ok := c.entries[key]
+		if !ok {
+			c.entriesLock.Unlock()
+			close(entry.Waiter)
+			return
+		}
+		if err == nil && result.Value != nil {
+			newEntry.Value = result.Value
+			newEntry.Index = result.Index
+			newEntry.Valid = true
+			newEntry.FetchedAt = time.Now()
+		}
+		newEntry.Error = err
+		newEntry.Fetching = false
+		newEntry.Waiter = make(chan struct{})
+		c.entries[key] = newEntry
+		c.entriesLock.Unlock()
+
+		// Trigger the old waiter
+		close(entry.Waiter)
+
+		// If refresh is enabled, run the refresh in due time. The refresh
+		// needs to occur after the Waiter is closed so it can set up a
+		// new waiter.
+		if tEntry.Opts.Refresh {
+			if err != nil {
+				if attempt == 0 {
+					attempt = 1
+				}
+				attempt++
+			} else {
+				attempt = 1
+			}
+			go c.refresh(tEntry.Opts, attempt, t, key, r)
+		}
+	}()
+
+	return entry.Waiter
+}
+
+// fetchDirect fetches the given request with no caching. Because this
+// bypasses the caching entirely, multiple matching requests will result
+// in multiple actual RPC calls (unlike fetch).
+func (c *Cache) fetchDirect(tEntry typeEntry, r Request, info *RequestInfo) (interface{}, ResultMeta, error) {
+	result, err := tEntry.Type.Fetch(FetchOptions{
+		MinIndex: info.MinIndex,
+		Timeout:  info.Timeout,
+	}, r)
+	if err != nil {
+		return nil, ResultMeta{}, err
+	}
+	return result.Value, ResultMeta{Index: result.Index}, nil
+}
+
+// refresh triggers a fetch for a specific Request according to the
+// registration options. Attempt starts at 1 for a healthy entry and grows
+// with every failed fetch, which backs off the retries.
+func (c *Cache) refresh(opts *RegisterOptions, attempt uint, t, key string, r Request) {
+	// Sanity-check, we should not schedule anything that has refresh disabled
+	if !opts.Refresh {
+		return
+	}
+
+	// Wait for the backoff after an error, then for the refresh timer.
+	wait := backoffWait(attempt) + opts.RefreshTimer
+	if wait > 0 {
+		select {
+		case <-time.After(wait):
+		case <-c.stopCh:
+			return
+		}
+	}
+
+	select {
+	case <-c.stopCh:
+		return
+	default:
+	}
+
+	// Trigger the fetch. An attempt greater than zero won't create an
+	// entry that has been evicted in the meantime, which ends the refresh.
+	c.fetch(t, key, r, false, attempt)
+}
+
+// backoffWait returns the time to wait before a background refresh, given
+// the number of the attempt, which is one for a healthy entry.
+func backoffWait(attempt uint) time.Duration {
+	if attempt <= 1 {
+		return 0
+	}
+	if attempt > 7 {
+		return maxBackoffWait
+	}
+	wait := time.Second << (attempt - 2)
+	if wait > maxBackoffWait {
+		wait = maxBackoffWait
+	}
+	return wait
+}
+
+// runEvictLoop periodically removes the entries that haven't been read for
+// the LastGetTTL of their type, which also stops their background refresh.
+func (c *Cache) runEvictLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.evict()
+		case <-c.stopCh:
+			return
+		}
+	}
+}
+
+// evict removes the entries that haven't been read for the LastGetTTL of
+// their type.
+func (c *Cache) evict() {
+	c.typesLock.RLock()
+	ttls := make(map[string]time.Duration, len(c.types))
+	for name, tEntry := range c.types {
+		ttls[name] = tEntry.Opts.LastGetTTL
+	}
+	c.typesLock.RUnlock()
+
+	c.entriesLock.Lock()
+	defer c.entriesLock.Unlock()
+	now := time.Now()
+	for key, entry := range c.entries {
+		t := key[:strings.IndexByte(key, '/')]
+		if ttl, ok := ttls[t]; ok && now.Sub(entry.LastGet) > ttl {
+			delete(c.entries, key)
+			metrics.IncrCounter([]string{"consul", "cache", "evict_expired"}, 1)
+		}
+	}
+	metrics.SetGauge([]string{"consul", "cache", "entries_count"}, float32(len(c.entries)))
+}
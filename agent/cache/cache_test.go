@@ -0,0 +1,394 @@
+package cache
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/testutil/retry"
+)
+
+// testType is a Type whose fetches are answered by a function.
+type testType struct {
+	calls uint32
+	fetch func(FetchOptions, Request) (FetchResult, error)
+}
+
+func (t *testType) Fetch(opts FetchOptions, r Request) (FetchResult, error) {
+	atomic.AddUint32(&t.calls, 1)
+	return t.fetch(opts, r)
+}
+
+func (t *testType) Calls() int {
+	return int(atomic.LoadUint32(&t.calls))
+}
+
+// testRequest is a Request with fixed cache info.
+type testRequest RequestInfo
+
+func (r testRequest) CacheInfo() RequestInfo {
+	return RequestInfo(r)
+}
+
+// blockingType returns a type that serves the value of the index, blocking
+// until the index is greater than the requested one or the timeout.
+func blockingType() (*testType, func(uint64)) {
+	var lock sync.Mutex
+	index := uint64(1)
+	changed := make(chan struct{})
+
+	typ := &testType{
+		fetch: func(opts FetchOptions, r Request) (FetchResult, error) {
+			timeout := time.After(opts.Timeout)
+			for {
+				lock.Lock()
+				idx, ch := index, changed
+				lock.Unlock()
+				if idx > opts.MinIndex {
+					return FetchResult{Value: idx, Index: idx}, nil
+				}
+				select {
+				case <-ch:
+				case <-timeout:
+					return FetchResult{Value: idx, Index: idx}, nil
+				}
+			}
+		},
+	}
+	set := func(idx uint64) {
+		lock.Lock()
+		defer lock.Unlock()
+		index = idx
+		close(changed)
+		changed = make(chan struct{})
+	}
+	return typ, set
+}
+
+func TestCache_Get_HitMiss(t *testing.T) {
+	t.Parallel()
+	c := New(nil)
+	defer c.Close()
+
+	typ := &testType{
+		fetch: func(opts FetchOptions, r Request) (FetchResult, error) {
+			return FetchResult{Value: "hello", Index: 5}, nil
+		},
+	}
+	c.RegisterType("t", typ, nil)
+
+	req := testRequest{Key: "hello", Datacenter: "dc1"}
+	val, meta, err := c.Get("t", req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if val != "hello" || meta.Hit || meta.Index != 5 {
+		t.Fatalf("bad: %v %#v", val, meta)
+	}
+
+	val, meta, err = c.Get("t", req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if val != "hello" || !meta.Hit || meta.Index != 5 {
+		t.Fatalf("bad: %v %#v", val, meta)
+	}
+	if typ.Calls() != 1 {
+		t.Fatalf("got %d fetches want 1", typ.Calls())
+	}
+
+	// A different token or datacenter is a different entry.
+	for _, req := range []testRequest{
+		{Key: "hello", Datacenter: "dc1", Token: "foo"},
+		{Key: "hello", Datacenter: "dc2"},
+	} {
+		if _, meta, err := c.Get("t", req); err != nil || meta.Hit {
+			t.Fatalf("bad: %#v %v", meta, err)
+		}
+	}
+	if typ.Calls() != 3 {
+		t.Fatalf("got %d fetches want 3", typ.Calls())
+	}
+
+	// Unknown types are an error.
+	if _, _, err := c.Get("nope", req); err == nil {
+		t.Fatalf("should fail")
+	}
+}
+
+func TestCache_Get_NoKey(t *testing.T) {
+	t.Parallel()
+	c := New(nil)
+	defer c.Close()
+
+	typ := &testType{
+		fetch: func(opts FetchOptions, r Request) (FetchResult, error) {
+			return FetchResult{Value: "hello", Index: 5}, nil
+		},
+	}
+	c.RegisterType("t", typ, nil)
+
+	// Requests without a key are never cached.
+	for i := 0; i < 2; i++ {
+		val, meta, err := c.Get("t", testRequest{})
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if val != "hello" || meta.Hit {
+			t.Fatalf("bad: %v %#v", val, meta)
+		}
+	}
+	if typ.Calls() != 2 {
+		t.Fatalf("got %d fetches want 2", typ.Calls())
+	}
+}
+
+func TestCache_Get_SharedFetch(t *testing.T) {
+	t.Parallel()
+	c := New(nil)
+	defer c.Close()
+
+	release := make(chan struct{})
+	typ := &testType{
+		fetch: func(opts FetchOptions, r Request) (FetchResult, error) {
+			<-release
+			return FetchResult{Value: "hello", Index: 5}, nil
+		},
+	}
+	c.RegisterType("t", typ, nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if val, _, err := c.Get("t", testRequest{Key: "hello"}); err != nil || val != "hello" {
+				t.Errorf("bad: %v %v", val, err)
+			}
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if typ.Calls() != 1 {
+		t.Fatalf("got %d fetches want 1", typ.Calls())
+	}
+}
+
+func TestCache_Get_Error(t *testing.T) {
+	t.Parallel()
+	c := New(nil)
+	defer c.Close()
+
+	var fail atomic.Value
+	fail.Store(true)
+	typ := &testType{
+		fetch: func(opts FetchOptions, r Request) (FetchResult, error) {
+			if fail.Load().(bool) {
+				return FetchResult{}, errors.New("boom")
+			}
+			return FetchResult{Value: "hello", Index: 5}, nil
+		},
+	}
+	c.RegisterType("t", typ, nil)
+
+	req := testRequest{Key: "hello"}
+	if _, _, err := c.Get("t", req); err == nil || err.Error() != "boom" {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Errors aren't cached.
+	fail.Store(false)
+	if val, _, err := c.Get("t", req); err != nil || val != "hello" {
+		t.Fatalf("bad: %v %v", val, err)
+	}
+}
+
+func TestCache_Get_MaxAge(t *testing.T) {
+	t.Parallel()
+	c := New(nil)
+	defer c.Close()
+
+	var index uint64
+	typ := &testType{
+		fetch: func(opts FetchOptions, r Request) (FetchResult, error) {
+			idx := atomic.AddUint64(&index, 1)
+			return FetchResult{Value: idx, Index: idx}, nil
+		},
+	}
+	c.RegisterType("t", typ, nil)
+
+	req := testRequest{Key: "hello", MaxAge: 100 * time.Millisecond}
+	if val, _, err := c.Get("t", req); err != nil || val != uint64(1) {
+		t.Fatalf("bad: %v %v", val, err)
+	}
+	val, meta, err := c.Get("t", req)
+	if err != nil || val != uint64(1) || !meta.Hit {
+		t.Fatalf("bad: %v %#v %v", val, meta, err)
+	}
+
+	// Once the value is too old it's fetched again.
+	time.Sleep(150 * time.Millisecond)
+	val, meta, err = c.Get("t", req)
+	if err != nil || val != uint64(2) || meta.Hit {
+		t.Fatalf("bad: %v %#v %v", val, meta, err)
+	}
+
+	// The smallest max age always fetches a new value.
+	req.MaxAge = time.Nanosecond
+	val, meta, err = c.Get("t", req)
+	if err != nil || val != uint64(3) || meta.Hit {
+		t.Fatalf("bad: %v %#v %v", val, meta, err)
+	}
+
+	// Without a max age any value is fine.
+	time.Sleep(150 * time.Millisecond)
+	val, meta, err = c.Get("t", testRequest{Key: "hello"})
+	if err != nil || val != uint64(3) || !meta.Hit || meta.Age < 150*time.Millisecond {
+		t.Fatalf("bad: %v %#v %v", val, meta, err)
+	}
+}
+
+func TestCache_Get_Blocking(t *testing.T) {
+	t.Parallel()
+	c := New(nil)
+	defer c.Close()
+
+	typ, set := blockingType()
+	c.RegisterType("t", typ, nil)
+
+	if val, _, err := c.Get("t", testRequest{Key: "hello"}); err != nil || val != uint64(1) {
+		t.Fatalf("bad: %v %v", val, err)
+	}
+
+	// A blocking get waits for a newer index.
+	type result struct {
+		val  interface{}
+		meta ResultMeta
+	}
+	resultCh := make(chan result, 1)
+	go func() {
+		val, meta, err := c.Get("t", testRequest{Key: "hello", MinIndex: 1, Timeout: 5 * time.Second})
+		if err != nil {
+			t.Errorf("err: %v", err)
+		}
+		resultCh <- result{val, meta}
+	}()
+
+	select {
+	case <-resultCh:
+		t.Fatalf("should block")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	set(2)
+	select {
+	case r := <-resultCh:
+		if r.val != uint64(2) || r.meta.Index != 2 || r.meta.Hit {
+			t.Fatalf("bad: %#v", r)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("should unblock")
+	}
+
+	// Timing out returns the current value.
+	val, meta, err := c.Get("t", testRequest{Key: "hello", MinIndex: 2, Timeout: 50 * time.Millisecond})
+	if err != nil || val != uint64(2) || meta.Index != 2 {
+		t.Fatalf("bad: %v %#v %v", val, meta, err)
+	}
+}
+
+func TestCache_Get_Refresh(t *testing.T) {
+	t.Parallel()
+	c := New(nil)
+	defer c.Close()
+
+	typ, set := blockingType()
+	c.RegisterType("t", typ, &RegisterOptions{
+		Refresh:        true,
+		RefreshTimeout: 5 * time.Second,
+	})
+
+	req := testRequest{Key: "hello"}
+	if val, _, err := c.Get("t", req); err != nil || val != uint64(1) {
+		t.Fatalf("bad: %v %v", val, err)
+	}
+
+	// The background refresh should pick up the change without any more
+	// gets, which are then hits.
+	set(2)
+	retry.Run(t, func(r *retry.R) {
+		val, meta, err := c.Get("t", req)
+		if err != nil || val != uint64(2) || !meta.Hit || meta.Age != 0 {
+			r.Fatalf("bad: %v %#v %v", val, meta, err)
+		}
+	})
+	retry.Run(t, func(r *retry.R) {
+		if typ.Calls() != 3 {
+			r.Fatalf("got %d fetches want 3", typ.Calls())
+		}
+	})
+}
+
+func TestCache_Evict(t *testing.T) {
+	t.Parallel()
+	c := New(&Options{EvictInterval: 10 * time.Millisecond})
+	defer c.Close()
+
+	typ, _ := blockingType()
+	c.RegisterType("t", typ, &RegisterOptions{
+		Refresh:        true,
+		RefreshTimeout: 50 * time.Millisecond,
+		LastGetTTL:     100 * time.Millisecond,
+	})
+
+	req := testRequest{Key: "hello"}
+	if _, _, err := c.Get("t", req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Once the entry hasn't been read for a while it should go away and
+	// stop refreshing.
+	retry.Run(t, func(r *retry.R) {
+		c.entriesLock.RLock()
+		n := len(c.entries)
+		c.entriesLock.RUnlock()
+		if n != 0 {
+			r.Fatalf("got %d entries want 0", n)
+		}
+	})
+	time.Sleep(100 * time.Millisecond)
+	calls := typ.Calls()
+	time.Sleep(200 * time.Millisecond)
+	if typ.Calls() != calls {
+		t.Fatalf("should stop refreshing")
+	}
+
+	if _, meta, err := c.Get("t", req); err != nil || meta.Hit {
+		t.Fatalf("bad: %#v %v", meta, err)
+	}
+}
+
+func TestCache_backoffWait(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		attempt uint
+		want    time.Duration
+	}{
+		{0, 0},
+		{1, 0},
+		{2, time.Second},
+		{3, 2 * time.Second},
+		{7, 32 * time.Second},
+		{8, maxBackoffWait},
+		{100, maxBackoffWait},
+	}
+	for _, tc := range cases {
+		if got := backoffWait(tc.attempt); got != tc.want {
+			t.Fatalf("%d: got %v want %v", tc.attempt, got, tc.want)
+		}
+	}
+}
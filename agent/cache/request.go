@@ -0,0 +1,50 @@
+package cache
+
+import (
+	"time"
+)
+
+// Request is a cacheable request.
+//
+// This interface is typically implemented by request structures in the
+// agent/structs package.
+type Request interface {
+	// CacheInfo returns information used for caching this request.
+	CacheInfo() RequestInfo
+}
+
+// RequestInfo represents cache information for a request. The caching
+// framework uses this to control the behavior of caching and to determine
+// cacheability.
+type RequestInfo struct {
+	// Key is a unique cache key for this request. This key should
+	// be globally unique to identify this request, since any conflicting
+	// cache keys could result in invalid data being returned from the cache.
+	// The Key does not need to include the ACL token or datacenter, since
+	// the cache keys entries by those separately. If the Key is empty then
+	// the request is never cached.
+	Key string
+
+	// Token is the ACL token associated with this request.
+	Token string
+
+	// Datacenter is the datacenter that the request is targeting.
+	Datacenter string
+
+	// MinIndex is the minimum index being queried. This is used to
+	// determine if we already have data satisfying the query or if we need
+	// to block until new data is available. If no index is available, the
+	// default value (zero) is acceptable.
+	MinIndex uint64
+
+	// Timeout is the timeout for waiting on a blocking query. When the
+	// timeout is reached, the last known value is returned (or maybe nil
+	// if there was no prior value). This "last known value" behavior matches
+	// normal Consul blocking queries.
+	Timeout time.Duration
+
+	// MaxAge is the maximum age of a cached result that is acceptable for
+	// this request. Older results are fetched again. Zero means any age is
+	// acceptable.
+	MaxAge time.Duration
+}
@@ -0,0 +1,44 @@
+package cache
+
+import (
+	"time"
+)
+
+// Type implements the logic to fetch certain types of data.
+type Type interface {
+	// Fetch fetches a single unique item.
+	//
+	// The FetchOptions contain the index and timeouts for blocking queries.
+	// The MinIndex value on the Request itself should NOT be used as the
+	// blocking index since a request may be reused multiple times as part
+	// of background refresh.
+	//
+	// The return value is a FetchResult which contains information about
+	// the fetch. If an error is given, the FetchResult is ignored. The
+	// cache does not support backends that return partial values.
+	//
+	// Values returned are shared by every reader of the cache and must not
+	// be modified afterwards.
+	Fetch(FetchOptions, Request) (FetchResult, error)
+}
+
+// FetchOptions are various settable options when a Fetch is called.
+type FetchOptions struct {
+	// MinIndex is the minimum index to be used for blocking queries.
+	// If blocking queries aren't supported for data being returned,
+	// this value can be ignored.
+	MinIndex uint64
+
+	// Timeout is the maximum time for the query. This must be implemented
+	// in the Fetch itself.
+	Timeout time.Duration
+}
+
+// FetchResult is the result of a Type Fetch operation.
+type FetchResult struct {
+	// Value is the result of the fetch.
+	Value interface{}
+
+	// Index is the corresponding index value for this data.
+	Index uint64
+}
@@ -0,0 +1,46 @@
+package cachetype
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/consul/agent/cache"
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+// Recommended name for registration.
+const CatalogServicesName = "catalog-services"
+
+// CatalogServices supports fetching the instances of a service from the
+// catalog, as returned by the Catalog.ServiceNodes RPC.
+type CatalogServices struct {
+	RPC RPC
+}
+
+func (c *CatalogServices) Fetch(opts cache.FetchOptions, req cache.Request) (cache.FetchResult, error) {
+	var result cache.FetchResult
+
+	// The request should be a ServiceSpecificRequest.
+	reqReal, ok := req.(*structs.ServiceSpecificRequest)
+	if !ok {
+		return result, fmt.Errorf(
+			"Internal cache failure: request wrong type: %T", req)
+	}
+
+	// Make a copy so we can set the index and timeout of the blocking
+	// query without changing the request that is reused for refreshes.
+	// Cached data is stale anyway, so any server can answer.
+	reqCopy := *reqReal
+	reqCopy.QueryOptions.MinQueryIndex = opts.MinIndex
+	reqCopy.QueryOptions.MaxQueryTime = opts.Timeout
+	reqCopy.QueryOptions.AllowStale = true
+
+	// Fetch
+	var reply structs.IndexedServiceNodes
+	if err := c.RPC.RPC("Catalog.ServiceNodes", &reqCopy, &reply); err != nil {
+		return result, err
+	}
+
+	result.Value = &reply
+	result.Index = reply.QueryMeta.Index
+	return result, nil
+}
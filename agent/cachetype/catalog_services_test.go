@@ -0,0 +1,60 @@
+package cachetype
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/agent/cache"
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+func TestCatalogServices(t *testing.T) {
+	rpc := &testRPC{
+		replyFn: func(method string, args interface{}, reply interface{}) error {
+			req := args.(*structs.ServiceSpecificRequest)
+			if req.MinQueryIndex != 24 || req.MaxQueryTime != time.Second || !req.AllowStale {
+				return fmt.Errorf("bad: %#v", req)
+			}
+			reply.(*structs.IndexedServiceNodes).QueryMeta.Index = 48
+			return nil
+		},
+	}
+	typ := &CatalogServices{RPC: rpc}
+
+	// Fetch
+	req := &structs.ServiceSpecificRequest{Datacenter: "dc1", ServiceName: "web"}
+	result, err := typ.Fetch(cache.FetchOptions{
+		MinIndex: 24,
+		Timeout:  1 * time.Second,
+	}, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	want := cache.FetchResult{
+		Value: &structs.IndexedServiceNodes{QueryMeta: structs.QueryMeta{Index: 48}},
+		Index: 48,
+	}
+	if !reflect.DeepEqual(result, want) {
+		t.Fatalf("got %#v want %#v", result, want)
+	}
+	if len(rpc.methods) != 1 || rpc.methods[0] != "Catalog.ServiceNodes" {
+		t.Fatalf("bad: %v", rpc.methods)
+	}
+
+	// The original request shouldn't be changed.
+	if req.MinQueryIndex != 0 || req.AllowStale {
+		t.Fatalf("bad: %#v", req)
+	}
+}
+
+func TestCatalogServices_badReqType(t *testing.T) {
+	typ := &CatalogServices{RPC: &testRPC{}}
+
+	// Fetch
+	_, err := typ.Fetch(cache.FetchOptions{}, &structs.PreparedQueryExecuteRequest{})
+	if err == nil {
+		t.Fatalf("should fail")
+	}
+}
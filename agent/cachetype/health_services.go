@@ -0,0 +1,46 @@
+package cachetype
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/consul/agent/cache"
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+// Recommended name for registration.
+const HealthServicesName = "health-services"
+
+// HealthServices supports fetching the health of the instances of a
+// service, as returned by the Health.ServiceNodes RPC.
+type HealthServices struct {
+	RPC RPC
+}
+
+func (c *HealthServices) Fetch(opts cache.FetchOptions, req cache.Request) (cache.FetchResult, error) {
+	var result cache.FetchResult
+
+	// The request should be a ServiceSpecificRequest.
+	reqReal, ok := req.(*structs.ServiceSpecificRequest)
+	if !ok {
+		return result, fmt.Errorf(
+			"Internal cache failure: request wrong type: %T", req)
+	}
+
+	// Make a copy so we can set the index and timeout of the blocking
+	// query without changing the request that is reused for refreshes.
+	// Cached data is stale anyway, so any server can answer.
+	reqCopy := *reqReal
+	reqCopy.QueryOptions.MinQueryIndex = opts.MinIndex
+	reqCopy.QueryOptions.MaxQueryTime = opts.Timeout
+	reqCopy.QueryOptions.AllowStale = true
+
+	// Fetch
+	var reply structs.IndexedCheckServiceNodes
+	if err := c.RPC.RPC("Health.ServiceNodes", &reqCopy, &reply); err != nil {
+		return result, err
+	}
+
+	result.Value = &reply
+	result.Index = reply.QueryMeta.Index
+	return result, nil
+}
@@ -0,0 +1,44 @@
+package cachetype
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/consul/agent/cache"
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+// Recommended name for registration.
+const PreparedQueryName = "prepared-query"
+
+// PreparedQuery supports fetching the results of executing a prepared
+// query. Executing a query isn't a blocking query, so the results are only
+// fetched again when they are too old for a request.
+type PreparedQuery struct {
+	RPC RPC
+}
+
+func (c *PreparedQuery) Fetch(opts cache.FetchOptions, req cache.Request) (cache.FetchResult, error) {
+	var result cache.FetchResult
+
+	// The request should be a PreparedQueryExecuteRequest.
+	reqReal, ok := req.(*structs.PreparedQueryExecuteRequest)
+	if !ok {
+		return result, fmt.Errorf(
+			"Internal cache failure: request wrong type: %T", req)
+	}
+
+	// Make a copy so the request that is reused for refreshes isn't
+	// changed. Cached data is stale anyway, so any server can answer.
+	reqCopy := *reqReal
+	reqCopy.QueryOptions.AllowStale = true
+
+	// Fetch
+	var reply structs.PreparedQueryExecuteResponse
+	if err := c.RPC.RPC("PreparedQuery.Execute", &reqCopy, &reply); err != nil {
+		return result, err
+	}
+
+	result.Value = &reply
+	result.Index = reply.QueryMeta.Index
+	return result, nil
+}
@@ -0,0 +1,56 @@
+package cachetype
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/consul/agent/cache"
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+func TestPreparedQuery(t *testing.T) {
+	rpc := &testRPC{
+		replyFn: func(method string, args interface{}, reply interface{}) error {
+			req := args.(*structs.PreparedQueryExecuteRequest)
+			if req.QueryIDOrName != "geo-db" || !req.AllowStale {
+				return fmt.Errorf("bad: %#v", req)
+			}
+			out := reply.(*structs.PreparedQueryExecuteResponse)
+			out.Service = "db"
+			out.QueryMeta.Index = 48
+			return nil
+		},
+	}
+	typ := &PreparedQuery{RPC: rpc}
+
+	// Fetch
+	req := &structs.PreparedQueryExecuteRequest{Datacenter: "dc1", QueryIDOrName: "geo-db"}
+	result, err := typ.Fetch(cache.FetchOptions{}, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	want := cache.FetchResult{
+		Value: &structs.PreparedQueryExecuteResponse{
+			Service:   "db",
+			QueryMeta: structs.QueryMeta{Index: 48},
+		},
+		Index: 48,
+	}
+	if !reflect.DeepEqual(result, want) {
+		t.Fatalf("got %#v want %#v", result, want)
+	}
+	if len(rpc.methods) != 1 || rpc.methods[0] != "PreparedQuery.Execute" {
+		t.Fatalf("bad: %v", rpc.methods)
+	}
+}
+
+func TestPreparedQuery_badReqType(t *testing.T) {
+	typ := &PreparedQuery{RPC: &testRPC{}}
+
+	// Fetch
+	_, err := typ.Fetch(cache.FetchOptions{}, &structs.ServiceSpecificRequest{})
+	if err == nil {
+		t.Fatalf("should fail")
+	}
+}
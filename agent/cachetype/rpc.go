@@ -0,0 +1,10 @@
+// Package cachetype implements the agent/cache Types for the read RPCs that
+// the agent caches.
+package cachetype
+
+// RPC is an interface that an RPC client must implement. This is a helper
+// interface that is implemented by the agent so that Type implementations
+// can make RPC calls.
+type RPC interface {
+	RPC(method string, args interface{}, reply interface{}) error
+}
@@ -0,0 +1,26 @@
+package cachetype
+
+import (
+	"sync"
+)
+
+// testRPC is an RPC implementation for tests that records the requests it
+// gets and answers them with a function.
+type testRPC struct {
+	sync.Mutex
+
+	// methods and args are the calls made so far.
+	methods []string
+	args    []interface{}
+
+	// replyFn fills in the reply for a call.
+	replyFn func(method string, args interface{}, reply interface{}) error
+}
+
+func (t *testRPC) RPC(method string, args interface{}, reply interface{}) error {
+	t.Lock()
+	t.methods = append(t.methods, method)
+	t.args = append(t.args, args)
+	t.Unlock()
+	return t.replyFn(method, args, reply)
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/hashicorp/consul/agent/cachetype"
 	"github.com/hashicorp/consul/agent/structs"
 )
 
@@ -81,7 +82,7 @@ func (s *HTTPServer) CatalogNodes(resp http.ResponseWriter, req *http.Request) (
 	args := structs.DCSpecificRequest{}
 	s.parseSource(req, &args.Source)
 	args.NodeMetaFilters = s.parseMetaFilter(req)
-	if done := s.parse(resp, req, &args.Datacenter, &args.QueryOptions); done {
+	if done := s.parseWithFilter(resp, req, &args.Datacenter, &args.QueryOptions); done {
 		return nil, nil
 	}
 
@@ -133,7 +134,8 @@ func (s *HTTPServer) CatalogServiceNodes(resp http.ResponseWriter, req *http.Req
 	args := structs.ServiceSpecificRequest{}
 	s.parseSource(req, &args.Source)
 	args.NodeMetaFilters = s.parseMetaFilter(req)
-	if done := s.parse(resp, req, &args.Datacenter, &args.QueryOptions); done {
+	args.ServiceMetaFilters = s.parseServiceMetaFilter(req)
+	if done := s.parseWithFilter(resp, req, &args.Datacenter, &args.QueryOptions); done {
 		return nil, nil
 	}
 
@@ -155,7 +157,7 @@ func (s *HTTPServer) CatalogServiceNodes(resp http.ResponseWriter, req *http.Req
 	// Make the RPC request
 	var out structs.IndexedServiceNodes
 	defer setMeta(resp, &out.QueryMeta)
-	if err := s.agent.RPC("Catalog.ServiceNodes", &args, &out); err != nil {
+	if err := s.cachedRPC(resp, args.UseCache, cachetype.CatalogServicesName, "Catalog.ServiceNodes", &args, &out); err != nil {
 		return nil, err
 	}
 	s.agent.TranslateAddresses(args.Datacenter, out.ServiceNodes)
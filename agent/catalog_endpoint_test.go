@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 	"time"
 
@@ -187,6 +189,64 @@ func TestCatalogNodes_MetaFilter(t *testing.T) {
 	}
 }
 
+func TestCatalogNodes_Filter(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t.Name(), "")
+	defer a.Shutdown()
+
+	// Register a node with a meta field
+	args := &structs.RegisterRequest{
+		Datacenter: "dc1",
+		Node:       "foo",
+		Address:    "127.0.0.1",
+		NodeMeta: map[string]string{
+			"rack": "r1",
+		},
+	}
+
+	var out struct{}
+	if err := a.RPC("Catalog.Register", args, &out); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	req, _ := http.NewRequest("GET", "/v1/catalog/nodes?filter="+url.QueryEscape("Meta.rack == r1"), nil)
+	resp := httptest.NewRecorder()
+	obj, err := a.srv.CatalogNodes(resp, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Verify an index is set
+	assertIndex(t, resp)
+
+	nodes := obj.(structs.Nodes)
+	if len(nodes) != 1 || nodes[0].Node != "foo" {
+		t.Fatalf("bad: %v", obj)
+	}
+
+	// An invalid filter should be a bad request.
+	req, _ = http.NewRequest("GET", "/v1/catalog/nodes?filter="+url.QueryEscape("Meta.rack = r1"), nil)
+	resp = httptest.NewRecorder()
+	a.srv.wrap(a.srv.CatalogNodes)(resp, req)
+	if got, want := resp.Code, http.StatusBadRequest; got != want {
+		t.Fatalf("got code %d want %d", got, want)
+	}
+	if got := resp.Body.String(); !strings.Contains(got, "Invalid filter: Unexpected '=' at position 10") {
+		t.Fatalf("bad body: %q", got)
+	}
+
+	// Endpoints that don't support filtering shouldn't ignore a filter.
+	req, _ = http.NewRequest("GET", "/v1/catalog/services?filter="+url.QueryEscape("Meta.rack == r1"), nil)
+	resp = httptest.NewRecorder()
+	a.srv.wrap(a.srv.CatalogServices)(resp, req)
+	if got, want := resp.Code, http.StatusBadRequest; got != want {
+		t.Fatalf("got code %d want %d", got, want)
+	}
+	if got := resp.Body.String(); !strings.Contains(got, "Filtering is not supported") {
+		t.Fatalf("bad body: %q", got)
+	}
+}
+
 func TestCatalogNodes_WanTranslation(t *testing.T) {
 	t.Parallel()
 	a1 := NewTestAgent(t.Name(), `
@@ -562,6 +622,61 @@ func TestCatalogServiceNodes(t *testing.T) {
 	}
 }
 
+func TestCatalogServiceNodes_Cached(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t.Name(), "")
+	defer a.Shutdown()
+
+	register := func(node string) {
+		args := &structs.RegisterRequest{
+			Datacenter: "dc1",
+			Node:       node,
+			Address:    "127.0.0.1",
+			Service: &structs.NodeService{
+				Service: "api",
+				Tags:    []string{"a"},
+			},
+		}
+		var out struct{}
+		if err := a.RPC("Catalog.Register", args, &out); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+	get := func(url string) (structs.ServiceNodes, *httptest.ResponseRecorder) {
+		req, _ := http.NewRequest("GET", url, nil)
+		resp := httptest.NewRecorder()
+		obj, err := a.srv.CatalogServiceNodes(resp, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		return obj.(structs.ServiceNodes), resp
+	}
+	register("foo")
+
+	for i, want := range []string{"MISS", "HIT"} {
+		nodes, resp := get("/v1/catalog/service/api?tag=a&cached")
+		if len(nodes) != 1 || resp.Header().Get("X-Cache") != want {
+			t.Fatalf("%d: bad: %v %v", i, nodes, resp.Header())
+		}
+		assertIndex(t, resp)
+	}
+
+	// A different tag is a different entry in the cache.
+	nodes, resp := get("/v1/catalog/service/api?tag=b&cached")
+	if len(nodes) != 0 || resp.Header().Get("X-Cache") != "MISS" {
+		t.Fatalf("bad: %v %v", nodes, resp.Header())
+	}
+
+	// The background refresh picks up changes.
+	register("bar")
+	retry.Run(t, func(r *retry.R) {
+		nodes, resp := get("/v1/catalog/service/api?tag=a&cached")
+		if len(nodes) != 2 || resp.Header().Get("X-Cache") != "HIT" {
+			r.Fatalf("bad: %v %v", nodes, resp.Header())
+		}
+	})
+}
+
 func TestCatalogServiceNodes_NodeMetaFilter(t *testing.T) {
 	t.Parallel()
 	a := NewTestAgent(t.Name(), "")
@@ -617,6 +732,56 @@ func TestCatalogServiceNodes_NodeMetaFilter(t *testing.T) {
 	}
 }
 
+func TestCatalogServiceNodes_ServiceMetaFilter(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t.Name(), "")
+	defer a.Shutdown()
+
+	// Register node
+	args := &structs.RegisterRequest{
+		Datacenter: "dc1",
+		Node:       "foo",
+		Address:    "127.0.0.1",
+		Service: &structs.NodeService{
+			Service: "api",
+			Meta: map[string]string{
+				"version": "2",
+			},
+		},
+	}
+
+	var out struct{}
+	if err := a.RPC("Catalog.Register", args, &out); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	req, _ := http.NewRequest("GET", "/v1/catalog/service/api?service-meta=version:2", nil)
+	resp := httptest.NewRecorder()
+	obj, err := a.srv.CatalogServiceNodes(resp, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	assertIndex(t, resp)
+
+	nodes := obj.(structs.ServiceNodes)
+	if len(nodes) != 1 || nodes[0].ServiceMeta["version"] != "2" {
+		t.Fatalf("bad: %v", obj)
+	}
+
+	// A non-matching filter should return an empty list
+	req, _ = http.NewRequest("GET", "/v1/catalog/service/api?service-meta=version:1", nil)
+	resp = httptest.NewRecorder()
+	obj, err = a.srv.CatalogServiceNodes(resp, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	nodes = obj.(structs.ServiceNodes)
+	if nodes == nil || len(nodes) != 0 {
+		t.Fatalf("bad: %v", obj)
+	}
+}
+
 func TestCatalogServiceNodes_WanTranslation(t *testing.T) {
 	t.Parallel()
 	a1 := NewTestAgent(t.Name(), `
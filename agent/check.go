@@ -6,6 +6,7 @@ import (
 	"io"
 	"io/ioutil"
 	"log"
+	"math"
 	"net"
 	"net/http"
 	"os"
@@ -15,6 +16,7 @@ import (
 	"time"
 
 	"github.com/armon/circbuf"
+	"github.com/armon/go-metrics"
 	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/lib"
@@ -523,6 +525,96 @@ func (c *CheckTCP) check() {
 	c.Notify.UpdateCheck(c.CheckID, api.HealthPassing, fmt.Sprintf("TCP connect %s: Success", c.TCP))
 }
 
+// CheckGRPC is used to periodically query a gRPC application
+// that implements the standard gRPC health checking protocol.
+// The check is passing if the application reports SERVING.
+// The check is critical if the application reports any other
+// status or if the request returns an error
+type CheckGRPC struct {
+	Notify        CheckNotifier
+	CheckID       types.CheckID
+	GRPC          string
+	GRPCUseTLS    bool
+	Interval      time.Duration
+	Timeout       time.Duration
+	Logger        *log.Logger
+	TLSSkipVerify bool
+
+	probe    *grpcHealthProbe
+	stop     bool
+	stopCh   chan struct{}
+	stopLock sync.Mutex
+}
+
+// Start is used to start a gRPC check.
+// The check runs until stop is called
+func (c *CheckGRPC) Start() {
+	c.stopLock.Lock()
+	defer c.stopLock.Unlock()
+
+	if c.probe == nil {
+		// For long (>10s) interval checks the request timeout is 10s,
+		// otherwise the timeout is the interval. This means that a check
+		// *should* return before the next check begins.
+		timeout := 10 * time.Second
+		if c.Timeout > 0 && c.Timeout < c.Interval {
+			timeout = c.Timeout
+		} else if c.Interval < 10*time.Second {
+			timeout = c.Interval
+		}
+
+		var tlsConfig *tls.Config
+		if c.GRPCUseTLS {
+			tlsConfig = &tls.Config{
+				InsecureSkipVerify: c.TLSSkipVerify,
+			}
+		}
+		c.probe = newGrpcHealthProbe(c.GRPC, timeout, tlsConfig)
+	}
+
+	c.stop = false
+	c.stopCh = make(chan struct{})
+	go c.run()
+}
+
+// Stop is used to stop a gRPC check.
+func (c *CheckGRPC) Stop() {
+	c.stopLock.Lock()
+	defer c.stopLock.Unlock()
+	if !c.stop {
+		c.stop = true
+		close(c.stopCh)
+	}
+}
+
+// run is invoked by a goroutine to run until Stop() is called
+func (c *CheckGRPC) run() {
+	// Get the randomized initial pause time
+	initialPauseTime := lib.RandomStagger(c.Interval)
+	c.Logger.Printf("[DEBUG] agent: pausing %v before first gRPC request of %s", initialPauseTime, c.GRPC)
+	next := time.After(initialPauseTime)
+	for {
+		select {
+		case <-next:
+			c.check()
+			next = time.After(c.Interval)
+		case <-c.stopCh:
+			return
+		}
+	}
+}
+
+// check is invoked periodically to perform the gRPC check
+func (c *CheckGRPC) check() {
+	if err := c.probe.Check(); err != nil {
+		c.Logger.Printf("[WARN] agent: gRPC request failed '%s': %s", c.GRPC, err)
+		c.Notify.UpdateCheck(c.CheckID, api.HealthCritical, err.Error())
+		return
+	}
+	c.Logger.Printf("[DEBUG] agent: Check '%v' is passing", c.CheckID)
+	c.Notify.UpdateCheck(c.CheckID, api.HealthPassing, fmt.Sprintf("gRPC check %s: success", c.GRPC))
+}
+
 // CheckDocker is used to periodically invoke a script to
 // determine the health of an application running inside a
 // Docker Container. We assume that the script is compatible
@@ -640,3 +732,186 @@ func (c *CheckDocker) doCheck() (string, *circbuf.Buffer, error) {
 		return api.HealthCritical, buf, nil
 	}
 }
+
+const (
+	// flapPenalty is the penalty a check collects each time its status
+	// changes when flap damping is enabled. The penalty then decays
+	// exponentially with the configured half-life.
+	flapPenalty = 1.0
+
+	// flapSuppressLimit is the penalty at which changes of the status of a
+	// check start being suppressed.
+	flapSuppressLimit = 3.0
+
+	// flapReuseLimit is the penalty below which a suppressed check goes
+	// back to reporting changes of its status.
+	flapReuseLimit = 1.0
+)
+
+// StatusHandler sits between a check and the CheckNotifier the check
+// reports to, and holds back the status updates that shouldn't be acted on
+// yet. A change between passing or warning and critical is only reported
+// once the check returned the new status the configured number of times in
+// a row. With flap damping enabled, a check whose status keeps changing is
+// suppressed and keeps reporting the status it had until it settles down or
+// the suppression window runs out.
+type StatusHandler struct {
+	Notify                 CheckNotifier
+	Logger                 *log.Logger
+	Status                 string
+	SuccessBeforePassing   int
+	FailuresBeforeCritical int
+	FlapHalfLife           time.Duration
+	FlapSuppressWindow     time.Duration
+
+	// now is used to get the current time, and incrCounter is used to
+	// emit the metrics for held back statuses. Both are swapped out in
+	// tests.
+	now         func() time.Time
+	incrCounter func(name, status string)
+
+	// reported is the last status that was passed on to Notify, and last
+	// is the last status that met the consecutive result thresholds.
+	reported string
+	last     string
+
+	successes int
+	failures  int
+	pending   string
+	damped    string
+
+	penalty         float64
+	penaltyTime     time.Time
+	suppressedSince time.Time
+
+	sync.Mutex
+}
+
+// UpdateCheck is used to pass a check result on to Notify, unless the
+// thresholds or the flap damping of the check hold it back.
+func (h *StatusHandler) UpdateCheck(checkID types.CheckID, status, output string) {
+	h.Lock()
+	defer h.Unlock()
+
+	// Checks start out critical unless they were registered with a status.
+	if h.reported == "" {
+		h.reported = h.Status
+		if h.reported == "" {
+			h.reported = api.HealthCritical
+		}
+		h.last = h.reported
+	}
+
+	if h.belowThreshold(status) {
+		if h.pending != status {
+			h.count("pending", status)
+		}
+		h.pending = status
+		if status == api.HealthCritical {
+			h.Logger.Printf("[DEBUG] agent: Check '%v' failed %d of %d times needed to become critical",
+				checkID, h.failures, h.FailuresBeforeCritical)
+		} else {
+			h.Logger.Printf("[DEBUG] agent: Check '%v' passed %d of %d times needed to become %s",
+				checkID, h.successes, h.SuccessBeforePassing, status)
+		}
+		return
+	}
+	h.pending = ""
+
+	if h.FlapHalfLife > 0 && h.damp(checkID, status) {
+		h.Logger.Printf("[DEBUG] agent: Check '%v' is %s but is flapping, suppressing the status change",
+			checkID, status)
+		if h.damped != status {
+			h.count("suppressed", status)
+		}
+		h.damped = status
+		return
+	}
+
+	h.damped = ""
+	h.reported = status
+	h.Notify.UpdateCheck(checkID, status, output)
+}
+
+// count increments the given counter for a status that started being held
+// back. This is labeled by status rather than by check to keep the number of
+// series bounded.
+func (h *StatusHandler) count(name, status string) {
+	if h.incrCounter != nil {
+		h.incrCounter(name, status)
+		return
+	}
+	labels := []metrics.Label{{Name: "status", Value: status}}
+	metrics.IncrCounterWithLabels([]string{"consul", "agent", "check", name}, 1, labels)
+	metrics.IncrCounterWithLabels([]string{"agent", "check", name}, 1, labels)
+}
+
+// belowThreshold counts the given status towards the consecutive result
+// thresholds and returns true if it would change the reported status before
+// the threshold for it is met.
+func (h *StatusHandler) belowThreshold(status string) bool {
+	if status == api.HealthCritical {
+		h.failures++
+		h.successes = 0
+		return h.reported != api.HealthCritical && h.failures < h.FailuresBeforeCritical
+	}
+	h.successes++
+	h.failures = 0
+	return h.reported == api.HealthCritical && h.successes < h.SuccessBeforePassing
+}
+
+// damp updates the flap penalty of the check with the given status and
+// returns true if reporting the status should be suppressed.
+func (h *StatusHandler) damp(checkID types.CheckID, status string) bool {
+	now := time.Now()
+	if h.now != nil {
+		now = h.now()
+	}
+
+	// Decay the penalty for the time since it was last updated, and add
+	// to it if the status changed.
+	if !h.penaltyTime.IsZero() {
+		elapsed := now.Sub(h.penaltyTime)
+		h.penalty *= math.Exp2(-float64(elapsed) / float64(h.FlapHalfLife))
+	}
+	h.penaltyTime = now
+	if status != h.last {
+		h.last = status
+		h.penalty += flapPenalty
+	}
+
+	suppressed := !h.suppressedSince.IsZero()
+	switch {
+	case !suppressed && h.penalty >= flapSuppressLimit:
+		h.suppressedSince = now
+		h.Logger.Printf("[WARN] agent: Check '%v' is flapping, suppressing status changes", checkID)
+
+	case suppressed && h.penalty < flapReuseLimit:
+		h.suppressedSince = time.Time{}
+		h.Logger.Printf("[INFO] agent: Check '%v' stopped flapping, resuming status changes", checkID)
+
+	case suppressed && h.FlapSuppressWindow > 0 && now.Sub(h.suppressedSince) >= h.FlapSuppressWindow:
+		// Start over so a check that keeps flapping gets to report its
+		// status for a while before it's suppressed again.
+		h.suppressedSince = time.Time{}
+		h.penalty = 0
+		h.Logger.Printf("[INFO] agent: Check '%v' reached the end of its suppression window, resuming status changes", checkID)
+	}
+	return !h.suppressedSince.IsZero() && status != h.reported
+}
+
+// PendingStatus returns the status the check has returned but that hasn't
+// met its consecutive result threshold yet, if any.
+func (h *StatusHandler) PendingStatus() string {
+	h.Lock()
+	defer h.Unlock()
+	return h.pending
+}
+
+// Suppressed returns true if flap damping is holding back the status
+// changes of the check.
+func (h *StatusHandler) Suppressed() bool {
+	h.Lock()
+	defer h.Unlock()
+	return !h.suppressedSince.IsZero()
+}
@@ -0,0 +1,213 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// grpcHealthCheckPath is the path of the grpc.health.v1.Health/Check
+	// method, which gRPC calls with a POST over HTTP/2.
+	grpcHealthCheckPath = "/grpc.health.v1.Health/Check"
+
+	// grpcServing is the SERVING value of the status field of the
+	// grpc.health.v1.HealthCheckResponse message.
+	grpcServing = 1
+)
+
+// grpcServingStatuses names the values of the status field of the
+// grpc.health.v1.HealthCheckResponse message.
+var grpcServingStatuses = map[uint64]string{
+	0: "UNKNOWN",
+	1: "SERVING",
+	2: "NOT_SERVING",
+	3: "SERVICE_UNKNOWN",
+}
+
+// grpcHealthProbe connects to a gRPC application and queries its health
+// using the standard grpc.health.v1.Health/Check protocol. The few bytes of
+// protobuf the protocol needs are encoded by hand, and the call is made
+// over HTTP/2 with the standard library's client.
+type grpcHealthProbe struct {
+	url     string
+	request []byte
+	timeout time.Duration
+	client  *http.Client
+}
+
+// newGrpcHealthProbe returns a probe for the given target, which is of the
+// form "host:port" or "host:port/service". If a service is given then its
+// health is queried, otherwise the overall health of the server is. If the
+// TLS config is nil then the connection will be made in plain text.
+func newGrpcHealthProbe(target string, timeout time.Duration, tlsConfig *tls.Config) *grpcHealthProbe {
+	server, service := target, ""
+	if idx := strings.Index(target, "/"); idx != -1 {
+		server, service = target[:idx], target[idx+1:]
+	}
+
+	// gRPC servers only speak HTTP/2, so don't fall back to HTTP/1.1, and
+	// speak it without an upgrade when there's no TLS.
+	scheme := "https"
+	protocols := new(http.Protocols)
+	if tlsConfig != nil {
+		protocols.SetHTTP2(true)
+	} else {
+		scheme = "http"
+		protocols.SetUnencryptedHTTP2(true)
+	}
+	transport := &http.Transport{
+		Protocols:         protocols,
+		TLSClientConfig:   tlsConfig,
+		DisableKeepAlives: true,
+	}
+
+	return &grpcHealthProbe{
+		url:     scheme + "://" + server + grpcHealthCheckPath,
+		request: grpcMessage(grpcHealthCheckRequest(service)),
+		timeout: timeout,
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   timeout,
+		},
+	}
+}
+
+// Check performs a single health check against the server. It returns an
+// error if the server couldn't be reached or if it reported any status other
+// than SERVING.
+func (p *grpcHealthProbe) Check() error {
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+
+	req, err := http.NewRequest("POST", p.url, bytes.NewReader(p.request))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("TE", "trailers")
+	req.Header.Set("User-Agent", UserAgent)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("gRPC server returned HTTP status %s", resp.Status)
+	}
+
+	// The body has to be read before the trailers are available. The
+	// status of the call is in the trailers, unless the server only sent
+	// headers.
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	header := resp.Trailer
+	if header.Get("Grpc-Status") == "" {
+		header = resp.Header
+	}
+	if code := header.Get("Grpc-Status"); code != "0" {
+		return fmt.Errorf("gRPC call failed with status %s: %s", code, header.Get("Grpc-Message"))
+	}
+
+	status, err := grpcHealthCheckResponse(body)
+	if err != nil {
+		return err
+	}
+	if status != grpcServing {
+		name, ok := grpcServingStatuses[status]
+		if !ok {
+			name = fmt.Sprintf("%d", status)
+		}
+		return fmt.Errorf("gRPC server reported status %s", name)
+	}
+	return nil
+}
+
+// grpcMessage prefixes a protobuf message with the header gRPC sends before
+// each message, which is an uncompressed flag and the message length.
+func grpcMessage(msg []byte) []byte {
+	buf := make([]byte, 5+len(msg))
+	binary.BigEndian.PutUint32(buf[1:5], uint32(len(msg)))
+	copy(buf[5:], msg)
+	return buf
+}
+
+// grpcHealthCheckRequest encodes a grpc.health.v1.HealthCheckRequest
+// message, whose only field is the service name with number 1.
+func grpcHealthCheckRequest(service string) []byte {
+	if service == "" {
+		return nil
+	}
+	buf := make([]byte, 1+binary.MaxVarintLen64+len(service))
+	buf[0] = 1<<3 | 2 // field 1, length delimited
+	n := 1 + binary.PutUvarint(buf[1:], uint64(len(service)))
+	n += copy(buf[n:], service)
+	return buf[:n]
+}
+
+// grpcHealthCheckResponse decodes the status field with number 1 from the
+// body of a grpc.health.v1.Health/Check response, skipping any other
+// fields. A missing status is UNKNOWN, like protobuf's default.
+func grpcHealthCheckResponse(body []byte) (uint64, error) {
+	if len(body) < 5 {
+		return 0, fmt.Errorf("gRPC response too short")
+	}
+	if body[0] != 0 {
+		return 0, fmt.Errorf("gRPC response is compressed")
+	}
+	size := binary.BigEndian.Uint32(body[1:5])
+	if uint64(len(body)-5) < uint64(size) {
+		return 0, fmt.Errorf("gRPC response truncated")
+	}
+	msg := body[5 : 5+size]
+
+	var status uint64
+	for len(msg) > 0 {
+		key, n := binary.Uvarint(msg)
+		if n <= 0 {
+			return 0, fmt.Errorf("invalid gRPC response")
+		}
+		msg = msg[n:]
+
+		var skip uint64
+		switch key & 7 {
+		case 0: // varint
+			v, n := binary.Uvarint(msg)
+			if n <= 0 {
+				return 0, fmt.Errorf("invalid gRPC response")
+			}
+			if key>>3 == 1 {
+				status = v
+			}
+			msg = msg[n:]
+		case 1: // 64 bit
+			skip = 8
+		case 2: // length delimited
+			l, n := binary.Uvarint(msg)
+			if n <= 0 {
+				return 0, fmt.Errorf("invalid gRPC response")
+			}
+			msg = msg[n:]
+			skip = l
+		case 5: // 32 bit
+			skip = 4
+		default:
+			return 0, fmt.Errorf("invalid gRPC response")
+		}
+		if uint64(len(msg)) < skip {
+			return 0, fmt.Errorf("invalid gRPC response")
+		}
+		msg = msg[skip:]
+	}
+	return status, nil
+}
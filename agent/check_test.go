@@ -2,6 +2,7 @@ package agent
 
 import (
 	"bytes"
+	"encoding/binary"
 	"fmt"
 	"io/ioutil"
 	"log"
@@ -544,6 +545,123 @@ func TestCheckTCPPassing(t *testing.T) {
 	tcpServer.Close()
 }
 
+// mockGRPCServer serves the grpc.health.v1.Health/Check method over
+// unencrypted HTTP/2, reporting the given statuses for the services. Other
+// services get the NOT_FOUND gRPC status, like from a real health server.
+func mockGRPCServer(statuses map[string]uint64) *httptest.Server {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != grpcHealthCheckPath {
+			http.NotFound(w, r)
+			return
+		}
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil || len(body) < 5 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		// The request is empty or only has the service name.
+		var service string
+		if msg := body[5:]; len(msg) > 0 {
+			l, n := binary.Uvarint(msg[1:])
+			service = string(msg[1+n : 1+n+int(l)])
+		}
+
+		w.Header().Set("Content-Type", "application/grpc")
+		status, ok := statuses[service]
+		if !ok {
+			w.Header().Set("Grpc-Status", "5")
+			w.Header().Set("Grpc-Message", "unknown service")
+			return
+		}
+		w.Write(grpcMessage([]byte{1 << 3, byte(status)}))
+		w.Header().Set(http.TrailerPrefix+"Grpc-Status", "0")
+	}))
+	server.Config.Protocols = new(http.Protocols)
+	server.Config.Protocols.SetUnencryptedHTTP2(true)
+	server.Start()
+	return server
+}
+
+func expectGRPCStatus(t *testing.T, target string, status string) {
+	notif := mock.NewNotify()
+	check := &CheckGRPC{
+		Notify:   notif,
+		CheckID:  types.CheckID("foo"),
+		GRPC:     target,
+		Interval: 10 * time.Millisecond,
+		Logger:   log.New(ioutil.Discard, UniqueID(), log.LstdFlags),
+	}
+	check.Start()
+	defer check.Stop()
+	retry.Run(t, func(r *retry.R) {
+		if got, want := notif.Updates("foo"), 2; got < want {
+			r.Fatalf("got %d updates want at least %d", got, want)
+		}
+		if got, want := notif.State("foo"), status; got != want {
+			r.Fatalf("got state %q want %q", got, want)
+		}
+	})
+}
+
+func TestCheckGRPC(t *testing.T) {
+	t.Parallel()
+	server := mockGRPCServer(map[string]uint64{
+		"":     grpcServing,
+		"up":   grpcServing,
+		"down": 2,
+	})
+	defer server.Close()
+	addr := server.Listener.Addr().String()
+
+	tests := []struct {
+		desc   string
+		target string
+		status string
+	}{
+		{"server", addr, api.HealthPassing},
+		{"serving service", addr + "/up", api.HealthPassing},
+		{"not serving service", addr + "/down", api.HealthCritical},
+		{"unknown service", addr + "/nope", api.HealthCritical},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			expectGRPCStatus(t, tt.target, tt.status)
+		})
+	}
+}
+
+func TestCheckGRPC_Unreachable(t *testing.T) {
+	t.Parallel()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	expectGRPCStatus(t, addr, api.HealthCritical)
+}
+
+func TestCheckGRPC_Timeout(t *testing.T) {
+	t.Parallel()
+	server := mockGRPCServer(map[string]uint64{"": grpcServing})
+	defer server.Close()
+
+	check := &CheckGRPC{
+		GRPC:     server.Listener.Addr().String(),
+		Interval: 10 * time.Second,
+		Timeout:  time.Second,
+		Logger:   log.New(ioutil.Discard, UniqueID(), log.LstdFlags),
+	}
+	check.Start()
+	defer check.Stop()
+
+	if got, want := check.probe.timeout, time.Second; got != want {
+		t.Fatalf("got timeout %v want %v", got, want)
+	}
+}
+
 func TestCheck_Docker(t *testing.T) {
 	tests := []struct {
 		desc     string
@@ -829,3 +947,162 @@ func TestCheck_Docker(t *testing.T) {
 		})
 	}
 }
+
+func TestStatusHandler_Thresholds(t *testing.T) {
+	t.Parallel()
+	notif := mock.NewNotify()
+	h := &StatusHandler{
+		Notify:                 notif,
+		Logger:                 log.New(ioutil.Discard, UniqueID(), log.LstdFlags),
+		Status:                 api.HealthPassing,
+		SuccessBeforePassing:   2,
+		FailuresBeforeCritical: 3,
+	}
+	var counted []string
+	h.incrCounter = func(name, status string) {
+		counted = append(counted, name+"/"+status)
+	}
+
+	// Failures are held back until there are enough in a row.
+	h.UpdateCheck("foo", api.HealthCritical, "")
+	h.UpdateCheck("foo", api.HealthPassing, "")
+	h.UpdateCheck("foo", api.HealthCritical, "")
+	h.UpdateCheck("foo", api.HealthCritical, "")
+	if got, want := notif.Updates("foo"), 1; got != want {
+		t.Fatalf("got %d updates want %d", got, want)
+	}
+	if got, want := h.PendingStatus(), api.HealthCritical; got != want {
+		t.Fatalf("got pending status %q want %q", got, want)
+	}
+	h.UpdateCheck("foo", api.HealthCritical, "down")
+	if got, want := notif.State("foo"), api.HealthCritical; got != want {
+		t.Fatalf("got state %q want %q", got, want)
+	}
+	if got, want := h.PendingStatus(), ""; got != want {
+		t.Fatalf("got pending status %q want %q", got, want)
+	}
+
+	// Further failures go straight through.
+	h.UpdateCheck("foo", api.HealthCritical, "still down")
+	if got, want := notif.Output("foo"), "still down"; got != want {
+		t.Fatalf("got output %q want %q", got, want)
+	}
+
+	// So do successes once there are enough in a row, and changes
+	// between passing and warning after that.
+	h.UpdateCheck("foo", api.HealthWarning, "")
+	if got, want := notif.State("foo"), api.HealthCritical; got != want {
+		t.Fatalf("got state %q want %q", got, want)
+	}
+	if got, want := h.PendingStatus(), api.HealthWarning; got != want {
+		t.Fatalf("got pending status %q want %q", got, want)
+	}
+	h.UpdateCheck("foo", api.HealthWarning, "")
+	if got, want := notif.State("foo"), api.HealthWarning; got != want {
+		t.Fatalf("got state %q want %q", got, want)
+	}
+	h.UpdateCheck("foo", api.HealthPassing, "")
+	if got, want := notif.State("foo"), api.HealthPassing; got != want {
+		t.Fatalf("got state %q want %q", got, want)
+	}
+
+	// Each status that was held back is only counted once.
+	want := []string{"pending/critical", "pending/critical", "pending/warning"}
+	if !reflect.DeepEqual(counted, want) {
+		t.Fatalf("got counts %v want %v", counted, want)
+	}
+}
+
+func TestStatusHandler_FlapDamping(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	notif := mock.NewNotify()
+	h := &StatusHandler{
+		Notify:       notif,
+		Logger:       log.New(ioutil.Discard, UniqueID(), log.LstdFlags),
+		Status:       api.HealthPassing,
+		FlapHalfLife: time.Minute,
+		now:          func() time.Time { return now },
+	}
+	var counted []string
+	h.incrCounter = func(name, status string) {
+		counted = append(counted, name+"/"+status)
+	}
+	update := func(after time.Duration, status string) {
+		now = now.Add(after)
+		h.UpdateCheck("foo", status, "")
+	}
+
+	// The first changes go through, until the penalty builds up.
+	update(0, api.HealthCritical)
+	update(time.Second, api.HealthPassing)
+	update(time.Second, api.HealthCritical)
+	if got, want := notif.Updates("foo"), 3; got != want {
+		t.Fatalf("got %d updates want %d", got, want)
+	}
+	if h.Suppressed() {
+		t.Fatalf("should not be suppressed")
+	}
+	update(time.Second, api.HealthPassing)
+	update(time.Second, api.HealthPassing)
+	if got, want := notif.State("foo"), api.HealthCritical; got != want {
+		t.Fatalf("got state %q want %q", got, want)
+	}
+	if got, want := notif.Updates("foo"), 3; got != want {
+		t.Fatalf("got %d updates want %d", got, want)
+	}
+	if !h.Suppressed() {
+		t.Fatalf("should be suppressed")
+	}
+	if want := []string{"suppressed/passing"}; !reflect.DeepEqual(counted, want) {
+		t.Fatalf("got counts %v want %v", counted, want)
+	}
+
+	// Once the penalty decays below the reuse limit, the status goes
+	// through again.
+	update(2*time.Minute, api.HealthPassing)
+	if got, want := notif.State("foo"), api.HealthPassing; got != want {
+		t.Fatalf("got state %q want %q", got, want)
+	}
+	if h.Suppressed() {
+		t.Fatalf("should not be suppressed")
+	}
+}
+
+func TestStatusHandler_FlapSuppressWindow(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	notif := mock.NewNotify()
+	h := &StatusHandler{
+		Notify:             notif,
+		Logger:             log.New(ioutil.Discard, UniqueID(), log.LstdFlags),
+		Status:             api.HealthPassing,
+		FlapHalfLife:       time.Hour,
+		FlapSuppressWindow: time.Minute,
+		now:                func() time.Time { return now },
+	}
+	update := func(after time.Duration, status string) {
+		now = now.Add(after)
+		h.UpdateCheck("foo", status, "")
+	}
+
+	// Keep flapping, which stays suppressed for no longer than the
+	// window.
+	for _, status := range []string{api.HealthCritical, api.HealthPassing, api.HealthCritical, api.HealthPassing} {
+		update(time.Second, status)
+	}
+	if !h.Suppressed() {
+		t.Fatalf("should be suppressed")
+	}
+	update(30*time.Second, api.HealthPassing)
+	if got, want := notif.State("foo"), api.HealthCritical; got != want {
+		t.Fatalf("got state %q want %q", got, want)
+	}
+	update(30*time.Second, api.HealthPassing)
+	if got, want := notif.State("foo"), api.HealthPassing; got != want {
+		t.Fatalf("got state %q want %q", got, want)
+	}
+	if h.Suppressed() {
+		t.Fatalf("should not be suppressed")
+	}
+}
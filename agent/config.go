@@ -86,6 +86,26 @@ func FixupCheckType(raw interface{}) error {
 			}
 			replace(k, "DeregisterCriticalServiceAfter", d)
 
+		case "flap_half_life", "flaphalflife":
+			d, err := parseDuration(v)
+			if err != nil {
+				return fmt.Errorf("invalid %q: %v", k, err)
+			}
+			replace(k, "FlapHalfLife", d)
+
+		case "flap_suppress_window", "flapsuppresswindow":
+			d, err := parseDuration(v)
+			if err != nil {
+				return fmt.Errorf("invalid %q: %v", k, err)
+			}
+			replace(k, "FlapSuppressWindow", d)
+
+		case "success_before_passing":
+			replace(k, "SuccessBeforePassing", v)
+
+		case "failures_before_critical":
+			replace(k, "FailuresBeforeCritical", v)
+
 		case "docker_container_id":
 			replace(k, "DockerContainerID", v)
 
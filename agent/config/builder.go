@@ -19,6 +19,7 @@ import (
 	"github.com/hashicorp/consul/ipaddr"
 	"github.com/hashicorp/consul/tlsutil"
 	"github.com/hashicorp/consul/types"
+	"github.com/hashicorp/consul/watch"
 	multierror "github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/go-sockaddr/template"
 	"golang.org/x/time/rate"
@@ -555,6 +556,7 @@ func (b *Builder) Build() (rt RuntimeConfig, err error) {
 		TelemetryAllowedPrefixes:                    telemetryAllowedPrefixes,
 		TelemetryBlockedPrefixes:                    telemetryBlockedPrefixes,
 		TelemetryMetricsPrefix:                      b.stringVal(c.Telemetry.MetricsPrefix),
+		TelemetryPrometheusRetentionTime:            b.durationVal("telemetry.prometheus_retention_time", c.Telemetry.PrometheusRetentionTime),
 		TelemetryStatsdAddr:                         b.stringVal(c.Telemetry.StatsdAddr),
 		TelemetryStatsiteAddr:                       b.stringVal(c.Telemetry.StatsiteAddr),
 
@@ -596,9 +598,15 @@ func (b *Builder) Build() (rt RuntimeConfig, err error) {
 		PidFile:                     b.stringVal(c.PidFile),
 		RPCAdvertiseAddr:            rpcAdvertiseAddr,
 		RPCBindAddr:                 rpcBindAddr,
+		RPCMaxBlockingQueries:       b.intVal(c.Limits.RPCMaxBlockingQueries),
 		RPCMaxBurst:                 b.intVal(c.Limits.RPCMaxBurst),
+		RPCMaxConnsPerClient:        b.intVal(c.Limits.RPCMaxConnsPerClient),
 		RPCProtocol:                 b.intVal(c.RPCProtocol),
 		RPCRateLimit:                rate.Limit(b.float64Val(c.Limits.RPCRate)),
+		RPCServerReadMaxBurst:       b.intVal(c.Limits.RPCServerReadMaxBurst),
+		RPCServerReadRate:           rate.Limit(b.float64Val(c.Limits.RPCServerReadRate)),
+		RPCServerWriteMaxBurst:      b.intVal(c.Limits.RPCServerWriteMaxBurst),
+		RPCServerWriteRate:          rate.Limit(b.float64Val(c.Limits.RPCServerWriteRate)),
 		RaftProtocol:                b.intVal(c.RaftProtocol),
 		ReconnectTimeoutLAN:         b.durationVal("reconnect_timeout", c.ReconnectTimeoutLAN),
 		ReconnectTimeoutWAN:         b.durationVal("reconnect_timeout_wan", c.ReconnectTimeoutWAN),
@@ -627,6 +635,7 @@ func (b *Builder) Build() (rt RuntimeConfig, err error) {
 		StartJoinAddrsWAN:           c.StartJoinAddrsWAN,
 		SyslogFacility:              b.stringVal(c.SyslogFacility),
 		TLSCipherSuites:             b.tlsCipherSuites("tls_cipher_suites", c.TLSCipherSuites),
+		TLSAutoReload:               b.boolVal(c.TLSAutoReload),
 		TLSMinVersion:               b.stringVal(c.TLSMinVersion),
 		TLSPreferServerCipherSuites: b.boolVal(c.TLSPreferServerCipherSuites),
 		TaggedAddresses:             c.TaggedAddresses,
@@ -736,6 +745,19 @@ func (b *Builder) Validate(rt RuntimeConfig) error {
 	if err := structs.ValidateMetadata(rt.NodeMeta, false); err != nil {
 		return fmt.Errorf("node_meta invalid: %v", err)
 	}
+	for _, s := range rt.Services {
+		if err := structs.ValidateServiceMetadata(s.Meta, false); err != nil {
+			return fmt.Errorf("service %q meta invalid: %v", s.Name, err)
+		}
+		if err := structs.ValidateWeights(s.Weights); err != nil {
+			return fmt.Errorf("service %q weights invalid: %v", s.Name, err)
+		}
+	}
+	for i, w := range rt.Watches {
+		if err := validateWatchHandler(w); err != nil {
+			return fmt.Errorf("watches[%d]: %v", i, err)
+		}
+	}
 	if rt.EncryptKey != "" {
 		if _, err := decodeBytes(rt.EncryptKey); err != nil {
 			return fmt.Errorf("encrypt has invalid key: %s", err)
@@ -884,6 +906,8 @@ func (b *Builder) checkVal(v *CheckDefinition) *structs.CheckDefinition {
 		Header:            v.Header,
 		Method:            b.stringVal(v.Method),
 		TCP:               b.stringVal(v.TCP),
+		GRPC:              b.stringVal(v.GRPC),
+		GRPCUseTLS:        b.boolVal(v.GRPCUseTLS),
 		Interval:          b.durationVal(fmt.Sprintf("check[%s].interval", id), v.Interval),
 		DockerContainerID: b.stringVal(v.DockerContainerID),
 		Shell:             b.stringVal(v.Shell),
@@ -891,6 +915,10 @@ func (b *Builder) checkVal(v *CheckDefinition) *structs.CheckDefinition {
 		Timeout:           b.durationVal(fmt.Sprintf("check[%s].timeout", id), v.Timeout),
 		TTL:               b.durationVal(fmt.Sprintf("check[%s].ttl", id), v.TTL),
 		DeregisterCriticalServiceAfter: b.durationVal(fmt.Sprintf("check[%s].deregister_critical_service_after", id), v.DeregisterCriticalServiceAfter),
+		SuccessBeforePassing:           b.intVal(v.SuccessBeforePassing),
+		FailuresBeforeCritical:         b.intVal(v.FailuresBeforeCritical),
+		FlapHalfLife:                   b.durationVal(fmt.Sprintf("check[%s].flap_half_life", id), v.FlapHalfLife),
+		FlapSuppressWindow:             b.durationVal(fmt.Sprintf("check[%s].flap_suppress_window", id), v.FlapSuppressWindow),
 	}
 }
 
@@ -912,13 +940,50 @@ func (b *Builder) serviceVal(v *ServiceDefinition) *structs.ServiceDefinition {
 		Name:              b.stringVal(v.Name),
 		Tags:              v.Tags,
 		Address:           b.stringVal(v.Address),
+		Meta:              v.Meta,
 		Port:              b.intVal(v.Port),
+		Weights:           b.serviceWeightsVal(v.Weights),
 		Token:             b.stringVal(v.Token),
 		EnableTagOverride: b.boolVal(v.EnableTagOverride),
 		Checks:            checks,
 	}
 }
 
+// ParseServices parses the service definitions of a config file in the given
+// format the same way the agent does when it loads its configuration. The
+// rest of the file is ignored.
+func ParseServices(data, format string) ([]*structs.ServiceDefinition, error) {
+	c, err := Parse(data, format)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &Builder{}
+	var services []*structs.ServiceDefinition
+	for _, service := range c.Services {
+		services = append(services, b.serviceVal(&service))
+	}
+	if c.Service != nil {
+		services = append(services, b.serviceVal(c.Service))
+	}
+	if b.err != nil {
+		return nil, b.err
+	}
+	return services, nil
+}
+
+// serviceWeightsVal returns the weights of a service definition. A weight
+// that isn't given defaults to 1.
+func (b *Builder) serviceWeightsVal(v *ServiceWeights) *structs.Weights {
+	if v == nil {
+		return nil
+	}
+	return &structs.Weights{
+		Passing: b.intValWithDefault(v.Passing, 1),
+		Warning: b.intValWithDefault(v.Warning, 1),
+	}
+}
+
 func (b *Builder) boolVal(v *bool) bool {
 	if v == nil {
 		return false
@@ -926,6 +991,34 @@ func (b *Builder) boolVal(v *bool) bool {
 	return *v
 }
 
+// validateWatchHandler checks the handler configuration of a watch. The
+// rest of the watch is only validated when the watch plan is created.
+func validateWatchHandler(w map[string]interface{}) error {
+	raw, ok := w["handler_type"]
+	if !ok {
+		return nil
+	}
+	handlerType, ok := raw.(string)
+	if !ok {
+		return fmt.Errorf("handler_type must be a string")
+	}
+	switch handlerType {
+	case watch.HandlerTypeScript:
+		return nil
+	case watch.HandlerTypeHTTP:
+		if _, ok := w["handler"]; ok {
+			return fmt.Errorf("handler cannot be set with handler_type %q", handlerType)
+		}
+		if _, ok := w["args"]; ok {
+			return fmt.Errorf("args cannot be set with handler_type %q", handlerType)
+		}
+		_, err := watch.ParseHTTPHandlerConfig(w["http_handler_config"])
+		return err
+	default:
+		return fmt.Errorf("handler_type must be one of %q or %q, not %q", watch.HandlerTypeScript, watch.HandlerTypeHTTP, handlerType)
+	}
+}
+
 func (b *Builder) durationVal(name string, v *string) (d time.Duration) {
 	if v == nil {
 		return 0
@@ -944,6 +1037,13 @@ func (b *Builder) intVal(v *int) int {
 	return *v
 }
 
+func (b *Builder) intValWithDefault(v *int, defaultVal int) int {
+	if v == nil {
+		return defaultVal
+	}
+	return *v
+}
+
 func (b *Builder) portVal(name string, v *int) int {
 	if v == nil || *v <= 0 {
 		return -1
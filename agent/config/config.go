@@ -202,6 +202,7 @@ type Config struct {
 	StartJoinAddrsLAN           []string                 `json:"start_join,omitempty" hcl:"start_join" mapstructure:"start_join"`
 	StartJoinAddrsWAN           []string                 `json:"start_join_wan,omitempty" hcl:"start_join_wan" mapstructure:"start_join_wan"`
 	SyslogFacility              *string                  `json:"syslog_facility,omitempty" hcl:"syslog_facility" mapstructure:"syslog_facility"`
+	TLSAutoReload               *bool                    `json:"tls_auto_reload,omitempty" hcl:"tls_auto_reload" mapstructure:"tls_auto_reload"`
 	TLSCipherSuites             *string                  `json:"tls_cipher_suites,omitempty" hcl:"tls_cipher_suites" mapstructure:"tls_cipher_suites"`
 	TLSMinVersion               *string                  `json:"tls_min_version,omitempty" hcl:"tls_min_version" mapstructure:"tls_min_version"`
 	TLSPreferServerCipherSuites *bool                    `json:"tls_prefer_server_cipher_suites,omitempty" hcl:"tls_prefer_server_cipher_suites" mapstructure:"tls_prefer_server_cipher_suites"`
@@ -296,13 +297,20 @@ type ServiceDefinition struct {
 	Name              *string           `json:"name,omitempty" hcl:"name" mapstructure:"name"`
 	Tags              []string          `json:"tags,omitempty" hcl:"tags" mapstructure:"tags"`
 	Address           *string           `json:"address,omitempty" hcl:"address" mapstructure:"address"`
+	Meta              map[string]string `json:"meta,omitempty" hcl:"meta" mapstructure:"meta"`
 	Port              *int              `json:"port,omitempty" hcl:"port" mapstructure:"port"`
+	Weights           *ServiceWeights   `json:"weights,omitempty" hcl:"weights" mapstructure:"weights"`
 	Check             *CheckDefinition  `json:"check,omitempty" hcl:"check" mapstructure:"check"`
 	Checks            []CheckDefinition `json:"checks,omitempty" hcl:"checks" mapstructure:"checks"`
 	Token             *string           `json:"token,omitempty" hcl:"token" mapstructure:"token"`
 	EnableTagOverride *bool             `json:"enable_tag_override,omitempty" hcl:"enable_tag_override" mapstructure:"enable_tag_override"`
 }
 
+type ServiceWeights struct {
+	Passing *int `json:"passing,omitempty" hcl:"passing" mapstructure:"passing"`
+	Warning *int `json:"warning,omitempty" hcl:"warning" mapstructure:"warning"`
+}
+
 type CheckDefinition struct {
 	ID                             *string             `json:"id,omitempty" hcl:"id" mapstructure:"id"`
 	CheckID                        *string             `json:"check_id,omitempty" hcl:"check_id" mapstructure:"check_id"`
@@ -317,6 +325,8 @@ type CheckDefinition struct {
 	Header                         map[string][]string `json:"header,omitempty" hcl:"header" mapstructure:"header"`
 	Method                         *string             `json:"method,omitempty" hcl:"method" mapstructure:"method"`
 	TCP                            *string             `json:"tcp,omitempty" hcl:"tcp" mapstructure:"tcp"`
+	GRPC                           *string             `json:"grpc,omitempty" hcl:"grpc" mapstructure:"grpc"`
+	GRPCUseTLS                     *bool               `json:"grpc_use_tls,omitempty" hcl:"grpc_use_tls" mapstructure:"grpc_use_tls"`
 	Interval                       *string             `json:"interval,omitempty" hcl:"interval" mapstructure:"interval"`
 	DockerContainerID              *string             `json:"docker_container_id,omitempty" hcl:"docker_container_id" mapstructure:"docker_container_id"`
 	Shell                          *string             `json:"shell,omitempty" hcl:"shell" mapstructure:"shell"`
@@ -324,6 +334,10 @@ type CheckDefinition struct {
 	Timeout                        *string             `json:"timeout,omitempty" hcl:"timeout" mapstructure:"timeout"`
 	TTL                            *string             `json:"ttl,omitempty" hcl:"ttl" mapstructure:"ttl"`
 	DeregisterCriticalServiceAfter *string             `json:"deregister_critical_service_after,omitempty" hcl:"deregister_critical_service_after" mapstructure:"deregister_critical_service_after"`
+	SuccessBeforePassing           *int                `json:"success_before_passing,omitempty" hcl:"success_before_passing" mapstructure:"success_before_passing"`
+	FailuresBeforeCritical         *int                `json:"failures_before_critical,omitempty" hcl:"failures_before_critical" mapstructure:"failures_before_critical"`
+	FlapHalfLife                   *string             `json:"flap_half_life,omitempty" hcl:"flap_half_life" mapstructure:"flap_half_life"`
+	FlapSuppressWindow             *string             `json:"flap_suppress_window,omitempty" hcl:"flap_suppress_window" mapstructure:"flap_suppress_window"`
 }
 
 type DNS struct {
@@ -367,6 +381,7 @@ type Telemetry struct {
 	FilterDefault                      *bool    `json:"filter_default,omitempty" hcl:"filter_default" mapstructure:"filter_default"`
 	PrefixFilter                       []string `json:"prefix_filter,omitempty" hcl:"prefix_filter" mapstructure:"prefix_filter"`
 	MetricsPrefix                      *string  `json:"metrics_prefix,omitempty" hcl:"metrics_prefix" mapstructure:"metrics_prefix"`
+	PrometheusRetentionTime            *string  `json:"prometheus_retention_time,omitempty" hcl:"prometheus_retention_time" mapstructure:"prometheus_retention_time"`
 	StatsdAddr                         *string  `json:"statsd_address,omitempty" hcl:"statsd_address" mapstructure:"statsd_address"`
 	StatsiteAddr                       *string  `json:"statsite_address,omitempty" hcl:"statsite_address" mapstructure:"statsite_address"`
 	EnableDeprecatedNames              *bool    `json:"enable_deprecated_names" hcl:"enable_deprecated_names" mapstructure:"enable_deprecated_names"`
@@ -412,8 +427,14 @@ type UnixSocket struct {
 }
 
 type Limits struct {
-	RPCMaxBurst *int     `json:"rpc_max_burst,omitempty" hcl:"rpc_max_burst" mapstructure:"rpc_max_burst"`
-	RPCRate     *float64 `json:"rpc_rate,omitempty" hcl:"rpc_rate" mapstructure:"rpc_rate"`
+	RPCMaxBlockingQueries  *int     `json:"rpc_max_blocking_queries,omitempty" hcl:"rpc_max_blocking_queries" mapstructure:"rpc_max_blocking_queries"`
+	RPCMaxBurst            *int     `json:"rpc_max_burst,omitempty" hcl:"rpc_max_burst" mapstructure:"rpc_max_burst"`
+	RPCMaxConnsPerClient   *int     `json:"rpc_max_conns_per_client,omitempty" hcl:"rpc_max_conns_per_client" mapstructure:"rpc_max_conns_per_client"`
+	RPCRate                *float64 `json:"rpc_rate,omitempty" hcl:"rpc_rate" mapstructure:"rpc_rate"`
+	RPCServerReadMaxBurst  *int     `json:"rpc_server_read_max_burst,omitempty" hcl:"rpc_server_read_max_burst" mapstructure:"rpc_server_read_max_burst"`
+	RPCServerReadRate      *float64 `json:"rpc_server_read_rate,omitempty" hcl:"rpc_server_read_rate" mapstructure:"rpc_server_read_rate"`
+	RPCServerWriteMaxBurst *int     `json:"rpc_server_write_max_burst,omitempty" hcl:"rpc_server_write_max_burst" mapstructure:"rpc_server_write_max_burst"`
+	RPCServerWriteRate     *float64 `json:"rpc_server_write_rate,omitempty" hcl:"rpc_server_write_rate" mapstructure:"rpc_server_write_rate"`
 }
 
 type Segment struct {
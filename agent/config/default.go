@@ -63,6 +63,12 @@ func DefaultSource() Source {
 		limits = {
 			rpc_rate = -1
 			rpc_max_burst = 1000
+			rpc_server_read_rate = -1
+			rpc_server_read_max_burst = 1000
+			rpc_server_write_rate = -1
+			rpc_server_write_max_burst = 1000
+			rpc_max_blocking_queries = 0
+			rpc_max_conns_per_client = 0
 		}
 		performance = {
 			raft_multiplier = ` + strconv.Itoa(int(consul.DefaultRaftMultiplier)) + `
@@ -103,6 +103,7 @@ type RuntimeConfig struct {
 	TelemetryAllowedPrefixes                    []string
 	TelemetryBlockedPrefixes                    []string
 	TelemetryMetricsPrefix                      string
+	TelemetryPrometheusRetentionTime            time.Duration
 	TelemetryStatsdAddr                         string
 	TelemetryStatsiteAddr                       string
 
@@ -154,9 +155,15 @@ type RuntimeConfig struct {
 	PidFile                     string
 	RPCAdvertiseAddr            *net.TCPAddr
 	RPCBindAddr                 *net.TCPAddr
+	RPCMaxBlockingQueries       int
 	RPCMaxBurst                 int
+	RPCMaxConnsPerClient        int
 	RPCProtocol                 int
 	RPCRateLimit                rate.Limit
+	RPCServerReadMaxBurst       int
+	RPCServerReadRate           rate.Limit
+	RPCServerWriteMaxBurst      int
+	RPCServerWriteRate          rate.Limit
 	RaftProtocol                int
 	ReconnectTimeoutLAN         time.Duration
 	ReconnectTimeoutWAN         time.Duration
@@ -184,6 +191,7 @@ type RuntimeConfig struct {
 	StartJoinAddrsLAN           []string
 	StartJoinAddrsWAN           []string
 	SyslogFacility              string
+	TLSAutoReload               bool
 	TLSCipherSuites             []uint16
 	TLSMinVersion               string
 	TLSPreferServerCipherSuites bool
@@ -202,8 +210,9 @@ type RuntimeConfig struct {
 }
 
 // IncomingHTTPSConfig returns the TLS configuration for HTTPS
-// connections to consul.
-func (c *RuntimeConfig) IncomingHTTPSConfig() (*tls.Config, error) {
+// connections to consul. The certificate and CAs are taken from the given
+// cert store if it isn't nil.
+func (c *RuntimeConfig) IncomingHTTPSConfig(certs *tlsutil.CertStore) (*tls.Config, error) {
 	tc := &tlsutil.Config{
 		VerifyIncoming:           c.VerifyIncoming || c.VerifyIncomingHTTPS,
 		VerifyOutgoing:           c.VerifyOutgoing,
@@ -216,6 +225,7 @@ func (c *RuntimeConfig) IncomingHTTPSConfig() (*tls.Config, error) {
 		TLSMinVersion:            c.TLSMinVersion,
 		CipherSuites:             c.TLSCipherSuites,
 		PreferServerCipherSuites: c.TLSPreferServerCipherSuites,
+		CertStore:                certs,
 	}
 	return tc.IncomingTLSConfig()
 }
@@ -1543,6 +1543,45 @@ func TestConfigFlagsAndEdgecases(t *testing.T) {
 			},
 			err: "Node metadata cannot contain more than 64 key/value pairs",
 		},
+		{
+			desc: "service meta too many keys",
+			flags: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{
+				`{ "service": { "name": "a", "meta": {` + metaPairs(70, "json") + `} } }`,
+			},
+			hcl: []string{
+				`service = { name = "a" meta = {` + metaPairs(70, "hcl") + ` } }`,
+			},
+			err: `service "a" meta invalid: Service metadata cannot contain more than 64 key/value pairs`,
+		},
+		{
+			desc: "service meta reserved prefix",
+			flags: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{
+				`{ "service": { "name": "a", "meta": { "consul-version": "1" } } }`,
+			},
+			hcl: []string{
+				`service = { name = "a" meta = { "consul-version" = "1" } }`,
+			},
+			err: "Key prefix 'consul-' is reserved for internal use",
+		},
+		{
+			desc: "service weights invalid",
+			flags: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{
+				`{ "service": { "name": "a", "weights": { "passing": 0 } } }`,
+			},
+			hcl: []string{
+				`service = { name = "a" weights = { passing = 0 } }`,
+			},
+			err: `service "a" weights invalid: Passing weight must be between 1 and 65535`,
+		},
 		{
 			desc: "unique listeners dns vs http",
 			flags: []string{
@@ -1681,6 +1720,33 @@ func TestConfigFlagsAndEdgecases(t *testing.T) {
 				rt.TelemetryBlockedPrefixes = []string{}
 			},
 		},
+		{
+			desc: "watches invalid handler_type",
+			flags: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "watches": [{ "type": "nodes", "handler_type": "email" }] }`},
+			hcl:  []string{` watches = [{ type = "nodes" handler_type = "email" }] `},
+			err:  `watches[0]: handler_type must be one of "script" or "http", not "email"`,
+		},
+		{
+			desc: "watches http handler requires path",
+			flags: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "watches": [{ "type": "nodes", "handler_type": "http", "http_handler_config": { "method": "PUT" } }] }`},
+			hcl:  []string{` watches = [{ type = "nodes" handler_type = "http" http_handler_config { method = "PUT" } }] `},
+			err:  `watches[0]: http_handler_config requires 'path' to be set`,
+		},
+		{
+			desc: "watches http handler cannot have args",
+			flags: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "watches": [{ "type": "nodes", "handler_type": "http", "args": ["foo"], "http_handler_config": { "path": "http://foo" } }] }`},
+			hcl:  []string{` watches = [{ type = "nodes" handler_type = "http" args = ["foo"] http_handler_config { path = "http://foo" } }] `},
+			err:  `watches[0]: args cannot be set with handler_type "http"`,
+		},
 		{
 			desc: "encrypt has invalid key",
 			flags: []string{
@@ -1773,6 +1839,27 @@ func TestConfigFlagsAndEdgecases(t *testing.T) {
 				rt.DataDir = dataDir
 			},
 		},
+		{
+			desc: "service weights default",
+			flags: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{
+				`{ "service": { "name": "a", "weights": { "passing": 10 } } }`,
+				`{ "service": { "name": "b", "weights": { "warning": 3 } } }`,
+			},
+			hcl: []string{
+				`service = { name = "a" weights = { passing = 10 } }`,
+				`service = { name = "b" weights = { warning = 3 } }`,
+			},
+			patch: func(rt *RuntimeConfig) {
+				rt.Services = []*structs.ServiceDefinition{
+					&structs.ServiceDefinition{Name: "a", Weights: &structs.Weights{Passing: 10, Warning: 1}},
+					&structs.ServiceDefinition{Name: "b", Weights: &structs.Weights{Passing: 1, Warning: 3}},
+				}
+				rt.DataDir = dataDir
+			},
+		},
 	}
 
 	testConfig(t, tests, dataDir)
@@ -1993,13 +2080,19 @@ func TestFullConfig(t *testing.T) {
 				},
 				"method": "Dou0nGT5",
 				"tcp": "JY6fTTcw",
+				"grpc": "qJomTEI1",
+				"grpc_use_tls": true,
 				"interval": "18714s",
 				"docker_container_id": "qF66POS9",
 				"shell": "sOnDy228",
 				"tls_skip_verify": true,
 				"timeout": "5954s",
 				"ttl": "30044s",
-				"deregister_critical_service_after": "13209s"
+				"deregister_critical_service_after": "13209s",
+				"success_before_passing": 7,
+				"failures_before_critical": 3,
+				"flap_half_life": "3291s",
+				"flap_suppress_window": "29105s"
 			},
 			"checks": [
 				{
@@ -2018,6 +2111,8 @@ func TestFullConfig(t *testing.T) {
 					},
 					"method": "aldrIQ4l",
 					"tcp": "RJQND605",
+					"grpc": "pLIix6ME",
+					"grpc_use_tls": true,
 					"interval": "22164s",
 					"docker_container_id": "ipgdFtjd",
 					"shell": "qAeOYy0M",
@@ -2042,6 +2137,8 @@ func TestFullConfig(t *testing.T) {
 					},
 					"method": "gLrztrNw",
 					"tcp": "4jG5casb",
+					"grpc": "OLeMa61E",
+					"grpc_use_tls": true,
 					"interval": "28767s",
 					"docker_container_id": "THW6u7rL",
 					"shell": "C1Zt3Zwh",
@@ -2093,7 +2190,13 @@ func TestFullConfig(t *testing.T) {
 			"leave_on_terminate": true,
 			"limits": {
 				"rpc_rate": 12029.43,
-				"rpc_max_burst": 44848
+				"rpc_max_burst": 44848,
+				"rpc_server_read_rate": 3291.22,
+				"rpc_server_read_max_burst": 27604,
+				"rpc_server_write_rate": 802.71,
+				"rpc_server_write_max_burst": 9466,
+				"rpc_max_blocking_queries": 17045,
+				"rpc_max_conns_per_client": 1371
 			},
 			"log_level": "k1zo9Spt",
 			"node_id": "AsUIlw99",
@@ -2153,6 +2256,7 @@ func TestFullConfig(t *testing.T) {
 				"address": "cOlSOhbp",
 				"token": "msy7iWER",
 				"port": 24237,
+				"meta": {"mymeta": "data-dLOXpSCI"},
 				"enable_tag_override": true,
 				"check": {
 					"check_id": "RMi85Dv8",
@@ -2168,6 +2272,8 @@ func TestFullConfig(t *testing.T) {
 					},
 					"method": "9afLm3Mj",
 					"tcp": "fjiLFqVd",
+					"grpc": "UZKC97i4",
+					"grpc_use_tls": true,
 					"interval": "23926s",
 					"docker_container_id": "dO5TtRHk",
 					"shell": "e6q2ttES",
@@ -2191,6 +2297,8 @@ func TestFullConfig(t *testing.T) {
 						},
 						"method": "T66MFBfR",
 						"tcp": "bNnNfx2A",
+						"grpc": "Xb03rEMU",
+						"grpc_use_tls": true,
 						"interval": "22224s",
 						"docker_container_id": "ipgdFtjd",
 						"shell": "omVZq7Sz",
@@ -2213,6 +2321,8 @@ func TestFullConfig(t *testing.T) {
 						},
 						"method": "ciYHWors",
 						"tcp": "FfvCwlqH",
+						"grpc": "64yTY6Bz",
+						"grpc_use_tls": true,
 						"interval": "12356s",
 						"docker_container_id": "HBndBU6R",
 						"shell": "hVI33JjA",
@@ -2231,6 +2341,7 @@ func TestFullConfig(t *testing.T) {
 					"address": "9RhqPSPB",
 					"token": "myjKJkWH",
 					"port": 72219,
+					"meta": {"mymeta": "data-wI1dzxS4"},
 					"enable_tag_override": true,
 					"check": {
 						"check_id": "qmfeO5if",
@@ -2246,6 +2357,8 @@ func TestFullConfig(t *testing.T) {
 						},
 						"method": "X5DrovFc",
 						"tcp": "ICbxkpSF",
+						"grpc": "JEzO3joO",
+						"grpc_use_tls": true,
 						"interval": "24392s",
 						"docker_container_id": "ZKXr68Yb",
 						"shell": "CEfzx0Fo",
@@ -2262,6 +2375,7 @@ func TestFullConfig(t *testing.T) {
 					"address": "R6H6g8h0",
 					"token": "ZgY8gjMI",
 					"port": 38292,
+					"meta": {"mymeta": "data-MRHVMZuD"},
 					"enable_tag_override": true,
 					"checks": [
 						{
@@ -2278,6 +2392,8 @@ func TestFullConfig(t *testing.T) {
 							},
 							"method": "5wkAxCUE",
 							"tcp": "MN3oA9D2",
+							"grpc": "j37HyVaQ",
+							"grpc_use_tls": true,
 							"interval": "32718s",
 							"docker_container_id": "cU15LMet",
 							"shell": "nEz9qz2l",
@@ -2300,6 +2416,8 @@ func TestFullConfig(t *testing.T) {
 							},
 							"method": "wzByP903",
 							"tcp": "2exjZIGE",
+							"grpc": "XekW9Lct",
+							"grpc_use_tls": true,
 							"interval": "5656s",
 							"docker_container_id": "5tDBWpfA",
 							"shell": "rlTpLM8s",
@@ -2341,9 +2459,11 @@ func TestFullConfig(t *testing.T) {
 				"prefix_filter": [ "+oJotS8XJ","-cazlEhGn" ],
 				"enable_deprecated_names": true,
 				"metrics_prefix": "ftO6DySn",
+				"prometheus_retention_time": "15s",
 				"statsd_address": "drce87cy",
 				"statsite_address": "HpFwKB8R"
 			},
+			"tls_auto_reload": true,
 			"tls_cipher_suites": "TLS_RSA_WITH_RC4_128_SHA,TLS_RSA_WITH_3DES_EDE_CBC_SHA",
 			"tls_min_version": "pAOWafkR",
 			"tls_prefer_server_cipher_suites": true,
@@ -2371,6 +2491,17 @@ func TestFullConfig(t *testing.T) {
 					"datacenter": "fYrl3F5d",
 					"key": "sl3Dffu7",
 					"args": ["dltjDJ2a", "flEa7C2d"]
+				}, {
+					"type": "service",
+					"service": "rFmn9qbV",
+					"handler_type": "http",
+					"http_handler_config": {
+						"path": "https://ZzLk52DV/lYT8LUpa",
+						"method": "PUT",
+						"timeout": "7s",
+						"header": { "JNwlBUFk": ["0Gwn2TfT"] },
+						"tls_skip_verify": true
+					}
 				}
 			]
 		}`,
@@ -2424,6 +2555,8 @@ func TestFullConfig(t *testing.T) {
 				}
 				method = "Dou0nGT5"
 				tcp = "JY6fTTcw"
+				grpc = "qJomTEI1"
+				grpc_use_tls = true
 				interval = "18714s"
 				docker_container_id = "qF66POS9"
 				shell = "sOnDy228"
@@ -2431,6 +2564,10 @@ func TestFullConfig(t *testing.T) {
 				timeout = "5954s"
 				ttl = "30044s"
 				deregister_critical_service_after = "13209s"
+				success_before_passing = 7
+				failures_before_critical = 3
+				flap_half_life = "3291s"
+				flap_suppress_window = "29105s"
 			},
 			checks = [
 				{
@@ -2449,6 +2586,8 @@ func TestFullConfig(t *testing.T) {
 					}
 					method = "aldrIQ4l"
 					tcp = "RJQND605"
+					grpc = "pLIix6ME"
+					grpc_use_tls = true
 					interval = "22164s"
 					docker_container_id = "ipgdFtjd"
 					shell = "qAeOYy0M"
@@ -2473,6 +2612,8 @@ func TestFullConfig(t *testing.T) {
 					}
 					method = "gLrztrNw"
 					tcp = "4jG5casb"
+					grpc = "OLeMa61E"
+					grpc_use_tls = true
 					interval = "28767s"
 					docker_container_id = "THW6u7rL"
 					shell = "C1Zt3Zwh"
@@ -2525,6 +2666,12 @@ func TestFullConfig(t *testing.T) {
 			limits {
 				rpc_rate = 12029.43
 				rpc_max_burst = 44848
+				rpc_server_read_rate = 3291.22
+				rpc_server_read_max_burst = 27604
+				rpc_server_write_rate = 802.71
+				rpc_server_write_max_burst = 9466
+				rpc_max_blocking_queries = 17045
+				rpc_max_conns_per_client = 1371
 			}
 			log_level = "k1zo9Spt"
 			node_id = "AsUIlw99"
@@ -2584,6 +2731,7 @@ func TestFullConfig(t *testing.T) {
 				address = "cOlSOhbp"
 				token = "msy7iWER"
 				port = 24237
+				meta = { mymeta = "data-dLOXpSCI" }
 				enable_tag_override = true
 				check = {
 					check_id = "RMi85Dv8"
@@ -2599,6 +2747,8 @@ func TestFullConfig(t *testing.T) {
 					}
 					method = "9afLm3Mj"
 					tcp = "fjiLFqVd"
+					grpc = "UZKC97i4"
+					grpc_use_tls = true
 					interval = "23926s"
 					docker_container_id = "dO5TtRHk"
 					shell = "e6q2ttES"
@@ -2622,6 +2772,8 @@ func TestFullConfig(t *testing.T) {
 						}
 						method = "T66MFBfR"
 						tcp = "bNnNfx2A"
+						grpc = "Xb03rEMU"
+						grpc_use_tls = true
 						interval = "22224s"
 						docker_container_id = "ipgdFtjd"
 						shell = "omVZq7Sz"
@@ -2644,6 +2796,8 @@ func TestFullConfig(t *testing.T) {
 						}
 						method = "ciYHWors"
 						tcp = "FfvCwlqH"
+						grpc = "64yTY6Bz"
+						grpc_use_tls = true
 						interval = "12356s"
 						docker_container_id = "HBndBU6R"
 						shell = "hVI33JjA"
@@ -2662,6 +2816,7 @@ func TestFullConfig(t *testing.T) {
 					address = "9RhqPSPB"
 					token = "myjKJkWH"
 					port = 72219
+					meta = { mymeta = "data-wI1dzxS4" }
 					enable_tag_override = true
 					check = {
 						check_id = "qmfeO5if"
@@ -2677,6 +2832,8 @@ func TestFullConfig(t *testing.T) {
 						}
 						method = "X5DrovFc"
 						tcp = "ICbxkpSF"
+						grpc = "JEzO3joO"
+						grpc_use_tls = true
 						interval = "24392s"
 						docker_container_id = "ZKXr68Yb"
 						shell = "CEfzx0Fo"
@@ -2693,6 +2850,7 @@ func TestFullConfig(t *testing.T) {
 					address = "R6H6g8h0"
 					token = "ZgY8gjMI"
 					port = 38292
+					meta = { mymeta = "data-MRHVMZuD" }
 					enable_tag_override = true
 					checks = [
 						{
@@ -2709,6 +2867,8 @@ func TestFullConfig(t *testing.T) {
 							}
 							method = "5wkAxCUE"
 							tcp = "MN3oA9D2"
+							grpc = "j37HyVaQ"
+							grpc_use_tls = true
 							interval = "32718s"
 							docker_container_id = "cU15LMet"
 							shell = "nEz9qz2l"
@@ -2731,6 +2891,8 @@ func TestFullConfig(t *testing.T) {
 							}
 							method = "wzByP903"
 							tcp = "2exjZIGE"
+							grpc = "XekW9Lct"
+							grpc_use_tls = true
 							interval = "5656s"
 							docker_container_id = "5tDBWpfA"
 							shell = "rlTpLM8s"
@@ -2772,9 +2934,11 @@ func TestFullConfig(t *testing.T) {
 				prefix_filter = [ "+oJotS8XJ","-cazlEhGn" ]
 				enable_deprecated_names = true
 				metrics_prefix = "ftO6DySn"
+				prometheus_retention_time = "15s"
 				statsd_address = "drce87cy"
 				statsite_address = "HpFwKB8R"
 			}
+			tls_auto_reload = true
 			tls_cipher_suites = "TLS_RSA_WITH_RC4_128_SHA,TLS_RSA_WITH_3DES_EDE_CBC_SHA"
 			tls_min_version = "pAOWafkR"
 			tls_prefer_server_cipher_suites = true
@@ -2801,6 +2965,17 @@ func TestFullConfig(t *testing.T) {
 				datacenter = "fYrl3F5d"
 				key = "sl3Dffu7"
 				args = ["dltjDJ2a", "flEa7C2d"]
+			}, {
+				type = "service"
+				service = "rFmn9qbV"
+				handler_type = "http"
+				http_handler_config {
+					path = "https://ZzLk52DV/lYT8LUpa"
+					method = "PUT"
+					timeout = "7s"
+					header = { JNwlBUFk = ["0Gwn2TfT"] }
+					tls_skip_verify = true
+				}
 			}]
 		`}
 
@@ -2992,6 +3167,8 @@ func TestFullConfig(t *testing.T) {
 				},
 				Method:            "aldrIQ4l",
 				TCP:               "RJQND605",
+				GRPC:              "pLIix6ME",
+				GRPCUseTLS:        true,
 				Interval:          22164 * time.Second,
 				DockerContainerID: "ipgdFtjd",
 				Shell:             "qAeOYy0M",
@@ -3016,6 +3193,8 @@ func TestFullConfig(t *testing.T) {
 				},
 				Method:            "gLrztrNw",
 				TCP:               "4jG5casb",
+				GRPC:              "OLeMa61E",
+				GRPCUseTLS:        true,
 				Interval:          28767 * time.Second,
 				DockerContainerID: "THW6u7rL",
 				Shell:             "C1Zt3Zwh",
@@ -3040,6 +3219,8 @@ func TestFullConfig(t *testing.T) {
 				},
 				Method:            "Dou0nGT5",
 				TCP:               "JY6fTTcw",
+				GRPC:              "qJomTEI1",
+				GRPCUseTLS:        true,
 				Interval:          18714 * time.Second,
 				DockerContainerID: "qF66POS9",
 				Shell:             "sOnDy228",
@@ -3047,6 +3228,10 @@ func TestFullConfig(t *testing.T) {
 				Timeout:           5954 * time.Second,
 				TTL:               30044 * time.Second,
 				DeregisterCriticalServiceAfter: 13209 * time.Second,
+				SuccessBeforePassing:           7,
+				FailuresBeforeCritical:         3,
+				FlapHalfLife:                   3291 * time.Second,
+				FlapSuppressWindow:             29105 * time.Second,
 			},
 		},
 		CheckUpdateInterval:       16507 * time.Second,
@@ -3100,6 +3285,12 @@ func TestFullConfig(t *testing.T) {
 		RPCProtocol:               30793,
 		RPCRateLimit:              12029.43,
 		RPCMaxBurst:               44848,
+		RPCServerReadRate:         3291.22,
+		RPCServerReadMaxBurst:     27604,
+		RPCServerWriteRate:        802.71,
+		RPCServerWriteMaxBurst:    9466,
+		RPCMaxBlockingQueries:     17045,
+		RPCMaxConnsPerClient:      1371,
 		RaftProtocol:              19016,
 		ReconnectTimeoutLAN:       23739 * time.Second,
 		ReconnectTimeoutWAN:       26694 * time.Second,
@@ -3138,6 +3329,7 @@ func TestFullConfig(t *testing.T) {
 				Address:           "9RhqPSPB",
 				Token:             "myjKJkWH",
 				Port:              72219,
+				Meta:              map[string]string{"mymeta": "data-wI1dzxS4"},
 				EnableTagOverride: true,
 				Checks: []*structs.CheckType{
 					&structs.CheckType{
@@ -3154,6 +3346,8 @@ func TestFullConfig(t *testing.T) {
 						},
 						Method:            "X5DrovFc",
 						TCP:               "ICbxkpSF",
+						GRPC:              "JEzO3joO",
+						GRPCUseTLS:        true,
 						Interval:          24392 * time.Second,
 						DockerContainerID: "ZKXr68Yb",
 						Shell:             "CEfzx0Fo",
@@ -3171,6 +3365,7 @@ func TestFullConfig(t *testing.T) {
 				Address:           "R6H6g8h0",
 				Token:             "ZgY8gjMI",
 				Port:              38292,
+				Meta:              map[string]string{"mymeta": "data-MRHVMZuD"},
 				EnableTagOverride: true,
 				Checks: structs.CheckTypes{
 					&structs.CheckType{
@@ -3187,6 +3382,8 @@ func TestFullConfig(t *testing.T) {
 						},
 						Method:            "5wkAxCUE",
 						TCP:               "MN3oA9D2",
+						GRPC:              "j37HyVaQ",
+						GRPCUseTLS:        true,
 						Interval:          32718 * time.Second,
 						DockerContainerID: "cU15LMet",
 						Shell:             "nEz9qz2l",
@@ -3209,6 +3406,8 @@ func TestFullConfig(t *testing.T) {
 						},
 						Method:            "wzByP903",
 						TCP:               "2exjZIGE",
+						GRPC:              "XekW9Lct",
+						GRPCUseTLS:        true,
 						Interval:          5656 * time.Second,
 						DockerContainerID: "5tDBWpfA",
 						Shell:             "rlTpLM8s",
@@ -3226,6 +3425,7 @@ func TestFullConfig(t *testing.T) {
 				Address:           "cOlSOhbp",
 				Token:             "msy7iWER",
 				Port:              24237,
+				Meta:              map[string]string{"mymeta": "data-dLOXpSCI"},
 				EnableTagOverride: true,
 				Checks: structs.CheckTypes{
 					&structs.CheckType{
@@ -3242,6 +3442,8 @@ func TestFullConfig(t *testing.T) {
 						},
 						Method:            "T66MFBfR",
 						TCP:               "bNnNfx2A",
+						GRPC:              "Xb03rEMU",
+						GRPCUseTLS:        true,
 						Interval:          22224 * time.Second,
 						DockerContainerID: "ipgdFtjd",
 						Shell:             "omVZq7Sz",
@@ -3264,6 +3466,8 @@ func TestFullConfig(t *testing.T) {
 						},
 						Method:            "ciYHWors",
 						TCP:               "FfvCwlqH",
+						GRPC:              "64yTY6Bz",
+						GRPCUseTLS:        true,
 						Interval:          12356 * time.Second,
 						DockerContainerID: "HBndBU6R",
 						Shell:             "hVI33JjA",
@@ -3286,6 +3490,8 @@ func TestFullConfig(t *testing.T) {
 						},
 						Method:            "9afLm3Mj",
 						TCP:               "fjiLFqVd",
+						GRPC:              "UZKC97i4",
+						GRPCUseTLS:        true,
 						Interval:          23926 * time.Second,
 						DockerContainerID: "dO5TtRHk",
 						Shell:             "e6q2ttES",
@@ -3326,8 +3532,10 @@ func TestFullConfig(t *testing.T) {
 		TelemetryAllowedPrefixes:                    []string{"oJotS8XJ", "consul.consul"},
 		TelemetryBlockedPrefixes:                    []string{"cazlEhGn"},
 		TelemetryMetricsPrefix:                      "ftO6DySn",
+		TelemetryPrometheusRetentionTime:            15 * time.Second,
 		TelemetryStatsdAddr:                         "drce87cy",
 		TelemetryStatsiteAddr:                       "HpFwKB8R",
+		TLSAutoReload:                               true,
 		TLSCipherSuites:                             []uint16{tls.TLS_RSA_WITH_RC4_128_SHA, tls.TLS_RSA_WITH_3DES_EDE_CBC_SHA},
 		TLSMinVersion:                               "pAOWafkR",
 		TLSPreferServerCipherSuites:                 true,
@@ -3360,6 +3568,18 @@ func TestFullConfig(t *testing.T) {
 				"key":        "sl3Dffu7",
 				"args":       []interface{}{"dltjDJ2a", "flEa7C2d"},
 			},
+			map[string]interface{}{
+				"type":         "service",
+				"service":      "rFmn9qbV",
+				"handler_type": "http",
+				"http_handler_config": map[string]interface{}{
+					"path":            "https://ZzLk52DV/lYT8LUpa",
+					"method":          "PUT",
+					"timeout":         "7s",
+					"header":          map[string]interface{}{"JNwlBUFk": []interface{}{"0Gwn2TfT"}},
+					"tls_skip_verify": true,
+				},
+			},
 		},
 	}
 
@@ -3615,6 +3835,62 @@ func TestConfigDecodeBytes(t *testing.T) {
 	}
 }
 
+func TestParseServices(t *testing.T) {
+	t.Parallel()
+	hcl := `
+		service {
+			name = "web"
+			port = 80
+			check {
+				id = "web-http"
+				http = "http://localhost/health"
+				interval = "10s"
+			}
+		}
+		services = [
+			{
+				id = "db1"
+				name = "db"
+				tags = ["primary"]
+			}
+		]
+		node_name = "ignored"
+	`
+	services, err := ParseServices(hcl, "hcl")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	want := []*structs.ServiceDefinition{
+		{
+			ID:   "db1",
+			Name: "db",
+			Tags: []string{"primary"},
+		},
+		{
+			Name: "web",
+			Port: 80,
+			Checks: structs.CheckTypes{
+				{
+					CheckID:  "web-http",
+					HTTP:     "http://localhost/health",
+					Interval: 10 * time.Second,
+				},
+			},
+		},
+	}
+	verify.Values(t, "", services, want)
+
+	// Bad fields and values are errors.
+	for _, data := range []string{
+		`{"service": {"name": "web", "nope": 1}}`,
+		`{"service": {"name": "web", "check": {"ttl": "nope"}}}`,
+	} {
+		if _, err := ParseServices(data, "json"); err == nil {
+			t.Fatalf("%s: should fail", data)
+		}
+	}
+}
+
 func TestSanitize(t *testing.T) {
 	rt := RuntimeConfig{
 		BindAddr:             &net.IPAddr{IP: net.ParseIP("127.0.0.1")},
@@ -3687,6 +3963,11 @@ func TestSanitize(t *testing.T) {
         {
             "DeregisterCriticalServiceAfter": "0s",
             "DockerContainerID": "",
+            "FailuresBeforeCritical": 0,
+            "FlapHalfLife": "0s",
+            "FlapSuppressWindow": "0s",
+            "GRPC": "",
+            "GRPCUseTLS": false,
             "HTTP": "",
             "Header": {},
             "ID": "",
@@ -3699,6 +3980,7 @@ func TestSanitize(t *testing.T) {
             "ServiceID": "",
             "Shell": "",
             "Status": "",
+            "SuccessBeforePassing": 0,
             "TCP": "",
             "TLSSkipVerify": false,
             "TTL": "0s",
@@ -3774,9 +4056,15 @@ func TestSanitize(t *testing.T) {
     "PidFile": "",
     "RPCAdvertiseAddr": "",
     "RPCBindAddr": "",
+    "RPCMaxBlockingQueries": 0,
     "RPCMaxBurst": 0,
+    "RPCMaxConnsPerClient": 0,
     "RPCProtocol": 0,
     "RPCRateLimit": 0,
+    "RPCServerReadMaxBurst": 0,
+    "RPCServerReadRate": 0,
+    "RPCServerWriteMaxBurst": 0,
+    "RPCServerWriteRate": 0,
     "RaftProtocol": 0,
     "ReconnectTimeoutLAN": "0s",
     "ReconnectTimeoutWAN": "0s",
@@ -3812,6 +4100,11 @@ func TestSanitize(t *testing.T) {
                 "CheckID": "",
                 "DeregisterCriticalServiceAfter": "0s",
                 "DockerContainerID": "",
+                "FailuresBeforeCritical": 0,
+                "FlapHalfLife": "0s",
+                "FlapSuppressWindow": "0s",
+                "GRPC": "",
+                "GRPCUseTLS": false,
                 "HTTP": "",
                 "Header": {},
                 "Interval": "0s",
@@ -3822,6 +4115,7 @@ func TestSanitize(t *testing.T) {
                 "ScriptArgs": [],
                 "Shell": "",
                 "Status": "",
+                "SuccessBeforePassing": 0,
                 "TCP": "",
                 "TLSSkipVerify": false,
                 "TTL": "0s",
@@ -3830,10 +4124,12 @@ func TestSanitize(t *testing.T) {
             "Checks": [],
             "EnableTagOverride": false,
             "ID": "",
+            "Meta": {},
             "Name": "foo",
             "Port": 0,
             "Tags": [],
-            "Token": "hidden"
+            "Token": "hidden",
+            "Weights": null
         }
     ],
     "SessionTTLMin": "0s",
@@ -3843,6 +4139,7 @@ func TestSanitize(t *testing.T) {
     "SyncCoordinateIntervalMin": "0s",
     "SyncCoordinateRateTarget": 0,
     "SyslogFacility": "",
+    "TLSAutoReload": false,
     "TLSCipherSuites": [],
     "TLSMinVersion": "",
     "TLSPreferServerCipherSuites": false,
@@ -3867,6 +4164,7 @@ func TestSanitize(t *testing.T) {
     "TelemetryDogstatsdTags": [],
     "TelemetryFilterDefault": false,
     "TelemetryMetricsPrefix": "",
+    "TelemetryPrometheusRetentionTime": "0s",
     "TelemetryStatsdAddr": "",
     "TelemetryStatsiteAddr": "",
     "TranslateWANAddrs": false,
@@ -40,7 +40,16 @@ type aclCacheEntry struct {
 // for an ACL if we take a miss. This goes directly to the state store, so it
 // assumes its running in the ACL datacenter, or in a non-ACL datacenter when
 // using its replicated ACLs during an outage.
-func (s *Server) aclLocalFault(id string) (string, string, error) {
+func (s *Server) aclLocalFault(id string) (string, []string, error) {
+	return s.aclFault(id, s.config.Datacenter)
+}
+
+// aclFault looks up the parent and the rules for an ACL as they apply in the
+// given datacenter. The rules are made up of any legacy rules embedded in the
+// token, along with the rules of all the policies linked to the token which
+// apply in the datacenter. Links to policies which no longer exist are
+// ignored.
+func (s *Server) aclFault(id, dc string) (string, []string, error) {
 	defer metrics.MeasureSince([]string{"consul", "acl", "fault"}, time.Now())
 	defer metrics.MeasureSince([]string{"acl", "fault"}, time.Now())
 
@@ -48,20 +57,35 @@ func (s *Server) aclLocalFault(id string) (string, string, error) {
 	state := s.fsm.State()
 	_, rule, err := state.ACLGet(nil, id)
 	if err != nil {
-		return "", "", err
+		return "", nil, err
 	}
 	if rule == nil {
-		return "", "", acl.ErrNotFound
+		return "", nil, acl.ErrNotFound
 	}
 
 	// Management tokens have no policy and inherit from the 'manage' root
 	// policy.
 	if rule.Type == structs.ACLTypeManagement {
-		return "manage", "", nil
+		return "manage", nil, nil
+	}
+
+	// Gather the rules of the token and its policies.
+	var rules []string
+	if rule.Rules != "" {
+		rules = append(rules, rule.Rules)
+	}
+	for _, link := range rule.Policies {
+		_, policy, err := state.ACLPolicyGet(nil, link.ID)
+		if err != nil {
+			return "", nil, err
+		}
+		if policy != nil && policy.AppliesTo(dc) {
+			rules = append(rules, policy.Rules)
+		}
 	}
 
 	// Otherwise use the default policy.
-	return s.config.ACLDefaultPolicy, rule.Rules, nil
+	return s.config.ACLDefaultPolicy, rules, nil
 }
 
 // resolveToken is the primary interface used by ACL-checkers (such as an
@@ -168,13 +192,14 @@ func (c *aclCache) lookupACL(id, authDC string) (acl.ACL, error) {
 
 	// Attempt to refresh the policy from the ACL datacenter via an RPC.
 	args := structs.ACLPolicyRequest{
-		Datacenter: authDC,
-		ACL:        id,
+		Datacenter:       authDC,
+		ACL:              id,
+		SourceDatacenter: c.config.Datacenter,
 	}
 	if cached != nil {
 		args.ETag = cached.ETag
 	}
-	var reply structs.ACLPolicy
+	var reply structs.ACLPolicyResponse
 	err := c.rpc("ACL.GetPolicy", &args, &reply)
 	if err == nil {
 		return c.useACLPolicy(id, authDC, cached, &reply)
@@ -216,12 +241,11 @@ func (c *aclCache) lookupACL(id, authDC string) (acl.ACL, error) {
 			goto ACL_DOWN
 		}
 
-		policy, err := acl.Parse(rules, c.sentinel)
+		policy, err := parseACLRules(rules, c.sentinel)
 		if err != nil {
 			c.logger.Printf("[DEBUG] consul.acl: Failed to parse policy for replicated ACL: %v", err)
 			goto ACL_DOWN
 		}
-		policy.ID = acl.RuleID(rules)
 
 		// Fake up an ACL datacenter reply and inject it into the cache.
 		// Note we use the local TTL here, so this'll be used for that
@@ -250,8 +274,27 @@ ACL_DOWN:
 	}
 }
 
-// useACLPolicy handles an ACLPolicy response
-func (c *aclCache) useACLPolicy(id, authDC string, cached *aclCacheEntry, p *structs.ACLPolicy) (acl.ACL, error) {
+// parseACLRules parses all the given rules and merges them into a single
+// policy.
+func parseACLRules(rules []string, sentinel sentinel.Evaluator) (*acl.Policy, error) {
+	if len(rules) == 0 {
+		rules = []string{""}
+	}
+
+	policies := make([]*acl.Policy, 0, len(rules))
+	for _, r := range rules {
+		policy, err := acl.Parse(r, sentinel)
+		if err != nil {
+			return nil, err
+		}
+		policy.ID = acl.RuleID(r)
+		policies = append(policies, policy)
+	}
+	return acl.MergePolicies(policies), nil
+}
+
+// useACLPolicy handles an ACLPolicyResponse
+func (c *aclCache) useACLPolicy(id, authDC string, cached *aclCacheEntry, p *structs.ACLPolicyResponse) (acl.ACL, error) {
 	// Check if we can used the cached policy
 	if cached != nil && cached.ETag == p.ETag {
 		if p.TTL > 0 {
@@ -679,6 +722,7 @@ func vetRegisterWithACL(rule acl.ACL, subj *structs.RegisterRequest,
 				Service:           subj.Service.Service,
 				Tags:              subj.Service.Tags,
 				Address:           subj.Service.Address,
+				Meta:              subj.Service.Meta,
 				Port:              subj.Service.Port,
 				EnableTagOverride: subj.Service.EnableTagOverride,
 			}
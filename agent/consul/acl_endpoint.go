@@ -10,8 +10,14 @@ import (
 	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/go-memdb"
 	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/go-version"
 )
 
+// minACLPolicyVersion is the version all servers need to be on before ACL
+// policies can be written, since older servers can't apply the new Raft
+// operation.
+var minACLPolicyVersion = version.Must(version.NewVersion("1.0.0"))
+
 // ACL endpoint is used to manipulate ACLs
 type ACL struct {
 	srv *Server
@@ -160,6 +166,14 @@ func (a *ACL) Apply(args *structs.ACLRequest, reply *string) error {
 		return acl.ErrPermissionDenied
 	}
 
+	// Make sure the linked policies exist. This isn't done for replicated
+	// ACLs since the links are already resolved in the ACL datacenter.
+	if args.Op == structs.ACLSet {
+		if err := a.resolveTokenPolicyLinks(&args.ACL); err != nil {
+			return err
+		}
+	}
+
 	// If no ID is provided, generate a new ID. This must be done prior to
 	// appending to the Raft log, because the ID is not deterministic. Once
 	// the entry is in the log, the state update MUST be deterministic or
@@ -220,7 +234,10 @@ func (a *ACL) Get(args *structs.ACLSpecificRequest,
 
 			reply.Index = index
 			if acl != nil {
-				reply.ACLs = structs.ACLs{acl}
+				_, reply.ACLs, err = fixupTokenPolicyLinks(ws, state, structs.ACLs{acl})
+				if err != nil {
+					return err
+				}
 			} else {
 				reply.ACLs = nil
 			}
@@ -235,7 +252,7 @@ func makeACLETag(parent string, policy *acl.Policy) string {
 
 // GetPolicy is used to retrieve a compiled policy object with a TTL. Does not
 // support a blocking query.
-func (a *ACL) GetPolicy(args *structs.ACLPolicyRequest, reply *structs.ACLPolicy) error {
+func (a *ACL) GetPolicy(args *structs.ACLPolicyRequest, reply *structs.ACLPolicyResponse) error {
 	if done, err := a.srv.forward("ACL.GetPolicy", args, args, reply); done {
 		return err
 	}
@@ -245,8 +262,21 @@ func (a *ACL) GetPolicy(args *structs.ACLPolicyRequest, reply *structs.ACLPolicy
 		return acl.ErrDisabled
 	}
 
-	// Get the policy via the cache
-	parent, policy, err := a.srv.aclAuthCache.GetACLPolicy(args.ACL)
+	// Get the policy via the cache. Policies may be limited to certain
+	// datacenters, so for requests from other datacenters the policy is
+	// compiled for the requesting datacenter.
+	var parent string
+	var policy *acl.Policy
+	var err error
+	if dc := args.SourceDatacenter; dc == "" || dc == a.srv.config.Datacenter {
+		parent, policy, err = a.srv.aclAuthCache.GetACLPolicy(args.ACL)
+	} else {
+		var rules []string
+		parent, rules, err = a.srv.aclFault(args.ACL, dc)
+		if err == nil {
+			policy, err = a.srv.aclAuthCache.GetMergedPolicy(rules)
+		}
+	}
 	if err != nil {
 		return err
 	}
@@ -295,6 +325,17 @@ func (a *ACL) List(args *structs.DCSpecificRequest,
 				return err
 			}
 
+			// Policy changes are reflected in the index as well, so
+			// that ACL replication blocking on this query picks them
+			// up.
+			policyIndex, acls, err := fixupTokenPolicyLinks(ws, state, acls)
+			if err != nil {
+				return err
+			}
+			if policyIndex > index {
+				index = policyIndex
+			}
+
 			reply.Index, reply.ACLs = index, acls
 			return nil
 		})
@@ -321,3 +362,352 @@ func (a *ACL) ReplicationStatus(args *structs.DCSpecificRequest,
 	a.srv.aclReplicationStatusLock.RUnlock()
 	return nil
 }
+
+// resolveTokenPolicyLinks makes sure all the policies linked to the token
+// exist, filling in the ID of policies which are linked by name. Duplicate
+// links are removed.
+func (a *ACL) resolveTokenPolicyLinks(token *structs.ACL) error {
+	state := a.srv.fsm.State()
+	var links []structs.ACLTokenPolicyLink
+	seen := make(map[string]bool)
+	for _, link := range token.Policies {
+		var policy *structs.ACLPolicy
+		var err error
+		if link.ID != "" {
+			_, policy, err = state.ACLPolicyGet(nil, link.ID)
+		} else {
+			_, policy, err = state.ACLPolicyGetByName(nil, link.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if policy == nil {
+			if link.ID != "" {
+				return fmt.Errorf("Unknown ACL policy ID %q", link.ID)
+			}
+			return fmt.Errorf("Unknown ACL policy %q", link.Name)
+		}
+
+		if seen[policy.ID] {
+			continue
+		}
+		seen[policy.ID] = true
+		links = append(links, structs.ACLTokenPolicyLink{ID: policy.ID, Name: policy.Name})
+	}
+	token.Policies = links
+	return nil
+}
+
+// fixupTokenPolicyLinks refreshes the policy names of the links in the given
+// tokens, since policies may have been renamed after they were linked. Tokens
+// are copied before they are changed so the state store isn't modified. This
+// returns the index of the policies table.
+func fixupTokenPolicyLinks(ws memdb.WatchSet, state *state.Store, tokens structs.ACLs) (uint64, structs.ACLs, error) {
+	index, policies, err := state.ACLPolicyList(ws)
+	if err != nil {
+		return 0, nil, err
+	}
+	names := make(map[string]string, len(policies))
+	for _, policy := range policies {
+		names[policy.ID] = policy.Name
+	}
+
+	var fixed structs.ACLs
+	for _, token := range tokens {
+		if len(token.Policies) > 0 {
+			clone := *token
+			clone.Policies = make([]structs.ACLTokenPolicyLink, len(token.Policies))
+			for i, link := range token.Policies {
+				if name, ok := names[link.ID]; ok {
+					link.Name = name
+				}
+				clone.Policies[i] = link
+			}
+			token = &clone
+		}
+		fixed = append(fixed, token)
+	}
+	return index, fixed, nil
+}
+
+// TokenUpgrade is used to upgrade a legacy token, moving its embedded rules
+// into a policy which the token is linked to instead. Tokens with the same
+// rules share a single policy, so once all of them are upgraded their rules
+// can be managed in one place. Tokens which aren't legacy tokens are returned
+// as-is.
+func (a *ACL) TokenUpgrade(args *structs.ACLTokenUpgradeRequest, reply *structs.ACL) error {
+	if done, err := a.srv.forward("ACL.TokenUpgrade", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"acl", "token_upgrade"}, time.Now())
+
+	// Verify we are allowed to serve this request
+	if a.srv.config.ACLDatacenter != a.srv.config.Datacenter {
+		return acl.ErrDisabled
+	}
+
+	// Verify token is permitted to modify ACLs
+	if rule, err := a.srv.resolveToken(args.Token); err != nil {
+		return err
+	} else if rule == nil || !rule.ACLModify() {
+		return acl.ErrPermissionDenied
+	}
+
+	state := a.srv.fsm.State()
+	_, token, err := state.ACLGet(nil, args.ACL)
+	if err != nil {
+		return err
+	}
+	if token == nil {
+		return acl.ErrNotFound
+	}
+	if !token.IsLegacy() {
+		*reply = *token
+		return nil
+	}
+
+	// Look for a policy with the same rules which applies everywhere, just
+	// like the token's rules did, otherwise make one.
+	_, policies, err := state.ACLPolicyList(nil)
+	if err != nil {
+		return err
+	}
+	var policy *structs.ACLPolicy
+	for _, p := range policies {
+		if p.Rules == token.Rules && len(p.Datacenters) == 0 {
+			policy = p
+			break
+		}
+	}
+	if policy == nil {
+		id, err := a.generatePolicyID()
+		if err != nil {
+			return err
+		}
+		req := structs.ACLPolicyApplyRequest{
+			Datacenter: args.Datacenter,
+			Op:         structs.ACLSet,
+			Policy: structs.ACLPolicy{
+				ID:          id,
+				Name:        "legacy-" + id[:8],
+				Description: "Rules of upgraded legacy tokens",
+				Rules:       token.Rules,
+			},
+		}
+		var out string
+		if err := aclPolicyApplyInternal(a.srv, &req, &out); err != nil {
+			return err
+		}
+		policy = &req.Policy
+	}
+
+	// Link the token to the policy in place of its rules.
+	req := structs.ACLRequest{
+		Datacenter: args.Datacenter,
+		Op:         structs.ACLSet,
+		ACL:        *token,
+	}
+	req.ACL.Rules = ""
+	req.ACL.Policies = []structs.ACLTokenPolicyLink{{ID: policy.ID, Name: policy.Name}}
+	var out string
+	if err := aclApplyInternal(a.srv, &req, &out); err != nil {
+		return err
+	}
+	a.srv.aclAuthCache.ClearACL(token.ID)
+	a.srv.logger.Printf("[INFO] consul.acl: Upgraded legacy token to use policy %q", policy.Name)
+
+	_, token, err = state.ACLGet(nil, args.ACL)
+	if err != nil {
+		return err
+	}
+	if token == nil {
+		return acl.ErrNotFound
+	}
+	*reply = *token
+	return nil
+}
+
+// generatePolicyID returns a new policy ID which isn't in use yet.
+func (a *ACL) generatePolicyID() (string, error) {
+	state := a.srv.fsm.State()
+	for {
+		id, err := uuid.GenerateUUID()
+		if err != nil {
+			a.srv.logger.Printf("[ERR] consul.acl: UUID generation failed: %v", err)
+			return "", err
+		}
+
+		_, policy, err := state.ACLPolicyGet(nil, id)
+		if err != nil {
+			a.srv.logger.Printf("[ERR] consul.acl: ACL policy lookup failed: %v", err)
+			return "", err
+		}
+		if policy == nil {
+			return id, nil
+		}
+	}
+}
+
+// aclPolicyApplyInternal is used to apply an ACL policy request after it has
+// been vetted that this is a valid operation. Like aclApplyInternal, this is
+// used for changes made by users as well as for ACL replication.
+func aclPolicyApplyInternal(srv *Server, args *structs.ACLPolicyApplyRequest, reply *string) error {
+	// All policies must have an ID by this point.
+	if args.Policy.ID == "" {
+		return fmt.Errorf("Missing ACL policy ID")
+	}
+
+	// Older servers would fail to apply the Raft operation, or to restore
+	// a snapshot with policies in it.
+	if !ServersMeetMinimumVersion(srv.LANMembers(), minACLPolicyVersion) {
+		return fmt.Errorf("All servers must be running version %s or later to use ACL policies", minACLPolicyVersion)
+	}
+
+	switch args.Op {
+	case structs.ACLSet:
+		if args.Policy.Name == "" {
+			return fmt.Errorf("Missing ACL policy name")
+		}
+		for _, dc := range args.Policy.Datacenters {
+			if dc == "" {
+				return fmt.Errorf("Invalid ACL policy datacenter")
+			}
+		}
+
+		// Validate the rules compile
+		_, err := acl.Parse(args.Policy.Rules, srv.sentinel)
+		if err != nil {
+			return fmt.Errorf("ACL rule compilation failed: %v", err)
+		}
+
+	case structs.ACLDelete:
+
+	default:
+		return fmt.Errorf("Invalid ACL Operation")
+	}
+
+	// Apply the update
+	resp, err := srv.raftApply(structs.ACLPolicyRequestType, args)
+	if err != nil {
+		srv.logger.Printf("[ERR] consul.acl: Policy apply failed: %v", err)
+		return err
+	}
+	if respErr, ok := resp.(error); ok {
+		return respErr
+	}
+
+	// Check if the return type is a string
+	if respString, ok := resp.(string); ok {
+		*reply = respString
+	}
+
+	return nil
+}
+
+// PolicyApply is used to create, update or delete an ACL policy.
+func (a *ACL) PolicyApply(args *structs.ACLPolicyApplyRequest, reply *string) error {
+	if done, err := a.srv.forward("ACL.PolicyApply", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"acl", "policy_apply"}, time.Now())
+
+	// Verify we are allowed to serve this request
+	if a.srv.config.ACLDatacenter != a.srv.config.Datacenter {
+		return acl.ErrDisabled
+	}
+
+	// Verify token is permitted to modify ACLs
+	if rule, err := a.srv.resolveToken(args.Token); err != nil {
+		return err
+	} else if rule == nil || !rule.ACLModify() {
+		return acl.ErrPermissionDenied
+	}
+
+	// If no ID is provided, generate a new ID. This must be done prior to
+	// appending to the Raft log, because the ID is not deterministic.
+	if args.Op == structs.ACLSet && args.Policy.ID == "" {
+		var err error
+		if args.Policy.ID, err = a.generatePolicyID(); err != nil {
+			return err
+		}
+	}
+
+	// Do the apply now that this update is vetted.
+	if err := aclPolicyApplyInternal(a.srv, args, reply); err != nil {
+		return err
+	}
+
+	// The policy might be linked to any number of tokens, so all the
+	// cached ACLs need to be compiled again.
+	a.srv.aclAuthCache.Purge()
+	return nil
+}
+
+// PolicyGet is used to retrieve a single ACL policy.
+func (a *ACL) PolicyGet(args *structs.ACLPolicySpecificRequest,
+	reply *structs.IndexedACLPolicies) error {
+	if done, err := a.srv.forward("ACL.PolicyGet", args, args, reply); done {
+		return err
+	}
+
+	// Verify we are allowed to serve this request
+	if a.srv.config.ACLDatacenter != a.srv.config.Datacenter {
+		return acl.ErrDisabled
+	}
+
+	// Verify token is permitted to list ACLs
+	if rule, err := a.srv.resolveToken(args.Token); err != nil {
+		return err
+	} else if rule == nil || !rule.ACLList() {
+		return acl.ErrPermissionDenied
+	}
+
+	return a.srv.blockingQuery(&args.QueryOptions,
+		&reply.QueryMeta,
+		func(ws memdb.WatchSet, state *state.Store) error {
+			index, policy, err := state.ACLPolicyGet(ws, args.PolicyID)
+			if err != nil {
+				return err
+			}
+
+			reply.Index = index
+			if policy != nil {
+				reply.Policies = structs.ACLPolicies{policy}
+			} else {
+				reply.Policies = nil
+			}
+			return nil
+		})
+}
+
+// PolicyList is used to list all the ACL policies.
+func (a *ACL) PolicyList(args *structs.DCSpecificRequest,
+	reply *structs.IndexedACLPolicies) error {
+	if done, err := a.srv.forward("ACL.PolicyList", args, args, reply); done {
+		return err
+	}
+
+	// Verify we are allowed to serve this request
+	if a.srv.config.ACLDatacenter != a.srv.config.Datacenter {
+		return acl.ErrDisabled
+	}
+
+	// Verify token is permitted to list ACLs
+	if rule, err := a.srv.resolveToken(args.Token); err != nil {
+		return err
+	} else if rule == nil || !rule.ACLList() {
+		return acl.ErrPermissionDenied
+	}
+
+	return a.srv.blockingQuery(&args.QueryOptions,
+		&reply.QueryMeta,
+		func(ws memdb.WatchSet, state *state.Store) error {
+			index, policies, err := state.ACLPolicyList(ws)
+			if err != nil {
+				return err
+			}
+
+			reply.Index, reply.Policies = index, policies
+			return nil
+		})
+}
@@ -1,7 +1,10 @@
 package consul
 
 import (
+	"fmt"
 	"os"
+	"reflect"
+	"sort"
 	"strings"
 	"testing"
 	"time"
@@ -421,7 +424,7 @@ func TestACLEndpoint_GetPolicy(t *testing.T) {
 		Datacenter: "dc1",
 		ACL:        out,
 	}
-	var acls structs.ACLPolicy
+	var acls structs.ACLPolicyResponse
 	if err := msgpackrpc.CallWithCodec(codec, "ACL.GetPolicy", &getR, &acls); err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -435,7 +438,7 @@ func TestACLEndpoint_GetPolicy(t *testing.T) {
 
 	// Do a conditional lookup with etag
 	getR.ETag = acls.ETag
-	var out2 structs.ACLPolicy
+	var out2 structs.ACLPolicyResponse
 	if err := msgpackrpc.CallWithCodec(codec, "ACL.GetPolicy", &getR, &out2); err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -562,3 +565,419 @@ func TestACLEndpoint_ReplicationStatus(t *testing.T) {
 		}
 	})
 }
+
+func TestACLEndpoint_PolicyApply(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.Build = "1.0.0"
+		c.ACLMasterToken = "root"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	arg := structs.ACLPolicyApplyRequest{
+		Datacenter: "dc1",
+		Op:         structs.ACLSet,
+		Policy: structs.ACLPolicy{
+			Name:        "web",
+			Description: "Web servers",
+			Rules:       testACLPolicy,
+			Datacenters: []string{"dc1"},
+		},
+		WriteRequest: structs.WriteRequest{Token: "root"},
+	}
+
+	// A management token is required.
+	var out string
+	arg.Token = ""
+	err := msgpackrpc.CallWithCodec(codec, "ACL.PolicyApply", &arg, &out)
+	if !acl.IsErrPermissionDenied(err) {
+		t.Fatalf("err: %v", err)
+	}
+	arg.Token = "root"
+	if err := msgpackrpc.CallWithCodec(codec, "ACL.PolicyApply", &arg, &out); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	id := out
+
+	// Verify
+	getR := structs.ACLPolicySpecificRequest{
+		Datacenter:   "dc1",
+		PolicyID:     id,
+		QueryOptions: structs.QueryOptions{Token: "root"},
+	}
+	var policies structs.IndexedACLPolicies
+	if err := msgpackrpc.CallWithCodec(codec, "ACL.PolicyGet", &getR, &policies); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(policies.Policies) != 1 {
+		t.Fatalf("bad: %#v", policies)
+	}
+	p := policies.Policies[0]
+	if p.ID != id || p.Name != "web" || p.Rules != testACLPolicy || len(p.Datacenters) != 1 {
+		t.Fatalf("bad: %#v", p)
+	}
+
+	// Listing needs a management token too.
+	listR := structs.DCSpecificRequest{
+		Datacenter: "dc1",
+	}
+	err = msgpackrpc.CallWithCodec(codec, "ACL.PolicyList", &listR, &policies)
+	if !acl.IsErrPermissionDenied(err) {
+		t.Fatalf("err: %v", err)
+	}
+	listR.Token = "root"
+	if err := msgpackrpc.CallWithCodec(codec, "ACL.PolicyList", &listR, &policies); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(policies.Policies) != 1 || policies.Policies[0].ID != id {
+		t.Fatalf("bad: %#v", policies)
+	}
+
+	// Bad rules and duplicate names are rejected.
+	dup := arg
+	dup.Policy.Rules = `key "" { policy = "nope" }`
+	err = msgpackrpc.CallWithCodec(codec, "ACL.PolicyApply", &dup, &out)
+	if err == nil || !strings.Contains(err.Error(), "ACL rule compilation failed") {
+		t.Fatalf("err: %v", err)
+	}
+	dup.Policy.Rules = ""
+	err = msgpackrpc.CallWithCodec(codec, "ACL.PolicyApply", &dup, &out)
+	if err == nil || !strings.Contains(err.Error(), "already in use") {
+		t.Fatalf("err: %v", err)
+	}
+	dup.Policy.Name = ""
+	err = msgpackrpc.CallWithCodec(codec, "ACL.PolicyApply", &dup, &out)
+	if err == nil || !strings.Contains(err.Error(), "Missing ACL policy name") {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Delete it
+	arg.Op = structs.ACLDelete
+	arg.Policy.ID = id
+	if err := msgpackrpc.CallWithCodec(codec, "ACL.PolicyApply", &arg, &out); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := msgpackrpc.CallWithCodec(codec, "ACL.PolicyGet", &getR, &policies); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(policies.Policies) != 0 {
+		t.Fatalf("bad: %#v", policies)
+	}
+}
+
+func TestACLEndpoint_PolicyApply_MinVersion(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.ACLMasterToken = "root"
+		c.Build = "0.9.3"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	// Policies can't be written until all the servers know about them.
+	arg := structs.ACLPolicyApplyRequest{
+		Datacenter: "dc1",
+		Op:         structs.ACLSet,
+		Policy: structs.ACLPolicy{
+			Name:  "web",
+			Rules: testACLPolicy,
+		},
+		WriteRequest: structs.WriteRequest{Token: "root"},
+	}
+	var out string
+	err := msgpackrpc.CallWithCodec(codec, "ACL.PolicyApply", &arg, &out)
+	if err == nil || !strings.Contains(err.Error(), "version 1.0.0 or later") {
+		t.Fatalf("err: %v", err)
+	}
+
+	state := s1.fsm.State()
+	_, policies, err := state.ACLPolicyList(nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(policies) != 0 {
+		t.Fatalf("bad: %v", policies)
+	}
+}
+
+func TestACLEndpoint_Apply_PolicyLinks(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.Build = "1.0.0"
+		c.ACLMasterToken = "root"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	policyArg := structs.ACLPolicyApplyRequest{
+		Datacenter: "dc1",
+		Op:         structs.ACLSet,
+		Policy: structs.ACLPolicy{
+			Name:  "foo",
+			Rules: testACLPolicy,
+		},
+		WriteRequest: structs.WriteRequest{Token: "root"},
+	}
+	var policyID string
+	if err := msgpackrpc.CallWithCodec(codec, "ACL.PolicyApply", &policyArg, &policyID); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Unknown policies can't be linked.
+	arg := structs.ACLRequest{
+		Datacenter: "dc1",
+		Op:         structs.ACLSet,
+		ACL: structs.ACL{
+			Name:     "User token",
+			Type:     structs.ACLTypeClient,
+			Policies: []structs.ACLTokenPolicyLink{{Name: "nope"}},
+		},
+		WriteRequest: structs.WriteRequest{Token: "root"},
+	}
+	var out string
+	err := msgpackrpc.CallWithCodec(codec, "ACL.Apply", &arg, &out)
+	if err == nil || !strings.Contains(err.Error(), `Unknown ACL policy "nope"`) {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Links by name get their ID filled in, and duplicates are dropped.
+	arg.ACL.Policies = []structs.ACLTokenPolicyLink{{Name: "foo"}, {ID: policyID}}
+	if err := msgpackrpc.CallWithCodec(codec, "ACL.Apply", &arg, &out); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	_, token, err := s1.fsm.State().ACLGet(nil, out)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(token.Policies) != 1 || token.Policies[0].ID != policyID || token.Policies[0].Name != "foo" {
+		t.Fatalf("bad: %#v", token)
+	}
+
+	// The token gets the permissions of the policy.
+	rule, err := s1.resolveToken(out)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !rule.KeyRead("foo/test") || rule.KeyRead("bar") {
+		t.Fatalf("bad: %#v", rule)
+	}
+
+	// Renaming the policy shows up when reading the token, and updating
+	// its rules changes the permissions of the token.
+	policyArg.Policy.ID = policyID
+	policyArg.Policy.Name = "bar"
+	policyArg.Policy.Rules = `key "" { policy = "deny" } key "bar" { policy = "read" }`
+	if err := msgpackrpc.CallWithCodec(codec, "ACL.PolicyApply", &policyArg, &policyID); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	getR := structs.ACLSpecificRequest{
+		Datacenter: "dc1",
+		ACL:        out,
+	}
+	var acls structs.IndexedACLs
+	if err := msgpackrpc.CallWithCodec(codec, "ACL.Get", &getR, &acls); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(acls.ACLs) != 1 || acls.ACLs[0].Policies[0].Name != "bar" {
+		t.Fatalf("bad: %#v", acls)
+	}
+	if token.Policies[0].Name != "foo" {
+		t.Fatalf("state store should not be modified")
+	}
+	rule, err = s1.resolveToken(out)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if rule.KeyRead("foo/test") || !rule.KeyRead("bar") {
+		t.Fatalf("bad: %#v", rule)
+	}
+}
+
+func TestACLEndpoint_GetPolicy_Datacenters(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.Build = "1.0.0"
+		c.ACLMasterToken = "root"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	// Make a policy for each datacenter, and a token using both of them
+	// along with some legacy rules.
+	var links []structs.ACLTokenPolicyLink
+	for _, dc := range []string{"dc1", "dc2"} {
+		policyArg := structs.ACLPolicyApplyRequest{
+			Datacenter: "dc1",
+			Op:         structs.ACLSet,
+			Policy: structs.ACLPolicy{
+				Name:        dc,
+				Rules:       fmt.Sprintf(`service "%s" { policy = "write" }`, dc),
+				Datacenters: []string{dc},
+			},
+			WriteRequest: structs.WriteRequest{Token: "root"},
+		}
+		var policyID string
+		if err := msgpackrpc.CallWithCodec(codec, "ACL.PolicyApply", &policyArg, &policyID); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		links = append(links, structs.ACLTokenPolicyLink{ID: policyID})
+	}
+	arg := structs.ACLRequest{
+		Datacenter: "dc1",
+		Op:         structs.ACLSet,
+		ACL: structs.ACL{
+			Name:     "User token",
+			Type:     structs.ACLTypeClient,
+			Rules:    `service "legacy" { policy = "write" }`,
+			Policies: links,
+		},
+		WriteRequest: structs.WriteRequest{Token: "root"},
+	}
+	var id string
+	if err := msgpackrpc.CallWithCodec(codec, "ACL.Apply", &arg, &id); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	services := func(dc string) []string {
+		getR := structs.ACLPolicyRequest{
+			Datacenter:       "dc1",
+			ACL:              id,
+			SourceDatacenter: dc,
+		}
+		var reply structs.ACLPolicyResponse
+		if err := msgpackrpc.CallWithCodec(codec, "ACL.GetPolicy", &getR, &reply); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		var names []string
+		for _, sp := range reply.Policy.Services {
+			names = append(names, sp.Name)
+		}
+		sort.Strings(names)
+		return names
+	}
+	if got, want := services(""), []string{"dc1", "legacy"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v want %v", got, want)
+	}
+	if got, want := services("dc2"), []string{"dc2", "legacy"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v want %v", got, want)
+	}
+	if got, want := services("dc3"), []string{"legacy"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v want %v", got, want)
+	}
+}
+
+func TestACLEndpoint_TokenUpgrade(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.Build = "1.0.0"
+		c.ACLMasterToken = "root"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	// Make two legacy tokens with the same rules.
+	var ids []string
+	for i := 0; i < 2; i++ {
+		arg := structs.ACLRequest{
+			Datacenter: "dc1",
+			Op:         structs.ACLSet,
+			ACL: structs.ACL{
+				Name:  "User token",
+				Type:  structs.ACLTypeClient,
+				Rules: testACLPolicy,
+			},
+			WriteRequest: structs.WriteRequest{Token: "root"},
+		}
+		var id string
+		if err := msgpackrpc.CallWithCodec(codec, "ACL.Apply", &arg, &id); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		ids = append(ids, id)
+	}
+
+	// A management token is required.
+	arg := structs.ACLTokenUpgradeRequest{
+		Datacenter: "dc1",
+		ACL:        ids[0],
+	}
+	var out structs.ACL
+	err := msgpackrpc.CallWithCodec(codec, "ACL.TokenUpgrade", &arg, &out)
+	if !acl.IsErrPermissionDenied(err) {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Upgrade both tokens, which should end up sharing a policy.
+	arg.Token = "root"
+	var links []structs.ACLTokenPolicyLink
+	for _, id := range ids {
+		arg.ACL = id
+		if err := msgpackrpc.CallWithCodec(codec, "ACL.TokenUpgrade", &arg, &out); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if out.ID != id || out.Rules != "" || len(out.Policies) != 1 || out.IsLegacy() {
+			t.Fatalf("bad: %#v", out)
+		}
+		links = append(links, out.Policies[0])
+	}
+	if links[0] != links[1] || !strings.HasPrefix(links[0].Name, "legacy-") {
+		t.Fatalf("bad: %#v", links)
+	}
+	_, policies, err := s1.fsm.State().ACLPolicyList(nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(policies) != 1 || policies[0].Rules != testACLPolicy {
+		t.Fatalf("bad: %#v", policies)
+	}
+
+	// The permissions of the token stay the same.
+	rule, err := s1.resolveToken(ids[0])
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !rule.KeyRead("foo/test") || rule.KeyRead("bar") {
+		t.Fatalf("bad: %#v", rule)
+	}
+
+	// Upgrading again is a no-op.
+	arg.ACL = ids[0]
+	if err := msgpackrpc.CallWithCodec(codec, "ACL.TokenUpgrade", &arg, &out); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(out.Policies) != 1 || out.Policies[0] != links[0] {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	// Unknown tokens can't be upgraded.
+	arg.ACL = "nope"
+	err = msgpackrpc.CallWithCodec(codec, "ACL.TokenUpgrade", &arg, &out)
+	if !acl.IsErrNotFound(err) {
+		t.Fatalf("err: %v", err)
+	}
+}
@@ -136,6 +136,50 @@ func reconcileACLs(local, remote structs.ACLs, lastRemoteIndex uint64) structs.A
 	return changes
 }
 
+// reconcileACLPolicies takes the local and remote ACL policies, and produces a
+// list of changes required in order to bring the local policies into sync
+// with the remote ones. Like with reconcileACLs, lastRemoteIndex is a hint
+// that only policies modified after that index need to be compared.
+func reconcileACLPolicies(local, remote structs.ACLPolicies, lastRemoteIndex uint64) structs.ACLPolicyApplyRequests {
+	localByID := make(map[string]*structs.ACLPolicy, len(local))
+	for _, policy := range local {
+		localByID[policy.ID] = policy
+	}
+
+	// Sort the remote policies so the changes are made in a predictable
+	// order.
+	remote = append(structs.ACLPolicies(nil), remote...)
+	sort.Slice(remote, func(i, j int) bool { return remote[i].ID < remote[j].ID })
+
+	// Add or update any remote policies, leaving only the local policies
+	// which need to be deleted.
+	var changes structs.ACLPolicyApplyRequests
+	for _, r := range remote {
+		l, ok := localByID[r.ID]
+		delete(localByID, r.ID)
+		if ok && (r.ModifyIndex <= lastRemoteIndex || r.IsSame(l)) {
+			continue
+		}
+		changes = append(changes, &structs.ACLPolicyApplyRequest{
+			Op:     structs.ACLSet,
+			Policy: *r,
+		})
+	}
+
+	var deletes structs.ACLPolicyApplyRequests
+	for _, l := range localByID {
+		deletes = append(deletes, &structs.ACLPolicyApplyRequest{
+			Op:     structs.ACLDelete,
+			Policy: *l,
+		})
+	}
+	sort.Slice(deletes, func(i, j int) bool { return deletes[i].Policy.ID < deletes[j].Policy.ID })
+
+	// Deletes go first so that a policy name freed up on the remote side
+	// can be taken by another policy.
+	return append(deletes, changes...)
+}
+
 // FetchLocalACLs returns the ACLs in the local state store.
 func (s *Server) fetchLocalACLs() (structs.ACLs, error) {
 	_, local, err := s.fsm.State().ACLList(nil)
@@ -167,6 +211,55 @@ func (s *Server) fetchRemoteACLs(lastRemoteIndex uint64) (*structs.IndexedACLs,
 	return &remote, nil
 }
 
+// fetchLocalACLPolicies returns the ACL policies in the local state store.
+func (s *Server) fetchLocalACLPolicies() (structs.ACLPolicies, error) {
+	_, local, err := s.fsm.State().ACLPolicyList(nil)
+	if err != nil {
+		return nil, err
+	}
+	return local, nil
+}
+
+// fetchRemoteACLPolicies is used to get the remote set of ACL policies from
+// the ACL datacenter. This doesn't block, since the remote ACL list already
+// blocks on changes to policies as well.
+func (s *Server) fetchRemoteACLPolicies() (*structs.IndexedACLPolicies, error) {
+	defer metrics.MeasureSince([]string{"leader", "fetchRemoteACLPolicies"}, time.Now())
+
+	args := structs.DCSpecificRequest{
+		Datacenter: s.config.ACLDatacenter,
+		QueryOptions: structs.QueryOptions{
+			Token:      s.tokens.ACLReplicationToken(),
+			AllowStale: true,
+		},
+	}
+	var remote structs.IndexedACLPolicies
+	if err := s.RPC("ACL.PolicyList", &args, &remote); err != nil {
+		return nil, err
+	}
+	return &remote, nil
+}
+
+// updateLocalACLPolicies is given a list of changes to apply in order to
+// bring the local ACL policies in-line with the remote policies from the ACL
+// datacenter. This is rate limited along with the ACL changes.
+func (s *Server) updateLocalACLPolicies(changes structs.ACLPolicyApplyRequests) error {
+	defer metrics.MeasureSince([]string{"leader", "updateLocalACLPolicies"}, time.Now())
+
+	minTimePerOp := time.Second / time.Duration(s.config.ACLReplicationApplyLimit)
+	for _, change := range changes {
+		var reply string
+		start := time.Now()
+		if err := aclPolicyApplyInternal(s, change, &reply); err != nil {
+			return err
+		}
+
+		elapsed := time.Now().Sub(start)
+		time.Sleep(minTimePerOp - elapsed)
+	}
+	return nil
+}
+
 // UpdateLocalACLs is given a list of changes to apply in order to bring the
 // local ACLs in-line with the remote ACLs from the ACL datacenter.
 func (s *Server) updateLocalACLs(changes structs.ACLRequests) error {
@@ -234,6 +327,21 @@ func (s *Server) replicateACLs(lastRemoteIndex uint64) (uint64, error) {
 		lastRemoteIndex = 0
 	}
 
+	// Sync the policies first, so they are in place before any tokens
+	// which link to them.
+	remotePolicies, err := s.fetchRemoteACLPolicies()
+	if err != nil {
+		return 0, fmt.Errorf("failed to retrieve remote ACL policies: %v", err)
+	}
+	localPolicies, err := s.fetchLocalACLPolicies()
+	if err != nil {
+		return 0, fmt.Errorf("failed to retrieve local ACL policies: %v", err)
+	}
+	policyChanges := reconcileACLPolicies(localPolicies, remotePolicies.Policies, lastRemoteIndex)
+	if err := s.updateLocalACLPolicies(policyChanges); err != nil {
+		return 0, fmt.Errorf("failed to sync ACL policy changes: %v", err)
+	}
+
 	// Calculate the changes required to bring the state into sync and then
 	// apply them.
 	changes := reconcileACLs(local, remote.ACLs, lastRemoteIndex)
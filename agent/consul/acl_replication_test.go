@@ -448,3 +448,167 @@ func TestACLReplication(t *testing.T) {
 		}
 	})
 }
+
+func TestACLReplication_reconcileACLPolicies(t *testing.T) {
+	t.Parallel()
+	policy := func(id, name string, index uint64) *structs.ACLPolicy {
+		return &structs.ACLPolicy{
+			ID:   id,
+			Name: name,
+			RaftIndex: structs.RaftIndex{
+				ModifyIndex: index,
+			},
+		}
+	}
+	parseChanges := func(changes structs.ACLPolicyApplyRequests) string {
+		var ret []string
+		for _, change := range changes {
+			ret = append(ret, fmt.Sprintf("%s:%s:%s", change.Op, change.Policy.ID, change.Policy.Name))
+		}
+		return strings.Join(ret, "|")
+	}
+
+	local := structs.ACLPolicies{
+		policy("a", "web", 1),
+		policy("b", "db", 2),
+		policy("c", "api", 3),
+	}
+	remote := structs.ACLPolicies{
+		policy("d", "new", 7),
+		policy("a", "web", 1),
+		policy("c", "api-renamed", 6),
+	}
+
+	// Everything after the last remote index is compared.
+	changes := reconcileACLPolicies(local, remote, 5)
+	if got, want := parseChanges(changes), "delete:b:db|set:c:api-renamed|set:d:new"; got != want {
+		t.Fatalf("got %q want %q", got, want)
+	}
+
+	// Policies which haven't changed since the last remote index aren't
+	// compared, but missing ones are always added.
+	changes = reconcileACLPolicies(local, remote, 7)
+	if got, want := parseChanges(changes), "delete:b:db|set:d:new"; got != want {
+		t.Fatalf("got %q want %q", got, want)
+	}
+
+	// The input must not be reordered.
+	if remote[0].ID != "d" {
+		t.Fatalf("bad: %#v", remote)
+	}
+}
+
+func TestACLReplication_Policies(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.Build = "1.0.0"
+		c.ACLMasterToken = "root"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+
+	dir2, s2 := testServerWithConfig(t, func(c *Config) {
+		c.Datacenter = "dc2"
+		c.ACLDatacenter = "dc1"
+		c.Build = "1.0.0"
+		c.EnableACLReplication = true
+		c.ACLReplicationInterval = 10 * time.Millisecond
+		c.ACLReplicationApplyLimit = 1000000
+	})
+	s2.tokens.UpdateACLReplicationToken("root")
+	defer os.RemoveAll(dir2)
+	defer s2.Shutdown()
+
+	// Try to join.
+	joinWAN(t, s2, s1)
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+	testrpc.WaitForLeader(t, s1.RPC, "dc2")
+
+	// Create a policy along with a token using it.
+	policyArg := structs.ACLPolicyApplyRequest{
+		Datacenter: "dc1",
+		Op:         structs.ACLSet,
+		Policy: structs.ACLPolicy{
+			Name:  "foo",
+			Rules: testACLPolicy,
+		},
+		WriteRequest: structs.WriteRequest{Token: "root"},
+	}
+	var policyID string
+	if err := s1.RPC("ACL.PolicyApply", &policyArg, &policyID); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	arg := structs.ACLRequest{
+		Datacenter: "dc1",
+		Op:         structs.ACLSet,
+		ACL: structs.ACL{
+			Name:     "User token",
+			Type:     structs.ACLTypeClient,
+			Policies: []structs.ACLTokenPolicyLink{{Name: "foo"}},
+		},
+		WriteRequest: structs.WriteRequest{Token: "root"},
+	}
+	var id string
+	if err := s1.RPC("ACL.Apply", &arg, &id); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	checkSame := func() error {
+		_, remote, err := s1.fsm.State().ACLPolicyList(nil)
+		if err != nil {
+			return err
+		}
+		_, local, err := s2.fsm.State().ACLPolicyList(nil)
+		if err != nil {
+			return err
+		}
+		if got, want := len(remote), len(local); got != want {
+			return fmt.Errorf("got %d remote policies want %d", got, want)
+		}
+		for i, policy := range remote {
+			if !policy.IsSame(local[i]) {
+				return fmt.Errorf("policies differ")
+			}
+		}
+
+		_, token, err := s2.fsm.State().ACLGet(nil, id)
+		if err != nil {
+			return err
+		}
+		if token == nil || len(token.Policies) != 1 || token.Policies[0].ID != policyID {
+			return fmt.Errorf("token not replicated: %#v", token)
+		}
+		return nil
+	}
+	// Wait for the replica to converge.
+	retry.Run(t, func(r *retry.R) {
+		if err := checkSame(); err != nil {
+			r.Fatal(err)
+		}
+	})
+
+	// Changing just the policy should also get replicated.
+	policyArg.Policy.ID = policyID
+	policyArg.Policy.Rules = ""
+	var dontCare string
+	if err := s1.RPC("ACL.PolicyApply", &policyArg, &dontCare); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	retry.Run(t, func(r *retry.R) {
+		if err := checkSame(); err != nil {
+			r.Fatal(err)
+		}
+	})
+
+	// As well as deleting it.
+	policyArg.Op = structs.ACLDelete
+	if err := s1.RPC("ACL.PolicyApply", &policyArg, &dontCare); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	retry.Run(t, func(r *retry.R) {
+		if err := checkSame(); err != nil {
+			r.Fatal(err)
+		}
+	})
+}
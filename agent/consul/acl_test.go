@@ -755,6 +755,84 @@ func TestACL_MultiDC_Found(t *testing.T) {
 	}
 }
 
+func TestACL_MultiDC_PolicyDatacenters(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.Build = "1.0.0"
+		c.ACLMasterToken = "root"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	client := rpcClient(t, s1)
+	defer client.Close()
+
+	dir2, s2 := testServerWithConfig(t, func(c *Config) {
+		c.Datacenter = "dc2"
+		c.ACLDatacenter = "dc1" // Enable ACLs!
+		c.Build = "1.0.0"
+	})
+	defer os.RemoveAll(dir2)
+	defer s2.Shutdown()
+
+	// Try to join
+	joinWAN(t, s2, s1)
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+	testrpc.WaitForLeader(t, s1.RPC, "dc2")
+
+	// Create a policy which only applies in dc2 and a token using it
+	policyArg := structs.ACLPolicyApplyRequest{
+		Datacenter: "dc1",
+		Op:         structs.ACLSet,
+		Policy: structs.ACLPolicy{
+			Name:        "dc2-only",
+			Rules:       testACLPolicy,
+			Datacenters: []string{"dc2"},
+		},
+		WriteRequest: structs.WriteRequest{Token: "root"},
+	}
+	var policyID string
+	if err := s1.RPC("ACL.PolicyApply", &policyArg, &policyID); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	arg := structs.ACLRequest{
+		Datacenter: "dc1",
+		Op:         structs.ACLSet,
+		ACL: structs.ACL{
+			Name:     "User token",
+			Type:     structs.ACLTypeClient,
+			Policies: []structs.ACLTokenPolicyLink{{ID: policyID}},
+		},
+		WriteRequest: structs.WriteRequest{Token: "root"},
+	}
+	var id string
+	if err := s1.RPC("ACL.Apply", &arg, &id); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The policy is enforced in dc2
+	acl, err := s2.resolveToken(id)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if acl.KeyRead("bar") {
+		t.Fatalf("unexpected read")
+	}
+	if !acl.KeyRead("foo/test") {
+		t.Fatalf("unexpected failed read")
+	}
+
+	// But not in dc1, where the default policy applies
+	acl, err = s1.resolveToken(id)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !acl.KeyRead("bar") {
+		t.Fatalf("unexpected failed read")
+	}
+}
+
 func TestACL_filterHealthChecks(t *testing.T) {
 	t.Parallel()
 	// Create some health checks.
@@ -3,6 +3,7 @@ package consul
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strconv"
 	"sync"
 	"time"
@@ -22,6 +23,7 @@ type AutopilotPolicy interface {
 }
 
 func (s *Server) startAutopilot() {
+	s.setAutopilotUpgradeVoters(0)
 	s.autopilotShutdownCh = make(chan struct{})
 	s.autopilotWaitGroup = sync.WaitGroup{}
 	s.autopilotWaitGroup.Add(1)
@@ -234,6 +236,509 @@ func (s *Server) handlePromotions(voterCount int, promotions []raft.Server) (boo
 	return newServers, nil
 }
 
+// AdvancedAutopilot defines a policy for promoting non-voting servers that
+// keeps one voter in each redundancy zone if the RedundancyZoneTag is set,
+// and moves the voters to the servers on a newer version during upgrades
+// unless DisableUpgradeMigration is set. Otherwise it promotes servers like
+// BasicAutopilot does.
+type AdvancedAutopilot struct {
+	server *Server
+}
+
+// PromoteNonVoters promotes and demotes servers according to the plan for
+// the current state of the cluster.
+func (a *AdvancedAutopilot) PromoteNonVoters(autopilotConfig *structs.AutopilotConfig) error {
+	minRaftProtocol, err := ServerMinRaftProtocol(a.server.LANMembers())
+	if err != nil {
+		return fmt.Errorf("error getting server raft protocol versions: %s", err)
+	}
+
+	// If we don't meet the minimum version for non-voter features, bail early
+	if minRaftProtocol < 3 {
+		return nil
+	}
+
+	future := a.server.raft.GetConfiguration()
+	if err := future.Error(); err != nil {
+		return fmt.Errorf("failed to get raft configuration: %v", err)
+	}
+
+	servers := newAutopilotServers(autopilotConfig, future.Configuration().Servers,
+		a.server.lanServersByID(), a.server.raft.Leader(), a.server.getServerHealth)
+	plan := planAutopilot(autopilotConfig, servers, a.server.getAutopilotUpgradeVoters())
+	a.server.setAutopilotUpgradeVoters(plan.upgradeVoters)
+
+	if len(plan.promotions) > 0 {
+		var promotions []raft.Server
+		for _, server := range plan.promotions {
+			promotions = append(promotions, server.Server)
+		}
+		if plan.keepOdd {
+			voterCount := 0
+			for _, server := range servers {
+				if server.Voter {
+					voterCount++
+				}
+			}
+			_, err := a.server.handlePromotions(voterCount, promotions)
+			return err
+		}
+
+		for _, server := range promotions {
+			a.server.logger.Printf("[INFO] autopilot: Promoting server %s to voter", server.ID)
+			addFuture := a.server.raft.AddVoter(server.ID, server.Address, 0, 0)
+			if err := addFuture.Error(); err != nil {
+				return fmt.Errorf("failed to add raft peer: %v", err)
+			}
+		}
+		select {
+		case a.server.autopilotRemoveDeadCh <- struct{}{}:
+		default:
+		}
+		return nil
+	}
+
+	for _, server := range plan.demotions {
+		a.server.logger.Printf("[INFO] autopilot: Demoting server %s to non-voter", server.ID)
+		demoteFuture := a.server.raft.DemoteVoter(server.ID, 0, 0)
+		if err := demoteFuture.Error(); err != nil {
+			return fmt.Errorf("failed to demote raft peer: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// autopilotServer is a server of the Raft configuration, along with what
+// Autopilot knows about it.
+type autopilotServer struct {
+	raft.Server
+
+	// Voter is whether the server votes, or is being promoted to.
+	Voter bool
+
+	// Leader is whether the server is the leader.
+	Leader bool
+
+	// NonVoter is whether the server is configured to never vote.
+	NonVoter bool
+
+	// Healthy and Stable are from the last health check of the server.
+	Healthy bool
+	Stable  bool
+
+	// Zone is the redundancy zone of the server, if any.
+	Zone string
+
+	// Version is the version of the server for upgrade migrations. It's nil
+	// if it isn't known, which counts as older than any other version.
+	Version *version.Version
+}
+
+// newAutopilotServers returns the servers of the Raft configuration with
+// their serf members, which are looked up by ID, and their health.
+func newAutopilotServers(conf *structs.AutopilotConfig, servers []raft.Server,
+	members map[string]*metadata.Server, leader raft.ServerAddress,
+	health func(id string) *structs.ServerHealth) []*autopilotServer {
+
+	now := time.Now()
+	var out []*autopilotServer
+	for _, server := range servers {
+		srv := &autopilotServer{
+			Server: server,
+			Voter:  isVoter(server.Suffrage),
+			Leader: server.Address == leader,
+		}
+		if h := health(string(server.ID)); h != nil {
+			srv.Healthy = h.Healthy
+			srv.Stable = h.IsStable(now, conf)
+		}
+		if parts, ok := members[string(server.ID)]; ok {
+			srv.NonVoter = parts.NonVoter
+			srv.Zone, srv.Version = autopilotPlacement(conf, parts)
+		}
+		out = append(out, srv)
+	}
+	return out
+}
+
+// autopilotPlacement returns the redundancy zone and the upgrade version of
+// the server according to the config.
+func autopilotPlacement(conf *structs.AutopilotConfig, parts *metadata.Server) (string, *version.Version) {
+	var zone string
+	if conf.RedundancyZoneTag != "" {
+		zone = parts.Meta[conf.RedundancyZoneTag]
+	}
+
+	if conf.UpgradeVersionTag == "" {
+		build := parts.Build
+		return zone, &build
+	}
+	v, err := version.NewVersion(parts.Meta[conf.UpgradeVersionTag])
+	if err != nil {
+		return zone, nil
+	}
+	return zone, v
+}
+
+// autopilotPlan is what Autopilot should do with the voters, along with the
+// state of the redundancy zones and the upgrade migration it's based on.
+type autopilotPlan struct {
+	// promotions are the non-voters to promote.
+	promotions []*autopilotServer
+
+	// keepOdd is whether the promotions must keep the number of voters odd,
+	// in which case some of them may be left for later.
+	keepOdd bool
+
+	// demotions are the voters to demote. These are only planned when
+	// there's nothing to promote.
+	demotions []*autopilotServer
+
+	// zones has the state of the redundancy zones, if they're enabled.
+	zones map[string]structs.RedundancyZone
+
+	// upgrade has the state of the upgrade migration.
+	upgrade *structs.AutopilotUpgrade
+
+	// upgradeVoters is the number of voters when the upgrade migration
+	// started, or 0 if there's none.
+	upgradeVoters int
+}
+
+// planAutopilot plans the promotions and demotions of the servers.
+// upgradeVoters is the number of voters when the upgrade migration in
+// progress started, or 0 if it isn't known yet.
+func planAutopilot(conf *structs.AutopilotConfig, servers []*autopilotServer, upgradeVoters int) *autopilotPlan {
+	plan := &autopilotPlan{}
+	zoned := conf.RedundancyZoneTag != ""
+	if zoned {
+		plan.zones = autopilotZones(servers)
+	}
+
+	// Servers configured to never vote take no part in any of this.
+	var eligible []*autopilotServer
+	for _, server := range servers {
+		if !server.NonVoter {
+			eligible = append(eligible, server)
+		}
+	}
+
+	// An upgrade migration takes precedence over everything else, and the
+	// servers on the new version wait for it before they're promoted.
+	var target *version.Version
+	var required int
+	plan.upgrade, target, plan.upgradeVoters, required = autopilotUpgradeState(conf, eligible, upgradeVoters)
+	switch plan.upgrade.Status {
+	case structs.AutopilotUpgradeAwaitNewServers:
+		var others []*autopilotServer
+		for _, server := range eligible {
+			if server.Voter || !autopilotVersionEqual(server.Version, target) {
+				others = append(others, server)
+			}
+		}
+		eligible = others
+
+	case structs.AutopilotUpgradePromoting:
+		plan.promotions = autopilotUpgradePromotions(zoned, eligible, target, required)
+		return plan
+
+	case structs.AutopilotUpgradeDemoting, structs.AutopilotUpgradeLeaderTransfer:
+		for _, server := range eligible {
+			if server.Voter && !autopilotVersionEqual(server.Version, target) &&
+				(!server.Leader || plan.upgrade.Status == structs.AutopilotUpgradeLeaderTransfer) {
+				plan.demotions = append(plan.demotions, server)
+			}
+		}
+		return plan
+	}
+
+	if !zoned {
+		for _, server := range eligible {
+			if !server.Voter && server.Stable {
+				plan.promotions = append(plan.promotions, server)
+			}
+		}
+		plan.keepOdd = true
+		return plan
+	}
+
+	// Keep one voter in each zone. The servers without a zone are promoted
+	// like they are without zones once the zones are taken care of.
+	byZone := make(map[string][]*autopilotServer)
+	var names []string
+	var zoneless []*autopilotServer
+	voterCount := 0
+	for _, server := range eligible {
+		if server.Voter {
+			voterCount++
+		}
+		if server.Zone == "" {
+			if !server.Voter && server.Stable {
+				zoneless = append(zoneless, server)
+			}
+			continue
+		}
+		if _, ok := byZone[server.Zone]; !ok {
+			names = append(names, server.Zone)
+		}
+		byZone[server.Zone] = append(byZone[server.Zone], server)
+	}
+	sort.Strings(names)
+
+	var demotions []*autopilotServer
+	for _, name := range names {
+		var voters []*autopilotServer
+		var healthy, standby *autopilotServer
+		for _, server := range byZone[name] {
+			switch {
+			case server.Voter:
+				voters = append(voters, server)
+				if server.Healthy && (healthy == nil || server.Leader) {
+					healthy = server
+				}
+			case server.Stable && standby == nil:
+				standby = server
+			}
+		}
+
+		// Promote a stable server if the zone has no healthy voter. The
+		// failed voter is demoted once the new one is in.
+		if healthy == nil {
+			if standby != nil {
+				plan.promotions = append(plan.promotions, standby)
+			}
+			continue
+		}
+
+		// Demote the extra voters, keeping the leader if it's in the zone.
+		for _, server := range voters {
+			if server != healthy && !server.Leader {
+				demotions = append(demotions, server)
+			}
+		}
+	}
+	if len(plan.promotions) > 0 {
+		return plan
+	}
+
+	// Only promote as many servers without a zone as keeps the number of
+	// voters odd, so the ones left over don't hold up the demotions.
+	if voterCount%2 == 1 && len(zoneless)%2 == 1 {
+		zoneless = zoneless[:len(zoneless)-1]
+	}
+	if len(zoneless) > 0 {
+		plan.promotions = zoneless
+		return plan
+	}
+	plan.demotions = demotions
+	return plan
+}
+
+// autopilotZones returns the state of the redundancy zones of the servers.
+func autopilotZones(servers []*autopilotServer) map[string]structs.RedundancyZone {
+	zones := make(map[string]structs.RedundancyZone)
+	healthy := make(map[string]int)
+	for _, server := range servers {
+		if server.Zone == "" {
+			continue
+		}
+		zone := zones[server.Zone]
+		zone.Servers = append(zone.Servers, string(server.ID))
+		if server.Voter {
+			zone.Voters = append(zone.Voters, string(server.ID))
+		}
+		if server.Healthy {
+			healthy[server.Zone]++
+		}
+		zones[server.Zone] = zone
+	}
+	for name, zone := range zones {
+		if healthy[name] > 1 {
+			zone.FailureTolerance = healthy[name] - 1
+		}
+		zones[name] = zone
+	}
+	return zones
+}
+
+// autopilotUpgradeState returns the state of the upgrade migration for the
+// servers, along with the version they're moving to. It also returns the
+// number of voters when the migration started, given the one recorded so far,
+// and the number of voters on the new version needed before the older voters
+// are demoted.
+func autopilotUpgradeState(conf *structs.AutopilotConfig, servers []*autopilotServer,
+	upgradeVoters int) (*structs.AutopilotUpgrade, *version.Version, int, int) {
+
+	if conf.DisableUpgradeMigration {
+		return &structs.AutopilotUpgrade{Status: structs.AutopilotUpgradeDisabled}, nil, 0, 0
+	}
+
+	var target *version.Version
+	for _, server := range servers {
+		if server.Version != nil && (target == nil || server.Version.GreaterThan(target)) {
+			target = server.Version
+		}
+	}
+	upgrade := &structs.AutopilotUpgrade{Status: structs.AutopilotUpgradeIdle}
+	if target == nil {
+		return upgrade, nil, 0, 0
+	}
+	upgrade.TargetVersion = target.String()
+
+	// Count the stable servers on the target version that can replace the
+	// older voters, by zone if the zones are enabled.
+	zoned := conf.RedundancyZoneTag != ""
+	newServers := 0
+	newZones := make(map[string]bool)
+	oldZones := make(map[string]bool)
+	var oldLeader bool
+	for _, server := range servers {
+		id := string(server.ID)
+		switch {
+		case autopilotVersionEqual(server.Version, target) && server.Voter:
+			upgrade.TargetVersionVoters = append(upgrade.TargetVersionVoters, id)
+		case autopilotVersionEqual(server.Version, target):
+			upgrade.TargetVersionNonVoters = append(upgrade.TargetVersionNonVoters, id)
+		case server.Voter:
+			upgrade.OtherVersionVoters = append(upgrade.OtherVersionVoters, id)
+		default:
+			upgrade.OtherVersionNonVoters = append(upgrade.OtherVersionNonVoters, id)
+		}
+
+		if autopilotVersionEqual(server.Version, target) && server.Stable {
+			newServers++
+			newZones[server.Zone] = true
+		} else if !autopilotVersionEqual(server.Version, target) && server.Voter {
+			oldZones[server.Zone] = true
+			oldLeader = oldLeader || server.Leader
+		}
+	}
+	if len(upgrade.OtherVersionVoters) == 0 {
+		return upgrade, target, 0, 0
+	}
+
+	// The voters on the new version have to make up for all the voters
+	// from before the migration, since some of them may have been upgraded
+	// in place, and their number has to be odd. Otherwise the migration
+	// would lower the failure tolerance.
+	if upgradeVoters == 0 {
+		upgradeVoters = len(upgrade.OtherVersionVoters) + len(upgrade.TargetVersionVoters)
+	}
+	required := upgradeVoters
+	if !zoned && required%2 == 0 {
+		required++
+	}
+
+	if newServers < required {
+		upgrade.Status = structs.AutopilotUpgradeAwaitNewServers
+		return upgrade, target, upgradeVoters, required
+	}
+	if zoned {
+		for zone := range oldZones {
+			if zone != "" && !newZones[zone] {
+				upgrade.Status = structs.AutopilotUpgradeAwaitNewServers
+				return upgrade, target, upgradeVoters, required
+			}
+		}
+	}
+
+	newVoters := len(upgrade.TargetVersionVoters)
+	switch {
+	case len(autopilotUpgradePromotions(zoned, servers, target, required)) > 0:
+		upgrade.Status = structs.AutopilotUpgradePromoting
+	case newVoters < required || (!zoned && newVoters%2 == 0):
+		// The stable servers are enough, but some of the voters on the
+		// new version aren't, so wait until they recover or are replaced.
+		upgrade.Status = structs.AutopilotUpgradeAwaitNewServers
+	case oldLeader && len(upgrade.OtherVersionVoters) == 1:
+		upgrade.Status = structs.AutopilotUpgradeLeaderTransfer
+	default:
+		upgrade.Status = structs.AutopilotUpgradeDemoting
+	}
+	return upgrade, target, upgradeVoters, required
+}
+
+// autopilotUpgradePromotions returns the stable servers on the target
+// version to promote during an upgrade migration. With redundancy zones,
+// that's one in each zone without a voter on the target version. Then more
+// are promoted until there are as many voters on the target version as
+// required, keeping their number odd without zones.
+func autopilotUpgradePromotions(zoned bool, servers []*autopilotServer, target *version.Version, required int) []*autopilotServer {
+	hasVoter := make(map[string]bool)
+	voters := 0
+	for _, server := range servers {
+		if server.Voter && autopilotVersionEqual(server.Version, target) {
+			hasVoter[server.Zone] = true
+			voters++
+		}
+	}
+
+	var promotions, rest []*autopilotServer
+	for _, server := range servers {
+		if server.Voter || !server.Stable || !autopilotVersionEqual(server.Version, target) {
+			continue
+		}
+		if zoned && server.Zone != "" && !hasVoter[server.Zone] {
+			hasVoter[server.Zone] = true
+			promotions = append(promotions, server)
+			voters++
+			continue
+		}
+		rest = append(rest, server)
+	}
+	for _, server := range rest {
+		if voters >= required && (zoned || voters%2 == 1) {
+			break
+		}
+		promotions = append(promotions, server)
+		voters++
+	}
+	return promotions
+}
+
+// getAutopilotUpgradeVoters returns the number of voters when the upgrade
+// migration in progress started, or 0 if there's none.
+func (s *Server) getAutopilotUpgradeVoters() int {
+	s.autopilotUpgradeVotersLock.Lock()
+	defer s.autopilotUpgradeVotersLock.Unlock()
+	return s.autopilotUpgradeVoters
+}
+
+// setAutopilotUpgradeVoters records the number of voters when the upgrade
+// migration in progress started.
+func (s *Server) setAutopilotUpgradeVoters(voters int) {
+	s.autopilotUpgradeVotersLock.Lock()
+	defer s.autopilotUpgradeVotersLock.Unlock()
+	s.autopilotUpgradeVoters = voters
+}
+
+// autopilotVersionEqual returns whether the versions are the same, where a
+// nil version is only equal to another nil version.
+func autopilotVersionEqual(a, b *version.Version) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(b)
+}
+
+// lanServersByID returns the servers in the LAN pool that haven't left, by
+// ID.
+func (s *Server) lanServersByID() map[string]*metadata.Server {
+	servers := make(map[string]*metadata.Server)
+	for _, member := range s.LANMembers() {
+		if member.Status == serf.StatusLeft {
+			continue
+		}
+
+		valid, parts := metadata.IsConsulServer(member)
+		if valid {
+			servers[parts.ID] = parts
+		}
+	}
+	return servers
+}
+
 // serverHealthLoop monitors the health of the servers in the cluster
 func (s *Server) serverHealthLoop() {
 	// Monitor server health until shutdown
@@ -275,17 +780,7 @@ func (s *Server) updateClusterHealth() error {
 	}
 
 	// Get the the serf members which are Consul servers
-	serverMap := make(map[string]*metadata.Server)
-	for _, member := range s.LANMembers() {
-		if member.Status == serf.StatusLeft {
-			continue
-		}
-
-		valid, parts := metadata.IsConsulServer(member)
-		if valid {
-			serverMap[parts.ID] = parts
-		}
-	}
+	serverMap := s.lanServersByID()
 
 	future := s.raft.GetConfiguration()
 	if err := future.Error(); err != nil {
@@ -351,6 +846,24 @@ func (s *Server) updateClusterHealth() error {
 	}
 	clusterHealth.Healthy = healthyCount == len(servers)
 
+	// Add the state of the redundancy zones and the upgrade migration.
+	healthByID := make(map[string]*structs.ServerHealth)
+	for i := range clusterHealth.Servers {
+		health := &clusterHealth.Servers[i]
+		healthByID[health.ID] = health
+		if parts, ok := serverMap[health.ID]; ok {
+			var upgradeVersion *version.Version
+			health.RedundancyZone, upgradeVersion = autopilotPlacement(autopilotConf, parts)
+			if upgradeVersion != nil {
+				health.UpgradeVersion = upgradeVersion.String()
+			}
+		}
+	}
+	plan := planAutopilot(autopilotConf, newAutopilotServers(autopilotConf, servers, serverMap, leader,
+		func(id string) *structs.ServerHealth { return healthByID[id] }), s.getAutopilotUpgradeVoters())
+	clusterHealth.RedundancyZones = plan.zones
+	clusterHealth.Upgrade = plan.upgrade
+
 	// If we have extra healthy voters, update FailureTolerance
 	requiredQuorum := voterCount/2 + 1
 	if healthyVoterCount > requiredQuorum {
@@ -2,11 +2,15 @@ package consul
 
 import (
 	"os"
+	"reflect"
 	"testing"
 	"time"
 
+	"github.com/hashicorp/consul/agent/metadata"
+	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/testrpc"
 	"github.com/hashicorp/consul/testutil/retry"
+	"github.com/hashicorp/go-version"
 	"github.com/hashicorp/raft"
 	"github.com/hashicorp/serf/serf"
 )
@@ -263,3 +267,394 @@ func TestAutopilot_PromoteNonVoter(t *testing.T) {
 		}
 	})
 }
+
+func TestAutopilot_PromoteNonVoter_RedundancyZones(t *testing.T) {
+	t.Parallel()
+	conf := func(zone string) func(c *Config) {
+		return func(c *Config) {
+			c.Datacenter = "dc1"
+			c.Bootstrap = zone == "a"
+			c.RaftConfig.ProtocolVersion = 3
+			c.AutopilotConfig.ServerStabilizationTime = 200 * time.Millisecond
+			c.AutopilotConfig.RedundancyZoneTag = "zone"
+			c.NodeMeta = map[string]string{"zone": zone}
+			c.ServerHealthInterval = 100 * time.Millisecond
+			c.AutopilotInterval = 100 * time.Millisecond
+		}
+	}
+	dir1, s1 := testServerWithConfig(t, conf("a"))
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	dir2, s2 := testServerWithConfig(t, conf("b"))
+	defer os.RemoveAll(dir2)
+	defer s2.Shutdown()
+	joinLAN(t, s2, s1)
+
+	dir3, s3 := testServerWithConfig(t, conf("b"))
+	defer os.RemoveAll(dir3)
+	defer s3.Shutdown()
+	joinLAN(t, s3, s1)
+
+	// Only one of the servers in zone b should be promoted, even though
+	// that makes for an even number of voters.
+	retry.Run(t, func(r *retry.R) {
+		future := s1.raft.GetConfiguration()
+		if err := future.Error(); err != nil {
+			r.Fatal(err)
+		}
+
+		servers := future.Configuration().Servers
+		if len(servers) != 3 {
+			r.Fatalf("bad: %v", servers)
+		}
+		voters := 0
+		for _, server := range servers {
+			if server.Suffrage == raft.Voter {
+				voters++
+			}
+		}
+		if voters != 2 {
+			r.Fatalf("bad: %v", servers)
+		}
+
+		health := s1.getClusterHealth()
+		zone, ok := health.RedundancyZones["b"]
+		if !ok || len(zone.Servers) != 2 || len(zone.Voters) != 1 || zone.FailureTolerance != 1 {
+			r.Fatalf("bad: %#v", health.RedundancyZones)
+		}
+	})
+}
+
+// testAutopilotServer returns a server for the Autopilot plans.
+func testAutopilotServer(id string, voter, stable bool, zone, vsn string) *autopilotServer {
+	return &autopilotServer{
+		Server: raft.Server{
+			ID:      raft.ServerID(id),
+			Address: raft.ServerAddress(id),
+		},
+		Voter:   voter,
+		Healthy: stable,
+		Stable:  stable,
+		Zone:    zone,
+		Version: version.Must(version.NewVersion(vsn)),
+	}
+}
+
+// testAutopilotIDs returns the IDs of the servers.
+func testAutopilotIDs(servers []*autopilotServer) []string {
+	var ids []string
+	for _, server := range servers {
+		ids = append(ids, string(server.ID))
+	}
+	return ids
+}
+
+func TestAutopilot_planAutopilot(t *testing.T) {
+	t.Parallel()
+	leader := func(s *autopilotServer) *autopilotServer {
+		s.Leader = true
+		return s
+	}
+	nonVoter := func(s *autopilotServer) *autopilotServer {
+		s.NonVoter = true
+		return s
+	}
+	unhealthy := func(s *autopilotServer) *autopilotServer {
+		s.Healthy, s.Stable = false, false
+		return s
+	}
+
+	cases := []struct {
+		name          string
+		conf          structs.AutopilotConfig
+		servers       []*autopilotServer
+		upgradeVoters int
+		promotions    []string
+		keepOdd       bool
+		demotions     []string
+		status        string
+	}{
+		{
+			name: "basic",
+			servers: []*autopilotServer{
+				leader(testAutopilotServer("s1", true, true, "", "1.0.0")),
+				testAutopilotServer("s2", false, true, "", "1.0.0"),
+				testAutopilotServer("s3", false, false, "", "1.0.0"),
+				nonVoter(testAutopilotServer("s4", false, true, "", "1.0.0")),
+			},
+			promotions: []string{"s2"},
+			keepOdd:    true,
+			status:     structs.AutopilotUpgradeIdle,
+		},
+		{
+			name: "zones promote",
+			conf: structs.AutopilotConfig{RedundancyZoneTag: "zone"},
+			servers: []*autopilotServer{
+				leader(testAutopilotServer("s1", true, true, "a", "1.0.0")),
+				testAutopilotServer("s2", false, true, "a", "1.0.0"),
+				testAutopilotServer("s3", false, true, "b", "1.0.0"),
+				testAutopilotServer("s4", false, true, "b", "1.0.0"),
+				testAutopilotServer("s5", false, true, "", "1.0.0"),
+			},
+			promotions: []string{"s3"},
+			status:     structs.AutopilotUpgradeIdle,
+		},
+		{
+			name: "zones promote without zone",
+			conf: structs.AutopilotConfig{RedundancyZoneTag: "zone"},
+			servers: []*autopilotServer{
+				leader(testAutopilotServer("s1", true, true, "a", "1.0.0")),
+				testAutopilotServer("s2", true, true, "b", "1.0.0"),
+				testAutopilotServer("s3", false, true, "", "1.0.0"),
+				testAutopilotServer("s4", false, false, "", "1.0.0"),
+			},
+			promotions: []string{"s3"},
+			status:     structs.AutopilotUpgradeIdle,
+		},
+		{
+			name: "zones keep odd without zone",
+			conf: structs.AutopilotConfig{RedundancyZoneTag: "zone"},
+			servers: []*autopilotServer{
+				testAutopilotServer("s1", true, true, "a", "1.0.0"),
+				leader(testAutopilotServer("s2", true, true, "a", "1.0.0")),
+				testAutopilotServer("s3", true, true, "b", "1.0.0"),
+				testAutopilotServer("s4", false, true, "", "1.0.0"),
+			},
+			demotions: []string{"s1"},
+			status:    structs.AutopilotUpgradeIdle,
+		},
+		{
+			name: "zones demote extra voters",
+			conf: structs.AutopilotConfig{RedundancyZoneTag: "zone"},
+			servers: []*autopilotServer{
+				testAutopilotServer("s1", true, true, "a", "1.0.0"),
+				leader(testAutopilotServer("s2", true, true, "a", "1.0.0")),
+				testAutopilotServer("s3", true, true, "b", "1.0.0"),
+				testAutopilotServer("s4", true, true, "b", "1.0.0"),
+				testAutopilotServer("s5", true, true, "", "1.0.0"),
+			},
+			demotions: []string{"s1", "s4"},
+			status:    structs.AutopilotUpgradeIdle,
+		},
+		{
+			name: "zones replace failed voter",
+			conf: structs.AutopilotConfig{RedundancyZoneTag: "zone"},
+			servers: []*autopilotServer{
+				leader(testAutopilotServer("s1", true, true, "a", "1.0.0")),
+				unhealthy(testAutopilotServer("s2", true, true, "b", "1.0.0")),
+				testAutopilotServer("s3", false, true, "b", "1.0.0"),
+			},
+			promotions: []string{"s3"},
+			status:     structs.AutopilotUpgradeIdle,
+		},
+		{
+			name: "zones demote failed voter",
+			conf: structs.AutopilotConfig{RedundancyZoneTag: "zone"},
+			servers: []*autopilotServer{
+				leader(testAutopilotServer("s1", true, true, "a", "1.0.0")),
+				unhealthy(testAutopilotServer("s2", true, true, "b", "1.0.0")),
+				testAutopilotServer("s3", true, true, "b", "1.0.0"),
+			},
+			demotions: []string{"s2"},
+			status:    structs.AutopilotUpgradeIdle,
+		},
+		{
+			name: "upgrade await new servers",
+			servers: []*autopilotServer{
+				leader(testAutopilotServer("s1", true, true, "", "1.0.0")),
+				testAutopilotServer("s2", true, true, "", "1.0.0"),
+				testAutopilotServer("s3", true, true, "", "1.0.0"),
+				testAutopilotServer("s4", false, true, "", "1.1.0"),
+				testAutopilotServer("s5", false, true, "", "1.1.0"),
+				testAutopilotServer("s6", false, true, "", "1.0.0"),
+			},
+			promotions: []string{"s6"},
+			keepOdd:    true,
+			status:     structs.AutopilotUpgradeAwaitNewServers,
+		},
+		{
+			name: "upgrade promoting",
+			servers: []*autopilotServer{
+				leader(testAutopilotServer("s1", true, true, "", "1.0.0")),
+				testAutopilotServer("s2", true, true, "", "1.0.0"),
+				testAutopilotServer("s3", true, true, "", "1.0.0"),
+				testAutopilotServer("s4", false, true, "", "1.1.0"),
+				testAutopilotServer("s5", false, true, "", "1.1.0"),
+				testAutopilotServer("s6", false, true, "", "1.1.0"),
+			},
+			promotions: []string{"s4", "s5", "s6"},
+			status:     structs.AutopilotUpgradePromoting,
+		},
+		{
+			name: "upgrade promoting only as needed",
+			servers: []*autopilotServer{
+				leader(testAutopilotServer("s1", true, true, "", "1.0.0")),
+				testAutopilotServer("s2", true, true, "", "1.0.0"),
+				testAutopilotServer("s3", true, true, "", "1.0.0"),
+				testAutopilotServer("s4", false, true, "", "1.1.0"),
+				testAutopilotServer("s5", false, true, "", "1.1.0"),
+				testAutopilotServer("s6", false, true, "", "1.1.0"),
+				testAutopilotServer("s7", false, true, "", "1.1.0"),
+			},
+			promotions: []string{"s4", "s5", "s6"},
+			status:     structs.AutopilotUpgradePromoting,
+		},
+		{
+			name: "upgrade demoting",
+			servers: []*autopilotServer{
+				leader(testAutopilotServer("s1", true, true, "", "1.0.0")),
+				testAutopilotServer("s2", true, true, "", "1.0.0"),
+				testAutopilotServer("s3", true, true, "", "1.0.0"),
+				testAutopilotServer("s4", true, true, "", "1.1.0"),
+				testAutopilotServer("s5", true, true, "", "1.1.0"),
+				testAutopilotServer("s6", true, true, "", "1.1.0"),
+			},
+			upgradeVoters: 3,
+			demotions:     []string{"s2", "s3"},
+			status:        structs.AutopilotUpgradeDemoting,
+		},
+		{
+			name: "upgrade demoting without start",
+			servers: []*autopilotServer{
+				leader(testAutopilotServer("s1", true, true, "", "1.0.0")),
+				testAutopilotServer("s2", true, true, "", "1.0.0"),
+				testAutopilotServer("s3", true, true, "", "1.0.0"),
+				testAutopilotServer("s4", true, true, "", "1.1.0"),
+				testAutopilotServer("s5", true, true, "", "1.1.0"),
+				testAutopilotServer("s6", true, true, "", "1.1.0"),
+			},
+			keepOdd: true,
+			status:  structs.AutopilotUpgradeAwaitNewServers,
+		},
+		{
+			name: "upgrade in place",
+			servers: []*autopilotServer{
+				leader(testAutopilotServer("s1", true, true, "", "1.0.0")),
+				testAutopilotServer("s2", true, true, "", "1.1.0"),
+				testAutopilotServer("s3", true, true, "", "1.1.0"),
+			},
+			upgradeVoters: 3,
+			keepOdd:       true,
+			status:        structs.AutopilotUpgradeAwaitNewServers,
+		},
+		{
+			name: "upgrade in place with new server",
+			servers: []*autopilotServer{
+				leader(testAutopilotServer("s1", true, true, "", "1.0.0")),
+				testAutopilotServer("s2", true, true, "", "1.1.0"),
+				testAutopilotServer("s3", true, true, "", "1.1.0"),
+				testAutopilotServer("s4", false, true, "", "1.1.0"),
+			},
+			upgradeVoters: 3,
+			promotions:    []string{"s4"},
+			status:        structs.AutopilotUpgradePromoting,
+		},
+		{
+			name: "upgrade keep odd",
+			servers: []*autopilotServer{
+				leader(testAutopilotServer("s1", true, true, "", "1.0.0")),
+				testAutopilotServer("s2", true, true, "", "1.0.0"),
+				testAutopilotServer("s3", true, true, "", "1.0.0"),
+				testAutopilotServer("s4", true, true, "", "1.1.0"),
+				testAutopilotServer("s5", true, true, "", "1.1.0"),
+				testAutopilotServer("s6", true, true, "", "1.1.0"),
+				testAutopilotServer("s7", true, false, "", "1.1.0"),
+			},
+			upgradeVoters: 3,
+			keepOdd:       true,
+			status:        structs.AutopilotUpgradeAwaitNewServers,
+		},
+		{
+			name: "upgrade leader transfer",
+			servers: []*autopilotServer{
+				leader(testAutopilotServer("s1", true, true, "", "1.0.0")),
+				testAutopilotServer("s2", false, true, "", "1.0.0"),
+				testAutopilotServer("s4", true, true, "", "1.1.0"),
+				testAutopilotServer("s5", true, true, "", "1.1.0"),
+				testAutopilotServer("s6", true, true, "", "1.1.0"),
+			},
+			upgradeVoters: 3,
+			demotions:     []string{"s1"},
+			status:        structs.AutopilotUpgradeLeaderTransfer,
+		},
+		{
+			name: "upgrade with zones",
+			conf: structs.AutopilotConfig{RedundancyZoneTag: "zone"},
+			servers: []*autopilotServer{
+				leader(testAutopilotServer("s1", true, true, "a", "1.0.0")),
+				testAutopilotServer("s2", true, true, "b", "1.0.0"),
+				testAutopilotServer("s3", false, true, "a", "1.1.0"),
+				testAutopilotServer("s4", false, true, "a", "1.1.0"),
+				testAutopilotServer("s5", false, true, "b", "1.1.0"),
+			},
+			promotions: []string{"s3", "s5"},
+			status:     structs.AutopilotUpgradePromoting,
+		},
+		{
+			name: "upgrade with zones await",
+			conf: structs.AutopilotConfig{RedundancyZoneTag: "zone"},
+			servers: []*autopilotServer{
+				leader(testAutopilotServer("s1", true, true, "a", "1.0.0")),
+				testAutopilotServer("s2", true, true, "b", "1.0.0"),
+				testAutopilotServer("s3", false, true, "a", "1.1.0"),
+				testAutopilotServer("s4", false, true, "a", "1.1.0"),
+			},
+			status: structs.AutopilotUpgradeAwaitNewServers,
+		},
+		{
+			name: "upgrade disabled",
+			conf: structs.AutopilotConfig{DisableUpgradeMigration: true},
+			servers: []*autopilotServer{
+				leader(testAutopilotServer("s1", true, true, "", "1.0.0")),
+				testAutopilotServer("s2", false, true, "", "1.1.0"),
+				testAutopilotServer("s3", false, true, "", "1.1.0"),
+			},
+			promotions: []string{"s2", "s3"},
+			keepOdd:    true,
+			status:     structs.AutopilotUpgradeDisabled,
+		},
+	}
+
+	for _, tc := range cases {
+		plan := planAutopilot(&tc.conf, tc.servers, tc.upgradeVoters)
+		if got := testAutopilotIDs(plan.promotions); !reflect.DeepEqual(got, tc.promotions) {
+			t.Errorf("%s: got promotions %v want %v", tc.name, got, tc.promotions)
+		}
+		if plan.keepOdd != tc.keepOdd {
+			t.Errorf("%s: got keepOdd %v want %v", tc.name, plan.keepOdd, tc.keepOdd)
+		}
+		if got := testAutopilotIDs(plan.demotions); !reflect.DeepEqual(got, tc.demotions) {
+			t.Errorf("%s: got demotions %v want %v", tc.name, got, tc.demotions)
+		}
+		if plan.upgrade.Status != tc.status {
+			t.Errorf("%s: got status %q want %q", tc.name, plan.upgrade.Status, tc.status)
+		}
+	}
+}
+
+func TestAutopilot_autopilotPlacement(t *testing.T) {
+	t.Parallel()
+	parts := &metadata.Server{
+		Build: *version.Must(version.NewVersion("1.0.0")),
+		Meta:  map[string]string{"zone": "east1a", "build": "0.0.2"},
+	}
+
+	zone, v := autopilotPlacement(&structs.AutopilotConfig{}, parts)
+	if zone != "" || v.String() != "1.0.0" {
+		t.Fatalf("bad: %q %v", zone, v)
+	}
+
+	conf := &structs.AutopilotConfig{RedundancyZoneTag: "zone", UpgradeVersionTag: "build"}
+	zone, v = autopilotPlacement(conf, parts)
+	if zone != "east1a" || v.String() != "0.0.2" {
+		t.Fatalf("bad: %q %v", zone, v)
+	}
+
+	// A server without the version tag has no version.
+	conf.UpgradeVersionTag = "nope"
+	if _, v = autopilotPlacement(conf, parts); v != nil {
+		t.Fatalf("bad: %v", v)
+	}
+}
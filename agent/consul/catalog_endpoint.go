@@ -61,6 +61,16 @@ func (c *Catalog) Register(args *structs.RegisterRequest, reply *struct{}) error
 			return fmt.Errorf("Invalid service address")
 		}
 
+		// Verify the service metadata.
+		if err := structs.ValidateServiceMetadata(args.Service.Meta, false); err != nil {
+			return fmt.Errorf("Invalid service meta: %v", err)
+		}
+
+		// Verify the service weights.
+		if err := structs.ValidateWeights(args.Service.Weights); err != nil {
+			return fmt.Errorf("Invalid service weights: %v", err)
+		}
+
 		// Apply the ACL policy if any. The 'consul' service is excluded
 		// since it is managed automatically internally (that behavior
 		// is going away after version 0.8). We check this same policy
@@ -177,6 +187,11 @@ func (c *Catalog) ListNodes(args *structs.DCSpecificRequest, reply *structs.Inde
 		return err
 	}
 
+	filter, err := newQueryFilter(args.Filter, reply.Nodes)
+	if err != nil {
+		return err
+	}
+
 	return c.srv.blockingQuery(
 		&args.QueryOptions,
 		&reply.QueryMeta,
@@ -197,6 +212,11 @@ func (c *Catalog) ListNodes(args *structs.DCSpecificRequest, reply *structs.Inde
 			if err := c.srv.filterACL(args.Token, reply); err != nil {
 				return err
 			}
+			raw, err := filter.Execute(reply.Nodes)
+			if err != nil {
+				return err
+			}
+			reply.Nodes = raw.(structs.Nodes)
 			return c.srv.sortNodesByDistanceFrom(args.Source, reply.Nodes)
 		})
 }
@@ -239,7 +259,12 @@ func (c *Catalog) ServiceNodes(args *structs.ServiceSpecificRequest, reply *stru
 		return fmt.Errorf("Must provide service name")
 	}
 
-	err := c.srv.blockingQuery(
+	filter, err := newQueryFilter(args.Filter, reply.ServiceNodes)
+	if err != nil {
+		return err
+	}
+
+	err = c.srv.blockingQuery(
 		&args.QueryOptions,
 		&reply.QueryMeta,
 		func(ws memdb.WatchSet, state *state.Store) error {
@@ -264,9 +289,23 @@ func (c *Catalog) ServiceNodes(args *structs.ServiceSpecificRequest, reply *stru
 				}
 				reply.ServiceNodes = filtered
 			}
+			if len(args.ServiceMetaFilters) > 0 {
+				var filtered structs.ServiceNodes
+				for _, service := range reply.ServiceNodes {
+					if structs.SatisfiesMetaFilters(service.ServiceMeta, args.ServiceMetaFilters) {
+						filtered = append(filtered, service)
+					}
+				}
+				reply.ServiceNodes = filtered
+			}
 			if err := c.srv.filterACL(args.Token, reply); err != nil {
 				return err
 			}
+			raw, err := filter.Execute(reply.ServiceNodes)
+			if err != nil {
+				return err
+			}
+			reply.ServiceNodes = raw.(structs.ServiceNodes)
 			return c.srv.sortNodesByDistanceFrom(args.Source, reply.ServiceNodes)
 		})
 
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/rpc"
 	"os"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -709,6 +710,60 @@ func TestCatalog_ListNodes_NodeMetaFilter(t *testing.T) {
 	})
 }
 
+func TestCatalog_ListNodes_Filter(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	// Add a couple of nodes in different racks.
+	for i, node := range []*structs.Node{
+		&structs.Node{Node: "foo", Address: "127.0.0.1", Meta: map[string]string{"rack": "r1"}},
+		&structs.Node{Node: "bar", Address: "127.0.0.2", Meta: map[string]string{"rack": "r2"}},
+	} {
+		if err := s1.fsm.State().EnsureNode(uint64(i+1), node); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	args := structs.DCSpecificRequest{
+		Datacenter: "dc1",
+		QueryOptions: structs.QueryOptions{
+			Filter: "Meta.rack == r1 or Address == 127.0.0.2",
+		},
+	}
+	var out structs.IndexedNodes
+	if err := msgpackrpc.CallWithCodec(codec, "Catalog.ListNodes", &args, &out); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(out.Nodes) != 2 || out.Nodes[0].Node != "bar" || out.Nodes[1].Node != "foo" {
+		t.Fatalf("bad: %v", out.Nodes)
+	}
+
+	// The server's own node gets registered by the leader.
+	args.Filter = "not (Meta.rack is not empty)"
+	retry.Run(t, func(r *retry.R) {
+		var out structs.IndexedNodes
+		if err := msgpackrpc.CallWithCodec(codec, "Catalog.ListNodes", &args, &out); err != nil {
+			r.Fatalf("err: %v", err)
+		}
+		if len(out.Nodes) != 1 || out.Nodes[0].Node != s1.config.NodeName {
+			r.Fatalf("bad: %v", out.Nodes)
+		}
+	})
+
+	// Invalid filters should be rejected.
+	args.Filter = "Meta.rack =="
+	err := msgpackrpc.CallWithCodec(codec, "Catalog.ListNodes", &args, &out)
+	if err == nil || !structs.IsErrInvalidFilter(err) {
+		t.Fatalf("err: %v", err)
+	}
+}
+
 func TestCatalog_ListNodes_StaleRead(t *testing.T) {
 	t.Parallel()
 	dir1, s1 := testServer(t)
@@ -1241,6 +1296,84 @@ func TestCatalog_ListServices_Blocking(t *testing.T) {
 	}
 }
 
+func TestCatalog_ServiceNodes_Filter(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	// Register the same service with different tags on two nodes.
+	for i, reg := range []*structs.RegisterRequest{
+		&structs.RegisterRequest{
+			Datacenter: "dc1",
+			Node:       "foo",
+			Address:    "127.0.0.1",
+			NodeMeta:   map[string]string{"rack": "r1"},
+			Service:    &structs.NodeService{ID: "db", Service: "db", Tags: []string{"primary"}, Port: 5000},
+		},
+		&structs.RegisterRequest{
+			Datacenter: "dc1",
+			Node:       "bar",
+			Address:    "127.0.0.2",
+			NodeMeta:   map[string]string{"rack": "r2"},
+			Service:    &structs.NodeService{ID: "db", Service: "db", Tags: []string{"secondary"}, Port: 5001},
+		},
+	} {
+		if err := s1.fsm.State().EnsureRegistration(uint64(i+1), reg); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	cases := []struct {
+		filter string
+		want   []string
+	}{
+		{"ServiceTags contains primary", []string{"foo"}},
+		{"secondary in ServiceTags", []string{"bar"}},
+		{"NodeMeta.rack == r2", []string{"bar"}},
+		{"ServicePort == 5000 or NodeMeta.rack == r2", []string{"bar", "foo"}},
+		{"ServiceTags contains primary and NodeMeta.rack == r2", nil},
+	}
+	for _, tc := range cases {
+		args := structs.ServiceSpecificRequest{
+			Datacenter:  "dc1",
+			ServiceName: "db",
+			QueryOptions: structs.QueryOptions{
+				Filter: tc.filter,
+			},
+		}
+		var out structs.IndexedServiceNodes
+		if err := msgpackrpc.CallWithCodec(codec, "Catalog.ServiceNodes", &args, &out); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		var got []string
+		for _, sn := range out.ServiceNodes {
+			got = append(got, sn.Node)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("%s: got %v want %v", tc.filter, got, tc.want)
+		}
+	}
+
+	// Filters are checked against the fields of the results.
+	args := structs.ServiceSpecificRequest{
+		Datacenter:  "dc1",
+		ServiceName: "db",
+		QueryOptions: structs.QueryOptions{
+			Filter: "Service.Tags contains primary",
+		},
+	}
+	var out structs.IndexedServiceNodes
+	err := msgpackrpc.CallWithCodec(codec, "Catalog.ServiceNodes", &args, &out)
+	if err == nil || !strings.Contains(err.Error(), `Invalid filter: Unknown field "Service"`) {
+		t.Fatalf("err: %v", err)
+	}
+}
+
 func TestCatalog_ListServices_Timeout(t *testing.T) {
 	t.Parallel()
 	dir1, s1 := testServer(t)
@@ -1472,6 +1605,93 @@ func TestCatalog_ListServiceNodes_NodeMetaFilter(t *testing.T) {
 	}
 }
 
+func TestCatalog_ListServiceNodes_ServiceMetaFilter(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	// Add 2 service instances with specific meta maps
+	if err := s1.fsm.State().EnsureNode(1, &structs.Node{Node: "foo", Address: "127.0.0.1"}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := s1.fsm.State().EnsureNode(2, &structs.Node{Node: "bar", Address: "127.0.0.2"}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := s1.fsm.State().EnsureService(3, "foo", &structs.NodeService{ID: "db", Service: "db", Tags: []string{"primary"}, Port: 5000,
+		Meta: map[string]string{"version": "2", "common": "1"}}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := s1.fsm.State().EnsureService(4, "bar", &structs.NodeService{ID: "db2", Service: "db", Tags: []string{"secondary"}, Port: 5000,
+		Meta: map[string]string{"version": "1", "common": "1"}}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	cases := []struct {
+		filters  map[string]string
+		tag      string
+		services structs.ServiceNodes
+	}{
+		// Basic meta filter
+		{
+			filters:  map[string]string{"version": "2"},
+			services: structs.ServiceNodes{&structs.ServiceNode{Node: "foo", ServiceID: "db"}},
+		},
+		// Common meta filter
+		{
+			filters: map[string]string{"common": "1"},
+			services: structs.ServiceNodes{
+				&structs.ServiceNode{Node: "bar", ServiceID: "db2"},
+				&structs.ServiceNode{Node: "foo", ServiceID: "db"},
+			},
+		},
+		// Common meta filter, tag
+		{
+			filters:  map[string]string{"common": "1"},
+			tag:      "secondary",
+			services: structs.ServiceNodes{&structs.ServiceNode{Node: "bar", ServiceID: "db2"}},
+		},
+		// Invalid meta filter
+		{
+			filters:  map[string]string{"invalid": "nope"},
+			services: structs.ServiceNodes{},
+		},
+		// Multiple filter values
+		{
+			filters:  map[string]string{"version": "1", "common": "1"},
+			services: structs.ServiceNodes{&structs.ServiceNode{Node: "bar", ServiceID: "db2"}},
+		},
+	}
+
+	for _, tc := range cases {
+		args := structs.ServiceSpecificRequest{
+			Datacenter:         "dc1",
+			ServiceMetaFilters: tc.filters,
+			ServiceName:        "db",
+			ServiceTag:         tc.tag,
+			TagFilter:          tc.tag != "",
+		}
+		var out structs.IndexedServiceNodes
+		if err := msgpackrpc.CallWithCodec(codec, "Catalog.ServiceNodes", &args, &out); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		if len(out.ServiceNodes) != len(tc.services) {
+			t.Fatalf("bad: %v", out)
+		}
+
+		for i, serviceNode := range out.ServiceNodes {
+			if serviceNode.Node != tc.services[i].Node || serviceNode.ServiceID != tc.services[i].ServiceID {
+				t.Fatalf("bad: %v, %v filters: %v", serviceNode, tc.services[i], tc.filters)
+			}
+		}
+	}
+}
+
 func TestCatalog_ListServiceNodes_DistanceSort(t *testing.T) {
 	t.Parallel()
 	dir1, s1 := testServer(t)
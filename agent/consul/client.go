@@ -1,12 +1,14 @@
 package consul
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/armon/go-metrics"
@@ -55,8 +57,9 @@ type Client struct {
 	routers *router.Manager
 
 	// rpcLimiter is used to rate limit the total number of RPCs initiated
-	// from an agent.
-	rpcLimiter *rate.Limiter
+	// from an agent. It holds a *rate.Limiter, which is replaced when the
+	// config is reloaded.
+	rpcLimiter atomic.Value
 
 	// eventCh is used to receive events from the
 	// serf cluster in the datacenter
@@ -125,12 +128,13 @@ func NewClientLogger(config *Config, logger *log.Logger) (*Client, error) {
 	c := &Client{
 		config:     config,
 		connPool:   connPool,
-		rpcLimiter: rate.NewLimiter(config.RPCRate, config.RPCMaxBurst),
 		eventCh:    make(chan serf.Event, serfEventBacklog),
 		logger:     logger,
 		shutdownCh: make(chan struct{}),
 	}
 
+	c.rpcLimiter.Store(rate.NewLimiter(config.RPCRate, config.RPCMaxBurst))
+
 	// Start lan event handlers before lan Serf setup to prevent deadlock
 	go c.lanEventHandler()
 
@@ -241,7 +245,7 @@ func (c *Client) RPC(method string, args interface{}, reply interface{}) error {
 	// Enforce the RPC limit.
 	metrics.IncrCounter([]string{"consul", "client", "rpc"}, 1)
 	metrics.IncrCounter([]string{"client", "rpc"}, 1)
-	if !c.rpcLimiter.Allow() {
+	if !c.rpcLimiter.Load().(*rate.Limiter).Allow() {
 		metrics.IncrCounter([]string{"consul", "client", "rpc", "exceeded"}, 1)
 		metrics.IncrCounter([]string{"client", "rpc", "exceeded"}, 1)
 		return structs.ErrRPCRateExceeded
@@ -249,6 +253,11 @@ func (c *Client) RPC(method string, args interface{}, reply interface{}) error {
 
 	// Make the request.
 	if err := c.connPool.RPC(c.config.Datacenter, server.Addr, server.Version, method, server.UseTLS, args, reply); err != nil {
+		// A server limiting our requests is busy, not failed, so it's
+		// kept and the caller can retry later.
+		if structs.IsErrRPCRateExceeded(err) {
+			return err
+		}
 		c.routers.NotifyFailedServer(server)
 		c.logger.Printf("[ERR] consul: RPC failed to server %s: %v", server.Addr, err)
 		return err
@@ -257,6 +266,38 @@ func (c *Client) RPC(method string, args interface{}, reply interface{}) error {
 	return nil
 }
 
+// SubscribeRPC subscribes to change events on one of the servers, calling fn
+// with each event until the context is done, fn returns an error, or the
+// subscription can't go on.
+func (c *Client) SubscribeRPC(ctx context.Context, args *structs.SubscribeRequest, fn structs.StreamEventFn) error {
+	server := c.routers.FindServer()
+	if server == nil {
+		return structs.ErrNoServers
+	}
+
+	// Enforce the RPC limit.
+	metrics.IncrCounter([]string{"consul", "client", "rpc"}, 1)
+	metrics.IncrCounter([]string{"client", "rpc"}, 1)
+	if !c.rpcLimiter.Load().(*rate.Limiter).Allow() {
+		metrics.IncrCounter([]string{"consul", "client", "rpc", "exceeded"}, 1)
+		metrics.IncrCounter([]string{"client", "rpc", "exceeded"}, 1)
+		return structs.ErrRPCRateExceeded
+	}
+
+	// Only servers we couldn't subscribe to at all are marked as failed,
+	// since subscriptions are closed on purpose once they're too far
+	// behind.
+	var started bool
+	err := SubscribeRPC(ctx, c.connPool, c.config.Datacenter, server.Addr, server.UseTLS, args, func(e *structs.StreamEvent) error {
+		started = true
+		return fn(e)
+	})
+	if err != nil && !started && ctx.Err() == nil {
+		c.routers.NotifyFailedServer(server)
+	}
+	return err
+}
+
 // SnapshotRPC sends the snapshot request to one of the servers, reading from
 // the streaming input and writing to the streaming output depending on the
 // operation.
@@ -270,7 +311,7 @@ func (c *Client) SnapshotRPC(args *structs.SnapshotRequest, in io.Reader, out io
 	// Enforce the RPC limit.
 	metrics.IncrCounter([]string{"consul", "client", "rpc"}, 1)
 	metrics.IncrCounter([]string{"client", "rpc"}, 1)
-	if !c.rpcLimiter.Allow() {
+	if !c.rpcLimiter.Load().(*rate.Limiter).Allow() {
 		metrics.IncrCounter([]string{"consul", "client", "rpc", "exceeded"}, 1)
 		metrics.IncrCounter([]string{"client", "rpc", "exceeded"}, 1)
 		return structs.ErrRPCRateExceeded
@@ -335,3 +376,10 @@ func (c *Client) GetLANCoordinate() (lib.CoordinateSet, error) {
 	cs := lib.CoordinateSet{c.config.Segment: lan}
 	return cs, nil
 }
+
+// ReloadConfig applies the parts of the config that can be changed while the
+// client is running.
+func (c *Client) ReloadConfig(config *Config) error {
+	c.rpcLimiter.Store(rate.NewLimiter(config.RPCRate, config.RPCMaxBurst))
+	return nil
+}
@@ -88,6 +88,11 @@ type Config struct {
 	// Node name is the name we use to advertise. Defaults to hostname.
 	NodeName string
 
+	// NodeMeta is the node meta of the agent. Servers advertise the entries
+	// named by the RedundancyZoneTag and UpgradeVersionTag of the Autopilot
+	// config to the other servers.
+	NodeMeta map[string]string
+
 	// Domain is the DNS domain for the records. Defaults to "consul."
 	Domain string
 
@@ -201,6 +206,10 @@ type Config struct {
 	// over the client ciphersuites.
 	TLSPreferServerCipherSuites bool
 
+	// TLSCertStore, if set, provides the certificate and CAs loaded from the
+	// files above, which can then be reloaded while running.
+	TLSCertStore *tlsutil.CertStore
+
 	// RejoinAfterLeave controls our interaction with Serf.
 	// When set to false (default), a leave causes a Consul to not rejoin
 	// the cluster until an explicit join is received. If this is set to
@@ -311,6 +320,13 @@ type Config struct {
 	// warning and discard the remaining updates.
 	CoordinateUpdateMaxBatches int
 
+	// CoordinateMaxError and CoordinateMaxAge are the default limits above
+	// which the coordinate of a node is flagged as inaccurate or stale in
+	// RTT estimates. A new coordinate starts with an error of 1.5, which
+	// goes down as the node measures RTTs to other nodes.
+	CoordinateMaxError float64
+	CoordinateMaxAge   time.Duration
+
 	// RPCHoldTimeout is how long an RPC can be "held" before it is errored.
 	// This is used to paper over a loss of leadership by instead holding RPCs,
 	// so that the caller experiences a slow response rather than an error.
@@ -329,6 +345,26 @@ type Config struct {
 	RPCRate     rate.Limit
 	RPCMaxBurst int
 
+	// RPCServerReadRate and RPCServerReadMaxBurst limit the read RPCs a
+	// server accepts from each source IP and from each ACL token, the same
+	// way RPCRate and RPCMaxBurst limit the RPCs of a client.
+	// RPCServerWriteRate and RPCServerWriteMaxBurst do the same for writes.
+	// RPCs from other servers are never limited.
+	RPCServerReadRate      rate.Limit
+	RPCServerReadMaxBurst  int
+	RPCServerWriteRate     rate.Limit
+	RPCServerWriteMaxBurst int
+
+	// RPCMaxBlockingQueries is the number of blocking queries a server runs
+	// at the same time. Blocking queries over the limit are rejected. Zero
+	// means no limit.
+	RPCMaxBlockingQueries int
+
+	// RPCMaxConnsPerClient is the number of RPC connections a server
+	// accepts from a single IP address, not counting other servers. Zero
+	// means no limit.
+	RPCMaxConnsPerClient int
+
 	// AutopilotConfig is used to apply the initial autopilot config when
 	// bootstrapping.
 	AutopilotConfig *structs.AutopilotConfig
@@ -405,6 +441,8 @@ func DefaultConfig() *Config {
 		CoordinateUpdatePeriod:     5 * time.Second,
 		CoordinateUpdateBatchSize:  128,
 		CoordinateUpdateMaxBatches: 5,
+		CoordinateMaxError:         0.5,
+		CoordinateMaxAge:           10 * time.Minute,
 
 		// This holds RPCs during leader elections. For the default Raft
 		// config the election timeout is 5 seconds, so we set this a
@@ -415,6 +453,11 @@ func DefaultConfig() *Config {
 		RPCRate:     rate.Inf,
 		RPCMaxBurst: 1000,
 
+		RPCServerReadRate:      rate.Inf,
+		RPCServerReadMaxBurst:  1000,
+		RPCServerWriteRate:     rate.Inf,
+		RPCServerWriteMaxBurst: 1000,
+
 		TLSMinVersion: "tls10",
 
 		AutopilotConfig: &structs.AutopilotConfig{
@@ -468,6 +511,7 @@ func (c *Config) tlsConfig() *tlsutil.Config {
 		Domain:                   c.Domain,
 		TLSMinVersion:            c.TLSMinVersion,
 		PreferServerCipherSuites: c.TLSPreferServerCipherSuites,
+		CertStore:                c.TLSCertStore,
 	}
 	return tlsConf
 }
@@ -2,6 +2,7 @@ package consul
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -9,6 +10,8 @@ import (
 	"github.com/hashicorp/consul/acl"
 	"github.com/hashicorp/consul/agent/consul/state"
 	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/lib"
+	"github.com/hashicorp/consul/types"
 	"github.com/hashicorp/go-memdb"
 )
 
@@ -71,8 +74,10 @@ func (c *Coordinate) batchApplyUpdates() error {
 	}
 
 	// Transform the map into a slice that we can feed to the Raft log in
-	// batches.
+	// batches. The updates were all received within the last period, which
+	// is close enough to stamp them all with the current time.
 	i := 0
+	now := time.Now().UTC()
 	updates := make(structs.Coordinates, size)
 	for _, update := range pending {
 		if !(i < size) {
@@ -83,6 +88,7 @@ func (c *Coordinate) batchApplyUpdates() error {
 			Node:    update.Node,
 			Segment: update.Segment,
 			Coord:   update.Coord,
+			Updated: now,
 		}
 		i++
 	}
@@ -200,3 +206,147 @@ func (c *Coordinate) ListNodes(args *structs.DCSpecificRequest, reply *structs.I
 			return nil
 		})
 }
+
+// Matrix returns the RTT estimates between the given nodes along with the
+// status of their coordinates, so clients don't need to do the coordinate
+// math themselves. This also returns the RTT estimates from this datacenter
+// to the others in the WAN pool.
+func (c *Coordinate) Matrix(args *structs.CoordinateMatrixRequest, reply *structs.IndexedCoordinateMatrix) error {
+	if done, err := c.srv.forward("Coordinate.Matrix", args, args, reply); done {
+		return err
+	}
+
+	if len(args.Nodes) > structs.CoordinateMatrixMaxNodes {
+		return fmt.Errorf("Too many nodes (%d > %d)", len(args.Nodes), structs.CoordinateMatrixMaxNodes)
+	}
+
+	maxError, maxAge := args.MaxError, args.MaxAge
+	if maxError <= 0 {
+		maxError = c.srv.config.CoordinateMaxError
+	}
+	if maxAge <= 0 {
+		maxAge = c.srv.config.CoordinateMaxAge
+	}
+
+	dcs, err := c.datacenterRTTs()
+	if err != nil {
+		return err
+	}
+
+	return c.srv.blockingQuery(&args.QueryOptions,
+		&reply.QueryMeta,
+		func(ws memdb.WatchSet, state *state.Store) error {
+			index, coords, err := state.Coordinates(ws)
+			if err != nil {
+				return err
+			}
+
+			// Filter the coordinates the same way as for ListNodes.
+			filtered := structs.IndexedCoordinates{Coordinates: coords}
+			if err := c.srv.filterACL(args.Token, &filtered); err != nil {
+				return err
+			}
+
+			matrix := coordinateMatrix(filtered.Coordinates, args.Nodes, maxError, maxAge, time.Now())
+			matrix.Datacenters = dcs
+			reply.Index, reply.Matrix = index, matrix
+			return nil
+		})
+}
+
+// coordinateMatrix computes the RTT estimates between each pair of the given
+// nodes and checks the status of their coordinates. Nodes without a
+// coordinate are left out.
+func coordinateMatrix(coords structs.Coordinates, nodes []string, maxError float64, maxAge time.Duration, now time.Time) *structs.CoordinateMatrix {
+	// Index the coordinates by node, keeping the order of the requested
+	// nodes.
+	index := make(map[string]structs.Coordinates)
+	var order []string
+	for _, node := range nodes {
+		if _, ok := index[node]; !ok {
+			index[node] = nil
+			order = append(order, node)
+		}
+	}
+	for _, coord := range coords {
+		if existing, ok := index[coord.Node]; ok {
+			index[coord.Node] = append(existing, coord)
+		}
+	}
+
+	// Check the status of the coordinates.
+	matrix := &structs.CoordinateMatrix{
+		Nodes: make([]*structs.CoordinateStatus, 0),
+		RTTs:  make([]*structs.CoordinateRTT, 0),
+	}
+	sets := make(map[string]lib.CoordinateSet)
+	for _, node := range order {
+		cs := make(lib.CoordinateSet)
+		for _, coord := range index[node] {
+			cs[coord.Segment] = coord.Coord
+			matrix.Nodes = append(matrix.Nodes, &structs.CoordinateStatus{
+				Node:       coord.Node,
+				Segment:    coord.Segment,
+				Error:      coord.Coord.Error,
+				Updated:    coord.Updated,
+				Inaccurate: coord.Coord.Error > maxError,
+				Stale:      !coord.Updated.IsZero() && now.Sub(coord.Updated) > maxAge,
+			})
+		}
+		sets[node] = cs
+	}
+
+	// Compute the RTTs between the nodes that have compatible coordinates.
+	for i, from := range order {
+		for _, to := range order[i+1:] {
+			a, b := sets[from].Intersect(sets[to])
+			if a == nil || b == nil {
+				continue
+			}
+			matrix.RTTs = append(matrix.RTTs, &structs.CoordinateRTT{
+				From: from,
+				To:   to,
+				RTT:  a.DistanceTo(b),
+			})
+		}
+	}
+	return matrix
+}
+
+// datacenterRTTs returns the median RTT estimates from this server to the
+// servers of each datacenter in the WAN pool, sorted by RTT.
+func (c *Coordinate) datacenterRTTs() ([]*structs.CoordinateRTT, error) {
+	coord, err := c.srv.GetWANCoordinate()
+	if err != nil {
+		return nil, err
+	}
+	maps, err := c.srv.router.GetDatacenterMaps()
+	if err != nil {
+		return nil, err
+	}
+
+	dcs := make([]*structs.CoordinateRTT, 0, len(maps))
+	for _, m := range maps {
+		if m.AreaID != types.AreaWAN || len(m.Coordinates) == 0 {
+			continue
+		}
+
+		rtts := make([]time.Duration, 0, len(m.Coordinates))
+		for _, entry := range m.Coordinates {
+			rtts = append(rtts, coord.DistanceTo(entry.Coord))
+		}
+		sort.Slice(rtts, func(i, j int) bool { return rtts[i] < rtts[j] })
+		dcs = append(dcs, &structs.CoordinateRTT{
+			From: c.srv.config.Datacenter,
+			To:   m.Datacenter,
+			RTT:  rtts[len(rtts)/2],
+		})
+	}
+	sort.Slice(dcs, func(i, j int) bool {
+		if dcs[i].RTT != dcs[j].RTT {
+			return dcs[i].RTT < dcs[j].RTT
+		}
+		return dcs[i].To < dcs[j].To
+	})
+	return dcs, nil
+}
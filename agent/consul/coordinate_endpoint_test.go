@@ -5,6 +5,7 @@ import (
 	"math"
 	"math/rand"
 	"os"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -502,3 +503,167 @@ node "foo" {
 		t.Fatalf("bad: %#v", resp.Coordinates)
 	}
 }
+
+func TestCoordinate_Matrix(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.CoordinateUpdatePeriod = 100 * time.Millisecond
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	// Register some nodes and give them known coordinates, with the one
+	// for baz having a large error.
+	coords := map[string]*coordinate.Coordinate{
+		"foo": lib.GenerateCoordinate(10 * time.Millisecond),
+		"bar": lib.GenerateCoordinate(25 * time.Millisecond),
+		"baz": lib.GenerateCoordinate(40 * time.Millisecond),
+	}
+	coords["foo"].Error = 0.1
+	coords["bar"].Error = 0.1
+	coords["baz"].Error = 1.2
+	for node, coord := range coords {
+		req := structs.RegisterRequest{
+			Datacenter: "dc1",
+			Node:       node,
+			Address:    "127.0.0.1",
+		}
+		var reply struct{}
+		if err := msgpackrpc.CallWithCodec(codec, "Catalog.Register", &req, &reply); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		arg := structs.CoordinateUpdateRequest{
+			Datacenter: "dc1",
+			Node:       node,
+			Coord:      coord,
+		}
+		if err := msgpackrpc.CallWithCodec(codec, "Coordinate.Update", &arg, &reply); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	// Ask for a subset of the nodes, in a given order, plus one that
+	// doesn't have a coordinate.
+	arg := structs.CoordinateMatrixRequest{
+		Datacenter: "dc1",
+		Nodes:      []string{"foo", "nope", "baz"},
+	}
+	var out structs.IndexedCoordinateMatrix
+	retry.Run(t, func(r *retry.R) {
+		if err := msgpackrpc.CallWithCodec(codec, "Coordinate.Matrix", &arg, &out); err != nil {
+			r.Fatalf("err: %v", err)
+		}
+		if len(out.Matrix.Nodes) != 2 {
+			r.Fatalf("bad: %v", out.Matrix.Nodes)
+		}
+	})
+
+	matrix := out.Matrix
+	if matrix.Nodes[0].Node != "foo" || matrix.Nodes[0].Inaccurate || matrix.Nodes[0].Stale ||
+		matrix.Nodes[1].Node != "baz" || !matrix.Nodes[1].Inaccurate || matrix.Nodes[1].Stale {
+		t.Fatalf("bad: %v", matrix.Nodes)
+	}
+	for _, status := range matrix.Nodes {
+		if status.Updated.IsZero() || time.Since(status.Updated) > time.Minute {
+			t.Fatalf("bad: %v", status)
+		}
+	}
+	if len(matrix.RTTs) != 1 {
+		t.Fatalf("bad: %v", matrix.RTTs)
+	}
+	expected := structs.CoordinateRTT{
+		From: "foo",
+		To:   "baz",
+		RTT:  coords["foo"].DistanceTo(coords["baz"]),
+	}
+	if *matrix.RTTs[0] != expected {
+		t.Fatalf("bad: %v", matrix.RTTs[0])
+	}
+
+	// A higher max error doesn't flag baz.
+	arg.MaxError = 2.0
+	if err := msgpackrpc.CallWithCodec(codec, "Coordinate.Matrix", &arg, &out); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.Matrix.Nodes[1].Inaccurate {
+		t.Fatalf("bad: %v", out.Matrix.Nodes)
+	}
+
+	// Only the datacenters are returned without any nodes.
+	arg.Nodes = nil
+	out = structs.IndexedCoordinateMatrix{}
+	if err := msgpackrpc.CallWithCodec(codec, "Coordinate.Matrix", &arg, &out); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(out.Matrix.Nodes) != 0 || len(out.Matrix.RTTs) != 0 {
+		t.Fatalf("bad: %v %v", out.Matrix.Nodes, out.Matrix.RTTs)
+	}
+
+	// Our own DC shows up with the RTT to ourselves.
+	if len(out.Matrix.Datacenters) != 1 ||
+		out.Matrix.Datacenters[0].From != "dc1" ||
+		out.Matrix.Datacenters[0].To != "dc1" {
+		t.Fatalf("bad: %v", out.Matrix.Datacenters)
+	}
+
+	// Too many nodes are refused.
+	arg.Nodes = make([]string, structs.CoordinateMatrixMaxNodes+1)
+	err := msgpackrpc.CallWithCodec(codec, "Coordinate.Matrix", &arg, &out)
+	if err == nil || !strings.Contains(err.Error(), "Too many nodes") {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func TestCoordinate_Matrix_Status(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	generate := func(rtt time.Duration, err float64) *coordinate.Coordinate {
+		coord := lib.GenerateCoordinate(rtt)
+		coord.Error = err
+		return coord
+	}
+	coords := structs.Coordinates{
+		{Node: "foo", Coord: generate(10*time.Millisecond, 0.1), Updated: now.Add(-time.Minute)},
+		{Node: "bar", Coord: generate(20*time.Millisecond, 0.1), Updated: now.Add(-time.Hour)},
+		{Node: "baz", Coord: generate(30*time.Millisecond, 0.1)},
+		{Node: "baz", Segment: "alpha", Coord: generate(5*time.Millisecond, 0.8), Updated: now},
+		{Node: "zip", Segment: "alpha", Coord: generate(40*time.Millisecond, 0.1), Updated: now},
+	}
+
+	matrix := coordinateMatrix(coords, []string{"foo", "bar", "baz", "zip"}, 0.5, 10*time.Minute, now)
+	var flags []string
+	for _, status := range matrix.Nodes {
+		flags = append(flags, fmt.Sprintf("%s/%s:%v:%v", status.Node, status.Segment, status.Inaccurate, status.Stale))
+	}
+	expected := []string{
+		"foo/:false:false",
+		"bar/:false:true",
+		"baz/:false:false",
+		"baz/alpha:true:false",
+		"zip/alpha:false:false",
+	}
+	if !reflect.DeepEqual(flags, expected) {
+		t.Fatalf("bad: %v", flags)
+	}
+
+	// The node in the alpha segment is only compatible with baz, which is
+	// in both segments.
+	var rtts []string
+	for _, rtt := range matrix.RTTs {
+		rtts = append(rtts, fmt.Sprintf("%s-%s:%s", rtt.From, rtt.To, rtt.RTT.Round(time.Millisecond)))
+	}
+	expected = []string{
+		"foo-bar:10ms",
+		"foo-baz:20ms",
+		"bar-baz:10ms",
+		"baz-zip:35ms",
+	}
+	if !reflect.DeepEqual(rtts, expected) {
+		t.Fatalf("bad: %v", rtts)
+	}
+}
@@ -1,7 +1,10 @@
 package consul
 
 import (
+	"fmt"
+
 	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/filter"
 	"github.com/hashicorp/consul/agent/structs"
 )
 
@@ -61,8 +64,24 @@ func (t *txnResultsFilter) Len() int {
 
 func (t *txnResultsFilter) Filter(i int) bool {
 	result := t.results[i]
-	if result.KV != nil {
+	switch {
+	case result.KV != nil:
 		return !t.acl.KeyRead(result.KV.Key)
+
+	case result.Node != nil:
+		return !t.acl.NodeRead(result.Node.Node)
+
+	case result.Service != nil:
+		return !t.acl.ServiceRead(result.Service.Service)
+
+	case result.Check != nil:
+		if result.Check.ServiceName != "" && !t.acl.ServiceRead(result.Check.ServiceName) {
+			return true
+		}
+		return !t.acl.NodeRead(result.Check.Node)
+
+	case result.Session != nil:
+		return !t.acl.SessionRead(result.Session.Node)
 	}
 	return false
 }
@@ -115,3 +134,14 @@ func FilterEntries(f Filter) int {
 	// Return the size of the slice
 	return dst
 }
+
+// newQueryFilter compiles the filter expression of a query for the given type
+// of results. Errors are wrapped so they can be told apart from other errors
+// on the client.
+func newQueryFilter(expression string, dataType interface{}) (*filter.Filter, error) {
+	f, err := filter.New(expression, dataType)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", structs.ErrInvalidFilter, err)
+	}
+	return f, nil
+}
@@ -50,8 +50,8 @@ type consulSnapshot struct {
 	state *state.Snapshot
 }
 
-// snapshotHeader is the first entry in our snapshot
-type snapshotHeader struct {
+// SnapshotHeader is the first entry in our snapshot
+type SnapshotHeader struct {
 	// LastIndex is the last index that affects the data.
 	// This is used when we do the restore for watchers.
 	LastIndex uint64
@@ -104,6 +104,8 @@ func (c *consulFSM) Apply(log *raft.Log) interface{} {
 		return c.applySessionOperation(buf[1:], log.Index)
 	case structs.ACLRequestType:
 		return c.applyACLOperation(buf[1:], log.Index)
+	case structs.ACLPolicyRequestType:
+		return c.applyACLPolicyOperation(buf[1:], log.Index)
 	case structs.TombstoneRequestType:
 		return c.applyTombstoneOperation(buf[1:], log.Index)
 	case structs.CoordinateBatchUpdateType:
@@ -203,6 +205,12 @@ func (c *consulFSM) applyKVSOperation(buf []byte, index uint64) interface{} {
 			return err
 		}
 		return act
+	case api.KVLockQueue:
+		act, err := c.state.KVSLockQueue(index, &req.DirEnt)
+		if err != nil {
+			return err
+		}
+		return act
 	case api.KVUnlock:
 		act, err := c.state.KVSUnlock(index, &req.DirEnt)
 		if err != nil {
@@ -232,6 +240,9 @@ func (c *consulFSM) applySessionOperation(buf []byte, index uint64) interface{}
 		}
 		return req.Session.ID
 	case structs.SessionDestroy:
+		if req.Invalidate {
+			return c.state.SessionInvalidate(index, req.Session.ID)
+		}
 		return c.state.SessionDestroy(index, req.Session.ID)
 	default:
 		c.logger.Printf("[WARN] consul.fsm: Invalid Session operation '%s'", req.Op)
@@ -273,6 +284,29 @@ func (c *consulFSM) applyACLOperation(buf []byte, index uint64) interface{} {
 	}
 }
 
+func (c *consulFSM) applyACLPolicyOperation(buf []byte, index uint64) interface{} {
+	var req structs.ACLPolicyApplyRequest
+	if err := structs.Decode(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode request: %v", err))
+	}
+	defer metrics.MeasureSinceWithLabels([]string{"consul", "fsm", "acl_policy"}, time.Now(),
+		[]metrics.Label{{Name: "op", Value: string(req.Op)}})
+	defer metrics.MeasureSinceWithLabels([]string{"fsm", "acl_policy"}, time.Now(),
+		[]metrics.Label{{Name: "op", Value: string(req.Op)}})
+	switch req.Op {
+	case structs.ACLSet:
+		if err := c.state.ACLPolicySet(index, &req.Policy); err != nil {
+			return err
+		}
+		return req.Policy.ID
+	case structs.ACLDelete:
+		return c.state.ACLPolicyDelete(index, req.Policy.ID)
+	default:
+		c.logger.Printf("[WARN] consul.fsm: Invalid ACL policy operation '%s'", req.Op)
+		return fmt.Errorf("Invalid ACL policy operation '%s'", req.Op)
+	}
+}
+
 func (c *consulFSM) applyTombstoneOperation(buf []byte, index uint64) interface{} {
 	var req structs.TombstoneRequest
 	if err := structs.Decode(buf, &req); err != nil {
@@ -386,28 +420,9 @@ func (c *consulFSM) Restore(old io.ReadCloser) error {
 	restore := stateNew.Restore()
 	defer restore.Abort()
 
-	// Create a decoder
-	dec := codec.NewDecoder(old, msgpackHandle)
-
-	// Read in the header
-	var header snapshotHeader
-	if err := dec.Decode(&header); err != nil {
-		return err
-	}
-
-	// Populate the new state
-	msgType := make([]byte, 1)
-	for {
-		// Read the message type
-		_, err := old.Read(msgType)
-		if err == io.EOF {
-			break
-		} else if err != nil {
-			return err
-		}
-
-		// Decode
-		switch structs.MessageType(msgType[0]) {
+	// Read the snapshot into the new state
+	err = ReadSnapshot(old, func(header *SnapshotHeader, msgType structs.MessageType, dec *codec.Decoder) error {
+		switch msgType {
 		case structs.RegisterRequestType:
 			var req structs.RegisterRequest
 			if err := dec.Decode(&req); err != nil {
@@ -470,6 +485,15 @@ func (c *consulFSM) Restore(old io.ReadCloser) error {
 				return err
 			}
 
+		case structs.ACLPolicyRequestType:
+			var req structs.ACLPolicy
+			if err := dec.Decode(&req); err != nil {
+				return err
+			}
+			if err := restore.ACLPolicy(&req); err != nil {
+				return err
+			}
+
 		case structs.CoordinateBatchUpdateType:
 			var req structs.Coordinates
 			if err := dec.Decode(&req); err != nil {
@@ -501,6 +525,10 @@ func (c *consulFSM) Restore(old io.ReadCloser) error {
 		default:
 			return fmt.Errorf("Unrecognized msg type: %v", msgType)
 		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
 	restore.Commit()
@@ -519,6 +547,37 @@ func (c *consulFSM) Restore(old io.ReadCloser) error {
 	return nil
 }
 
+// ReadSnapshot decodes each record of the snapshot written by Persist, and
+// calls the handler with the snapshot header and the type of the record. The
+// handler must decode the record with the given decoder before returning.
+func ReadSnapshot(r io.Reader, handler func(header *SnapshotHeader, msgType structs.MessageType, dec *codec.Decoder) error) error {
+	// Create a decoder
+	dec := codec.NewDecoder(r, msgpackHandle)
+
+	// Read in the header
+	var header SnapshotHeader
+	if err := dec.Decode(&header); err != nil {
+		return err
+	}
+
+	// Read each record, which is its message type followed by the record
+	msgType := make([]byte, 1)
+	for {
+		// Read the message type
+		_, err := r.Read(msgType)
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		// Decode
+		if err := handler(&header, structs.MessageType(msgType[0]), dec); err != nil {
+			return err
+		}
+	}
+}
+
 func (s *consulSnapshot) Persist(sink raft.SnapshotSink) error {
 	defer metrics.MeasureSince([]string{"consul", "fsm", "persist"}, time.Now())
 	defer metrics.MeasureSince([]string{"fsm", "persist"}, time.Now())
@@ -527,7 +586,7 @@ func (s *consulSnapshot) Persist(sink raft.SnapshotSink) error {
 	encoder := codec.NewEncoder(sink, msgpackHandle)
 
 	// Write the header
-	header := snapshotHeader{
+	header := SnapshotHeader{
 		LastIndex: s.state.LastIndex(),
 	}
 	if err := encoder.Encode(&header); err != nil {
@@ -683,6 +742,18 @@ func (s *consulSnapshot) persistACLs(sink raft.SnapshotSink,
 		}
 	}
 
+	policies, err := s.state.ACLPolicies()
+	if err != nil {
+		return err
+	}
+
+	for policy := policies.Next(); policy != nil; policy = policies.Next() {
+		sink.Write([]byte{byte(structs.ACLPolicyRequestType)})
+		if err := encoder.Encode(policy.(*structs.ACLPolicy)); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
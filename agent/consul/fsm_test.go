@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -385,10 +386,19 @@ func TestFSM_SnapshotRestore(t *testing.T) {
 	fsm.state.KVSSet(8, &structs.DirEntry{
 		Key:   "/test",
 		Value: []byte("foo"),
+		TTL:   "30s",
 	})
 	session := &structs.Session{ID: generateUUID(), Node: "foo"}
 	fsm.state.SessionCreate(9, session)
-	acl := &structs.ACL{ID: generateUUID(), Name: "User Token"}
+	policy := &structs.ACLPolicy{ID: generateUUID(), Name: "web", Rules: `service "web" { policy = "write" }`}
+	if err := fsm.state.ACLPolicySet(10, policy); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	acl := &structs.ACL{
+		ID:       generateUUID(),
+		Name:     "User Token",
+		Policies: []structs.ACLTokenPolicyLink{{ID: policy.ID, Name: policy.Name}},
+	}
 	fsm.state.ACLSet(10, acl)
 	if _, err := fsm.state.ACLBootstrapInit(10); err != nil {
 		t.Fatalf("err: %v", err)
@@ -516,10 +526,19 @@ func TestFSM_SnapshotRestore(t *testing.T) {
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-	if string(d.Value) != "foo" {
+	if string(d.Value) != "foo" || d.TTL != "30s" {
 		t.Fatalf("bad: %v", d)
 	}
 
+	// Verify the TTL index is restored
+	_, ttlEnts, err := fsm2.state.KVSListTTL(nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(ttlEnts) != 1 || ttlEnts[0].Key != "/test" {
+		t.Fatalf("bad: %v", ttlEnts)
+	}
+
 	// Verify session is restored
 	idx, s, err := fsm2.state.SessionGet(nil, session.ID)
 	if err != nil {
@@ -537,12 +556,19 @@ func TestFSM_SnapshotRestore(t *testing.T) {
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-	if a.Name != "User Token" {
+	if a.Name != "User Token" || len(a.Policies) != 1 || a.Policies[0].ID != policy.ID {
 		t.Fatalf("bad: %v", a)
 	}
 	if a.ModifyIndex <= 1 {
 		t.Fatalf("bad index: %d", idx)
 	}
+	_, p, err := fsm2.state.ACLPolicyGet(nil, policy.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(p, policy) {
+		t.Fatalf("bad: %#v", p)
+	}
 	gotB, err := fsm2.state.ACLGetBootstrap()
 	if err != nil {
 		t.Fatalf("err: %v", err)
@@ -1108,6 +1134,112 @@ func TestFSM_KVSUnlock(t *testing.T) {
 	}
 }
 
+func TestFSM_KVSLockQueue_Failover(t *testing.T) {
+	t.Parallel()
+	fsm, err := NewFSM(nil, os.Stderr)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	fsm.state.EnsureNode(1, &structs.Node{Node: "foo", Address: "127.0.0.1"})
+	holder := &structs.Session{ID: generateUUID(), Node: "foo", Behavior: structs.SessionKeysTransfer}
+	fsm.state.SessionCreate(2, holder)
+	next := &structs.Session{ID: generateUUID(), Node: "foo", Behavior: structs.SessionKeysTransfer, LockDelay: time.Minute}
+	fsm.state.SessionCreate(3, next)
+	last := &structs.Session{ID: generateUUID(), Node: "foo"}
+	fsm.state.SessionCreate(4, last)
+
+	// Take the lock and queue the other sessions for it.
+	for i, id := range []string{holder.ID, next.ID, last.ID} {
+		req := structs.KVSRequest{
+			Datacenter: "dc1",
+			Op:         api.KVLockQueue,
+			DirEnt: structs.DirEntry{
+				Key:     "/test/path",
+				Value:   []byte("test"),
+				Session: id,
+			},
+		}
+		buf, err := structs.Encode(structs.KVSRequestType, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		resp := fsm.Apply(makeLog(buf))
+		if resp != (i == 0) {
+			t.Fatalf("resp: %v", resp)
+		}
+	}
+
+	// Snapshot the FSM and restore it on a new one, like a new leader
+	// would.
+	snap, err := fsm.Snapshot()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer snap.Release()
+	buf := bytes.NewBuffer(nil)
+	sink := &MockSink{buf, false}
+	if err := snap.Persist(sink); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	fsm2, err := NewFSM(nil, os.Stderr)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := fsm2.Restore(sink); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The queue survived.
+	_, d, err := fsm2.state.KVSGet(nil, "/test/path")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if d == nil || d.Session != holder.ID ||
+		!reflect.DeepEqual(d.LockWaiters, []string{next.ID, last.ID}) {
+		t.Fatalf("bad: %v", d)
+	}
+
+	// Destroying the holder on the new FSM hands the lock over.
+	destroy := func(id string, invalidate bool) {
+		req := structs.SessionRequest{
+			Datacenter: "dc1",
+			Op:         structs.SessionDestroy,
+			Session:    structs.Session{ID: id},
+			Invalidate: invalidate,
+		}
+		buf, err := structs.Encode(structs.SessionRequestType, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if resp := fsm2.Apply(makeLog(buf)); resp != nil {
+			t.Fatalf("resp: %v", resp)
+		}
+	}
+	destroy(holder.ID, false)
+	_, d, err = fsm2.state.KVSGet(nil, "/test/path")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if d.Session != next.ID || d.LockIndex != 2 ||
+		!reflect.DeepEqual(d.LockWaiters, []string{last.ID}) {
+		t.Fatalf("bad: %v", *d)
+	}
+
+	// An expired session releases the lock under the lock delay instead.
+	destroy(next.ID, true)
+	_, d, err = fsm2.state.KVSGet(nil, "/test/path")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if d.Session != "" || !reflect.DeepEqual(d.LockWaiters, []string{last.ID}) {
+		t.Fatalf("bad: %v", *d)
+	}
+	if expires := fsm2.state.KVSLockDelay("/test/path"); expires.Before(time.Now()) {
+		t.Fatalf("bad: %v", expires)
+	}
+}
+
 func TestFSM_ACL_CRUD(t *testing.T) {
 	t.Parallel()
 	fsm, err := NewFSM(nil, os.Stderr)
@@ -1228,6 +1360,87 @@ func TestFSM_ACL_CRUD(t *testing.T) {
 	verify.Values(t, "", respACL, &bootstrap.ACL)
 }
 
+func TestFSM_ACLPolicy_CRUD(t *testing.T) {
+	t.Parallel()
+	fsm, err := NewFSM(nil, os.Stderr)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Create a new policy.
+	req := structs.ACLPolicyApplyRequest{
+		Datacenter: "dc1",
+		Op:         structs.ACLSet,
+		Policy: structs.ACLPolicy{
+			ID:          generateUUID(),
+			Name:        "web",
+			Rules:       `service "web" { policy = "write" }`,
+			Datacenters: []string{"dc1"},
+		},
+	}
+	buf, err := structs.Encode(structs.ACLPolicyRequestType, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	resp := fsm.Apply(makeLog(buf))
+	if err, ok := resp.(error); ok {
+		t.Fatalf("resp: %v", err)
+	}
+
+	// Get the policy.
+	id := resp.(string)
+	_, policy, err := fsm.state.ACLPolicyGet(nil, id)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	req.Policy.CreateIndex = policy.CreateIndex
+	req.Policy.ModifyIndex = policy.ModifyIndex
+	verify.Values(t, "", policy, &req.Policy)
+
+	// A policy can't take the name of another one.
+	dup := structs.ACLPolicyApplyRequest{
+		Datacenter: "dc1",
+		Op:         structs.ACLSet,
+		Policy: structs.ACLPolicy{
+			ID:   generateUUID(),
+			Name: "web",
+		},
+	}
+	buf, err = structs.Encode(structs.ACLPolicyRequestType, dup)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	resp = fsm.Apply(makeLog(buf))
+	if err, ok := resp.(error); !ok || !strings.Contains(err.Error(), "already in use") {
+		t.Fatalf("resp: %v", resp)
+	}
+
+	// Try to destroy.
+	destroy := structs.ACLPolicyApplyRequest{
+		Datacenter: "dc1",
+		Op:         structs.ACLDelete,
+		Policy: structs.ACLPolicy{
+			ID: id,
+		},
+	}
+	buf, err = structs.Encode(structs.ACLPolicyRequestType, destroy)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	resp = fsm.Apply(makeLog(buf))
+	if resp != nil {
+		t.Fatalf("resp: %v", resp)
+	}
+
+	_, policy, err = fsm.state.ACLPolicyGet(nil, id)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if policy != nil {
+		t.Fatalf("should be destroyed")
+	}
+}
+
 func TestFSM_PreparedQuery_CRUD(t *testing.T) {
 	t.Parallel()
 	fsm, err := NewFSM(nil, os.Stderr)
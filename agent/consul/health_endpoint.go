@@ -21,6 +21,11 @@ func (h *Health) ChecksInState(args *structs.ChecksInStateRequest,
 		return err
 	}
 
+	filter, err := newQueryFilter(args.Filter, reply.HealthChecks)
+	if err != nil {
+		return err
+	}
+
 	return h.srv.blockingQuery(
 		&args.QueryOptions,
 		&reply.QueryMeta,
@@ -40,6 +45,11 @@ func (h *Health) ChecksInState(args *structs.ChecksInStateRequest,
 			if err := h.srv.filterACL(args.Token, reply); err != nil {
 				return err
 			}
+			raw, err := filter.Execute(reply.HealthChecks)
+			if err != nil {
+				return err
+			}
+			reply.HealthChecks = raw.(structs.HealthChecks)
 			return h.srv.sortNodesByDistanceFrom(args.Source, reply.HealthChecks)
 		})
 }
@@ -51,6 +61,11 @@ func (h *Health) NodeChecks(args *structs.NodeSpecificRequest,
 		return err
 	}
 
+	filter, err := newQueryFilter(args.Filter, reply.HealthChecks)
+	if err != nil {
+		return err
+	}
+
 	return h.srv.blockingQuery(
 		&args.QueryOptions,
 		&reply.QueryMeta,
@@ -60,7 +75,15 @@ func (h *Health) NodeChecks(args *structs.NodeSpecificRequest,
 				return err
 			}
 			reply.Index, reply.HealthChecks = index, checks
-			return h.srv.filterACL(args.Token, reply)
+			if err := h.srv.filterACL(args.Token, reply); err != nil {
+				return err
+			}
+			raw, err := filter.Execute(reply.HealthChecks)
+			if err != nil {
+				return err
+			}
+			reply.HealthChecks = raw.(structs.HealthChecks)
+			return nil
 		})
 }
 
@@ -77,6 +100,11 @@ func (h *Health) ServiceChecks(args *structs.ServiceSpecificRequest,
 		return err
 	}
 
+	filter, err := newQueryFilter(args.Filter, reply.HealthChecks)
+	if err != nil {
+		return err
+	}
+
 	return h.srv.blockingQuery(
 		&args.QueryOptions,
 		&reply.QueryMeta,
@@ -96,6 +124,11 @@ func (h *Health) ServiceChecks(args *structs.ServiceSpecificRequest,
 			if err := h.srv.filterACL(args.Token, reply); err != nil {
 				return err
 			}
+			raw, err := filter.Execute(reply.HealthChecks)
+			if err != nil {
+				return err
+			}
+			reply.HealthChecks = raw.(structs.HealthChecks)
 			return h.srv.sortNodesByDistanceFrom(args.Source, reply.HealthChecks)
 		})
 }
@@ -111,7 +144,12 @@ func (h *Health) ServiceNodes(args *structs.ServiceSpecificRequest, reply *struc
 		return fmt.Errorf("Must provide service name")
 	}
 
-	err := h.srv.blockingQuery(
+	filter, err := newQueryFilter(args.Filter, reply.Nodes)
+	if err != nil {
+		return err
+	}
+
+	err = h.srv.blockingQuery(
 		&args.QueryOptions,
 		&reply.QueryMeta,
 		func(ws memdb.WatchSet, state *state.Store) error {
@@ -131,9 +169,17 @@ func (h *Health) ServiceNodes(args *structs.ServiceSpecificRequest, reply *struc
 			if len(args.NodeMetaFilters) > 0 {
 				reply.Nodes = nodeMetaFilter(args.NodeMetaFilters, reply.Nodes)
 			}
+			if len(args.ServiceMetaFilters) > 0 {
+				reply.Nodes = serviceMetaFilter(args.ServiceMetaFilters, reply.Nodes)
+			}
 			if err := h.srv.filterACL(args.Token, reply); err != nil {
 				return err
 			}
+			raw, err := filter.Execute(reply.Nodes)
+			if err != nil {
+				return err
+			}
+			reply.Nodes = raw.(structs.CheckServiceNodes)
 			return h.srv.sortNodesByDistanceFrom(args.Source, reply.Nodes)
 		})
 
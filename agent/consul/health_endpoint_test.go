@@ -601,6 +601,78 @@ func TestHealth_ServiceNodes(t *testing.T) {
 	}
 }
 
+func TestHealth_ServiceNodes_Filter(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	// Register the service on two nodes with checks in different states.
+	for i, reg := range []*structs.RegisterRequest{
+		&structs.RegisterRequest{
+			Datacenter: "dc1",
+			Node:       "foo",
+			Address:    "127.0.0.1",
+			Service:    &structs.NodeService{ID: "db", Service: "db", Tags: []string{"primary"}},
+			Check:      &structs.HealthCheck{Node: "foo", CheckID: "db", Name: "db", ServiceID: "db", Status: api.HealthPassing},
+		},
+		&structs.RegisterRequest{
+			Datacenter: "dc1",
+			Node:       "bar",
+			Address:    "127.0.0.2",
+			Service:    &structs.NodeService{ID: "db", Service: "db", Tags: []string{"secondary"}},
+			Check:      &structs.HealthCheck{Node: "bar", CheckID: "db", Name: "db", ServiceID: "db", Status: api.HealthWarning},
+		},
+	} {
+		if err := s1.fsm.State().EnsureRegistration(uint64(i+1), reg); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	args := structs.ServiceSpecificRequest{
+		Datacenter:  "dc1",
+		ServiceName: "db",
+		QueryOptions: structs.QueryOptions{
+			Filter: "Checks.Status == warning",
+		},
+	}
+	var out structs.IndexedCheckServiceNodes
+	if err := msgpackrpc.CallWithCodec(codec, "Health.ServiceNodes", &args, &out); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(out.Nodes) != 1 || out.Nodes[0].Node.Node != "bar" {
+		t.Fatalf("bad: %v", out.Nodes)
+	}
+
+	args.Filter = "Service.Tags contains primary and Node.Address == 127.0.0.1"
+	if err := msgpackrpc.CallWithCodec(codec, "Health.ServiceNodes", &args, &out); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(out.Nodes) != 1 || out.Nodes[0].Node.Node != "foo" {
+		t.Fatalf("bad: %v", out.Nodes)
+	}
+
+	// The checks endpoints filter the checks themselves.
+	checkArgs := structs.ChecksInStateRequest{
+		Datacenter: "dc1",
+		State:      api.HealthAny,
+		QueryOptions: structs.QueryOptions{
+			Filter: "Node == bar",
+		},
+	}
+	var checks structs.IndexedHealthChecks
+	if err := msgpackrpc.CallWithCodec(codec, "Health.ChecksInState", &checkArgs, &checks); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(checks.HealthChecks) != 1 || checks.HealthChecks[0].Status != api.HealthWarning {
+		t.Fatalf("bad: %v", checks.HealthChecks)
+	}
+}
+
 func TestHealth_ServiceNodes_NodeMetaFilter(t *testing.T) {
 	t.Parallel()
 	dir1, s1 := testServer(t)
@@ -732,6 +804,85 @@ func TestHealth_ServiceNodes_NodeMetaFilter(t *testing.T) {
 	}
 }
 
+func TestHealth_ServiceNodes_ServiceMetaFilter(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	arg := structs.RegisterRequest{
+		Datacenter: "dc1",
+		Node:       "foo",
+		Address:    "127.0.0.1",
+		Service: &structs.NodeService{
+			ID:      "db",
+			Service: "db",
+			Meta:    map[string]string{"version": "2"},
+		},
+	}
+	var out struct{}
+	if err := msgpackrpc.CallWithCodec(codec, "Catalog.Register", &arg, &out); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	arg = structs.RegisterRequest{
+		Datacenter: "dc1",
+		Node:       "bar",
+		Address:    "127.0.0.2",
+		Service: &structs.NodeService{
+			ID:      "db",
+			Service: "db",
+			Meta:    map[string]string{"version": "1"},
+		},
+	}
+	if err := msgpackrpc.CallWithCodec(codec, "Catalog.Register", &arg, &out); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	cases := []struct {
+		filters map[string]string
+		nodes   []string
+	}{
+		{
+			filters: map[string]string{"version": "2"},
+			nodes:   []string{"foo"},
+		},
+		{
+			filters: map[string]string{"version": "1"},
+			nodes:   []string{"bar"},
+		},
+		{
+			filters: map[string]string{"invalid": "nope"},
+			nodes:   []string{},
+		},
+	}
+
+	for _, tc := range cases {
+		req := structs.ServiceSpecificRequest{
+			Datacenter:         "dc1",
+			ServiceMetaFilters: tc.filters,
+			ServiceName:        "db",
+		}
+		var resp structs.IndexedCheckServiceNodes
+		if err := msgpackrpc.CallWithCodec(codec, "Health.ServiceNodes", &req, &resp); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		if len(resp.Nodes) != len(tc.nodes) {
+			t.Fatalf("bad: %v, %v, filters: %v", resp.Nodes, tc.nodes, tc.filters)
+		}
+		for i, node := range resp.Nodes {
+			if node.Node.Node != tc.nodes[i] {
+				t.Fatalf("bad: %v, %v filters: %v", node, tc.nodes[i], tc.filters)
+			}
+		}
+	}
+}
+
 func TestHealth_ServiceNodes_DistanceSort(t *testing.T) {
 	t.Parallel()
 	dir1, s1 := testServer(t)
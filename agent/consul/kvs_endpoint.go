@@ -11,8 +11,14 @@ import (
 	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/sentinel"
 	"github.com/hashicorp/go-memdb"
+	"github.com/hashicorp/go-version"
 )
 
+// minLockQueueVersion is the version all servers need to be on before lock
+// queues, the transfer session behavior and session invalidation can be
+// used, since older servers would apply them differently.
+var minLockQueueVersion = version.Must(version.NewVersion("1.0.0"))
+
 // KVS endpoint is used to manipulate the Key-Value store
 type KVS struct {
 	srv *Server
@@ -27,6 +33,18 @@ func kvsPreApply(srv *Server, rule acl.ACL, op api.KVOp, dirEnt *structs.DirEntr
 	if dirEnt.Key == "" && op != api.KVDeleteTree {
 		return false, fmt.Errorf("Must provide key")
 	}
+	if op == api.KVLockQueue && !ServersMeetMinimumVersion(srv.LANMembers(), minLockQueueVersion) {
+		return false, fmt.Errorf("All servers must be running version %s or later to use lock queues", minLockQueueVersion)
+	}
+	if dirEnt.TTL != "" {
+		ttl, err := time.ParseDuration(dirEnt.TTL)
+		if err != nil {
+			return false, fmt.Errorf("Invalid KV TTL '%s': %v", dirEnt.TTL, err)
+		}
+		if ttl <= 0 {
+			return false, fmt.Errorf("Invalid KV TTL '%s': must be positive", dirEnt.TTL)
+		}
+	}
 
 	// Apply the ACL policy if any.
 	if rule != nil {
@@ -63,7 +81,7 @@ func kvsPreApply(srv *Server, rule acl.ACL, op api.KVOp, dirEnt *structs.DirEntr
 	// after the raft log is committed as it would lead to inconsistent FSMs.
 	// Instead, the lock-delay must be enforced before commit. This means that
 	// only the wall-time of the leader node is used, preventing any inconsistencies.
-	if op == api.KVLock {
+	if op == api.KVLock || op == api.KVLockQueue {
 		state := srv.fsm.State()
 		expires := state.KVSLockDelay(dirEnt.Key)
 		if expires.After(time.Now()) {
@@ -112,6 +130,12 @@ func (k *KVS) Apply(args *structs.KVSRequest, reply *bool) error {
 	if respBool, ok := resp.(bool); ok {
 		*reply = respBool
 	}
+
+	// Start or stop the expiration timer of the key if it was written. Only
+	// the conditional operations return whether they were applied.
+	if respBool, ok := resp.(bool); !ok || respBool {
+		k.srv.updateKVSTimer(args.Op, args.DirEnt.Key)
+	}
 	return nil
 }
 
@@ -2,6 +2,7 @@ package consul
 
 import (
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -821,6 +822,49 @@ func TestKVS_Apply_LockDelay(t *testing.T) {
 	}
 }
 
+func TestKVS_Apply_LockQueue_MinVersion(t *testing.T) {
+	t.Parallel()
+	for _, build := range []string{"0.9.3", "1.0.0"} {
+		dir1, s1 := testServerWithConfig(t, func(c *Config) {
+			c.Build = build
+		})
+		defer os.RemoveAll(dir1)
+		defer s1.Shutdown()
+		codec := rpcClient(t, s1)
+		defer codec.Close()
+
+		testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+		state := s1.fsm.State()
+		if err := state.EnsureNode(1, &structs.Node{Node: "foo", Address: "127.0.0.1"}); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		session := &structs.Session{ID: generateUUID(), Node: "foo"}
+		if err := state.SessionCreate(2, session); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Lock queues can't be used until all the servers know about them.
+		arg := structs.KVSRequest{
+			Datacenter: "dc1",
+			Op:         api.KVLockQueue,
+			DirEnt: structs.DirEntry{
+				Key:     "test",
+				Session: session.ID,
+			},
+		}
+		var out bool
+		err := msgpackrpc.CallWithCodec(codec, "KVS.Apply", &arg, &out)
+		if build == "1.0.0" {
+			if err != nil || !out {
+				t.Fatalf("%s: should acquire: %v", build, err)
+			}
+		} else if err == nil || !strings.Contains(err.Error(), "version 1.0.0 or later") {
+			t.Fatalf("%s: err: %v", build, err)
+		}
+	}
+}
+
 func TestKVS_Issue_1626(t *testing.T) {
 	t.Parallel()
 	dir1, s1 := testServer(t)
@@ -0,0 +1,142 @@
+package consul
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/api"
+)
+
+// initializeKVSTimers is used when a leader is newly elected to reset the
+// timers of all KV entries which have a TTL. Like session timers, this means
+// the TTLs are renewed at the time of failover, since the contract is only
+// that an entry doesn't expire before its TTL.
+func (s *Server) initializeKVSTimers() error {
+	state := s.fsm.State()
+	_, entries, err := state.KVSListTTL(nil)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := s.resetKVSTimer(entry.Key, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resetKVSTimer is used to (re)start the expiration timer of a KV entry after
+// it was written. The entry will be faulted in if not given. If the entry
+// doesn't exist anymore or no longer has a TTL then its timer is stopped.
+func (s *Server) resetKVSTimer(key string, entry *structs.DirEntry) error {
+	// Fault the entry in if not given
+	if entry == nil {
+		state := s.fsm.State()
+		_, e, err := state.KVSGet(nil, key)
+		if err != nil {
+			return err
+		}
+		entry = e
+	}
+	if entry == nil || entry.TTL == "" {
+		s.kvsTimers.Stop(key)
+		return nil
+	}
+
+	ttl, err := time.ParseDuration(entry.TTL)
+	if err != nil {
+		return fmt.Errorf("Invalid KV TTL '%s': %v", entry.TTL, err)
+	}
+
+	// The timer has to be replaced rather than reset since it expires the
+	// entry only as long as it hasn't been modified since.
+	index := entry.ModifyIndex
+	s.kvsTimers.Stop(key)
+	s.kvsTimers.ResetOrCreate(key, ttl, func() { s.expireKVS(key, index) })
+	return nil
+}
+
+// updateKVSTimer updates the expiration timer of the key after a successful
+// KV operation.
+func (s *Server) updateKVSTimer(op api.KVOp, key string) {
+	switch op {
+	case api.KVSet, api.KVCAS, api.KVLock, api.KVUnlock, api.KVDelete, api.KVDeleteCAS:
+		if err := s.resetKVSTimer(key, nil); err != nil {
+			s.logger.Printf("[ERR] consul.kvs: Failed to update TTL of key %q: %v", key, err)
+		}
+	}
+}
+
+// expireKVS is invoked when the TTL of a KV entry is reached and we need to
+// delete it. The delete is a check-and-set against the modify index the timer
+// was started for, so an entry which has been written again since isn't lost.
+// Deleting the entry leaves a tombstone, just like a regular delete, so
+// blocking queries will see the change.
+func (s *Server) expireKVS(key string, index uint64) {
+	defer metrics.MeasureSince([]string{"kvs_ttl", "expire"}, time.Now())
+
+	// Clear the timer
+	s.kvsTimers.Del(key)
+
+	// Create a KVS delete request
+	args := structs.KVSRequest{
+		Datacenter: s.config.Datacenter,
+		Op:         api.KVDeleteCAS,
+		DirEnt: structs.DirEntry{
+			Key: key,
+			RaftIndex: structs.RaftIndex{
+				ModifyIndex: index,
+			},
+		},
+	}
+
+	// Retry with exponential backoff to delete the entry
+	for attempt := uint(0); attempt < maxInvalidateAttempts; attempt++ {
+		resp, err := s.raftApply(structs.KVSRequestType, &args)
+		if err == nil {
+			if respErr, ok := resp.(error); ok {
+				err = respErr
+			}
+		}
+		if err == nil {
+			if ok, _ := resp.(bool); ok {
+				s.logger.Printf("[DEBUG] consul.kvs: Key %q TTL expired", key)
+				return
+			}
+
+			// The entry was written again or deleted in the meantime,
+			// so pick up its current state.
+			if err := s.resetKVSTimer(key, nil); err != nil {
+				s.logger.Printf("[ERR] consul.kvs: Failed to update TTL of key %q: %v", key, err)
+			}
+			return
+		}
+
+		s.logger.Printf("[ERR] consul.kvs: Expiration failed: %v", err)
+		time.Sleep((1 << attempt) * invalidateRetryBase)
+	}
+	s.logger.Printf("[ERR] consul.kvs: maximum expiration attempts reached for key: %s", key)
+}
+
+// clearAllKVSTimers is used when a leader is stepping down and we no longer
+// need to track any KV timers.
+func (s *Server) clearAllKVSTimers() error {
+	s.kvsTimers.StopAll()
+	return nil
+}
+
+// kvsStats is a long running routine used to capture the number of KV
+// entries with a TTL being tracked
+func (s *Server) kvsStats() {
+	for {
+		select {
+		case <-time.After(5 * time.Second):
+			metrics.SetGauge([]string{"kvs_ttl", "active"}, float32(s.kvsTimers.Len()))
+
+		case <-s.shutdownCh:
+			return
+		}
+	}
+}
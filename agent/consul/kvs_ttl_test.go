@@ -0,0 +1,223 @@
+package consul
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/testrpc"
+	"github.com/hashicorp/consul/testutil/retry"
+	"github.com/hashicorp/go-memdb"
+	"github.com/hashicorp/net-rpc-msgpackrpc"
+)
+
+func TestInitializeKVSTimers(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	state := s1.fsm.State()
+	if err := state.KVSSet(100, &structs.DirEntry{Key: "foo", TTL: "10s"}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := state.KVSSet(101, &structs.DirEntry{Key: "bar"}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Reset the KV timers
+	if err := s1.initializeKVSTimers(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Check that we only have a timer for the key with a TTL
+	if s1.kvsTimers.Get("foo") == nil {
+		t.Fatalf("missing kvs timer")
+	}
+	if s1.kvsTimers.Get("bar") != nil {
+		t.Fatalf("unexpected kvs timer")
+	}
+
+	// Clearing the timers on step down removes them
+	if err := s1.clearAllKVSTimers(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if s1.kvsTimers.Len() != 0 {
+		t.Fatalf("timers should be gone")
+	}
+}
+
+func TestKVS_Apply_TTL(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	arg := structs.KVSRequest{
+		Datacenter: "dc1",
+		Op:         api.KVSet,
+		DirEnt: structs.DirEntry{
+			Key:   "test",
+			Value: []byte("test"),
+			TTL:   "250ms",
+		},
+	}
+	var out bool
+	if err := msgpackrpc.CallWithCodec(codec, "KVS.Apply", &arg, &out); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The key should be there with its TTL and a timer running.
+	state := s1.fsm.State()
+	ws := memdb.NewWatchSet()
+	_, d, err := state.KVSGet(ws, "test")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if d == nil || d.TTL != "250ms" {
+		t.Fatalf("bad: %#v", d)
+	}
+	if s1.kvsTimers.Get("test") == nil {
+		t.Fatalf("missing kvs timer")
+	}
+
+	// Wait for it to expire, which should wake up the watch and leave a
+	// tombstone behind so the index doesn't go backwards.
+	retry.Run(t, func(r *retry.R) {
+		_, d, err := state.KVSGet(nil, "test")
+		if err != nil {
+			r.Fatalf("err: %v", err)
+		}
+		if d != nil {
+			r.Fatalf("key should be expired")
+		}
+	})
+	if ws.Watch(nil) {
+		t.Fatalf("watch should have fired")
+	}
+	idx, _, err := state.KVSGet(nil, "test")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if idx <= d.ModifyIndex {
+		t.Fatalf("bad index: %d", idx)
+	}
+	if s1.kvsTimers.Get("test") != nil {
+		t.Fatalf("kvs timer should be gone")
+	}
+}
+
+func TestKVS_Apply_TTL_Cleared(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	arg := structs.KVSRequest{
+		Datacenter: "dc1",
+		Op:         api.KVSet,
+		DirEnt: structs.DirEntry{
+			Key:   "test",
+			Value: []byte("test"),
+			TTL:   "10s",
+		},
+	}
+	var out bool
+	if err := msgpackrpc.CallWithCodec(codec, "KVS.Apply", &arg, &out); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if s1.kvsTimers.Get("test") == nil {
+		t.Fatalf("missing kvs timer")
+	}
+
+	// Writing the key again without a TTL stops the timer.
+	arg.DirEnt.TTL = ""
+	if err := msgpackrpc.CallWithCodec(codec, "KVS.Apply", &arg, &out); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if s1.kvsTimers.Get("test") != nil {
+		t.Fatalf("kvs timer should be gone")
+	}
+
+	// Expiring the old version of the key must not delete the new one.
+	_, d, err := s1.fsm.State().KVSGet(nil, "test")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	s1.expireKVS("test", d.CreateIndex)
+	_, d, err = s1.fsm.State().KVSGet(nil, "test")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if d == nil {
+		t.Fatalf("key should not be expired")
+	}
+
+	// Setting a TTL through a transaction starts the timer again.
+	txn := structs.TxnRequest{
+		Datacenter: "dc1",
+		Ops: structs.TxnOps{
+			&structs.TxnOp{
+				KV: &structs.TxnKVOp{
+					Verb: api.KVSet,
+					DirEnt: structs.DirEntry{
+						Key: "test",
+						TTL: "10s",
+					},
+				},
+			},
+		},
+	}
+	var txnOut structs.TxnResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Txn.Apply", &txn, &txnOut); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(txnOut.Errors) != 0 {
+		t.Fatalf("bad: %v", txnOut.Errors)
+	}
+	if s1.kvsTimers.Get("test") == nil {
+		t.Fatalf("missing kvs timer")
+	}
+}
+
+func TestKVS_Apply_TTL_Invalid(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	for ttl, want := range map[string]string{
+		"nope": "Invalid KV TTL 'nope'",
+		"-1s":  "Invalid KV TTL '-1s': must be positive",
+	} {
+		arg := structs.KVSRequest{
+			Datacenter: "dc1",
+			Op:         api.KVSet,
+			DirEnt: structs.DirEntry{
+				Key: "test",
+				TTL: ttl,
+			},
+		}
+		var out bool
+		err := msgpackrpc.CallWithCodec(codec, "KVS.Apply", &arg, &out)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("%s: err: %v", ttl, err)
+		}
+	}
+}
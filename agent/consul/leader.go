@@ -178,6 +178,12 @@ func (s *Server) establishLeadership() error {
 		return err
 	}
 
+	// Setup the KV timers in the same way, which also renews all the
+	// TTLs at the time of failover.
+	if err := s.initializeKVSTimers(); err != nil {
+		return err
+	}
+
 	s.getOrCreateAutopilotConfig()
 	s.startAutopilot()
 	s.setConsistentReadReady()
@@ -195,6 +201,9 @@ func (s *Server) revokeLeadership() error {
 	if err := s.clearAllSessionTimers(); err != nil {
 		return err
 	}
+	if err := s.clearAllKVSTimers(); err != nil {
+		return err
+	}
 
 	s.resetConsistentReadReady()
 	s.stopAutopilot()
@@ -60,18 +60,18 @@ func Compile(query *structs.PreparedQuery) (*CompiledTemplate, error) {
 		return nil, fmt.Errorf("Failed to copy query")
 	}
 
-	// Walk over all the string fields in the Service sub-structure and
-	// parse them as HIL.
+	// Walk over all the string fields in the interpolated sub-structures
+	// and parse them as HIL.
 	parse := func(path string, v reflect.Value) error {
 		tree, err := hil.Parse(v.String())
 		if err != nil {
-			return fmt.Errorf("Bad format '%s' in Service%s: %s", v.String(), path, err)
+			return fmt.Errorf("Bad format '%s' in %s: %s", v.String(), path, err)
 		}
 
 		ct.trees[path] = tree
 		return nil
 	}
-	if err := walk(&ct.query.Service, parse); err != nil {
+	if err := walkTemplate(ct.query, parse); err != nil {
 		return nil, err
 	}
 
@@ -140,6 +140,13 @@ func (ct *CompiledTemplate) Render(name string, source structs.QuerySource) (*st
 		},
 	}
 
+	funcs := map[string]ast.Function{
+		"match": match,
+	}
+	for name, fn := range stringFuncs {
+		funcs[name] = fn
+	}
+
 	// Build up the HIL evaluation context.
 	config := &hil.EvalConfig{
 		GlobalScope: &ast.BasicScope{
@@ -160,15 +167,21 @@ func (ct *CompiledTemplate) Render(name string, source structs.QuerySource) (*st
 					Type:  ast.TypeString,
 					Value: source.Segment,
 				},
+				"agent.datacenter": ast.Variable{
+					Type:  ast.TypeString,
+					Value: source.Datacenter,
+				},
+				"agent.node": ast.Variable{
+					Type:  ast.TypeString,
+					Value: source.Node,
+				},
 			},
-			FuncMap: map[string]ast.Function{
-				"match": match,
-			},
+			FuncMap: funcs,
 		},
 	}
 
-	// Run through the Service sub-structure and evaluate all the strings
-	// as HIL.
+	// Run through the interpolated sub-structures and evaluate all the
+	// strings as HIL.
 	eval := func(path string, v reflect.Value) error {
 		tree, ok := ct.trees[path]
 		if !ok {
@@ -177,16 +190,16 @@ func (ct *CompiledTemplate) Render(name string, source structs.QuerySource) (*st
 
 		res, err := hil.Eval(tree, config)
 		if err != nil {
-			return fmt.Errorf("Bad evaluation for '%s' in Service%s: %s", v.String(), path, err)
+			return fmt.Errorf("Bad evaluation for '%s' in %s: %s", v.String(), path, err)
 		}
 		if res.Type != hil.TypeString {
-			return fmt.Errorf("Expected Service%s field to be a string, got %s", path, res.Type)
+			return fmt.Errorf("Expected %s field to be a string, got %s", path, res.Type)
 		}
 
 		v.SetString(res.Value.(string))
 		return nil
 	}
-	if err := walk(&query.Service, eval); err != nil {
+	if err := walkTemplate(query, eval); err != nil {
 		return nil, err
 	}
 
@@ -202,3 +215,76 @@ func (ct *CompiledTemplate) Render(name string, source structs.QuerySource) (*st
 
 	return query, nil
 }
+
+// walkTemplate calls the visitor function for each string in the parts of the
+// query that are interpolated, which are the Service and DNS sub-structures.
+// The paths passed to the visitor are prefixed with the sub-structure name,
+// such as "Service.Tags[0]" or "DNS.TTL".
+func walkTemplate(query *structs.PreparedQuery, fn visitor) error {
+	fields := []struct {
+		name string
+		obj  interface{}
+	}{
+		{"Service", &query.Service},
+		{"DNS", &query.DNS},
+	}
+	for _, field := range fields {
+		name := field.name
+		prefixed := func(path string, v reflect.Value) error {
+			return fn(name+path, v)
+		}
+		if err := walk(field.obj, prefixed); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stringFuncs are the string manipulation functions available to templates.
+// Like match, these can't fail at run time, so a template that compiles will
+// always render.
+var stringFuncs = map[string]ast.Function{
+	// lower returns the string in lower case.
+	"lower": ast.Function{
+		ArgTypes:   []ast.Type{ast.TypeString},
+		ReturnType: ast.TypeString,
+		Callback: func(inputs []interface{}) (interface{}, error) {
+			return strings.ToLower(inputs[0].(string)), nil
+		},
+	},
+
+	// upper returns the string in upper case.
+	"upper": ast.Function{
+		ArgTypes:   []ast.Type{ast.TypeString},
+		ReturnType: ast.TypeString,
+		Callback: func(inputs []interface{}) (interface{}, error) {
+			return strings.ToUpper(inputs[0].(string)), nil
+		},
+	},
+
+	// replace returns the string with all the occurrences of the second
+	// argument replaced by the third.
+	"replace": ast.Function{
+		ArgTypes:   []ast.Type{ast.TypeString, ast.TypeString, ast.TypeString},
+		ReturnType: ast.TypeString,
+		Callback: func(inputs []interface{}) (interface{}, error) {
+			s, old, repl := inputs[0].(string), inputs[1].(string), inputs[2].(string)
+			return strings.Replace(s, old, repl, -1), nil
+		},
+	},
+
+	// split splits the string around the separator and returns the element
+	// at the given index, or an empty string if there's no such element.
+	"split": ast.Function{
+		ArgTypes:   []ast.Type{ast.TypeString, ast.TypeString, ast.TypeInt},
+		ReturnType: ast.TypeString,
+		Callback: func(inputs []interface{}) (interface{}, error) {
+			parts := strings.Split(inputs[0].(string), inputs[1].(string))
+			i := inputs[2].(int)
+			if i >= 0 && i < len(parts) {
+				return parts[i], nil
+			}
+			return "", nil
+		},
+	},
+}
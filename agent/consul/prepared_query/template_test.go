@@ -46,7 +46,12 @@ var (
 				"bar": "${match(0)}",
 				"baz": "${match(1)}",
 				"zoo": "${agent.segment}",
+				"dc":  "${agent.datacenter}",
 			},
+			Near: "${agent.node}",
+		},
+		DNS: structs.QueryDNSOptions{
+			TTL: "${replace(lower(match(2)), \"-\", \"\")}",
 		},
 	}
 
@@ -180,6 +185,22 @@ func TestTemplate_Compile(t *testing.T) {
 		t.Fatalf("bad: %v", err)
 	}
 
+	// Try a bad HIL interpolation outside of the Service sub-structure.
+	query.Service.Service = "${name.full}"
+	query.DNS.TTL = "${lower(name.full}"
+	_, err = Compile(query)
+	if err == nil || !strings.Contains(err.Error(), "in DNS.TTL") {
+		t.Fatalf("bad: %v", err)
+	}
+
+	// Try a function with the wrong arguments.
+	query.DNS.TTL = "${split(name.full, 1)}"
+	_, err = Compile(query)
+	if err == nil || !strings.Contains(err.Error(), "split") {
+		t.Fatalf("bad: %v", err)
+	}
+	query.DNS.TTL = ""
+
 	// Try a bad regexp.
 	query.Template.Regexp = "^(nope$"
 	query.Service.Service = "${name.full}"
@@ -382,3 +403,68 @@ func TestTemplate_Render(t *testing.T) {
 		}
 	}
 }
+
+func TestTemplate_Render_AgentAndFunctions(t *testing.T) {
+	// Route "<service>-<tag>" to the service with that tag, near the agent
+	// that made the request.
+	query := &structs.PreparedQuery{
+		Name: "",
+		Template: structs.QueryTemplateOptions{
+			Type:            structs.QueryTemplateTypeNamePrefixMatch,
+			RemoveEmptyTags: true,
+		},
+		Service: structs.ServiceQuery{
+			Service: "${lower(split(name.full, \"-\", 0))}",
+			Failover: structs.QueryDatacenterOptions{
+				Datacenters: []string{
+					"${replace(agent.datacenter, \"east\", \"west\")}",
+				},
+			},
+			Near: "${agent.node}",
+			Tags: []string{
+				"${split(name.full, \"-\", 1)}",
+				"${split(name.full, \"-\", 2)}",
+				"${split(name.full, \"-\", -1)}",
+			},
+			NodeMeta: map[string]string{"segment": "${upper(agent.segment)}"},
+		},
+		DNS: structs.QueryDNSOptions{
+			TTL: "${replace(split(name.full, \"-\", 1), \"canary\", \"5s\")}",
+		},
+	}
+	ct, err := Compile(query)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	source := structs.QuerySource{
+		Datacenter: "east-1",
+		Segment:    "alpha",
+		Node:       "node1",
+	}
+	actual, err := ct.Render("API-canary", source)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	expected := &structs.PreparedQuery{
+		Template: structs.QueryTemplateOptions{
+			Type:            structs.QueryTemplateTypeNamePrefixMatch,
+			RemoveEmptyTags: true,
+		},
+		Service: structs.ServiceQuery{
+			Service: "api",
+			Failover: structs.QueryDatacenterOptions{
+				Datacenters: []string{"west-1"},
+			},
+			Near:     "node1",
+			Tags:     []string{"canary"},
+			NodeMeta: map[string]string{"segment": "ALPHA"},
+		},
+		DNS: structs.QueryDNSOptions{
+			TTL: "5s",
+		},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("bad:\n%#v\nexpected:\n%#v\n", actual, expected)
+	}
+}
@@ -159,8 +159,13 @@ func parseQuery(query *structs.PreparedQuery, enforceVersion8 bool) error {
 		return err
 	}
 
-	// Parse the DNS options sub-structure.
-	if err := parseDNS(&query.DNS); err != nil {
+	// Parse the DNS options sub-structure. A template can interpolate the
+	// TTL, in which case it's only known once the template is rendered.
+	dns := query.DNS
+	if query.Template.Type != "" && strings.Contains(dns.TTL, "${") {
+		dns.TTL = ""
+	}
+	if err := parseDNS(&dns); err != nil {
 		return err
 	}
 
@@ -186,6 +191,9 @@ func parseService(svc *structs.ServiceQuery) error {
 	if err := structs.ValidateMetadata(svc.NodeMeta, true); err != nil {
 		return err
 	}
+	if err := structs.ValidateServiceMetadata(svc.ServiceMeta, true); err != nil {
+		return err
+	}
 
 	// We skip a few fields:
 	// - There's no validation for Datacenters; we skip any unknown entries
@@ -503,6 +511,11 @@ func (p *PreparedQuery) execute(query *structs.PreparedQuery,
 		nodes = nodeMetaFilter(query.Service.NodeMeta, nodes)
 	}
 
+	// Apply the service metadata filters, if any.
+	if len(query.Service.ServiceMeta) > 0 {
+		nodes = serviceMetaFilter(query.Service.ServiceMeta, nodes)
+	}
+
 	// Apply the tag filters, if any.
 	if len(query.Service.Tags) > 0 {
 		nodes = tagFilter(query.Service.Tags, nodes)
@@ -585,6 +598,19 @@ func nodeMetaFilter(filters map[string]string, nodes structs.CheckServiceNodes)
 	return filtered
 }
 
+// serviceMetaFilter returns a list of the service instances who satisfy the
+// given metadata filters. Instances must have ALL the given metadata
+// key/value pairs to pass.
+func serviceMetaFilter(filters map[string]string, nodes structs.CheckServiceNodes) structs.CheckServiceNodes {
+	var filtered structs.CheckServiceNodes
+	for _, node := range nodes {
+		if structs.SatisfiesMetaFilters(node.Service.Meta, filters) {
+			filtered = append(filtered, node)
+		}
+	}
+	return filtered
+}
+
 // queryServer is a wrapper that makes it easier to test the failover logic.
 type queryServer interface {
 	GetLogger() *log.Logger
@@ -58,6 +58,16 @@ func (s *Server) listen(listener net.Listener) {
 			continue
 		}
 
+		// Enforce the per-client connection limit. Other servers are
+		// exempt, and the slot is given back when the conn is closed.
+		ip := remoteIP(conn)
+		if !s.rpcLimits.acquireConn(ip, func() bool { return s.isServerIP(ip) }) {
+			s.logger.Printf("[WARN] consul.rpc: rejecting conn, too many connections from the same client %s", logConn(conn))
+			conn.Close()
+			continue
+		}
+		conn = &limitedConn{Conn: conn, release: func() { s.rpcLimits.releaseConn(ip) }}
+
 		go s.handleConn(conn, false)
 		metrics.IncrCounter([]string{"consul", "rpc", "accept_conn"}, 1)
 		metrics.IncrCounter([]string{"rpc", "accept_conn"}, 1)
@@ -116,6 +126,9 @@ func (s *Server) handleConn(conn net.Conn, isTLS bool) {
 	case pool.RPCSnapshot:
 		s.handleSnapshotConn(conn)
 
+	case pool.RPCSubscribe:
+		s.handleSubscribeConn(conn)
+
 	default:
 		s.logger.Printf("[ERR] consul.rpc: unrecognized RPC byte: %v %s", typ, logConn(conn))
 		conn.Close()
@@ -145,7 +158,11 @@ func (s *Server) handleMultiplexV2(conn net.Conn) {
 // handleConsulConn is used to service a single Consul RPC connection
 func (s *Server) handleConsulConn(conn net.Conn) {
 	defer conn.Close()
-	rpcCodec := msgpackrpc.NewServerCodec(conn)
+	rpcCodec := &limitedServerCodec{
+		ServerCodec: msgpackrpc.NewServerCodec(conn),
+		srv:         s,
+		ip:          remoteIP(conn),
+	}
 	for {
 		select {
 		case <-s.shutdownCh:
@@ -154,6 +171,11 @@ func (s *Server) handleConsulConn(conn net.Conn) {
 		}
 
 		if err := s.rpcServer.ServeRequest(rpcCodec); err != nil {
+			// Requests over the rate limit have already been answered
+			// with the error, so the conn can go on.
+			if structs.IsErrRPCRateExceeded(err) {
+				continue
+			}
 			if err != io.EOF && !strings.Contains(err.Error(), "closed") {
 				s.logger.Printf("[ERR] consul.rpc: RPC error: %v %s", err, logConn(conn))
 				metrics.IncrCounter([]string{"consul", "rpc", "request_error"}, 1)
@@ -166,6 +188,17 @@ func (s *Server) handleConsulConn(conn net.Conn) {
 	}
 }
 
+// handleSubscribeConn is used to serve subscriptions to change events, which
+// stream so don't use the normal RPC mechanism.
+func (s *Server) handleSubscribeConn(conn net.Conn) {
+	go func() {
+		defer conn.Close()
+		if err := s.handleSubscribeRequest(conn); err != nil {
+			s.logger.Printf("[ERR] consul.rpc: Subscribe RPC error: %v %s", err, logConn(conn))
+		}
+	}()
+}
+
 // handleSnapshotConn is used to dispatch snapshot saves and restores, which
 // stream so don't use the normal RPC mechanism.
 func (s *Server) handleSnapshotConn(conn net.Conn) {
@@ -351,6 +384,13 @@ func (s *Server) blockingQuery(queryOpts *structs.QueryOptions, queryMeta *struc
 		goto RUN_QUERY
 	}
 
+	// Take a slot under the blocking query cap. Queries over it are
+	// rejected so the caller can retry later.
+	if !s.rpcLimits.acquireBlockingQuery() {
+		return structs.ErrRPCRateExceeded
+	}
+	defer s.rpcLimits.releaseBlockingQuery()
+
 	// Restrict the max query time, and ensure there is always one.
 	if queryOpts.MaxQueryTime > maxQueryTime {
 		queryOpts.MaxQueryTime = maxQueryTime
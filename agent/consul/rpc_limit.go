@@ -0,0 +1,310 @@
+package consul
+
+import (
+	"net"
+	"net/rpc"
+	"sync"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/consul/agent/metadata"
+	"github.com/hashicorp/consul/agent/structs"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// rpcLimitsPruneInterval is how often the token buckets of sources
+	// that went away are removed.
+	rpcLimitsPruneInterval = time.Minute
+
+	// rpcLimitsIdleTimeout is how long a token bucket has to be unused
+	// before it's removed.
+	rpcLimitsIdleTimeout = 10 * time.Minute
+)
+
+// rpcLimits holds the limits a server applies to the RPCs it gets from other
+// agents, along with the state needed to enforce them. The limits can be
+// changed while the server is running.
+type rpcLimits struct {
+	sync.Mutex
+
+	readRate           rate.Limit
+	readBurst          int
+	writeRate          rate.Limit
+	writeBurst         int
+	maxBlockingQueries int
+	maxConnsPerClient  int
+
+	// limiters has the token buckets for reads and writes by source IP and
+	// by ACL token.
+	limiters map[rpcLimitKey]*rpcBucket
+
+	// blockingQueries is the number of blocking queries running.
+	blockingQueries int
+
+	// conns is the number of open connections by source IP.
+	conns map[string]int
+}
+
+// rpcLimitKey identifies a token bucket. Only one of the source IP and the
+// ACL token is set.
+type rpcLimitKey struct {
+	write bool
+	ip    string
+	token string
+}
+
+// rpcBucket is a token bucket along with the last time it was used.
+type rpcBucket struct {
+	limiter  *rate.Limiter
+	lastUsed time.Time
+}
+
+// newRPCLimits returns the limits of the given config.
+func newRPCLimits(config *Config) *rpcLimits {
+	l := &rpcLimits{
+		limiters: make(map[rpcLimitKey]*rpcBucket),
+		conns:    make(map[string]int),
+	}
+	l.update(config)
+	return l
+}
+
+// update applies the limits of the given config. All the token buckets start
+// over when the rates change, and lowered caps only apply to new blocking
+// queries and connections.
+func (l *rpcLimits) update(config *Config) {
+	l.Lock()
+	defer l.Unlock()
+
+	if config.RPCServerReadRate != l.readRate || config.RPCServerReadMaxBurst != l.readBurst ||
+		config.RPCServerWriteRate != l.writeRate || config.RPCServerWriteMaxBurst != l.writeBurst {
+		l.limiters = make(map[rpcLimitKey]*rpcBucket)
+	}
+	l.readRate, l.readBurst = config.RPCServerReadRate, config.RPCServerReadMaxBurst
+	l.writeRate, l.writeBurst = config.RPCServerWriteRate, config.RPCServerWriteMaxBurst
+	l.maxBlockingQueries = config.RPCMaxBlockingQueries
+	l.maxConnsPerClient = config.RPCMaxConnsPerClient
+}
+
+// rateLimited returns whether reads or writes are rate limited.
+func (l *rpcLimits) rateLimited() bool {
+	l.Lock()
+	defer l.Unlock()
+	return l.readRate != rate.Inf || l.writeRate != rate.Inf
+}
+
+// allow returns ErrRPCRateExceeded if the request is over the budget of its
+// source IP or of its ACL token. Requests without a token are only limited by
+// their source IP.
+func (l *rpcLimits) allow(ip string, info structs.RPCInfo) error {
+	write := !info.IsRead()
+
+	l.Lock()
+	defer l.Unlock()
+
+	r, burst := l.readRate, l.readBurst
+	if write {
+		r, burst = l.writeRate, l.writeBurst
+	}
+	if r == rate.Inf {
+		return nil
+	}
+
+	now := time.Now()
+	keys := []rpcLimitKey{{write: write, ip: ip}}
+	if token := info.ACLToken(); token != "" {
+		keys = append(keys, rpcLimitKey{write: write, token: token})
+	}
+	for _, key := range keys {
+		lim, ok := l.limiters[key]
+		if !ok {
+			lim = &rpcBucket{limiter: rate.NewLimiter(r, burst)}
+			l.limiters[key] = lim
+		}
+		lim.lastUsed = now
+		if !lim.limiter.AllowN(now, 1) {
+			kind := "read"
+			if write {
+				kind = "write"
+			}
+			metrics.IncrCounter([]string{"consul", "rpc", "rate_limit", kind}, 1)
+			metrics.IncrCounter([]string{"rpc", "rate_limit", kind}, 1)
+			return structs.ErrRPCRateExceeded
+		}
+	}
+	return nil
+}
+
+// acquireBlockingQuery takes a slot for a blocking query, returning false if
+// they are all in use. The slot must be given back with releaseBlockingQuery.
+func (l *rpcLimits) acquireBlockingQuery() bool {
+	l.Lock()
+	defer l.Unlock()
+
+	if l.maxBlockingQueries > 0 && l.blockingQueries >= l.maxBlockingQueries {
+		metrics.IncrCounter([]string{"consul", "rpc", "blocking_query", "rejected"}, 1)
+		metrics.IncrCounter([]string{"rpc", "blocking_query", "rejected"}, 1)
+		return false
+	}
+	l.blockingQueries++
+	return true
+}
+
+// releaseBlockingQuery gives back the slot of a blocking query.
+func (l *rpcLimits) releaseBlockingQuery() {
+	l.Lock()
+	defer l.Unlock()
+	l.blockingQueries--
+}
+
+// acquireConn takes a connection slot for the source IP, returning false if
+// it has all its slots in use and isn't exempt. The exempt func is only
+// called then, and without holding the lock since it may be slow. The slot
+// must be given back with releaseConn.
+func (l *rpcLimits) acquireConn(ip string, exempt func() bool) bool {
+	l.Lock()
+	full := l.maxConnsPerClient > 0 && l.conns[ip] >= l.maxConnsPerClient
+	if !full {
+		l.conns[ip]++
+	}
+	l.Unlock()
+	if !full {
+		return true
+	}
+
+	if !exempt() {
+		metrics.IncrCounter([]string{"consul", "rpc", "conn", "rejected"}, 1)
+		metrics.IncrCounter([]string{"rpc", "conn", "rejected"}, 1)
+		return false
+	}
+	l.Lock()
+	l.conns[ip]++
+	l.Unlock()
+	return true
+}
+
+// releaseConn gives back a connection slot of the source IP.
+func (l *rpcLimits) releaseConn(ip string) {
+	l.Lock()
+	defer l.Unlock()
+
+	l.conns[ip]--
+	if l.conns[ip] <= 0 {
+		delete(l.conns, ip)
+	}
+}
+
+// prune removes the token buckets that haven't been used for the given time,
+// so the sources and tokens that went away don't pile up.
+func (l *rpcLimits) prune(idle time.Duration) {
+	l.Lock()
+	defer l.Unlock()
+
+	cutoff := time.Now().Add(-idle)
+	for key, lim := range l.limiters {
+		if lim.lastUsed.Before(cutoff) {
+			delete(l.limiters, key)
+		}
+	}
+}
+
+// pruneRPCLimits periodically removes the unused token buckets until the
+// server shuts down.
+func (s *Server) pruneRPCLimits() {
+	for {
+		select {
+		case <-time.After(rpcLimitsPruneInterval):
+			s.rpcLimits.prune(rpcLimitsIdleTimeout)
+
+		case <-s.shutdownCh:
+			return
+		}
+	}
+}
+
+// acquireSubscription applies the RPC limits to a subscription from the
+// given IP. Subscriptions are long-lived like blocking queries, so besides
+// the read rate limit they take a slot under the blocking query cap, which
+// must be given back with releaseBlockingQuery.
+func (s *Server) acquireSubscription(ip string, args *structs.SubscribeRequest) error {
+	if s.rpcLimits.rateLimited() && !s.isServerIP(ip) {
+		if err := s.rpcLimits.allow(ip, args); err != nil {
+			return err
+		}
+	}
+	if !s.rpcLimits.acquireBlockingQuery() {
+		return structs.ErrRPCRateExceeded
+	}
+	return nil
+}
+
+// isServerIP returns whether the IP address belongs to a Consul server in any
+// datacenter. RPCs from servers are forwarded on behalf of others, so they
+// are never limited.
+func (s *Server) isServerIP(ip string) bool {
+	members := s.LANMembers()
+	if s.serfWAN != nil {
+		members = append(members, s.WANMembers()...)
+	}
+	for _, m := range members {
+		if ok, _ := metadata.IsConsulServer(m); ok && m.Addr.String() == ip {
+			return true
+		}
+	}
+	return false
+}
+
+// remoteIP returns the IP address of the remote end of the connection.
+func remoteIP(conn net.Conn) string {
+	addr := conn.RemoteAddr().String()
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// limitedConn is a connection that gives back its slot in the per-client
+// connection limit when it's closed.
+type limitedConn struct {
+	net.Conn
+	once    sync.Once
+	release func()
+}
+
+func (c *limitedConn) Close() error {
+	c.once.Do(c.release)
+	return c.Conn.Close()
+}
+
+// limitedServerCodec applies the server's RPC rate limits to the requests
+// read from a connection. Requests over the limit are answered with the error
+// without being run.
+type limitedServerCodec struct {
+	rpc.ServerCodec
+	srv *Server
+	ip  string
+
+	// fromServer is whether the connection comes from another server. It's
+	// only looked up once requests are limited.
+	fromServer     bool
+	fromServerOnce sync.Once
+}
+
+func (c *limitedServerCodec) ReadRequestBody(body interface{}) error {
+	if err := c.ServerCodec.ReadRequestBody(body); err != nil {
+		return err
+	}
+
+	info, ok := body.(structs.RPCInfo)
+	if !ok || !c.srv.rpcLimits.rateLimited() {
+		return nil
+	}
+	c.fromServerOnce.Do(func() {
+		c.fromServer = c.srv.isServerIP(c.ip)
+	})
+	if c.fromServer {
+		return nil
+	}
+	return c.srv.rpcLimits.allow(c.ip, info)
+}
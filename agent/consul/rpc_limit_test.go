@@ -0,0 +1,312 @@
+package consul
+
+import (
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/agent/consul/state"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/testrpc"
+	"github.com/hashicorp/go-memdb"
+	"github.com/hashicorp/net-rpc-msgpackrpc"
+	"golang.org/x/time/rate"
+)
+
+func testRPCLimitsConfig() *Config {
+	config := DefaultConfig()
+	config.RPCServerReadRate = 1
+	config.RPCServerReadMaxBurst = 2
+	config.RPCServerWriteRate = 1
+	config.RPCServerWriteMaxBurst = 1
+	return config
+}
+
+func TestRPCLimits_Allow(t *testing.T) {
+	t.Parallel()
+	l := newRPCLimits(testRPCLimitsConfig())
+
+	read := &structs.DCSpecificRequest{}
+	write := &structs.RegisterRequest{}
+
+	// Reads and writes have separate budgets.
+	for i := 0; i < 2; i++ {
+		if err := l.allow("1.2.3.4", read); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+	if err := l.allow("1.2.3.4", read); !structs.IsErrRPCRateExceeded(err) {
+		t.Fatalf("err: %v", err)
+	}
+	if err := l.allow("1.2.3.4", write); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := l.allow("1.2.3.4", write); !structs.IsErrRPCRateExceeded(err) {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Another source has its own budget.
+	if err := l.allow("5.6.7.8", write); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// A token is limited across sources.
+	tokenWrite := &structs.RegisterRequest{
+		WriteRequest: structs.WriteRequest{Token: "foo"},
+	}
+	if err := l.allow("9.9.9.1", tokenWrite); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := l.allow("9.9.9.2", tokenWrite); !structs.IsErrRPCRateExceeded(err) {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func TestRPCLimits_Allow_Unlimited(t *testing.T) {
+	t.Parallel()
+	l := newRPCLimits(DefaultConfig())
+	if l.rateLimited() {
+		t.Fatalf("should not be rate limited")
+	}
+	for i := 0; i < 10000; i++ {
+		if err := l.allow("1.2.3.4", &structs.RegisterRequest{}); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+	if len(l.limiters) != 0 {
+		t.Fatalf("bad: %v", l.limiters)
+	}
+}
+
+func TestRPCLimits_Update(t *testing.T) {
+	t.Parallel()
+	config := testRPCLimitsConfig()
+	l := newRPCLimits(config)
+
+	write := &structs.RegisterRequest{}
+	if err := l.allow("1.2.3.4", write); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := l.allow("1.2.3.4", write); !structs.IsErrRPCRateExceeded(err) {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The same limits keep the buckets.
+	l.update(config)
+	if err := l.allow("1.2.3.4", write); !structs.IsErrRPCRateExceeded(err) {
+		t.Fatalf("err: %v", err)
+	}
+
+	// New limits start over.
+	config.RPCServerWriteMaxBurst = 2
+	l.update(config)
+	for i := 0; i < 2; i++ {
+		if err := l.allow("1.2.3.4", write); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	// Limits can be removed.
+	config.RPCServerReadRate = rate.Inf
+	config.RPCServerWriteRate = rate.Inf
+	l.update(config)
+	if l.rateLimited() {
+		t.Fatalf("should not be rate limited")
+	}
+	if err := l.allow("1.2.3.4", write); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func TestRPCLimits_Prune(t *testing.T) {
+	t.Parallel()
+	l := newRPCLimits(testRPCLimitsConfig())
+	if err := l.allow("1.2.3.4", &structs.DCSpecificRequest{}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	l.prune(time.Hour)
+	if len(l.limiters) != 1 {
+		t.Fatalf("bad: %v", l.limiters)
+	}
+	l.prune(0)
+	if len(l.limiters) != 0 {
+		t.Fatalf("bad: %v", l.limiters)
+	}
+}
+
+func TestRPCLimits_BlockingQueries(t *testing.T) {
+	t.Parallel()
+	config := DefaultConfig()
+	config.RPCMaxBlockingQueries = 2
+	l := newRPCLimits(config)
+
+	for i := 0; i < 2; i++ {
+		if !l.acquireBlockingQuery() {
+			t.Fatalf("should acquire")
+		}
+	}
+	if l.acquireBlockingQuery() {
+		t.Fatalf("should not acquire")
+	}
+	l.releaseBlockingQuery()
+	if !l.acquireBlockingQuery() {
+		t.Fatalf("should acquire")
+	}
+
+	// No cap lets them all through.
+	config.RPCMaxBlockingQueries = 0
+	l.update(config)
+	if !l.acquireBlockingQuery() {
+		t.Fatalf("should acquire")
+	}
+}
+
+func TestRPCLimits_Conns(t *testing.T) {
+	t.Parallel()
+	config := DefaultConfig()
+	config.RPCMaxConnsPerClient = 1
+	l := newRPCLimits(config)
+
+	exempt := func() bool { return false }
+	if !l.acquireConn("1.2.3.4", exempt) {
+		t.Fatalf("should acquire")
+	}
+	if l.acquireConn("1.2.3.4", exempt) {
+		t.Fatalf("should not acquire")
+	}
+	if !l.acquireConn("5.6.7.8", exempt) {
+		t.Fatalf("should acquire")
+	}
+
+	// Exempt sources aren't limited. The check doesn't run under the
+	// lock, so it can take its time.
+	if !l.acquireConn("1.2.3.4", func() bool { return !l.rateLimited() }) {
+		t.Fatalf("should acquire")
+	}
+
+	// Released slots can be used again.
+	l.releaseConn("1.2.3.4")
+	l.releaseConn("1.2.3.4")
+	if len(l.conns) != 1 {
+		t.Fatalf("bad: %v", l.conns)
+	}
+	if !l.acquireConn("1.2.3.4", exempt) {
+		t.Fatalf("should acquire")
+	}
+}
+
+func TestRPC_blockingQuery_Limit(t *testing.T) {
+	t.Parallel()
+	dir, s := testServerWithConfig(t, func(c *Config) {
+		c.RPCMaxBlockingQueries = 1
+	})
+	defer os.RemoveAll(dir)
+	defer s.Shutdown()
+
+	// Hold the only slot with a query that blocks until it's told to
+	// return.
+	doneCh := make(chan struct{})
+	blockingCh := make(chan struct{})
+	errCh := make(chan error, 1)
+	go func() {
+		opts := structs.QueryOptions{MinQueryIndex: 3}
+		var meta structs.QueryMeta
+		errCh <- s.blockingQuery(&opts, &meta, func(ws memdb.WatchSet, state *state.Store) error {
+			meta.Index = 3
+			select {
+			case <-doneCh:
+				meta.Index = 4
+				return nil
+			default:
+			}
+			select {
+			case <-blockingCh:
+			default:
+				close(blockingCh)
+			}
+			ws.Add(doneCh)
+			return nil
+		})
+	}()
+	<-blockingCh
+
+	fn := func(ws memdb.WatchSet, state *state.Store) error { return nil }
+	opts := structs.QueryOptions{MinQueryIndex: 3}
+	var meta structs.QueryMeta
+	if err := s.blockingQuery(&opts, &meta, fn); !structs.IsErrRPCRateExceeded(err) {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Non-blocking queries aren't limited.
+	opts = structs.QueryOptions{}
+	if err := s.blockingQuery(&opts, &meta, fn); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Raising the cap lets blocking queries through.
+	config := *s.config
+	config.RPCMaxBlockingQueries = 2
+	if err := s.ReloadConfig(&config); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	opts = structs.QueryOptions{MinQueryIndex: 3, MaxQueryTime: 10 * time.Millisecond}
+	if err := s.blockingQuery(&opts, &meta, fn); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	close(doneCh)
+	if err := <-errCh; err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func TestRPC_RateLimit(t *testing.T) {
+	t.Parallel()
+	dir, s := testServerWithConfig(t, func(c *Config) {
+		c.RPCServerWriteRate = 0.001
+		c.RPCServerWriteMaxBurst = 1
+	})
+	defer os.RemoveAll(dir)
+	defer s.Shutdown()
+	testrpc.WaitForLeader(t, s.RPC, "dc1")
+
+	// Serve a conn over a pipe, since the conns made over the network
+	// come from the server's own address, which is exempt.
+	client, server := net.Pipe()
+	go s.handleConsulConn(server)
+	codec := msgpackrpc.NewClientCodec(client)
+	defer codec.Close()
+
+	args := structs.RegisterRequest{
+		Datacenter: "dc1",
+		Node:       "foo",
+		Address:    "127.0.0.1",
+	}
+	var out struct{}
+	if err := msgpackrpc.CallWithCodec(codec, "Catalog.Register", &args, &out); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	err := msgpackrpc.CallWithCodec(codec, "Catalog.Register", &args, &out)
+	if err == nil || !structs.IsErrRPCRateExceeded(err) {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The conn is still usable, and reads have their own budget.
+	nodesArgs := structs.DCSpecificRequest{Datacenter: "dc1"}
+	var nodes structs.IndexedNodes
+	if err := msgpackrpc.CallWithCodec(codec, "Catalog.ListNodes", &nodesArgs, &nodes); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Removing the limit lets writes through again.
+	config := *s.config
+	config.RPCServerWriteRate = rate.Inf
+	if err := s.ReloadConfig(&config); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := msgpackrpc.CallWithCodec(codec, "Catalog.Register", &args, &out); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
@@ -97,6 +97,12 @@ type Server struct {
 	// autopilotWaitGroup is used to block until Autopilot shuts down.
 	autopilotWaitGroup sync.WaitGroup
 
+	// autopilotUpgradeVoters is the number of voters when the upgrade
+	// migration in progress started, or 0 if there's none. It's only
+	// tracked while Autopilot runs on the leader.
+	autopilotUpgradeVoters     int
+	autopilotUpgradeVotersLock sync.Mutex
+
 	// clusterHealth stores the current view of the cluster's health.
 	clusterHealth     structs.OperatorHealthReply
 	clusterHealthLock sync.RWMutex
@@ -162,6 +168,9 @@ type Server struct {
 	// rpcTLS is the TLS config for incoming TLS requests
 	rpcTLS *tls.Config
 
+	// rpcLimits are the limits applied to the RPCs from other agents.
+	rpcLimits *rpcLimits
+
 	// serfLAN is the Serf cluster maintained inside the DC
 	// which contains all the DC nodes
 	serfLAN *serf.Serf
@@ -186,6 +195,11 @@ type Server struct {
 	// destroy the session via standard session destroy processing
 	sessionTimers *SessionTimers
 
+	// kvsTimers track the expiration time of each KV entry that has a
+	// TTL. On expiration, the entry is deleted if it hasn't been written
+	// again in the meantime.
+	kvsTimers *SessionTimers
+
 	// statsFetcher is used by autopilot to check the status of the other
 	// Consul router.
 	statsFetcher *StatsFetcher
@@ -305,17 +319,19 @@ func NewServerLogger(config *Config, logger *log.Logger, tokens *token.Store) (*
 		reconcileCh:           make(chan serf.Member, 32),
 		router:                router.NewRouter(logger, config.Datacenter),
 		rpcServer:             rpc.NewServer(),
+		rpcLimits:             newRPCLimits(config),
 		rpcTLS:                incomingTLS,
 		reassertLeaderCh:      make(chan chan error),
 		segmentLAN:            make(map[string]*serf.Serf, len(config.Segments)),
 		sessionTimers:         NewSessionTimers(),
+		kvsTimers:             NewSessionTimers(),
 		tombstoneGC:           gc,
 		serverLookup:          NewServerLookup(),
 		shutdownCh:            shutdownCh,
 	}
 
 	// Set up the autopilot policy
-	s.autopilotPolicy = &BasicAutopilot{server: s}
+	s.autopilotPolicy = &AdvancedAutopilot{server: s}
 
 	// Initialize the stats fetcher that autopilot will use.
 	s.statsFetcher = NewStatsFetcher(logger, s.connPool, s.config.Datacenter)
@@ -437,6 +453,10 @@ func NewServerLogger(config *Config, logger *log.Logger, tokens *token.Store) (*
 
 	// Start the metrics handlers.
 	go s.sessionStats()
+	go s.kvsStats()
+
+	// Start cleaning up the RPC limits of sources that went away.
+	go s.pruneRPCLimits()
 
 	// Start the server health checking.
 	go s.serverHealthLoop()
@@ -880,6 +900,13 @@ func (s *Server) WANMembers() []serf.Member {
 	return s.serfWAN.Members()
 }
 
+// ReloadConfig applies the parts of the config that can be changed while the
+// server is running.
+func (s *Server) ReloadConfig(config *Config) error {
+	s.rpcLimits.update(config)
+	return nil
+}
+
 // RemoveFailedNode is used to remove a failed node from the cluster
 func (s *Server) RemoveFailedNode(node string) error {
 	if err := s.serfLAN.RemoveFailedNode(node); err != nil {
@@ -67,6 +67,17 @@ func (s *Server) setupSerf(conf *serf.Config, ch chan serf.Event, path string, w
 	if s.config.UseTLS {
 		conf.Tags["use_tls"] = "1"
 	}
+	if !wan {
+		// Advertise the node meta Autopilot needs to place this server.
+		for _, key := range []string{
+			s.config.AutopilotConfig.RedundancyZoneTag,
+			s.config.AutopilotConfig.UpgradeVersionTag,
+		} {
+			if value, ok := s.config.NodeMeta[key]; ok && key != "" {
+				conf.Tags["meta_"+key] = value
+			}
+		}
+	}
 	if s.logger == nil {
 		conf.MemberlistConfig.LogOutput = s.config.LogOutput
 		conf.LogOutput = s.config.LogOutput
@@ -33,6 +33,9 @@ func (s *Session) Apply(args *structs.SessionRequest, reply *string) error {
 	if args.Session.Node == "" && args.Op == structs.SessionCreate {
 		return fmt.Errorf("Must provide Node")
 	}
+	if args.Invalidate && !ServersMeetMinimumVersion(s.srv.LANMembers(), minLockQueueVersion) {
+		return fmt.Errorf("All servers must be running version %s or later to invalidate sessions", minLockQueueVersion)
+	}
 
 	// Fetch the ACL token, if any, and apply the policy.
 	rule, err := s.srv.resolveToken(args.Token)
@@ -64,28 +67,9 @@ func (s *Session) Apply(args *structs.SessionRequest, reply *string) error {
 		}
 	}
 
-	// Ensure that the specified behavior is allowed
-	switch args.Session.Behavior {
-	case "":
-		// Default behavior to Release for backwards compatibility
-		args.Session.Behavior = structs.SessionKeysRelease
-	case structs.SessionKeysRelease:
-	case structs.SessionKeysDelete:
-	default:
-		return fmt.Errorf("Invalid Behavior setting '%s'", args.Session.Behavior)
-	}
-
-	// Ensure the Session TTL is valid if provided
-	if args.Session.TTL != "" {
-		ttl, err := time.ParseDuration(args.Session.TTL)
-		if err != nil {
-			return fmt.Errorf("Session TTL '%s' invalid: %v", args.Session.TTL, err)
-		}
-
-		if ttl != 0 && (ttl < s.srv.config.SessionTTLMin || ttl > structs.SessionTTLMax) {
-			return fmt.Errorf("Invalid Session TTL '%d', must be between [%v=%v]",
-				ttl, s.srv.config.SessionTTLMin, structs.SessionTTLMax)
-		}
+	// Ensure that the specified behavior and TTL are allowed.
+	if err := sessionPreApply(s.srv, &args.Session); err != nil {
+		return err
 	}
 
 	// If this is a create, we must generate the Session ID. This must
@@ -93,22 +77,9 @@ func (s *Session) Apply(args *structs.SessionRequest, reply *string) error {
 	// deterministic. Once the entry is in the log, the state update MUST
 	// be deterministic or the followers will not converge.
 	if args.Op == structs.SessionCreate {
-		// Generate a new session ID, verify uniqueness
-		state := s.srv.fsm.State()
-		for {
-			var err error
-			if args.Session.ID, err = uuid.GenerateUUID(); err != nil {
-				s.srv.logger.Printf("[ERR] consul.session: UUID generation failed: %v", err)
-				return err
-			}
-			_, sess, err := state.SessionGet(nil, args.Session.ID)
-			if err != nil {
-				s.srv.logger.Printf("[ERR] consul.session: Session lookup failed: %v", err)
-				return err
-			}
-			if sess == nil {
-				break
-			}
+		if args.Session.ID, err = generateSessionID(s.srv); err != nil {
+			s.srv.logger.Printf("[ERR] consul.session: %v", err)
+			return err
 		}
 	}
 
@@ -257,3 +228,57 @@ func (s *Session) Renew(args *structs.SessionSpecificRequest,
 
 	return nil
 }
+
+// sessionPreApply verifies the behavior and TTL of the given session, filling
+// in the default behavior if none was given.
+func sessionPreApply(srv *Server, sess *structs.Session) error {
+	// Ensure that the specified behavior is allowed
+	switch sess.Behavior {
+	case "":
+		// Default behavior to Release for backwards compatibility
+		sess.Behavior = structs.SessionKeysRelease
+	case structs.SessionKeysRelease:
+	case structs.SessionKeysDelete:
+	case structs.SessionKeysTransfer:
+		if !ServersMeetMinimumVersion(srv.LANMembers(), minLockQueueVersion) {
+			return fmt.Errorf("All servers must be running version %s or later to use the '%s' behavior",
+				minLockQueueVersion, sess.Behavior)
+		}
+	default:
+		return fmt.Errorf("Invalid Behavior setting '%s'", sess.Behavior)
+	}
+
+	// Ensure the Session TTL is valid if provided
+	if sess.TTL != "" {
+		ttl, err := time.ParseDuration(sess.TTL)
+		if err != nil {
+			return fmt.Errorf("Session TTL '%s' invalid: %v", sess.TTL, err)
+		}
+
+		if ttl != 0 && (ttl < srv.config.SessionTTLMin || ttl > structs.SessionTTLMax) {
+			return fmt.Errorf("Invalid Session TTL '%d', must be between [%v=%v]",
+				ttl, srv.config.SessionTTLMin, structs.SessionTTLMax)
+		}
+	}
+
+	return nil
+}
+
+// generateSessionID returns a new session ID that isn't used by any session
+// in the state store.
+func generateSessionID(srv *Server) (string, error) {
+	state := srv.fsm.State()
+	for {
+		id, err := uuid.GenerateUUID()
+		if err != nil {
+			return "", fmt.Errorf("UUID generation failed: %v", err)
+		}
+		_, sess, err := state.SessionGet(nil, id)
+		if err != nil {
+			return "", fmt.Errorf("Session lookup failed: %v", err)
+		}
+		if sess == nil {
+			return id, nil
+		}
+	}
+}
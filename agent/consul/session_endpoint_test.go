@@ -2,6 +2,7 @@ package consul
 
 import (
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -72,6 +73,55 @@ func TestSession_Apply(t *testing.T) {
 	}
 }
 
+func TestSession_Apply_MinVersion(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.Build = "0.9.3"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	state := s1.fsm.State()
+	if err := state.EnsureNode(1, &structs.Node{Node: "foo", Address: "127.0.0.1"}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The transfer behavior and invalidations can't be used until all the
+	// servers know about them.
+	arg := structs.SessionRequest{
+		Datacenter: "dc1",
+		Op:         structs.SessionCreate,
+		Session: structs.Session{
+			Node:     "foo",
+			Behavior: structs.SessionKeysTransfer,
+		},
+	}
+	var out string
+	err := msgpackrpc.CallWithCodec(codec, "Session.Apply", &arg, &out)
+	if err == nil || !strings.Contains(err.Error(), "version 1.0.0 or later") {
+		t.Fatalf("err: %v", err)
+	}
+
+	arg.Session.Behavior = structs.SessionKeysRelease
+	if err := msgpackrpc.CallWithCodec(codec, "Session.Apply", &arg, &out); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	arg.Op = structs.SessionDestroy
+	arg.Session.ID = out
+	arg.Invalidate = true
+	err = msgpackrpc.CallWithCodec(codec, "Session.Apply", &arg, &out)
+	if err == nil || !strings.Contains(err.Error(), "version 1.0.0 or later") {
+		t.Fatalf("err: %v", err)
+	}
+	if _, s, err := state.SessionGet(nil, arg.Session.ID); err != nil || s == nil {
+		t.Fatalf("bad: %v %v", s, err)
+	}
+}
+
 func TestSession_DeleteApply(t *testing.T) {
 	t.Parallel()
 	dir1, s1 := testServer(t)
@@ -90,13 +90,17 @@ func (s *Server) invalidateSession(id string) {
 	// Clear the session timer
 	s.sessionTimers.Del(id)
 
-	// Create a session destroy request
+	// Create a session destroy request. Older servers would apply an
+	// invalidation as a plain destroy, so it's only used once they're all
+	// upgraded, which is also when sessions with the transfer behavior that
+	// need it can be created.
 	args := structs.SessionRequest{
 		Datacenter: s.config.Datacenter,
 		Op:         structs.SessionDestroy,
 		Session: structs.Session{
 			ID: id,
 		},
+		Invalidate: ServersMeetMinimumVersion(s.LANMembers(), minLockQueueVersion),
 	}
 
 	// Retry with exponential backoff to invalidate the session
@@ -44,6 +44,34 @@ func aclsBootstrapTableSchema() *memdb.TableSchema {
 	}
 }
 
+// aclPoliciesTableSchema returns a new table schema used for storing ACL
+// policies, which can be linked to any number of tokens.
+func aclPoliciesTableSchema() *memdb.TableSchema {
+	return &memdb.TableSchema{
+		Name: "acl-policies",
+		Indexes: map[string]*memdb.IndexSchema{
+			"id": &memdb.IndexSchema{
+				Name:         "id",
+				AllowMissing: false,
+				Unique:       true,
+				Indexer: &memdb.StringFieldIndex{
+					Field:     "ID",
+					Lowercase: false,
+				},
+			},
+			"name": &memdb.IndexSchema{
+				Name:         "name",
+				AllowMissing: false,
+				Unique:       true,
+				Indexer: &memdb.StringFieldIndex{
+					Field:     "Name",
+					Lowercase: true,
+				},
+			},
+		},
+	}
+}
+
 // ACLs is used to pull all the ACLs from the snapshot.
 func (s *Snapshot) ACLs() (memdb.ResultIterator, error) {
 	iter, err := s.tx.Get("acls", "id")
@@ -65,6 +93,28 @@ func (s *Restore) ACL(acl *structs.ACL) error {
 	return nil
 }
 
+// ACLPolicies is used to pull all the ACL policies from the snapshot.
+func (s *Snapshot) ACLPolicies() (memdb.ResultIterator, error) {
+	iter, err := s.tx.Get("acl-policies", "id")
+	if err != nil {
+		return nil, err
+	}
+	return iter, nil
+}
+
+// ACLPolicy is used when restoring from a snapshot. For general inserts, use
+// ACLPolicySet.
+func (s *Restore) ACLPolicy(policy *structs.ACLPolicy) error {
+	if err := s.tx.Insert("acl-policies", policy); err != nil {
+		return fmt.Errorf("failed restoring acl policy: %s", err)
+	}
+
+	if err := indexUpdateMaxTxn(s.tx, policy.ModifyIndex, "acl-policies"); err != nil {
+		return fmt.Errorf("failed updating index: %s", err)
+	}
+	return nil
+}
+
 // ACLBootstrap is used to pull the ACL bootstrap info from the snapshot. This
 // might return nil, in which case nothing should be saved to the snapshot.
 func (s *Snapshot) ACLBootstrap() (*structs.ACLBootstrap, error) {
@@ -390,3 +440,136 @@ func (s *Store) aclDeleteTxn(tx *memdb.Txn, idx uint64, aclID string) error {
 
 	return nil
 }
+
+// ACLPolicySet is used to insert an ACL policy into the state store. Policy
+// names must be unique, ignoring case.
+func (s *Store) ACLPolicySet(idx uint64, policy *structs.ACLPolicy) error {
+	tx := s.db.Txn(true)
+	defer tx.Abort()
+
+	// Check that the ID and name are set
+	if policy.ID == "" {
+		return ErrMissingACLPolicyID
+	}
+	if policy.Name == "" {
+		return ErrMissingACLPolicyName
+	}
+
+	// Make sure the name isn't used by another policy
+	existing, err := tx.First("acl-policies", "name", policy.Name)
+	if err != nil {
+		return fmt.Errorf("failed acl policy lookup: %s", err)
+	}
+	if existing != nil && existing.(*structs.ACLPolicy).ID != policy.ID {
+		return fmt.Errorf("ACL policy name %q is already in use", policy.Name)
+	}
+
+	// Check for an existing policy
+	existing, err = tx.First("acl-policies", "id", policy.ID)
+	if err != nil {
+		return fmt.Errorf("failed acl policy lookup: %s", err)
+	}
+
+	// Set the indexes
+	if existing != nil {
+		policy.CreateIndex = existing.(*structs.ACLPolicy).CreateIndex
+		policy.ModifyIndex = idx
+	} else {
+		policy.CreateIndex = idx
+		policy.ModifyIndex = idx
+	}
+
+	// Insert the policy
+	if err := tx.Insert("acl-policies", policy); err != nil {
+		return fmt.Errorf("failed inserting acl policy: %s", err)
+	}
+	if err := tx.Insert("index", &IndexEntry{"acl-policies", idx}); err != nil {
+		return fmt.Errorf("failed updating index: %s", err)
+	}
+
+	tx.Commit()
+	return nil
+}
+
+// ACLPolicyGet is used to look up an existing ACL policy by ID.
+func (s *Store) ACLPolicyGet(ws memdb.WatchSet, policyID string) (uint64, *structs.ACLPolicy, error) {
+	return s.aclPolicyGet(ws, "id", policyID)
+}
+
+// ACLPolicyGetByName is used to look up an existing ACL policy by name.
+func (s *Store) ACLPolicyGetByName(ws memdb.WatchSet, name string) (uint64, *structs.ACLPolicy, error) {
+	return s.aclPolicyGet(ws, "name", name)
+}
+
+// aclPolicyGet looks up an ACL policy using the given index.
+func (s *Store) aclPolicyGet(ws memdb.WatchSet, index, value string) (uint64, *structs.ACLPolicy, error) {
+	tx := s.db.Txn(false)
+	defer tx.Abort()
+
+	// Get the table index.
+	idx := maxIndexTxn(tx, "acl-policies")
+
+	// Query for the existing policy
+	watchCh, policy, err := tx.FirstWatch("acl-policies", index, value)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed acl policy lookup: %s", err)
+	}
+	ws.Add(watchCh)
+
+	if policy != nil {
+		return idx, policy.(*structs.ACLPolicy), nil
+	}
+	return idx, nil, nil
+}
+
+// ACLPolicyList is used to list out all of the ACL policies in the state
+// store.
+func (s *Store) ACLPolicyList(ws memdb.WatchSet) (uint64, structs.ACLPolicies, error) {
+	tx := s.db.Txn(false)
+	defer tx.Abort()
+
+	// Get the table index.
+	idx := maxIndexTxn(tx, "acl-policies")
+
+	// Query all of the policies in the state store
+	iter, err := tx.Get("acl-policies", "id")
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed acl policy lookup: %s", err)
+	}
+	ws.Add(iter.WatchCh())
+
+	var result structs.ACLPolicies
+	for policy := iter.Next(); policy != nil; policy = iter.Next() {
+		result = append(result, policy.(*structs.ACLPolicy))
+	}
+	return idx, result, nil
+}
+
+// ACLPolicyDelete is used to remove an existing ACL policy from the state
+// store. If the policy does not exist this is a no-op and no error is
+// returned. Tokens linked to the policy aren't changed, the link is ignored
+// once the policy is gone.
+func (s *Store) ACLPolicyDelete(idx uint64, policyID string) error {
+	tx := s.db.Txn(true)
+	defer tx.Abort()
+
+	// Look up the existing policy
+	policy, err := tx.First("acl-policies", "id", policyID)
+	if err != nil {
+		return fmt.Errorf("failed acl policy lookup: %s", err)
+	}
+	if policy == nil {
+		return nil
+	}
+
+	// Delete the policy from the state store and update indexes
+	if err := tx.Delete("acl-policies", policy); err != nil {
+		return fmt.Errorf("failed deleting acl policy: %s", err)
+	}
+	if err := tx.Insert("index", &IndexEntry{"acl-policies", idx}); err != nil {
+		return fmt.Errorf("failed updating index: %s", err)
+	}
+
+	tx.Commit()
+	return nil
+}
@@ -2,6 +2,7 @@ package state
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/hashicorp/consul/agent/structs"
@@ -575,3 +576,256 @@ func TestStateStore_ACL_Snapshot_Restore(t *testing.T) {
 		}
 	}()
 }
+
+func TestStateStore_ACLPolicySet_ACLPolicyGet(t *testing.T) {
+	s := testStateStore(t)
+
+	// Querying with no results returns nil
+	ws := memdb.NewWatchSet()
+	idx, res, err := s.ACLPolicyGet(ws, "policy1")
+	if idx != 0 || res != nil || err != nil {
+		t.Fatalf("expected (0, nil, nil), got: (%d, %#v, %#v)", idx, res, err)
+	}
+
+	// Policies need an ID and a name
+	if err := s.ACLPolicySet(1, &structs.ACLPolicy{Name: "web"}); err != ErrMissingACLPolicyID {
+		t.Fatalf("expected %#v, got: %#v", ErrMissingACLPolicyID, err)
+	}
+	if err := s.ACLPolicySet(1, &structs.ACLPolicy{ID: "policy1"}); err != ErrMissingACLPolicyName {
+		t.Fatalf("expected %#v, got: %#v", ErrMissingACLPolicyName, err)
+	}
+	if watchFired(ws) {
+		t.Fatalf("bad")
+	}
+
+	// Index is updated if the policy is inserted
+	policy := &structs.ACLPolicy{
+		ID:          "policy1",
+		Name:        "web",
+		Rules:       `service "web" { policy = "write" }`,
+		Datacenters: []string{"dc1"},
+	}
+	if err := s.ACLPolicySet(1, policy); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !watchFired(ws) {
+		t.Fatalf("bad")
+	}
+	if idx := s.maxIndex("acl-policies"); idx != 1 {
+		t.Fatalf("bad index: %d", idx)
+	}
+
+	// Retrieve the policy by ID and by name, ignoring case.
+	ws = memdb.NewWatchSet()
+	idx, res, err = s.ACLPolicyGet(ws, "policy1")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if idx != 1 || !reflect.DeepEqual(res, policy) {
+		t.Fatalf("bad: %d %#v", idx, res)
+	}
+	_, res, err = s.ACLPolicyGetByName(nil, "WEB")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if res == nil || res.ID != "policy1" {
+		t.Fatalf("bad: %#v", res)
+	}
+	if res.CreateIndex != 1 || res.ModifyIndex != 1 {
+		t.Fatalf("bad: %#v", res)
+	}
+
+	// Another policy can't take the same name
+	err = s.ACLPolicySet(2, &structs.ACLPolicy{ID: "policy2", Name: "Web"})
+	if err == nil || !strings.Contains(err.Error(), "already in use") {
+		t.Fatalf("err: %v", err)
+	}
+	if watchFired(ws) {
+		t.Fatalf("bad")
+	}
+
+	// Updating the policy keeps the create index and allows renames
+	policy = &structs.ACLPolicy{
+		ID:    "policy1",
+		Name:  "web-servers",
+		Rules: `service "web" { policy = "read" }`,
+	}
+	if err := s.ACLPolicySet(3, policy); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !watchFired(ws) {
+		t.Fatalf("bad")
+	}
+	idx, res, err = s.ACLPolicyGet(nil, "policy1")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if idx != 3 || res.CreateIndex != 1 || res.ModifyIndex != 3 || res.Name != "web-servers" {
+		t.Fatalf("bad: %d %#v", idx, res)
+	}
+
+	// The old name is free again
+	_, res, err = s.ACLPolicyGetByName(nil, "web")
+	if err != nil || res != nil {
+		t.Fatalf("bad: %#v %v", res, err)
+	}
+	if err := s.ACLPolicySet(4, &structs.ACLPolicy{ID: "policy2", Name: "web"}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+}
+
+func TestStateStore_ACLPolicyList_ACLPolicyDelete(t *testing.T) {
+	s := testStateStore(t)
+
+	// Listing when no policies exist returns nil
+	ws := memdb.NewWatchSet()
+	idx, res, err := s.ACLPolicyList(ws)
+	if idx != 0 || res != nil || err != nil {
+		t.Fatalf("expected (0, nil, nil), got: (%d, %#v, %#v)", idx, res, err)
+	}
+
+	// Insert some policies
+	policies := structs.ACLPolicies{
+		&structs.ACLPolicy{
+			ID:   "policy1",
+			Name: "web",
+			RaftIndex: structs.RaftIndex{
+				CreateIndex: 1,
+				ModifyIndex: 1,
+			},
+		},
+		&structs.ACLPolicy{
+			ID:   "policy2",
+			Name: "db",
+			RaftIndex: structs.RaftIndex{
+				CreateIndex: 2,
+				ModifyIndex: 2,
+			},
+		},
+	}
+	for _, policy := range policies {
+		if err := s.ACLPolicySet(policy.ModifyIndex, policy); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+	if !watchFired(ws) {
+		t.Fatalf("bad")
+	}
+
+	// Query the policies
+	idx, res, err = s.ACLPolicyList(nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if idx != 2 || !reflect.DeepEqual(res, policies) {
+		t.Fatalf("bad: %d %#v", idx, res)
+	}
+
+	// Deleting a policy updates the index
+	ws = memdb.NewWatchSet()
+	if _, _, err := s.ACLPolicyList(ws); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := s.ACLPolicyDelete(3, "policy1"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !watchFired(ws) {
+		t.Fatalf("bad")
+	}
+	idx, res, err = s.ACLPolicyList(nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if idx != 3 || len(res) != 1 || res[0].ID != "policy2" {
+		t.Fatalf("bad: %d %#v", idx, res)
+	}
+
+	// Deleting a nonexistent policy is a no-op
+	if err := s.ACLPolicyDelete(4, "nope"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if idx := s.maxIndex("acl-policies"); idx != 3 {
+		t.Fatalf("bad index: %d", idx)
+	}
+}
+
+func TestStateStore_ACLPolicy_Snapshot_Restore(t *testing.T) {
+	s := testStateStore(t)
+
+	// Insert some policies.
+	policies := structs.ACLPolicies{
+		&structs.ACLPolicy{
+			ID:    "policy1",
+			Name:  "web",
+			Rules: "rules1",
+			RaftIndex: structs.RaftIndex{
+				CreateIndex: 1,
+				ModifyIndex: 1,
+			},
+		},
+		&structs.ACLPolicy{
+			ID:          "policy2",
+			Name:        "db",
+			Rules:       "rules2",
+			Datacenters: []string{"dc2"},
+			RaftIndex: structs.RaftIndex{
+				CreateIndex: 2,
+				ModifyIndex: 2,
+			},
+		},
+	}
+	for _, policy := range policies {
+		if err := s.ACLPolicySet(policy.ModifyIndex, policy); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+
+	// Snapshot the policies.
+	snap := s.Snapshot()
+	defer snap.Close()
+
+	// Alter the real state store.
+	if err := s.ACLPolicyDelete(3, "policy1"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// Verify the snapshot.
+	if idx := snap.LastIndex(); idx != 2 {
+		t.Fatalf("bad index: %d", idx)
+	}
+	iter, err := snap.ACLPolicies()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	var dump structs.ACLPolicies
+	for policy := iter.Next(); policy != nil; policy = iter.Next() {
+		dump = append(dump, policy.(*structs.ACLPolicy))
+	}
+	if !reflect.DeepEqual(dump, policies) {
+		t.Fatalf("bad: %#v", dump)
+	}
+
+	// Restore the values into a new state store.
+	func() {
+		s := testStateStore(t)
+		restore := s.Restore()
+		for _, policy := range dump {
+			if err := restore.ACLPolicy(policy); err != nil {
+				t.Fatalf("err: %s", err)
+			}
+		}
+		restore.Commit()
+
+		// Read the restored policies back out and verify that they match.
+		idx, res, err := s.ACLPolicyList(nil)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if idx != 2 {
+			t.Fatalf("bad index: %d", idx)
+		}
+		if !reflect.DeepEqual(res, policies) {
+			t.Fatalf("bad: %#v", res)
+		}
+	}()
+}
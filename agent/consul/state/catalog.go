@@ -214,9 +214,46 @@ func (s *Store) ensureNodeTxn(tx *memdb.Txn, idx uint64, node *structs.Node) err
 		return fmt.Errorf("failed updating index: %s", err)
 	}
 
+	// The services on an existing node changed along with it.
+	if n != nil {
+		if err := s.nodeServicesEventsTxn(tx, idx, node); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// ensureNodeCASTxn updates a node only if the existing index matches the given
+// index. A ModifyIndex of 0 means that the node should only be created if it
+// doesn't already exist. Returns a bool indicating if a write happened and any
+// error.
+func (s *Store) ensureNodeCASTxn(tx *memdb.Txn, idx uint64, node *structs.Node) (bool, error) {
+	// Retrieve the existing entry.
+	existing, err := tx.First("nodes", "id", node.Node)
+	if err != nil {
+		return false, fmt.Errorf("node lookup failed: %s", err)
+	}
+
+	// Check if we should do the set.
+	if node.ModifyIndex == 0 && existing != nil {
+		return false, nil
+	}
+	if node.ModifyIndex != 0 && existing == nil {
+		return false, nil
+	}
+	if existing != nil && node.ModifyIndex != existing.(*structs.Node).ModifyIndex {
+		return false, nil
+	}
+
+	// Perform the update.
+	if err := s.ensureNodeTxn(tx, idx, node); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
 // GetNode is used to retrieve a node registration by node name ID.
 func (s *Store) GetNode(id string) (uint64, *structs.Node, error) {
 	tx := s.db.Txn(false)
@@ -323,6 +360,35 @@ func (s *Store) DeleteNode(idx uint64, nodeName string) error {
 	return nil
 }
 
+// deleteNodeCASTxn is used to try doing a node delete operation with a given
+// raft index. If the CAS index specified is not equal to the last observed index
+// for the given node, then the call is a noop, otherwise a normal delete is
+// invoked.
+func (s *Store) deleteNodeCASTxn(tx *memdb.Txn, idx, cidx uint64, nodeName string) (bool, error) {
+	// Look up the node.
+	node, err := tx.First("nodes", "id", nodeName)
+	if err != nil {
+		return false, fmt.Errorf("node lookup failed: %s", err)
+	}
+	if node == nil {
+		return false, nil
+	}
+
+	// If the existing index does not match the provided CAS
+	// index arg, then we shouldn't update anything and can safely
+	// return early here.
+	if node.(*structs.Node).ModifyIndex != cidx {
+		return false, nil
+	}
+
+	// Call the actual deletion if the above passed.
+	if err := s.deleteNodeTxn(tx, idx, nodeName); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
 // deleteNodeTxn is the inner method used for removing a node from
 // the store within a given transaction.
 func (s *Store) deleteNodeTxn(tx *memdb.Txn, idx uint64, nodeName string) error {
@@ -404,7 +470,7 @@ func (s *Store) deleteNodeTxn(tx *memdb.Txn, idx uint64, nodeName string) error
 
 	// Do the delete in a separate loop so we don't trash the iterator.
 	for _, id := range ids {
-		if err := s.deleteSessionTxn(tx, idx, id); err != nil {
+		if err := s.deleteSessionTxn(tx, idx, id, true); err != nil {
 			return fmt.Errorf("failed session delete: %s", err)
 		}
 	}
@@ -463,10 +529,41 @@ func (s *Store) ensureServiceTxn(tx *memdb.Txn, idx uint64, node string, svc *st
 	if err := tx.Insert("index", &IndexEntry{"services", idx}); err != nil {
 		return fmt.Errorf("failed updating index: %s", err)
 	}
+	s.serviceEventTxn(tx, idx, structs.StreamServiceUpsert, n.(*structs.Node), entry)
 
 	return nil
 }
 
+// ensureServiceCASTxn updates a service only if the existing index matches the
+// given index. A ModifyIndex of 0 means that the service should only be created
+// if it doesn't already exist. Returns a bool indicating if a write happened and
+// any error.
+func (s *Store) ensureServiceCASTxn(tx *memdb.Txn, idx uint64, node string, svc *structs.NodeService) (bool, error) {
+	// Retrieve the existing service.
+	existing, err := tx.First("services", "id", node, svc.ID)
+	if err != nil {
+		return false, fmt.Errorf("failed service lookup: %s", err)
+	}
+
+	// Check if we should do the set.
+	if svc.ModifyIndex == 0 && existing != nil {
+		return false, nil
+	}
+	if svc.ModifyIndex != 0 && existing == nil {
+		return false, nil
+	}
+	if existing != nil && svc.ModifyIndex != existing.(*structs.ServiceNode).ModifyIndex {
+		return false, nil
+	}
+
+	// Perform the update.
+	if err := s.ensureServiceTxn(tx, idx, node, svc); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
 // Services returns all services along with a list of associated tags.
 func (s *Store) Services(ws memdb.WatchSet) (uint64, structs.Services, error) {
 	tx := s.db.Txn(false)
@@ -804,6 +901,35 @@ func (s *Store) DeleteService(idx uint64, nodeName, serviceID string) error {
 	return nil
 }
 
+// deleteServiceCASTxn is used to try doing a service delete operation with a
+// given raft index. If the CAS index specified is not equal to the last observed
+// index for the given service, then the call is a noop, otherwise a normal
+// delete is invoked.
+func (s *Store) deleteServiceCASTxn(tx *memdb.Txn, idx, cidx uint64, nodeName, serviceID string) (bool, error) {
+	// Look up the service.
+	service, err := tx.First("services", "id", nodeName, serviceID)
+	if err != nil {
+		return false, fmt.Errorf("failed service lookup: %s", err)
+	}
+	if service == nil {
+		return false, nil
+	}
+
+	// If the existing index does not match the provided CAS
+	// index arg, then we shouldn't update anything and can safely
+	// return early here.
+	if service.(*structs.ServiceNode).ModifyIndex != cidx {
+		return false, nil
+	}
+
+	// Call the actual deletion if the above passed.
+	if err := s.deleteServiceTxn(tx, idx, nodeName, serviceID); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
 // deleteServiceTxn is the inner method called to remove a service
 // registration within an existing transaction.
 func (s *Store) deleteServiceTxn(tx *memdb.Txn, idx uint64, nodeName, serviceID string) error {
@@ -846,6 +972,9 @@ func (s *Store) deleteServiceTxn(tx *memdb.Txn, idx uint64, nodeName, serviceID
 	if err := tx.Insert("index", &IndexEntry{"services", idx}); err != nil {
 		return fmt.Errorf("failed updating index: %s", err)
 	}
+	svc := service.(*structs.ServiceNode)
+	s.serviceEventTxn(tx, idx, structs.StreamServiceDelete, &structs.Node{Node: nodeName},
+		&structs.ServiceNode{Node: nodeName, ServiceID: svc.ServiceID, ServiceName: svc.ServiceName})
 
 	return nil
 }
@@ -929,7 +1058,7 @@ func (s *Store) ensureCheckTxn(tx *memdb.Txn, idx uint64, hc *structs.HealthChec
 		// Delete the session in a separate loop so we don't trash the
 		// iterator.
 		for _, id := range ids {
-			if err := s.deleteSessionTxn(tx, idx, id); err != nil {
+			if err := s.deleteSessionTxn(tx, idx, id, true); err != nil {
 				return fmt.Errorf("failed deleting session: %s", err)
 			}
 		}
@@ -943,9 +1072,47 @@ func (s *Store) ensureCheckTxn(tx *memdb.Txn, idx uint64, hc *structs.HealthChec
 		return fmt.Errorf("failed updating index: %s", err)
 	}
 
+	// Only send events for actual changes, since checks are synced
+	// individually.
+	if existing == nil || !existing.(*structs.HealthCheck).IsSame(hc) {
+		if err := s.checkEventsTxn(tx, idx, structs.StreamCheckUpdate, hc); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// ensureCheckCASTxn updates a check only if the existing index matches the
+// given index. A ModifyIndex of 0 means that the check should only be created
+// if it doesn't already exist. Returns a bool indicating if a write happened and
+// any error.
+func (s *Store) ensureCheckCASTxn(tx *memdb.Txn, idx uint64, hc *structs.HealthCheck) (bool, error) {
+	// Retrieve the existing entry.
+	existing, err := tx.First("checks", "id", hc.Node, string(hc.CheckID))
+	if err != nil {
+		return false, fmt.Errorf("failed health check lookup: %s", err)
+	}
+
+	// Check if we should do the set.
+	if hc.ModifyIndex == 0 && existing != nil {
+		return false, nil
+	}
+	if hc.ModifyIndex != 0 && existing == nil {
+		return false, nil
+	}
+	if existing != nil && hc.ModifyIndex != existing.(*structs.HealthCheck).ModifyIndex {
+		return false, nil
+	}
+
+	// Perform the update.
+	if err := s.ensureCheckTxn(tx, idx, hc); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
 // NodeCheck is used to retrieve a specific check associated with the given
 // node.
 func (s *Store) NodeCheck(nodeName string, checkID types.CheckID) (uint64, *structs.HealthCheck, error) {
@@ -1142,6 +1309,35 @@ func (s *Store) DeleteCheck(idx uint64, node string, checkID types.CheckID) erro
 	return nil
 }
 
+// deleteCheckCASTxn is used to try doing a check delete operation with a given
+// raft index. If the CAS index specified is not equal to the last observed index
+// for the given check, then the call is a noop, otherwise a normal check delete
+// is invoked.
+func (s *Store) deleteCheckCASTxn(tx *memdb.Txn, idx, cidx uint64, node string, checkID types.CheckID) (bool, error) {
+	// Try to retrieve the existing health check.
+	hc, err := tx.First("checks", "id", node, string(checkID))
+	if err != nil {
+		return false, fmt.Errorf("check lookup failed: %s", err)
+	}
+	if hc == nil {
+		return false, nil
+	}
+
+	// If the existing index does not match the provided CAS
+	// index arg, then we shouldn't update anything and can safely
+	// return early here.
+	if hc.(*structs.HealthCheck).ModifyIndex != cidx {
+		return false, nil
+	}
+
+	// Call the actual deletion if the above passed.
+	if err := s.deleteCheckTxn(tx, idx, node, checkID); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
 // deleteCheckTxn is the inner method used to call a health
 // check deletion within an existing transaction.
 func (s *Store) deleteCheckTxn(tx *memdb.Txn, idx uint64, node string, checkID types.CheckID) error {
@@ -1161,6 +1357,9 @@ func (s *Store) deleteCheckTxn(tx *memdb.Txn, idx uint64, node string, checkID t
 	if err := tx.Insert("index", &IndexEntry{"checks", idx}); err != nil {
 		return fmt.Errorf("failed updating index: %s", err)
 	}
+	if err := s.checkEventsTxn(tx, idx, structs.StreamCheckDelete, hc.(*structs.HealthCheck)); err != nil {
+		return err
+	}
 
 	// Delete any sessions for this check.
 	mappings, err := tx.Get("session_checks", "node_check", node, string(checkID))
@@ -1174,7 +1373,7 @@ func (s *Store) deleteCheckTxn(tx *memdb.Txn, idx uint64, node string, checkID t
 
 	// Do the delete in a separate loop so we don't trash the iterator.
 	for _, id := range ids {
-		if err := s.deleteSessionTxn(tx, idx, id); err != nil {
+		if err := s.deleteSessionTxn(tx, idx, id, true); err != nil {
 			return fmt.Errorf("failed deleting session: %s", err)
 		}
 	}
@@ -76,8 +76,8 @@ func (s *Store) KVSSet(idx uint64, entry *structs.DirEntry) error {
 // kvsSetTxn is used to insert or update a key/value pair in the state
 // store. It is the inner method used and handles only the actual storage.
 // If updateSession is true, then the incoming entry will set the new
-// session (should be validated before calling this). Otherwise, we will keep
-// whatever the existing session is.
+// session and lock waiters (should be validated before calling this).
+// Otherwise, we will keep whatever the existing session and waiters are.
 func (s *Store) kvsSetTxn(tx *memdb.Txn, idx uint64, entry *structs.DirEntry, updateSession bool) error {
 	// Retrieve an existing KV pair
 	existing, err := tx.First("kvs", "id", entry.Key)
@@ -98,8 +98,10 @@ func (s *Store) kvsSetTxn(tx *memdb.Txn, idx uint64, entry *structs.DirEntry, up
 	if !updateSession {
 		if existing != nil {
 			entry.Session = existing.(*structs.DirEntry).Session
+			entry.LockWaiters = existing.(*structs.DirEntry).LockWaiters
 		} else {
 			entry.Session = ""
+			entry.LockWaiters = nil
 		}
 	}
 
@@ -110,6 +112,7 @@ func (s *Store) kvsSetTxn(tx *memdb.Txn, idx uint64, entry *structs.DirEntry, up
 	if err := tx.Insert("index", &IndexEntry{"kvs", idx}); err != nil {
 		return fmt.Errorf("failed updating index: %s", err)
 	}
+	s.kvsEventTxn(tx, idx, structs.StreamKVPut, entry)
 
 	return nil
 }
@@ -197,6 +200,29 @@ func (s *Store) kvsListTxn(tx *memdb.Txn, ws memdb.WatchSet, prefix string) (uin
 	return idx, ents, nil
 }
 
+// KVSListTTL returns all KVS entries which have a TTL set. This is used by the
+// leader to set up the timers which expire them.
+func (s *Store) KVSListTTL(ws memdb.WatchSet) (uint64, structs.DirEntries, error) {
+	tx := s.db.Txn(false)
+	defer tx.Abort()
+
+	// Get the table index.
+	idx := maxIndexTxn(tx, "kvs")
+
+	// Query all entries with a TTL.
+	entries, err := tx.Get("kvs", "ttl_prefix", "")
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed kvs lookup: %s", err)
+	}
+	ws.Add(entries.WatchCh())
+
+	var ents structs.DirEntries
+	for entry := entries.Next(); entry != nil; entry = entries.Next() {
+		ents = append(ents, entry.(*structs.DirEntry))
+	}
+	return idx, ents, nil
+}
+
 // KVSListKeys is used to query the KV store for keys matching the given prefix.
 // An optional separator may be specified, which can be used to slice off a part
 // of the response so that only a subset of the prefix is returned. In this
@@ -311,6 +337,7 @@ func (s *Store) kvsDeleteTxn(tx *memdb.Txn, idx uint64, key string) error {
 	if err := tx.Insert("index", &IndexEntry{"kvs", idx}); err != nil {
 		return fmt.Errorf("failed updating index: %s", err)
 	}
+	s.kvsEventTxn(tx, idx, structs.StreamKVDelete, &structs.DirEntry{Key: key})
 
 	return nil
 }
@@ -420,6 +447,19 @@ func (s *Store) KVSDeleteTree(idx uint64, prefix string) error {
 // kvsDeleteTreeTxn is the inner method that does a recursive delete inside an
 // existing transaction.
 func (s *Store) kvsDeleteTreeTxn(tx *memdb.Txn, idx uint64, prefix string) error {
+	// Find the keys being deleted for the change events, unless nobody is
+	// subscribed since this can be a large part of the tree.
+	collect := s.publisher.hasSubscribers()
+	var keys []string
+	if collect {
+		entries, err := tx.Get("kvs", "id_prefix", prefix)
+		if err != nil {
+			return fmt.Errorf("failed kvs lookup: %s", err)
+		}
+		for entry := entries.Next(); entry != nil; entry = entries.Next() {
+			keys = append(keys, entry.(*structs.DirEntry).Key)
+		}
+	}
 
 	// For prefix deletes, only insert one tombstone and delete the entire subtree
 
@@ -438,6 +478,12 @@ func (s *Store) kvsDeleteTreeTxn(tx *memdb.Txn, idx uint64, prefix string) error
 		if err := tx.Insert("index", &IndexEntry{"kvs", idx}); err != nil {
 			return fmt.Errorf("failed updating index: %s", err)
 		}
+		if !collect {
+			s.publisher.skip(tx, idx)
+		}
+		for _, key := range keys {
+			s.kvsEventTxn(tx, idx, structs.StreamKVDelete, &structs.DirEntry{Key: key})
+		}
 	}
 	return nil
 }
@@ -454,7 +500,7 @@ func (s *Store) KVSLock(idx uint64, entry *structs.DirEntry) (bool, error) {
 	tx := s.db.Txn(true)
 	defer tx.Abort()
 
-	locked, err := s.kvsLockTxn(tx, idx, entry)
+	locked, err := s.kvsLockTxn(tx, idx, entry, false)
 	if !locked || err != nil {
 		return false, err
 	}
@@ -463,9 +509,28 @@ func (s *Store) KVSLock(idx uint64, entry *structs.DirEntry) (bool, error) {
 	return true, nil
 }
 
+// KVSLockQueue is similar to KVSLock, but if the lock can't be acquired then
+// the session is queued for it. Queued sessions get the lock in order, either
+// by acquiring it once it's free, or by having it handed over when it's held
+// by a session with the transfer behavior.
+func (s *Store) KVSLockQueue(idx uint64, entry *structs.DirEntry) (bool, error) {
+	tx := s.db.Txn(true)
+	defer tx.Abort()
+
+	locked, err := s.kvsLockTxn(tx, idx, entry, true)
+	if err != nil {
+		return false, err
+	}
+
+	tx.Commit()
+	return locked, nil
+}
+
 // kvsLockTxn is the inner method that does a lock inside an existing
-// transaction.
-func (s *Store) kvsLockTxn(tx *memdb.Txn, idx uint64, entry *structs.DirEntry) (bool, error) {
+// transaction. A free lock can only be acquired by the first of the sessions
+// queued for it, if any. If queue is set and the lock can't be acquired, the
+// session is queued for it.
+func (s *Store) kvsLockTxn(tx *memdb.Txn, idx uint64, entry *structs.DirEntry, queue bool) (bool, error) {
 	// Verify that a session is present.
 	if entry.Session == "" {
 		return false, fmt.Errorf("missing session")
@@ -493,17 +558,23 @@ func (s *Store) kvsLockTxn(tx *memdb.Txn, idx uint64, entry *structs.DirEntry) (
 			// We already hold this lock, good to go.
 			entry.CreateIndex = e.CreateIndex
 			entry.LockIndex = e.LockIndex
-		} else if e.Session != "" {
-			// Bail out, someone else holds this lock.
+		} else if e.Session != "" || (len(e.LockWaiters) > 0 && e.LockWaiters[0] != entry.Session) {
+			// Bail out, someone else holds this lock or is queued
+			// ahead for it.
+			if queue {
+				return false, s.kvsQueueLockTxn(tx, idx, e, entry.Session)
+			}
 			return false, nil
 		} else {
 			// Set up a new lock with this session.
 			entry.CreateIndex = e.CreateIndex
 			entry.LockIndex = e.LockIndex + 1
 		}
+		entry.LockWaiters = removeLockWaiter(e.LockWaiters, entry.Session)
 	} else {
 		entry.CreateIndex = idx
 		entry.LockIndex = 1
+		entry.LockWaiters = nil
 	}
 	entry.ModifyIndex = idx
 
@@ -548,17 +619,33 @@ func (s *Store) kvsUnlockTxn(tx *memdb.Txn, idx uint64, entry *structs.DirEntry)
 		return false, nil
 	}
 
-	// Make sure the given session is the lock holder.
+	// Make sure the given session is the lock holder. A session that is
+	// queued for the lock leaves the queue instead.
 	e := existing.(*structs.DirEntry)
 	if e.Session != entry.Session {
-		return false, nil
+		if !hasLockWaiter(e.LockWaiters, entry.Session) {
+			return false, nil
+		}
+		if err := s.kvsDequeueLockTxn(tx, idx, e, entry.Session); err != nil {
+			return false, err
+		}
+		return true, nil
 	}
 
-	// Clear the lock and update the entry.
+	// Clear the lock and update the entry, handing the lock to the first
+	// queued session if the holder transfers its locks.
 	entry.Session = ""
 	entry.LockIndex = e.LockIndex
+	entry.LockWaiters = e.LockWaiters
 	entry.CreateIndex = e.CreateIndex
 	entry.ModifyIndex = idx
+	sess, err := tx.First("sessions", "id", e.Session)
+	if err != nil {
+		return false, fmt.Errorf("failed session lookup: %s", err)
+	}
+	if sess != nil && sess.(*structs.Session).Behavior == structs.SessionKeysTransfer {
+		handOffLock(entry)
+	}
 
 	// If we made it this far, we should perform the set.
 	if err := s.kvsSetTxn(tx, idx, entry, true); err != nil {
@@ -567,6 +654,60 @@ func (s *Store) kvsUnlockTxn(tx *memdb.Txn, idx uint64, entry *structs.DirEntry)
 	return true, nil
 }
 
+// kvsQueueLockTxn adds the session to the end of the queue for the lock on
+// the entry, unless it's already queued. The queue change updates the indexes
+// of the entry so that blocking reads of the key see it.
+func (s *Store) kvsQueueLockTxn(tx *memdb.Txn, idx uint64, e *structs.DirEntry, session string) error {
+	if hasLockWaiter(e.LockWaiters, session) {
+		return nil
+	}
+	queued := e.Clone()
+	queued.LockWaiters = append(queued.LockWaiters, session)
+	return s.kvsSetTxn(tx, idx, queued, true)
+}
+
+// kvsDequeueLockTxn removes the session from the queue for the lock on the
+// entry, updating its indexes the same way as queueing.
+func (s *Store) kvsDequeueLockTxn(tx *memdb.Txn, idx uint64, e *structs.DirEntry, session string) error {
+	dequeued := e.Clone()
+	dequeued.LockWaiters = removeLockWaiter(e.LockWaiters, session)
+	return s.kvsSetTxn(tx, idx, dequeued, true)
+}
+
+// handOffLock gives the lock on a released entry to the first session queued
+// for it, if any, and returns whether it did.
+func handOffLock(entry *structs.DirEntry) bool {
+	if len(entry.LockWaiters) == 0 {
+		return false
+	}
+	entry.Session = entry.LockWaiters[0]
+	entry.LockWaiters = removeLockWaiter(entry.LockWaiters, entry.Session)
+	entry.LockIndex++
+	return true
+}
+
+// hasLockWaiter returns whether the session is in the given lock queue.
+func hasLockWaiter(waiters []string, session string) bool {
+	for _, waiter := range waiters {
+		if waiter == session {
+			return true
+		}
+	}
+	return false
+}
+
+// removeLockWaiter returns a copy of the lock queue without the session. The
+// given queue may be shared with the state store, so it isn't modified.
+func removeLockWaiter(waiters []string, session string) []string {
+	var out []string
+	for _, waiter := range waiters {
+		if waiter != session {
+			out = append(out, waiter)
+		}
+	}
+	return out
+}
+
 // kvsCheckSessionTxn checks to see if the given session matches the current
 // entry for a key.
 func (s *Store) kvsCheckSessionTxn(tx *memdb.Txn, key string, session string) (*structs.DirEntry, error) {
@@ -470,6 +470,50 @@ func TestStateStore_KVSList(t *testing.T) {
 	}
 }
 
+func TestStateStore_KVSListTTL(t *testing.T) {
+	s := testStateStore(t)
+
+	// Listing an empty KVS returns nothing
+	ws := memdb.NewWatchSet()
+	idx, entries, err := s.KVSListTTL(ws)
+	if idx != 0 || entries != nil || err != nil {
+		t.Fatalf("expected (0, nil, nil), got: (%d, %#v, %#v)", idx, entries, err)
+	}
+
+	// Create some KVS entries, only some of which have a TTL
+	testSetKey(t, s, 1, "foo", "foo")
+	if err := s.KVSSet(2, &structs.DirEntry{Key: "bar", TTL: "10s"}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := s.KVSSet(3, &structs.DirEntry{Key: "baz", TTL: "1m"}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !watchFired(ws) {
+		t.Fatalf("bad")
+	}
+
+	// Only the entries with a TTL should be returned
+	idx, entries, err = s.KVSListTTL(nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if idx != 3 {
+		t.Fatalf("bad index: %d", idx)
+	}
+	if len(entries) != 2 || entries[0].Key != "bar" || entries[1].Key != "baz" {
+		t.Fatalf("bad: %#v", entries)
+	}
+
+	// Overwriting an entry without a TTL clears it
+	testSetKey(t, s, 4, "bar", "bar")
+	if _, entries, err = s.KVSListTTL(nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(entries) != 1 || entries[0].Key != "baz" || entries[0].TTL != "1m" {
+		t.Fatalf("bad: %#v", entries)
+	}
+}
+
 func TestStateStore_KVSListKeys(t *testing.T) {
 	s := testStateStore(t)
 
@@ -1377,6 +1421,215 @@ func TestStateStore_KVSUnlock(t *testing.T) {
 	}
 }
 
+func TestStateStore_KVSLockQueue(t *testing.T) {
+	s := testStateStore(t)
+
+	// Make some sessions.
+	testRegisterNode(t, s, 1, "node1")
+	session1, session2, session3 := testUUID(), testUUID(), testUUID()
+	for i, id := range []string{session1, session2, session3} {
+		if err := s.SessionCreate(uint64(2+i), &structs.Session{ID: id, Node: "node1"}); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+
+	// Queueing for a free lock just acquires it.
+	ok, err := s.KVSLockQueue(5, &structs.DirEntry{Key: "foo", Value: []byte("foo"), Session: session1})
+	if !ok || err != nil {
+		t.Fatalf("didn't get the lock: %v %s", ok, err)
+	}
+
+	// The other sessions get queued in order, and queueing again doesn't
+	// change their place.
+	for i, id := range []string{session2, session3, session2} {
+		ok, err = s.KVSLockQueue(uint64(6+i), &structs.DirEntry{Key: "foo", Value: []byte("bar"), Session: id})
+		if ok || err != nil {
+			t.Fatalf("didn't handle the held lock: %v %s", ok, err)
+		}
+	}
+
+	// Queueing only changed the waiters and the indexes of the entry, and
+	// queueing again didn't change them.
+	idx, result, err := s.KVSGet(nil, "foo")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if result.Session != session1 || result.LockIndex != 1 || result.ModifyIndex != 7 ||
+		string(result.Value) != "foo" ||
+		!reflect.DeepEqual(result.LockWaiters, []string{session2, session3}) {
+		t.Fatalf("bad entry: %#v", result)
+	}
+	if idx != 7 {
+		t.Fatalf("bad index: %d", idx)
+	}
+
+	// Writes to the key keep the queue.
+	if err := s.KVSSet(9, &structs.DirEntry{Key: "foo", Value: []byte("baz")}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// Unlocking a queued session takes it out of the queue.
+	ok, err = s.KVSUnlock(10, &structs.DirEntry{Key: "foo", Session: session2})
+	if !ok || err != nil {
+		t.Fatalf("didn't leave the queue: %v %s", ok, err)
+	}
+	_, result, err = s.KVSGet(nil, "foo")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if result.Session != session1 || string(result.Value) != "baz" ||
+		!reflect.DeepEqual(result.LockWaiters, []string{session3}) {
+		t.Fatalf("bad entry: %#v", result)
+	}
+
+	// Releasing the lock with the default behavior leaves it free, but
+	// only the first session in the queue can acquire it.
+	ok, err = s.KVSUnlock(11, &structs.DirEntry{Key: "foo", Session: session1})
+	if !ok || err != nil {
+		t.Fatalf("didn't release the lock: %v %s", ok, err)
+	}
+	for _, id := range []string{session1, session2} {
+		ok, err = s.KVSLock(12, &structs.DirEntry{Key: "foo", Session: id})
+		if ok || err != nil {
+			t.Fatalf("jumped the queue: %v %s", ok, err)
+		}
+	}
+	ok, err = s.KVSLock(13, &structs.DirEntry{Key: "foo", Session: session3})
+	if !ok || err != nil {
+		t.Fatalf("didn't get the lock: %v %s", ok, err)
+	}
+	_, result, err = s.KVSGet(nil, "foo")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if result.Session != session3 || result.LockWaiters != nil {
+		t.Fatalf("bad entry: %#v", result)
+	}
+}
+
+func TestStateStore_KVSLockQueue_Watch(t *testing.T) {
+	s := testStateStore(t)
+
+	testRegisterNode(t, s, 1, "node1")
+	session1, session2, session3 := testUUID(), testUUID(), testUUID()
+	for i, id := range []string{session1, session2, session3} {
+		if err := s.SessionCreate(uint64(2+i), &structs.Session{ID: id, Node: "node1"}); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+	ok, err := s.KVSLock(5, &structs.DirEntry{Key: "foo", Session: session1})
+	if !ok || err != nil {
+		t.Fatalf("didn't get the lock: %v %s", ok, err)
+	}
+
+	// Each change to the queue fires a watch on the key and bumps its
+	// indexes.
+	verify := func(idx uint64, change func() error, waiters []string) {
+		t.Helper()
+		ws := memdb.NewWatchSet()
+		if _, _, err := s.KVSGet(ws, "foo"); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if err := change(); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if !watchFired(ws) {
+			t.Fatalf("bad")
+		}
+		index, result, err := s.KVSGet(nil, "foo")
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if index != idx || result.ModifyIndex != idx ||
+			!reflect.DeepEqual(result.LockWaiters, waiters) {
+			t.Fatalf("bad: %d %#v", index, result)
+		}
+	}
+	queue := func(idx uint64, session string) func() error {
+		return func() error {
+			_, err := s.KVSLockQueue(idx, &structs.DirEntry{Key: "foo", Session: session})
+			return err
+		}
+	}
+	verify(6, queue(6, session2), []string{session2})
+	verify(7, queue(7, session3), []string{session2, session3})
+
+	// Dequeueing the head waiter of a free lock wakes up the next one.
+	ok, err = s.KVSUnlock(8, &structs.DirEntry{Key: "foo", Session: session1})
+	if !ok || err != nil {
+		t.Fatalf("didn't release the lock: %v %s", ok, err)
+	}
+	verify(9, func() error {
+		return s.SessionDestroy(9, session2)
+	}, []string{session3})
+	verify(10, func() error {
+		_, err := s.KVSUnlock(10, &structs.DirEntry{Key: "foo", Session: session3})
+		return err
+	}, nil)
+}
+
+func TestStateStore_KVSUnlock_Transfer(t *testing.T) {
+	s := testStateStore(t)
+
+	// Make a session with the transfer behavior, and a regular one.
+	testRegisterNode(t, s, 1, "node1")
+	session1, session2 := testUUID(), testUUID()
+	sess := &structs.Session{
+		ID:        session1,
+		Node:      "node1",
+		LockDelay: 50 * time.Millisecond,
+		Behavior:  structs.SessionKeysTransfer,
+	}
+	if err := s.SessionCreate(2, sess); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := s.SessionCreate(3, &structs.Session{ID: session2, Node: "node1"}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// Releasing without anyone queued leaves the lock free.
+	ok, err := s.KVSLock(4, &structs.DirEntry{Key: "foo", Session: session1})
+	if !ok || err != nil {
+		t.Fatalf("didn't get the lock: %v %s", ok, err)
+	}
+	ok, err = s.KVSUnlock(5, &structs.DirEntry{Key: "foo", Session: session1})
+	if !ok || err != nil {
+		t.Fatalf("didn't release the lock: %v %s", ok, err)
+	}
+	_, result, err := s.KVSGet(nil, "foo")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if result.Session != "" || result.LockIndex != 1 {
+		t.Fatalf("bad entry: %#v", result)
+	}
+
+	// Releasing with a session queued hands the lock over.
+	ok, err = s.KVSLock(6, &structs.DirEntry{Key: "foo", Session: session1})
+	if !ok || err != nil {
+		t.Fatalf("didn't get the lock: %v %s", ok, err)
+	}
+	ok, err = s.KVSLockQueue(7, &structs.DirEntry{Key: "foo", Session: session2})
+	if ok || err != nil {
+		t.Fatalf("didn't queue: %v %s", ok, err)
+	}
+	ok, err = s.KVSUnlock(8, &structs.DirEntry{Key: "foo", Value: []byte("handed"), Session: session1})
+	if !ok || err != nil {
+		t.Fatalf("didn't release the lock: %v %s", ok, err)
+	}
+	idx, result, err := s.KVSGet(nil, "foo")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if result.Session != session2 || result.LockIndex != 3 || result.ModifyIndex != 8 ||
+		string(result.Value) != "handed" || result.LockWaiters != nil {
+		t.Fatalf("bad entry: %#v", result)
+	}
+	if idx != 8 {
+		t.Fatalf("bad index: %d", idx)
+	}
+}
+
 func TestStateStore_KVS_Snapshot_Restore(t *testing.T) {
 	s := testStateStore(t)
 
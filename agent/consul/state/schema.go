@@ -30,6 +30,7 @@ func stateStoreSchema() *memdb.DBSchema {
 		sessionChecksTableSchema,
 		aclsTableSchema,
 		aclsBootstrapTableSchema,
+		aclPoliciesTableSchema,
 		coordinatesTableSchema,
 		preparedQueriesTableSchema,
 		autopilotConfigTableSchema,
@@ -258,6 +259,24 @@ func kvsTableSchema() *memdb.TableSchema {
 					Field: "Session",
 				},
 			},
+			"ttl": &memdb.IndexSchema{
+				Name:         "ttl",
+				AllowMissing: true,
+				Unique:       false,
+				Indexer: &memdb.StringFieldIndex{
+					Field:     "TTL",
+					Lowercase: false,
+				},
+			},
+			"lock_waiters": &memdb.IndexSchema{
+				Name:         "lock_waiters",
+				AllowMissing: true,
+				Unique:       false,
+				Indexer: &memdb.StringSliceFieldIndex{
+					Field:     "LockWaiters",
+					Lowercase: false,
+				},
+			},
 		},
 	}
 }
@@ -83,6 +83,7 @@ func (s *Store) sessionCreateTxn(tx *memdb.Txn, idx uint64, sess *structs.Sessio
 		sess.Behavior = structs.SessionKeysRelease
 	case structs.SessionKeysRelease:
 	case structs.SessionKeysDelete:
+	case structs.SessionKeysTransfer:
 	default:
 		return fmt.Errorf("Invalid session behavior: %s", sess.Behavior)
 	}
@@ -219,7 +220,25 @@ func (s *Store) SessionDestroy(idx uint64, sessionID string) error {
 	defer tx.Abort()
 
 	// Call the session deletion.
-	if err := s.deleteSessionTxn(tx, idx, sessionID); err != nil {
+	if err := s.deleteSessionTxn(tx, idx, sessionID, false); err != nil {
+		return err
+	}
+
+	tx.Commit()
+	return nil
+}
+
+// SessionInvalidate is like SessionDestroy, but is used when the session is
+// removed because it expired rather than by its owner. Locks held by a
+// session with the transfer behavior are then released like for the release
+// behavior instead of being handed over, since the holder may still be
+// working under the lock.
+func (s *Store) SessionInvalidate(idx uint64, sessionID string) error {
+	tx := s.db.Txn(true)
+	defer tx.Abort()
+
+	// Call the session deletion.
+	if err := s.deleteSessionTxn(tx, idx, sessionID, true); err != nil {
 		return err
 	}
 
@@ -228,8 +247,10 @@ func (s *Store) SessionDestroy(idx uint64, sessionID string) error {
 }
 
 // deleteSessionTxn is the inner method, which is used to do the actual
-// session deletion and handle session invalidation, etc.
-func (s *Store) deleteSessionTxn(tx *memdb.Txn, idx uint64, sessionID string) error {
+// session deletion and handle session invalidation, etc. The invalidated
+// flag is set when the session is removed by a health check, a node going
+// away or an expired TTL rather than by its owner.
+func (s *Store) deleteSessionTxn(tx *memdb.Txn, idx uint64, sessionID string, invalidated bool) error {
 	// Look up the session.
 	sess, err := tx.First("sessions", "id", sessionID)
 	if err != nil {
@@ -258,6 +279,21 @@ func (s *Store) deleteSessionTxn(tx *memdb.Txn, idx uint64, sessionID string) er
 	// the same way.
 	now := time.Now()
 
+	// Take the session out of any lock queues it's in.
+	queued, err := tx.Get("kvs", "lock_waiters", sessionID)
+	if err != nil {
+		return fmt.Errorf("failed kvs lookup: %s", err)
+	}
+	var waiting []*structs.DirEntry
+	for entry := queued.Next(); entry != nil; entry = queued.Next() {
+		waiting = append(waiting, entry.(*structs.DirEntry))
+	}
+	for _, e := range waiting {
+		if err := s.kvsDequeueLockTxn(tx, idx, e, sessionID); err != nil {
+			return err
+		}
+	}
+
 	// Get an iterator over all of the keys with the given session.
 	entries, err := tx.Get("kvs", "session", sessionID)
 	if err != nil {
@@ -286,6 +322,23 @@ func (s *Store) deleteSessionTxn(tx *memdb.Txn, idx uint64, sessionID string) er
 				s.lockDelay.SetExpiration(e.Key, now, delay)
 			}
 		}
+	case structs.SessionKeysTransfer:
+		for _, obj := range kvs {
+			// Hand the lock straight to the next queued session,
+			// unless the session was invalidated, in which case the
+			// lock is released subject to the lock delay as usual.
+			e := obj.(*structs.DirEntry).Clone()
+			e.Session = ""
+			handedOff := !invalidated && handOffLock(e)
+			if err := s.kvsSetTxn(tx, idx, e, true); err != nil {
+				return fmt.Errorf("failed kvs update: %s", err)
+			}
+
+			// Apply the lock delay if present.
+			if !handedOff && delay > 0 {
+				s.lockDelay.SetExpiration(e.Key, now, delay)
+			}
+		}
 	case structs.SessionKeysDelete:
 		for _, obj := range kvs {
 			e := obj.(*structs.DirEntry)
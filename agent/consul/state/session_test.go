@@ -870,6 +870,89 @@ func TestStateStore_Session_Invalidate_Key_Delete_Behavior(t *testing.T) {
 	}
 }
 
+func TestStateStore_Session_Transfer_Behavior(t *testing.T) {
+	s := testStateStore(t)
+
+	// Set up a lock held by a transfer session, with two sessions queued.
+	testRegisterNode(t, s, 1, "foo")
+	holder := &structs.Session{
+		ID:        testUUID(),
+		Node:      "foo",
+		LockDelay: 50 * time.Millisecond,
+		Behavior:  structs.SessionKeysTransfer,
+	}
+	if err := s.SessionCreate(2, holder); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	next := &structs.Session{
+		ID:        testUUID(),
+		Node:      "foo",
+		LockDelay: 50 * time.Millisecond,
+		Behavior:  structs.SessionKeysTransfer,
+	}
+	if err := s.SessionCreate(3, next); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	last := &structs.Session{ID: testUUID(), Node: "foo"}
+	if err := s.SessionCreate(4, last); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i, id := range []string{holder.ID, next.ID, last.ID} {
+		if _, err := s.KVSLockQueue(uint64(5+i), &structs.DirEntry{Key: "/bar", Session: id}); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	// Destroying the holder hands the lock to the next session without a
+	// lock delay.
+	if err := s.SessionDestroy(8, holder.ID); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	idx, d, err := s.KVSGet(nil, "/bar")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if d.Session != next.ID || d.LockIndex != 2 ||
+		!reflect.DeepEqual(d.LockWaiters, []string{last.ID}) {
+		t.Fatalf("bad entry: %#v", d)
+	}
+	if idx != 8 {
+		t.Fatalf("bad index: %d", idx)
+	}
+	if expires := s.KVSLockDelay("/bar"); !expires.IsZero() {
+		t.Fatalf("unexpected lock delay: %v", expires)
+	}
+
+	// Invalidating the new holder releases the lock with a lock delay
+	// instead, and keeps the queue.
+	if err := s.SessionInvalidate(9, next.ID); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	_, d, err = s.KVSGet(nil, "/bar")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if d.Session != "" || d.LockIndex != 2 ||
+		!reflect.DeepEqual(d.LockWaiters, []string{last.ID}) {
+		t.Fatalf("bad entry: %#v", d)
+	}
+	if expires := s.KVSLockDelay("/bar"); expires.Before(time.Now()) {
+		t.Fatalf("Bad: %v", expires)
+	}
+
+	// Destroying a queued session takes it out of the queue.
+	if err := s.SessionDestroy(10, last.ID); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	_, d, err = s.KVSGet(nil, "/bar")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if d.Session != "" || d.LockWaiters != nil {
+		t.Fatalf("bad entry: %#v", d)
+	}
+}
+
 func TestStateStore_Session_Invalidate_PreparedQuery_Delete(t *testing.T) {
 	s := testStateStore(t)
 
@@ -25,6 +25,14 @@ var (
 	// an ACL with an empty ID.
 	ErrMissingACLID = errors.New("Missing ACL ID")
 
+	// ErrMissingACLPolicyID is returned when an ACL policy set is called
+	// on a policy with an empty ID.
+	ErrMissingACLPolicyID = errors.New("Missing ACL policy ID")
+
+	// ErrMissingACLPolicyName is returned when an ACL policy set is called
+	// on a policy with an empty name.
+	ErrMissingACLPolicyName = errors.New("Missing ACL policy name")
+
 	// ErrMissingQueryID is returned when a Query set is called on
 	// a Query with an empty ID.
 	ErrMissingQueryID = errors.New("Missing Query ID")
@@ -59,6 +67,9 @@ type Store struct {
 
 	// lockDelay holds expiration times for locks associated with keys.
 	lockDelay *Delay
+
+	// publisher keeps the change events for subscriptions.
+	publisher *eventPublisher
 }
 
 // Snapshot is used to provide a point-in-time snapshot. It
@@ -108,6 +119,7 @@ func NewStateStore(gc *TombstoneGC) (*Store, error) {
 		abandonCh:    make(chan struct{}),
 		kvsGraveyard: NewGraveyard(gc),
 		lockDelay:    NewDelay(),
+		publisher:    newEventPublisher(),
 	}
 	return s, nil
 }
@@ -140,6 +152,7 @@ func (s *Snapshot) Close() {
 // transaction.
 func (s *Store) Restore() *Restore {
 	tx := s.db.Txn(true)
+	s.publisher.ignore(tx)
 	return &Restore{s, tx}
 }
 
@@ -153,6 +166,14 @@ func (s *Restore) Abort() {
 // called.
 func (s *Restore) Commit() {
 	s.tx.Commit()
+
+	// The restored data didn't generate any events, so subscriptions can't
+	// resume from before it.
+	var tables []string
+	for table := range s.store.schema.Tables {
+		tables = append(tables, table)
+	}
+	s.store.publisher.reset(s.store.maxIndex(tables...))
 }
 
 // AbandonCh returns a channel you can wait on to know if the state store was
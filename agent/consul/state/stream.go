@@ -0,0 +1,509 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/go-memdb"
+)
+
+const (
+	// streamBufferSize is the number of change events kept for
+	// subscriptions to read and to resume from. Subscriptions that fall
+	// further behind are closed and have to subscribe again.
+	streamBufferSize = 4096
+
+	// streamBufferMaxBytes bounds the estimated size of the buffered
+	// events, since KV events carry their values. The oldest events are
+	// dropped once it's exceeded, just like when there are too many.
+	streamBufferMaxBytes = 32 * 1024 * 1024
+
+	// streamEventOverhead is the estimated size of an event, not counting
+	// its key and KV value.
+	streamEventOverhead = 512
+)
+
+var (
+	// ErrSubscriptionClosed is returned by a subscription that can't
+	// continue, because it fell too far behind or the state store was
+	// abandoned. Clients should subscribe again from the index of the last
+	// event they received.
+	ErrSubscriptionClosed = errors.New("Subscription closed by server, subscribe again")
+)
+
+// eventPublisher collects the change events of write transactions, and
+// keeps a buffer of the events of committed transactions for subscriptions
+// to read.
+//
+// Events are published when a transaction commits, in the order of the
+// commits. This relies on all writes coming from the FSM, which applies
+// them one at a time.
+type eventPublisher struct {
+	// subscribers is the number of open subscriptions. This is first so
+	// it's aligned for atomic access.
+	subscribers int64
+
+	lock sync.RWMutex
+
+	// events is the buffer of published events, where events[i] has the
+	// sequence number first+i. size is their estimated size in bytes.
+	events []*structs.StreamEvent
+	first  uint64
+	size   int
+
+	// dropped is the highest index of the events that were dropped from
+	// the buffer. Subscriptions can only resume after it.
+	dropped uint64
+
+	// notifyCh is closed and replaced when events are published.
+	notifyCh chan struct{}
+
+	// pendingTx is the write transaction pending is collecting events for.
+	// A nil pending drops the events of the transaction.
+	pendingLock sync.Mutex
+	pendingTx   *memdb.Txn
+	pending     *pendingEvents
+}
+
+// pendingEvents are the events collected for a write transaction.
+type pendingEvents struct {
+	events []*structs.StreamEvent
+
+	// skipped is the index of the transaction if some of its events
+	// weren't collected, in which case none of them are published.
+	skipped uint64
+}
+
+// newEventPublisher returns an empty event publisher.
+func newEventPublisher() *eventPublisher {
+	return &eventPublisher{
+		notifyCh: make(chan struct{}),
+	}
+}
+
+// add records events for the given write transaction, which are published
+// if the transaction commits.
+func (p *eventPublisher) add(tx *memdb.Txn, events ...*structs.StreamEvent) {
+	p.pendingLock.Lock()
+	defer p.pendingLock.Unlock()
+
+	if pending := p.pendingTxn(tx); pending != nil {
+		pending.events = append(pending.events, events...)
+	}
+}
+
+// skip records that some events of the given write transaction weren't
+// collected, which is done when nobody is subscribed and collecting them
+// would be expensive. If the transaction commits, subscriptions can only
+// resume after its index.
+func (p *eventPublisher) skip(tx *memdb.Txn, idx uint64) {
+	p.pendingLock.Lock()
+	defer p.pendingLock.Unlock()
+
+	if pending := p.pendingTxn(tx); pending != nil {
+		pending.skipped = idx
+	}
+}
+
+// pendingTxn returns the pending events of the given write transaction, or
+// nil if they're ignored. The pendingLock must be held.
+func (p *eventPublisher) pendingTxn(tx *memdb.Txn) *pendingEvents {
+	if p.pendingTx != tx {
+		// Write transactions are serialized, so this is a new one and the
+		// last one was either committed or aborted.
+		pending := new(pendingEvents)
+		p.pendingTx, p.pending = tx, pending
+		tx.Defer(func() { p.publish(pending) })
+	}
+	return p.pending
+}
+
+// hasSubscribers returns whether any subscriptions are open.
+func (p *eventPublisher) hasSubscribers() bool {
+	return atomic.LoadInt64(&p.subscribers) > 0
+}
+
+// ignore drops any events for the given write transaction, which is used
+// for restores.
+func (p *eventPublisher) ignore(tx *memdb.Txn) {
+	p.pendingLock.Lock()
+	defer p.pendingLock.Unlock()
+	p.pendingTx, p.pending = tx, nil
+}
+
+// publish adds the events of a committed transaction to the buffer and
+// wakes up the subscriptions. The oldest events are dropped once there are
+// too many of them, or they're too big.
+func (p *eventPublisher) publish(pending *pendingEvents) {
+	if pending.skipped > 0 {
+		p.skipped(pending.skipped)
+		return
+	}
+	if len(pending.events) == 0 {
+		return
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.events = append(p.events, pending.events...)
+	for _, e := range pending.events {
+		p.size += streamEventSize(e)
+	}
+	n := 0
+	for len(p.events)-n > streamBufferSize || (n < len(p.events) && p.size > streamBufferMaxBytes) {
+		p.size -= streamEventSize(p.events[n])
+		n++
+	}
+	if n > 0 {
+		p.dropped = p.events[n-1].Index
+		p.first += uint64(n)
+		p.events = append([]*structs.StreamEvent(nil), p.events[n:]...)
+	}
+	close(p.notifyCh)
+	p.notifyCh = make(chan struct{})
+}
+
+// skipped drops all the events in the buffer after a transaction whose
+// events weren't collected, so subscriptions can only resume after its
+// index. The sequence numbers move past it as if it had an event, so any
+// subscriptions that started before it committed are closed.
+func (p *eventPublisher) skipped(idx uint64) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.first += uint64(len(p.events)) + 1
+	p.events = nil
+	p.size = 0
+	p.dropped = idx
+	close(p.notifyCh)
+	p.notifyCh = make(chan struct{})
+}
+
+// reset drops all the events in the buffer, so subscriptions can only
+// resume after the given index.
+func (p *eventPublisher) reset(idx uint64) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.first += uint64(len(p.events))
+	p.events = nil
+	p.size = 0
+	p.dropped = idx
+	close(p.notifyCh)
+	p.notifyCh = make(chan struct{})
+}
+
+// streamEventSize returns the estimated size of an event in bytes, which is
+// mostly its key and KV value.
+func streamEventSize(e *structs.StreamEvent) int {
+	size := streamEventOverhead + len(e.Key)
+	if e.KV != nil {
+		size += len(e.KV.Key) + len(e.KV.Value)
+	}
+	return size
+}
+
+// Subscription reads the change events of a topic from the state store.
+// Create one with Store.Subscribe.
+type Subscription struct {
+	store *Store
+	req   structs.SubscribeRequest
+
+	// next is the sequence number of the next event to read.
+	next uint64
+
+	// index is the highest index the subscriber has seen, whether from
+	// events or the snapshot. Older events are skipped.
+	index uint64
+
+	// initial holds the events to return before reading the buffer.
+	initial []*structs.StreamEvent
+
+	// closed is whether Close was called.
+	closed bool
+}
+
+// Subscribe returns a subscription to the change events of a topic. If the
+// request has an index and the events after it are still buffered, the
+// subscription resumes from there. Otherwise it starts with a snapshot of
+// the current data for the topic.
+func (s *Store) Subscribe(req *structs.SubscribeRequest) (*Subscription, error) {
+	switch req.Topic {
+	case structs.StreamTopicServiceHealth:
+		if req.Key == "" {
+			return nil, fmt.Errorf("Must provide a service name")
+		}
+	case structs.StreamTopicKV:
+	default:
+		return nil, fmt.Errorf("Unknown topic %q", req.Topic)
+	}
+
+	sub := &Subscription{store: s, req: *req}
+	p := s.publisher
+	atomic.AddInt64(&p.subscribers, 1)
+
+	// Grab our position in the buffer before taking the snapshot, so
+	// nothing is missed in between. Events that made it into the snapshot
+	// are skipped by their index.
+	p.lock.RLock()
+	resume := req.Index > 0 && req.Index >= p.dropped
+	if resume {
+		sub.next = p.first
+	} else {
+		sub.next = p.first + uint64(len(p.events))
+	}
+	p.lock.RUnlock()
+
+	if resume {
+		sub.index = req.Index
+		sub.initial = []*structs.StreamEvent{
+			&structs.StreamEvent{
+				Topic: req.Topic,
+				Type:  structs.StreamResumed,
+				Key:   req.Key,
+				Index: req.Index,
+			},
+		}
+		return sub, nil
+	}
+
+	tx := s.db.Txn(false)
+	defer tx.Abort()
+
+	var tables []string
+	for table := range s.schema.Tables {
+		tables = append(tables, table)
+	}
+	idx := maxIndexTxn(tx, tables...)
+
+	var events []*structs.StreamEvent
+	var err error
+	switch req.Topic {
+	case structs.StreamTopicServiceHealth:
+		events, err = s.serviceHealthSnapshotTxn(tx, idx, req.Key)
+	case structs.StreamTopicKV:
+		events, err = s.kvsSnapshotTxn(tx, idx, req.Key)
+	}
+	if err != nil {
+		sub.Close()
+		return nil, err
+	}
+
+	sub.index = idx
+	sub.initial = append(events, &structs.StreamEvent{
+		Topic: req.Topic,
+		Type:  structs.StreamEndOfSnapshot,
+		Key:   req.Key,
+		Index: idx,
+	})
+	return sub, nil
+}
+
+// Next returns the next batch of events for the subscription, blocking
+// until there are any. It returns ErrSubscriptionClosed if the
+// subscription can't continue, or the error of the context if it's done.
+func (sub *Subscription) Next(ctx context.Context) ([]*structs.StreamEvent, error) {
+	if sub.initial != nil {
+		events := sub.initial
+		sub.initial = nil
+		return events, nil
+	}
+
+	p := sub.store.publisher
+	for {
+		p.lock.RLock()
+		notifyCh := p.notifyCh
+
+		// If events we haven't read were dropped we can only go on if
+		// we've seen their index already.
+		if sub.next < p.first {
+			if p.dropped > sub.index {
+				p.lock.RUnlock()
+				return nil, ErrSubscriptionClosed
+			}
+			sub.next = p.first
+		}
+
+		// All the events of a transaction are published together, so this
+		// never returns part of one.
+		var events []*structs.StreamEvent
+		for _, e := range p.events[sub.next-p.first:] {
+			if e.Index > sub.index && sub.matches(e) {
+				events = append(events, e)
+			}
+		}
+		if n := len(p.events); n > 0 && p.events[n-1].Index > sub.index {
+			sub.index = p.events[n-1].Index
+		}
+		sub.next = p.first + uint64(len(p.events))
+		p.lock.RUnlock()
+
+		if len(events) > 0 {
+			return events, nil
+		}
+
+		select {
+		case <-notifyCh:
+		case <-sub.store.AbandonCh():
+			return nil, ErrSubscriptionClosed
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Close releases the subscription once it's no longer read, so writes can
+// tell whether anyone is subscribed.
+func (sub *Subscription) Close() {
+	if !sub.closed {
+		sub.closed = true
+		atomic.AddInt64(&sub.store.publisher.subscribers, -1)
+	}
+}
+
+// matches returns true if the event is for the subscription's topic and key.
+func (sub *Subscription) matches(e *structs.StreamEvent) bool {
+	if e.Topic != sub.req.Topic {
+		return false
+	}
+	if e.Topic == structs.StreamTopicKV {
+		return strings.HasPrefix(e.Key, sub.req.Key)
+	}
+	return e.Key == sub.req.Key
+}
+
+// serviceHealthSnapshotTxn returns the events that describe the current
+// instances of a service and their checks.
+func (s *Store) serviceHealthSnapshotTxn(tx *memdb.Txn, idx uint64, serviceName string) ([]*structs.StreamEvent, error) {
+	services, err := tx.Get("services", "service", serviceName)
+	if err != nil {
+		return nil, fmt.Errorf("failed service lookup: %s", err)
+	}
+	var results structs.ServiceNodes
+	for service := services.Next(); service != nil; service = services.Next() {
+		results = append(results, service.(*structs.ServiceNode))
+	}
+	_, nodes, err := s.parseCheckServiceNodes(tx, memdb.NewWatchSet(), idx, serviceName, results, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []*structs.StreamEvent
+	for _, n := range nodes {
+		events = append(events, &structs.StreamEvent{
+			Topic:   structs.StreamTopicServiceHealth,
+			Type:    structs.StreamServiceUpsert,
+			Key:     serviceName,
+			Index:   idx,
+			Service: &structs.CheckServiceNode{Node: n.Node, Service: n.Service},
+		})
+		for _, check := range n.Checks {
+			events = append(events, &structs.StreamEvent{
+				Topic: structs.StreamTopicServiceHealth,
+				Type:  structs.StreamCheckUpdate,
+				Key:   serviceName,
+				Index: idx,
+				Check: check,
+			})
+		}
+	}
+	return events, nil
+}
+
+// kvsSnapshotTxn returns the events that describe the current entries
+// under a prefix.
+func (s *Store) kvsSnapshotTxn(tx *memdb.Txn, idx uint64, prefix string) ([]*structs.StreamEvent, error) {
+	entries, err := tx.Get("kvs", "id_prefix", prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed kvs lookup: %s", err)
+	}
+	var events []*structs.StreamEvent
+	for entry := entries.Next(); entry != nil; entry = entries.Next() {
+		e := entry.(*structs.DirEntry)
+		events = append(events, &structs.StreamEvent{
+			Topic: structs.StreamTopicKV,
+			Type:  structs.StreamKVPut,
+			Key:   e.Key,
+			Index: idx,
+			KV:    e,
+		})
+	}
+	return events, nil
+}
+
+// serviceEventTxn records a service event for the instance.
+func (s *Store) serviceEventTxn(tx *memdb.Txn, idx uint64, typ structs.StreamEventType,
+	node *structs.Node, svc *structs.ServiceNode) {
+	s.publisher.add(tx, &structs.StreamEvent{
+		Topic: structs.StreamTopicServiceHealth,
+		Type:  typ,
+		Key:   svc.ServiceName,
+		Index: idx,
+		Service: &structs.CheckServiceNode{
+			Node:    node,
+			Service: svc.ToNodeService(),
+		},
+	})
+}
+
+// nodeServicesEventsTxn records an upsert event for each service instance
+// on the node, after the node changed.
+func (s *Store) nodeServicesEventsTxn(tx *memdb.Txn, idx uint64, node *structs.Node) error {
+	services, err := tx.Get("services", "node", node.Node)
+	if err != nil {
+		return fmt.Errorf("failed service lookup: %s", err)
+	}
+	for service := services.Next(); service != nil; service = services.Next() {
+		s.serviceEventTxn(tx, idx, structs.StreamServiceUpsert, node, service.(*structs.ServiceNode))
+	}
+	return nil
+}
+
+// checkEventsTxn records a check event for each service instance the check
+// applies to, which is every instance on the node for node checks.
+func (s *Store) checkEventsTxn(tx *memdb.Txn, idx uint64, typ structs.StreamEventType,
+	hc *structs.HealthCheck) error {
+	if hc.ServiceID != "" {
+		s.publisher.add(tx, &structs.StreamEvent{
+			Topic: structs.StreamTopicServiceHealth,
+			Type:  typ,
+			Key:   hc.ServiceName,
+			Index: idx,
+			Check: hc,
+		})
+		return nil
+	}
+
+	services, err := tx.Get("services", "node", hc.Node)
+	if err != nil {
+		return fmt.Errorf("failed service lookup: %s", err)
+	}
+	for service := services.Next(); service != nil; service = services.Next() {
+		s.publisher.add(tx, &structs.StreamEvent{
+			Topic: structs.StreamTopicServiceHealth,
+			Type:  typ,
+			Key:   service.(*structs.ServiceNode).ServiceName,
+			Index: idx,
+			Check: hc,
+		})
+	}
+	return nil
+}
+
+// kvsEventTxn records a KV event for the entry.
+func (s *Store) kvsEventTxn(tx *memdb.Txn, idx uint64, typ structs.StreamEventType,
+	entry *structs.DirEntry) {
+	s.publisher.add(tx, &structs.StreamEvent{
+		Topic: structs.StreamTopicKV,
+		Type:  typ,
+		Key:   entry.Key,
+		Index: idx,
+		KV:    entry,
+	})
+}
@@ -0,0 +1,302 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/api"
+)
+
+// testNextEvents returns the next batch of events of the subscription
+// formatted as type/key/index.
+func testNextEvents(t *testing.T, sub *Subscription) []string {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	events, err := sub.Next(ctx)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	var out []string
+	for _, e := range events {
+		out = append(out, fmt.Sprintf("%s/%s/%d", e.Type, e.Key, e.Index))
+	}
+	return out
+}
+
+// testNoEvents makes sure the subscription has no events waiting.
+func testNoEvents(t *testing.T, sub *Subscription) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	events, err := sub.Next(ctx)
+	if err != context.DeadlineExceeded {
+		t.Fatalf("got %v %v want no events", events, err)
+	}
+}
+
+func testVerifyEvents(t *testing.T, sub *Subscription, want ...string) {
+	if got := testNextEvents(t, sub); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v want %v", got, want)
+	}
+}
+
+func TestStateStore_Subscribe_ServiceHealth(t *testing.T) {
+	s := testStateStore(t)
+	testRegisterNode(t, s, 1, "node1")
+	testRegisterService(t, s, 2, "node1", "web")
+	testRegisterCheck(t, s, 3, "node1", "web", "check1", api.HealthPassing)
+
+	sub, err := s.Subscribe(&structs.SubscribeRequest{
+		Topic: structs.StreamTopicServiceHealth,
+		Key:   "web",
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// The snapshot comes first.
+	ctx := context.Background()
+	events, err := sub.Next(ctx)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(events) != 3 ||
+		events[0].Type != structs.StreamServiceUpsert ||
+		events[0].Service.Node.Node != "node1" || events[0].Service.Service.ID != "web" ||
+		events[1].Type != structs.StreamCheckUpdate || events[1].Check.CheckID != "check1" ||
+		events[2].Type != structs.StreamEndOfSnapshot || events[2].Index != 3 {
+		t.Fatalf("bad: %#v", events)
+	}
+
+	// A check changing status is a single event.
+	testRegisterCheck(t, s, 4, "node1", "web", "check1", api.HealthCritical)
+	events, err = sub.Next(ctx)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(events) != 1 || events[0].Check.Status != api.HealthCritical {
+		t.Fatalf("bad: %#v", events)
+	}
+
+	// Writes that don't change anything or are for other services aren't
+	// sent.
+	testRegisterCheck(t, s, 5, "node1", "web", "check1", api.HealthCritical)
+	testRegisterService(t, s, 6, "node1", "db")
+	testSetKey(t, s, 7, "foo", "bar")
+	testNoEvents(t, sub)
+
+	// Node checks apply to every service on the node.
+	testRegisterCheck(t, s, 8, "node1", "", "serfHealth", api.HealthPassing)
+	testVerifyEvents(t, sub, "check-update/web/8")
+
+	// Changes to the node are changes to its services.
+	if err := s.EnsureNode(9, &structs.Node{Node: "node1", Address: "1.2.3.4"}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	events, err = sub.Next(ctx)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(events) != 1 || events[0].Type != structs.StreamServiceUpsert ||
+		events[0].Service.Node.Address != "1.2.3.4" {
+		t.Fatalf("bad: %#v", events)
+	}
+
+	// Deleting the service deletes its checks.
+	if err := s.DeleteService(10, "node1", "web"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	testVerifyEvents(t, sub, "check-delete/web/10", "service-delete/web/10")
+
+	// Services must be named.
+	if _, err := s.Subscribe(&structs.SubscribeRequest{Topic: structs.StreamTopicServiceHealth}); err == nil {
+		t.Fatalf("should fail")
+	}
+	if _, err := s.Subscribe(&structs.SubscribeRequest{Topic: "nope"}); err == nil {
+		t.Fatalf("should fail")
+	}
+}
+
+func TestStateStore_Subscribe_KV(t *testing.T) {
+	s := testStateStore(t)
+	testSetKey(t, s, 1, "foo/a", "1")
+	testSetKey(t, s, 2, "bar", "1")
+
+	sub, err := s.Subscribe(&structs.SubscribeRequest{
+		Topic: structs.StreamTopicKV,
+		Key:   "foo/",
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	testVerifyEvents(t, sub, "kv-put/foo/a/2", "end-of-snapshot/foo//2")
+
+	testSetKey(t, s, 3, "foo/b", "2")
+	testSetKey(t, s, 4, "bar", "2")
+	testVerifyEvents(t, sub, "kv-put/foo/b/3")
+
+	if err := s.KVSDelete(5, "foo/a"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	testVerifyEvents(t, sub, "kv-delete/foo/a/5")
+
+	testSetKey(t, s, 6, "foo/c", "3")
+	testSetKey(t, s, 7, "foo/d", "4")
+	if err := s.KVSDeleteTree(8, "foo/"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	testVerifyEvents(t, sub, "kv-put/foo/c/6", "kv-put/foo/d/7",
+		"kv-delete/foo/b/8", "kv-delete/foo/c/8", "kv-delete/foo/d/8")
+}
+
+func TestStateStore_Subscribe_Resume(t *testing.T) {
+	s := testStateStore(t)
+	testSetKey(t, s, 1, "foo", "1")
+	testSetKey(t, s, 2, "foo", "2")
+	testSetKey(t, s, 3, "foo", "3")
+
+	req := &structs.SubscribeRequest{
+		Topic: structs.StreamTopicKV,
+		Key:   "foo",
+		Index: 1,
+	}
+	sub, err := s.Subscribe(req)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	testVerifyEvents(t, sub, "resumed/foo/1")
+	testVerifyEvents(t, sub, "kv-put/foo/2", "kv-put/foo/3")
+
+	// Fill the buffer so the early events are dropped.
+	for i := 0; i < streamBufferSize; i++ {
+		testSetKey(t, s, uint64(4+i), "bar", "x")
+	}
+	last := uint64(3 + streamBufferSize)
+
+	testSetKey(t, s, last+1, "foo", "4")
+
+	// Resuming from before the buffer gets a snapshot instead.
+	sub, err = s.Subscribe(req)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	testVerifyEvents(t, sub, fmt.Sprintf("kv-put/foo/%d", last+1),
+		fmt.Sprintf("end-of-snapshot/foo/%d", last+1))
+}
+
+func TestStateStore_Subscribe_Lagged(t *testing.T) {
+	s := testStateStore(t)
+	sub, err := s.Subscribe(&structs.SubscribeRequest{Topic: structs.StreamTopicKV})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	testVerifyEvents(t, sub, "end-of-snapshot//0")
+
+	// A subscription that doesn't keep up is closed.
+	for i := 0; i <= streamBufferSize; i++ {
+		testSetKey(t, s, uint64(1+i), "foo", "x")
+	}
+	if _, err := sub.Next(context.Background()); err != ErrSubscriptionClosed {
+		t.Fatalf("got %v want %v", err, ErrSubscriptionClosed)
+	}
+}
+
+func TestStateStore_Subscribe_LaggedBytes(t *testing.T) {
+	s := testStateStore(t)
+	sub, err := s.Subscribe(&structs.SubscribeRequest{Topic: structs.StreamTopicKV})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	testVerifyEvents(t, sub, "end-of-snapshot//0")
+
+	// Big values fill the buffer long before there are too many events.
+	value := make([]byte, 1024*1024)
+	for i := 0; i*len(value) <= streamBufferMaxBytes; i++ {
+		if err := s.KVSSet(uint64(1+i), &structs.DirEntry{Key: "foo", Value: value}); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+	if _, err := sub.Next(context.Background()); err != ErrSubscriptionClosed {
+		t.Fatalf("got %v want %v", err, ErrSubscriptionClosed)
+	}
+}
+
+func TestStateStore_Subscribe_DeleteTreeUnsubscribed(t *testing.T) {
+	s := testStateStore(t)
+	testSetKey(t, s, 1, "foo/a", "1")
+	testSetKey(t, s, 2, "foo/b", "2")
+
+	// With nobody subscribed the deleted keys aren't collected, so a
+	// subscription can't resume from before the delete.
+	if err := s.KVSDeleteTree(3, "foo/"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	testSetKey(t, s, 4, "foo/c", "3")
+
+	req := &structs.SubscribeRequest{
+		Topic: structs.StreamTopicKV,
+		Key:   "foo/",
+		Index: 2,
+	}
+	sub, err := s.Subscribe(req)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	testVerifyEvents(t, sub, "kv-put/foo/c/4", "end-of-snapshot/foo//4")
+	sub.Close()
+
+	// Resuming from after the delete works.
+	req.Index = 3
+	sub, err = s.Subscribe(req)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	testVerifyEvents(t, sub, "resumed/foo//3")
+	testVerifyEvents(t, sub, "kv-put/foo/c/4")
+	sub.Close()
+
+	if s.publisher.hasSubscribers() {
+		t.Fatalf("should not have subscribers")
+	}
+}
+
+func TestStateStore_Subscribe_Abandon(t *testing.T) {
+	s := testStateStore(t)
+	sub, err := s.Subscribe(&structs.SubscribeRequest{Topic: structs.StreamTopicKV})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	testVerifyEvents(t, sub, "end-of-snapshot//0")
+
+	s.Abandon()
+	if _, err := sub.Next(context.Background()); err != ErrSubscriptionClosed {
+		t.Fatalf("got %v want %v", err, ErrSubscriptionClosed)
+	}
+}
+
+func TestStateStore_Subscribe_Restore(t *testing.T) {
+	s := testStateStore(t)
+	restore := s.Restore()
+	if err := restore.KVS(&structs.DirEntry{
+		Key:       "foo",
+		RaftIndex: structs.RaftIndex{CreateIndex: 5, ModifyIndex: 5},
+	}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	restore.Commit()
+
+	// The restore doesn't make events, so a subscription from before it
+	// gets a snapshot.
+	sub, err := s.Subscribe(&structs.SubscribeRequest{
+		Topic: structs.StreamTopicKV,
+		Index: 1,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	testVerifyEvents(t, sub, "kv-put/foo/5", "end-of-snapshot//5")
+	testNoEvents(t, sub)
+}
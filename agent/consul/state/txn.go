@@ -42,7 +42,7 @@ func (s *Store) txnKVS(tx *memdb.Txn, idx uint64, op *structs.TxnKVOp) (structs.
 	case api.KVLock:
 		var ok bool
 		entry = &op.DirEnt
-		ok, err = s.kvsLockTxn(tx, idx, entry)
+		ok, err = s.kvsLockTxn(tx, idx, entry, false)
 		if !ok && err == nil {
 			err = fmt.Errorf("failed to lock key %q, lock is already held", op.DirEnt.Key)
 		}
@@ -110,6 +110,293 @@ func (s *Store) txnKVS(tx *memdb.Txn, idx uint64, op *structs.TxnKVOp) (structs.
 	return nil, nil
 }
 
+// txnNode handles all Node-related operations.
+func (s *Store) txnNode(tx *memdb.Txn, idx uint64, op *structs.TxnNodeOp) (structs.TxnResults, error) {
+	var entry *structs.Node
+	var err error
+
+	getNode := func() (*structs.Node, error) {
+		n, err := tx.First("nodes", "id", op.Node.Node)
+		if err != nil {
+			return nil, fmt.Errorf("node lookup failed: %s", err)
+		}
+		if n == nil {
+			return nil, nil
+		}
+		return n.(*structs.Node), nil
+	}
+
+	switch op.Verb {
+	case api.NodeGet:
+		entry, err = getNode()
+		if entry == nil && err == nil {
+			err = fmt.Errorf("node %q doesn't exist", op.Node.Node)
+		}
+
+	case api.NodeSet:
+		err = s.ensureNodeTxn(tx, idx, &op.Node)
+		if err == nil {
+			entry, err = getNode()
+		}
+
+	case api.NodeCAS:
+		var ok bool
+		ok, err = s.ensureNodeCASTxn(tx, idx, &op.Node)
+		if !ok && err == nil {
+			err = fmt.Errorf("failed to set node %q, index is stale", op.Node.Node)
+			break
+		}
+		if err == nil {
+			entry, err = getNode()
+		}
+
+	case api.NodeDelete:
+		err = s.deleteNodeTxn(tx, idx, op.Node.Node)
+
+	case api.NodeDeleteCAS:
+		var ok bool
+		ok, err = s.deleteNodeCASTxn(tx, idx, op.Node.ModifyIndex, op.Node.Node)
+		if !ok && err == nil {
+			err = fmt.Errorf("failed to delete node %q, index is stale", op.Node.Node)
+		}
+
+	default:
+		err = fmt.Errorf("unknown Node verb %q", op.Verb)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// For a GET we keep the value, otherwise we clone it (we have to clone
+	// so we don't modify the entry being used by the state store).
+	if entry != nil {
+		if op.Verb == api.NodeGet {
+			result := structs.TxnResult{Node: entry}
+			return structs.TxnResults{&result}, nil
+		}
+
+		clone := *entry
+		result := structs.TxnResult{Node: &clone}
+		return structs.TxnResults{&result}, nil
+	}
+
+	return nil, nil
+}
+
+// txnService handles all Service-related operations.
+func (s *Store) txnService(tx *memdb.Txn, idx uint64, op *structs.TxnServiceOp) (structs.TxnResults, error) {
+	var entry *structs.NodeService
+	var err error
+
+	getService := func() (*structs.NodeService, error) {
+		svc, err := tx.First("services", "id", op.Node, op.Service.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed service lookup: %s", err)
+		}
+		if svc == nil {
+			return nil, nil
+		}
+		return svc.(*structs.ServiceNode).ToNodeService(), nil
+	}
+
+	switch op.Verb {
+	case api.ServiceGet:
+		entry, err = getService()
+		if entry == nil && err == nil {
+			err = fmt.Errorf("service %q on node %q doesn't exist", op.Service.ID, op.Node)
+		}
+
+	case api.ServiceSet:
+		err = s.ensureServiceTxn(tx, idx, op.Node, &op.Service)
+		if err == nil {
+			entry, err = getService()
+		}
+
+	case api.ServiceCAS:
+		var ok bool
+		ok, err = s.ensureServiceCASTxn(tx, idx, op.Node, &op.Service)
+		if !ok && err == nil {
+			err = fmt.Errorf("failed to set service %q on node %q, index is stale", op.Service.ID, op.Node)
+			break
+		}
+		if err == nil {
+			entry, err = getService()
+		}
+
+	case api.ServiceDelete:
+		err = s.deleteServiceTxn(tx, idx, op.Node, op.Service.ID)
+
+	case api.ServiceDeleteCAS:
+		var ok bool
+		ok, err = s.deleteServiceCASTxn(tx, idx, op.Service.ModifyIndex, op.Node, op.Service.ID)
+		if !ok && err == nil {
+			err = fmt.Errorf("failed to delete service %q on node %q, index is stale", op.Service.ID, op.Node)
+		}
+
+	default:
+		err = fmt.Errorf("unknown Service verb %q", op.Verb)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The service entry is always a fresh copy converted from the stored
+	// service node, so we can return it directly.
+	if entry != nil {
+		result := structs.TxnResult{Service: entry}
+		return structs.TxnResults{&result}, nil
+	}
+
+	return nil, nil
+}
+
+// txnCheck handles all Check-related operations.
+func (s *Store) txnCheck(tx *memdb.Txn, idx uint64, op *structs.TxnCheckOp) (structs.TxnResults, error) {
+	var entry *structs.HealthCheck
+	var err error
+
+	getCheck := func() (*structs.HealthCheck, error) {
+		hc, err := tx.First("checks", "id", op.Check.Node, string(op.Check.CheckID))
+		if err != nil {
+			return nil, fmt.Errorf("failed health check lookup: %s", err)
+		}
+		if hc == nil {
+			return nil, nil
+		}
+		return hc.(*structs.HealthCheck), nil
+	}
+
+	switch op.Verb {
+	case api.CheckGet:
+		entry, err = getCheck()
+		if entry == nil && err == nil {
+			err = fmt.Errorf("check %q on node %q doesn't exist", op.Check.CheckID, op.Check.Node)
+		}
+
+	case api.CheckSet:
+		err = s.ensureCheckTxn(tx, idx, &op.Check)
+		if err == nil {
+			entry, err = getCheck()
+		}
+
+	case api.CheckCAS:
+		var ok bool
+		ok, err = s.ensureCheckCASTxn(tx, idx, &op.Check)
+		if !ok && err == nil {
+			err = fmt.Errorf("failed to set check %q on node %q, index is stale", op.Check.CheckID, op.Check.Node)
+			break
+		}
+		if err == nil {
+			entry, err = getCheck()
+		}
+
+	case api.CheckDelete:
+		err = s.deleteCheckTxn(tx, idx, op.Check.Node, op.Check.CheckID)
+
+	case api.CheckDeleteCAS:
+		var ok bool
+		ok, err = s.deleteCheckCASTxn(tx, idx, op.Check.ModifyIndex, op.Check.Node, op.Check.CheckID)
+		if !ok && err == nil {
+			err = fmt.Errorf("failed to delete check %q on node %q, index is stale", op.Check.CheckID, op.Check.Node)
+		}
+
+	default:
+		err = fmt.Errorf("unknown Check verb %q", op.Verb)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// For a GET we keep the value, otherwise we clone it (we have to clone
+	// so we don't modify the entry being used by the state store).
+	if entry != nil {
+		if op.Verb == api.CheckGet {
+			result := structs.TxnResult{Check: entry}
+			return structs.TxnResults{&result}, nil
+		}
+
+		clone := entry.Clone()
+		result := structs.TxnResult{Check: clone}
+		return structs.TxnResults{&result}, nil
+	}
+
+	return nil, nil
+}
+
+// txnSession handles all Session-related operations.
+func (s *Store) txnSession(tx *memdb.Txn, idx uint64, op *structs.TxnSessionOp) (structs.TxnResults, error) {
+	var entry *structs.Session
+	var err error
+
+	getSession := func() (*structs.Session, error) {
+		sess, err := tx.First("sessions", "id", op.Session.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed session lookup: %s", err)
+		}
+		if sess == nil {
+			return nil, nil
+		}
+		return sess.(*structs.Session), nil
+	}
+
+	switch op.Verb {
+	case api.SessionGet:
+		entry, err = getSession()
+		if entry == nil && err == nil {
+			err = fmt.Errorf("session %q doesn't exist", op.Session.ID)
+		}
+
+	case api.SessionCreate:
+		// Sessions are never updated in place, so creating one with an
+		// ID that's already in use is an error.
+		entry, err = getSession()
+		if entry != nil && err == nil {
+			err = fmt.Errorf("session %q already exists", op.Session.ID)
+			break
+		}
+		if err == nil {
+			err = s.sessionCreateTxn(tx, idx, &op.Session)
+		}
+		if err == nil {
+			entry, err = getSession()
+		}
+
+	case api.SessionDelete:
+		err = s.deleteSessionTxn(tx, idx, op.Session.ID, false)
+
+	case api.SessionDeleteCAS:
+		var existing *structs.Session
+		existing, err = getSession()
+		if err == nil && (existing == nil || existing.ModifyIndex != op.Session.ModifyIndex) {
+			err = fmt.Errorf("failed to delete session %q, index is stale", op.Session.ID)
+		}
+		if err == nil {
+			err = s.deleteSessionTxn(tx, idx, op.Session.ID, false)
+		}
+
+	default:
+		err = fmt.Errorf("unknown Session verb %q", op.Verb)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// For a GET we keep the value, otherwise we clone it (we have to clone
+	// so we don't modify the entry being used by the state store).
+	if entry != nil {
+		if op.Verb == api.SessionGet {
+			result := structs.TxnResult{Session: entry}
+			return structs.TxnResults{&result}, nil
+		}
+
+		clone := *entry
+		result := structs.TxnResult{Session: &clone}
+		return structs.TxnResults{&result}, nil
+	}
+
+	return nil, nil
+}
+
 // txnDispatch runs the given operations inside the state store transaction.
 func (s *Store) txnDispatch(tx *memdb.Txn, idx uint64, ops structs.TxnOps) (structs.TxnResults, structs.TxnErrors) {
 	results := make(structs.TxnResults, 0, len(ops))
@@ -119,9 +406,18 @@ func (s *Store) txnDispatch(tx *memdb.Txn, idx uint64, ops structs.TxnOps) (stru
 		var err error
 
 		// Dispatch based on the type of operation.
-		if op.KV != nil {
+		switch {
+		case op.KV != nil:
 			ret, err = s.txnKVS(tx, idx, op.KV)
-		} else {
+		case op.Node != nil:
+			ret, err = s.txnNode(tx, idx, op.Node)
+		case op.Service != nil:
+			ret, err = s.txnService(tx, idx, op.Service)
+		case op.Check != nil:
+			ret, err = s.txnCheck(tx, idx, op.Check)
+		case op.Session != nil:
+			ret, err = s.txnSession(tx, idx, op.Session)
+		default:
 			err = fmt.Errorf("no operation specified")
 		}
 
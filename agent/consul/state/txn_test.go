@@ -712,3 +712,360 @@ func TestStateStore_Txn_KVS_RO_Safety(t *testing.T) {
 		}
 	}
 }
+
+func TestStateStore_Txn_Catalog(t *testing.T) {
+	s := testStateStore(t)
+
+	// Set up an existing node, service and check to update and delete.
+	testRegisterNode(t, s, 1, "node1")
+	testRegisterService(t, s, 2, "node1", "redis")
+	testRegisterCheck(t, s, 3, "node1", "redis", "redis-check", api.HealthPassing)
+	testRegisterNode(t, s, 4, "node2")
+
+	// Register a whole new node, its service and its check, and also do
+	// conditional updates and deletes on the existing entries.
+	ops := structs.TxnOps{
+		&structs.TxnOp{
+			Node: &structs.TxnNodeOp{
+				Verb: api.NodeSet,
+				Node: structs.Node{Node: "node3", Address: "127.0.0.3"},
+			},
+		},
+		&structs.TxnOp{
+			Service: &structs.TxnServiceOp{
+				Verb:    api.ServiceSet,
+				Node:    "node3",
+				Service: structs.NodeService{ID: "web", Service: "web", Port: 80},
+			},
+		},
+		&structs.TxnOp{
+			Check: &structs.TxnCheckOp{
+				Verb: api.CheckCAS,
+				Check: structs.HealthCheck{
+					Node:      "node3",
+					CheckID:   "web-check",
+					ServiceID: "web",
+					Status:    api.HealthPassing,
+				},
+			},
+		},
+		&structs.TxnOp{
+			Service: &structs.TxnServiceOp{
+				Verb: api.ServiceCAS,
+				Node: "node1",
+				Service: structs.NodeService{
+					ID:        "redis",
+					Service:   "redis",
+					Port:      6379,
+					RaftIndex: structs.RaftIndex{ModifyIndex: 2},
+				},
+			},
+		},
+		&structs.TxnOp{
+			Check: &structs.TxnCheckOp{
+				Verb: api.CheckDeleteCAS,
+				Check: structs.HealthCheck{
+					Node:      "node1",
+					CheckID:   "redis-check",
+					RaftIndex: structs.RaftIndex{ModifyIndex: 3},
+				},
+			},
+		},
+		&structs.TxnOp{
+			Node: &structs.TxnNodeOp{
+				Verb: api.NodeDeleteCAS,
+				Node: structs.Node{
+					Node:      "node2",
+					RaftIndex: structs.RaftIndex{ModifyIndex: 4},
+				},
+			},
+		},
+		&structs.TxnOp{
+			Node: &structs.TxnNodeOp{
+				Verb: api.NodeGet,
+				Node: structs.Node{Node: "node1"},
+			},
+		},
+	}
+	results, errors := s.TxnRW(5, ops)
+	if len(errors) > 0 {
+		t.Fatalf("err: %v", errors)
+	}
+
+	// Make sure the response looks as expected.
+	expected := structs.TxnResults{
+		&structs.TxnResult{
+			Node: &structs.Node{
+				Node:      "node3",
+				Address:   "127.0.0.3",
+				RaftIndex: structs.RaftIndex{CreateIndex: 5, ModifyIndex: 5},
+			},
+		},
+		&structs.TxnResult{
+			Service: &structs.NodeService{
+				ID:        "web",
+				Service:   "web",
+				Port:      80,
+				RaftIndex: structs.RaftIndex{CreateIndex: 5, ModifyIndex: 5},
+			},
+		},
+		&structs.TxnResult{
+			Check: &structs.HealthCheck{
+				Node:        "node3",
+				CheckID:     "web-check",
+				ServiceID:   "web",
+				ServiceName: "web",
+				Status:      api.HealthPassing,
+				RaftIndex:   structs.RaftIndex{CreateIndex: 5, ModifyIndex: 5},
+			},
+		},
+		&structs.TxnResult{
+			Service: &structs.NodeService{
+				ID:        "redis",
+				Service:   "redis",
+				Port:      6379,
+				RaftIndex: structs.RaftIndex{CreateIndex: 2, ModifyIndex: 5},
+			},
+		},
+		&structs.TxnResult{
+			Node: &structs.Node{
+				Node:      "node1",
+				RaftIndex: structs.RaftIndex{CreateIndex: 1, ModifyIndex: 1},
+			},
+		},
+	}
+	if len(results) != len(expected) {
+		t.Fatalf("bad: %v", results)
+	}
+	for i := range results {
+		if !reflect.DeepEqual(results[i], expected[i]) {
+			t.Fatalf("bad %d: %#v", i, results[i])
+		}
+	}
+
+	// Pull the resulting state store contents.
+	idx, nodes, err := s.Nodes(nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if idx != 5 {
+		t.Fatalf("bad index: %d", idx)
+	}
+	if len(nodes) != 2 || nodes[0].Node != "node1" || nodes[1].Node != "node3" {
+		t.Fatalf("bad: %v", nodes)
+	}
+	_, checks, err := s.NodeChecks(nil, "node1")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(checks) != 0 {
+		t.Fatalf("bad: %v", checks)
+	}
+	_, checks, err = s.NodeChecks(nil, "node3")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(checks) != 1 || checks[0].CheckID != "web-check" {
+		t.Fatalf("bad: %v", checks)
+	}
+}
+
+func TestStateStore_Txn_Catalog_Rollback(t *testing.T) {
+	s := testStateStore(t)
+
+	testRegisterNode(t, s, 1, "node1")
+	testRegisterService(t, s, 2, "node1", "redis")
+	testRegisterCheck(t, s, 3, "node1", "redis", "redis-check", api.HealthPassing)
+
+	// Set up a transaction where every conditional operation fails, with
+	// a good write up front to make sure it gets rolled back.
+	ops := structs.TxnOps{
+		&structs.TxnOp{
+			Node: &structs.TxnNodeOp{
+				Verb: api.NodeSet,
+				Node: structs.Node{Node: "node2", Address: "127.0.0.2"},
+			},
+		},
+		&structs.TxnOp{
+			Node: &structs.TxnNodeOp{
+				Verb: api.NodeCAS,
+				Node: structs.Node{Node: "node1", Address: "127.0.0.1"},
+			},
+		},
+		&structs.TxnOp{
+			Node: &structs.TxnNodeOp{
+				Verb: api.NodeDeleteCAS,
+				Node: structs.Node{
+					Node:      "node1",
+					RaftIndex: structs.RaftIndex{ModifyIndex: 2},
+				},
+			},
+		},
+		&structs.TxnOp{
+			Service: &structs.TxnServiceOp{
+				Verb: api.ServiceCAS,
+				Node: "node1",
+				Service: structs.NodeService{
+					ID:        "redis",
+					Service:   "redis",
+					RaftIndex: structs.RaftIndex{ModifyIndex: 1},
+				},
+			},
+		},
+		&structs.TxnOp{
+			Service: &structs.TxnServiceOp{
+				Verb:    api.ServiceSet,
+				Node:    "nope",
+				Service: structs.NodeService{ID: "web", Service: "web"},
+			},
+		},
+		&structs.TxnOp{
+			Check: &structs.TxnCheckOp{
+				Verb: api.CheckDeleteCAS,
+				Check: structs.HealthCheck{
+					Node:      "node1",
+					CheckID:   "redis-check",
+					RaftIndex: structs.RaftIndex{ModifyIndex: 2},
+				},
+			},
+		},
+		&structs.TxnOp{
+			Check: &structs.TxnCheckOp{
+				Verb:  api.CheckGet,
+				Check: structs.HealthCheck{Node: "node1", CheckID: "nope"},
+			},
+		},
+		&structs.TxnOp{
+			Node: &structs.TxnNodeOp{
+				Verb: "nope",
+				Node: structs.Node{Node: "node1"},
+			},
+		},
+	}
+	results, errors := s.TxnRW(4, ops)
+	if len(results) > 0 {
+		t.Fatalf("bad: %v", results)
+	}
+	expected := []string{
+		`failed to set node "node1", index is stale`,
+		`failed to delete node "node1", index is stale`,
+		`failed to set service "redis" on node "node1", index is stale`,
+		"Missing node registration",
+		`failed to delete check "redis-check" on node "node1", index is stale`,
+		`check "nope" on node "node1" doesn't exist`,
+		`unknown Node verb "nope"`,
+	}
+	if len(errors) != len(expected) {
+		t.Fatalf("bad len: %d != %d", len(errors), len(expected))
+	}
+	for i, msg := range expected {
+		if errors[i].OpIndex != i+1 {
+			t.Fatalf("bad index: %d != %d", i+1, errors[i].OpIndex)
+		}
+		if !strings.Contains(errors[i].Error(), msg) {
+			t.Fatalf("bad %d: %v", i, errors[i].Error())
+		}
+	}
+
+	// Make sure the good write didn't go through.
+	idx, node, err := s.GetNode("node2")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if idx != 1 || node != nil {
+		t.Fatalf("bad: %d %v", idx, node)
+	}
+}
+
+func TestStateStore_Txn_Session(t *testing.T) {
+	s := testStateStore(t)
+
+	testRegisterNode(t, s, 1, "node1")
+	session := testUUID()
+	existing := testUUID()
+	if err := s.SessionCreate(2, &structs.Session{ID: existing, Node: "node1"}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// Create a session and use it to take a lock in the same transaction,
+	// and clean up the old session with a CAS delete.
+	ops := structs.TxnOps{
+		&structs.TxnOp{
+			Session: &structs.TxnSessionOp{
+				Verb:    api.SessionCreate,
+				Session: structs.Session{ID: session, Node: "node1"},
+			},
+		},
+		&structs.TxnOp{
+			KV: &structs.TxnKVOp{
+				Verb: api.KVLock,
+				DirEnt: structs.DirEntry{
+					Key:     "leader",
+					Session: session,
+				},
+			},
+		},
+		&structs.TxnOp{
+			Session: &structs.TxnSessionOp{
+				Verb: api.SessionDeleteCAS,
+				Session: structs.Session{
+					ID:        existing,
+					RaftIndex: structs.RaftIndex{ModifyIndex: 2},
+				},
+			},
+		},
+	}
+	results, errors := s.TxnRW(3, ops)
+	if len(errors) > 0 {
+		t.Fatalf("err: %v", errors)
+	}
+	if len(results) != 2 || results[0].Session == nil || results[0].Session.ID != session ||
+		results[0].Session.Behavior != structs.SessionKeysRelease || results[1].KV == nil {
+		t.Fatalf("bad: %v", results)
+	}
+
+	// Make sure the lock is held by the new session and the old one is gone.
+	_, d, err := s.KVSGet(nil, "leader")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if d == nil || d.Session != session || d.LockIndex != 1 {
+		t.Fatalf("bad: %v", d)
+	}
+	_, sess, err := s.SessionGet(nil, existing)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if sess != nil {
+		t.Fatalf("bad: %v", sess)
+	}
+
+	// Creating the same session again should fail, and so should a stale
+	// CAS delete.
+	ops = structs.TxnOps{
+		&structs.TxnOp{
+			Session: &structs.TxnSessionOp{
+				Verb:    api.SessionCreate,
+				Session: structs.Session{ID: session, Node: "node1"},
+			},
+		},
+		&structs.TxnOp{
+			Session: &structs.TxnSessionOp{
+				Verb: api.SessionDeleteCAS,
+				Session: structs.Session{
+					ID:        session,
+					RaftIndex: structs.RaftIndex{ModifyIndex: 2},
+				},
+			},
+		},
+	}
+	results, errors = s.TxnRW(4, ops)
+	if len(results) > 0 {
+		t.Fatalf("bad: %v", results)
+	}
+	if len(errors) != 2 ||
+		!strings.Contains(errors[0].Error(), "already exists") ||
+		!strings.Contains(errors[1].Error(), "index is stale") {
+		t.Fatalf("bad: %v", errors)
+	}
+}
@@ -0,0 +1,247 @@
+// The subscribe endpoint is a special non-RPC endpoint that streams the change
+// events of the state store to subscribers. This gets wired directly into
+// Consul's stream handler, and a new TCP connection is made for each
+// subscription.
+//
+// This also includes a SubscribeRPC() function, which acts as a lightweight
+// client that knows the details of the stream protocol.
+package consul
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"time"
+
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/pool"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/go-msgpack/codec"
+)
+
+// SubscribeRPC serves the given subscription, calling fn with each event
+// until the context is done, fn returns an error, or the subscription can't
+// go on. Subscriptions for other datacenters are forwarded. They are never
+// forwarded to the leader since every server has the events, like reads with
+// AllowStale set.
+func (s *Server) SubscribeRPC(ctx context.Context, args *structs.SubscribeRequest, fn structs.StreamEventFn) error {
+	// Perform DC forwarding.
+	if dc := args.Datacenter; dc != "" && dc != s.config.Datacenter {
+		manager, server, ok := s.router.FindRoute(dc)
+		if !ok {
+			return structs.ErrNoDCPath
+		}
+
+		var started bool
+		err := SubscribeRPC(ctx, s.connPool, dc, server.Addr, server.UseTLS, args, func(e *structs.StreamEvent) error {
+			started = true
+			return fn(e)
+		})
+		if err != nil && !started && ctx.Err() == nil {
+			manager.NotifyFailedServer(server)
+		}
+		return err
+	}
+
+	// Services need read access up front, and the events are then filtered
+	// by the rest of the policy.
+	rule, err := s.resolveToken(args.Token)
+	if err != nil {
+		return err
+	}
+	if args.Topic == structs.StreamTopicServiceHealth && rule != nil && !rule.ServiceRead(args.Key) {
+		return acl.ErrPermissionDenied
+	}
+
+	sub, err := s.fsm.State().Subscribe(args)
+	if err != nil {
+		return err
+	}
+	defer sub.Close()
+	for {
+		events, err := sub.Next(ctx)
+		if err != nil {
+			return err
+		}
+
+		// Resolve the token for every batch so changes to the policy are
+		// applied to long-lived subscriptions.
+		rule, err := s.resolveToken(args.Token)
+		if err != nil {
+			return err
+		}
+		for _, e := range events {
+			if rule != nil && !streamEventAllowed(rule, e) {
+				continue
+			}
+			if err := fn(e); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// streamEventAllowed returns whether the policy allows reading the given
+// event. The key of service and check events is the service name. Events
+// that don't carry any data are always allowed.
+func streamEventAllowed(rule acl.ACL, e *structs.StreamEvent) bool {
+	switch {
+	case e.Service != nil:
+		return rule.ServiceRead(e.Key) && rule.NodeRead(e.Service.Node.Node)
+	case e.Check != nil:
+		return rule.ServiceRead(e.Key) && rule.NodeRead(e.Check.Node)
+	case e.KV != nil:
+		return rule.KeyRead(e.KV.Key)
+	default:
+		return true
+	}
+}
+
+// handleSubscribeRequest reads the request from the conn and serves the
+// subscription until the client goes away. This will be called from a
+// goroutine after an incoming stream is determined to be a subscription.
+func (s *Server) handleSubscribeRequest(conn net.Conn) error {
+	var args structs.SubscribeRequest
+	dec := codec.NewDecoder(conn, &codec.MsgpackHandle{})
+	if err := dec.Decode(&args); err != nil {
+		return fmt.Errorf("failed to decode request: %v", err)
+	}
+
+	enc := codec.NewEncoder(conn, &codec.MsgpackHandle{})
+	if err := s.acquireSubscription(remoteIP(conn), &args); err != nil {
+		reply := structs.SubscribeResponse{Error: err.Error()}
+		if err := enc.Encode(&reply); err != nil {
+			return fmt.Errorf("failed to encode response: %v", err)
+		}
+		return nil
+	}
+	defer s.rpcLimits.releaseBlockingQuery()
+
+	// The client doesn't send anything else, so it's gone once reading
+	// returns.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		io.Copy(ioutil.Discard, conn)
+		cancel()
+	}()
+	go func() {
+		select {
+		case <-s.shutdownCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	// The response header goes out with the first event, or with the
+	// error if the subscription couldn't be started. Errors after that
+	// are sent as an error event.
+	var started bool
+	var index uint64
+	err := s.SubscribeRPC(ctx, &args, func(e *structs.StreamEvent) error {
+		if !started {
+			started = true
+			if err := enc.Encode(&structs.SubscribeResponse{}); err != nil {
+				return fmt.Errorf("failed to encode response: %v", err)
+			}
+		}
+		index = e.Index
+		if err := enc.Encode(e); err != nil {
+			return fmt.Errorf("failed to encode event: %v", err)
+		}
+		return nil
+	})
+	switch {
+	case ctx.Err() != nil:
+		return nil
+
+	case !started:
+		reply := structs.SubscribeResponse{Error: err.Error()}
+		if err := enc.Encode(&reply); err != nil {
+			return fmt.Errorf("failed to encode response: %v", err)
+		}
+		return nil
+
+	default:
+		e := structs.StreamEvent{
+			Topic: args.Topic,
+			Type:  structs.StreamError,
+			Key:   args.Key,
+			Index: index,
+			Error: err.Error(),
+		}
+		if err := enc.Encode(&e); err != nil {
+			return fmt.Errorf("failed to encode event: %v", err)
+		}
+		return nil
+	}
+}
+
+// SubscribeRPC is a streaming client function for subscribing to the change
+// events of a remote server. It will create a fresh connection for each
+// subscription, send the request header, and parse the received response
+// header. If there's no error it calls fn with each event until the context
+// is done, fn returns an error, or the server ends the subscription. Error
+// events sent by the server are returned as errors and not passed to fn.
+func SubscribeRPC(ctx context.Context, connPool *pool.ConnPool, dc string, addr net.Addr, useTLS bool,
+	args *structs.SubscribeRequest, fn structs.StreamEventFn) error {
+
+	conn, _, err := connPool.DialTimeout(dc, addr, 10*time.Second, useTLS)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// Closing the connection is how we tell the server we're done, and it
+	// also unblocks the decoder below.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	// Write the subscribe RPC byte to set the mode, then perform the
+	// request.
+	if _, err := conn.Write([]byte{byte(pool.RPCSubscribe)}); err != nil {
+		return fmt.Errorf("failed to write stream type: %v", err)
+	}
+	enc := codec.NewEncoder(conn, &codec.MsgpackHandle{})
+	if err := enc.Encode(args); err != nil {
+		return fmt.Errorf("failed to encode request: %v", err)
+	}
+
+	// Pull the header decoded as msgpack, then the events.
+	var reply structs.SubscribeResponse
+	dec := codec.NewDecoder(conn, &codec.MsgpackHandle{})
+	if err := dec.Decode(&reply); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("failed to decode response: %v", err)
+	}
+	if reply.Error != "" {
+		return errors.New(reply.Error)
+	}
+	for {
+		var e structs.StreamEvent
+		if err := dec.Decode(&e); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("failed to decode event: %v", err)
+		}
+		if e.Type == structs.StreamError {
+			return errors.New(e.Error)
+		}
+		if err := fn(&e); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package consul
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/testrpc"
+	"github.com/hashicorp/consul/testutil/retry"
+	"github.com/hashicorp/net-rpc-msgpackrpc"
+)
+
+// testSubscription is a subscription running in the background.
+type testSubscription struct {
+	eventCh chan *structs.StreamEvent
+	errCh   chan error
+	cancel  func()
+}
+
+// testSubscribe starts a subscription to the server over the stream
+// protocol.
+func testSubscribe(t *testing.T, s *Server, args *structs.SubscribeRequest) *testSubscription {
+	ctx, cancel := context.WithCancel(context.Background())
+	sub := &testSubscription{
+		eventCh: make(chan *structs.StreamEvent, 100),
+		errCh:   make(chan error, 1),
+		cancel:  cancel,
+	}
+	go func() {
+		sub.errCh <- SubscribeRPC(ctx, s.connPool, s.config.Datacenter, s.config.RPCAddr, false,
+			args, func(e *structs.StreamEvent) error {
+				sub.eventCh <- e
+				return nil
+			})
+	}()
+	return sub
+}
+
+// verify waits for the given events, formatted as type/key.
+func (sub *testSubscription) verify(t *testing.T, want ...string) {
+	for _, w := range want {
+		select {
+		case e := <-sub.eventCh:
+			if got := string(e.Type) + "/" + e.Key; got != w {
+				t.Fatalf("got %s want %s", got, w)
+			}
+		case err := <-sub.errCh:
+			t.Fatalf("err: %v", err)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %s", w)
+		}
+	}
+}
+
+// testSetKV writes a key through the KVS endpoint.
+func testSetKV(t *testing.T, s *Server, dc, key, token string) {
+	codec := rpcClient(t, s)
+	defer codec.Close()
+
+	args := structs.KVSRequest{
+		Datacenter: dc,
+		Op:         api.KVSet,
+		DirEnt: structs.DirEntry{
+			Key:   key,
+			Value: []byte("hello"),
+		},
+		WriteRequest: structs.WriteRequest{Token: token},
+	}
+	var out bool
+	if err := msgpackrpc.CallWithCodec(codec, "KVS.Apply", &args, &out); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+	testSetKV(t, s1, "dc1", "foo/a", "")
+	testSetKV(t, s1, "dc1", "bar", "")
+
+	sub := testSubscribe(t, s1, &structs.SubscribeRequest{
+		Datacenter: "dc1",
+		Topic:      structs.StreamTopicKV,
+		Key:        "foo/",
+	})
+	sub.verify(t, "kv-put/foo/a", "end-of-snapshot/foo/")
+
+	testSetKV(t, s1, "dc1", "bar", "")
+	testSetKV(t, s1, "dc1", "foo/b", "")
+	sub.verify(t, "kv-put/foo/b")
+
+	// Going away ends the subscription.
+	sub.cancel()
+	select {
+	case err := <-sub.errCh:
+		if err != context.Canceled {
+			t.Fatalf("err: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("should end")
+	}
+
+	// Bad requests fail before any events are sent.
+	sub = testSubscribe(t, s1, &structs.SubscribeRequest{
+		Datacenter: "dc1",
+		Topic:      structs.StreamTopicServiceHealth,
+	})
+	select {
+	case err := <-sub.errCh:
+		if err == nil || err.Error() != "Must provide a service name" {
+			t.Fatalf("err: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("should fail")
+	}
+}
+
+func TestSubscribe_Limit(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.RPCMaxBlockingQueries = 1
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	// Subscriptions take a slot under the blocking query cap.
+	args := &structs.SubscribeRequest{
+		Datacenter: "dc1",
+		Topic:      structs.StreamTopicKV,
+	}
+	sub := testSubscribe(t, s1, args)
+	sub.verify(t, "end-of-snapshot/")
+
+	sub2 := testSubscribe(t, s1, args)
+	select {
+	case err := <-sub2.errCh:
+		if !structs.IsErrRPCRateExceeded(err) {
+			t.Fatalf("err: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("should fail")
+	}
+
+	// The slot is given back once the subscription ends.
+	sub.cancel()
+	<-sub.errCh
+	retry.Run(t, func(r *retry.R) {
+		sub := testSubscribe(t, s1, args)
+		defer sub.cancel()
+		select {
+		case e := <-sub.eventCh:
+			if e.Type != structs.StreamEndOfSnapshot {
+				r.Fatalf("bad: %v", e)
+			}
+		case err := <-sub.errCh:
+			r.Fatalf("err: %v", err)
+		}
+	})
+}
+
+func TestSubscribe_ACLDeny(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.ACLMasterToken = "root"
+		c.ACLDefaultPolicy = "deny"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	arg := structs.ACLRequest{
+		Datacenter: "dc1",
+		Op:         structs.ACLSet,
+		ACL: structs.ACL{
+			Name: "User token",
+			Type: structs.ACLTypeClient,
+			Rules: `
+key "foo/" {
+	policy = "read"
+}
+`,
+		},
+		WriteRequest: structs.WriteRequest{Token: "root"},
+	}
+	var token string
+	if err := msgpackrpc.CallWithCodec(codec, "ACL.Apply", &arg, &token); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Services need read access up front.
+	sub := testSubscribe(t, s1, &structs.SubscribeRequest{
+		Datacenter: "dc1",
+		Token:      token,
+		Topic:      structs.StreamTopicServiceHealth,
+		Key:        "web",
+	})
+	select {
+	case err := <-sub.errCh:
+		if !acl.IsErrPermissionDenied(err) {
+			t.Fatalf("err: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("should fail")
+	}
+
+	// Keys are filtered.
+	testSetKV(t, s1, "dc1", "foo/a", "root")
+	testSetKV(t, s1, "dc1", "bar", "root")
+	sub = testSubscribe(t, s1, &structs.SubscribeRequest{
+		Datacenter: "dc1",
+		Token:      token,
+		Topic:      structs.StreamTopicKV,
+	})
+	defer sub.cancel()
+	sub.verify(t, "kv-put/foo/a", "end-of-snapshot/")
+
+	testSetKV(t, s1, "dc1", "bar", "root")
+	testSetKV(t, s1, "dc1", "foo/b", "root")
+	sub.verify(t, "kv-put/foo/b")
+}
+
+func TestSubscribe_Forward_Datacenter(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServerDC(t, "dc1")
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+
+	dir2, s2 := testServerDC(t, "dc2")
+	defer os.RemoveAll(dir2)
+	defer s2.Shutdown()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+	testrpc.WaitForLeader(t, s2.RPC, "dc2")
+
+	// Try to WAN join.
+	joinWAN(t, s2, s1)
+	retry.Run(t, func(r *retry.R) {
+		if got, want := len(s1.WANMembers()), 2; got < want {
+			r.Fatalf("got %d WAN members want at least %d", got, want)
+		}
+	})
+
+	testSetKV(t, s2, "dc2", "foo", "")
+	sub := testSubscribe(t, s1, &structs.SubscribeRequest{
+		Datacenter: "dc2",
+		Topic:      structs.StreamTopicKV,
+		Key:        "foo",
+	})
+	defer sub.cancel()
+	sub.verify(t, "kv-put/foo", "end-of-snapshot/foo")
+
+	testSetKV(t, s2, "dc2", "foo", "")
+	sub.verify(t, "kv-put/foo")
+}
@@ -7,6 +7,11 @@ import (
 	"github.com/armon/go-metrics"
 	"github.com/hashicorp/consul/acl"
 	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/ipaddr"
+	"github.com/hashicorp/consul/sentinel"
+	"github.com/hashicorp/consul/types"
+	"github.com/hashicorp/go-uuid"
 )
 
 // Txn endpoint is used to perform multi-object atomic transactions.
@@ -16,31 +21,311 @@ type Txn struct {
 
 // preCheck is used to verify the incoming operations before any further
 // processing takes place. This checks things like ACLs.
-func (t *Txn) preCheck(acl acl.ACL, ops structs.TxnOps) structs.TxnErrors {
+func (t *Txn) preCheck(rule acl.ACL, ops structs.TxnOps) structs.TxnErrors {
 	var errors structs.TxnErrors
 
 	// Perform the pre-apply checks for any KV operations.
 	for i, op := range ops {
-		if op.KV != nil {
-			ok, err := kvsPreApply(t.srv, acl, op.KV.Verb, &op.KV.DirEnt)
-			if err != nil {
-				errors = append(errors, &structs.TxnError{
-					OpIndex: i,
-					What:    err.Error(),
-				})
-			} else if !ok {
+		var err error
+		switch {
+		case op.KV != nil:
+			var ok bool
+			ok, err = kvsPreApply(t.srv, rule, op.KV.Verb, &op.KV.DirEnt)
+			if err == nil && !ok {
 				err = fmt.Errorf("failed to lock key %q due to lock delay", op.KV.DirEnt.Key)
-				errors = append(errors, &structs.TxnError{
-					OpIndex: i,
-					What:    err.Error(),
-				})
 			}
+
+		case op.Node != nil:
+			err = t.vetNodeOp(rule, op.Node)
+
+		case op.Service != nil:
+			err = t.vetServiceOp(rule, op.Service)
+
+		case op.Check != nil:
+			err = t.vetCheckOp(rule, op.Check)
+
+		case op.Session != nil:
+			err = t.vetSessionOp(rule, op.Session)
+		}
+		if err != nil {
+			errors = append(errors, &structs.TxnError{
+				OpIndex: i,
+				What:    err.Error(),
+			})
 		}
 	}
 
 	return errors
 }
 
+// vetNodeOp verifies the arguments of a node operation and checks them
+// against the given ACL policy. Reads are filtered from the results later so
+// they aren't checked here.
+func (t *Txn) vetNodeOp(rule acl.ACL, op *structs.TxnNodeOp) error {
+	if op.Verb == api.NodeGet {
+		return nil
+	}
+
+	node := &op.Node
+	if node.Node == "" {
+		return fmt.Errorf("Must provide node")
+	}
+	switch op.Verb {
+	case api.NodeSet, api.NodeCAS:
+		if node.Address == "" {
+			return fmt.Errorf("Must provide address for node %q", node.Node)
+		}
+		if node.ID != "" {
+			if _, err := uuid.ParseUUID(string(node.ID)); err != nil {
+				return fmt.Errorf("Bad node ID: %v", err)
+			}
+		}
+	}
+
+	// Fast path if ACLs are not enabled.
+	if rule == nil {
+		return nil
+	}
+
+	// Sentinel only applies to creates and updates.
+	var scope sentinel.ScopeFn
+	if op.Verb == api.NodeSet || op.Verb == api.NodeCAS {
+		scope = func() map[string]interface{} {
+			return sentinel.ScopeCatalogUpsert(&api.Node{
+				ID:              string(node.ID),
+				Node:            node.Node,
+				Address:         node.Address,
+				Datacenter:      node.Datacenter,
+				TaggedAddresses: node.TaggedAddresses,
+				Meta:            node.Meta,
+			}, nil)
+		}
+	}
+	if !rule.NodeWrite(node.Node, scope) {
+		return acl.ErrPermissionDenied
+	}
+	return nil
+}
+
+// vetServiceOp verifies the arguments of a service operation and checks them
+// against the given ACL policy. Reads are filtered from the results later so
+// they aren't checked here.
+func (t *Txn) vetServiceOp(rule acl.ACL, op *structs.TxnServiceOp) error {
+	if op.Verb == api.ServiceGet {
+		return nil
+	}
+
+	svc := &op.Service
+	if op.Node == "" {
+		return fmt.Errorf("Must provide node")
+	}
+
+	// If no service id, but service name, use default
+	if svc.ID == "" && svc.Service != "" {
+		svc.ID = svc.Service
+	}
+	if svc.ID == "" {
+		return fmt.Errorf("Must provide service ID")
+	}
+
+	isWrite := op.Verb == api.ServiceSet || op.Verb == api.ServiceCAS
+	if isWrite {
+		if svc.Service == "" {
+			return fmt.Errorf("Must provide service name with ID")
+		}
+		if ipaddr.IsAny(svc.Address) {
+			return fmt.Errorf("Invalid service address")
+		}
+	}
+
+	// Fast path if ACLs are not enabled.
+	if rule == nil {
+		return nil
+	}
+
+	// Any existing service with this ID is either being overwritten or
+	// deleted, so we need write access to it as well. This is racy in the
+	// same way as vetRegisterWithACL since we look outside of the Raft
+	// transaction.
+	_, existing, err := t.srv.fsm.State().NodeService(op.Node, svc.ID)
+	if err != nil {
+		return fmt.Errorf("Service lookup failed: %v", err)
+	}
+	if existing != nil && !rule.ServiceWrite(existing.Service, nil) {
+		return acl.ErrPermissionDenied
+	}
+
+	if isWrite {
+		scope := func() map[string]interface{} {
+			return sentinel.ScopeCatalogUpsert(&api.Node{Node: op.Node}, &api.AgentService{
+				ID:                svc.ID,
+				Service:           svc.Service,
+				Tags:              svc.Tags,
+				Address:           svc.Address,
+				Meta:              svc.Meta,
+				Port:              svc.Port,
+				EnableTagOverride: svc.EnableTagOverride,
+			})
+		}
+		if !rule.ServiceWrite(svc.Service, scope) {
+			return acl.ErrPermissionDenied
+		}
+	}
+	return nil
+}
+
+// vetCheckOp verifies the arguments of a check operation and checks them
+// against the given ACL policy. Node-level checks require node write, and
+// service-level checks require service write. Reads are filtered from the
+// results later so they aren't checked here.
+func (t *Txn) vetCheckOp(rule acl.ACL, op *structs.TxnCheckOp) error {
+	if op.Verb == api.CheckGet {
+		return nil
+	}
+
+	check := &op.Check
+	if check.Node == "" {
+		return fmt.Errorf("Must provide node")
+	}
+	if check.CheckID == "" && check.Name != "" {
+		check.CheckID = types.CheckID(check.Name)
+	}
+	if check.CheckID == "" {
+		return fmt.Errorf("Must provide check ID")
+	}
+
+	// Fast path if ACLs are not enabled.
+	if rule == nil {
+		return nil
+	}
+
+	// For deletes we go by the service the existing check is registered
+	// against, for everything else we go by the service being asked for.
+	state := t.srv.fsm.State()
+	serviceID := check.ServiceID
+	if op.Verb == api.CheckDelete || op.Verb == api.CheckDeleteCAS {
+		_, existing, err := state.NodeCheck(check.Node, check.CheckID)
+		if err != nil {
+			return fmt.Errorf("Check lookup failed: %v", err)
+		}
+		if existing == nil {
+			return nil
+		}
+		serviceID = existing.ServiceID
+	}
+
+	// Node-level check.
+	if serviceID == "" {
+		if !rule.NodeWrite(check.Node, nil) {
+			return acl.ErrPermissionDenied
+		}
+		return nil
+	}
+
+	// Service-level check. We are only adding or removing a check here,
+	// so we don't add the scope, since the sentinel policy doesn't apply
+	// to checks at this time. A service that doesn't exist yet may be
+	// registered earlier in the same transaction, so we fall back to the
+	// service ID in that case.
+	_, ns, err := state.NodeService(check.Node, serviceID)
+	if err != nil {
+		return fmt.Errorf("Service lookup failed: %v", err)
+	}
+	service := serviceID
+	if ns != nil {
+		service = ns.Service
+	}
+	if !rule.ServiceWrite(service, nil) {
+		return acl.ErrPermissionDenied
+	}
+	return nil
+}
+
+// vetSessionOp verifies the arguments of a session operation and checks them
+// against the given ACL policy. This will also generate a session ID for
+// creates that didn't supply one, which must happen before the operation is
+// sent to Raft since it isn't deterministic. Reads are filtered from the
+// results later so they aren't checked here.
+func (t *Txn) vetSessionOp(rule acl.ACL, op *structs.TxnSessionOp) error {
+	sess := &op.Session
+	switch op.Verb {
+	case api.SessionGet:
+		return nil
+
+	case api.SessionCreate:
+		if sess.Node == "" {
+			return fmt.Errorf("Must provide Node")
+		}
+
+		// Default to the node's serf check, just like a session made
+		// with the session endpoint. An empty list means no checks.
+		if sess.Checks == nil {
+			sess.Checks = []types.CheckID{structs.SerfCheckID}
+		}
+		if err := sessionPreApply(t.srv, sess); err != nil {
+			return err
+		}
+		if sess.ID == "" {
+			id, err := generateSessionID(t.srv)
+			if err != nil {
+				return err
+			}
+			sess.ID = id
+		} else if _, err := uuid.ParseUUID(sess.ID); err != nil {
+			return fmt.Errorf("Bad session ID: %v", err)
+		}
+		if rule != nil && !rule.SessionWrite(sess.Node) {
+			return acl.ErrPermissionDenied
+		}
+
+	case api.SessionDelete, api.SessionDeleteCAS:
+		if sess.ID == "" {
+			return fmt.Errorf("Must provide ID")
+		}
+		if rule == nil {
+			return nil
+		}
+		_, existing, err := t.srv.fsm.State().SessionGet(nil, sess.ID)
+		if err != nil {
+			return fmt.Errorf("Session lookup failed: %v", err)
+		}
+		if existing != nil && !rule.SessionWrite(existing.Node) {
+			return acl.ErrPermissionDenied
+		}
+	}
+	return nil
+}
+
+// updateSessionTimers keeps the leader's session TTL timers in sync with any
+// sessions that were created or destroyed by a transaction.
+func (t *Txn) updateSessionTimers(ops structs.TxnOps) {
+	for _, op := range ops {
+		if op.Session == nil {
+			continue
+		}
+
+		switch op.Session.Verb {
+		case api.SessionCreate:
+			if op.Session.Session.TTL != "" {
+				t.srv.resetSessionTimer(op.Session.Session.ID, &op.Session.Session)
+			}
+
+		case api.SessionDelete, api.SessionDeleteCAS:
+			t.srv.clearSessionTimer(op.Session.Session.ID)
+		}
+	}
+}
+
+// updateKVSTimers starts or stops the expiration timers of the keys written
+// by a successful transaction.
+func (t *Txn) updateKVSTimers(ops structs.TxnOps) {
+	for _, op := range ops {
+		if op.KV != nil {
+			t.srv.updateKVSTimer(op.KV.Verb, op.KV.DirEnt.Key)
+		}
+	}
+}
+
 // Apply is used to apply multiple operations in a single, atomic transaction.
 func (t *Txn) Apply(args *structs.TxnRequest, reply *structs.TxnResponse) error {
 	if done, err := t.srv.forward("Txn.Apply", args, args, reply); done {
@@ -72,6 +357,10 @@ func (t *Txn) Apply(args *structs.TxnRequest, reply *structs.TxnResponse) error
 	// Convert the return type. This should be a cheap copy since we are
 	// just taking the two slices.
 	if txnResp, ok := resp.(structs.TxnResponse); ok {
+		if len(txnResp.Errors) == 0 {
+			t.updateSessionTimers(args.Ops)
+			t.updateKVSTimers(args.Ops)
+		}
 		if acl != nil {
 			txnResp.Results = FilterTxnResults(acl, txnResp.Results)
 		}
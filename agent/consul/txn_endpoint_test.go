@@ -12,6 +12,7 @@ import (
 	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/testrpc"
+	"github.com/hashicorp/consul/types"
 	"github.com/hashicorp/net-rpc-msgpackrpc"
 )
 
@@ -587,3 +588,288 @@ func TestTxn_Read_ACLDeny(t *testing.T) {
 		t.Fatalf("bad %v", out)
 	}
 }
+
+func TestTxn_Apply_Catalog(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	// Register a node with a service and a check, and take a lock with a
+	// TTL session for it, all in one go. The session ID is left out so the
+	// server has to generate one.
+	arg := structs.TxnRequest{
+		Datacenter: "dc1",
+		Ops: structs.TxnOps{
+			&structs.TxnOp{
+				Node: &structs.TxnNodeOp{
+					Verb: api.NodeSet,
+					Node: structs.Node{Node: "foo", Address: "127.0.0.1"},
+				},
+			},
+			&structs.TxnOp{
+				Service: &structs.TxnServiceOp{
+					Verb:    api.ServiceSet,
+					Node:    "foo",
+					Service: structs.NodeService{Service: "redis", Port: 6379},
+				},
+			},
+			&structs.TxnOp{
+				Check: &structs.TxnCheckOp{
+					Verb: api.CheckSet,
+					Check: structs.HealthCheck{
+						Node:      "foo",
+						Name:      "redis-alive",
+						ServiceID: "redis",
+						Status:    api.HealthPassing,
+					},
+				},
+			},
+			&structs.TxnOp{
+				Session: &structs.TxnSessionOp{
+					Verb: api.SessionCreate,
+					Session: structs.Session{
+						Node:   "foo",
+						Checks: []types.CheckID{"redis-alive"},
+						TTL:    "30s",
+					},
+				},
+			},
+		},
+	}
+	var out structs.TxnResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Txn.Apply", &arg, &out); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(out.Errors) != 0 || len(out.Results) != 4 {
+		t.Fatalf("bad: %v", out)
+	}
+
+	// Verify the state store directly.
+	state := s1.fsm.State()
+	_, services, err := state.NodeServices(nil, "foo")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if services == nil || services.Node.Address != "127.0.0.1" {
+		t.Fatalf("bad: %v", services)
+	}
+	if svc, ok := services.Services["redis"]; !ok || svc.Port != 6379 {
+		t.Fatalf("bad: %v", services.Services)
+	}
+	_, checks, err := state.NodeChecks(nil, "foo")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(checks) != 1 || checks[0].CheckID != "redis-alive" || checks[0].ServiceName != "redis" {
+		t.Fatalf("bad: %v", checks)
+	}
+
+	// The session should have gotten an ID and a TTL timer.
+	sess := out.Results[3].Session
+	if sess == nil || sess.ID == "" {
+		t.Fatalf("bad: %v", out.Results[3])
+	}
+	if s1.sessionTimers.Get(sess.ID) == nil {
+		t.Fatalf("missing session timer")
+	}
+}
+
+func TestTxn_Apply_Session_DefaultChecks(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	// Make a node with a serf check.
+	state := s1.fsm.State()
+	if err := state.EnsureNode(1, &structs.Node{Node: "foo", Address: "127.0.0.1"}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	check := &structs.HealthCheck{
+		Node:    "foo",
+		CheckID: structs.SerfCheckID,
+		Name:    structs.SerfCheckName,
+		Status:  api.HealthPassing,
+	}
+	if err := state.EnsureCheck(2, check); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// A session without checks should get the serf check, unless it
+	// asked for none with an empty list.
+	arg := structs.TxnRequest{
+		Datacenter: "dc1",
+		Ops: structs.TxnOps{
+			&structs.TxnOp{
+				Session: &structs.TxnSessionOp{
+					Verb:    api.SessionCreate,
+					Session: structs.Session{Node: "foo"},
+				},
+			},
+			&structs.TxnOp{
+				Session: &structs.TxnSessionOp{
+					Verb:    api.SessionCreate,
+					Session: structs.Session{Node: "foo", Checks: []types.CheckID{}},
+				},
+			},
+		},
+	}
+	var out structs.TxnResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Txn.Apply", &arg, &out); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(out.Errors) != 0 || len(out.Results) != 2 {
+		t.Fatalf("bad: %v", out)
+	}
+
+	for i, want := range [][]types.CheckID{{structs.SerfCheckID}, nil} {
+		_, sess, err := state.SessionGet(nil, out.Results[i].Session.ID)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if sess == nil || len(sess.Checks) != len(want) ||
+			(len(want) > 0 && !reflect.DeepEqual(sess.Checks, want)) {
+			t.Fatalf("bad: %v", sess)
+		}
+	}
+}
+
+func TestTxn_Apply_Catalog_ACLDeny(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.ACLMasterToken = "root"
+		c.ACLDefaultPolicy = "deny"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	// Put in a node with a service to read back.
+	state := s1.fsm.State()
+	if err := state.EnsureNode(1, &structs.Node{Node: "nope", Address: "127.0.0.1"}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := state.EnsureService(2, "nope", &structs.NodeService{ID: "nope", Service: "nope"}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Create the ACL.
+	var id string
+	{
+		arg := structs.ACLRequest{
+			Datacenter: "dc1",
+			Op:         structs.ACLSet,
+			ACL: structs.ACL{
+				Name:  "User token",
+				Type:  structs.ACLTypeClient,
+				Rules: testListRules,
+			},
+			WriteRequest: structs.WriteRequest{Token: "root"},
+		}
+		if err := s1.RPC("ACL.Apply", &arg, &id); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	// Set up a transaction where every operation should get blocked due to
+	// ACLs.
+	arg := structs.TxnRequest{
+		Datacenter: "dc1",
+		Ops: structs.TxnOps{
+			&structs.TxnOp{
+				Node: &structs.TxnNodeOp{
+					Verb: api.NodeGet,
+					Node: structs.Node{Node: "nope"},
+				},
+			},
+			&structs.TxnOp{
+				Node: &structs.TxnNodeOp{
+					Verb: api.NodeSet,
+					Node: structs.Node{Node: "nope", Address: "127.0.0.1"},
+				},
+			},
+			&structs.TxnOp{
+				Node: &structs.TxnNodeOp{
+					Verb: api.NodeDelete,
+					Node: structs.Node{Node: "nope"},
+				},
+			},
+			&structs.TxnOp{
+				Service: &structs.TxnServiceOp{
+					Verb:    api.ServiceGet,
+					Node:    "nope",
+					Service: structs.NodeService{ID: "nope"},
+				},
+			},
+			&structs.TxnOp{
+				Service: &structs.TxnServiceOp{
+					Verb:    api.ServiceSet,
+					Node:    "nope",
+					Service: structs.NodeService{ID: "nope", Service: "nope"},
+				},
+			},
+			&structs.TxnOp{
+				Service: &structs.TxnServiceOp{
+					Verb:    api.ServiceDeleteCAS,
+					Node:    "nope",
+					Service: structs.NodeService{ID: "nope"},
+				},
+			},
+			&structs.TxnOp{
+				Check: &structs.TxnCheckOp{
+					Verb:  api.CheckSet,
+					Check: structs.HealthCheck{Node: "nope", CheckID: "nope"},
+				},
+			},
+			&structs.TxnOp{
+				Check: &structs.TxnCheckOp{
+					Verb:  api.CheckCAS,
+					Check: structs.HealthCheck{Node: "nope", CheckID: "nope", ServiceID: "nope"},
+				},
+			},
+			&structs.TxnOp{
+				Session: &structs.TxnSessionOp{
+					Verb:    api.SessionCreate,
+					Session: structs.Session{Node: "nope"},
+				},
+			},
+		},
+		WriteRequest: structs.WriteRequest{
+			Token: id,
+		},
+	}
+	var out structs.TxnResponse
+	if err := s1.RPC("Txn.Apply", &arg, &out); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Verify the transaction's return value.
+	var expected structs.TxnResponse
+	for i, op := range arg.Ops {
+		switch {
+		case op.Node != nil && op.Node.Verb == api.NodeGet,
+			op.Service != nil && op.Service.Verb == api.ServiceGet:
+			// These get filtered but won't result in an error.
+
+		default:
+			expected.Errors = append(expected.Errors, &structs.TxnError{
+				OpIndex: i,
+				What:    acl.ErrPermissionDenied.Error(),
+			})
+		}
+	}
+	if !reflect.DeepEqual(out, expected) {
+		t.Fatalf("bad %v", out)
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"net/http"
 	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/hashicorp/consul/agent/structs"
 )
@@ -104,3 +107,112 @@ func (s *HTTPServer) CoordinateNodes(resp http.ResponseWriter, req *http.Request
 
 	return out.Coordinates, nil
 }
+
+// parseCoordinateLimits parses the limits above which coordinates are flagged
+// as inaccurate or stale. It returns true if the request was bad and an error
+// response was written.
+func parseCoordinateLimits(resp http.ResponseWriter, req *http.Request, args *structs.CoordinateMatrixRequest) bool {
+	query := req.URL.Query()
+	if v := query.Get("max-error"); v != "" {
+		maxError, err := strconv.ParseFloat(v, 64)
+		if err != nil || maxError <= 0 {
+			resp.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(resp, "Invalid max-error %q", v)
+			return true
+		}
+		args.MaxError = maxError
+	}
+	if v := query.Get("max-age"); v != "" {
+		maxAge, err := time.ParseDuration(v)
+		if err != nil || maxAge <= 0 {
+			resp.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(resp, "Invalid max-age %q", v)
+			return true
+		}
+		args.MaxAge = maxAge
+	}
+	return false
+}
+
+// CoordinateRTT returns the estimated round trip time between two LAN nodes
+// in the given datacenter, along with the status of their coordinates.
+func (s *HTTPServer) CoordinateRTT(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if req.Method != "GET" {
+		return nil, MethodNotAllowedError{req.Method, []string{"GET"}}
+	}
+
+	args := structs.CoordinateMatrixRequest{}
+	if done := s.parse(resp, req, &args.Datacenter, &args.QueryOptions); done {
+		return nil, nil
+	}
+	if done := parseCoordinateLimits(resp, req, &args); done {
+		return nil, nil
+	}
+
+	// The destination defaults to the agent's node, which is only known
+	// to be in the agent's datacenter.
+	from, to := req.URL.Query().Get("from"), req.URL.Query().Get("to")
+	if to == "" {
+		if args.Datacenter != "" && args.Datacenter != s.agent.config.Datacenter {
+			resp.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(resp, "Missing to node, which is required for another datacenter")
+			return nil, nil
+		}
+		to = s.agent.config.NodeName
+	}
+	if from == "" || from == to {
+		resp.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(resp, "Missing from node, or it's the same as the to node")
+		return nil, nil
+	}
+	args.Nodes = []string{from, to}
+
+	var out structs.IndexedCoordinateMatrix
+	defer setMeta(resp, &out.QueryMeta)
+	if err := s.agent.RPC("Coordinate.Matrix", &args, &out); err != nil {
+		return nil, err
+	}
+
+	if len(out.Matrix.RTTs) == 0 {
+		resp.WriteHeader(http.StatusNotFound)
+		fmt.Fprintf(resp, "No compatible coordinates for nodes %q and %q", from, to)
+		return nil, nil
+	}
+	return structs.CoordinateNodeRTT{
+		CoordinateRTT: *out.Matrix.RTTs[0],
+		Nodes:         out.Matrix.Nodes,
+	}, nil
+}
+
+// CoordinateMatrix returns the estimated round trip times between the given
+// LAN nodes in the given datacenter, along with the status of their
+// coordinates, and the estimated round trip times from the datacenter to the
+// others.
+func (s *HTTPServer) CoordinateMatrix(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if req.Method != "GET" {
+		return nil, MethodNotAllowedError{req.Method, []string{"GET"}}
+	}
+
+	args := structs.CoordinateMatrixRequest{}
+	if done := s.parse(resp, req, &args.Datacenter, &args.QueryOptions); done {
+		return nil, nil
+	}
+	if done := parseCoordinateLimits(resp, req, &args); done {
+		return nil, nil
+	}
+	if v := req.URL.Query().Get("nodes"); v != "" {
+		args.Nodes = strings.Split(v, ",")
+	}
+	if len(args.Nodes) > structs.CoordinateMatrixMaxNodes {
+		resp.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(resp, "Too many nodes (%d > %d)", len(args.Nodes), structs.CoordinateMatrixMaxNodes)
+		return nil, nil
+	}
+
+	var out structs.IndexedCoordinateMatrix
+	defer setMeta(resp, &out.QueryMeta)
+	if err := s.agent.RPC("Coordinate.Matrix", &args, &out); err != nil {
+		return nil, err
+	}
+	return out.Matrix, nil
+}
@@ -3,10 +3,12 @@ package agent
 import (
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/lib"
 	"github.com/hashicorp/serf/coordinate"
 )
 
@@ -140,3 +142,109 @@ func TestCoordinate_Nodes(t *testing.T) {
 		t.Fatalf("bad: %v", coordinates)
 	}
 }
+
+func TestCoordinate_RTT_Matrix(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t.Name(), "")
+	defer a.Shutdown()
+
+	// Register some nodes with known coordinates, with baz in a segment.
+	coords := map[string]*coordinate.Coordinate{
+		"foo": lib.GenerateCoordinate(10 * time.Millisecond),
+		"bar": lib.GenerateCoordinate(25 * time.Millisecond),
+		"baz": lib.GenerateCoordinate(40 * time.Millisecond),
+	}
+	for node, coord := range coords {
+		req := structs.RegisterRequest{
+			Datacenter: "dc1",
+			Node:       node,
+			Address:    "127.0.0.1",
+		}
+		var reply struct{}
+		if err := a.RPC("Catalog.Register", &req, &reply); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+
+		arg := structs.CoordinateUpdateRequest{
+			Datacenter: "dc1",
+			Node:       node,
+			Coord:      coord,
+		}
+		if node == "baz" {
+			arg.Segment = "alpha"
+		}
+		if err := a.RPC("Coordinate.Update", &arg, &reply); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+	time.Sleep(300 * time.Millisecond)
+
+	// Get the RTT between two nodes.
+	req, _ := http.NewRequest("GET", "/v1/coordinate/rtt?from=foo&to=bar&max-error=2", nil)
+	resp := httptest.NewRecorder()
+	obj, err := a.srv.CoordinateRTT(resp, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	rtt := obj.(structs.CoordinateNodeRTT)
+	if rtt.From != "foo" || rtt.To != "bar" ||
+		rtt.RTT != coords["foo"].DistanceTo(coords["bar"]) ||
+		len(rtt.Nodes) != 2 || rtt.Nodes[0].Inaccurate {
+		t.Fatalf("bad: %v", rtt)
+	}
+
+	// The default max error flags the new coordinates.
+	req, _ = http.NewRequest("GET", "/v1/coordinate/rtt?from=foo&to=bar", nil)
+	resp = httptest.NewRecorder()
+	obj, err = a.srv.CoordinateRTT(resp, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if rtt := obj.(structs.CoordinateNodeRTT); !rtt.Nodes[0].Inaccurate {
+		t.Fatalf("bad: %v", rtt.Nodes)
+	}
+
+	// Unknown nodes and nodes with incompatible coordinates give a 404,
+	// and bad arguments give a 400.
+	for url, code := range map[string]int{
+		"/v1/coordinate/rtt?from=foo&to=nope":       404,
+		"/v1/coordinate/rtt?from=foo&to=baz":        404,
+		"/v1/coordinate/rtt?to=foo":                 400,
+		"/v1/coordinate/rtt?from=foo&to=foo":        400,
+		"/v1/coordinate/rtt?from=foo&max-error=no":  400,
+		"/v1/coordinate/rtt?from=foo&max-age=-1s":   400,
+		"/v1/coordinate/matrix?max-age=forever":     400,
+		"/v1/coordinate/matrix?max-error=-0.5&dc=1": 400,
+		"/v1/coordinate/matrix?nodes=" + strings.Repeat("foo,", structs.CoordinateMatrixMaxNodes) + "bar": 400,
+		"/v1/coordinate/rtt?from=foo&dc=dc2": 400,
+	} {
+		req, _ := http.NewRequest("GET", url, nil)
+		resp := httptest.NewRecorder()
+		var err error
+		if strings.HasPrefix(url, "/v1/coordinate/rtt") {
+			_, err = a.srv.CoordinateRTT(resp, req)
+		} else {
+			_, err = a.srv.CoordinateMatrix(resp, req)
+		}
+		if err != nil {
+			t.Fatalf("%s: err: %v", url, err)
+		}
+		if resp.Code != code {
+			t.Fatalf("%s: expected %d, got %d", url, code, resp.Code)
+		}
+	}
+
+	// Get the matrix for some of the nodes.
+	req, _ = http.NewRequest("GET", "/v1/coordinate/matrix?nodes=bar,foo,baz", nil)
+	resp = httptest.NewRecorder()
+	obj, err = a.srv.CoordinateMatrix(resp, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	matrix := obj.(*structs.CoordinateMatrix)
+	if len(matrix.Nodes) != 3 || matrix.Nodes[0].Node != "bar" ||
+		len(matrix.RTTs) != 1 || matrix.RTTs[0].From != "bar" || matrix.RTTs[0].To != "foo" ||
+		len(matrix.Datacenters) != 1 || matrix.Datacenters[0].To != "dc1" {
+		t.Fatalf("bad: %v", matrix)
+	}
+}
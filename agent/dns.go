@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"log"
 	"net"
+	"sort"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -15,7 +17,9 @@ import (
 	"github.com/hashicorp/consul/agent/config"
 	"github.com/hashicorp/consul/agent/consul"
 	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/lib"
+	"github.com/hashicorp/serf/serf"
 	"github.com/miekg/dns"
 )
 
@@ -30,6 +34,10 @@ const (
 	// Increment a counter when requests staler than this are served
 	staleCounterThreshold = 5 * time.Second
 
+	// clientNodesTTL is how long the LAN members used to find the node a
+	// query came from are cached.
+	clientNodesTTL = 5 * time.Second
+
 	defaultMaxUDPSize = 512
 )
 
@@ -63,6 +71,18 @@ type DNSServer struct {
 	// be safely changed at runtime. It always contains a bool and is
 	// initialized with the value from config.DisableCompression.
 	disableCompression atomic.Value
+
+	// clientNodes caches the live LAN members sorted by name, so finding
+	// the node a query came from doesn't copy the member list every time.
+	clientNodes        []clientNodeAddr
+	clientNodesExpires time.Time
+	clientNodesLock    sync.Mutex
+}
+
+// clientNodeAddr is the name and address of a live LAN member.
+type clientNodeAddr struct {
+	name string
+	addr net.IP
 }
 
 func NewDNSServer(a *Agent) (*DNSServer, error) {
@@ -272,6 +292,19 @@ func (d *DNSServer) handleQuery(resp dns.ResponseWriter, req *dns.Msg) {
 	// Handle EDNS
 	if edns := req.IsEdns0(); edns != nil {
 		m.SetEdns0(edns.UDPSize(), false)
+
+		// Echo the client subnet back, scoped to the subnet since the
+		// order of the answers may depend on it.
+		if subnet := ednsSubnetForRequest(req); subnet != nil {
+			opt := m.IsEdns0()
+			opt.Option = append(opt.Option, &dns.EDNS0_SUBNET{
+				Code:          dns.EDNS0SUBNET,
+				Family:        subnet.Family,
+				SourceNetmask: subnet.SourceNetmask,
+				SourceScope:   subnet.SourceNetmask,
+				Address:       subnet.Address,
+			})
+		}
 	}
 
 	// Write out the complete response
@@ -280,6 +313,78 @@ func (d *DNSServer) handleQuery(resp dns.ResponseWriter, req *dns.Msg) {
 	}
 }
 
+// ednsSubnetForRequest returns the EDNS client subnet option of the request,
+// or nil if it doesn't have one.
+func ednsSubnetForRequest(req *dns.Msg) *dns.EDNS0_SUBNET {
+	edns := req.IsEdns0()
+	if edns == nil {
+		return nil
+	}
+	for _, o := range edns.Option {
+		if subnet, ok := o.(*dns.EDNS0_SUBNET); ok {
+			return subnet
+		}
+	}
+	return nil
+}
+
+// clientNode returns the name of the node a query came from, based on the
+// EDNS client subnet option, or "" if it isn't known. A node whose address
+// matches the client address exactly is preferred, otherwise the live node
+// inside the client subnet that sorts first by name is used. Only nodes in
+// the agent's LAN pool are considered, since distances can't be compared
+// across datacenters.
+func (d *DNSServer) clientNode(req *dns.Msg) string {
+	subnet := ednsSubnetForRequest(req)
+	if subnet == nil || subnet.SourceNetmask == 0 {
+		return ""
+	}
+
+	bits := 8 * net.IPv4len
+	if subnet.Family == 2 {
+		bits = 8 * net.IPv6len
+	}
+	mask := net.CIDRMask(int(subnet.SourceNetmask), bits)
+	if mask == nil {
+		return ""
+	}
+	ipnet := net.IPNet{IP: subnet.Address.Mask(mask), Mask: mask}
+
+	var match string
+	for _, n := range d.clientNodeAddrs() {
+		if n.addr.Equal(subnet.Address) {
+			return n.name
+		}
+		if match == "" && ipnet.Contains(n.addr) {
+			match = n.name
+		}
+	}
+	return match
+}
+
+// clientNodeAddrs returns the live LAN members sorted by name, which are
+// looked up again once the cached ones expire. The returned slice must not
+// be modified.
+func (d *DNSServer) clientNodeAddrs() []clientNodeAddr {
+	d.clientNodesLock.Lock()
+	defer d.clientNodesLock.Unlock()
+
+	now := time.Now()
+	if now.Before(d.clientNodesExpires) {
+		return d.clientNodes
+	}
+
+	var nodes []clientNodeAddr
+	for _, m := range d.agent.LANMembers() {
+		if m.Status == serf.StatusAlive {
+			nodes = append(nodes, clientNodeAddr{name: m.Name, addr: m.Addr})
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].name < nodes[j].name })
+	d.clientNodes, d.clientNodesExpires = nodes, now.Add(clientNodesTTL)
+	return nodes
+}
+
 func (d *DNSServer) soa() *dns.SOA {
 	return &dns.SOA{
 		Hdr: dns.RR_Header{
@@ -308,7 +413,7 @@ func (d *DNSServer) addSOA(msg *dns.Msg) {
 // nameservers returns the names and ip addresses of up to three random servers
 // in the current cluster which serve as authoritative name servers for zone.
 func (d *DNSServer) nameservers(edns bool) (ns []dns.RR, extra []dns.RR) {
-	out, err := d.lookupServiceNodes(d.agent.config.Datacenter, structs.ConsulServiceName, "")
+	out, err := d.lookupServiceNodes(d.agent.config.Datacenter, structs.ConsulServiceName, "", structs.QuerySource{})
 	if err != nil {
 		d.logger.Printf("[WARN] dns: Unable to get list of servers: %s", err)
 		return nil, nil
@@ -625,31 +730,45 @@ func (d *DNSServer) formatNodeRecord(node *structs.Node, addr, qName string, qTy
 	}
 
 	if node != nil && (qType == dns.TypeANY || qType == dns.TypeTXT) {
-		for key, value := range node.Meta {
-			txt := value
-			if !strings.HasPrefix(strings.ToLower(key), "rfc1035-") {
-				txt = encodeKVasRFC1464(key, value)
-			}
-			records = append(records, &dns.TXT{
-				Hdr: dns.RR_Header{
-					Name:   qName,
-					Rrtype: dns.TypeTXT,
-					Class:  dns.ClassINET,
-					Ttl:    uint32(ttl / time.Second),
-				},
-				Txt: []string{txt},
-			})
-		}
+		records = append(records, formatMetaRecords(node.Meta, qName, ttl)...)
 	}
 
 	return records
 }
 
+// formatMetaRecords takes a set of metadata key/value pairs and returns a TXT
+// record for each one, encoded as described in RFC 1464 unless the key has
+// the "rfc1035-" prefix, in which case the raw value is used.
+func formatMetaRecords(meta map[string]string, qName string, ttl time.Duration) (records []dns.RR) {
+	for key, value := range meta {
+		txt := value
+		if !strings.HasPrefix(strings.ToLower(key), "rfc1035-") {
+			txt = encodeKVasRFC1464(key, value)
+		}
+		records = append(records, &dns.TXT{
+			Hdr: dns.RR_Header{
+				Name:   qName,
+				Rrtype: dns.TypeTXT,
+				Class:  dns.ClassINET,
+				Ttl:    uint32(ttl / time.Second),
+			},
+			Txt: []string{txt},
+		})
+	}
+	return records
+}
+
 // indexRRs populates a map which indexes a given list of RRs by name. NOTE that
 // the names are all squashed to lower case so we can perform case-insensitive
 // lookups; the RRs are not modified.
 func indexRRs(rrs []dns.RR, index map[string]dns.RR) {
 	for _, rr := range rrs {
+		// TXT records are metadata that ride along with the address
+		// records, see syncExtra.
+		if rr.Header().Rrtype == dns.TypeTXT {
+			continue
+		}
+
 		name := strings.ToLower(rr.Header().Name)
 		if _, ok := index[name]; !ok {
 			index[name] = rr
@@ -661,8 +780,19 @@ func indexRRs(rrs []dns.RR, index map[string]dns.RR) {
 // minimal set needed to cover the answer data. A pre-made index of RRs is given
 // so that can be re-used between calls. This assumes that the extra data is
 // only used to provide info for SRV records. If that's not the case, then this
-// will wipe out any additional data.
+// will wipe out any additional data. TXT records holding metadata for a kept
+// target are kept along with its address record.
 func syncExtra(index map[string]dns.RR, resp *dns.Msg) {
+	// Keep the metadata TXT records for each name so they can follow the
+	// address records.
+	txts := make(map[string][]dns.RR)
+	for _, rr := range resp.Extra {
+		if rr.Header().Rrtype == dns.TypeTXT {
+			name := strings.ToLower(rr.Header().Name)
+			txts[name] = append(txts[name], rr)
+		}
+	}
+
 	extra := make([]dns.RR, 0, len(resp.Answer))
 	resolved := make(map[string]struct{}, len(resp.Answer))
 	for _, ansRR := range resp.Answer {
@@ -685,6 +815,7 @@ func syncExtra(index map[string]dns.RR, resp *dns.Msg) {
 		extraRR, ok := index[target]
 		if ok {
 			extra = append(extra, extraRR)
+			extra = append(extra, txts[target]...)
 			if cname, ok := extraRR.(*dns.CNAME); ok {
 				target = strings.ToLower(cname.Target)
 				goto RESOLVE
@@ -746,13 +877,15 @@ func trimUDPResponse(req, resp *dns.Msg, udpAnswerLimit int) (trimmed bool) {
 	return len(resp.Answer) < numAnswers
 }
 
-// lookupServiceNodes returns nodes with a given service.
-func (d *DNSServer) lookupServiceNodes(datacenter, service, tag string) (structs.IndexedCheckServiceNodes, error) {
+// lookupServiceNodes returns nodes with a given service. If the source has a
+// node then the nodes are sorted by distance from it.
+func (d *DNSServer) lookupServiceNodes(datacenter, service, tag string, source structs.QuerySource) (structs.IndexedCheckServiceNodes, error) {
 	args := structs.ServiceSpecificRequest{
 		Datacenter:  datacenter,
 		ServiceName: service,
 		ServiceTag:  tag,
 		TagFilter:   tag != "",
+		Source:      source,
 		QueryOptions: structs.QueryOptions{
 			Token:      d.agent.tokens.UserToken(),
 			AllowStale: d.config.AllowStale,
@@ -786,7 +919,18 @@ func (d *DNSServer) lookupServiceNodes(datacenter, service, tag string) (structs
 
 // serviceLookup is used to handle a service query
 func (d *DNSServer) serviceLookup(network, datacenter, service, tag string, req, resp *dns.Msg) {
-	out, err := d.lookupServiceNodes(datacenter, service, tag)
+	// If we know which node the query came from, sort by distance from it
+	// instead of shuffling, just like the ?near parameter of the HTTP API.
+	var source structs.QuerySource
+	if datacenter == d.agent.config.Datacenter {
+		source = structs.QuerySource{
+			Datacenter: datacenter,
+			Segment:    d.agent.config.SegmentName,
+			Node:       d.clientNode(req),
+		}
+	}
+
+	out, err := d.lookupServiceNodes(datacenter, service, tag, source)
 	if err != nil {
 		d.logger.Printf("[ERR] dns: rpc error: %v", err)
 		resp.SetRcode(req, dns.RcodeServerFailure)
@@ -800,8 +944,10 @@ func (d *DNSServer) serviceLookup(network, datacenter, service, tag string, req,
 		return
 	}
 
-	// Perform a random shuffle
-	out.Nodes.Shuffle()
+	// Perform a random shuffle, unless the nodes were sorted by distance
+	if source.Node == "" {
+		out.Nodes.Shuffle()
+	}
 
 	// Determine the TTL
 	var ttl time.Duration
@@ -860,6 +1006,16 @@ func (d *DNSServer) preparedQueryLookup(network, datacenter, query string, req,
 		},
 	}
 
+	// If we know which node the query came from, sort relative to it, just
+	// like the ?near parameter of the HTTP API.
+	if node := d.clientNode(req); node != "" {
+		args.Source = structs.QuerySource{
+			Datacenter: d.agent.config.Datacenter,
+			Segment:    d.agent.config.SegmentName,
+			Node:       node,
+		}
+	}
+
 	// TODO (slackpad) - What's a safe limit we can set here? It seems like
 	// with dup filtering done at this level we need to get everything to
 	// match the previous behavior. We can optimize by pushing more filtering
@@ -981,6 +1137,11 @@ func (d *DNSServer) serviceNodeRecords(dc string, nodes structs.CheckServiceNode
 		if records != nil {
 			resp.Answer = append(resp.Answer, records...)
 		}
+
+		// Add the service metadata as TXT records, if requested
+		if qType == dns.TypeANY || qType == dns.TypeTXT {
+			resp.Answer = append(resp.Answer, formatMetaRecords(node.Service.Meta, qName, ttl)...)
+		}
 	}
 }
 
@@ -1007,7 +1168,7 @@ func (d *DNSServer) serviceSRVRecords(dc string, nodes structs.CheckServiceNodes
 				Ttl:    uint32(ttl / time.Second),
 			},
 			Priority: 1,
-			Weight:   1,
+			Weight:   serviceNodeWeight(node),
 			Port:     uint16(node.Service.Port),
 			Target:   fmt.Sprintf("%s.node.%s.%s", node.Node.Node, dc, d.domain),
 		}
@@ -1039,12 +1200,14 @@ func (d *DNSServer) serviceSRVRecords(dc string, nodes structs.CheckServiceNodes
 					srvRec.Target = fmt.Sprintf("%s.addr.%s.%s", addr[len(addr)-(net.IPv4len*2):], dc, d.domain)
 					record.Hdr.Name = srvRec.Target
 					resp.Extra = append(resp.Extra, record)
+					resp.Extra = append(resp.Extra, formatMetaRecords(node.Node.Meta, srvRec.Target, ttl)...)
 
 				// IPv6
 				case *dns.AAAA:
 					srvRec.Target = fmt.Sprintf("%s.addr.%s.%s", hex.EncodeToString(record.AAAA), dc, d.domain)
 					record.Hdr.Name = srvRec.Target
 					resp.Extra = append(resp.Extra, record)
+					resp.Extra = append(resp.Extra, formatMetaRecords(node.Node.Meta, srvRec.Target, ttl)...)
 
 				// Something else (probably a CNAME; just add the records).
 				default:
@@ -1052,7 +1215,28 @@ func (d *DNSServer) serviceSRVRecords(dc string, nodes structs.CheckServiceNodes
 				}
 			}
 		}
+
+		// Add the service metadata as TXT records for the target. These
+		// go after the address records so that trimming keeps the
+		// addresses.
+		resp.Extra = append(resp.Extra, formatMetaRecords(node.Service.Meta, srvRec.Target, ttl)...)
+	}
+}
+
+// serviceNodeWeight returns the SRV weight of a service instance, which
+// depends on whether any of its checks are warning. Instances without weights
+// get a weight of 1.
+func serviceNodeWeight(node structs.CheckServiceNode) uint16 {
+	weights := node.Service.Weights
+	if weights == nil {
+		return 1
+	}
+	for _, check := range node.Checks {
+		if check.Status == api.HealthWarning {
+			return uint16(weights.Warning)
+		}
 	}
+	return uint16(weights.Passing)
 }
 
 // handleRecurse is used to handle recursive DNS queries
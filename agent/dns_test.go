@@ -14,6 +14,7 @@ import (
 	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/lib"
 	"github.com/hashicorp/consul/testutil/retry"
+	"github.com/hashicorp/serf/coordinate"
 	"github.com/miekg/dns"
 	"github.com/pascaldekloe/goe/verify"
 )
@@ -757,6 +758,402 @@ func TestDNS_ServiceLookup(t *testing.T) {
 	}
 }
 
+func TestDNS_ServiceLookup_ServiceMeta(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t.Name(), "")
+	defer a.Shutdown()
+
+	// Register a node with a service that has metadata.
+	args := &structs.RegisterRequest{
+		Datacenter: "dc1",
+		Node:       "foo",
+		Address:    "127.0.0.1",
+		Service: &structs.NodeService{
+			Service: "db",
+			Port:    12345,
+			Meta: map[string]string{
+				"version": "2",
+			},
+		},
+	}
+
+	var out struct{}
+	if err := a.RPC("Catalog.Register", args, &out); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// An SRV lookup should carry the metadata in the additional section.
+	m := new(dns.Msg)
+	m.SetQuestion("db.service.consul.", dns.TypeSRV)
+
+	c := new(dns.Client)
+	in, _, err := c.Exchange(m, a.DNSAddr())
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(in.Answer) != 1 {
+		t.Fatalf("Bad: %#v", in)
+	}
+	if len(in.Extra) != 2 {
+		t.Fatalf("Bad: %#v", in)
+	}
+	if _, ok := in.Extra[0].(*dns.A); !ok {
+		t.Fatalf("Bad: %#v", in.Extra[0])
+	}
+	txtRec, ok := in.Extra[1].(*dns.TXT)
+	if !ok {
+		t.Fatalf("Bad: %#v", in.Extra[1])
+	}
+	if txtRec.Hdr.Name != "foo.node.dc1.consul." {
+		t.Fatalf("Bad: %#v", txtRec)
+	}
+	if len(txtRec.Txt) != 1 || txtRec.Txt[0] != "version=2" {
+		t.Fatalf("Bad: %#v", txtRec)
+	}
+
+	// A TXT lookup should return the metadata as answers.
+	m = new(dns.Msg)
+	m.SetQuestion("db.service.consul.", dns.TypeTXT)
+	in, _, err = c.Exchange(m, a.DNSAddr())
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(in.Answer) != 1 {
+		t.Fatalf("Bad: %#v", in)
+	}
+	txtRec, ok = in.Answer[0].(*dns.TXT)
+	if !ok {
+		t.Fatalf("Bad: %#v", in.Answer[0])
+	}
+	if len(txtRec.Txt) != 1 || txtRec.Txt[0] != "version=2" {
+		t.Fatalf("Bad: %#v", txtRec)
+	}
+}
+
+func TestDNS_ServiceLookup_Weights(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t.Name(), "")
+	defer a.Shutdown()
+
+	// Register instances in various states, with and without weights.
+	weights := &structs.Weights{Passing: 10, Warning: 2}
+	for _, reg := range []struct {
+		node    string
+		weights *structs.Weights
+		status  string
+	}{
+		{"passing", weights, api.HealthPassing},
+		{"warning", weights, api.HealthWarning},
+		{"default", nil, api.HealthWarning},
+	} {
+		args := &structs.RegisterRequest{
+			Datacenter: "dc1",
+			Node:       reg.node,
+			Address:    "127.0.0.1",
+			Service: &structs.NodeService{
+				Service: "db",
+				Port:    12345,
+				Weights: reg.weights,
+			},
+			Check: &structs.HealthCheck{
+				CheckID:   "db",
+				Name:      "db",
+				ServiceID: "db",
+				Status:    reg.status,
+			},
+		}
+
+		var out struct{}
+		if err := a.RPC("Catalog.Register", args, &out); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	m := new(dns.Msg)
+	m.SetQuestion("db.service.consul.", dns.TypeSRV)
+
+	c := new(dns.Client)
+	in, _, err := c.Exchange(m, a.DNSAddr())
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(in.Answer) != 3 {
+		t.Fatalf("Bad: %#v", in)
+	}
+
+	got := make(map[string]uint16)
+	for _, rr := range in.Answer {
+		srvRec, ok := rr.(*dns.SRV)
+		if !ok {
+			t.Fatalf("Bad: %#v", rr)
+		}
+		if srvRec.Priority != 1 {
+			t.Fatalf("Bad: %#v", srvRec)
+		}
+		got[srvRec.Target] = srvRec.Weight
+	}
+	want := map[string]uint16{
+		"passing.node.dc1.consul.": 10,
+		"warning.node.dc1.consul.": 2,
+		"default.node.dc1.consul.": 1,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v want %v", got, want)
+	}
+}
+
+func TestDNS_ServiceLookup_NodeMetaServiceAddress(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t.Name(), "")
+	defer a.Shutdown()
+
+	// Register a node with metadata and a service at a different address,
+	// which gets a target in the addr domain.
+	args := &structs.RegisterRequest{
+		Datacenter: "dc1",
+		Node:       "foo",
+		Address:    "127.0.0.1",
+		NodeMeta: map[string]string{
+			"rack": "r1",
+		},
+		Service: &structs.NodeService{
+			Service: "db",
+			Address: "127.0.0.2",
+			Port:    12345,
+		},
+	}
+
+	var out struct{}
+	if err := a.RPC("Catalog.Register", args, &out); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	m := new(dns.Msg)
+	m.SetQuestion("db.service.consul.", dns.TypeSRV)
+
+	c := new(dns.Client)
+	in, _, err := c.Exchange(m, a.DNSAddr())
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(in.Answer) != 1 || len(in.Extra) != 2 {
+		t.Fatalf("Bad: %#v", in)
+	}
+	target := in.Answer[0].(*dns.SRV).Target
+	if target != "7f000002.addr.dc1.consul." {
+		t.Fatalf("Bad: %#v", in.Answer[0])
+	}
+	if aRec, ok := in.Extra[0].(*dns.A); !ok || aRec.Hdr.Name != target {
+		t.Fatalf("Bad: %#v", in.Extra[0])
+	}
+	txtRec, ok := in.Extra[1].(*dns.TXT)
+	if !ok || txtRec.Hdr.Name != target {
+		t.Fatalf("Bad: %#v", in.Extra[1])
+	}
+	if len(txtRec.Txt) != 1 || txtRec.Txt[0] != "rack=r1" {
+		t.Fatalf("Bad: %#v", txtRec)
+	}
+}
+
+func TestDNS_ServiceLookup_ClientSubnet(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t.Name(), "")
+	defer a.Shutdown()
+
+	// Register the service on the agent's own node, which is the only LAN
+	// member, and on a few other nodes. The agent's own one goes through
+	// the agent so anti-entropy doesn't remove it.
+	nodes := []string{a.Config.NodeName, "far1", "far2", "far3", "far4"}
+	if err := a.AddService(&structs.NodeService{Service: "db", Port: 12345}, nil, false, ""); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i, node := range nodes[1:] {
+		args := &structs.RegisterRequest{
+			Datacenter: "dc1",
+			Node:       node,
+			Address:    fmt.Sprintf("127.0.0.%d", i+2),
+			Service: &structs.NodeService{
+				Service: "db",
+				Port:    12345,
+			},
+		}
+
+		var out struct{}
+		if err := a.RPC("Catalog.Register", args, &out); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	// Put the other nodes far away from the agent.
+	for i, node := range nodes {
+		coord := coordinate.NewCoordinate(coordinate.DefaultConfig())
+		coord.Vec[0] = float64(i)
+		args := structs.CoordinateUpdateRequest{
+			Datacenter: "dc1",
+			Node:       node,
+			Coord:      coord,
+		}
+		var out struct{}
+		if err := a.RPC("Coordinate.Update", &args, &out); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	query := func(addr string, netmask uint8) *dns.Msg {
+		m := new(dns.Msg)
+		m.SetQuestion("db.service.consul.", dns.TypeSRV)
+		m.SetEdns0(4096, false)
+		opt := m.IsEdns0()
+		opt.Option = append(opt.Option, &dns.EDNS0_SUBNET{
+			Code:          dns.EDNS0SUBNET,
+			Family:        1,
+			SourceNetmask: netmask,
+			Address:       net.ParseIP(addr).To4(),
+		})
+
+		c := new(dns.Client)
+		in, _, err := c.Exchange(m, a.DNSAddr())
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		return in
+	}
+
+	// Both an exact address and a subnet containing the agent should sort
+	// the answers by distance from the agent's node.
+	for _, tc := range []struct {
+		addr    string
+		netmask uint8
+	}{
+		{"127.0.0.1", 32},
+		{"127.0.0.0", 8},
+	} {
+		retry.Run(t, func(r *retry.R) {
+			in := query(tc.addr, tc.netmask)
+			if len(in.Answer) != len(nodes) {
+				r.Fatalf("Bad: %#v", in)
+			}
+			// The agent's node name needs escaping, so just check that
+			// it doesn't sort among the far nodes.
+			for i, rr := range in.Answer {
+				got := rr.(*dns.SRV).Target
+				if i == 0 {
+					if strings.HasPrefix(got, "far") {
+						r.Fatalf("got %q want the agent's node", got)
+					}
+					continue
+				}
+				if want := nodes[i] + ".node.dc1.consul."; got != want {
+					r.Fatalf("got %q want %q", got, want)
+				}
+			}
+
+			// The subnet should be echoed back.
+			opt := in.IsEdns0()
+			if opt == nil || len(opt.Option) != 1 {
+				r.Fatalf("Bad: %#v", in)
+			}
+			subnet, ok := opt.Option[0].(*dns.EDNS0_SUBNET)
+			if !ok || subnet.SourceNetmask != tc.netmask || subnet.SourceScope != tc.netmask {
+				r.Fatalf("Bad: %#v", opt.Option[0])
+			}
+		})
+	}
+}
+
+func TestDNS_clientNode(t *testing.T) {
+	t.Parallel()
+	d := &DNSServer{
+		clientNodes: []clientNodeAddr{
+			{name: "a", addr: net.ParseIP("10.0.1.2")},
+			{name: "b", addr: net.ParseIP("10.0.0.3")},
+			{name: "c", addr: net.ParseIP("10.0.0.2")},
+		},
+		clientNodesExpires: time.Now().Add(time.Hour),
+	}
+
+	query := func(addr string, netmask uint8) *dns.Msg {
+		m := new(dns.Msg)
+		m.SetQuestion("db.service.consul.", dns.TypeSRV)
+		m.SetEdns0(4096, false)
+		opt := m.IsEdns0()
+		opt.Option = append(opt.Option, &dns.EDNS0_SUBNET{
+			Code:          dns.EDNS0SUBNET,
+			Family:        1,
+			SourceNetmask: netmask,
+			Address:       net.ParseIP(addr).To4(),
+		})
+		return m
+	}
+
+	// An exact match wins, then the first node by name in the subnet.
+	for _, tc := range []struct {
+		addr    string
+		netmask uint8
+		want    string
+	}{
+		{"10.0.0.2", 32, "c"},
+		{"10.0.0.2", 24, "c"},
+		{"10.0.0.9", 24, "b"},
+		{"10.0.0.9", 16, "a"},
+		{"10.0.0.9", 32, ""},
+		{"10.0.0.2", 0, ""},
+	} {
+		if got := d.clientNode(query(tc.addr, tc.netmask)); got != tc.want {
+			t.Errorf("%s/%d: got %q want %q", tc.addr, tc.netmask, got, tc.want)
+		}
+	}
+	if got := d.clientNode(new(dns.Msg)); got != "" {
+		t.Fatalf("got %q want no node", got)
+	}
+}
+
+func TestDNS_PreparedQuery_ClientSubnetSource(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t.Name(), "")
+	defer a.Shutdown()
+
+	var source structs.QuerySource
+	m := MockPreparedQuery{
+		executeFn: func(args *structs.PreparedQueryExecuteRequest, reply *structs.PreparedQueryExecuteResponse) error {
+			source = args.Source
+			return nil
+		},
+	}
+
+	if err := a.registerEndpoint("PreparedQuery", &m); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	req := new(dns.Msg)
+	req.SetQuestion("foo.query.consul.", dns.TypeSRV)
+	req.SetEdns0(4096, false)
+	opt := req.IsEdns0()
+	opt.Option = append(opt.Option, &dns.EDNS0_SUBNET{
+		Code:          dns.EDNS0SUBNET,
+		Family:        1,
+		SourceNetmask: 32,
+		Address:       net.ParseIP("127.0.0.1").To4(),
+	})
+
+	c := new(dns.Client)
+	if _, _, err := c.Exchange(req, a.DNSAddr()); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if source.Node != a.Config.NodeName || source.Datacenter != a.Config.Datacenter {
+		t.Fatalf("bad: %#v", source)
+	}
+
+	// A client subnet that doesn't match any node leaves the source alone.
+	opt.Option[0].(*dns.EDNS0_SUBNET).Address = net.ParseIP("10.1.2.3").To4()
+	if _, _, err := c.Exchange(req, a.DNSAddr()); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if source.Node != "" {
+		t.Fatalf("bad: %#v", source)
+	}
+}
+
 func TestDNS_ServiceLookupWithInternalServiceAddress(t *testing.T) {
 	t.Parallel()
 	a := NewTestAgent(t.Name(), `
@@ -4212,6 +4609,66 @@ func TestDNS_PreparedQuery_AgentSource(t *testing.T) {
 	}
 }
 
+func TestDNS_syncExtra_TXT(t *testing.T) {
+	t.Parallel()
+	srv := func(target string) dns.RR {
+		return &dns.SRV{
+			Hdr: dns.RR_Header{
+				Name:   "db.service.consul.",
+				Rrtype: dns.TypeSRV,
+				Class:  dns.ClassINET,
+			},
+			Target: target,
+		}
+	}
+	a := func(name string) dns.RR {
+		return &dns.A{
+			Hdr: dns.RR_Header{
+				Name:   name,
+				Rrtype: dns.TypeA,
+				Class:  dns.ClassINET,
+			},
+			A: net.ParseIP("10.0.1.185"),
+		}
+	}
+	txt := func(name, value string) dns.RR {
+		return &dns.TXT{
+			Hdr: dns.RR_Header{
+				Name:   name,
+				Rrtype: dns.TypeTXT,
+				Class:  dns.ClassINET,
+			},
+			Txt: []string{value},
+		}
+	}
+
+	resp := &dns.Msg{
+		Answer: []dns.RR{
+			srv("foo.node.dc1.consul."),
+		},
+		Extra: []dns.RR{
+			a("foo.node.dc1.consul."),
+			txt("foo.node.dc1.consul.", "rack=r1"),
+			txt("foo.node.dc1.consul.", "version=2"),
+			a("bar.node.dc1.consul."),
+			txt("bar.node.dc1.consul.", "rack=r2"),
+		},
+	}
+
+	index := make(map[string]dns.RR)
+	indexRRs(resp.Extra, index)
+	syncExtra(index, resp)
+
+	expected := []dns.RR{
+		a("foo.node.dc1.consul."),
+		txt("foo.node.dc1.consul.", "rack=r1"),
+		txt("foo.node.dc1.consul.", "version=2"),
+	}
+	if !reflect.DeepEqual(resp.Extra, expected) {
+		t.Fatalf("Bad %#v vs. %#v", resp.Extra, expected)
+	}
+}
+
 func TestDNS_trimUDPResponse_NoTrim(t *testing.T) {
 	t.Parallel()
 	req := &dns.Msg{}
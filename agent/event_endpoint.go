@@ -2,6 +2,7 @@ package agent
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -187,6 +188,85 @@ RUN_QUERY:
 	return events, nil
 }
 
+// EventStream streams the change events of a topic as newline-delimited JSON
+// until the client goes away. The initial snapshot is sent first unless the
+// subscription resumes from the given index.
+func (s *HTTPServer) EventStream(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if req.Method != "GET" {
+		return nil, MethodNotAllowedError{req.Method, []string{"GET"}}
+	}
+
+	args := structs.SubscribeRequest{
+		Topic: structs.StreamTopic(req.URL.Query().Get("topic")),
+		Key:   req.URL.Query().Get("key"),
+	}
+	s.parseDC(req, &args.Datacenter)
+	s.parseToken(req, &args.Token)
+	switch args.Topic {
+	case structs.StreamTopicServiceHealth:
+		if args.Key == "" {
+			resp.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(resp, "Missing service name")
+			return nil, nil
+		}
+	case structs.StreamTopicKV:
+	default:
+		resp.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(resp, "Invalid topic %q", args.Topic)
+		return nil, nil
+	}
+	if idx := req.URL.Query().Get("index"); idx != "" {
+		index, err := strconv.ParseUint(idx, 10, 64)
+		if err != nil {
+			resp.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(resp, "Invalid index: %s", err)
+			return nil, nil
+		}
+		args.Index = index
+	}
+
+	flusher, ok := resp.(http.Flusher)
+	if !ok {
+		return nil, fmt.Errorf("Streaming not supported")
+	}
+
+	// The status goes out with the first event, so errors starting the
+	// subscription get the usual responses. Errors after that are sent as
+	// an error event.
+	enc := json.NewEncoder(resp)
+	var started bool
+	var index uint64
+	err := s.agent.SubscribeRPC(req.Context(), &args, func(e *structs.StreamEvent) error {
+		if !started {
+			started = true
+			resp.Header().Set("Content-Type", "application/json")
+		}
+		index = e.Index
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+
+		flusher.Flush()
+		return nil
+	})
+	switch {
+	case req.Context().Err() != nil:
+		return nil, nil
+	case !started:
+		return nil, err
+	default:
+		enc.Encode(&structs.StreamEvent{
+			Topic: args.Topic,
+			Type:  structs.StreamError,
+			Key:   args.Key,
+			Index: index,
+			Error: err.Error(),
+		})
+		flusher.Flush()
+		return nil, nil
+	}
+}
+
 // uuidToUint64 is a bit of a hack to generate a 64bit Consul index.
 // In effect, we take our random UUID, convert it to a 128 bit number,
 // then XOR the high-order and low-order 64bit's together to get the
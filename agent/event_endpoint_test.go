@@ -2,15 +2,19 @@ package agent
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"testing"
 	"time"
 
 	"github.com/hashicorp/consul/acl"
 	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/testutil/retry"
 )
 
@@ -323,6 +327,92 @@ func TestEventList_EventBufOrder(t *testing.T) {
 	})
 }
 
+func TestEventStream(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t.Name(), "")
+	defer a.Shutdown()
+
+	setKey := func(key string) {
+		args := structs.KVSRequest{
+			Datacenter: "dc1",
+			Op:         api.KVSet,
+			DirEnt: structs.DirEntry{
+				Key:   key,
+				Value: []byte("hello"),
+			},
+		}
+		var out bool
+		if err := a.RPC("KVS.Apply", &args, &out); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+	setKey("foo/a")
+	setKey("bar")
+
+	// Stream for a bit while changing a key, then read everything that
+	// was sent.
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequest("GET", "/v1/event/stream?topic=kv&key=foo/", nil)
+	req = req.WithContext(ctx)
+	resp := httptest.NewRecorder()
+	doneCh := make(chan struct{})
+	go func() {
+		defer close(doneCh)
+		obj, err := a.srv.EventStream(resp, req)
+		if err != nil || obj != nil {
+			t.Errorf("bad: %v %v", obj, err)
+		}
+	}()
+	time.Sleep(100 * time.Millisecond)
+	setKey("foo/b")
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	select {
+	case <-doneCh:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("should end")
+	}
+
+	var got []string
+	dec := json.NewDecoder(resp.Body)
+	for dec.More() {
+		var e structs.StreamEvent
+		if err := dec.Decode(&e); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		got = append(got, string(e.Type)+"/"+e.Key)
+	}
+	want := []string{"kv-put/foo/a", "end-of-snapshot/foo/", "kv-put/foo/b"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v want %v", got, want)
+	}
+	if ct := resp.Header().Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("bad: %s", ct)
+	}
+}
+
+func TestEventStream_BadRequest(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t.Name(), "")
+	defer a.Shutdown()
+
+	for _, url := range []string{
+		"/v1/event/stream",
+		"/v1/event/stream?topic=nope",
+		"/v1/event/stream?topic=service-health",
+		"/v1/event/stream?topic=kv&index=nope",
+	} {
+		req, _ := http.NewRequest("GET", url, nil)
+		resp := httptest.NewRecorder()
+		if _, err := a.srv.EventStream(resp, req); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if resp.Code != 400 {
+			t.Fatalf("%s: got %d want 400", url, resp.Code)
+		}
+	}
+}
+
 func TestUUIDToUint64(t *testing.T) {
 	t.Parallel()
 	inp := "cb9a81ad-fff6-52ac-92a7-5f70687805ec"
@@ -0,0 +1,386 @@
+// Package filter implements the expression language used by the ?filter=
+// query parameter to select items from the results of list endpoints.
+//
+// An expression is made of matches joined with "and", "or" and "not", and
+// grouped with parentheses. Each match compares the field named by a selector
+// with a value:
+//
+//	<Selector> == <Value>
+//	<Selector> != <Value>
+//	<Selector> contains <Value>
+//	<Selector> not contains <Value>
+//	<Value> in <Selector>
+//	<Value> not in <Selector>
+//	<Selector> is empty
+//	<Selector> is not empty
+//
+// A selector is a dotted path of field names, like Service.Tags, which may
+// also index into maps with string keys, like NodeMeta.rack. A selector that
+// passes through a list matches if any element of the list matches, so
+// Checks.Status == passing selects items with at least one passing check.
+// Values are bare words or quoted strings, and are converted to the type of
+// the selected field when the filter is created.
+package filter
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Filter is a compiled filter expression that can be used to filter slices
+// of the type it was created for.
+type Filter struct {
+	// expression is the original expression.
+	expression string
+
+	// typ is the type of the items being filtered.
+	typ reflect.Type
+
+	// root is the root of the parsed expression, or nil if the expression
+	// is empty and everything should match.
+	root node
+}
+
+// New parses and validates the given expression for filtering items of the
+// given data type, which may be either a slice or an item of a slice. An
+// empty expression creates a filter that matches everything.
+func New(expression string, dataType interface{}) (*Filter, error) {
+	typ := reflect.TypeOf(dataType)
+	if typ == nil {
+		return nil, fmt.Errorf("Missing data type for filter")
+	}
+	if typ.Kind() == reflect.Slice {
+		typ = typ.Elem()
+	}
+
+	f := &Filter{
+		expression: expression,
+		typ:        typ,
+	}
+	if strings.TrimSpace(expression) == "" {
+		return f, nil
+	}
+
+	tokens, err := lex(expression)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens, typ: typ}
+	if f.root, err = p.parse(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// String returns the original expression of the filter.
+func (f *Filter) String() string {
+	return f.expression
+}
+
+// Match returns true if the given item is selected by the filter.
+func (f *Filter) Match(item interface{}) (bool, error) {
+	v := reflect.ValueOf(item)
+	if v.Type() != f.typ {
+		return false, fmt.Errorf("Filter is for %s, not %s", f.typ, v.Type())
+	}
+	if f.root == nil {
+		return true, nil
+	}
+	return f.root.eval(v), nil
+}
+
+// Execute returns a new slice with the items of the given slice selected by
+// the filter, in the same order. The result has the same type as the data,
+// so it can be type asserted back. If the filter matches everything then the
+// data is returned as-is.
+func (f *Filter) Execute(data interface{}) (interface{}, error) {
+	v := reflect.ValueOf(data)
+	if v.Kind() != reflect.Slice || v.Type().Elem() != f.typ {
+		return nil, fmt.Errorf("Filter is for []%s, not %T", f.typ, data)
+	}
+	if f.root == nil {
+		return data, nil
+	}
+
+	out := reflect.MakeSlice(v.Type(), 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		if item := v.Index(i); f.root.eval(item) {
+			out = reflect.Append(out, item)
+		}
+	}
+	return out.Interface(), nil
+}
+
+// node is a part of a parsed expression.
+type node interface {
+	eval(v reflect.Value) bool
+}
+
+// andNode matches if both of its sides match.
+type andNode struct {
+	left, right node
+}
+
+func (n *andNode) eval(v reflect.Value) bool {
+	return n.left.eval(v) && n.right.eval(v)
+}
+
+// orNode matches if either of its sides match.
+type orNode struct {
+	left, right node
+}
+
+func (n *orNode) eval(v reflect.Value) bool {
+	return n.left.eval(v) || n.right.eval(v)
+}
+
+// notNode inverts the match of the node it wraps.
+type notNode struct {
+	node node
+}
+
+func (n *notNode) eval(v reflect.Value) bool {
+	return !n.node.eval(v)
+}
+
+// matchOp is the operator of a match.
+type matchOp int
+
+const (
+	opEqual matchOp = iota
+	opContains
+	opEmpty
+)
+
+// matchNode compares the values at a selector with a value.
+type matchNode struct {
+	op       matchOp
+	selector []string
+	negate   bool
+
+	// value is the value to compare with, already converted to the kind
+	// of the selected field: string, bool, int64, uint64 or float64.
+	value interface{}
+}
+
+func (n *matchNode) eval(v reflect.Value) bool {
+	values := resolve(v, n.selector)
+
+	var match bool
+	switch n.op {
+	case opEqual:
+		for _, v := range values {
+			if equal(v, n.value) {
+				match = true
+				break
+			}
+		}
+
+	case opContains:
+		for _, v := range values {
+			if contains(v, n.value) {
+				match = true
+				break
+			}
+		}
+
+	case opEmpty:
+		match = true
+		for _, v := range values {
+			if v.Len() > 0 {
+				match = false
+				break
+			}
+		}
+	}
+	return match != n.negate
+}
+
+// indirect follows pointers and interfaces, returning an invalid value if
+// any of them are nil.
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// resolve returns all the values at the given path, fanning out over any
+// lists along the way. Nil pointers and missing map keys have no values.
+func resolve(v reflect.Value, path []string) []reflect.Value {
+	v = indirect(v)
+	if !v.IsValid() {
+		return nil
+	}
+	if len(path) == 0 {
+		return []reflect.Value{v}
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		f := v.FieldByName(path[0])
+		if !f.IsValid() {
+			return nil
+		}
+		return resolve(f, path[1:])
+
+	case reflect.Map:
+		key := reflect.ValueOf(path[0]).Convert(v.Type().Key())
+		e := v.MapIndex(key)
+		if !e.IsValid() {
+			return nil
+		}
+		return resolve(e, path[1:])
+
+	case reflect.Slice, reflect.Array:
+		var values []reflect.Value
+		for i := 0; i < v.Len(); i++ {
+			values = append(values, resolve(v.Index(i), path)...)
+		}
+		return values
+	}
+	return nil
+}
+
+// equal returns true if the scalar value equals the given converted value.
+func equal(v reflect.Value, want interface{}) bool {
+	v = indirect(v)
+	if !v.IsValid() {
+		return false
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String() == want
+	case reflect.Bool:
+		return v.Bool() == want
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == want
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.Uint() == want
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == want
+	}
+	return false
+}
+
+// contains returns true if the list has an element equal to the given
+// value, the map has it as a key, or the string has it as a substring.
+func contains(v reflect.Value, want interface{}) bool {
+	switch v.Kind() {
+	case reflect.String:
+		return strings.Contains(v.String(), want.(string))
+
+	case reflect.Map:
+		return v.MapIndex(reflect.ValueOf(want).Convert(v.Type().Key())).IsValid()
+
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if equal(v.Index(i), want) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isScalar returns true if values of the given kind can be compared with ==.
+func isScalar(k reflect.Kind) bool {
+	switch k {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// deref follows pointer types to the type they point to.
+func deref(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// selectorType returns the type of the field at the given path, with lists
+// along the way replaced by the type of their elements. This mirrors how
+// resolve walks values.
+func selectorType(t reflect.Type, path []string) (reflect.Type, error) {
+	t = deref(t)
+	if len(path) == 0 {
+		return t, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		f, ok := t.FieldByName(path[0])
+		if !ok || f.PkgPath != "" {
+			return nil, fmt.Errorf("Unknown field %q in %s", path[0], t)
+		}
+		return selectorType(f.Type, path[1:])
+
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("Cannot select %q from %s", path[0], t)
+		}
+		return selectorType(t.Elem(), path[1:])
+
+	case reflect.Slice, reflect.Array:
+		return selectorType(t.Elem(), path)
+	}
+	return nil, fmt.Errorf("Cannot select %q from %s", path[0], t)
+}
+
+// convert converts the given value to the kind of the given scalar type.
+func convert(t reflect.Type, value string) (interface{}, error) {
+	t = deref(t)
+	if t == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid duration %q", value)
+		}
+		return int64(d), nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return value, nil
+
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid boolean %q", value)
+		}
+		return b, nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, t.Bits())
+		if err != nil {
+			return nil, fmt.Errorf("Invalid integer %q", value)
+		}
+		return i, nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(value, 10, t.Bits())
+		if err != nil {
+			return nil, fmt.Errorf("Invalid unsigned integer %q", value)
+		}
+		return u, nil
+
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, t.Bits())
+		if err != nil {
+			return nil, fmt.Errorf("Invalid number %q", value)
+		}
+		return f, nil
+	}
+	return nil, fmt.Errorf("Cannot compare values of type %s", t)
+}
@@ -0,0 +1,188 @@
+package filter
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testCheck struct {
+	Name   string
+	Status string
+}
+
+type testItem struct {
+	Name    string
+	Port    int
+	Weight  uint16
+	Score   float64
+	Enabled bool
+	Timeout time.Duration
+	Tags    []string
+	Meta    map[string]string
+	Checks  []*testCheck
+	Parent  *testItem
+
+	hidden string
+}
+
+func testItems() []*testItem {
+	return []*testItem{
+		&testItem{
+			Name:    "web",
+			Port:    80,
+			Weight:  10,
+			Score:   1.5,
+			Enabled: true,
+			Timeout: 10 * time.Second,
+			Tags:    []string{"primary", "v1"},
+			Meta:    map[string]string{"rack": "r1"},
+			Checks: []*testCheck{
+				&testCheck{Name: "ping", Status: "passing"},
+				&testCheck{Name: "http", Status: "warning"},
+			},
+		},
+		&testItem{
+			Name:   "db",
+			Port:   5432,
+			Weight: 1,
+			Tags:   []string{"secondary"},
+			Meta:   map[string]string{"rack": "r2", "disk": "ssd"},
+			Checks: []*testCheck{
+				&testCheck{Name: "ping", Status: "critical"},
+			},
+			Parent: &testItem{Name: "web"},
+		},
+		&testItem{
+			Name: "cache",
+			Port: 6379,
+		},
+	}
+}
+
+func TestFilter_Execute(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		expr string
+		want []string
+	}{
+		{"", []string{"web", "db", "cache"}},
+		{"   ", []string{"web", "db", "cache"}},
+		{"Name == web", []string{"web"}},
+		{`Name == "web"`, []string{"web"}},
+		{"Name == `db`", []string{"db"}},
+		{"Name != web", []string{"db", "cache"}},
+		{"Port == 5432", []string{"db"}},
+		{"Weight == 10", []string{"web"}},
+		{"Score == 1.5", []string{"web"}},
+		{"Enabled == true", []string{"web"}},
+		{"Enabled == false", []string{"db", "cache"}},
+		{"Timeout == 10s", []string{"web"}},
+		{"Tags contains primary", []string{"web"}},
+		{"Tags not contains primary", []string{"db", "cache"}},
+		{"primary in Tags", []string{"web"}},
+		{"primary not in Tags", []string{"db", "cache"}},
+		{"Name contains e", []string{"web", "cache"}},
+		{"Meta contains disk", []string{"db"}},
+		{"disk in Meta", []string{"db"}},
+		{"Meta.rack == r1", []string{"web"}},
+		{"Meta.rack != r1", []string{"db", "cache"}},
+		{"Meta.rack is empty", []string{"cache"}},
+		{"Meta.rack is not empty", []string{"web", "db"}},
+		{"Tags is empty", []string{"cache"}},
+		{"Tags is not empty", []string{"web", "db"}},
+		{"Checks.Status == warning", []string{"web"}},
+		{"Checks.Status != passing", []string{"db", "cache"}},
+		{"Checks.Status is empty", []string{"cache"}},
+		{"Parent.Name == web", []string{"db"}},
+		{"Parent.Tags is empty", []string{"web", "db", "cache"}},
+		{"Name == web or Name == db", []string{"web", "db"}},
+		{"Name == web and Port == 80", []string{"web"}},
+		{"Name == web and Port == 81", nil},
+		{"not Name == web", []string{"db", "cache"}},
+		{"not not Name == web", []string{"web"}},
+		{"Name == cache or Name == web and Port == 81", []string{"cache"}},
+		{"(Name == cache or Name == web) and Port == 80", []string{"web"}},
+		{"not (Name == cache or Name == web)", []string{"db"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.expr, func(t *testing.T) {
+			f, err := New(tc.expr, []*testItem(nil))
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			raw, err := f.Execute(testItems())
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			var got []string
+			for _, item := range raw.([]*testItem) {
+				got = append(got, item.Name)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("got %v want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFilter_New_Errors(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		expr string
+		err  string
+	}{
+		{"Name ==", "Expected a value but found end of filter"},
+		{"Name", "Expected an operator but found end of filter"},
+		{"Name = web", `Unexpected '=' at position 5`},
+		{"Name == web and", "Expected a selector or value but found end of filter"},
+		{"(Name == web", `Expected ")" but found end of filter`},
+		{"Name == web)", `Unexpected ")" at position 11`},
+		{`Name == "web`, "Unterminated string at position 8"},
+		{"Nope == web", `Unknown field "Nope"`},
+		{"hidden == web", `Unknown field "hidden"`},
+		{"Name.Foo == web", `Cannot select "Foo" from string`},
+		{"Meta..rack == r1", "Invalid selector"},
+		{`"Name" == web`, "Expected a selector"},
+		{"Port == web", `Invalid integer "web"`},
+		{"Weight == -1", `Invalid unsigned integer "-1"`},
+		{"Enabled == yes", `Invalid boolean "yes"`},
+		{"Timeout == 10", `Invalid duration "10"`},
+		{"Tags == web", "use contains instead"},
+		{"Port contains 1", `Cannot search "Port"`},
+		{"Port is empty", `Cannot check if "Port"`},
+		{"Tags is full", `Expected "empty"`},
+		{"Name like web", "Expected an operator"},
+		{"r1 in (Meta)", `Expected a selector but found "(" at position 6`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.expr, func(t *testing.T) {
+			_, err := New(tc.expr, &testItem{})
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Fatalf("got %v want %q", err, tc.err)
+			}
+		})
+	}
+}
+
+func TestFilter_TypeMismatch(t *testing.T) {
+	t.Parallel()
+	f, err := New("Name == web", []*testItem(nil))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := f.Execute([]testItem{}); err == nil {
+		t.Fatalf("should fail")
+	}
+	if _, err := f.Match(&testCheck{}); err == nil {
+		t.Fatalf("should fail")
+	}
+	ok, err := f.Match(testItems()[0])
+	if err != nil || !ok {
+		t.Fatalf("got %v %v", ok, err)
+	}
+	if f.String() != "Name == web" {
+		t.Fatalf("bad: %s", f)
+	}
+}
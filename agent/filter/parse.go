@@ -0,0 +1,325 @@
+package filter
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// tokenType is the type of a lexed token.
+type tokenType int
+
+const (
+	tokenEOF tokenType = iota
+	tokenWord
+	tokenString
+	tokenLParen
+	tokenRParen
+	tokenEqual
+	tokenNotEqual
+)
+
+// token is a lexed token along with its position in the expression.
+type token struct {
+	typ tokenType
+	val string
+	pos int
+}
+
+func (t token) String() string {
+	if t.typ == tokenEOF {
+		return "end of filter"
+	}
+	return fmt.Sprintf("%q at position %d", t.val, t.pos)
+}
+
+// isWordChar returns true if the rune can be part of a bare word.
+func isWordChar(r rune) bool {
+	if unicode.IsSpace(r) {
+		return false
+	}
+	switch r {
+	case '(', ')', '=', '!', '"', '`':
+		return false
+	}
+	return true
+}
+
+// lex splits the expression into tokens, ending with an EOF token.
+func lex(expression string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(expression); {
+		rest := expression[i:]
+		switch {
+		case unicode.IsSpace(rune(rest[0])):
+			i++
+
+		case rest[0] == '(':
+			tokens = append(tokens, token{tokenLParen, "(", i})
+			i++
+
+		case rest[0] == ')':
+			tokens = append(tokens, token{tokenRParen, ")", i})
+			i++
+
+		case strings.HasPrefix(rest, "=="):
+			tokens = append(tokens, token{tokenEqual, "==", i})
+			i += 2
+
+		case strings.HasPrefix(rest, "!="):
+			tokens = append(tokens, token{tokenNotEqual, "!=", i})
+			i += 2
+
+		case rest[0] == '"' || rest[0] == '`':
+			s, err := strconv.QuotedPrefix(rest)
+			if err != nil {
+				return nil, fmt.Errorf("Unterminated string at position %d", i)
+			}
+			val, err := strconv.Unquote(s)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid string at position %d", i)
+			}
+			tokens = append(tokens, token{tokenString, val, i})
+			i += len(s)
+
+		default:
+			end := strings.IndexFunc(rest, func(r rune) bool { return !isWordChar(r) })
+			if end == 0 {
+				return nil, fmt.Errorf("Unexpected %q at position %d", rest[0], i)
+			}
+			if end < 0 {
+				end = len(rest)
+			}
+			tokens = append(tokens, token{tokenWord, rest[:end], i})
+			i += end
+		}
+	}
+	return append(tokens, token{tokenEOF, "", len(expression)}), nil
+}
+
+// parser is a recursive descent parser for filter expressions. Matches are
+// validated against the type of the items being filtered as they are parsed.
+type parser struct {
+	tokens []token
+	pos    int
+	typ    reflect.Type
+}
+
+// peek returns the token n places ahead without consuming anything.
+func (p *parser) peek(n int) token {
+	if p.pos+n < len(p.tokens) {
+		return p.tokens[p.pos+n]
+	}
+	return p.tokens[len(p.tokens)-1]
+}
+
+// next consumes and returns the next token.
+func (p *parser) next() token {
+	t := p.peek(0)
+	if t.typ != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// isKeyword returns true if the token is the given bare keyword.
+func isKeyword(t token, keyword string) bool {
+	return t.typ == tokenWord && t.val == keyword
+}
+
+// acceptKeyword consumes the next token if it's the given keyword.
+func (p *parser) acceptKeyword(keyword string) bool {
+	if isKeyword(p.peek(0), keyword) {
+		p.next()
+		return true
+	}
+	return false
+}
+
+// parse parses the whole expression.
+func (p *parser) parse() (node, error) {
+	n, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.next(); t.typ != tokenEOF {
+		return nil, fmt.Errorf("Unexpected %s", t)
+	}
+	return n, nil
+}
+
+// parseOr parses matches joined with "or", which binds loosest.
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("or") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &orNode{left, right}
+	}
+	return left, nil
+}
+
+// parseAnd parses matches joined with "and".
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("and") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &andNode{left, right}
+	}
+	return left, nil
+}
+
+// parseNot parses a negation, a group in parentheses, or a single match.
+func (p *parser) parseNot() (node, error) {
+	if p.acceptKeyword("not") {
+		n, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{n}, nil
+	}
+
+	if p.peek(0).typ == tokenLParen {
+		p.next()
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if t := p.next(); t.typ != tokenRParen {
+			return nil, fmt.Errorf("Expected \")\" but found %s", t)
+		}
+		return n, nil
+	}
+
+	return p.parseMatch()
+}
+
+// parseMatch parses a single match. The operator decides whether the first
+// operand is a selector or a value.
+func (p *parser) parseMatch() (node, error) {
+	first := p.next()
+	if first.typ != tokenWord && first.typ != tokenString {
+		return nil, fmt.Errorf("Expected a selector or value but found %s", first)
+	}
+
+	op := p.next()
+	switch {
+	case op.typ == tokenEqual, op.typ == tokenNotEqual:
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		return p.newMatch(opEqual, first, value.val, op.typ == tokenNotEqual)
+
+	case isKeyword(op, "contains"):
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		return p.newMatch(opContains, first, value.val, false)
+
+	case isKeyword(op, "in"):
+		selector := p.next()
+		return p.newMatch(opContains, selector, first.val, false)
+
+	case isKeyword(op, "not") && isKeyword(p.peek(0), "contains"):
+		p.next()
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		return p.newMatch(opContains, first, value.val, true)
+
+	case isKeyword(op, "not") && isKeyword(p.peek(0), "in"):
+		p.next()
+		selector := p.next()
+		return p.newMatch(opContains, selector, first.val, true)
+
+	case isKeyword(op, "is"):
+		negate := p.acceptKeyword("not")
+		if t := p.next(); !isKeyword(t, "empty") {
+			return nil, fmt.Errorf("Expected \"empty\" but found %s", t)
+		}
+		return p.newMatch(opEmpty, first, "", negate)
+	}
+	return nil, fmt.Errorf("Expected an operator but found %s", op)
+}
+
+// value consumes the value of a match.
+func (p *parser) value() (token, error) {
+	t := p.next()
+	if t.typ != tokenWord && t.typ != tokenString {
+		return t, fmt.Errorf("Expected a value but found %s", t)
+	}
+	return t, nil
+}
+
+// newMatch validates the selector and value for the given operator and
+// returns the match node.
+func (p *parser) newMatch(op matchOp, selector token, value string, negate bool) (node, error) {
+	if selector.typ != tokenWord {
+		return nil, fmt.Errorf("Expected a selector but found %s", selector)
+	}
+	path := strings.Split(selector.val, ".")
+	for _, part := range path {
+		if part == "" {
+			return nil, fmt.Errorf("Invalid selector %s", selector)
+		}
+	}
+	t, err := selectorType(p.typ, path)
+	if err != nil {
+		return nil, err
+	}
+
+	n := &matchNode{
+		op:       op,
+		selector: path,
+		negate:   negate,
+	}
+	switch op {
+	case opEqual:
+		if !isScalar(t.Kind()) {
+			return nil, fmt.Errorf("Cannot compare %q of type %s with ==, use contains instead", selector.val, t)
+		}
+		n.value, err = convert(t, value)
+
+	case opContains:
+		switch t.Kind() {
+		case reflect.String:
+			n.value = value
+		case reflect.Map:
+			if t.Key().Kind() != reflect.String {
+				return nil, fmt.Errorf("Cannot search keys of %q of type %s", selector.val, t)
+			}
+			n.value = value
+		case reflect.Slice, reflect.Array:
+			n.value, err = convert(t.Elem(), value)
+		default:
+			return nil, fmt.Errorf("Cannot search %q of type %s", selector.val, t)
+		}
+
+	case opEmpty:
+		switch t.Kind() {
+		case reflect.String, reflect.Map, reflect.Slice, reflect.Array:
+		default:
+			return nil, fmt.Errorf("Cannot check if %q of type %s is empty", selector.val, t)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return n, nil
+}
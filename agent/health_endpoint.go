@@ -6,6 +6,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/hashicorp/consul/agent/cachetype"
 	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/api"
 )
@@ -19,7 +20,7 @@ func (s *HTTPServer) HealthChecksInState(resp http.ResponseWriter, req *http.Req
 	args := structs.ChecksInStateRequest{}
 	s.parseSource(req, &args.Source)
 	args.NodeMetaFilters = s.parseMetaFilter(req)
-	if done := s.parse(resp, req, &args.Datacenter, &args.QueryOptions); done {
+	if done := s.parseWithFilter(resp, req, &args.Datacenter, &args.QueryOptions); done {
 		return nil, nil
 	}
 
@@ -57,7 +58,7 @@ func (s *HTTPServer) HealthNodeChecks(resp http.ResponseWriter, req *http.Reques
 
 	// Set default DC
 	args := structs.NodeSpecificRequest{}
-	if done := s.parse(resp, req, &args.Datacenter, &args.QueryOptions); done {
+	if done := s.parseWithFilter(resp, req, &args.Datacenter, &args.QueryOptions); done {
 		return nil, nil
 	}
 
@@ -97,7 +98,7 @@ func (s *HTTPServer) HealthServiceChecks(resp http.ResponseWriter, req *http.Req
 	args := structs.ServiceSpecificRequest{}
 	s.parseSource(req, &args.Source)
 	args.NodeMetaFilters = s.parseMetaFilter(req)
-	if done := s.parse(resp, req, &args.Datacenter, &args.QueryOptions); done {
+	if done := s.parseWithFilter(resp, req, &args.Datacenter, &args.QueryOptions); done {
 		return nil, nil
 	}
 
@@ -137,7 +138,8 @@ func (s *HTTPServer) HealthServiceNodes(resp http.ResponseWriter, req *http.Requ
 	args := structs.ServiceSpecificRequest{}
 	s.parseSource(req, &args.Source)
 	args.NodeMetaFilters = s.parseMetaFilter(req)
-	if done := s.parse(resp, req, &args.Datacenter, &args.QueryOptions); done {
+	args.ServiceMetaFilters = s.parseServiceMetaFilter(req)
+	if done := s.parseWithFilter(resp, req, &args.Datacenter, &args.QueryOptions); done {
 		return nil, nil
 	}
 
@@ -159,7 +161,7 @@ func (s *HTTPServer) HealthServiceNodes(resp http.ResponseWriter, req *http.Requ
 	// Make the RPC request
 	var out structs.IndexedCheckServiceNodes
 	defer setMeta(resp, &out.QueryMeta)
-	if err := s.agent.RPC("Health.ServiceNodes", &args, &out); err != nil {
+	if err := s.cachedRPC(resp, args.UseCache, cachetype.HealthServicesName, "Health.ServiceNodes", &args, &out); err != nil {
 		return nil, err
 	}
 
@@ -460,6 +460,77 @@ func TestHealthServiceNodes(t *testing.T) {
 	}
 }
 
+func TestHealthServiceNodes_Cached(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t.Name(), "")
+	defer a.Shutdown()
+
+	register := func(node, status string) {
+		args := &structs.RegisterRequest{
+			Datacenter: "dc1",
+			Node:       node,
+			Address:    "127.0.0.1",
+			Service: &structs.NodeService{
+				ID:      "test",
+				Service: "test",
+			},
+			Check: &structs.HealthCheck{
+				Node:      node,
+				Name:      "test check",
+				ServiceID: "test",
+				Status:    status,
+			},
+		}
+		var out struct{}
+		if err := a.RPC("Catalog.Register", args, &out); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+	get := func(url string) (structs.CheckServiceNodes, *httptest.ResponseRecorder) {
+		req, _ := http.NewRequest("GET", url, nil)
+		resp := httptest.NewRecorder()
+		obj, err := a.srv.HealthServiceNodes(resp, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		return obj.(structs.CheckServiceNodes), resp
+	}
+	register("foo", api.HealthPassing)
+	register("bar", api.HealthCritical)
+
+	// The first read misses and the second is answered from the cache.
+	nodes, resp := get("/v1/health/service/test?cached&passing")
+	if len(nodes) != 1 || resp.Header().Get("X-Cache") != "MISS" {
+		t.Fatalf("bad: %v %v", nodes, resp.Header())
+	}
+	assertIndex(t, resp)
+
+	// Filtering the passing nodes must not change the cached value.
+	nodes, resp = get("/v1/health/service/test?cached")
+	if len(nodes) != 2 || resp.Header().Get("X-Cache") != "HIT" {
+		t.Fatalf("bad: %v %v", nodes, resp.Header())
+	}
+	if resp.Header().Get("Age") == "" {
+		t.Fatalf("missing Age header")
+	}
+	assertIndex(t, resp)
+
+	// The background refresh picks up changes.
+	register("baz", api.HealthPassing)
+	retry.Run(t, func(r *retry.R) {
+		nodes, resp := get("/v1/health/service/test?cached")
+		if len(nodes) != 3 || resp.Header().Get("X-Cache") != "HIT" {
+			r.Fatalf("bad: %v %v", nodes, resp.Header())
+		}
+	})
+
+	// Uncached reads don't set the cache headers.
+	nodes, resp = get("/v1/health/service/test")
+	if len(nodes) != 3 || resp.Header().Get("X-Cache") != "" {
+		t.Fatalf("bad: %v %v", nodes, resp.Header())
+	}
+}
+
 func TestHealthServiceNodes_NodeMetaFilter(t *testing.T) {
 	t.Parallel()
 	a := NewTestAgent(t.Name(), "")
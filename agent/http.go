@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/http/pprof"
 	"net/url"
+	"reflect"
 	"regexp"
 	"strconv"
 	"strings"
@@ -14,7 +15,9 @@ import (
 
 	"github.com/armon/go-metrics"
 	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/cache"
 	"github.com/hashicorp/consul/agent/structs"
+	"github.com/mitchellh/copystructure"
 	"github.com/mitchellh/mapstructure"
 )
 
@@ -97,6 +100,13 @@ func (s *HTTPServer) handler(enableDebug bool) http.Handler {
 		handleFuncMetrics("/v1/acl/clone/", s.wrap(s.ACLClone))
 		handleFuncMetrics("/v1/acl/list", s.wrap(s.ACLList))
 		handleFuncMetrics("/v1/acl/replication", s.wrap(s.ACLReplicationStatus))
+		handleFuncMetrics("/v1/acl/policy", s.wrap(s.ACLPolicyCreate))
+		handleFuncMetrics("/v1/acl/policy/", s.wrap(s.ACLPolicySpecific))
+		handleFuncMetrics("/v1/acl/policies", s.wrap(s.ACLPolicyList))
+		handleFuncMetrics("/v1/acl/token", s.wrap(s.ACLTokenCreate))
+		handleFuncMetrics("/v1/acl/token/", s.wrap(s.ACLTokenSpecific))
+		handleFuncMetrics("/v1/acl/tokens", s.wrap(s.ACLTokenList))
+		handleFuncMetrics("/v1/acl/upgrade/", s.wrap(s.ACLTokenUpgrade))
 		handleFuncMetrics("/v1/agent/token/", s.wrap(s.AgentToken))
 	} else {
 		handleFuncMetrics("/v1/acl/bootstrap", s.wrap(ACLDisabled))
@@ -107,6 +117,13 @@ func (s *HTTPServer) handler(enableDebug bool) http.Handler {
 		handleFuncMetrics("/v1/acl/clone/", s.wrap(ACLDisabled))
 		handleFuncMetrics("/v1/acl/list", s.wrap(ACLDisabled))
 		handleFuncMetrics("/v1/acl/replication", s.wrap(ACLDisabled))
+		handleFuncMetrics("/v1/acl/policy", s.wrap(ACLDisabled))
+		handleFuncMetrics("/v1/acl/policy/", s.wrap(ACLDisabled))
+		handleFuncMetrics("/v1/acl/policies", s.wrap(ACLDisabled))
+		handleFuncMetrics("/v1/acl/token", s.wrap(ACLDisabled))
+		handleFuncMetrics("/v1/acl/token/", s.wrap(ACLDisabled))
+		handleFuncMetrics("/v1/acl/tokens", s.wrap(ACLDisabled))
+		handleFuncMetrics("/v1/acl/upgrade/", s.wrap(ACLDisabled))
 		handleFuncMetrics("/v1/agent/token/", s.wrap(ACLDisabled))
 	}
 	handleFuncMetrics("/v1/agent/self", s.wrap(s.AgentSelf))
@@ -139,12 +156,17 @@ func (s *HTTPServer) handler(enableDebug bool) http.Handler {
 	if !s.agent.config.DisableCoordinates {
 		handleFuncMetrics("/v1/coordinate/datacenters", s.wrap(s.CoordinateDatacenters))
 		handleFuncMetrics("/v1/coordinate/nodes", s.wrap(s.CoordinateNodes))
+		handleFuncMetrics("/v1/coordinate/rtt", s.wrap(s.CoordinateRTT))
+		handleFuncMetrics("/v1/coordinate/matrix", s.wrap(s.CoordinateMatrix))
 	} else {
 		handleFuncMetrics("/v1/coordinate/datacenters", s.wrap(coordinateDisabled))
 		handleFuncMetrics("/v1/coordinate/nodes", s.wrap(coordinateDisabled))
+		handleFuncMetrics("/v1/coordinate/rtt", s.wrap(coordinateDisabled))
+		handleFuncMetrics("/v1/coordinate/matrix", s.wrap(coordinateDisabled))
 	}
 	handleFuncMetrics("/v1/event/fire/", s.wrap(s.EventFire))
 	handleFuncMetrics("/v1/event/list", s.wrap(s.EventList))
+	handleFuncMetrics("/v1/event/stream", s.wrap(s.EventStream))
 	handleFuncMetrics("/v1/health/node/", s.wrap(s.HealthNodeChecks))
 	handleFuncMetrics("/v1/health/checks/", s.wrap(s.HealthServiceChecks))
 	handleFuncMetrics("/v1/health/state/", s.wrap(s.HealthChecksInState))
@@ -257,6 +279,9 @@ func (s *HTTPServer) wrap(handler func(resp http.ResponseWriter, req *http.Reque
 				fmt.Fprint(resp, err.Error())
 			case structs.IsErrRPCRateExceeded(err):
 				resp.WriteHeader(http.StatusTooManyRequests)
+			case structs.IsErrInvalidFilter(err):
+				resp.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(resp, err.Error())
 			case isMethodNotAllowed(err):
 				// RFC2616 states that for 405 Method Not Allowed the response
 				// MUST include an Allow header containing the list of valid
@@ -393,6 +418,61 @@ func setMeta(resp http.ResponseWriter, m *structs.QueryMeta) {
 	setKnownLeader(resp, m.KnownLeader)
 }
 
+// setCacheMeta is used to set the headers for responses that could be
+// answered from the agent cache.
+func setCacheMeta(resp http.ResponseWriter, m *cache.ResultMeta) {
+	if m == nil {
+		return
+	}
+	str := "MISS"
+	if m.Hit {
+		str = "HIT"
+		resp.Header().Set("Age", strconv.FormatUint(uint64(m.Age/time.Second), 10))
+	}
+	resp.Header().Set("X-Cache", str)
+}
+
+// cachedRPC makes an RPC request, or reads its result from the agent cache
+// of type t when the client asked for it with ?cached. Values in the cache
+// are shared, so out always gets its own copy that the caller is free to
+// modify.
+func (s *HTTPServer) cachedRPC(resp http.ResponseWriter, useCache bool, t, method string, args cache.Request, out interface{}) error {
+	if !useCache {
+		return s.agent.RPC(method, args, out)
+	}
+
+	raw, m, err := s.agent.cache.Get(t, args)
+	if err != nil {
+		return err
+	}
+	setCacheMeta(resp, &m)
+
+	// A blocking read times out without a value when the cache is cold
+	// and the first fetch hasn't returned yet. Leave out empty, as if the
+	// query timed out without a change.
+	if raw == nil {
+		index := m.Index
+		if min := args.CacheInfo().MinIndex; min > index {
+			index = min
+		}
+		if meta := reflect.ValueOf(out).Elem().FieldByName("QueryMeta"); meta.IsValid() {
+			meta.FieldByName("Index").SetUint(index)
+		}
+		return nil
+	}
+
+	dup, err := copystructure.Copy(raw)
+	if err != nil {
+		return err
+	}
+	dst, src := reflect.ValueOf(out).Elem(), reflect.ValueOf(dup).Elem()
+	if src.Type() != dst.Type() {
+		return fmt.Errorf("internal error: cached %s result has type %s, expected %s", t, src.Type(), dst.Type())
+	}
+	dst.Set(src)
+	return nil
+}
+
 // setHeaders is used to set canonical response header fields
 func setHeaders(resp http.ResponseWriter, headers map[string]string) {
 	for field, value := range headers {
@@ -443,6 +523,41 @@ func parseConsistency(resp http.ResponseWriter, req *http.Request, b *structs.Qu
 	return false
 }
 
+// parseCacheControl is used to parse the ?cached query param and the
+// max-age directive of the Cache-Control header, which only applies to
+// cached requests. Returns true on error
+func parseCacheControl(resp http.ResponseWriter, req *http.Request, b *structs.QueryOptions) bool {
+	if _, ok := req.URL.Query()["cached"]; !ok {
+		return false
+	}
+	b.UseCache = true
+	if b.RequireConsistent {
+		resp.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(resp, "Cannot specify ?cached with ?consistent, conflicting semantics.")
+		return true
+	}
+
+	for _, dir := range strings.Split(req.Header.Get("Cache-Control"), ",") {
+		dir = strings.TrimSpace(dir)
+		if !strings.HasPrefix(dir, "max-age=") {
+			continue
+		}
+		secs, err := strconv.ParseUint(strings.TrimPrefix(dir, "max-age="), 10, 64)
+		if err != nil {
+			resp.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(resp, "Invalid max-age in Cache-Control header")
+			return true
+		}
+		b.MaxAge = time.Duration(secs) * time.Second
+		if secs == 0 {
+			// A zero MaxAge accepts any age, so use the smallest one
+			// instead to make sure the result is fresh.
+			b.MaxAge = time.Nanosecond
+		}
+	}
+	return false
+}
+
 // parseDC is used to parse the ?dc query param
 func (s *HTTPServer) parseDC(req *http.Request, dc *string) {
 	if other := req.URL.Query().Get("dc"); other != "" {
@@ -485,7 +600,20 @@ func (s *HTTPServer) parseSource(req *http.Request, source *structs.QuerySource)
 // parseMetaFilter is used to parse the ?node-meta=key:value query parameter, used for
 // filtering results to nodes with the given metadata key/value
 func (s *HTTPServer) parseMetaFilter(req *http.Request) map[string]string {
-	if filterList, ok := req.URL.Query()["node-meta"]; ok {
+	return parseMetaPairs(req, "node-meta")
+}
+
+// parseServiceMetaFilter is used to parse the ?service-meta=key:value query
+// parameter, used for filtering results to service instances with the given
+// metadata key/value
+func (s *HTTPServer) parseServiceMetaFilter(req *http.Request) map[string]string {
+	return parseMetaPairs(req, "service-meta")
+}
+
+// parseMetaPairs parses all the key:value pairs given for the named query
+// parameter, returning nil if the parameter wasn't present.
+func parseMetaPairs(req *http.Request, param string) map[string]string {
+	if filterList, ok := req.URL.Query()[param]; ok {
 		filters := make(map[string]string)
 		for _, filter := range filterList {
 			key, value := ParseMetaPair(filter)
@@ -496,13 +624,41 @@ func (s *HTTPServer) parseMetaFilter(req *http.Request) map[string]string {
 	return nil
 }
 
+// parseFilter is used to parse the ?filter= query parameter, used for
+// selecting results with an expression on endpoints that support it.
+func parseFilter(req *http.Request, filter *string) {
+	*filter = req.URL.Query().Get("filter")
+}
+
 // parse is a convenience method for endpoints that need
-// to use both parseWait and parseDC.
+// to use both parseWait and parseDC. Endpoints that don't
+// support filtering reject the ?filter= query parameter.
 func (s *HTTPServer) parse(resp http.ResponseWriter, req *http.Request, dc *string, b *structs.QueryOptions) bool {
+	if _, ok := req.URL.Query()["filter"]; ok {
+		resp.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(resp, "Filtering is not supported by this endpoint")
+		return true
+	}
+	return s.parseOptions(resp, req, dc, b)
+}
+
+// parseWithFilter is like parse, but also parses the ?filter= query
+// parameter for the endpoints that support it.
+func (s *HTTPServer) parseWithFilter(resp http.ResponseWriter, req *http.Request, dc *string, b *structs.QueryOptions) bool {
+	parseFilter(req, &b.Filter)
+	return s.parseOptions(resp, req, dc, b)
+}
+
+// parseOptions parses the query parameters common to all the endpoints
+// using parse or parseWithFilter.
+func (s *HTTPServer) parseOptions(resp http.ResponseWriter, req *http.Request, dc *string, b *structs.QueryOptions) bool {
 	s.parseDC(req, dc)
 	s.parseToken(req, &b.Token)
 	if parseConsistency(resp, req, b) {
 		return true
 	}
+	if parseCacheControl(resp, req, b) {
+		return true
+	}
 	return parseWait(resp, req, b)
 }
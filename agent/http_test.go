@@ -18,6 +18,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/hashicorp/consul/agent/cache"
 	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/logger"
 	"github.com/hashicorp/consul/testutil"
@@ -662,6 +663,86 @@ func TestParseConsistency_Invalid(t *testing.T) {
 	}
 }
 
+func TestParseCacheControl(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		url      string
+		header   string
+		useCache bool
+		maxAge   time.Duration
+		code     int
+	}{
+		{"/v1/catalog/nodes", "", false, 0, 200},
+		{"/v1/catalog/nodes", "max-age=30", false, 0, 200},
+		{"/v1/catalog/nodes?cached", "", true, 0, 200},
+		{"/v1/catalog/nodes?cached", "max-age=30", true, 30 * time.Second, 200},
+		{"/v1/catalog/nodes?cached", "no-store, max-age=30", true, 30 * time.Second, 200},
+		{"/v1/catalog/nodes?cached", "max-age=0", true, time.Nanosecond, 200},
+		{"/v1/catalog/nodes?cached", "max-age=nope", true, 0, 400},
+		{"/v1/catalog/nodes?cached&consistent", "", true, 0, 400},
+	}
+	for _, tc := range cases {
+		t.Run(tc.url+" "+tc.header, func(t *testing.T) {
+			resp := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", tc.url, nil)
+			if tc.header != "" {
+				req.Header.Set("Cache-Control", tc.header)
+			}
+			var b structs.QueryOptions
+			if parseConsistency(resp, req, &b) {
+				t.Fatalf("unexpected done")
+			}
+			if done := parseCacheControl(resp, req, &b); done != (tc.code != 200) {
+				t.Fatalf("got done %v", done)
+			}
+			if resp.Code != tc.code {
+				t.Fatalf("got code %d want %d", resp.Code, tc.code)
+			}
+			if b.UseCache != tc.useCache || b.MaxAge != tc.maxAge {
+				t.Fatalf("bad: %#v", b)
+			}
+		})
+	}
+}
+
+// slowCacheType is a cache type whose fetches take longer than the reads in
+// the tests wait for.
+type slowCacheType struct{}
+
+func (slowCacheType) Fetch(cache.FetchOptions, cache.Request) (cache.FetchResult, error) {
+	time.Sleep(time.Second)
+	return cache.FetchResult{Value: &structs.IndexedServiceNodes{}, Index: 20}, nil
+}
+
+func TestHTTPServer_cachedRPC_ColdTimeout(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t.Name(), "")
+	defer a.Shutdown()
+	a.cache.RegisterType("slow", slowCacheType{}, &cache.RegisterOptions{})
+
+	// The read times out before the first fetch returns, which looks like
+	// a blocking query that timed out without a change.
+	args := structs.ServiceSpecificRequest{
+		Datacenter:  "dc1",
+		ServiceName: "web",
+		QueryOptions: structs.QueryOptions{
+			MinQueryIndex: 10,
+			MaxQueryTime:  10 * time.Millisecond,
+		},
+	}
+	var out structs.IndexedServiceNodes
+	resp := httptest.NewRecorder()
+	if err := a.srv.cachedRPC(resp, true, "slow", "Catalog.ServiceNodes", &args, &out); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.Index != 10 || len(out.ServiceNodes) != 0 {
+		t.Fatalf("bad: %#v", out)
+	}
+	if got, want := resp.Header().Get("X-Cache"), "MISS"; got != want {
+		t.Fatalf("got X-Cache %q want %q", got, want)
+	}
+}
+
 // Test ACL token is resolved in correct order
 func TestACLResolution(t *testing.T) {
 	t.Parallel()
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/api"
@@ -160,6 +161,17 @@ func (s *HTTPServer) KVSPut(resp http.ResponseWriter, req *http.Request, args *s
 		applyReq.DirEnt.Flags = flagVal
 	}
 
+	// Check for a TTL
+	if _, ok := params["ttl"]; ok {
+		ttl, err := time.ParseDuration(params.Get("ttl"))
+		if err != nil || ttl <= 0 {
+			resp.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(resp, "Invalid TTL %q: must be a positive duration", params.Get("ttl"))
+			return nil, nil
+		}
+		applyReq.DirEnt.TTL = params.Get("ttl")
+	}
+
 	// Check for cas value
 	if _, ok := params["cas"]; ok {
 		casVal, err := strconv.ParseUint(params.Get("cas"), 10, 64)
@@ -170,10 +182,17 @@ func (s *HTTPServer) KVSPut(resp http.ResponseWriter, req *http.Request, args *s
 		applyReq.Op = api.KVCAS
 	}
 
-	// Check for lock acquisition
+	// Check for lock acquisition, queueing for the lock if asked to.
 	if _, ok := params["acquire"]; ok {
 		applyReq.DirEnt.Session = params.Get("acquire")
 		applyReq.Op = api.KVLock
+		if _, ok := params["queue"]; ok {
+			applyReq.Op = api.KVLockQueue
+		}
+	} else if _, ok := params["queue"]; ok {
+		resp.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(resp, "The queue parameter can only be used with acquire")
+		return nil, nil
 	}
 
 	// Check for lock release
@@ -70,6 +70,50 @@ func TestKVSEndpoint_PUT_GET_DELETE(t *testing.T) {
 	}
 }
 
+func TestKVSEndpoint_PUT_TTL(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t.Name(), "")
+	defer a.Shutdown()
+
+	t.Run("valid", func(t *testing.T) {
+		buf := bytes.NewBuffer([]byte("test"))
+		req, _ := http.NewRequest("PUT", "/v1/kv/test?ttl=10s", buf)
+		resp := httptest.NewRecorder()
+		obj, err := a.srv.KVSEndpoint(resp, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if res := obj.(bool); !res {
+			t.Fatalf("should work")
+		}
+
+		req, _ = http.NewRequest("GET", "/v1/kv/test", nil)
+		resp = httptest.NewRecorder()
+		obj, err = a.srv.KVSEndpoint(resp, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		res := obj.(structs.DirEntries)
+		if len(res) != 1 || res[0].TTL != "10s" {
+			t.Fatalf("bad: %v", res)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, ttl := range []string{"nope", "0s", "-5s"} {
+			buf := bytes.NewBuffer([]byte("test"))
+			req, _ := http.NewRequest("PUT", "/v1/kv/test?ttl="+ttl, buf)
+			resp := httptest.NewRecorder()
+			if _, err := a.srv.KVSEndpoint(resp, req); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			if resp.Code != http.StatusBadRequest {
+				t.Fatalf("%s: expected 400, got %d", ttl, resp.Code)
+			}
+		}
+	})
+}
+
 func TestKVSEndpoint_Recurse(t *testing.T) {
 	t.Parallel()
 	a := NewTestAgent(t.Name(), "")
@@ -394,6 +438,48 @@ func TestKVSEndpoint_AcquireRelease(t *testing.T) {
 	}
 }
 
+func TestKVSEndpoint_AcquireQueue(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t.Name(), "")
+	defer a.Shutdown()
+
+	// The queue parameter needs acquire
+	req, _ := http.NewRequest("PUT", "/v1/kv/test?queue", bytes.NewReader(nil))
+	resp := httptest.NewRecorder()
+	if _, err := a.srv.KVSEndpoint(resp, req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Code != 400 {
+		t.Fatalf("expected 400, got %d", resp.Code)
+	}
+
+	// Take the lock, then queue a second session for it
+	ids := []string{makeTestSession(t, a.srv), makeTestSession(t, a.srv)}
+	for i, id := range ids {
+		req, _ := http.NewRequest("PUT", "/v1/kv/test?queue&acquire="+id, bytes.NewReader(nil))
+		resp := httptest.NewRecorder()
+		obj, err := a.srv.KVSEndpoint(resp, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if res := obj.(bool); res != (i == 0) {
+			t.Fatalf("bad: %v", res)
+		}
+	}
+
+	// Verify the second session is queued
+	req, _ = http.NewRequest("GET", "/v1/kv/test", nil)
+	resp = httptest.NewRecorder()
+	obj, err := a.srv.KVSEndpoint(resp, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	d := obj.(structs.DirEntries)[0]
+	if d.Session != ids[0] || len(d.LockWaiters) != 1 || d.LockWaiters[0] != ids[1] {
+		t.Fatalf("bad: %v", d)
+	}
+}
+
 func TestKVSEndpoint_GET_Raw(t *testing.T) {
 	t.Parallel()
 	a := NewTestAgent(t.Name(), "")
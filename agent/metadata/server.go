@@ -40,6 +40,10 @@ type Server struct {
 	Addr         net.Addr
 	Status       serf.MemberStatus
 
+	// Meta has the node meta the server advertises in its "meta_" tags, for
+	// the keys Autopilot uses to find its redundancy zone and version.
+	Meta map[string]string
+
 	// If true, use TLS when connecting to this server
 	UseTLS bool
 }
@@ -94,7 +98,11 @@ func IsConsulServer(m serf.Member) (bool, *Server) {
 
 	segment_addrs := make(map[string]string)
 	segment_ports := make(map[string]int)
+	meta := make(map[string]string)
 	for name, value := range m.Tags {
+		if strings.HasPrefix(name, "meta_") {
+			meta[strings.TrimPrefix(name, "meta_")] = value
+		}
 		if strings.HasPrefix(name, "sl_") {
 			addr, port, err := net.SplitHostPort(value)
 			if err != nil {
@@ -162,6 +170,7 @@ func IsConsulServer(m serf.Member) (bool, *Server) {
 		Status:       m.Status,
 		NonVoter:     nonVoter,
 		UseTLS:       useTLS,
+		Meta:         meta,
 	}
 	return true, parts
 }
@@ -65,6 +65,7 @@ func TestIsConsulServer(t *testing.T) {
 			"expect":        "3",
 			"raft_vsn":      "3",
 			"use_tls":       "1",
+			"meta_zone":     "east-1a",
 		},
 		Status: serf.StatusLeft,
 	}
@@ -99,6 +100,9 @@ func TestIsConsulServer(t *testing.T) {
 	if !parts.UseTLS {
 		t.Fatalf("bad: %v", parts.UseTLS)
 	}
+	if len(parts.Meta) != 1 || parts.Meta["zone"] != "east-1a" {
+		t.Fatalf("bad: %v", parts.Meta)
+	}
 	m.Tags["bootstrap"] = "1"
 	m.Tags["disabled"] = "1"
 	ok, parts = metadata.IsConsulServer(m)
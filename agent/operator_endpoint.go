@@ -327,8 +327,31 @@ func (s *HTTPServer) OperatorServerHealth(resp http.ResponseWriter, req *http.Re
 			Healthy:     server.Healthy,
 			Voter:       server.Voter,
 			StableSince: server.StableSince.Round(time.Second).UTC(),
+
+			RedundancyZone: server.RedundancyZone,
+			UpgradeVersion: server.UpgradeVersion,
 		})
 	}
+	if reply.RedundancyZones != nil {
+		out.RedundancyZones = make(map[string]api.AutopilotZone)
+		for name, zone := range reply.RedundancyZones {
+			out.RedundancyZones[name] = api.AutopilotZone{
+				Servers:          zone.Servers,
+				Voters:           zone.Voters,
+				FailureTolerance: zone.FailureTolerance,
+			}
+		}
+	}
+	if reply.Upgrade != nil {
+		out.Upgrade = &api.AutopilotUpgrade{
+			Status:                 reply.Upgrade.Status,
+			TargetVersion:          reply.Upgrade.TargetVersion,
+			TargetVersionVoters:    reply.Upgrade.TargetVersionVoters,
+			TargetVersionNonVoters: reply.Upgrade.TargetVersionNonVoters,
+			OtherVersionVoters:     reply.Upgrade.OtherVersionVoters,
+			OtherVersionNonVoters:  reply.Upgrade.OtherVersionNonVoters,
+		}
+	}
 
 	return out, nil
 }
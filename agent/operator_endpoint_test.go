@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -437,6 +438,51 @@ func TestOperator_ServerHealth(t *testing.T) {
 	})
 }
 
+func TestOperator_ServerHealth_RedundancyZones(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t.Name(), `
+		raft_protocol = 3
+		node_meta {
+			az = "east1a"
+			build = "0.0.2"
+		}
+		autopilot {
+			redundancy_zone_tag = "az"
+			upgrade_version_tag = "build"
+		}
+	`)
+	defer a.Shutdown()
+
+	body := bytes.NewBuffer(nil)
+	req, _ := http.NewRequest("GET", "/v1/operator/autopilot/health", body)
+	retry.Run(t, func(r *retry.R) {
+		resp := httptest.NewRecorder()
+		obj, err := a.srv.OperatorServerHealth(resp, req)
+		if err != nil {
+			r.Fatalf("err: %v", err)
+		}
+		out, ok := obj.(*api.OperatorHealthReply)
+		if !ok {
+			r.Fatalf("unexpected: %T", obj)
+		}
+		if len(out.Servers) != 1 ||
+			out.Servers[0].RedundancyZone != "east1a" ||
+			out.Servers[0].UpgradeVersion != "0.0.2" {
+			r.Fatalf("bad: %v", out)
+		}
+		id := string(a.Config.NodeID)
+		zone, ok := out.RedundancyZones["east1a"]
+		if !ok || !reflect.DeepEqual(zone.Voters, []string{id}) {
+			r.Fatalf("bad: %v", out.RedundancyZones)
+		}
+		if out.Upgrade == nil ||
+			out.Upgrade.Status != structs.AutopilotUpgradeIdle ||
+			out.Upgrade.TargetVersion != "0.0.2" {
+			r.Fatalf("bad: %v", out.Upgrade)
+		}
+	})
+}
+
 func TestOperator_ServerHealth_Unhealthy(t *testing.T) {
 	t.Parallel()
 	a := NewTestAgent(t.Name(), `
@@ -12,4 +12,5 @@ const (
 	RPCMultiplexV2         = 4
 	RPCSnapshot            = 5
 	RPCGossip              = 6
+	RPCSubscribe           = 7
 )
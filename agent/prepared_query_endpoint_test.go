@@ -472,6 +472,57 @@ func TestPreparedQuery_Execute(t *testing.T) {
 	})
 }
 
+func TestPreparedQuery_Execute_Cached(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t.Name(), "")
+	defer a.Shutdown()
+
+	var calls int
+	m := MockPreparedQuery{
+		executeFn: func(args *structs.PreparedQueryExecuteRequest, reply *structs.PreparedQueryExecuteResponse) error {
+			calls++
+			if !args.AllowStale {
+				t.Fatalf("bad: %v", args)
+			}
+			reply.Failovers = calls
+			return nil
+		},
+	}
+	if err := a.registerEndpoint("PreparedQuery", &m); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	cases := []struct {
+		maxAge    string
+		cache     string
+		failovers int
+	}{
+		{"", "MISS", 1},
+		{"", "HIT", 1},
+		{"max-age=60", "HIT", 1},
+		{"max-age=0", "MISS", 2},
+		{"", "HIT", 2},
+	}
+	for i, tc := range cases {
+		req, _ := http.NewRequest("GET", "/v1/query/my-id/execute?cached", nil)
+		if tc.maxAge != "" {
+			req.Header.Set("Cache-Control", tc.maxAge)
+		}
+		resp := httptest.NewRecorder()
+		obj, err := a.srv.PreparedQuerySpecific(resp, req)
+		if err != nil {
+			t.Fatalf("%d: err: %v", i, err)
+		}
+		r, ok := obj.(structs.PreparedQueryExecuteResponse)
+		if !ok {
+			t.Fatalf("%d: unexpected: %T", i, obj)
+		}
+		if r.Failovers != tc.failovers || resp.Header().Get("X-Cache") != tc.cache {
+			t.Fatalf("%d: bad: %v %v", i, r, resp.Header())
+		}
+	}
+}
+
 func TestPreparedQuery_Explain(t *testing.T) {
 	t.Parallel()
 	t.Run("", func(t *testing.T) {
@@ -56,9 +56,23 @@ type ACL struct {
 	Type  string
 	Rules string
 
+	// Policies are the reusable policies linked to this token. Their rules
+	// are merged with any rules embedded in the token itself. Tokens which
+	// only have embedded rules are considered legacy tokens.
+	Policies []ACLTokenPolicyLink `json:",omitempty"`
+
 	RaftIndex
 }
 
+// ACLTokenPolicyLink links a token to a policy. Tokens may be linked by
+// policy name, in which case the ID is filled in by the servers. The name
+// is refreshed when the token is read, so it always reflects the policy's
+// current name.
+type ACLTokenPolicyLink struct {
+	ID   string
+	Name string
+}
+
 // ACLs is a slice of ACLs.
 type ACLs []*ACL
 
@@ -70,13 +84,77 @@ func (a *ACL) IsSame(other *ACL) bool {
 	if a.ID != other.ID ||
 		a.Name != other.Name ||
 		a.Type != other.Type ||
-		a.Rules != other.Rules {
+		a.Rules != other.Rules ||
+		len(a.Policies) != len(other.Policies) {
 		return false
 	}
 
+	// Policy names are only informational, so the links are compared by
+	// ID.
+	for i := range a.Policies {
+		if a.Policies[i].ID != other.Policies[i].ID {
+			return false
+		}
+	}
+
 	return true
 }
 
+// IsLegacy returns true if the token carries embedded rules and isn't linked
+// to any policies yet.
+func (a *ACL) IsLegacy() bool {
+	return a.Type == ACLTypeClient && a.Rules != "" && len(a.Policies) == 0
+}
+
+// ACLPolicy is a named, reusable set of rules which can be linked to any
+// number of tokens.
+type ACLPolicy struct {
+	ID          string
+	Name        string
+	Description string
+	Rules       string
+
+	// Datacenters limits the datacenters the policy is enforced in. If
+	// this is empty the policy applies in all datacenters.
+	Datacenters []string `json:",omitempty"`
+
+	RaftIndex
+}
+
+// ACLPolicies is a slice of ACL policies.
+type ACLPolicies []*ACLPolicy
+
+// IsSame checks if one policy is the same as another, without looking at the
+// Raft information.
+func (p *ACLPolicy) IsSame(other *ACLPolicy) bool {
+	if p.ID != other.ID ||
+		p.Name != other.Name ||
+		p.Description != other.Description ||
+		p.Rules != other.Rules ||
+		len(p.Datacenters) != len(other.Datacenters) {
+		return false
+	}
+	for i := range p.Datacenters {
+		if p.Datacenters[i] != other.Datacenters[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// AppliesTo returns true if the policy is enforced in the given datacenter.
+func (p *ACLPolicy) AppliesTo(dc string) bool {
+	if len(p.Datacenters) == 0 {
+		return true
+	}
+	for _, d := range p.Datacenters {
+		if d == dc {
+			return true
+		}
+	}
+	return false
+}
+
 // ACLBootstrap keeps track of whether bootstrapping ACLs is allowed for a
 // cluster.
 type ACLBootstrap struct {
@@ -120,6 +198,12 @@ type ACLPolicyRequest struct {
 	Datacenter string
 	ACL        string
 	ETag       string
+
+	// SourceDatacenter is the datacenter the ACL will be enforced in,
+	// which decides which of the token's policies apply. This defaults to
+	// the ACL datacenter if not given.
+	SourceDatacenter string
+
 	QueryOptions
 }
 
@@ -134,8 +218,9 @@ type IndexedACLs struct {
 	QueryMeta
 }
 
-// ACLPolicy is a policy that can be associated with a token.
-type ACLPolicy struct {
+// ACLPolicyResponse is the compiled policy of a token, which is used by
+// non-authoritative caches.
+type ACLPolicyResponse struct {
 	ETag   string
 	Parent string
 	Policy *acl.Policy
@@ -143,6 +228,53 @@ type ACLPolicy struct {
 	QueryMeta
 }
 
+// ACLPolicyApplyRequest is used to create, update or delete an ACL policy.
+type ACLPolicyApplyRequest struct {
+	Datacenter string
+	Op         ACLOp
+	Policy     ACLPolicy
+	WriteRequest
+}
+
+// RequestDatacenter returns the DC this request is targeted to.
+func (r *ACLPolicyApplyRequest) RequestDatacenter() string {
+	return r.Datacenter
+}
+
+// ACLPolicyApplyRequests is a list of ACL policy change requests.
+type ACLPolicyApplyRequests []*ACLPolicyApplyRequest
+
+// ACLPolicySpecificRequest is used to request an ACL policy by ID.
+type ACLPolicySpecificRequest struct {
+	Datacenter string
+	PolicyID   string
+	QueryOptions
+}
+
+// RequestDatacenter returns the DC this request is targeted to.
+func (r *ACLPolicySpecificRequest) RequestDatacenter() string {
+	return r.Datacenter
+}
+
+// IndexedACLPolicies has policies along with the Raft metadata about them.
+type IndexedACLPolicies struct {
+	Policies ACLPolicies
+	QueryMeta
+}
+
+// ACLTokenUpgradeRequest is used to upgrade a legacy token so that its rules
+// are held by a policy instead.
+type ACLTokenUpgradeRequest struct {
+	Datacenter string
+	ACL        string
+	WriteRequest
+}
+
+// RequestDatacenter returns the DC this request is targeted to.
+func (r *ACLTokenUpgradeRequest) RequestDatacenter() string {
+	return r.Datacenter
+}
+
 // ACLReplicationStatus provides information about the health of the ACL
 // replication system.
 type ACLReplicationStatus struct {
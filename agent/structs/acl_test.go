@@ -10,6 +10,9 @@ func TestStructs_ACL_IsSame(t *testing.T) {
 		Name:  "An ACL for testing",
 		Type:  "client",
 		Rules: "service \"\" { policy = \"read\" }",
+		Policies: []ACLTokenPolicyLink{
+			{ID: "policy1", Name: "web"},
+		},
 	}
 	if !acl.IsSame(acl) {
 		t.Fatalf("should be equal to itself")
@@ -20,13 +23,16 @@ func TestStructs_ACL_IsSame(t *testing.T) {
 		Name:  "An ACL for testing",
 		Type:  "client",
 		Rules: "service \"\" { policy = \"read\" }",
+		Policies: []ACLTokenPolicyLink{
+			{ID: "policy1", Name: "renamed"},
+		},
 		RaftIndex: RaftIndex{
 			CreateIndex: 1,
 			ModifyIndex: 2,
 		},
 	}
 	if !acl.IsSame(other) || !other.IsSame(acl) {
-		t.Fatalf("should not care about Raft fields")
+		t.Fatalf("should not care about Raft fields or policy names")
 	}
 
 	check := func(twiddle, restore func()) {
@@ -49,4 +55,77 @@ func TestStructs_ACL_IsSame(t *testing.T) {
 	check(func() { other.Name = "nope" }, func() { other.Name = "An ACL for testing" })
 	check(func() { other.Type = "management" }, func() { other.Type = "client" })
 	check(func() { other.Rules = "" }, func() { other.Rules = "service \"\" { policy = \"read\" }" })
+	check(func() { other.Policies[0].ID = "nope" }, func() { other.Policies[0].ID = "policy1" })
+	check(func() { other.Policies = nil }, func() { other.Policies = []ACLTokenPolicyLink{{ID: "policy1"}} })
+}
+
+func TestStructs_ACL_IsLegacy(t *testing.T) {
+	token := &ACL{
+		Type:  ACLTypeClient,
+		Rules: "service \"\" { policy = \"read\" }",
+	}
+	if !token.IsLegacy() {
+		t.Fatalf("should be legacy")
+	}
+
+	token.Policies = []ACLTokenPolicyLink{{ID: "policy1"}}
+	if token.IsLegacy() {
+		t.Fatalf("should not be legacy")
+	}
+
+	token.Policies, token.Type = nil, ACLTypeManagement
+	if token.IsLegacy() {
+		t.Fatalf("should not be legacy")
+	}
+}
+
+func TestStructs_ACLPolicy_IsSame(t *testing.T) {
+	policy := &ACLPolicy{
+		ID:          "guid",
+		Name:        "web",
+		Description: "Web servers",
+		Rules:       "service \"web\" { policy = \"write\" }",
+		Datacenters: []string{"dc1"},
+	}
+	other := &ACLPolicy{
+		ID:          "guid",
+		Name:        "web",
+		Description: "Web servers",
+		Rules:       "service \"web\" { policy = \"write\" }",
+		Datacenters: []string{"dc1"},
+		RaftIndex: RaftIndex{
+			CreateIndex: 1,
+			ModifyIndex: 2,
+		},
+	}
+	if !policy.IsSame(other) || !other.IsSame(policy) {
+		t.Fatalf("should not care about Raft fields")
+	}
+
+	for _, twiddle := range []func(*ACLPolicy){
+		func(p *ACLPolicy) { p.ID = "nope" },
+		func(p *ACLPolicy) { p.Name = "nope" },
+		func(p *ACLPolicy) { p.Description = "nope" },
+		func(p *ACLPolicy) { p.Rules = "" },
+		func(p *ACLPolicy) { p.Datacenters = []string{"dc2"} },
+		func(p *ACLPolicy) { p.Datacenters = nil },
+	} {
+		changed := *other
+		twiddle(&changed)
+		if policy.IsSame(&changed) || changed.IsSame(policy) {
+			t.Fatalf("should not be the same: %#v", changed)
+		}
+	}
+}
+
+func TestStructs_ACLPolicy_AppliesTo(t *testing.T) {
+	policy := &ACLPolicy{}
+	if !policy.AppliesTo("dc1") {
+		t.Fatalf("should apply everywhere")
+	}
+
+	policy.Datacenters = []string{"dc1", "dc2"}
+	if !policy.AppliesTo("dc2") || policy.AppliesTo("dc3") {
+		t.Fatalf("bad: %#v", policy)
+	}
 }
@@ -27,6 +27,8 @@ type CheckDefinition struct {
 	Header                         map[string][]string
 	Method                         string
 	TCP                            string
+	GRPC                           string
+	GRPCUseTLS                     bool
 	Interval                       time.Duration
 	DockerContainerID              string
 	Shell                          string
@@ -34,6 +36,10 @@ type CheckDefinition struct {
 	Timeout                        time.Duration
 	TTL                            time.Duration
 	DeregisterCriticalServiceAfter time.Duration
+	SuccessBeforePassing           int
+	FailuresBeforeCritical         int
+	FlapHalfLife                   time.Duration
+	FlapSuppressWindow             time.Duration
 }
 
 func (c *CheckDefinition) HealthCheck(node string) *HealthCheck {
@@ -67,6 +73,8 @@ func (c *CheckDefinition) CheckType() *CheckType {
 		Header:            c.Header,
 		Method:            c.Method,
 		TCP:               c.TCP,
+		GRPC:              c.GRPC,
+		GRPCUseTLS:        c.GRPCUseTLS,
 		Interval:          c.Interval,
 		DockerContainerID: c.DockerContainerID,
 		Shell:             c.Shell,
@@ -74,5 +82,9 @@ func (c *CheckDefinition) CheckType() *CheckType {
 		Timeout:           c.Timeout,
 		TTL:               c.TTL,
 		DeregisterCriticalServiceAfter: c.DeregisterCriticalServiceAfter,
+		SuccessBeforePassing:           c.SuccessBeforePassing,
+		FailuresBeforeCritical:         c.FailuresBeforeCritical,
+		FlapHalfLife:                   c.FlapHalfLife,
+		FlapSuppressWindow:             c.FlapSuppressWindow,
 	}
 }
@@ -7,10 +7,10 @@ import (
 )
 
 // CheckType is used to create either the CheckMonitor or the CheckTTL.
-// Five types are supported: Script, HTTP, TCP, Docker and TTL. Script, HTTP,
-// Docker and TCP all require Interval. Only one of the types may to be
-// provided: TTL or Script/Interval or HTTP/Interval or TCP/Interval or
-// Docker/Interval.
+// Six types are supported: Script, HTTP, TCP, gRPC, Docker and TTL. Script,
+// HTTP, TCP, gRPC and Docker all require Interval. Only one of the types may
+// to be provided: TTL or Script/Interval or HTTP/Interval or TCP/Interval or
+// GRPC/Interval or Docker/Interval.
 type CheckType struct {
 	// fields already embedded in CheckDefinition
 	// Note: CheckType.CheckID == CheckDefinition.ID
@@ -29,6 +29,8 @@ type CheckType struct {
 	Header            map[string][]string
 	Method            string
 	TCP               string
+	GRPC              string
+	GRPCUseTLS        bool
 	Interval          time.Duration
 	DockerContainerID string
 	Shell             string
@@ -40,12 +42,25 @@ type CheckType struct {
 	// service, if any, to be deregistered if this check is critical for
 	// longer than this duration.
 	DeregisterCriticalServiceAfter time.Duration
+
+	// SuccessBeforePassing and FailuresBeforeCritical are the number of
+	// results in a row an interval based check must return before it goes
+	// from critical to passing or warning, and back.
+	SuccessBeforePassing   int
+	FailuresBeforeCritical int
+
+	// FlapHalfLife, if >0, enables flap damping for an interval based
+	// check, with the penalty for each status change halving over this
+	// duration. FlapSuppressWindow, if >0, limits how long a flapping
+	// check has its status changes suppressed.
+	FlapHalfLife       time.Duration
+	FlapSuppressWindow time.Duration
 }
 type CheckTypes []*CheckType
 
 // Valid checks if the CheckType is valid
 func (c *CheckType) Valid() bool {
-	return c.IsTTL() || c.IsMonitor() || c.IsHTTP() || c.IsTCP() || c.IsDocker()
+	return c.IsTTL() || c.IsMonitor() || c.IsHTTP() || c.IsTCP() || c.IsGRPC() || c.IsDocker()
 }
 
 // IsScript checks if this is a check that execs some kind of script.
@@ -73,6 +88,11 @@ func (c *CheckType) IsTCP() bool {
 	return c.TCP != "" && c.Interval != 0
 }
 
+// IsGRPC checks if this is a gRPC type
+func (c *CheckType) IsGRPC() bool {
+	return c.GRPC != "" && c.Interval != 0
+}
+
 // IsDocker returns true when checking a docker container.
 func (c *CheckType) IsDocker() bool {
 	return c.IsScript() && c.DockerContainerID != "" && c.Interval != 0
@@ -12,6 +12,7 @@ const (
 	errNotReadyForConsistentReads = "Not ready to serve consistent reads"
 	errSegmentsNotSupported       = "Network segments are not supported in this version of Consul"
 	errRPCRateExceeded            = "RPC rate limit exceeded"
+	errInvalidFilter              = "Invalid filter"
 )
 
 var (
@@ -21,8 +22,13 @@ var (
 	ErrNotReadyForConsistentReads = errors.New(errNotReadyForConsistentReads)
 	ErrSegmentsNotSupported       = errors.New(errSegmentsNotSupported)
 	ErrRPCRateExceeded            = errors.New(errRPCRateExceeded)
+	ErrInvalidFilter              = errors.New(errInvalidFilter)
 )
 
 func IsErrRPCRateExceeded(err error) bool {
 	return strings.Contains(err.Error(), errRPCRateExceeded)
 }
+
+func IsErrInvalidFilter(err error) bool {
+	return strings.Contains(err.Error(), errInvalidFilter)
+}
@@ -161,6 +161,15 @@ type ServerHealth struct {
 
 	// StableSince is the last time this server's Healthy value changed.
 	StableSince time.Time
+
+	// RedundancyZone is the redundancy zone of the server, from the node
+	// meta key set in the RedundancyZoneTag of the Autopilot config.
+	RedundancyZone string
+
+	// UpgradeVersion is the version of the server used for upgrade
+	// migrations. This is the Consul version, unless the UpgradeVersionTag
+	// of the Autopilot config is set.
+	UpgradeVersion string
 }
 
 // IsHealthy determines whether this ServerHealth is considered healthy
@@ -226,6 +235,76 @@ type OperatorHealthReply struct {
 
 	// Servers holds the health of each server.
 	Servers []ServerHealth
+
+	// RedundancyZones has the state of each redundancy zone, by name. This is
+	// only set if the RedundancyZoneTag of the Autopilot config is set.
+	RedundancyZones map[string]RedundancyZone
+
+	// Upgrade has the state of the upgrade migration.
+	Upgrade *AutopilotUpgrade
+}
+
+// RedundancyZone is the state of a redundancy zone.
+type RedundancyZone struct {
+	// Servers has the IDs of the servers in the zone.
+	Servers []string
+
+	// Voters has the IDs of the voting servers in the zone. Autopilot keeps
+	// one voter in each zone.
+	Voters []string
+
+	// FailureTolerance is the number of healthy servers in the zone that
+	// could be lost while the zone still has a server to vote.
+	FailureTolerance int
+}
+
+const (
+	// AutopilotUpgradeDisabled means upgrade migrations are disabled.
+	AutopilotUpgradeDisabled = "disabled"
+
+	// AutopilotUpgradeIdle means all the servers are on the same version.
+	AutopilotUpgradeIdle = "idle"
+
+	// AutopilotUpgradeAwaitNewServers means there are servers on a newer
+	// version, but not enough of them are stable to replace the voters.
+	AutopilotUpgradeAwaitNewServers = "await-new-servers"
+
+	// AutopilotUpgradePromoting means the servers on the newer version are
+	// being promoted to voters.
+	AutopilotUpgradePromoting = "promoting"
+
+	// AutopilotUpgradeDemoting means the voters on older versions are being
+	// demoted.
+	AutopilotUpgradeDemoting = "demoting"
+
+	// AutopilotUpgradeLeaderTransfer means only the leader is left on an
+	// older version, and it's being demoted so that a voter on the newer
+	// version takes over.
+	AutopilotUpgradeLeaderTransfer = "leader-transfer"
+)
+
+// AutopilotUpgrade is the state of an upgrade migration, where the voters
+// are moved to the servers on the newest version.
+type AutopilotUpgrade struct {
+	// Status is the stage of the migration, one of the AutopilotUpgrade*
+	// constants.
+	Status string
+
+	// TargetVersion is the newest version of the servers.
+	TargetVersion string
+
+	// TargetVersionVoters has the IDs of the voters on the target version.
+	TargetVersionVoters []string
+
+	// TargetVersionNonVoters has the IDs of the non-voters on the target
+	// version.
+	TargetVersionNonVoters []string
+
+	// OtherVersionVoters has the IDs of the voters on older versions.
+	OtherVersionVoters []string
+
+	// OtherVersionNonVoters has the IDs of the non-voters on older versions.
+	OtherVersionNonVoters []string
 }
 
 // (Enterprise-only) NetworkSegment is the configuration for a network segment, which is an
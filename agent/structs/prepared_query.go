@@ -1,5 +1,9 @@
 package structs
 
+import (
+	"github.com/hashicorp/consul/agent/cache"
+)
+
 // QueryDatacenterOptions sets options about how we fail over if there are no
 // healthy nodes in the local datacenter.
 type QueryDatacenterOptions struct {
@@ -49,6 +53,11 @@ type ServiceQuery struct {
 	// pair is in this map it must be present on the node in order for the
 	// service entry to be returned.
 	NodeMeta map[string]string
+
+	// ServiceMeta is a map of required service metadata fields. If a
+	// key/value pair is in this map it must be present on the service
+	// instance in order for it to be returned.
+	ServiceMeta map[string]string
 }
 
 const (
@@ -205,6 +214,18 @@ func (q *PreparedQueryExecuteRequest) RequestDatacenter() string {
 	return q.Datacenter
 }
 
+// CacheInfo implements cache.Request so the results of the request can be
+// cached by the agent. Prepared queries don't support blocking, so there's
+// no index to wait for.
+func (q *PreparedQueryExecuteRequest) CacheInfo() cache.RequestInfo {
+	return cache.RequestInfo{
+		Token:      q.Token,
+		Datacenter: q.Datacenter,
+		MaxAge:     q.MaxAge,
+		Key:        cacheKey(q.QueryIDOrName, q.Limit, q.Source, q.Agent),
+	}
+}
+
 // PreparedQueryExecuteRemoteRequest is used when running a local query in a
 // remote datacenter.
 type PreparedQueryExecuteRemoteRequest struct {
@@ -6,7 +6,9 @@ type ServiceDefinition struct {
 	Name              string
 	Tags              []string
 	Address           string
+	Meta              map[string]string
 	Port              int
+	Weights           *Weights
 	Check             CheckType
 	Checks            CheckTypes
 	Token             string
@@ -19,7 +21,9 @@ func (s *ServiceDefinition) NodeService() *NodeService {
 		Service:           s.Name,
 		Tags:              s.Tags,
 		Address:           s.Address,
+		Meta:              s.Meta,
 		Port:              s.Port,
+		Weights:           s.Weights,
 		EnableTagOverride: s.EnableTagOverride,
 	}
 	if ns.ID == "" && ns.Service != "" {
@@ -0,0 +1,132 @@
+package structs
+
+// StreamTopic is the kind of data a subscription to change events is for.
+type StreamTopic string
+
+const (
+	// StreamTopicServiceHealth is for the instances of a service and their
+	// health checks. The key of the subscription is the service name.
+	StreamTopicServiceHealth StreamTopic = "service-health"
+
+	// StreamTopicKV is for entries in the KV store. The key of the
+	// subscription is a prefix of the keys to watch.
+	StreamTopicKV StreamTopic = "kv"
+)
+
+// StreamEventType is the kind of change a stream event describes.
+type StreamEventType string
+
+const (
+	// StreamServiceUpsert is sent when a service instance is registered or
+	// changed, including changes to its node. Its checks are sent in
+	// separate events.
+	StreamServiceUpsert StreamEventType = "service-upsert"
+
+	// StreamServiceDelete is sent when a service instance is deregistered,
+	// which also removes its checks.
+	StreamServiceDelete StreamEventType = "service-delete"
+
+	// StreamCheckUpdate is sent when a check that applies to a service
+	// instance is registered or changes status or output. Node checks are
+	// sent for each service instance on the node.
+	StreamCheckUpdate StreamEventType = "check-update"
+
+	// StreamCheckDelete is sent when a check that applies to a service
+	// instance is deregistered.
+	StreamCheckDelete StreamEventType = "check-delete"
+
+	// StreamKVPut is sent when a key is created or updated.
+	StreamKVPut StreamEventType = "kv-put"
+
+	// StreamKVDelete is sent when a key is deleted.
+	StreamKVDelete StreamEventType = "kv-delete"
+
+	// StreamEndOfSnapshot follows the events of the initial snapshot of a
+	// subscription. Its index is the index of the snapshot.
+	StreamEndOfSnapshot StreamEventType = "end-of-snapshot"
+
+	// StreamResumed is sent instead of a snapshot when a subscription
+	// resumes from the index it asked for. It's followed by the events
+	// after that index.
+	StreamResumed StreamEventType = "resumed"
+
+	// StreamError ends a stream that can't continue. Clients should
+	// subscribe again from the index of the last event they received.
+	StreamError StreamEventType = "error"
+)
+
+// StreamEvent is a change to the state store sent to subscribers. Only the
+// field for the type of the event is set.
+type StreamEvent struct {
+	Topic StreamTopic
+	Type  StreamEventType
+	Key   string
+	Index uint64
+
+	// Service is set for service events. The node and service are always
+	// set, but deletes only carry the names and IDs. Checks are sent in
+	// their own events so it's always empty.
+	Service *CheckServiceNode `json:",omitempty"`
+
+	// Check is set for check events.
+	Check *HealthCheck `json:",omitempty"`
+
+	// KV is set for KV events. Deletes only carry the key.
+	KV *DirEntry `json:",omitempty"`
+
+	// Error is set for error events.
+	Error string `json:",omitempty"`
+}
+
+// SubscribeRequest is used to subscribe to the change events of a topic.
+// The subscription is served by the server that receives it, so events may
+// be arbitrarily stale, like reads with AllowStale set.
+type SubscribeRequest struct {
+	// Datacenter is the target datacenter for this request. The request
+	// will be forwarded if necessary.
+	Datacenter string
+
+	// Token is the ACL token used to filter the events.
+	Token string
+
+	// Topic and Key select the events to send.
+	Topic StreamTopic
+	Key   string
+
+	// Index is the index of the last event the client has seen. If the
+	// server still has all the events after it, the subscription resumes
+	// from there instead of sending a snapshot first.
+	Index uint64
+}
+
+// RequestDatacenter returns the datacenter for a given request.
+func (r *SubscribeRequest) RequestDatacenter() string {
+	return r.Datacenter
+}
+
+// IsRead is always true since subscriptions only read.
+func (r *SubscribeRequest) IsRead() bool {
+	return true
+}
+
+// AllowStaleRead is always true since subscriptions are served by any
+// server.
+func (r *SubscribeRequest) AllowStaleRead() bool {
+	return true
+}
+
+// ACLToken returns the token used to filter the events.
+func (r *SubscribeRequest) ACLToken() string {
+	return r.Token
+}
+
+// SubscribeResponse is the header of a subscription stream, which is
+// followed by msgpack-encoded StreamEvents.
+type SubscribeResponse struct {
+	// Error is the overall error status of the RPC request.
+	Error string
+}
+
+// StreamEventFn is called with each event of a subscription. Returning an
+// error ends the subscription.
+type StreamEventFn func(event *StreamEvent) error
@@ -2,13 +2,16 @@ package structs
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
+	"math"
 	"math/rand"
 	"reflect"
 	"regexp"
 	"strings"
 	"time"
 
+	"github.com/hashicorp/consul/agent/cache"
 	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/types"
 	"github.com/hashicorp/go-msgpack/codec"
@@ -39,6 +42,7 @@ const (
 	AutopilotRequestType                  = 9
 	AreaRequestType                       = 10
 	ACLBootstrapRequestType               = 11 // FSM snapshots only.
+	ACLPolicyRequestType                  = 12
 )
 
 const (
@@ -110,6 +114,21 @@ type QueryOptions struct {
 	// If set, the leader must verify leadership prior to
 	// servicing the request. Prevents a stale read.
 	RequireConsistent bool
+
+	// Filter is an expression used to select the results of list
+	// endpoints that support it. See the agent/filter package for the
+	// syntax.
+	Filter string
+
+	// UseCache requests that the agent answer the query from its local
+	// cache if the endpoint supports it. This is only used by the agent,
+	// so it's left out of the RPC encoding and never sent to the servers.
+	UseCache bool `codec:"-"`
+
+	// MaxAge limits how old a cached result may be, when UseCache is set.
+	// Zero means any age is acceptable. Like UseCache, it isn't sent to
+	// the servers.
+	MaxAge time.Duration `codec:"-"`
 }
 
 // IsRead is always true for QueryOption.
@@ -253,12 +272,13 @@ func (r *DCSpecificRequest) RequestDatacenter() string {
 
 // ServiceSpecificRequest is used to query about a specific service
 type ServiceSpecificRequest struct {
-	Datacenter      string
-	NodeMetaFilters map[string]string
-	ServiceName     string
-	ServiceTag      string
-	TagFilter       bool // Controls tag filtering
-	Source          QuerySource
+	Datacenter         string
+	NodeMetaFilters    map[string]string
+	ServiceMetaFilters map[string]string
+	ServiceName        string
+	ServiceTag         string
+	TagFilter          bool // Controls tag filtering
+	Source             QuerySource
 	QueryOptions
 }
 
@@ -266,6 +286,31 @@ func (r *ServiceSpecificRequest) RequestDatacenter() string {
 	return r.Datacenter
 }
 
+// CacheInfo implements cache.Request so the results of the request can be
+// cached by the agent.
+func (r *ServiceSpecificRequest) CacheInfo() cache.RequestInfo {
+	return cache.RequestInfo{
+		Token:      r.Token,
+		Datacenter: r.Datacenter,
+		MinIndex:   r.MinQueryIndex,
+		Timeout:    r.MaxQueryTime,
+		MaxAge:     r.MaxAge,
+		Key: cacheKey(r.ServiceName, r.ServiceTag, r.TagFilter,
+			r.NodeMetaFilters, r.ServiceMetaFilters, r.Source, r.Filter),
+	}
+}
+
+// cacheKey returns a key for the cache made from the fields of a request
+// that change its results, or "" if they can't be encoded, which keeps the
+// request from being cached.
+func cacheKey(fields ...interface{}) string {
+	buf, err := json.Marshal(fields)
+	if err != nil {
+		return ""
+	}
+	return string(buf)
+}
+
 // NodeSpecificRequest is used to request the information about a single node
 type NodeSpecificRequest struct {
 	Datacenter string
@@ -305,8 +350,20 @@ type Nodes []*Node
 
 // ValidateMeta validates a set of key/value pairs from the agent config
 func ValidateMetadata(meta map[string]string, allowConsulPrefix bool) error {
+	return validateMetadata("Node", meta, allowConsulPrefix)
+}
+
+// ValidateServiceMetadata validates a set of key/value pairs attached to a
+// service definition.
+func ValidateServiceMetadata(meta map[string]string, allowConsulPrefix bool) error {
+	return validateMetadata("Service", meta, allowConsulPrefix)
+}
+
+// validateMetadata validates a set of node or service key/value pairs. The
+// kind is used to give context in error messages.
+func validateMetadata(kind string, meta map[string]string, allowConsulPrefix bool) error {
 	if len(meta) > metaMaxKeyPairs {
-		return fmt.Errorf("Node metadata cannot contain more than %d key/value pairs", metaMaxKeyPairs)
+		return fmt.Errorf("%s metadata cannot contain more than %d key/value pairs", kind, metaMaxKeyPairs)
 	}
 
 	for key, value := range meta {
@@ -368,7 +425,9 @@ type ServiceNode struct {
 	ServiceName              string
 	ServiceTags              []string
 	ServiceAddress           string
+	ServiceMeta              map[string]string
 	ServicePort              int
+	ServiceWeights           *Weights
 	ServiceEnableTagOverride bool
 
 	RaftIndex
@@ -379,6 +438,13 @@ type ServiceNode struct {
 func (s *ServiceNode) PartialClone() *ServiceNode {
 	tags := make([]string, len(s.ServiceTags))
 	copy(tags, s.ServiceTags)
+	var nsmeta map[string]string
+	if s.ServiceMeta != nil {
+		nsmeta = make(map[string]string, len(s.ServiceMeta))
+		for k, v := range s.ServiceMeta {
+			nsmeta[k] = v
+		}
+	}
 
 	return &ServiceNode{
 		// Skip ID, see above.
@@ -390,6 +456,8 @@ func (s *ServiceNode) PartialClone() *ServiceNode {
 		ServiceTags:              tags,
 		ServiceAddress:           s.ServiceAddress,
 		ServicePort:              s.ServicePort,
+		ServiceMeta:              nsmeta,
+		ServiceWeights:           s.ServiceWeights.Clone(),
 		ServiceEnableTagOverride: s.ServiceEnableTagOverride,
 		RaftIndex: RaftIndex{
 			CreateIndex: s.CreateIndex,
@@ -406,6 +474,8 @@ func (s *ServiceNode) ToNodeService() *NodeService {
 		Tags:              s.ServiceTags,
 		Address:           s.ServiceAddress,
 		Port:              s.ServicePort,
+		Meta:              s.ServiceMeta,
+		Weights:           s.ServiceWeights,
 		EnableTagOverride: s.ServiceEnableTagOverride,
 		RaftIndex: RaftIndex{
 			CreateIndex: s.CreateIndex,
@@ -422,7 +492,9 @@ type NodeService struct {
 	Service           string
 	Tags              []string
 	Address           string
+	Meta              map[string]string
 	Port              int
+	Weights           *Weights
 	EnableTagOverride bool
 
 	RaftIndex
@@ -438,6 +510,8 @@ func (s *NodeService) IsSame(other *NodeService) bool {
 		!reflect.DeepEqual(s.Tags, other.Tags) ||
 		s.Address != other.Address ||
 		s.Port != other.Port ||
+		!reflect.DeepEqual(s.Meta, other.Meta) ||
+		!reflect.DeepEqual(s.Weights, other.Weights) ||
 		s.EnableTagOverride != other.EnableTagOverride {
 		return false
 	}
@@ -457,6 +531,8 @@ func (s *NodeService) ToServiceNode(node string) *ServiceNode {
 		ServiceTags:              s.Tags,
 		ServiceAddress:           s.Address,
 		ServicePort:              s.Port,
+		ServiceMeta:              s.Meta,
+		ServiceWeights:           s.Weights,
 		ServiceEnableTagOverride: s.EnableTagOverride,
 		RaftIndex: RaftIndex{
 			CreateIndex: s.CreateIndex,
@@ -465,6 +541,39 @@ func (s *NodeService) ToServiceNode(node string) *ServiceNode {
 	}
 }
 
+// Weights are the DNS SRV weights of a service instance, depending on its
+// health. Instances without weights get a weight of 1 in both states.
+type Weights struct {
+	Passing int
+	Warning int
+}
+
+// Clone returns a copy of the weights, which may be nil.
+func (w *Weights) Clone() *Weights {
+	if w == nil {
+		return nil
+	}
+	clone := *w
+	return &clone
+}
+
+// ValidateWeights makes sure the weights fit in an SRV record. An instance
+// must have a positive weight when it's passing, but a weight of 0 when it's
+// warning is allowed, so that it only gets traffic when nothing else is
+// available.
+func ValidateWeights(w *Weights) error {
+	if w == nil {
+		return nil
+	}
+	if w.Passing < 1 || w.Passing > math.MaxUint16 {
+		return fmt.Errorf("Passing weight must be between 1 and %d", math.MaxUint16)
+	}
+	if w.Warning < 0 || w.Warning > math.MaxUint16 {
+		return fmt.Errorf("Warning weight must be between 0 and %d", math.MaxUint16)
+	}
+	return nil
+}
+
 type NodeServices struct {
 	Node     *Node
 	Services map[string]*NodeService
@@ -615,22 +724,35 @@ type DirEntry struct {
 	Value     []byte
 	Session   string `json:",omitempty"`
 
+	// TTL is an optional duration after which the entry is deleted by the
+	// leader unless it has been written again in the meantime.
+	TTL string `json:",omitempty"`
+
+	// LockWaiters are the sessions queued for the lock on this entry, in
+	// the order they will get it.
+	LockWaiters []string `json:",omitempty"`
+
 	RaftIndex
 }
 
 // Returns a clone of the given directory entry.
 func (d *DirEntry) Clone() *DirEntry {
-	return &DirEntry{
+	clone := &DirEntry{
 		LockIndex: d.LockIndex,
 		Key:       d.Key,
 		Flags:     d.Flags,
 		Value:     d.Value,
 		Session:   d.Session,
+		TTL:       d.TTL,
 		RaftIndex: RaftIndex{
 			CreateIndex: d.CreateIndex,
 			ModifyIndex: d.ModifyIndex,
 		},
 	}
+	if d.LockWaiters != nil {
+		clone.LockWaiters = append([]string(nil), d.LockWaiters...)
+	}
+	return clone
 }
 
 type DirEntries []*DirEntry
@@ -683,8 +805,9 @@ type IndexedKeyList struct {
 type SessionBehavior string
 
 const (
-	SessionKeysRelease SessionBehavior = "release"
-	SessionKeysDelete                  = "delete"
+	SessionKeysRelease  SessionBehavior = "release"
+	SessionKeysDelete                   = "delete"
+	SessionKeysTransfer                 = "transfer"
 )
 
 const (
@@ -719,6 +842,13 @@ type SessionRequest struct {
 	Datacenter string
 	Op         SessionOp // Which operation are we performing
 	Session    Session   // Which session
+
+	// Invalidate is set when a session is destroyed because its TTL
+	// expired, rather than by its owner. The locks of a session with the
+	// transfer behavior are then released subject to the lock-delay,
+	// instead of being handed to the next waiter.
+	Invalidate bool
+
 	WriteRequest
 }
 
@@ -747,6 +877,10 @@ type Coordinate struct {
 	Node    string
 	Segment string
 	Coord   *coordinate.Coordinate
+
+	// Updated is when the leader received the coordinate. This is zero for
+	// coordinates that were stored by older servers.
+	Updated time.Time
 }
 
 type Coordinates []*Coordinate
@@ -789,6 +923,81 @@ func (c *CoordinateUpdateRequest) RequestDatacenter() string {
 	return c.Datacenter
 }
 
+// CoordinateMatrixMaxNodes is the most nodes RTT estimates can be requested
+// between at once, since the number of estimates grows with its square.
+const CoordinateMatrixMaxNodes = 256
+
+// CoordinateMatrixRequest is used to request RTT estimates between the nodes
+// of a datacenter, computed from their network coordinates.
+type CoordinateMatrixRequest struct {
+	Datacenter string
+
+	// Nodes are the nodes to estimate the RTTs between, up to
+	// CoordinateMatrixMaxNodes. Only the RTTs to the datacenters are
+	// returned if this is empty.
+	Nodes []string
+
+	// MaxError and MaxAge are the limits above which the coordinate of a
+	// node is flagged as inaccurate or stale. The server's defaults are
+	// used when these are zero.
+	MaxError float64
+	MaxAge   time.Duration
+
+	QueryOptions
+}
+
+// RequestDatacenter returns the datacenter for a given request.
+func (c *CoordinateMatrixRequest) RequestDatacenter() string {
+	return c.Datacenter
+}
+
+// CoordinateStatus describes how far the coordinate of a node can be trusted.
+type CoordinateStatus struct {
+	Node    string
+	Segment string
+	Error   float64
+	Updated time.Time
+
+	// Inaccurate is set when the error of the coordinate is above the max
+	// error of the request.
+	Inaccurate bool
+
+	// Stale is set when the coordinate hasn't been updated within the max
+	// age of the request. Coordinates without an update time are never
+	// flagged.
+	Stale bool
+}
+
+// CoordinateRTT is an RTT estimate between two nodes, or two datacenters.
+type CoordinateRTT struct {
+	From string
+	To   string
+	RTT  time.Duration
+}
+
+// CoordinateNodeRTT is an RTT estimate between two nodes, along with the
+// status of the coordinates it was computed from.
+type CoordinateNodeRTT struct {
+	CoordinateRTT
+	Nodes []*CoordinateStatus
+}
+
+// CoordinateMatrix holds the RTT estimates between each pair of nodes in a
+// datacenter that have compatible coordinates, as well as the median RTT
+// estimates from the datacenter to each datacenter in the WAN pool.
+type CoordinateMatrix struct {
+	Nodes       []*CoordinateStatus
+	RTTs        []*CoordinateRTT
+	Datacenters []*CoordinateRTT
+}
+
+// IndexedCoordinateMatrix is used to return a coordinate matrix with the
+// index of the coordinates it was computed from.
+type IndexedCoordinateMatrix struct {
+	Matrix *CoordinateMatrix
+	QueryMeta
+}
+
 // EventFireRequest is used to ask a server to fire
 // a Serf event. It is a bit odd, since it doesn't depend on
 // the catalog or leader. Any node can respond, so it's not quite
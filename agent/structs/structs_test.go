@@ -5,6 +5,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/types"
@@ -39,6 +40,34 @@ func TestEncodeDecode(t *testing.T) {
 	}
 }
 
+func TestEncodeDecode_QueryOptionsCache(t *testing.T) {
+	arg := &ServiceSpecificRequest{
+		Datacenter:  "dc1",
+		ServiceName: "web",
+		QueryOptions: QueryOptions{
+			Token:    "foo",
+			UseCache: true,
+			MaxAge:   time.Minute,
+		},
+	}
+	buf, err := Encode(RegisterRequestType, arg)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The cache options are only for the agent, so they shouldn't make it
+	// to the servers.
+	var out ServiceSpecificRequest
+	if err := Decode(buf[1:], &out); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	want := *arg
+	want.UseCache, want.MaxAge = false, 0
+	if !reflect.DeepEqual(out, want) {
+		t.Fatalf("bad: %#v", out)
+	}
+}
+
 func TestStructs_Implements(t *testing.T) {
 	var (
 		_ RPCInfo          = &RegisterRequest{}
@@ -246,6 +275,14 @@ func TestStructs_NodeService_IsSame(t *testing.T) {
 	check(func() { other.Address = "XXX" }, func() { other.Address = "127.0.0.1" })
 	check(func() { other.Port = 9999 }, func() { other.Port = 1234 })
 	check(func() { other.EnableTagOverride = false }, func() { other.EnableTagOverride = true })
+	check(func() { other.Weights = &Weights{Passing: 10, Warning: 1} }, func() { other.Weights = nil })
+	check(func() {
+		ns.Weights = &Weights{Passing: 10, Warning: 1}
+		other.Weights = &Weights{Passing: 10, Warning: 2}
+	}, func() {
+		ns.Weights = nil
+		other.Weights = nil
+	})
 }
 
 func TestStructs_HealthCheck_IsSame(t *testing.T) {
@@ -517,6 +554,66 @@ func TestStructs_ValidateMetadata(t *testing.T) {
 	}
 }
 
+func TestStructs_ValidateServiceMetadata(t *testing.T) {
+	// Load a valid set of key/value pairs
+	meta := map[string]string{
+		"key1": "value1",
+		"key2": "value2",
+	}
+	// Should succeed
+	if err := ValidateServiceMetadata(meta, false); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// Should get error
+	meta = make(map[string]string)
+	for i := 0; i < metaMaxKeyPairs+1; i++ {
+		meta[fmt.Sprintf("key%d", i)] = "value"
+	}
+	err := ValidateServiceMetadata(meta, false)
+	if err == nil || !strings.Contains(err.Error(), "Service metadata cannot contain more than") {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Should fail without the reserved prefix allowed
+	meta = map[string]string{
+		metaKeyReservedPrefix + "key": "value1",
+	}
+	if err := ValidateServiceMetadata(meta, false); err == nil || !strings.Contains(err.Error(), "reserved for internal use") {
+		t.Fatalf("err: %s", err)
+	}
+	if err := ValidateServiceMetadata(meta, true); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+}
+
+func TestStructs_ValidateWeights(t *testing.T) {
+	cases := []struct {
+		weights *Weights
+		err     string
+	}{
+		{nil, ""},
+		{&Weights{Passing: 1, Warning: 0}, ""},
+		{&Weights{Passing: 65535, Warning: 65535}, ""},
+		{&Weights{Passing: 0, Warning: 1}, "Passing weight"},
+		{&Weights{Passing: 65536, Warning: 1}, "Passing weight"},
+		{&Weights{Passing: 1, Warning: -1}, "Warning weight"},
+		{&Weights{Passing: 1, Warning: 65536}, "Warning weight"},
+	}
+	for _, c := range cases {
+		err := ValidateWeights(c.weights)
+		if c.err == "" {
+			if err != nil {
+				t.Fatalf("%#v: err: %v", c.weights, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), c.err) {
+			t.Fatalf("%#v: got %v want %q", c.weights, err, c.err)
+		}
+	}
+}
+
 func TestStructs_validateMetaPair(t *testing.T) {
 	longKey := strings.Repeat("a", metaKeyMaxLength+1)
 	longValue := strings.Repeat("b", metaValueMaxLength+1)
@@ -17,10 +17,59 @@ type TxnKVOp struct {
 // inside a transaction.
 type TxnKVResult *DirEntry
 
+// TxnNodeOp is used to define a single operation on a node in the catalog
+// inside a transaction.
+type TxnNodeOp struct {
+	Verb api.NodeOp
+	Node Node
+}
+
+// TxnNodeResult is used to define the result of a single operation on a node
+// in the catalog inside a transaction.
+type TxnNodeResult *Node
+
+// TxnServiceOp is used to define a single operation on a service in the
+// catalog inside a transaction.
+type TxnServiceOp struct {
+	Verb    api.ServiceOp
+	Node    string
+	Service NodeService
+}
+
+// TxnServiceResult is used to define the result of a single operation on a
+// service in the catalog inside a transaction.
+type TxnServiceResult *NodeService
+
+// TxnCheckOp is used to define a single operation on a health check inside a
+// transaction.
+type TxnCheckOp struct {
+	Verb  api.CheckOp
+	Check HealthCheck
+}
+
+// TxnCheckResult is used to define the result of a single operation on a
+// health check inside a transaction.
+type TxnCheckResult *HealthCheck
+
+// TxnSessionOp is used to define a single operation on a session inside a
+// transaction.
+type TxnSessionOp struct {
+	Verb    api.SessionOp
+	Session Session
+}
+
+// TxnSessionResult is used to define the result of a single operation on a
+// session inside a transaction.
+type TxnSessionResult *Session
+
 // TxnOp is used to define a single operation inside a transaction. Only one
 // of the types should be filled out per entry.
 type TxnOp struct {
-	KV *TxnKVOp
+	KV      *TxnKVOp
+	Node    *TxnNodeOp
+	Service *TxnServiceOp
+	Check   *TxnCheckOp
+	Session *TxnSessionOp
 }
 
 // TxnOps is a list of operations within a transaction.
@@ -68,7 +117,11 @@ type TxnErrors []*TxnError
 // TxnResult is used to define the result of a given operation inside a
 // transaction. Only one of the types should be filled out per entry.
 type TxnResult struct {
-	KV TxnKVResult
+	KV      TxnKVResult      `json:",omitempty"`
+	Node    TxnNodeResult    `json:",omitempty"`
+	Service TxnServiceResult `json:",omitempty"`
+	Check   TxnCheckResult   `json:",omitempty"`
+	Session TxnSessionResult `json:",omitempty"`
 }
 
 // TxnResults is a list of TxnResult entries.
@@ -0,0 +1,82 @@
+package agent
+
+import (
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/consul/agent/config"
+	"github.com/hashicorp/consul/tlsutil"
+)
+
+const (
+	// tlsWatchInterval is how often the TLS files are checked for changes
+	// when they are reloaded automatically, and how often the expiry of the
+	// certificates is reported.
+	tlsWatchInterval = 10 * time.Second
+)
+
+// tlsFiles returns a tlsutil config with the TLS files of the runtime config.
+func tlsFiles(cfg *config.RuntimeConfig) *tlsutil.Config {
+	return &tlsutil.Config{
+		CAFile:   cfg.CAFile,
+		CAPath:   cfg.CAPath,
+		CertFile: cfg.CertFile,
+		KeyFile:  cfg.KeyFile,
+	}
+}
+
+// loadTLSCerts loads the TLS certificate and CAs of the config into the cert
+// store, which is shared by the RPC, Raft and HTTPS connections. A failed
+// load keeps the ones that were loaded before.
+func (a *Agent) loadTLSCerts(cfg *config.RuntimeConfig) error {
+	if a.tlsCerts == nil {
+		certs, err := tlsutil.NewCertStore(tlsFiles(cfg))
+		if err != nil {
+			return err
+		}
+		a.tlsCerts = certs
+	} else if err := a.tlsCerts.Update(tlsFiles(cfg)); err != nil {
+		return err
+	}
+	a.logTLSExpiry()
+	return nil
+}
+
+// logTLSExpiry logs when the loaded TLS certificate and CAs expire.
+func (a *Agent) logTLSExpiry() {
+	if t := a.tlsCerts.CertExpiry(); !t.IsZero() {
+		a.logger.Printf("[INFO] agent: Loaded TLS certificate, expires at %s", t.Format(time.RFC3339))
+	}
+	if t := a.tlsCerts.CAExpiry(); !t.IsZero() {
+		a.logger.Printf("[INFO] agent: Loaded TLS CA certificates, the first expires at %s", t.Format(time.RFC3339))
+	}
+}
+
+// watchTLSCerts reports the time left until the TLS certificate and CAs
+// expire, and reloads them when their files change if that's enabled. This
+// runs until the agent shuts down.
+func (a *Agent) watchTLSCerts() {
+	for {
+		select {
+		case <-time.After(tlsWatchInterval):
+		case <-a.shutdownCh:
+			return
+		}
+
+		if a.config.TLSAutoReload && a.tlsCerts.Changed() {
+			if err := a.tlsCerts.Reload(); err != nil {
+				a.logger.Printf("[ERR] agent: Failed to reload TLS certificates: %v", err)
+			} else {
+				a.logger.Printf("[INFO] agent: Reloaded TLS certificates after their files changed")
+				a.logTLSExpiry()
+			}
+		}
+
+		if t := a.tlsCerts.CertExpiry(); !t.IsZero() {
+			metrics.SetGauge([]string{"consul", "agent", "tls", "cert", "expiry"}, float32(time.Until(t).Seconds()))
+		}
+		if t := a.tlsCerts.CAExpiry(); !t.IsZero() {
+			metrics.SetGauge([]string{"consul", "agent", "tls", "ca", "expiry"}, float32(time.Until(t).Seconds()))
+		}
+	}
+}
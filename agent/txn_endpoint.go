@@ -8,6 +8,7 @@ import (
 
 	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/types"
 )
 
 const (
@@ -91,6 +92,129 @@ func isWrite(op api.KVOp) bool {
 	return false
 }
 
+// isWriteOp returns true if the given non-KV operation alters the state
+// store. Every operation except for a get is a write.
+func isWriteOp(op *api.TxnOp) bool {
+	switch {
+	case op.Node != nil:
+		return op.Node.Verb != api.NodeGet
+	case op.Service != nil:
+		return op.Service.Verb != api.ServiceGet
+	case op.Check != nil:
+		return op.Check.Verb != api.CheckGet
+	case op.Session != nil:
+		return op.Session.Verb != api.SessionGet
+	}
+	return false
+}
+
+// convertNodeOp converts a node operation from the API format to the
+// internal RPC format.
+func convertNodeOp(in *api.NodeTxnOp) *structs.TxnNodeOp {
+	node := in.Node
+	return &structs.TxnNodeOp{
+		Verb: in.Verb,
+		Node: structs.Node{
+			ID:              types.NodeID(node.ID),
+			Node:            node.Node,
+			Address:         node.Address,
+			Datacenter:      node.Datacenter,
+			TaggedAddresses: node.TaggedAddresses,
+			Meta:            node.Meta,
+			RaftIndex: structs.RaftIndex{
+				ModifyIndex: node.ModifyIndex,
+			},
+		},
+	}
+}
+
+// convertServiceOp converts a service operation from the API format to the
+// internal RPC format.
+func convertServiceOp(in *api.ServiceTxnOp) *structs.TxnServiceOp {
+	svc := in.Service
+	return &structs.TxnServiceOp{
+		Verb: in.Verb,
+		Node: in.Node,
+		Service: structs.NodeService{
+			ID:                svc.ID,
+			Service:           svc.Service,
+			Tags:              svc.Tags,
+			Address:           svc.Address,
+			Meta:              svc.Meta,
+			Port:              svc.Port,
+			Weights:           convertWeights(svc.Weights),
+			EnableTagOverride: svc.EnableTagOverride,
+			RaftIndex: structs.RaftIndex{
+				ModifyIndex: svc.ModifyIndex,
+			},
+		},
+	}
+}
+
+// convertWeights converts service weights from the API format to the internal
+// RPC format.
+func convertWeights(in *api.AgentWeights) *structs.Weights {
+	if in == nil {
+		return nil
+	}
+	return &structs.Weights{
+		Passing: in.Passing,
+		Warning: in.Warning,
+	}
+}
+
+// convertCheckOp converts a check operation from the API format to the
+// internal RPC format.
+func convertCheckOp(in *api.CheckTxnOp) *structs.TxnCheckOp {
+	check := in.Check
+	return &structs.TxnCheckOp{
+		Verb: in.Verb,
+		Check: structs.HealthCheck{
+			Node:        check.Node,
+			CheckID:     types.CheckID(check.CheckID),
+			Name:        check.Name,
+			Status:      check.Status,
+			Notes:       check.Notes,
+			Output:      check.Output,
+			ServiceID:   check.ServiceID,
+			ServiceName: check.ServiceName,
+			ServiceTags: check.ServiceTags,
+			RaftIndex: structs.RaftIndex{
+				ModifyIndex: check.ModifyIndex,
+			},
+		},
+	}
+}
+
+// convertSessionOp converts a session operation from the API format to the
+// internal RPC format.
+func convertSessionOp(in *api.SessionTxnOp) *structs.TxnSessionOp {
+	sess := in.Session
+	out := &structs.TxnSessionOp{
+		Verb: in.Verb,
+		Session: structs.Session{
+			ID:        sess.ID,
+			Name:      sess.Name,
+			Node:      sess.Node,
+			LockDelay: sess.LockDelay,
+			Behavior:  structs.SessionBehavior(sess.Behavior),
+			TTL:       sess.TTL,
+			RaftIndex: structs.RaftIndex{
+				ModifyIndex: sess.ModifyIndex,
+			},
+		},
+	}
+	// A missing list gets the default checks on the server, so an empty
+	// one has to stay empty.
+	if sess.Checks != nil {
+		out.Session.Checks = make([]types.CheckID, 0, len(sess.Checks))
+	}
+	for _, checkID := range sess.Checks {
+		out.Session.Checks = append(out.Session.Checks, types.CheckID(checkID))
+	}
+	return out
+}
+
 // convertOps takes the incoming body in API format and converts it to the
 // internal RPC format. This returns a count of the number of write ops, and
 // a boolean, that if false means an error response has been generated and
@@ -116,7 +240,7 @@ func (s *HTTPServer) convertOps(resp http.ResponseWriter, req *http.Request) (st
 		return nil, 0, false
 	}
 
-	// Convert the KV API format into the RPC format. Note that fixupKVOps
+	// Convert the API format into the RPC format. Note that fixupKVOps
 	// above will have already converted the base64 encoded strings into
 	// byte arrays so we can assign right over.
 	var opsRPC structs.TxnOps
@@ -145,6 +269,7 @@ func (s *HTTPServer) convertOps(resp http.ResponseWriter, req *http.Request) (st
 						Value:   in.KV.Value,
 						Flags:   in.KV.Flags,
 						Session: in.KV.Session,
+						TTL:     in.KV.TTL,
 						RaftIndex: structs.RaftIndex{
 							ModifyIndex: in.KV.Index,
 						},
@@ -152,6 +277,30 @@ func (s *HTTPServer) convertOps(resp http.ResponseWriter, req *http.Request) (st
 				},
 			}
 			opsRPC = append(opsRPC, out)
+			continue
+		}
+
+		if isWriteOp(in) {
+			writes++
+		}
+
+		switch {
+		case in.Node != nil:
+			opsRPC = append(opsRPC, &structs.TxnOp{Node: convertNodeOp(in.Node)})
+
+		case in.Service != nil:
+			opsRPC = append(opsRPC, &structs.TxnOp{Service: convertServiceOp(in.Service)})
+
+		case in.Check != nil:
+			opsRPC = append(opsRPC, &structs.TxnOp{Check: convertCheckOp(in.Check)})
+
+		case in.Session != nil:
+			opsRPC = append(opsRPC, &structs.TxnOp{Session: convertSessionOp(in.Session)})
+
+		default:
+			// Keep the op indexes lined up with the request so errors
+			// point at the right operation.
+			opsRPC = append(opsRPC, &structs.TxnOp{})
 		}
 	}
 
@@ -8,8 +8,10 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/types"
 )
 
 func TestTxnEndpoint_Bad_JSON(t *testing.T) {
@@ -397,3 +399,146 @@ func TestTxnEndpoint_KV_Actions(t *testing.T) {
 		}
 	})
 }
+
+func TestTxnEndpoint_Catalog_Actions(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t.Name(), "")
+	defer a.Shutdown()
+
+	// Make sure all incoming fields get converted properly to the internal
+	// RPC format.
+	buf := bytes.NewBuffer([]byte(`
+ [
+     {
+         "Node": {
+             "Verb": "set",
+             "Node": {
+                 "ID": "67539c9d-b948-ba67-edd4-d07a676d6673",
+                 "Node": "bar",
+                 "Address": "192.168.0.1",
+                 "Datacenter": "dc1",
+                 "TaggedAddresses": {"wan": "1.2.3.4"},
+                 "Meta": {"instance_type": "t2.micro"}
+             }
+         }
+     },
+     {
+         "Service": {
+             "Verb": "set",
+             "Node": "bar",
+             "Service": {
+                 "ID": "redis1",
+                 "Service": "redis",
+                 "Tags": ["master"],
+                 "Address": "192.168.0.1",
+                 "Port": 6379
+             }
+         }
+     },
+     {
+         "Check": {
+             "Verb": "cas",
+             "Check": {
+                 "Node": "bar",
+                 "CheckID": "redis:alive",
+                 "Name": "Redis alive",
+                 "Status": "passing",
+                 "ServiceID": "redis1"
+             }
+         }
+     },
+     {
+         "Session": {
+             "Verb": "create",
+             "Session": {
+                 "ID": "adf4238a-882b-9ddc-4a9d-5b6758e4159e",
+                 "Node": "bar",
+                 "Checks": ["redis:alive"],
+                 "LockDelay": 1000000000,
+                 "Behavior": "delete"
+             }
+         }
+     }
+ ]
+ `))
+	req, _ := http.NewRequest("PUT", "/v1/txn", buf)
+	resp := httptest.NewRecorder()
+	obj, err := a.srv.Txn(resp, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Code != 200 {
+		t.Fatalf("expected 200, got %d: %s", resp.Code, resp.Body.String())
+	}
+
+	txnResp, ok := obj.(structs.TxnResponse)
+	if !ok {
+		t.Fatalf("bad type: %T", obj)
+	}
+	if len(txnResp.Results) != 4 {
+		t.Fatalf("bad: %v", txnResp)
+	}
+	index := txnResp.Results[0].Node.ModifyIndex
+	expected := structs.TxnResponse{
+		Results: structs.TxnResults{
+			&structs.TxnResult{
+				Node: &structs.Node{
+					ID:              "67539c9d-b948-ba67-edd4-d07a676d6673",
+					Node:            "bar",
+					Address:         "192.168.0.1",
+					Datacenter:      "dc1",
+					TaggedAddresses: map[string]string{"wan": "1.2.3.4"},
+					Meta:            map[string]string{"instance_type": "t2.micro"},
+					RaftIndex: structs.RaftIndex{
+						CreateIndex: index,
+						ModifyIndex: index,
+					},
+				},
+			},
+			&structs.TxnResult{
+				Service: &structs.NodeService{
+					ID:      "redis1",
+					Service: "redis",
+					Tags:    []string{"master"},
+					Address: "192.168.0.1",
+					Port:    6379,
+					RaftIndex: structs.RaftIndex{
+						CreateIndex: index,
+						ModifyIndex: index,
+					},
+				},
+			},
+			&structs.TxnResult{
+				Check: &structs.HealthCheck{
+					Node:        "bar",
+					CheckID:     "redis:alive",
+					Name:        "Redis alive",
+					Status:      "passing",
+					ServiceID:   "redis1",
+					ServiceName: "redis",
+					ServiceTags: []string{"master"},
+					RaftIndex: structs.RaftIndex{
+						CreateIndex: index,
+						ModifyIndex: index,
+					},
+				},
+			},
+			&structs.TxnResult{
+				Session: &structs.Session{
+					ID:        "adf4238a-882b-9ddc-4a9d-5b6758e4159e",
+					Node:      "bar",
+					Checks:    []types.CheckID{"redis:alive"},
+					LockDelay: time.Second,
+					Behavior:  structs.SessionKeysDelete,
+					RaftIndex: structs.RaftIndex{
+						CreateIndex: index,
+						ModifyIndex: index,
+					},
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(txnResp, expected) {
+		t.Fatalf("bad: %v", txnResp)
+	}
+}
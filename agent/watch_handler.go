@@ -2,16 +2,20 @@ package agent
 
 import (
 	"bytes"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
+	"net/http"
 	"os"
 	"os/exec"
 	"strconv"
+	"time"
 
 	"github.com/armon/circbuf"
 	"github.com/hashicorp/consul/watch"
+	"github.com/hashicorp/go-cleanhttp"
 )
 
 const (
@@ -19,6 +23,14 @@ const (
 	// last WatchBufSize. Prevents an enormous buffer
 	// from being captured
 	WatchBufSize = 4 * 1024 // 4KB
+
+	// WatchHTTPRetries is the number of times an HTTP watch handler request
+	// is retried after a failure before giving up.
+	WatchHTTPRetries = 3
+
+	// watchHTTPRetryInterval is the base interval between retries of an
+	// HTTP watch handler request, which is doubled after each attempt.
+	watchHTTPRetryInterval = 1 * time.Second
 )
 
 // makeWatchHandler returns a handler for the given watch
@@ -87,3 +99,93 @@ func makeWatchHandler(logOutput io.Writer, handler interface{}) watch.HandlerFun
 	}
 	return fn
 }
+
+// makeHTTPWatchHandler returns a handler which sends the watch data to the
+// HTTP endpoint given in the config.
+func makeHTTPWatchHandler(logOutput io.Writer, config *watch.HTTPHandlerConfig) watch.HandlerFunc {
+	logger := log.New(logOutput, "", log.LstdFlags)
+	fn := func(idx uint64, data interface{}) {
+		output, err := PostWatchData(config, idx, data)
+		if err != nil {
+			logger.Printf("[ERR] agent: Failed to run watch handler '%s': %v", config.Path, err)
+			return
+		}
+		logger.Printf("[DEBUG] agent: watch handler '%s' output: %s", config.Path, output)
+	}
+	return fn
+}
+
+// PostWatchData sends the JSON encoded watch data to the HTTP endpoint given
+// in the config, along with the index in the X-Consul-Index header. Failed
+// requests and responses with a 429 or 5xx status code are retried with an
+// exponential backoff. The (possibly truncated) response body is returned.
+func PostWatchData(config *watch.HTTPHandlerConfig, idx uint64, data interface{}) (string, error) {
+	var inp bytes.Buffer
+	if err := json.NewEncoder(&inp).Encode(data); err != nil {
+		return "", fmt.Errorf("failed to encode data: %v", err)
+	}
+
+	trans := cleanhttp.DefaultTransport()
+	trans.TLSClientConfig = &tls.Config{
+		InsecureSkipVerify: config.TLSSkipVerify,
+	}
+	client := &http.Client{
+		Transport: trans,
+		Timeout:   config.Timeout,
+	}
+
+	var output string
+	var err error
+	var retry bool
+	wait := watchHTTPRetryInterval
+	for attempt := 0; attempt <= WatchHTTPRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(wait)
+			wait *= 2
+		}
+		output, retry, err = postWatchData(client, config, idx, inp.Bytes())
+		if err == nil || !retry {
+			break
+		}
+	}
+	return output, err
+}
+
+// postWatchData makes a single request for PostWatchData, returning whether
+// it is worth retrying if it failed.
+func postWatchData(client *http.Client, config *watch.HTTPHandlerConfig, idx uint64, body []byte) (string, bool, error) {
+	req, err := http.NewRequest(config.Method, config.Path, bytes.NewReader(body))
+	if err != nil {
+		return "", false, err
+	}
+	for key, values := range config.Header {
+		for _, val := range values {
+			req.Header.Add(key, val)
+		}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Consul-Index", strconv.FormatUint(idx, 10))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", true, err
+	}
+	defer resp.Body.Close()
+
+	// Collect the output
+	output, _ := circbuf.NewBuffer(WatchBufSize)
+	if _, err := io.Copy(output, resp.Body); err != nil {
+		return "", true, err
+	}
+	outputStr := string(output.Bytes())
+	if output.TotalWritten() > output.Size() {
+		outputStr = fmt.Sprintf("Captured %d of %d bytes\n...\n%s",
+			output.Size(), output.TotalWritten(), outputStr)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		return outputStr, retry, fmt.Errorf("unexpected response code %d: %s", resp.StatusCode, outputStr)
+	}
+	return outputStr, false, nil
+}
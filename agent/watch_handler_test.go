@@ -2,8 +2,14 @@ package agent
 
 import (
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/watch"
 )
 
 func TestMakeWatchHandler(t *testing.T) {
@@ -28,3 +34,83 @@ func TestMakeWatchHandler(t *testing.T) {
 		t.Fatalf("bad: %s", raw)
 	}
 }
+
+func TestMakeHTTPWatchHandler(t *testing.T) {
+	t.Parallel()
+	var called int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&called, 1)
+		if r.Method != "PUT" {
+			t.Errorf("bad: %s", r.Method)
+		}
+		if got, want := r.Header.Get("X-Consul-Index"), "100"; got != want {
+			t.Errorf("got %q want %q", got, want)
+		}
+		if got, want := r.Header.Get("X-Custom"), "abc"; got != want {
+			t.Errorf("got %q want %q", got, want)
+		}
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("err: %v", err)
+		}
+		if got, want := string(body), "[\"foo\",\"bar\",\"baz\"]\n"; got != want {
+			t.Errorf("got %q want %q", got, want)
+		}
+		w.Write([]byte("Ok, i see"))
+	}))
+	defer server.Close()
+	config := watch.HTTPHandlerConfig{
+		Path:    server.URL,
+		Method:  "PUT",
+		Header:  map[string][]string{"X-Custom": []string{"abc"}},
+		Timeout: time.Minute,
+	}
+	handler := makeHTTPWatchHandler(os.Stderr, &config)
+	handler(100, []string{"foo", "bar", "baz"})
+	if got := atomic.LoadInt32(&called); got != 1 {
+		t.Fatalf("got %d calls want 1", got)
+	}
+}
+
+func TestPostWatchData_Retry(t *testing.T) {
+	t.Parallel()
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&calls, 1) {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.Write([]byte("done"))
+		}
+	}))
+	defer server.Close()
+	config := watch.HTTPHandlerConfig{Path: server.URL, Method: "POST", Timeout: time.Minute}
+	output, err := PostWatchData(&config, 1, "foo")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if output != "done" {
+		t.Fatalf("bad: %q", output)
+	}
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Fatalf("got %d calls want 2", got)
+	}
+}
+
+func TestPostWatchData_NoRetryOnClientError(t *testing.T) {
+	t.Parallel()
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("nope"))
+	}))
+	defer server.Close()
+	config := watch.HTTPHandlerConfig{Path: server.URL, Method: "POST", Timeout: time.Minute}
+	if _, err := PostWatchData(&config, 1, "foo"); err == nil || err.Error() != "unexpected response code 400: nope" {
+		t.Fatalf("err: %v", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Fatalf("got %d calls want 1", got)
+	}
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"fmt"
 	"time"
 )
 
@@ -20,6 +21,26 @@ type ACLEntry struct {
 	Name        string
 	Type        string
 	Rules       string
+	Policies    []ACLTokenPolicyLink `json:",omitempty"`
+}
+
+// ACLTokenPolicyLink links a token to a policy. Either the ID or the name of
+// the policy can be given when creating or updating a token.
+type ACLTokenPolicyLink struct {
+	ID   string `json:",omitempty"`
+	Name string `json:",omitempty"`
+}
+
+// ACLPolicy is used to represent a reusable set of ACL rules that can be
+// linked to tokens.
+type ACLPolicy struct {
+	CreateIndex uint64
+	ModifyIndex uint64
+	ID          string
+	Name        string
+	Description string
+	Rules       string
+	Datacenters []string `json:",omitempty"`
 }
 
 // ACLReplicationStatus is used to represent the status of ACL replication.
@@ -191,3 +212,202 @@ func (a *ACL) Replication(q *QueryOptions) (*ACLReplicationStatus, *QueryMeta, e
 	}
 	return entries, qm, nil
 }
+
+// PolicyCreate is used to create a new ACL policy. The ID is generated by
+// the servers and returned.
+func (a *ACL) PolicyCreate(policy *ACLPolicy, q *WriteOptions) (string, *WriteMeta, error) {
+	return a.policyWrite("/v1/acl/policy", policy, q)
+}
+
+// PolicyUpdate is used to update an existing ACL policy.
+func (a *ACL) PolicyUpdate(policy *ACLPolicy, q *WriteOptions) (*WriteMeta, error) {
+	if policy.ID == "" {
+		return nil, fmt.Errorf("Must specify an ID for policy update")
+	}
+	_, wm, err := a.policyWrite("/v1/acl/policy/"+policy.ID, policy, q)
+	return wm, err
+}
+
+func (a *ACL) policyWrite(endpoint string, policy *ACLPolicy, q *WriteOptions) (string, *WriteMeta, error) {
+	r := a.c.newRequest("PUT", endpoint)
+	r.setWriteOptions(q)
+	r.obj = policy
+	rtt, resp, err := requireOK(a.c.doRequest(r))
+	if err != nil {
+		return "", nil, err
+	}
+	defer resp.Body.Close()
+
+	wm := &WriteMeta{RequestTime: rtt}
+	var out struct{ ID string }
+	if err := decodeBody(resp, &out); err != nil {
+		return "", nil, err
+	}
+	return out.ID, wm, nil
+}
+
+// PolicyDelete is used to delete an ACL policy. Tokens linked to the policy
+// are left alone, but the link no longer grants anything.
+func (a *ACL) PolicyDelete(id string, q *WriteOptions) (*WriteMeta, error) {
+	return a.delete("/v1/acl/policy/"+id, q)
+}
+
+// PolicyRead is used to look up an ACL policy by ID. A nil policy is
+// returned if it doesn't exist.
+func (a *ACL) PolicyRead(id string, q *QueryOptions) (*ACLPolicy, *QueryMeta, error) {
+	var out ACLPolicy
+	found, qm, err := a.read("/v1/acl/policy/"+id, q, &out)
+	if err != nil || !found {
+		return nil, qm, err
+	}
+	return &out, qm, nil
+}
+
+// PolicyList is used to get all the ACL policies.
+func (a *ACL) PolicyList(q *QueryOptions) ([]*ACLPolicy, *QueryMeta, error) {
+	r := a.c.newRequest("GET", "/v1/acl/policies")
+	r.setQueryOptions(q)
+	rtt, resp, err := requireOK(a.c.doRequest(r))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	qm := &QueryMeta{}
+	parseQueryMeta(resp, qm)
+	qm.RequestTime = rtt
+
+	var entries []*ACLPolicy
+	if err := decodeBody(resp, &entries); err != nil {
+		return nil, nil, err
+	}
+	return entries, qm, nil
+}
+
+// TokenCreate is used to create a new token, which may be linked to ACL
+// policies. The type defaults to a client token.
+func (a *ACL) TokenCreate(token *ACLEntry, q *WriteOptions) (string, *WriteMeta, error) {
+	return a.tokenWrite("/v1/acl/token", token, q)
+}
+
+// TokenUpdate is used to update an existing token. The whole token is
+// replaced, including its policy links.
+func (a *ACL) TokenUpdate(token *ACLEntry, q *WriteOptions) (*WriteMeta, error) {
+	if token.ID == "" {
+		return nil, fmt.Errorf("Must specify an ID for token update")
+	}
+	_, wm, err := a.tokenWrite("/v1/acl/token/"+token.ID, token, q)
+	return wm, err
+}
+
+func (a *ACL) tokenWrite(endpoint string, token *ACLEntry, q *WriteOptions) (string, *WriteMeta, error) {
+	r := a.c.newRequest("PUT", endpoint)
+	r.setWriteOptions(q)
+	r.obj = token
+	rtt, resp, err := requireOK(a.c.doRequest(r))
+	if err != nil {
+		return "", nil, err
+	}
+	defer resp.Body.Close()
+
+	wm := &WriteMeta{RequestTime: rtt}
+	var out struct{ ID string }
+	if err := decodeBody(resp, &out); err != nil {
+		return "", nil, err
+	}
+	return out.ID, wm, nil
+}
+
+// TokenDelete is used to delete a token.
+func (a *ACL) TokenDelete(id string, q *WriteOptions) (*WriteMeta, error) {
+	return a.delete("/v1/acl/token/"+id, q)
+}
+
+// TokenRead is used to look up a token by ID. A nil token is returned if it
+// doesn't exist.
+func (a *ACL) TokenRead(id string, q *QueryOptions) (*ACLEntry, *QueryMeta, error) {
+	var out ACLEntry
+	found, qm, err := a.read("/v1/acl/token/"+id, q, &out)
+	if err != nil || !found {
+		return nil, qm, err
+	}
+	return &out, qm, nil
+}
+
+// TokenList is used to get all the tokens.
+func (a *ACL) TokenList(q *QueryOptions) ([]*ACLEntry, *QueryMeta, error) {
+	r := a.c.newRequest("GET", "/v1/acl/tokens")
+	r.setQueryOptions(q)
+	rtt, resp, err := requireOK(a.c.doRequest(r))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	qm := &QueryMeta{}
+	parseQueryMeta(resp, qm)
+	qm.RequestTime = rtt
+
+	var entries []*ACLEntry
+	if err := decodeBody(resp, &entries); err != nil {
+		return nil, nil, err
+	}
+	return entries, qm, nil
+}
+
+// TokenUpgrade moves the rules of a legacy token into a policy and links the
+// token to it. Tokens that are already linked to policies are returned as-is.
+func (a *ACL) TokenUpgrade(id string, q *WriteOptions) (*ACLEntry, *WriteMeta, error) {
+	r := a.c.newRequest("PUT", "/v1/acl/upgrade/"+id)
+	r.setWriteOptions(q)
+	rtt, resp, err := requireOK(a.c.doRequest(r))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	wm := &WriteMeta{RequestTime: rtt}
+	var out ACLEntry
+	if err := decodeBody(resp, &out); err != nil {
+		return nil, nil, err
+	}
+	return &out, wm, nil
+}
+
+func (a *ACL) delete(endpoint string, q *WriteOptions) (*WriteMeta, error) {
+	r := a.c.newRequest("DELETE", endpoint)
+	r.setWriteOptions(q)
+	rtt, resp, err := requireOK(a.c.doRequest(r))
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	wm := &WriteMeta{RequestTime: rtt}
+	return wm, nil
+}
+
+// read decodes a single object into out, returning false if it wasn't found.
+func (a *ACL) read(endpoint string, q *QueryOptions, out interface{}) (bool, *QueryMeta, error) {
+	r := a.c.newRequest("GET", endpoint)
+	r.setQueryOptions(q)
+	rtt, resp, err := a.c.doRequest(r)
+	if err != nil {
+		return false, nil, err
+	}
+	defer resp.Body.Close()
+
+	qm := &QueryMeta{}
+	parseQueryMeta(resp, qm)
+	qm.RequestTime = rtt
+
+	if resp.StatusCode == 404 {
+		return false, qm, nil
+	} else if resp.StatusCode != 200 {
+		return false, nil, fmt.Errorf("Unexpected response code: %d", resp.StatusCode)
+	}
+	if err := decodeBody(resp, out); err != nil {
+		return false, nil, err
+	}
+	return true, qm, nil
+}
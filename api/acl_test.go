@@ -162,3 +162,161 @@ func TestAPI_ACLReplication(t *testing.T) {
 		t.Fatalf("bad: %v", qm)
 	}
 }
+
+func TestAPI_ACLPolicy_CRUD(t *testing.T) {
+	t.Parallel()
+	c, s := makeACLClient(t)
+	defer s.Stop()
+
+	acl := c.ACL()
+
+	policy := &ACLPolicy{
+		Name:        "web",
+		Description: "Web service",
+		Rules:       `service "web" { policy = "write" }`,
+	}
+	id, wm, err := acl.PolicyCreate(policy, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if wm.RequestTime == 0 {
+		t.Fatalf("bad: %v", wm)
+	}
+	if id == "" {
+		t.Fatalf("invalid: %v", id)
+	}
+
+	policy.ID = id
+	policy.Rules = `service "web" { policy = "read" }`
+	if _, err := acl.PolicyUpdate(policy, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	out, _, err := acl.PolicyRead(id, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.Name != policy.Name || out.Description != policy.Description || out.Rules != policy.Rules {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	policies, qm, err := acl.PolicyList(nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(policies) != 1 || policies[0].ID != id {
+		t.Fatalf("bad: %v", policies)
+	}
+	if qm.LastIndex == 0 {
+		t.Fatalf("bad: %v", qm)
+	}
+
+	if _, err := acl.PolicyDelete(id, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	out, _, err = acl.PolicyRead(id, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out != nil {
+		t.Fatalf("bad: %#v", out)
+	}
+}
+
+func TestAPI_ACLToken_CRUD(t *testing.T) {
+	t.Parallel()
+	c, s := makeACLClient(t)
+	defer s.Stop()
+
+	acl := c.ACL()
+
+	policyID, _, err := acl.PolicyCreate(&ACLPolicy{
+		Name:  "web",
+		Rules: `service "web" { policy = "write" }`,
+	}, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	token := &ACLEntry{
+		Name:     "web token",
+		Policies: []ACLTokenPolicyLink{{Name: "web"}},
+	}
+	id, _, err := acl.TokenCreate(token, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	out, _, err := acl.TokenRead(id, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.Type != ACLClientType || len(out.Policies) != 1 ||
+		out.Policies[0].ID != policyID || out.Policies[0].Name != "web" {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	token.ID = id
+	token.Name = "renamed"
+	if _, err := acl.TokenUpdate(token, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	tokens, _, err := acl.TokenList(nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	found := false
+	for _, tok := range tokens {
+		if tok.ID == id && tok.Name == "renamed" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("bad: %v", tokens)
+	}
+
+	if _, err := acl.TokenDelete(id, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	out, _, err = acl.TokenRead(id, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out != nil {
+		t.Fatalf("bad: %#v", out)
+	}
+}
+
+func TestAPI_ACLTokenUpgrade(t *testing.T) {
+	t.Parallel()
+	c, s := makeACLClient(t)
+	defer s.Stop()
+
+	acl := c.ACL()
+
+	id, _, err := acl.Create(&ACLEntry{
+		Name:  "legacy",
+		Type:  ACLClientType,
+		Rules: `key "foo/" { policy = "write" }`,
+	}, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	token, _, err := acl.TokenUpgrade(id, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if token.ID != id || token.Rules != "" || len(token.Policies) != 1 {
+		t.Fatalf("bad: %#v", token)
+	}
+
+	policy, _, err := acl.PolicyRead(token.Policies[0].ID, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if policy.Rules != `key "foo/" { policy = "write" }` {
+		t.Fatalf("bad: %#v", policy)
+	}
+}
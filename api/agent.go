@@ -15,6 +15,12 @@ type AgentCheck struct {
 	Output      string
 	ServiceID   string
 	ServiceName string
+
+	// PendingStatus is the status the check returned that hasn't met its
+	// consecutive result threshold yet, and Suppressed is true while flap
+	// damping holds back the status changes of the check.
+	PendingStatus string
+	Suppressed    bool
 }
 
 // AgentService represents a service known to the agent
@@ -22,13 +28,22 @@ type AgentService struct {
 	ID                string
 	Service           string
 	Tags              []string
+	Meta              map[string]string
 	Port              int
 	Address           string
+	Weights           *AgentWeights `json:",omitempty"`
 	EnableTagOverride bool
 	CreateIndex       uint64
 	ModifyIndex       uint64
 }
 
+// AgentWeights are the DNS SRV weights of a service instance, depending on
+// whether its checks are passing or warning.
+type AgentWeights struct {
+	Passing int
+	Warning int
+}
+
 // AgentMember represents a cluster member known to the agent
 type AgentMember struct {
 	Name        string
@@ -59,12 +74,14 @@ type MembersOpts struct {
 
 // AgentServiceRegistration is used to register a new service
 type AgentServiceRegistration struct {
-	ID                string   `json:",omitempty"`
-	Name              string   `json:",omitempty"`
-	Tags              []string `json:",omitempty"`
-	Port              int      `json:",omitempty"`
-	Address           string   `json:",omitempty"`
-	EnableTagOverride bool     `json:",omitempty"`
+	ID                string            `json:",omitempty"`
+	Name              string            `json:",omitempty"`
+	Tags              []string          `json:",omitempty"`
+	Port              int               `json:",omitempty"`
+	Address           string            `json:",omitempty"`
+	EnableTagOverride bool              `json:",omitempty"`
+	Meta              map[string]string `json:",omitempty"`
+	Weights           *AgentWeights     `json:",omitempty"`
 	Check             *AgentServiceCheck
 	Checks            AgentServiceChecks
 }
@@ -80,7 +97,13 @@ type AgentCheckRegistration struct {
 
 // AgentServiceCheck is used to define a node or service level check
 type AgentServiceCheck struct {
+	// CheckID and Name are only used for the checks of a service
+	// registration, where they default to ones derived from the service.
+	CheckID string `json:",omitempty"`
+	Name    string `json:",omitempty"`
+
 	Script            string              `json:",omitempty"`
+	ScriptArgs        []string            `json:",omitempty"`
 	DockerContainerID string              `json:",omitempty"`
 	Shell             string              `json:",omitempty"` // Only supported for Docker.
 	Interval          string              `json:",omitempty"`
@@ -90,6 +113,8 @@ type AgentServiceCheck struct {
 	Header            map[string][]string `json:",omitempty"`
 	Method            string              `json:",omitempty"`
 	TCP               string              `json:",omitempty"`
+	GRPC              string              `json:",omitempty"`
+	GRPCUseTLS        bool                `json:",omitempty"`
 	Status            string              `json:",omitempty"`
 	Notes             string              `json:",omitempty"`
 	TLSSkipVerify     bool                `json:",omitempty"`
@@ -101,6 +126,17 @@ type AgentServiceCheck struct {
 	// then its associated service (and all of its associated checks) will
 	// automatically be deregistered.
 	DeregisterCriticalServiceAfter string `json:",omitempty"`
+
+	// SuccessBeforePassing and FailuresBeforeCritical are the number of
+	// results in a row an interval based check must return before it
+	// changes between critical and passing or warning. FlapHalfLife
+	// enables flap damping, and FlapSuppressWindow limits how long a
+	// flapping check has its status changes suppressed. Both are in the
+	// same Go time format as Interval.
+	SuccessBeforePassing   int    `json:",omitempty"`
+	FailuresBeforeCritical int    `json:",omitempty"`
+	FlapHalfLife           string `json:",omitempty"`
+	FlapSuppressWindow     string `json:",omitempty"`
 }
 type AgentServiceChecks []*AgentServiceCheck
 
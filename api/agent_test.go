@@ -4,11 +4,13 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/hashicorp/consul/testutil"
+	"github.com/hashicorp/consul/testutil/retry"
 	"github.com/hashicorp/serf/serf"
 )
 
@@ -184,6 +186,52 @@ func TestAPI_AgentServices(t *testing.T) {
 	}
 }
 
+func TestAPI_AgentServices_Weights(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	agent := c.Agent()
+
+	reg := &AgentServiceRegistration{
+		Name: "foo",
+		Port: 8000,
+		Weights: &AgentWeights{
+			Passing: 10,
+			Warning: 2,
+		},
+	}
+	if err := agent.ServiceRegister(reg); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	services, err := agent.Services()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	svc, ok := services["foo"]
+	if !ok {
+		t.Fatalf("missing service: %v", services)
+	}
+	if !reflect.DeepEqual(svc.Weights, reg.Weights) {
+		t.Fatalf("bad: %#v", svc.Weights)
+	}
+
+	// The weights should make it into the catalog.
+	retry.Run(t, func(r *retry.R) {
+		nodes, _, err := c.Catalog().Service("foo", "", nil)
+		if err != nil {
+			r.Fatal(err)
+		}
+		if len(nodes) != 1 {
+			r.Fatalf("bad: %v", nodes)
+		}
+		if !reflect.DeepEqual(nodes[0].ServiceWeights, reg.Weights) {
+			r.Fatalf("bad: %#v", nodes[0].ServiceWeights)
+		}
+	})
+}
+
 func TestAPI_AgentServices_CheckPassing(t *testing.T) {
 	t.Parallel()
 	c, s := makeClient(t)
@@ -579,6 +627,39 @@ func TestAPI_AgentChecks_serviceBound(t *testing.T) {
 	}
 }
 
+func TestAPI_AgentChecks_GRPC(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	agent := c.Agent()
+
+	reg := &AgentCheckRegistration{
+		Name: "grpccheck",
+		AgentServiceCheck: AgentServiceCheck{
+			GRPC:          "localhost:12345/my.Service",
+			GRPCUseTLS:    true,
+			TLSSkipVerify: true,
+			Interval:      "10s",
+		},
+	}
+	if err := agent.CheckRegister(reg); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	checks, err := agent.Checks()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, ok := checks["grpccheck"]; !ok {
+		t.Fatalf("missing check: %v", checks)
+	}
+
+	if err := agent.CheckDeregister("grpccheck"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
+
 func TestAPI_AgentChecks_Docker(t *testing.T) {
 	t.Parallel()
 	c, s := makeClientWithConfig(t, nil, func(c *testutil.TestServerConfig) {
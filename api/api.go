@@ -101,6 +101,28 @@ type QueryOptions struct {
 	// be provided for filtering.
 	NodeMeta map[string]string
 
+	// ServiceMeta is used to filter service results by instances with the
+	// given metadata key/value pairs. This is only supported by the
+	// catalog and health service endpoints.
+	ServiceMeta map[string]string
+
+	// Filter is an expression used to select the results, such as
+	// `Service.Tags contains primary and Checks.Status == passing`. This
+	// is only supported by the catalog node and service list endpoints
+	// and the health endpoints.
+	Filter string
+
+	// UseCache requests that the agent answer the query from its local
+	// cache, which is kept up to date in the background. This is only
+	// supported by the health and catalog service endpoints and prepared
+	// query execution.
+	UseCache bool
+
+	// MaxAge limits how old a cached result may be when UseCache is set.
+	// Older results are fetched again from the servers. It's sent in
+	// whole seconds.
+	MaxAge time.Duration
+
 	// RelayFactor is used in keyring operations to cause reponses to be
 	// relayed back to the sender through N other random nodes. Must be
 	// a value from 0 to 5 (inclusive).
@@ -181,6 +203,12 @@ type QueryMeta struct {
 
 	// Is address translation enabled for HTTP responses on this agent
 	AddressTranslationEnabled bool
+
+	// CacheHit is true if the result was served from the agent cache.
+	CacheHit bool
+
+	// CacheAge is the age of the cached result, if CacheHit is true.
+	CacheAge time.Duration
 }
 
 // WriteMeta is used to return meta data about a write
@@ -534,6 +562,20 @@ func (r *request) setQueryOptions(q *QueryOptions) {
 			r.params.Add("node-meta", key+":"+value)
 		}
 	}
+	if len(q.ServiceMeta) > 0 {
+		for key, value := range q.ServiceMeta {
+			r.params.Add("service-meta", key+":"+value)
+		}
+	}
+	if q.Filter != "" {
+		r.params.Set("filter", q.Filter)
+	}
+	if q.UseCache {
+		r.params.Set("cached", "")
+		if q.MaxAge > 0 {
+			r.header.Set("Cache-Control", fmt.Sprintf("max-age=%d", int64(q.MaxAge/time.Second)))
+		}
+	}
 	if q.RelayFactor != 0 {
 		r.params.Set("relay-factor", strconv.Itoa(int(q.RelayFactor)))
 	}
@@ -737,6 +779,15 @@ func parseQueryMeta(resp *http.Response, q *QueryMeta) error {
 		q.AddressTranslationEnabled = false
 	}
 
+	// Parse the cache headers, which are only set if the agent cache was
+	// asked for.
+	if header.Get("X-Cache") == "HIT" {
+		q.CacheHit = true
+		if age, err := strconv.ParseUint(header.Get("Age"), 10, 64); err == nil {
+			q.CacheAge = time.Duration(age) * time.Second
+		}
+	}
+
 	return nil
 }
 
@@ -382,6 +382,9 @@ func TestAPI_SetQueryOptions(t *testing.T) {
 		WaitTime:          100 * time.Second,
 		Token:             "12345",
 		Near:              "nodex",
+		Filter:            "Node == nodex",
+		UseCache:          true,
+		MaxAge:            30 * time.Second,
 	}
 	r.setQueryOptions(q)
 
@@ -406,6 +409,15 @@ func TestAPI_SetQueryOptions(t *testing.T) {
 	if r.params.Get("near") != "nodex" {
 		t.Fatalf("bad: %v", r.params)
 	}
+	if r.params.Get("filter") != "Node == nodex" {
+		t.Fatalf("bad: %v", r.params)
+	}
+	if _, ok := r.params["cached"]; !ok {
+		t.Fatalf("bad: %v", r.params)
+	}
+	if r.header.Get("Cache-Control") != "max-age=30" {
+		t.Fatalf("bad: %v", r.header)
+	}
 }
 
 func TestAPI_SetWriteOptions(t *testing.T) {
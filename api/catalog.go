@@ -22,7 +22,9 @@ type CatalogService struct {
 	ServiceName              string
 	ServiceAddress           string
 	ServiceTags              []string
+	ServiceMeta              map[string]string
 	ServicePort              int
+	ServiceWeights           *AgentWeights `json:",omitempty"`
 	ServiceEnableTagOverride bool
 	CreateIndex              uint64
 	ModifyIndex              uint64
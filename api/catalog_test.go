@@ -1,6 +1,7 @@
 package api
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/hashicorp/consul/testutil"
@@ -235,6 +236,84 @@ func TestAPI_CatalogService_NodeMetaFilter(t *testing.T) {
 	})
 }
 
+func TestAPI_CatalogService_ServiceMetaFilter(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	agent := c.Agent()
+	reg := &AgentServiceRegistration{
+		Name: "redis",
+		Port: 6379,
+		Meta: map[string]string{"version": "4.0"},
+	}
+	if err := agent.ServiceRegister(reg); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	catalog := c.Catalog()
+	retry.Run(t, func(r *retry.R) {
+		services, _, err := catalog.Service("redis", "", &QueryOptions{ServiceMeta: map[string]string{"version": "4.0"}})
+		if err != nil {
+			r.Fatal(err)
+		}
+		if len(services) != 1 {
+			r.Fatalf("Bad: %v", services)
+		}
+		if services[0].ServiceMeta["version"] != "4.0" {
+			r.Fatalf("Bad: %v", services[0])
+		}
+	})
+
+	services, _, err := catalog.Service("redis", "", &QueryOptions{ServiceMeta: map[string]string{"version": "3.2"}})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(services) != 0 {
+		t.Fatalf("Bad: %v", services)
+	}
+}
+
+func TestAPI_CatalogService_Filter(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	agent := c.Agent()
+	reg := &AgentServiceRegistration{
+		Name: "redis",
+		Tags: []string{"primary"},
+		Port: 6379,
+	}
+	if err := agent.ServiceRegister(reg); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	catalog := c.Catalog()
+	retry.Run(t, func(r *retry.R) {
+		services, _, err := catalog.Service("redis", "", &QueryOptions{Filter: "ServiceTags contains primary"})
+		if err != nil {
+			r.Fatal(err)
+		}
+		if len(services) != 1 {
+			r.Fatalf("Bad: %v", services)
+		}
+	})
+
+	services, _, err := catalog.Service("redis", "", &QueryOptions{Filter: "ServicePort != 6379"})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(services) != 0 {
+		t.Fatalf("Bad: %v", services)
+	}
+
+	_, _, err = catalog.Service("redis", "", &QueryOptions{Filter: "ServicePort == redis"})
+	if err == nil || !strings.Contains(err.Error(), "400") {
+		t.Fatalf("err: %v", err)
+	}
+}
+
 func TestAPI_CatalogNode(t *testing.T) {
 	t.Parallel()
 	c, s := makeClient(t)
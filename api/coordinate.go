@@ -1,6 +1,9 @@
 package api
 
 import (
+	"strings"
+	"time"
+
 	"github.com/hashicorp/serf/coordinate"
 )
 
@@ -9,6 +12,48 @@ type CoordinateEntry struct {
 	Node    string
 	Segment string
 	Coord   *coordinate.Coordinate
+
+	// Updated is when the servers received the coordinate. This is zero if
+	// it's not known.
+	Updated time.Time
+}
+
+// CoordinateStatus describes how far the coordinate of a node can be trusted.
+// Inaccurate is set when the error of the coordinate is above the limit, and
+// Stale when it hasn't been updated recently enough.
+type CoordinateStatus struct {
+	Node       string
+	Segment    string
+	Error      float64
+	Updated    time.Time
+	Inaccurate bool
+	Stale      bool
+}
+
+// CoordinateRTT is an RTT estimate between two nodes, or two datacenters.
+type CoordinateRTT struct {
+	From string
+	To   string
+	RTT  time.Duration
+}
+
+// CoordinateNodeRTT is an RTT estimate between two nodes, along with the
+// status of the coordinates it was computed from.
+type CoordinateNodeRTT struct {
+	From  string
+	To    string
+	RTT   time.Duration
+	Nodes []*CoordinateStatus
+}
+
+// CoordinateMatrix holds the RTT estimates between each pair of nodes in a
+// datacenter that have compatible coordinates, along with the status of
+// their coordinates. Datacenters holds the median RTT estimates from the
+// datacenter to each datacenter in the WAN pool.
+type CoordinateMatrix struct {
+	Nodes       []*CoordinateStatus
+	RTTs        []*CoordinateRTT
+	Datacenters []*CoordinateRTT
 }
 
 // CoordinateDatacenterMap has the coordinates for servers in a given datacenter
@@ -66,3 +111,56 @@ func (c *Coordinate) Nodes(q *QueryOptions) ([]*CoordinateEntry, *QueryMeta, err
 	}
 	return out, qm, nil
 }
+
+// RTT is used to return the estimated round trip time between two nodes in
+// the LAN pool. If to is empty, the agent's node is used, which requires the
+// query to be for the agent's datacenter.
+func (c *Coordinate) RTT(from, to string, q *QueryOptions) (*CoordinateNodeRTT, *QueryMeta, error) {
+	r := c.c.newRequest("GET", "/v1/coordinate/rtt")
+	r.setQueryOptions(q)
+	r.params.Set("from", from)
+	if to != "" {
+		r.params.Set("to", to)
+	}
+	rtt, resp, err := requireOK(c.c.doRequest(r))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	qm := &QueryMeta{}
+	parseQueryMeta(resp, qm)
+	qm.RequestTime = rtt
+
+	var out CoordinateNodeRTT
+	if err := decodeBody(resp, &out); err != nil {
+		return nil, nil, err
+	}
+	return &out, qm, nil
+}
+
+// Matrix is used to return the estimated round trip times between the given
+// nodes in the LAN pool, up to 256 of them, as well as from the datacenter to
+// the others. Only the datacenters are returned if no nodes are given.
+func (c *Coordinate) Matrix(nodes []string, q *QueryOptions) (*CoordinateMatrix, *QueryMeta, error) {
+	r := c.c.newRequest("GET", "/v1/coordinate/matrix")
+	r.setQueryOptions(q)
+	if len(nodes) > 0 {
+		r.params.Set("nodes", strings.Join(nodes, ","))
+	}
+	rtt, resp, err := requireOK(c.c.doRequest(r))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	qm := &QueryMeta{}
+	parseQueryMeta(resp, qm)
+	qm.RequestTime = rtt
+
+	var out CoordinateMatrix
+	if err := decodeBody(resp, &out); err != nil {
+		return nil, nil, err
+	}
+	return &out, qm, nil
+}
@@ -42,3 +42,28 @@ func TestAPI_CoordinateNodes(t *testing.T) {
 		// get an error.
 	})
 }
+
+func TestAPI_CoordinateMatrix(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	coordinate := c.Coordinate()
+	retry.Run(t, func(r *retry.R) {
+		matrix, _, err := coordinate.Matrix(nil, nil)
+		if err != nil {
+			r.Fatal(err)
+		}
+
+		// As with the nodes, the best we can check is that our own
+		// datacenter shows up.
+		if len(matrix.Datacenters) != 1 || matrix.Datacenters[0].To != "dc1" {
+			r.Fatalf("Bad: %v", matrix.Datacenters)
+		}
+	})
+
+	// There's no second node to get the RTT to.
+	if _, _, err := coordinate.RTT(s.Config.NodeName, "nope", nil); err == nil {
+		t.Fatalf("expected an error")
+	}
+}
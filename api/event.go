@@ -2,6 +2,9 @@ package api
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
 	"strconv"
 )
 
@@ -22,6 +25,59 @@ type UserEvent struct {
 	LTime         uint64
 }
 
+const (
+	// StreamTopicServiceHealth is for the instances of a service and their
+	// health checks. The key of the stream is the service name.
+	StreamTopicServiceHealth = "service-health"
+
+	// StreamTopicKV is for entries in the KV store. The key of the stream
+	// is a prefix of the keys to watch.
+	StreamTopicKV = "kv"
+)
+
+// These are the types of stream events. See the stream endpoint docs for
+// when each is sent.
+const (
+	StreamServiceUpsert = "service-upsert"
+	StreamServiceDelete = "service-delete"
+	StreamCheckUpdate   = "check-update"
+	StreamCheckDelete   = "check-delete"
+	StreamKVPut         = "kv-put"
+	StreamKVDelete      = "kv-delete"
+	StreamEndOfSnapshot = "end-of-snapshot"
+	StreamResumed       = "resumed"
+	StreamError         = "error"
+)
+
+// StreamEvent is a change sent on an event stream. Only the field for the
+// type of the event is set.
+type StreamEvent struct {
+	Topic string
+	Type  string
+	Key   string
+	Index uint64
+
+	// Service is set for service events. Its checks are sent in their own
+	// events so they're always empty.
+	Service *ServiceEntry
+
+	// Check is set for check events.
+	Check *HealthCheck
+
+	// KV is set for KV events. Deletes only carry the key.
+	KV *KVPair
+
+	// Error is set for error events.
+	Error string
+}
+
+// EventStream is an open stream of change events. It must be closed once
+// it's no longer needed.
+type EventStream struct {
+	resp *http.Response
+	dec  *json.Decoder
+}
+
 // Event returns a handle to the event endpoints
 func (c *Client) Event() *Event {
 	return &Event{c}
@@ -102,3 +158,44 @@ func (e *Event) IDToIndex(uuid string) uint64 {
 	}
 	return lowVal ^ highVal
 }
+
+// Stream subscribes to the change events of a topic. The events of the
+// current state are sent first, followed by an end-of-snapshot event, unless
+// index is the index of an event that's still recent enough to resume from.
+// In that case a resumed event is sent first instead, followed by the events
+// after the index.
+func (e *Event) Stream(topic, key string, index uint64, q *QueryOptions) (*EventStream, error) {
+	r := e.c.newRequest("GET", "/v1/event/stream")
+	r.setQueryOptions(q)
+	r.params.Set("topic", topic)
+	if key != "" {
+		r.params.Set("key", key)
+	}
+	if index != 0 {
+		r.params.Set("index", strconv.FormatUint(index, 10))
+	}
+	_, resp, err := requireOK(e.c.doRequest(r))
+	if err != nil {
+		return nil, err
+	}
+	return &EventStream{resp: resp, dec: json.NewDecoder(resp.Body)}, nil
+}
+
+// Next blocks until the next event of the stream. Error events end the
+// stream and are returned as errors, in which case the caller should stream
+// again from the index of the last event it got.
+func (s *EventStream) Next() (*StreamEvent, error) {
+	var event StreamEvent
+	if err := s.dec.Decode(&event); err != nil {
+		return nil, err
+	}
+	if event.Type == StreamError {
+		return nil, errors.New(event.Error)
+	}
+	return &event, nil
+}
+
+// Close ends the stream.
+func (s *EventStream) Close() error {
+	return s.resp.Body.Close()
+}
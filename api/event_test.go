@@ -48,3 +48,56 @@ func TestAPI_EventFireList(t *testing.T) {
 		t.Fatalf("Bad: %#v", qm)
 	}
 }
+
+func TestAPI_EventStream(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	kv := c.KV()
+	if _, err := kv.Put(&KVPair{Key: "foo/a", Value: []byte("1")}, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	stream, err := c.Event().Stream(StreamTopicKV, "foo/", 0, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer stream.Close()
+
+	next := func() *StreamEvent {
+		e, err := stream.Next()
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		return e
+	}
+	if e := next(); e.Type != StreamKVPut || e.KV.Key != "foo/a" || string(e.KV.Value) != "1" {
+		t.Fatalf("bad: %#v", e)
+	}
+	e := next()
+	if e.Type != StreamEndOfSnapshot {
+		t.Fatalf("bad: %#v", e)
+	}
+	index := e.Index
+
+	if _, err := kv.Put(&KVPair{Key: "foo/b", Value: []byte("2")}, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if e := next(); e.Type != StreamKVPut || e.KV.Key != "foo/b" || e.Index <= index {
+		t.Fatalf("bad: %#v", e)
+	}
+
+	// Streaming again from the snapshot resumes from there.
+	resumed, err := c.Event().Stream(StreamTopicKV, "foo/", index, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer resumed.Close()
+	if e, err := resumed.Next(); err != nil || e.Type != StreamResumed {
+		t.Fatalf("bad: %#v %v", e, err)
+	}
+	if e, err := resumed.Next(); err != nil || e.Type != StreamKVPut || e.KV.Key != "foo/b" {
+		t.Fatalf("bad: %#v %v", e, err)
+	}
+}
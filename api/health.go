@@ -34,6 +34,9 @@ type HealthCheck struct {
 	ServiceID   string
 	ServiceName string
 	ServiceTags []string
+
+	CreateIndex uint64
+	ModifyIndex uint64
 }
 
 // HealthChecks is a collection of HealthCheck structs.
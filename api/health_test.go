@@ -3,6 +3,7 @@ package api
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/consul/testutil"
 	"github.com/hashicorp/consul/testutil/retry"
@@ -212,6 +213,11 @@ func TestAPI_HealthChecks(t *testing.T) {
 		if meta.LastIndex == 0 {
 			r.Fatalf("bad: %v", meta)
 		}
+		// The indexes depend on the writes made by the agent, so they're
+		// left out of the comparison.
+		for _, check := range out {
+			check.CreateIndex, check.ModifyIndex = 0, 0
+		}
 		if got, want := out, checks; !verify.Values(t, "checks", got, want) {
 			r.Fatal("health.Checks failed")
 		}
@@ -282,6 +288,45 @@ func TestAPI_HealthService(t *testing.T) {
 	})
 }
 
+func TestAPI_HealthService_Cached(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	health := c.Health()
+	opts := &QueryOptions{UseCache: true, MaxAge: time.Minute}
+	retry.Run(t, func(r *retry.R) {
+		checks, meta, err := health.Service("consul", "", true, opts)
+		if err != nil {
+			r.Fatal(err)
+		}
+		if len(checks) == 0 {
+			r.Fatalf("Bad: %v", checks)
+		}
+		if meta.LastIndex == 0 {
+			r.Fatalf("bad: %v", meta)
+		}
+	})
+
+	// The first read filled the cache.
+	_, meta, err := health.Service("consul", "", true, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !meta.CacheHit {
+		t.Fatalf("bad: %v", meta)
+	}
+
+	// Reads that don't ask for the cache never hit it.
+	_, meta, err = health.Service("consul", "", true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.CacheHit {
+		t.Fatalf("bad: %v", meta)
+	}
+}
+
 func TestAPI_HealthService_NodeMetaFilter(t *testing.T) {
 	t.Parallel()
 	meta := map[string]string{"somekey": "somevalue"}
@@ -40,6 +40,16 @@ type KVPair struct {
 	// interactions with this key over the same session must specify the same
 	// session ID.
 	Session string
+
+	// TTL is an optional duration, such as "30s", after which the key is
+	// deleted unless it has been written again in the meantime. It is
+	// respected by all the write operations.
+	TTL string
+
+	// LockWaiters are the sessions queued for the lock on this key with
+	// AcquireQueued, in the order they will get it. This is a read-only
+	// field.
+	LockWaiters []string
 }
 
 // KVPairs is a list of KVPair objects
@@ -55,6 +65,7 @@ const (
 	KVDeleteTree     KVOp = "delete-tree"
 	KVCAS            KVOp = "cas"
 	KVLock           KVOp = "lock"
+	KVLockQueue      KVOp = "lock-queue"
 	KVUnlock         KVOp = "unlock"
 	KVGet            KVOp = "get"
 	KVGetTree        KVOp = "get-tree"
@@ -71,6 +82,7 @@ type KVTxnOp struct {
 	Flags   uint64
 	Index   uint64
 	Session string
+	TTL     string
 }
 
 // KVTxnOps defines a set of operations to be performed inside a single
@@ -182,48 +194,78 @@ func (k *KV) getInternal(key string, params map[string]string, q *QueryOptions)
 }
 
 // Put is used to write a new value. Only the
-// Key, Flags and Value is respected.
+// Key, Flags, Value and TTL is respected.
 func (k *KV) Put(p *KVPair, q *WriteOptions) (*WriteMeta, error) {
 	params := make(map[string]string, 1)
 	if p.Flags != 0 {
 		params["flags"] = strconv.FormatUint(p.Flags, 10)
 	}
+	if p.TTL != "" {
+		params["ttl"] = p.TTL
+	}
 	_, wm, err := k.put(p.Key, params, p.Value, q)
 	return wm, err
 }
 
 // CAS is used for a Check-And-Set operation. The Key,
-// ModifyIndex, Flags and Value are respected. Returns true
+// ModifyIndex, Flags, Value and TTL are respected. Returns true
 // on success or false on failures.
 func (k *KV) CAS(p *KVPair, q *WriteOptions) (bool, *WriteMeta, error) {
 	params := make(map[string]string, 2)
 	if p.Flags != 0 {
 		params["flags"] = strconv.FormatUint(p.Flags, 10)
 	}
+	if p.TTL != "" {
+		params["ttl"] = p.TTL
+	}
 	params["cas"] = strconv.FormatUint(p.ModifyIndex, 10)
 	return k.put(p.Key, params, p.Value, q)
 }
 
 // Acquire is used for a lock acquisition operation. The Key,
-// Flags, Value and Session are respected. Returns true
+// Flags, Value, Session and TTL are respected. Returns true
 // on success or false on failures.
 func (k *KV) Acquire(p *KVPair, q *WriteOptions) (bool, *WriteMeta, error) {
 	params := make(map[string]string, 2)
 	if p.Flags != 0 {
 		params["flags"] = strconv.FormatUint(p.Flags, 10)
 	}
+	if p.TTL != "" {
+		params["ttl"] = p.TTL
+	}
+	params["acquire"] = p.Session
+	return k.put(p.Key, params, p.Value, q)
+}
+
+// AcquireQueued is used for a fair lock acquisition operation. The Key,
+// Flags, Value, Session and TTL are respected. The lock is only acquired if
+// it's free and no other session is queued ahead for it. Otherwise, the
+// session is queued for the lock and false is returned. A queued session
+// leaves the queue with Release, or when it is destroyed.
+func (k *KV) AcquireQueued(p *KVPair, q *WriteOptions) (bool, *WriteMeta, error) {
+	params := make(map[string]string, 3)
+	if p.Flags != 0 {
+		params["flags"] = strconv.FormatUint(p.Flags, 10)
+	}
+	if p.TTL != "" {
+		params["ttl"] = p.TTL
+	}
 	params["acquire"] = p.Session
+	params["queue"] = ""
 	return k.put(p.Key, params, p.Value, q)
 }
 
 // Release is used for a lock release operation. The Key,
-// Flags, Value and Session are respected. Returns true
+// Flags, Value, Session and TTL are respected. Returns true
 // on success or false on failures.
 func (k *KV) Release(p *KVPair, q *WriteOptions) (bool, *WriteMeta, error) {
 	params := make(map[string]string, 2)
 	if p.Flags != 0 {
 		params["flags"] = strconv.FormatUint(p.Flags, 10)
 	}
+	if p.TTL != "" {
+		params["ttl"] = p.TTL
+	}
 	params["release"] = p.Session
 	return k.put(p.Key, params, p.Value, q)
 }
@@ -300,38 +342,6 @@ func (k *KV) deleteInternal(key string, params map[string]string, q *WriteOption
 	return res, qm, nil
 }
 
-// TxnOp is the internal format we send to Consul. It's not specific to KV,
-// though currently only KV operations are supported.
-type TxnOp struct {
-	KV *KVTxnOp
-}
-
-// TxnOps is a list of transaction operations.
-type TxnOps []*TxnOp
-
-// TxnResult is the internal format we receive from Consul.
-type TxnResult struct {
-	KV *KVPair
-}
-
-// TxnResults is a list of TxnResult objects.
-type TxnResults []*TxnResult
-
-// TxnError is used to return information about an operation in a transaction.
-type TxnError struct {
-	OpIndex int
-	What    string
-}
-
-// TxnErrors is a list of TxnError objects.
-type TxnErrors []*TxnError
-
-// TxnResponse is the internal format we receive from Consul.
-type TxnResponse struct {
-	Results TxnResults
-	Errors  TxnErrors
-}
-
 // Txn is used to apply multiple KV operations in a single, atomic transaction.
 //
 // Note that Go will perform the required base64 encoding on the values
@@ -340,9 +350,8 @@ type TxnResponse struct {
 // to define operations. If any operation fails, none of the changes are applied
 // to the state store. Note that this hides the internal raw transaction interface
 // and munges the input and output types into KV-specific ones for ease of use.
-// If there are more non-KV operations in the future we may break out a new
-// transaction API client, but it will be easy to keep this KV-specific variant
-// supported.
+// Transactions that mix KV operations with catalog or session operations should
+// use the Txn client instead.
 //
 // Even though this is generally a write operation, we take a QueryOptions input
 // and return a QueryMeta output. If the transaction contains only read ops, then
@@ -377,44 +386,22 @@ type TxnResponse struct {
 // entries referencing the index of the operation that failed along with an error
 // message.
 func (k *KV) Txn(txn KVTxnOps, q *QueryOptions) (bool, *KVTxnResponse, *QueryMeta, error) {
-	r := k.c.newRequest("PUT", "/v1/txn")
-	r.setQueryOptions(q)
-
-	// Convert into the internal format since this is an all-KV txn.
 	ops := make(TxnOps, 0, len(txn))
 	for _, kvOp := range txn {
 		ops = append(ops, &TxnOp{KV: kvOp})
 	}
-	r.obj = ops
-	rtt, resp, err := k.c.doRequest(r)
+
+	ok, txnResp, qm, err := k.c.txn(ops, q)
 	if err != nil {
 		return false, nil, nil, err
 	}
-	defer resp.Body.Close()
-
-	qm := &QueryMeta{}
-	parseQueryMeta(resp, qm)
-	qm.RequestTime = rtt
 
-	if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusConflict {
-		var txnResp TxnResponse
-		if err := decodeBody(resp, &txnResp); err != nil {
-			return false, nil, nil, err
-		}
-
-		// Convert from the internal format.
-		kvResp := KVTxnResponse{
-			Errors: txnResp.Errors,
-		}
-		for _, result := range txnResp.Results {
-			kvResp.Results = append(kvResp.Results, result.KV)
-		}
-		return resp.StatusCode == http.StatusOK, &kvResp, qm, nil
+	// Convert from the internal format.
+	kvResp := KVTxnResponse{
+		Errors: txnResp.Errors,
 	}
-
-	var buf bytes.Buffer
-	if _, err := io.Copy(&buf, resp.Body); err != nil {
-		return false, nil, nil, fmt.Errorf("Failed to read response: %v", err)
+	for _, result := range txnResp.Results {
+		kvResp.Results = append(kvResp.Results, result.KV)
 	}
-	return false, nil, nil, fmt.Errorf("Failed request: %s", buf.String())
+	return ok, &kvResp, qm, nil
 }
@@ -73,6 +73,43 @@ func TestAPI_ClientPutGetDelete(t *testing.T) {
 	}
 }
 
+func TestAPI_ClientPut_TTL(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	kv := c.KV()
+
+	// Put a key with a short TTL
+	key := testKey()
+	p := &KVPair{Key: key, Value: []byte("test"), TTL: "500ms"}
+	if _, err := kv.Put(p, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Get should return the TTL
+	pair, meta, err := kv.Get(key, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if pair == nil || pair.TTL != "500ms" {
+		t.Fatalf("unexpected value: %#v", pair)
+	}
+
+	// A blocking query should wake up once the key expires
+	options := &QueryOptions{WaitIndex: meta.LastIndex, WaitTime: 10 * time.Second}
+	pair, meta2, err := kv.Get(key, options)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if pair != nil {
+		t.Fatalf("unexpected value: %#v", pair)
+	}
+	if meta2.LastIndex <= meta.LastIndex {
+		t.Fatalf("unexpected value: %#v", meta2)
+	}
+}
+
 func TestAPI_ClientList_DeleteRecurse(t *testing.T) {
 	t.Parallel()
 	c, s := makeClient(t)
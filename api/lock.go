@@ -79,6 +79,7 @@ type LockOptions struct {
 	MonitorRetryTime time.Duration // Optional, defaults to DefaultMonitorRetryTime
 	LockWaitTime     time.Duration // Optional, defaults to DefaultLockWaitTime
 	LockTryOnce      bool          // Optional, defaults to false which means try forever
+	Fair             bool          // Optional, queue for the lock so it's acquired in order
 }
 
 // LockKey returns a handle to a lock struct which can be used
@@ -130,6 +131,12 @@ func (c *Client) LockOpts(opts *LockOptions) (*Lock, error) {
 // created without any associated health checks. By default Consul sessions
 // prefer liveness over safety and an application must be able to handle
 // the lock being lost.
+//
+// With the Fair option, the lock is acquired in the order the contenders
+// asked for it. The session is queued for the lock and it leaves the queue
+// if the attempt is aborted. A session created by the lock uses the transfer
+// behavior, so the lock is handed directly to the next one in the queue when
+// it's released.
 func (l *Lock) Lock(stopCh <-chan struct{}) (<-chan struct{}, error) {
 	// Hold the lock as we try to acquire
 	l.l.Lock()
@@ -168,6 +175,15 @@ func (l *Lock) Lock(stopCh <-chan struct{}) (<-chan struct{}, error) {
 		WaitTime: l.opts.LockWaitTime,
 	}
 
+	// Leave the queue for the lock if we give up on it
+	if l.opts.Fair {
+		defer func() {
+			if !l.isHeld {
+				kv.Release(l.lockEntry(l.lockSession), nil)
+			}
+		}()
+	}
+
 	start := time.Now()
 	attempts := 0
 WAIT:
@@ -201,14 +217,23 @@ WAIT:
 	if pair != nil && pair.Session == l.lockSession {
 		goto HELD
 	}
-	if pair != nil && pair.Session != "" {
+	if pair != nil && pair.Session != "" && !l.opts.Fair {
+		qOpts.WaitIndex = meta.LastIndex
+		goto WAIT
+	}
+	if l.opts.Fair && l.mustWait(pair) {
+		// We're already queued behind someone, so wait our turn
 		qOpts.WaitIndex = meta.LastIndex
 		goto WAIT
 	}
 
 	// Try to acquire the lock
 	pair = l.lockEntry(l.lockSession)
-	locked, _, err = kv.Acquire(pair, nil)
+	if l.opts.Fair {
+		locked, _, err = kv.AcquireQueued(pair, nil)
+	} else {
+		locked, _, err = kv.Acquire(pair, nil)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to acquire lock: %v", err)
 	}
@@ -218,7 +243,11 @@ WAIT:
 		// Determine why the lock failed
 		qOpts.WaitIndex = 0
 		pair, meta, err = kv.Get(l.opts.Key, qOpts)
-		if pair != nil && pair.Session != "" {
+		if pair != nil && pair.Session == l.lockSession {
+			// The lock was handed to us in the meantime
+			goto HELD
+		}
+		if pair != nil && pair.Session != "" || l.opts.Fair && l.mustWait(pair) {
 			//If the session is not null, this means that a wait can safely happen
 			//using a long poll
 			qOpts.WaitIndex = meta.LastIndex
@@ -313,7 +342,7 @@ func (l *Lock) Destroy() error {
 	}
 
 	// Check if it is in use
-	if pair.Session != "" {
+	if pair.Session != "" || len(pair.LockWaiters) > 0 {
 		return ErrLockInUse
 	}
 
@@ -337,6 +366,9 @@ func (l *Lock) createSession() (string, error) {
 			Name: l.opts.SessionName,
 			TTL:  l.opts.SessionTTL,
 		}
+		if l.opts.Fair {
+			se.Behavior = SessionBehaviorTransfer
+		}
 	}
 	id, _, err := session.Create(se, nil)
 	if err != nil {
@@ -345,6 +377,20 @@ func (l *Lock) createSession() (string, error) {
 	return id, nil
 }
 
+// mustWait returns whether our session is queued for the lock behind
+// another session, so there's no point in trying to acquire it.
+func (l *Lock) mustWait(pair *KVPair) bool {
+	if pair == nil {
+		return false
+	}
+	for i, waiter := range pair.LockWaiters {
+		if waiter == l.lockSession {
+			return i > 0 || pair.Session != ""
+		}
+	}
+	return false
+}
+
 // lockEntry returns a formatted KVPair for the lock
 func (l *Lock) lockEntry(session string) *KVPair {
 	return &KVPair{
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"fmt"
 	"log"
 	"net/http"
 	"net/http/httptest"
@@ -9,6 +10,8 @@ import (
 	"sync"
 	"testing"
 	"time"
+
+	"github.com/hashicorp/consul/testutil/retry"
 )
 
 func TestAPI_LockLockUnlock(t *testing.T) {
@@ -199,6 +202,95 @@ func TestAPI_LockContend(t *testing.T) {
 	}
 }
 
+func TestAPI_LockContend_Fair(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	kv := c.KV()
+	waiters := func() []string {
+		pair, _, err := kv.Get("test/lock", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		return pair.LockWaiters
+	}
+	newLock := func() *Lock {
+		lock, err := c.LockOpts(&LockOptions{Key: "test/lock", Fair: true})
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		return lock
+	}
+
+	// Take the lock first.
+	holder := newLock()
+	if _, err := holder.Lock(nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Queue up the contenders one after the other, so we know the order
+	// they asked for the lock in.
+	order := make(chan int, 3)
+	errCh := make(chan error, 3)
+	for idx := 0; idx < 3; idx++ {
+		go func(idx int) {
+			lock := newLock()
+			leaderCh, err := lock.Lock(nil)
+			if err != nil || leaderCh == nil {
+				errCh <- fmt.Errorf("contender %d: %v", idx, err)
+				return
+			}
+			order <- idx
+			errCh <- lock.Unlock()
+		}(idx)
+
+		retry.Run(t, func(r *retry.R) {
+			if n := len(waiters()); n != idx+1 {
+				r.Fatalf("got %d waiters", n)
+			}
+		})
+	}
+
+	// Giving up on the lock leaves the queue.
+	stopCh := make(chan struct{})
+	doneCh := make(chan struct{})
+	go func() {
+		defer close(doneCh)
+		newLock().Lock(stopCh)
+	}()
+	retry.Run(t, func(r *retry.R) {
+		if n := len(waiters()); n != 4 {
+			r.Fatalf("got %d waiters", n)
+		}
+	})
+	close(stopCh)
+	<-doneCh
+	if n := len(waiters()); n != 3 {
+		t.Fatalf("got %d waiters", n)
+	}
+
+	// Releasing the lock hands it to the contenders in order.
+	if err := holder.Unlock(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		select {
+		case err := <-errCh:
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+		case <-time.After(DefaultLockRetryTime):
+			t.Fatalf("timeout")
+		}
+	}
+	for i := 0; i < 3; i++ {
+		if idx := <-order; idx != i {
+			t.Fatalf("contender %d acquired out of order", idx)
+		}
+	}
+}
+
 func TestAPI_LockDestroy(t *testing.T) {
 	t.Parallel()
 	c, s := makeClient(t)
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"net/http"
 	"strconv"
 	"strings"
 	"time"
@@ -92,6 +93,15 @@ type ServerHealth struct {
 
 	// StableSince is the last time this server's Healthy value changed.
 	StableSince time.Time
+
+	// RedundancyZone is the redundancy zone of the server, from the node
+	// meta key set in the RedundancyZoneTag of the Autopilot config.
+	RedundancyZone string
+
+	// UpgradeVersion is the version of the server used for upgrade
+	// migrations. This is the Consul version, unless the UpgradeVersionTag
+	// of the Autopilot config is set.
+	UpgradeVersion string
 }
 
 // OperatorHealthReply is a representation of the overall health of the cluster
@@ -105,6 +115,52 @@ type OperatorHealthReply struct {
 
 	// Servers holds the health of each server.
 	Servers []ServerHealth
+
+	// RedundancyZones has the state of each redundancy zone, by name. This is
+	// only set if the RedundancyZoneTag of the Autopilot config is set.
+	RedundancyZones map[string]AutopilotZone
+
+	// Upgrade has the state of the upgrade migration.
+	Upgrade *AutopilotUpgrade
+}
+
+// AutopilotZone is the state of a redundancy zone.
+type AutopilotZone struct {
+	// Servers has the IDs of the servers in the zone.
+	Servers []string
+
+	// Voters has the IDs of the voting servers in the zone. Autopilot keeps
+	// one voter in each zone.
+	Voters []string
+
+	// FailureTolerance is the number of healthy servers in the zone that
+	// could be lost while the zone still has a server to vote.
+	FailureTolerance int
+}
+
+// AutopilotUpgrade is the state of an upgrade migration, where the voters
+// are moved to the servers on the newest version.
+type AutopilotUpgrade struct {
+	// Status is the stage of the migration. This is one of "disabled",
+	// "idle", "await-new-servers", "promoting", "demoting" or
+	// "leader-transfer".
+	Status string
+
+	// TargetVersion is the newest version of the servers.
+	TargetVersion string
+
+	// TargetVersionVoters has the IDs of the voters on the target version.
+	TargetVersionVoters []string
+
+	// TargetVersionNonVoters has the IDs of the non-voters on the target
+	// version.
+	TargetVersionNonVoters []string
+
+	// OtherVersionVoters has the IDs of the voters on older versions.
+	OtherVersionVoters []string
+
+	// OtherVersionNonVoters has the IDs of the non-voters on older versions.
+	OtherVersionNonVoters []string
 }
 
 // ReadableDuration is a duration type that is serialized to JSON in human readable format.
@@ -201,14 +257,22 @@ func (op *Operator) AutopilotCASConfiguration(conf *AutopilotConfiguration, q *W
 	return res, nil
 }
 
-// AutopilotServerHealth
+// AutopilotServerHealth is used to query the health of the servers, along
+// with the state of the redundancy zones and the upgrade migration.
 func (op *Operator) AutopilotServerHealth(q *QueryOptions) (*OperatorHealthReply, error) {
 	r := op.c.newRequest("GET", "/v1/operator/autopilot/health")
 	r.setQueryOptions(q)
-	_, resp, err := requireOK(op.c.doRequest(r))
+	d, resp, err := op.c.doRequest(r)
 	if err != nil {
 		return nil, err
 	}
+
+	// The health is also returned when a server is unhealthy, with a 429.
+	if resp.StatusCode != http.StatusTooManyRequests {
+		if _, resp, err = requireOK(d, resp, nil); err != nil {
+			return nil, err
+		}
+	}
 	defer resp.Body.Close()
 
 	var out OperatorHealthReply
@@ -100,5 +100,10 @@ func TestAPI_OperatorAutopilotServerHealth(t *testing.T) {
 			out.Servers[0].Name != s.Config.NodeName {
 			r.Fatalf("bad: %v", out)
 		}
+		if out.Servers[0].UpgradeVersion == "" ||
+			out.Upgrade == nil || out.Upgrade.Status != "idle" ||
+			len(out.Upgrade.TargetVersionVoters) != 1 {
+			r.Fatalf("bad: %v", out.Upgrade)
+		}
 	})
 }
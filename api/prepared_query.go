@@ -48,6 +48,11 @@ type ServiceQuery struct {
 	// pair is in this map it must be present on the node in order for the
 	// service entry to be returned.
 	NodeMeta map[string]string
+
+	// ServiceMeta is a map of required service metadata fields. If a
+	// key/value pair is in this map it must be present on the service
+	// instance in order for it to be returned.
+	ServiceMeta map[string]string
 }
 
 // QueryTemplate carries the arguments for creating a templated query.
@@ -114,6 +119,12 @@ type PreparedQueryExecuteResponse struct {
 	Failovers int
 }
 
+// PreparedQueryExplainResponse has the results of explaining a query.
+type PreparedQueryExplainResponse struct {
+	// Query has the fully-rendered query, after any template was applied.
+	Query PreparedQueryDefinition
+}
+
 // PreparedQuery can be used to query the prepared query endpoints.
 type PreparedQuery struct {
 	c *Client
@@ -196,3 +207,14 @@ func (c *PreparedQuery) Execute(queryIDOrName string, q *QueryOptions) (*Prepare
 	}
 	return out, qm, nil
 }
+
+// Explain is used to show the fully-rendered query a query ID or name
+// resolves to, which is useful for debugging templates.
+func (c *PreparedQuery) Explain(queryIDOrName string, q *QueryOptions) (*PreparedQueryExplainResponse, *QueryMeta, error) {
+	var out *PreparedQueryExplainResponse
+	qm, err := c.c.query("/v1/query/"+queryIDOrName+"/explain", &out, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, qm, nil
+}
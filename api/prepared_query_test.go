@@ -116,6 +116,15 @@ func TestAPI_PreparedQuery(t *testing.T) {
 		t.Fatalf("bad datacenter: %v", results)
 	}
 
+	// Explain it.
+	explain, _, err := query.Explain("my-query", nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if explain.Query.ID != def.ID || explain.Query.Service.Service != "redis" {
+		t.Fatalf("bad: %v", explain)
+	}
+
 	// Delete it.
 	_, err = query.Delete(def.ID, nil)
 	if err != nil {
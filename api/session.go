@@ -15,6 +15,12 @@ const (
 	// behavior to delete all associated locks on session invalidation.
 	// It can be used in a way similar to Ephemeral Nodes in ZooKeeper.
 	SessionBehaviorDelete = "delete"
+
+	// SessionBehaviorTransfer releases the locks like the release behavior,
+	// except that when the session is destroyed by its owner, or a lock is
+	// released, the lock is handed straight to the first session queued
+	// for it with AcquireQueued, without applying the lock-delay.
+	SessionBehaviorTransfer = "transfer"
 )
 
 var ErrSessionExpired = errors.New("session expired")
@@ -29,6 +35,7 @@ type SessionEntry struct {
 	LockDelay   time.Duration
 	Behavior    string
 	TTL         string
+	ModifyIndex uint64
 }
 
 // Session can be used to query the Session endpoints
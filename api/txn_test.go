@@ -0,0 +1,163 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAPI_ClientTxn_Catalog(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	txn := c.Txn()
+
+	// Register a node with a service and a check, and take a lock with a
+	// session created in the same transaction.
+	session := "adf4238a-882b-9ddc-4a9d-5b6758e4159e"
+	ops := TxnOps{
+		&TxnOp{
+			Node: &NodeTxnOp{
+				Verb: NodeSet,
+				Node: Node{
+					Node:    "foo",
+					Address: "2.2.2.2",
+					Meta:    map[string]string{"rack": "a"},
+				},
+			},
+		},
+		&TxnOp{
+			Service: &ServiceTxnOp{
+				Verb: ServiceSet,
+				Node: "foo",
+				Service: AgentService{
+					ID:      "redis1",
+					Service: "redis",
+					Tags:    []string{"master"},
+					Port:    6379,
+				},
+			},
+		},
+		&TxnOp{
+			Check: &CheckTxnOp{
+				Verb: CheckSet,
+				Check: HealthCheck{
+					Node:      "foo",
+					CheckID:   "redis:alive",
+					Name:      "Redis alive",
+					Status:    HealthPassing,
+					ServiceID: "redis1",
+				},
+			},
+		},
+		&TxnOp{
+			Session: &SessionTxnOp{
+				Verb: SessionCreate,
+				Session: SessionEntry{
+					ID:     session,
+					Node:   "foo",
+					Checks: []string{"redis:alive"},
+				},
+			},
+		},
+		&TxnOp{
+			KV: &KVTxnOp{
+				Verb:    KVLock,
+				Key:     "service/redis/leader",
+				Value:   []byte("foo"),
+				Session: session,
+			},
+		},
+	}
+	ok, ret, _, err := txn.Txn(ops, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !ok {
+		t.Fatalf("transaction failure: %v", ret.Errors)
+	}
+	if len(ret.Results) != 5 {
+		t.Fatalf("bad: %v", ret)
+	}
+	if ret.Results[0].Node == nil || ret.Results[0].Node.Node != "foo" {
+		t.Fatalf("bad: %v", ret.Results[0])
+	}
+	if ret.Results[1].Service == nil || ret.Results[1].Service.ID != "redis1" {
+		t.Fatalf("bad: %v", ret.Results[1])
+	}
+	if ret.Results[2].Check == nil || ret.Results[2].Check.ServiceName != "redis" {
+		t.Fatalf("bad: %v", ret.Results[2])
+	}
+	if ret.Results[3].Session == nil || ret.Results[3].Session.ID != session {
+		t.Fatalf("bad: %v", ret.Results[3])
+	}
+	if ret.Results[4].KV == nil || ret.Results[4].KV.Session != session {
+		t.Fatalf("bad: %v", ret.Results[4])
+	}
+	index := ret.Results[0].Node.ModifyIndex
+
+	// Read everything back with a read-only transaction.
+	ops = TxnOps{
+		&TxnOp{
+			Node: &NodeTxnOp{
+				Verb: NodeGet,
+				Node: Node{Node: "foo"},
+			},
+		},
+		&TxnOp{
+			Service: &ServiceTxnOp{
+				Verb:    ServiceGet,
+				Node:    "foo",
+				Service: AgentService{ID: "redis1"},
+			},
+		},
+	}
+	ok, ret, _, err = txn.Txn(ops, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !ok {
+		t.Fatalf("transaction failure: %v", ret.Errors)
+	}
+	expected := TxnResults{
+		&TxnResult{
+			Node: &Node{
+				Node:        "foo",
+				Address:     "2.2.2.2",
+				Meta:        map[string]string{"rack": "a"},
+				CreateIndex: index,
+				ModifyIndex: index,
+			},
+		},
+		&TxnResult{
+			Service: &AgentService{
+				ID:          "redis1",
+				Service:     "redis",
+				Tags:        []string{"master"},
+				Port:        6379,
+				CreateIndex: index,
+				ModifyIndex: index,
+			},
+		},
+	}
+	if !reflect.DeepEqual(ret.Results, expected) {
+		t.Fatalf("bad: %#v %#v", ret.Results[0].Node, ret.Results[1].Service)
+	}
+
+	// A stale CAS delete of the node should roll back.
+	ops = TxnOps{
+		&TxnOp{
+			Node: &NodeTxnOp{
+				Verb: NodeDeleteCAS,
+				Node: Node{Node: "foo", ModifyIndex: index - 1},
+			},
+		},
+	}
+	ok, ret, _, err = txn.Txn(ops, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if ok || len(ret.Results) != 0 || len(ret.Errors) != 1 || ret.Errors[0].OpIndex != 0 {
+		t.Fatalf("bad: %v", ret)
+	}
+}
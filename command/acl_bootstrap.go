@@ -0,0 +1,64 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ACLBootstrapCommand is a Command implementation that is used to get the
+// first management token of a cluster.
+type ACLBootstrapCommand struct {
+	BaseCommand
+}
+
+func (c *ACLBootstrapCommand) Help() string {
+	helpText := `
+Usage: consul acl bootstrap [options]
+
+  Bootstraps the ACL system of a new cluster and prints the ID of its first
+  management token. This only works once, when no management tokens exist
+  yet:
+
+      $ consul acl bootstrap
+
+` + c.BaseCommand.Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *ACLBootstrapCommand) Run(args []string) int {
+	f := c.BaseCommand.NewFlagSet(c)
+	format := f.String("format", "table", formatUsage)
+	if err := c.BaseCommand.Parse(args); err != nil {
+		return 1
+	}
+	if err := validateFormat(*format); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	if l := len(f.Args()); l > 0 {
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 0, got %d)", l))
+		return 1
+	}
+
+	client, err := c.BaseCommand.HTTPClient()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error connecting to Consul agent: %s", err))
+		return 1
+	}
+
+	id, _, err := client.ACL().Bootstrap()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error bootstrapping ACLs: %s", err))
+		return 1
+	}
+	if err := printID(c.UI, *format, id); err != nil {
+		c.UI.Error(fmt.Sprintf("Error printing token: %s", err))
+		return 1
+	}
+	return 0
+}
+
+func (c *ACLBootstrapCommand) Synopsis() string {
+	return "Bootstraps the ACL system"
+}
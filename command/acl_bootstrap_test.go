@@ -0,0 +1,67 @@
+package command
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/consul/agent"
+	"github.com/mitchellh/cli"
+)
+
+func testACLBootstrapCommand(t *testing.T) (*cli.MockUi, *ACLBootstrapCommand) {
+	ui := cli.NewMockUi()
+	return ui, &ACLBootstrapCommand{
+		BaseCommand: BaseCommand{
+			UI:    ui,
+			Flags: FlagSetHTTP,
+		},
+	}
+}
+
+func TestACLBootstrapCommand_implements(t *testing.T) {
+	t.Parallel()
+	var _ cli.Command = &ACLBootstrapCommand{}
+}
+
+func TestACLBootstrapCommand_noTabs(t *testing.T) {
+	t.Parallel()
+	assertNoTabs(t, new(ACLBootstrapCommand))
+}
+
+func TestACLBootstrapCommand_Run(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t.Name(), `
+		acl_datacenter = "dc1"
+		acl_default_policy = "deny"
+	`)
+	defer a.Shutdown()
+
+	ui, c := testACLBootstrapCommand(t)
+	args := []string{"-http-addr=" + a.HTTPAddr(), "-format=json"}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+	var out struct{ ID string }
+	if err := json.Unmarshal(ui.OutputWriter.Bytes(), &out); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The new token is a management token.
+	entry, _, err := a.Client().ACL().Info(out.ID, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if entry == nil || entry.Type != "management" {
+		t.Fatalf("bad: %#v", entry)
+	}
+
+	// Bootstrapping only works once.
+	ui, c = testACLBootstrapCommand(t)
+	if code := c.Run(args); code != 1 {
+		t.Fatalf("bad: %d", code)
+	}
+	if !strings.Contains(ui.ErrorWriter.String(), "Error bootstrapping ACLs") {
+		t.Fatalf("bad: %s", ui.ErrorWriter.String())
+	}
+}
@@ -0,0 +1,64 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ACLCloneCommand is a Command implementation that is used to copy an ACL
+// token.
+type ACLCloneCommand struct {
+	BaseCommand
+}
+
+func (c *ACLCloneCommand) Help() string {
+	helpText := `
+Usage: consul acl clone [options] ID
+
+  Creates a new ACL token with the same name, type and rules as an existing
+  one, and prints its ID:
+
+      $ consul acl clone 8f246b77-f3e1-ff88-5b48-8ec93abf3e05
+
+` + c.BaseCommand.Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *ACLCloneCommand) Run(args []string) int {
+	f := c.BaseCommand.NewFlagSet(c)
+	format := f.String("format", "table", formatUsage)
+	if err := c.BaseCommand.Parse(args); err != nil {
+		return 1
+	}
+	if err := validateFormat(*format); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	id, err := idFromArgs(f.Args())
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	client, err := c.BaseCommand.HTTPClient()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error connecting to Consul agent: %s", err))
+		return 1
+	}
+
+	newID, _, err := client.ACL().Clone(id, nil)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error cloning token %q: %s", id, err))
+		return 1
+	}
+	if err := printID(c.UI, *format, newID); err != nil {
+		c.UI.Error(fmt.Sprintf("Error printing token: %s", err))
+		return 1
+	}
+	return 0
+}
+
+func (c *ACLCloneCommand) Synopsis() string {
+	return "Clones an ACL token"
+}
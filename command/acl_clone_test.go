@@ -0,0 +1,55 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func testACLCloneCommand(t *testing.T) (*cli.MockUi, *ACLCloneCommand) {
+	ui := cli.NewMockUi()
+	return ui, &ACLCloneCommand{
+		BaseCommand: BaseCommand{
+			UI:    ui,
+			Flags: FlagSetHTTP,
+		},
+	}
+}
+
+func TestACLCloneCommand_implements(t *testing.T) {
+	t.Parallel()
+	var _ cli.Command = &ACLCloneCommand{}
+}
+
+func TestACLCloneCommand_noTabs(t *testing.T) {
+	t.Parallel()
+	assertNoTabs(t, new(ACLCloneCommand))
+}
+
+func TestACLCloneCommand_Run(t *testing.T) {
+	t.Parallel()
+	a := testACLAgent(t)
+	defer a.Shutdown()
+
+	rules := `key "foo/" { policy = "read" }`
+	id := testACLToken(t, a, "web", rules)
+
+	ui, c := testACLCloneCommand(t)
+	args := []string{"-http-addr=" + a.HTTPAddr(), "-token=root", id}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+
+	newID := strings.TrimSpace(ui.OutputWriter.String())
+	if newID == id {
+		t.Fatalf("bad: %s", newID)
+	}
+	entry, _, err := a.Client().ACL().Info(newID, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if entry == nil || entry.Name != "web" || entry.Rules != rules {
+		t.Fatalf("bad: %#v", entry)
+	}
+}
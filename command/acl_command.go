@@ -0,0 +1,98 @@
+package command
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/api"
+	"github.com/mitchellh/cli"
+	"github.com/ryanuber/columnize"
+)
+
+// ACLCommand is a Command implementation that just shows help for
+// the subcommands nested below it.
+type ACLCommand struct {
+	BaseCommand
+}
+
+func (c *ACLCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
+
+func (c *ACLCommand) Help() string {
+	helpText := `
+Usage: consul acl <subcommand> [options] [args]
+
+  This command has subcommands for managing Consul's ACL tokens. Here are
+  some simple examples, and more detailed examples are available in the
+  subcommands or the documentation.
+
+  Bootstrap the ACL system and get the first management token:
+
+      $ consul acl bootstrap
+
+  Create a client token with the rules from a file:
+
+      $ consul acl create -name=web -rules=@web.hcl
+
+  List all the tokens:
+
+      $ consul acl list
+
+  Finally, destroy a token:
+
+      $ consul acl destroy 8f246b77-f3e1-ff88-5b48-8ec93abf3e05
+
+  For more examples, ask for subcommand help or view the documentation.
+
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *ACLCommand) Synopsis() string {
+	return "Interact with Consul's ACLs"
+}
+
+// aclRulesFromArg reads the rules given as the value of the -rules flag,
+// which is read from a file when prefixed with "@" and from stdin when it's
+// "-". The rules are parsed to catch mistakes before they're sent.
+func aclRulesFromArg(arg string, stdin io.Reader) (string, error) {
+	rules, err := valueFromArg(arg, stdin)
+	if err != nil {
+		return "", err
+	}
+	if _, err := acl.Parse(rules, nil); err != nil {
+		return "", err
+	}
+	return rules, nil
+}
+
+// validateACLType returns an error if the token type isn't valid.
+func validateACLType(typ string) error {
+	switch typ {
+	case api.ACLClientType, api.ACLManagementType:
+		return nil
+	default:
+		return fmt.Errorf("Invalid type %q, must be %q or %q", typ,
+			api.ACLClientType, api.ACLManagementType)
+	}
+}
+
+// formatACLEntry returns the token as a table of its fields, followed by
+// its rules.
+func formatACLEntry(entry *api.ACLEntry) string {
+	result := []string{
+		fmt.Sprintf("ID|%s", entry.ID),
+		fmt.Sprintf("Name|%s", entry.Name),
+		fmt.Sprintf("Type|%s", entry.Type),
+		fmt.Sprintf("CreateIndex|%d", entry.CreateIndex),
+		fmt.Sprintf("ModifyIndex|%d", entry.ModifyIndex),
+	}
+	out := columnize.SimpleFormat(result)
+	if rules := strings.TrimSpace(entry.Rules); rules != "" {
+		out += "\nRules:\n" + rules
+	}
+	return out
+}
@@ -0,0 +1,82 @@
+package command
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/consul/agent"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/testutil"
+	"github.com/mitchellh/cli"
+)
+
+// testACLAgent starts an agent with ACLs enabled and "root" as the master
+// token.
+func testACLAgent(t *testing.T) *agent.TestAgent {
+	return agent.NewTestAgent(t.Name(), agent.TestACLConfig())
+}
+
+// testACLToken creates a client token with the given rules.
+func testACLToken(t *testing.T, a *agent.TestAgent, name, rules string) string {
+	id, _, err := a.Client().ACL().Create(&api.ACLEntry{
+		Name:  name,
+		Type:  api.ACLClientType,
+		Rules: rules,
+	}, &api.WriteOptions{Token: "root"})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	return id
+}
+
+func TestACLCommand_implements(t *testing.T) {
+	t.Parallel()
+	var _ cli.Command = &ACLCommand{}
+}
+
+func TestACLCommand_noTabs(t *testing.T) {
+	t.Parallel()
+	assertNoTabs(t, new(ACLCommand))
+}
+
+func TestACLRulesFromArg(t *testing.T) {
+	t.Parallel()
+	rules := `key "foo/" { policy = "read" }`
+
+	f := testutil.TempFile(t, "acl-rules")
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(rules); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	cases := []struct {
+		arg   string
+		stdin string
+		want  string
+		err   string
+	}{
+		{"", "", "", ""},
+		{rules, "", rules, ""},
+		{"@" + f.Name(), "", rules, ""},
+		{"-", rules, rules, ""},
+		{"@/does/not/exist", "", "", "Failed to read file"},
+		{`key "foo/" { policy = "nope" }`, "", "", "Invalid key policy"},
+		{`key "foo/" {`, "", "", "Failed to parse ACL rules"},
+	}
+	for _, tc := range cases {
+		got, err := aclRulesFromArg(tc.arg, strings.NewReader(tc.stdin))
+		if tc.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Fatalf("%q: got %v want %q", tc.arg, err, tc.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%q: err: %v", tc.arg, err)
+		}
+		if got != tc.want {
+			t.Fatalf("%q: got %q want %q", tc.arg, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package command
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/hashicorp/consul/api"
+)
+
+// ACLCreateCommand is a Command implementation that is used to create a new
+// ACL token.
+type ACLCreateCommand struct {
+	BaseCommand
+
+	// testStdin is the input for testing.
+	testStdin io.Reader
+}
+
+func (c *ACLCreateCommand) Help() string {
+	helpText := `
+Usage: consul acl create [options]
+
+  Creates a new ACL token and prints its ID. The rules can be given inline,
+  read from a file on disk by prefixing with the "@" symbol, or read from
+  stdin using the "-" symbol. They are checked before the token is created:
+
+      $ consul acl create -name=web -rules=@web.hcl
+
+      $ cat web.hcl | consul acl create -name=web -rules=-
+
+  To create a management token:
+
+      $ consul acl create -name=operator -type=management
+
+` + c.BaseCommand.Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *ACLCreateCommand) Run(args []string) int {
+	f := c.BaseCommand.NewFlagSet(c)
+	id := f.String("id", "",
+		"ID of the new token. If unspecified, a random ID is generated.")
+	name := f.String("name", "", "Human readable name of the token.")
+	typ := f.String("type", api.ACLClientType,
+		"Type of the token, which can be \"client\" or \"management\". The "+
+			"default value is \"client\".")
+	rules := f.String("rules", "",
+		"Rules of the token in HCL format. Prefix with \"@\" to read them "+
+			"from a file, or use \"-\" to read them from stdin.")
+	format := f.String("format", "table", formatUsage)
+	if err := c.BaseCommand.Parse(args); err != nil {
+		return 1
+	}
+	if err := validateFormat(*format); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	if err := validateACLType(*typ); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	if l := len(f.Args()); l > 0 {
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 0, got %d)", l))
+		return 1
+	}
+	data, err := aclRulesFromArg(*rules, c.testStdin)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error reading rules: %s", err))
+		return 1
+	}
+
+	client, err := c.BaseCommand.HTTPClient()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error connecting to Consul agent: %s", err))
+		return 1
+	}
+
+	entry := &api.ACLEntry{
+		ID:    *id,
+		Name:  *name,
+		Type:  *typ,
+		Rules: data,
+	}
+	newID, _, err := client.ACL().Create(entry, nil)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error creating token: %s", err))
+		return 1
+	}
+	if err := printID(c.UI, *format, newID); err != nil {
+		c.UI.Error(fmt.Sprintf("Error printing token: %s", err))
+		return 1
+	}
+	return 0
+}
+
+func (c *ACLCreateCommand) Synopsis() string {
+	return "Creates an ACL token"
+}
@@ -0,0 +1,91 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func testACLCreateCommand(t *testing.T) (*cli.MockUi, *ACLCreateCommand) {
+	ui := cli.NewMockUi()
+	return ui, &ACLCreateCommand{
+		BaseCommand: BaseCommand{
+			UI:    ui,
+			Flags: FlagSetHTTP,
+		},
+	}
+}
+
+func TestACLCreateCommand_implements(t *testing.T) {
+	t.Parallel()
+	var _ cli.Command = &ACLCreateCommand{}
+}
+
+func TestACLCreateCommand_noTabs(t *testing.T) {
+	t.Parallel()
+	assertNoTabs(t, new(ACLCreateCommand))
+}
+
+func TestACLCreateCommand_Validation(t *testing.T) {
+	t.Parallel()
+	cases := map[string]struct {
+		args   []string
+		output string
+	}{
+		"bad type": {
+			[]string{"-type=nope"},
+			"Invalid type",
+		},
+		"bad format": {
+			[]string{"-format=nope"},
+			"Invalid format",
+		},
+		"bad rules": {
+			[]string{`-rules=key "foo" { policy = "nope" }`},
+			"Invalid key policy",
+		},
+		"extra args": {
+			[]string{"foo"},
+			"Too many arguments",
+		},
+	}
+
+	for name, tc := range cases {
+		ui, c := testACLCreateCommand(t)
+		if code := c.Run(tc.args); code == 0 {
+			t.Errorf("%s: expected non-zero exit", name)
+		}
+		if output := ui.ErrorWriter.String(); !strings.Contains(output, tc.output) {
+			t.Errorf("%s: expected %q to contain %q", name, output, tc.output)
+		}
+	}
+}
+
+func TestACLCreateCommand_Run(t *testing.T) {
+	t.Parallel()
+	a := testACLAgent(t)
+	defer a.Shutdown()
+
+	rules := `key "foo/" { policy = "read" }`
+	ui, c := testACLCreateCommand(t)
+	c.testStdin = strings.NewReader(rules)
+	args := []string{
+		"-http-addr=" + a.HTTPAddr(),
+		"-token=root",
+		"-name=web",
+		"-rules=-",
+	}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+
+	id := strings.TrimSpace(ui.OutputWriter.String())
+	entry, _, err := a.Client().ACL().Info(id, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if entry == nil || entry.Name != "web" || entry.Type != "client" || entry.Rules != rules {
+		t.Fatalf("bad: %#v", entry)
+	}
+}
@@ -0,0 +1,56 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ACLDestroyCommand is a Command implementation that is used to delete an
+// ACL token.
+type ACLDestroyCommand struct {
+	BaseCommand
+}
+
+func (c *ACLDestroyCommand) Help() string {
+	helpText := `
+Usage: consul acl destroy [options] ID
+
+  Deletes an ACL token. Requests using the token are then treated as if
+  they were anonymous:
+
+      $ consul acl destroy 8f246b77-f3e1-ff88-5b48-8ec93abf3e05
+
+` + c.BaseCommand.Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *ACLDestroyCommand) Run(args []string) int {
+	f := c.BaseCommand.NewFlagSet(c)
+	if err := c.BaseCommand.Parse(args); err != nil {
+		return 1
+	}
+	id, err := idFromArgs(f.Args())
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	client, err := c.BaseCommand.HTTPClient()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error connecting to Consul agent: %s", err))
+		return 1
+	}
+
+	if _, err := client.ACL().Destroy(id, nil); err != nil {
+		c.UI.Error(fmt.Sprintf("Error destroying token %q: %s", id, err))
+		return 1
+	}
+
+	c.UI.Info(fmt.Sprintf("Token %q destroyed", id))
+	return 0
+}
+
+func (c *ACLDestroyCommand) Synopsis() string {
+	return "Destroys an ACL token"
+}
@@ -0,0 +1,56 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func testACLDestroyCommand(t *testing.T) (*cli.MockUi, *ACLDestroyCommand) {
+	ui := cli.NewMockUi()
+	return ui, &ACLDestroyCommand{
+		BaseCommand: BaseCommand{
+			UI:    ui,
+			Flags: FlagSetHTTP,
+		},
+	}
+}
+
+func TestACLDestroyCommand_implements(t *testing.T) {
+	t.Parallel()
+	var _ cli.Command = &ACLDestroyCommand{}
+}
+
+func TestACLDestroyCommand_noTabs(t *testing.T) {
+	t.Parallel()
+	assertNoTabs(t, new(ACLDestroyCommand))
+}
+
+func TestACLDestroyCommand_Run(t *testing.T) {
+	t.Parallel()
+	a := testACLAgent(t)
+	defer a.Shutdown()
+
+	id := testACLToken(t, a, "web", "")
+
+	ui, c := testACLDestroyCommand(t)
+	if code := c.Run([]string{"-http-addr=" + a.HTTPAddr(), "-token=root"}); code != 1 {
+		t.Fatalf("bad: %d", code)
+	}
+	if !strings.Contains(ui.ErrorWriter.String(), "Missing ID argument") {
+		t.Fatalf("bad: %s", ui.ErrorWriter.String())
+	}
+
+	ui, c = testACLDestroyCommand(t)
+	if code := c.Run([]string{"-http-addr=" + a.HTTPAddr(), "-token=root", id}); code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+	entry, _, err := a.Client().ACL().Info(id, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if entry != nil {
+		t.Fatalf("bad: %#v", entry)
+	}
+}
@@ -0,0 +1,72 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ACLInfoCommand is a Command implementation that is used to show an ACL
+// token.
+type ACLInfoCommand struct {
+	BaseCommand
+}
+
+func (c *ACLInfoCommand) Help() string {
+	helpText := `
+Usage: consul acl info [options] ID
+
+  Shows the fields and rules of an ACL token:
+
+      $ consul acl info 8f246b77-f3e1-ff88-5b48-8ec93abf3e05
+
+` + c.BaseCommand.Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *ACLInfoCommand) Run(args []string) int {
+	f := c.BaseCommand.NewFlagSet(c)
+	format := f.String("format", "table", formatUsage)
+	if err := c.BaseCommand.Parse(args); err != nil {
+		return 1
+	}
+	if err := validateFormat(*format); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	id, err := idFromArgs(f.Args())
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	client, err := c.BaseCommand.HTTPClient()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error connecting to Consul agent: %s", err))
+		return 1
+	}
+
+	entry, _, err := client.ACL().Info(id, nil)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error reading token %q: %s", id, err))
+		return 1
+	}
+	if entry == nil {
+		c.UI.Error(fmt.Sprintf("Token %q not found", id))
+		return 1
+	}
+
+	if *format == "json" {
+		if err := outputJSON(c.UI, entry); err != nil {
+			c.UI.Error(fmt.Sprintf("Error printing token: %s", err))
+			return 1
+		}
+		return 0
+	}
+	c.UI.Output(formatACLEntry(entry))
+	return 0
+}
+
+func (c *ACLInfoCommand) Synopsis() string {
+	return "Shows an ACL token"
+}
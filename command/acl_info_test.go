@@ -0,0 +1,71 @@
+package command
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/mitchellh/cli"
+)
+
+func testACLInfoCommand(t *testing.T) (*cli.MockUi, *ACLInfoCommand) {
+	ui := cli.NewMockUi()
+	return ui, &ACLInfoCommand{
+		BaseCommand: BaseCommand{
+			UI:    ui,
+			Flags: FlagSetHTTP,
+		},
+	}
+}
+
+func TestACLInfoCommand_implements(t *testing.T) {
+	t.Parallel()
+	var _ cli.Command = &ACLInfoCommand{}
+}
+
+func TestACLInfoCommand_noTabs(t *testing.T) {
+	t.Parallel()
+	assertNoTabs(t, new(ACLInfoCommand))
+}
+
+func TestACLInfoCommand_Run(t *testing.T) {
+	t.Parallel()
+	a := testACLAgent(t)
+	defer a.Shutdown()
+
+	rules := `key "foo/" { policy = "read" }`
+	id := testACLToken(t, a, "web", rules)
+
+	ui, c := testACLInfoCommand(t)
+	args := []string{"-http-addr=" + a.HTTPAddr(), "-token=root", id}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+	output := ui.OutputWriter.String()
+	for _, want := range []string{id, "web", "client", "Rules:\n" + rules} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected %q to contain %q", output, want)
+		}
+	}
+
+	ui, c = testACLInfoCommand(t)
+	args = []string{"-http-addr=" + a.HTTPAddr(), "-token=root", "-format=json", id}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+	var entry api.ACLEntry
+	if err := json.Unmarshal(ui.OutputWriter.Bytes(), &entry); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if entry.ID != id || entry.Name != "web" || entry.Rules != rules {
+		t.Fatalf("bad: %#v", entry)
+	}
+
+	// Unknown tokens are an error.
+	ui, c = testACLInfoCommand(t)
+	args = []string{"-http-addr=" + a.HTTPAddr(), "-token=root", "8f246b77-f3e1-ff88-5b48-8ec93abf3e05"}
+	if code := c.Run(args); code != 1 {
+		t.Fatalf("bad: %d", code)
+	}
+}
@@ -0,0 +1,75 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/ryanuber/columnize"
+)
+
+// ACLListCommand is a Command implementation that is used to list the ACL
+// tokens.
+type ACLListCommand struct {
+	BaseCommand
+}
+
+func (c *ACLListCommand) Help() string {
+	helpText := `
+Usage: consul acl list [options]
+
+  Lists the ACL tokens. The rules of the tokens are only included with
+  -format=json:
+
+      $ consul acl list
+
+` + c.BaseCommand.Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *ACLListCommand) Run(args []string) int {
+	f := c.BaseCommand.NewFlagSet(c)
+	format := f.String("format", "table", formatUsage)
+	if err := c.BaseCommand.Parse(args); err != nil {
+		return 1
+	}
+	if err := validateFormat(*format); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	if l := len(f.Args()); l > 0 {
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 0, got %d)", l))
+		return 1
+	}
+
+	client, err := c.BaseCommand.HTTPClient()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error connecting to Consul agent: %s", err))
+		return 1
+	}
+
+	entries, _, err := client.ACL().List(&api.QueryOptions{AllowStale: c.BaseCommand.HTTPStale()})
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error listing tokens: %s", err))
+		return 1
+	}
+
+	if *format == "json" {
+		if err := outputJSON(c.UI, entries); err != nil {
+			c.UI.Error(fmt.Sprintf("Error printing tokens: %s", err))
+			return 1
+		}
+		return 0
+	}
+	result := []string{"ID|Name|Type"}
+	for _, entry := range entries {
+		result = append(result, fmt.Sprintf("%s|%s|%s", entry.ID, entry.Name, entry.Type))
+	}
+	c.UI.Output(columnize.SimpleFormat(result))
+	return 0
+}
+
+func (c *ACLListCommand) Synopsis() string {
+	return "Lists ACL tokens"
+}
@@ -0,0 +1,79 @@
+package command
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/mitchellh/cli"
+)
+
+func testACLListCommand(t *testing.T) (*cli.MockUi, *ACLListCommand) {
+	ui := cli.NewMockUi()
+	return ui, &ACLListCommand{
+		BaseCommand: BaseCommand{
+			UI:    ui,
+			Flags: FlagSetHTTP,
+		},
+	}
+}
+
+func TestACLListCommand_implements(t *testing.T) {
+	t.Parallel()
+	var _ cli.Command = &ACLListCommand{}
+}
+
+func TestACLListCommand_noTabs(t *testing.T) {
+	t.Parallel()
+	assertNoTabs(t, new(ACLListCommand))
+}
+
+func TestACLListCommand_Run(t *testing.T) {
+	t.Parallel()
+	a := testACLAgent(t)
+	defer a.Shutdown()
+
+	id := testACLToken(t, a, "web", "")
+
+	ui, c := testACLListCommand(t)
+	args := []string{"-http-addr=" + a.HTTPAddr(), "-token=root"}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+	output := ui.OutputWriter.String()
+	for _, want := range []string{"ID", id, "web", "root", "Master Token"} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected %q to contain %q", output, want)
+		}
+	}
+
+	ui, c = testACLListCommand(t)
+	args = []string{"-http-addr=" + a.HTTPAddr(), "-token=root", "-format=json"}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+	var entries []*api.ACLEntry
+	if err := json.Unmarshal(ui.OutputWriter.Bytes(), &entries); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	var found bool
+	for _, entry := range entries {
+		if entry.ID == id {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("bad: %#v", entries)
+	}
+
+	// Listing needs a management token.
+	ui, c = testACLListCommand(t)
+	args = []string{"-http-addr=" + a.HTTPAddr(), "-token=" + id}
+	if code := c.Run(args); code != 1 {
+		t.Fatalf("bad: %d", code)
+	}
+	if !strings.Contains(ui.ErrorWriter.String(), "Permission denied") {
+		t.Fatalf("bad: %s", ui.ErrorWriter.String())
+	}
+}
@@ -0,0 +1,88 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/ryanuber/columnize"
+)
+
+// ACLReplicationCommand is a Command implementation that is used to show
+// the status of ACL replication.
+type ACLReplicationCommand struct {
+	BaseCommand
+}
+
+func (c *ACLReplicationCommand) Help() string {
+	helpText := `
+Usage: consul acl replication [options]
+
+  Shows the status of ACL replication in the datacenter of the agent, or the
+  one given with -datacenter:
+
+      $ consul acl replication
+
+` + c.BaseCommand.Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *ACLReplicationCommand) Run(args []string) int {
+	f := c.BaseCommand.NewFlagSet(c)
+	format := f.String("format", "table", formatUsage)
+	if err := c.BaseCommand.Parse(args); err != nil {
+		return 1
+	}
+	if err := validateFormat(*format); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	if l := len(f.Args()); l > 0 {
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 0, got %d)", l))
+		return 1
+	}
+
+	client, err := c.BaseCommand.HTTPClient()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error connecting to Consul agent: %s", err))
+		return 1
+	}
+
+	status, _, err := client.ACL().Replication(&api.QueryOptions{AllowStale: c.BaseCommand.HTTPStale()})
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error reading replication status: %s", err))
+		return 1
+	}
+
+	if *format == "json" {
+		if err := outputJSON(c.UI, status); err != nil {
+			c.UI.Error(fmt.Sprintf("Error printing replication status: %s", err))
+			return 1
+		}
+		return 0
+	}
+	result := []string{
+		fmt.Sprintf("Enabled|%v", status.Enabled),
+		fmt.Sprintf("Running|%v", status.Running),
+		fmt.Sprintf("SourceDatacenter|%s", status.SourceDatacenter),
+		fmt.Sprintf("ReplicatedIndex|%d", status.ReplicatedIndex),
+		fmt.Sprintf("LastSuccess|%s", formatACLTime(status.LastSuccess)),
+		fmt.Sprintf("LastError|%s", formatACLTime(status.LastError)),
+	}
+	c.UI.Output(columnize.SimpleFormat(result))
+	return 0
+}
+
+func (c *ACLReplicationCommand) Synopsis() string {
+	return "Shows the status of ACL replication"
+}
+
+// formatACLTime formats the time, leaving out the zero time.
+func formatACLTime(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	return t.Format(time.RFC3339)
+}
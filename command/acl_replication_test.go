@@ -0,0 +1,61 @@
+package command
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/mitchellh/cli"
+)
+
+func testACLReplicationCommand(t *testing.T) (*cli.MockUi, *ACLReplicationCommand) {
+	ui := cli.NewMockUi()
+	return ui, &ACLReplicationCommand{
+		BaseCommand: BaseCommand{
+			UI:    ui,
+			Flags: FlagSetHTTP,
+		},
+	}
+}
+
+func TestACLReplicationCommand_implements(t *testing.T) {
+	t.Parallel()
+	var _ cli.Command = &ACLReplicationCommand{}
+}
+
+func TestACLReplicationCommand_noTabs(t *testing.T) {
+	t.Parallel()
+	assertNoTabs(t, new(ACLReplicationCommand))
+}
+
+func TestACLReplicationCommand_Run(t *testing.T) {
+	t.Parallel()
+	a := testACLAgent(t)
+	defer a.Shutdown()
+
+	ui, c := testACLReplicationCommand(t)
+	args := []string{"-http-addr=" + a.HTTPAddr(), "-token=root"}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+	output := ui.OutputWriter.String()
+	for _, want := range []string{"Enabled", "false", "LastSuccess", "never"} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("expected %q to contain %q", output, want)
+		}
+	}
+
+	ui, c = testACLReplicationCommand(t)
+	args = []string{"-http-addr=" + a.HTTPAddr(), "-token=root", "-format=json"}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+	var status api.ACLReplicationStatus
+	if err := json.Unmarshal(ui.OutputWriter.Bytes(), &status); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if status.Enabled {
+		t.Fatalf("bad: %#v", status)
+	}
+}
@@ -0,0 +1,109 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ACLUpdateCommand is a Command implementation that is used to change an
+// existing ACL token.
+type ACLUpdateCommand struct {
+	BaseCommand
+
+	// testStdin is the input for testing.
+	testStdin io.Reader
+}
+
+func (c *ACLUpdateCommand) Help() string {
+	helpText := `
+Usage: consul acl update [options] ID
+
+  Changes the name, type or rules of an existing ACL token. Only the given
+  fields are changed. The rules can be given inline, read from a file on
+  disk by prefixing with the "@" symbol, or read from stdin using the "-"
+  symbol. They are checked before the token is updated:
+
+      $ consul acl update -rules=@web.hcl 8f246b77-f3e1-ff88-5b48-8ec93abf3e05
+
+` + c.BaseCommand.Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *ACLUpdateCommand) Run(args []string) int {
+	f := c.BaseCommand.NewFlagSet(c)
+	name := f.String("name", "", "Human readable name of the token.")
+	typ := f.String("type", "",
+		"Type of the token, which can be \"client\" or \"management\".")
+	rules := f.String("rules", "",
+		"Rules of the token in HCL format. Prefix with \"@\" to read them "+
+			"from a file, or use \"-\" to read them from stdin.")
+	if err := c.BaseCommand.Parse(args); err != nil {
+		return 1
+	}
+	id, err := idFromArgs(f.Args())
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	// Only change what was given, so an empty name or rules can still be
+	// set explicitly.
+	set := make(map[string]bool)
+	f.Visit(func(fl *flag.Flag) { set[fl.Name] = true })
+	if !set["name"] && !set["type"] && !set["rules"] {
+		c.UI.Error("Must specify at least one of -name, -type or -rules")
+		return 1
+	}
+	if set["type"] {
+		if err := validateACLType(*typ); err != nil {
+			c.UI.Error(err.Error())
+			return 1
+		}
+	}
+	var data string
+	if set["rules"] {
+		if data, err = aclRulesFromArg(*rules, c.testStdin); err != nil {
+			c.UI.Error(fmt.Sprintf("Error reading rules: %s", err))
+			return 1
+		}
+	}
+
+	client, err := c.BaseCommand.HTTPClient()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error connecting to Consul agent: %s", err))
+		return 1
+	}
+
+	entry, _, err := client.ACL().Info(id, nil)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error reading token %q: %s", id, err))
+		return 1
+	}
+	if entry == nil {
+		c.UI.Error(fmt.Sprintf("Token %q not found", id))
+		return 1
+	}
+	if set["name"] {
+		entry.Name = *name
+	}
+	if set["type"] {
+		entry.Type = *typ
+	}
+	if set["rules"] {
+		entry.Rules = data
+	}
+	if _, err := client.ACL().Update(entry, nil); err != nil {
+		c.UI.Error(fmt.Sprintf("Error updating token %q: %s", id, err))
+		return 1
+	}
+
+	c.UI.Info(fmt.Sprintf("Token %q updated", id))
+	return 0
+}
+
+func (c *ACLUpdateCommand) Synopsis() string {
+	return "Updates an ACL token"
+}
@@ -0,0 +1,125 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func testACLUpdateCommand(t *testing.T) (*cli.MockUi, *ACLUpdateCommand) {
+	ui := cli.NewMockUi()
+	return ui, &ACLUpdateCommand{
+		BaseCommand: BaseCommand{
+			UI:    ui,
+			Flags: FlagSetHTTP,
+		},
+	}
+}
+
+func TestACLUpdateCommand_implements(t *testing.T) {
+	t.Parallel()
+	var _ cli.Command = &ACLUpdateCommand{}
+}
+
+func TestACLUpdateCommand_noTabs(t *testing.T) {
+	t.Parallel()
+	assertNoTabs(t, new(ACLUpdateCommand))
+}
+
+func TestACLUpdateCommand_Validation(t *testing.T) {
+	t.Parallel()
+	cases := map[string]struct {
+		args   []string
+		output string
+	}{
+		"no id": {
+			[]string{"-name=web"},
+			"Missing ID argument",
+		},
+		"nothing to change": {
+			[]string{"foo"},
+			"Must specify at least one",
+		},
+		"bad type": {
+			[]string{"-type=nope", "foo"},
+			"Invalid type",
+		},
+		"extra args": {
+			[]string{"foo", "bar"},
+			"Too many arguments",
+		},
+	}
+
+	for name, tc := range cases {
+		ui, c := testACLUpdateCommand(t)
+		if code := c.Run(tc.args); code == 0 {
+			t.Errorf("%s: expected non-zero exit", name)
+		}
+		if output := ui.ErrorWriter.String(); !strings.Contains(output, tc.output) {
+			t.Errorf("%s: expected %q to contain %q", name, output, tc.output)
+		}
+	}
+}
+
+func TestACLUpdateCommand_Run(t *testing.T) {
+	t.Parallel()
+	a := testACLAgent(t)
+	defer a.Shutdown()
+
+	rules := `key "foo/" { policy = "read" }`
+	id := testACLToken(t, a, "web", rules)
+
+	// Only the given fields change.
+	ui, c := testACLUpdateCommand(t)
+	args := []string{
+		"-http-addr=" + a.HTTPAddr(),
+		"-token=root",
+		"-name=api",
+		id,
+	}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+	entry, _, err := a.Client().ACL().Info(id, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if entry == nil || entry.Name != "api" || entry.Rules != rules {
+		t.Fatalf("bad: %#v", entry)
+	}
+
+	// Rules can be cleared.
+	ui, c = testACLUpdateCommand(t)
+	args = []string{
+		"-http-addr=" + a.HTTPAddr(),
+		"-token=root",
+		"-rules=",
+		id,
+	}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+	entry, _, err = a.Client().ACL().Info(id, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if entry == nil || entry.Name != "api" || entry.Rules != "" {
+		t.Fatalf("bad: %#v", entry)
+	}
+
+	// Unknown tokens are an error.
+	ui, c = testACLUpdateCommand(t)
+	args = []string{
+		"-http-addr=" + a.HTTPAddr(),
+		"-token=root",
+		"-name=api",
+		"8f246b77-f3e1-ff88-5b48-8ec93abf3e05",
+	}
+	if code := c.Run(args); code != 1 {
+		t.Fatalf("bad: %d", code)
+	}
+	if !strings.Contains(ui.ErrorWriter.String(), "not found") {
+		t.Fatalf("bad: %s", ui.ErrorWriter.String())
+	}
+}
@@ -216,7 +216,7 @@ func circonusSink(config *config.RuntimeConfig, hostname string) (metrics.Metric
 	return sink, nil
 }
 
-func startupTelemetry(conf *config.RuntimeConfig) (*metrics.InmemSink, error) {
+func startupTelemetry(conf *config.RuntimeConfig) (*metrics.InmemSink, *lib.PrometheusSink, error) {
 	// Setup telemetry
 	// Aggregate on 10 second intervals for 1 minute. Expose the
 	// metrics over stderr when there is a SIGUSR1 received.
@@ -241,16 +241,28 @@ func startupTelemetry(conf *config.RuntimeConfig) (*metrics.InmemSink, error) {
 	}
 
 	if err := addSink("statsite", statsiteSink); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	if err := addSink("statsd", statsdSink); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	if err := addSink("dogstatd", dogstatdSink); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	if err := addSink("circonus", circonusSink); err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	// The Prometheus sink is scraped through the agent's metrics endpoint
+	// rather than pushing anywhere, so it needs to be handed to the agent.
+	var promSink *lib.PrometheusSink
+	if conf.TelemetryPrometheusRetentionTime > 0 {
+		hostname := metricsConf.HostName
+		if conf.TelemetryDisableHostname {
+			hostname = ""
+		}
+		promSink = lib.NewPrometheusSink(conf.TelemetryPrometheusRetentionTime, hostname, conf.Datacenter)
+		sinks = append(sinks, promSink)
 	}
 
 	if len(sinks) > 0 {
@@ -260,7 +272,7 @@ func startupTelemetry(conf *config.RuntimeConfig) (*metrics.InmemSink, error) {
 		metricsConf.EnableHostname = false
 		metrics.NewGlobal(metricsConf, memSink)
 	}
-	return memSink, nil
+	return memSink, promSink, nil
 }
 
 func (cmd *AgentCommand) Run(args []string) int {
@@ -300,7 +312,7 @@ func (cmd *AgentCommand) run(args []string) int {
 	cmd.logOutput = logOutput
 	cmd.logger = log.New(logOutput, "", log.LstdFlags)
 
-	memSink, err := startupTelemetry(config)
+	memSink, promSink, err := startupTelemetry(config)
 	if err != nil {
 		cmd.UI.Error(err.Error())
 		return 1
@@ -316,6 +328,7 @@ func (cmd *AgentCommand) run(args []string) int {
 	agent.LogOutput = logOutput
 	agent.LogWriter = logWriter
 	agent.MemSink = memSink
+	agent.PromSink = promSink
 
 	if err := agent.Start(); err != nil {
 		cmd.UI.Error(fmt.Sprintf("Error starting agent: %s", err))
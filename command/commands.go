@@ -19,6 +19,87 @@ func init() {
 	ui := &cli.BasicUi{Writer: os.Stdout, ErrorWriter: os.Stderr}
 
 	Commands = map[string]cli.CommandFactory{
+		"acl": func() (cli.Command, error) {
+			return &ACLCommand{
+				BaseCommand: BaseCommand{
+					UI:    ui,
+					Flags: FlagSetNone,
+				},
+			}, nil
+		},
+
+		"acl bootstrap": func() (cli.Command, error) {
+			return &ACLBootstrapCommand{
+				BaseCommand: BaseCommand{
+					UI:    ui,
+					Flags: FlagSetHTTP,
+				},
+			}, nil
+		},
+
+		"acl clone": func() (cli.Command, error) {
+			return &ACLCloneCommand{
+				BaseCommand: BaseCommand{
+					UI:    ui,
+					Flags: FlagSetHTTP,
+				},
+			}, nil
+		},
+
+		"acl create": func() (cli.Command, error) {
+			return &ACLCreateCommand{
+				BaseCommand: BaseCommand{
+					UI:    ui,
+					Flags: FlagSetHTTP,
+				},
+			}, nil
+		},
+
+		"acl destroy": func() (cli.Command, error) {
+			return &ACLDestroyCommand{
+				BaseCommand: BaseCommand{
+					UI:    ui,
+					Flags: FlagSetHTTP,
+				},
+			}, nil
+		},
+
+		"acl info": func() (cli.Command, error) {
+			return &ACLInfoCommand{
+				BaseCommand: BaseCommand{
+					UI:    ui,
+					Flags: FlagSetHTTP,
+				},
+			}, nil
+		},
+
+		"acl list": func() (cli.Command, error) {
+			return &ACLListCommand{
+				BaseCommand: BaseCommand{
+					UI:    ui,
+					Flags: FlagSetHTTP,
+				},
+			}, nil
+		},
+
+		"acl replication": func() (cli.Command, error) {
+			return &ACLReplicationCommand{
+				BaseCommand: BaseCommand{
+					UI:    ui,
+					Flags: FlagSetHTTP,
+				},
+			}, nil
+		},
+
+		"acl update": func() (cli.Command, error) {
+			return &ACLUpdateCommand{
+				BaseCommand: BaseCommand{
+					UI:    ui,
+					Flags: FlagSetHTTP,
+				},
+			}, nil
+		},
+
 		"agent": func() (cli.Command, error) {
 			return &AgentCommand{
 				BaseCommand: BaseCommand{
@@ -169,6 +250,15 @@ func init() {
 			}, nil
 		},
 
+		"kv diff": func() (cli.Command, error) {
+			return &KVDiffCommand{
+				BaseCommand: BaseCommand{
+					UI:    ui,
+					Flags: FlagSetHTTP,
+				},
+			}, nil
+		},
+
 		"kv export": func() (cli.Command, error) {
 			return &KVExportCommand{
 				BaseCommand: BaseCommand{
@@ -270,6 +360,15 @@ func init() {
 			}, nil
 		},
 
+		"operator autopilot state": func() (cli.Command, error) {
+			return &OperatorAutopilotStateCommand{
+				BaseCommand: BaseCommand{
+					Flags: FlagSetHTTP,
+					UI:    ui,
+				},
+			}, nil
+		},
+
 		"operator raft": func() (cli.Command, error) {
 			return &OperatorRaftCommand{
 				BaseCommand: BaseCommand{
@@ -297,6 +396,78 @@ func init() {
 			}, nil
 		},
 
+		"query": func() (cli.Command, error) {
+			return &QueryCommand{
+				BaseCommand: BaseCommand{
+					UI:    ui,
+					Flags: FlagSetNone,
+				},
+			}, nil
+		},
+
+		"query create": func() (cli.Command, error) {
+			return &QueryCreateCommand{
+				BaseCommand: BaseCommand{
+					UI:    ui,
+					Flags: FlagSetHTTP,
+				},
+			}, nil
+		},
+
+		"query delete": func() (cli.Command, error) {
+			return &QueryDeleteCommand{
+				BaseCommand: BaseCommand{
+					UI:    ui,
+					Flags: FlagSetHTTP,
+				},
+			}, nil
+		},
+
+		"query execute": func() (cli.Command, error) {
+			return &QueryExecuteCommand{
+				BaseCommand: BaseCommand{
+					UI:    ui,
+					Flags: FlagSetHTTP,
+				},
+			}, nil
+		},
+
+		"query explain": func() (cli.Command, error) {
+			return &QueryExplainCommand{
+				BaseCommand: BaseCommand{
+					UI:    ui,
+					Flags: FlagSetHTTP,
+				},
+			}, nil
+		},
+
+		"query list": func() (cli.Command, error) {
+			return &QueryListCommand{
+				BaseCommand: BaseCommand{
+					UI:    ui,
+					Flags: FlagSetHTTP,
+				},
+			}, nil
+		},
+
+		"query read": func() (cli.Command, error) {
+			return &QueryReadCommand{
+				BaseCommand: BaseCommand{
+					UI:    ui,
+					Flags: FlagSetHTTP,
+				},
+			}, nil
+		},
+
+		"query update": func() (cli.Command, error) {
+			return &QueryUpdateCommand{
+				BaseCommand: BaseCommand{
+					UI:    ui,
+					Flags: FlagSetHTTP,
+				},
+			}, nil
+		},
+
 		"reload": func() (cli.Command, error) {
 			return &ReloadCommand{
 				BaseCommand: BaseCommand{
@@ -315,12 +486,103 @@ func init() {
 			}, nil
 		},
 
+		"services": func() (cli.Command, error) {
+			return &ServicesCommand{
+				BaseCommand: BaseCommand{
+					UI:    ui,
+					Flags: FlagSetNone,
+				},
+			}, nil
+		},
+
+		"services register": func() (cli.Command, error) {
+			return &ServicesRegisterCommand{
+				BaseCommand: BaseCommand{
+					UI:    ui,
+					Flags: FlagSetClientHTTP,
+				},
+			}, nil
+		},
+
+		"services deregister": func() (cli.Command, error) {
+			return &ServicesDeregisterCommand{
+				BaseCommand: BaseCommand{
+					UI:    ui,
+					Flags: FlagSetClientHTTP,
+				},
+			}, nil
+		},
+
+		"session": func() (cli.Command, error) {
+			return &SessionCommand{
+				BaseCommand: BaseCommand{
+					UI:    ui,
+					Flags: FlagSetNone,
+				},
+			}, nil
+		},
+
+		"session create": func() (cli.Command, error) {
+			return &SessionCreateCommand{
+				BaseCommand: BaseCommand{
+					UI:    ui,
+					Flags: FlagSetHTTP,
+				},
+			}, nil
+		},
+
+		"session destroy": func() (cli.Command, error) {
+			return &SessionDestroyCommand{
+				BaseCommand: BaseCommand{
+					UI:    ui,
+					Flags: FlagSetHTTP,
+				},
+			}, nil
+		},
+
+		"session info": func() (cli.Command, error) {
+			return &SessionInfoCommand{
+				BaseCommand: BaseCommand{
+					UI:    ui,
+					Flags: FlagSetHTTP,
+				},
+			}, nil
+		},
+
+		"session list": func() (cli.Command, error) {
+			return &SessionListCommand{
+				BaseCommand: BaseCommand{
+					UI:    ui,
+					Flags: FlagSetHTTP,
+				},
+			}, nil
+		},
+
+		"session renew": func() (cli.Command, error) {
+			return &SessionRenewCommand{
+				BaseCommand: BaseCommand{
+					UI:    ui,
+					Flags: FlagSetHTTP,
+				},
+			}, nil
+		},
+
 		"snapshot": func() (cli.Command, error) {
 			return &SnapshotCommand{
 				UI: ui,
 			}, nil
 		},
 
+		"snapshot agent": func() (cli.Command, error) {
+			return &SnapshotAgentCommand{
+				ShutdownCh: makeShutdownCh(),
+				BaseCommand: BaseCommand{
+					Flags: FlagSetHTTP,
+					UI:    ui,
+				},
+			}, nil
+		},
+
 		"snapshot restore": func() (cli.Command, error) {
 			return &SnapshotRestoreCommand{
 				BaseCommand: BaseCommand{
@@ -339,6 +601,15 @@ func init() {
 			}, nil
 		},
 
+		"snapshot export": func() (cli.Command, error) {
+			return &SnapshotExportCommand{
+				BaseCommand: BaseCommand{
+					Flags: FlagSetNone,
+					UI:    ui,
+				},
+			}, nil
+		},
+
 		"snapshot inspect": func() (cli.Command, error) {
 			return &SnapshotInspectCommand{
 				BaseCommand: BaseCommand{
@@ -0,0 +1,113 @@
+package command
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/consul/api"
+)
+
+// KVDiffCommand is a Command implementation that is used to compare a KV
+// tree stored as JSON to the KV store.
+type KVDiffCommand struct {
+	BaseCommand
+
+	// testStdin is the input for testing.
+	testStdin io.Reader
+}
+
+func (c *KVDiffCommand) Synopsis() string {
+	return "Compares a tree stored as JSON to the KV store"
+}
+
+func (c *KVDiffCommand) Help() string {
+	helpText := `
+Usage: consul kv diff [options] [DATA]
+
+  Compares key-value pairs in the format read by "consul kv import" to the
+  key-value store, and shows the keys that would be added, changed or removed
+  by importing them with the -delete-missing option:
+
+      $ consul kv diff -prefix-rewrite=staging/app/:prod/app/ @staging.json
+      + prod/app/feature
+      ~ prod/app/port
+      - prod/app/legacy
+
+  The data can be read from a file, stdin or a directory in the same way as
+  for "consul kv import".
+
+  For a full list of options and examples, please see the Consul documentation.
+
+` + c.BaseCommand.Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *KVDiffCommand) Run(args []string) int {
+	f := c.BaseCommand.NewFlagSet(c)
+	format := f.String("format", "json", kvInputFormatUsage)
+	prefixRewrite := f.String("prefix-rewrite", "",
+		"Rewrites the prefix of the keys in the data, given as \"old:new\". All the "+
+			"keys must start with the old prefix.")
+	prefix := f.String("prefix", "",
+		"Prefix the keys in the data must be under, and that is searched for "+
+			"removed keys. Defaults to the new prefix of -prefix-rewrite.")
+
+	if err := c.BaseCommand.Parse(args); err != nil {
+		return 1
+	}
+
+	rewrite, err := parseKVPrefixRewrite(*prefixRewrite)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error! %s", err))
+		return 1
+	}
+	if *prefix == "" && rewrite != nil {
+		*prefix = rewrite.to
+	}
+
+	// Check for arg validation
+	var stdin io.Reader = os.Stdin
+	if c.testStdin != nil {
+		stdin = c.testStdin
+	}
+	pairs, err := readKVPairs(f.Args(), stdin, *format, rewrite)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error! %s", err))
+		return 1
+	}
+	if err := checkKVScope(pairs, *prefix); err != nil {
+		c.UI.Error(fmt.Sprintf("Error! %s", err))
+		return 1
+	}
+
+	// Create and test the HTTP client
+	client, err := c.BaseCommand.HTTPClient()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error connecting to Consul agent: %s", err))
+		return 1
+	}
+
+	current, _, err := client.KV().List(*prefix, &api.QueryOptions{
+		AllowStale: c.BaseCommand.HTTPStale(),
+	})
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error querying Consul agent: %s", err))
+		return 1
+	}
+
+	for _, change := range diffKV(pairs, current, true) {
+		switch {
+		case change.Old == nil:
+			c.UI.Output("+ " + change.Key())
+		case change.New == nil:
+			c.UI.Output("- " + change.Key())
+		default:
+			c.UI.Output("~ " + change.Key())
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,105 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/consul/agent"
+	"github.com/hashicorp/consul/api"
+	"github.com/mitchellh/cli"
+)
+
+func TestKVDiffCommand_implements(t *testing.T) {
+	t.Parallel()
+	var _ cli.Command = &KVDiffCommand{}
+}
+
+func TestKVDiffCommand_noTabs(t *testing.T) {
+	t.Parallel()
+	assertNoTabs(t, new(KVDiffCommand))
+}
+
+func TestKVDiffCommand_Run(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t.Name(), ``)
+	defer a.Shutdown()
+	client := a.Client()
+
+	current := map[string]string{
+		"prod/app/port":   "8080",
+		"prod/app/same":   "same",
+		"prod/app/legacy": "old",
+		"other":           "untouched",
+	}
+	for k, v := range current {
+		pair := &api.KVPair{Key: k, Value: []byte(v)}
+		if _, err := client.KV().Put(pair, nil); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	const data = `
+staging:
+  app:
+    port: 9090
+    same: same
+    feature: true
+`
+
+	ui := cli.NewMockUi()
+	c := &KVDiffCommand{
+		BaseCommand: BaseCommand{
+			UI:    ui,
+			Flags: FlagSetHTTP,
+		},
+		testStdin: strings.NewReader(data),
+	}
+	args := []string{
+		"-http-addr=" + a.HTTPAddr(),
+		"-format=yaml",
+		"-prefix-rewrite=staging/app/:prod/app/",
+		"-",
+	}
+
+	code := c.Run(args)
+	if code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+
+	expected := "+ prod/app/feature\n- prod/app/legacy\n~ prod/app/port\n"
+	if output := ui.OutputWriter.String(); output != expected {
+		t.Fatalf("bad: %q", output)
+	}
+
+	// The diff must not change anything.
+	pair, _, err := client.KV().Get("prod/app/legacy", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if pair == nil || string(pair.Value) != "old" {
+		t.Fatalf("bad: %#v", pair)
+	}
+}
+
+func TestKVDiffCommand_OutsidePrefix(t *testing.T) {
+	t.Parallel()
+	ui := cli.NewMockUi()
+	c := &KVDiffCommand{
+		BaseCommand: BaseCommand{
+			UI:    ui,
+			Flags: FlagSetHTTP,
+		},
+	}
+	args := []string{
+		"-prefix=prod/",
+		`[{"key": "staging/a", "flags": 0, "value": ""}]`,
+	}
+
+	code := c.Run(args)
+	if code == 0 {
+		t.Fatalf("expected non-zero exit")
+	}
+	if !strings.Contains(ui.ErrorWriter.String(), "outside of the prefix") {
+		t.Fatalf("bad: %#v", ui.ErrorWriter.String())
+	}
+}
@@ -0,0 +1,449 @@
+package command
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/consul/api"
+	"gopkg.in/yaml.v2"
+)
+
+// kvTxnBatchSize is the number of operations sent in each transaction when
+// applying changes to the KV store. This is the most the transaction
+// endpoint accepts.
+const kvTxnBatchSize = 64
+
+// kvInputFormatUsage is the usage text for the flags that select the format
+// of the data read by the KV commands.
+const kvInputFormatUsage = "Format of the data. One of \"json\", \"yaml\" or \"dir\". " +
+	"\"json\" is the format written by \"consul kv export\". \"yaml\" is either " +
+	"the same list of entries, or a tree of nested maps with plain text " +
+	"values. \"dir\" reads a directory tree, with each file making a key."
+
+// kvPrefixRewrite replaces the prefix of the keys moved between the KV store
+// and a file.
+type kvPrefixRewrite struct {
+	from, to string
+}
+
+// parseKVPrefixRewrite parses the "old:new" value of the -prefix-rewrite flag.
+// This returns nil if no rewrite was given.
+func parseKVPrefixRewrite(s string) (*kvPrefixRewrite, error) {
+	if s == "" {
+		return nil, nil
+	}
+	parts := strings.SplitN(s, ":", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return nil, fmt.Errorf("Invalid prefix rewrite %q, must be of the form \"old:new\"", s)
+	}
+	return &kvPrefixRewrite{from: parts[0], to: parts[1]}, nil
+}
+
+// Apply returns the key with its prefix rewritten. It's an error for the key
+// not to start with the prefix being rewritten.
+func (r *kvPrefixRewrite) Apply(key string) (string, error) {
+	if r == nil {
+		return key, nil
+	}
+	if !strings.HasPrefix(key, r.from) {
+		return "", fmt.Errorf("Key %q does not start with the rewritten prefix %q", key, r.from)
+	}
+	return r.to + strings.TrimPrefix(key, r.from), nil
+}
+
+// kvDataFromArgs returns the data given as the single argument of a KV
+// command. The data is read from a file if the argument starts with "@", or
+// from stdin if it is "-".
+func kvDataFromArgs(args []string, stdin io.Reader) (string, error) {
+	switch len(args) {
+	case 0:
+		return "", errors.New("Missing DATA argument")
+	case 1:
+	default:
+		return "", fmt.Errorf("Too many arguments (expected 1, got %d)", len(args))
+	}
+
+	data := args[0]
+
+	if len(data) == 0 {
+		return "", errors.New("Empty DATA argument")
+	}
+
+	switch data[0] {
+	case '@':
+		data, err := ioutil.ReadFile(data[1:])
+		if err != nil {
+			return "", fmt.Errorf("Failed to read file: %s", err)
+		}
+		return string(data), nil
+	case '-':
+		if len(data) > 1 {
+			return data, nil
+		}
+		var b bytes.Buffer
+		if _, err := io.Copy(&b, stdin); err != nil {
+			return "", fmt.Errorf("Failed to read stdin: %s", err)
+		}
+		return b.String(), nil
+	default:
+		return data, nil
+	}
+}
+
+// readKVPairs reads the pairs given as the arguments of a KV command in the
+// given format, and rewrites their keys.
+func readKVPairs(args []string, stdin io.Reader, format string, rewrite *kvPrefixRewrite) ([]*api.KVPair, error) {
+	var pairs []*api.KVPair
+	switch format {
+	case "json", "yaml":
+		data, err := kvDataFromArgs(args, stdin)
+		if err != nil {
+			return nil, err
+		}
+		if format == "json" {
+			pairs, err = decodeKVJSON([]byte(data))
+		} else {
+			pairs, err = decodeKVYAML([]byte(data))
+		}
+		if err != nil {
+			return nil, err
+		}
+
+	case "dir":
+		switch len(args) {
+		case 0:
+			return nil, errors.New("Missing DIR argument")
+		case 1:
+		default:
+			return nil, fmt.Errorf("Too many arguments (expected 1, got %d)", len(args))
+		}
+		var err error
+		if pairs, err = readKVDir(args[0]); err != nil {
+			return nil, err
+		}
+
+	default:
+		return nil, fmt.Errorf("Invalid format %q, must be \"json\", \"yaml\" or \"dir\"", format)
+	}
+
+	seen := make(map[string]bool)
+	for _, pair := range pairs {
+		key, err := rewrite.Apply(pair.Key)
+		if err != nil {
+			return nil, err
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("Duplicate key %q", key)
+		}
+		seen[key] = true
+		pair.Key = key
+	}
+	return pairs, nil
+}
+
+// decodeKVJSON decodes the entries written by "consul kv export".
+func decodeKVJSON(data []byte) ([]*api.KVPair, error) {
+	var entries []*kvExportEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("Cannot unmarshal data: %s", err)
+	}
+	return fromExportEntries(entries)
+}
+
+// decodeKVYAML decodes either a list of the entries written by "consul kv
+// export", or a tree of nested maps where each map is a folder and each
+// scalar is a plain text value.
+func decodeKVYAML(data []byte) ([]*api.KVPair, error) {
+	var raw interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("Cannot unmarshal data: %s", err)
+	}
+
+	switch raw.(type) {
+	case nil:
+		return nil, nil
+	case []interface{}:
+		var entries []*kvExportEntry
+		if err := yaml.Unmarshal(data, &entries); err != nil {
+			return nil, fmt.Errorf("Cannot unmarshal data: %s", err)
+		}
+		return fromExportEntries(entries)
+	case map[interface{}]interface{}:
+		var pairs []*api.KVPair
+		if err := flattenKVTree("", raw, &pairs); err != nil {
+			return nil, err
+		}
+		return pairs, nil
+	default:
+		return nil, errors.New("Cannot unmarshal data: must be a list of entries or a map of keys")
+	}
+}
+
+// flattenKVTree adds a pair for each value found in a tree decoded from YAML.
+// Empty maps are added as folders, with a trailing "/".
+func flattenKVTree(key string, node interface{}, pairs *[]*api.KVPair) error {
+	switch node := node.(type) {
+	case map[interface{}]interface{}:
+		if len(node) == 0 && key != "" {
+			*pairs = append(*pairs, &api.KVPair{Key: key + "/"})
+			return nil
+		}
+		for k, v := range node {
+			child := fmt.Sprintf("%v", k)
+			if key != "" {
+				child = key + "/" + child
+			}
+			if err := flattenKVTree(child, v, pairs); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		return fmt.Errorf("Unsupported list value for key %q", key)
+	case nil:
+		*pairs = append(*pairs, &api.KVPair{Key: key})
+	default:
+		*pairs = append(*pairs, &api.KVPair{Key: key, Value: []byte(fmt.Sprintf("%v", node))})
+	}
+	return nil
+}
+
+// readKVDir reads a directory tree, adding a pair for each file keyed by its
+// path relative to the directory.
+func readKVDir(dir string) ([]*api.KVPair, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read directory: %s", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("Failed to read directory: %s is not a directory", dir)
+	}
+
+	var pairs []*api.KVPair
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		value, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		pairs = append(pairs, &api.KVPair{Key: filepath.ToSlash(rel), Value: value})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read directory: %s", err)
+	}
+	return pairs, nil
+}
+
+// fromExportEntries decodes the base64 values of exported entries.
+func fromExportEntries(entries []*kvExportEntry) ([]*api.KVPair, error) {
+	pairs := make([]*api.KVPair, 0, len(entries))
+	for _, entry := range entries {
+		value, err := base64.StdEncoding.DecodeString(entry.Value)
+		if err != nil {
+			return nil, fmt.Errorf("Error base 64 decoding value for key %s: %s", entry.Key, err)
+		}
+		pairs = append(pairs, &api.KVPair{
+			Key:   entry.Key,
+			Flags: entry.Flags,
+			Value: value,
+		})
+	}
+	return pairs, nil
+}
+
+// kvChange is a change to a single key. Old is nil if the key is added, and
+// New is nil if the key is removed.
+type kvChange struct {
+	Old *api.KVPair
+	New *api.KVPair
+}
+
+// Key returns the key being changed.
+func (c *kvChange) Key() string {
+	if c.New != nil {
+		return c.New.Key
+	}
+	return c.Old.Key
+}
+
+// diffKV returns the changes needed to turn the current pairs into the given
+// ones, sorted by key. Current pairs that aren't given are only removed if
+// deleteMissing is set.
+func diffKV(pairs []*api.KVPair, current api.KVPairs, deleteMissing bool) []*kvChange {
+	existing := make(map[string]*api.KVPair, len(current))
+	for _, pair := range current {
+		existing[pair.Key] = pair
+	}
+
+	var changes []*kvChange
+	for _, pair := range pairs {
+		old, ok := existing[pair.Key]
+		delete(existing, pair.Key)
+		switch {
+		case !ok:
+			changes = append(changes, &kvChange{New: pair})
+		case old.Flags != pair.Flags || !bytes.Equal(old.Value, pair.Value):
+			changes = append(changes, &kvChange{Old: old, New: pair})
+		}
+	}
+	if deleteMissing {
+		for _, old := range existing {
+			changes = append(changes, &kvChange{Old: old})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key() < changes[j].Key()
+	})
+	return changes
+}
+
+// checkKVScope returns an error if any of the pairs isn't under the prefix.
+func checkKVScope(pairs []*api.KVPair, prefix string) error {
+	for _, pair := range pairs {
+		if !strings.HasPrefix(pair.Key, prefix) {
+			return fmt.Errorf("Key %q is outside of the prefix %q", pair.Key, prefix)
+		}
+	}
+	return nil
+}
+
+// kvCommonPrefix returns the longest prefix shared by the keys of the pairs.
+func kvCommonPrefix(pairs []*api.KVPair) string {
+	if len(pairs) == 0 {
+		return ""
+	}
+	prefix := pairs[0].Key
+	for _, pair := range pairs[1:] {
+		i := 0
+		for i < len(prefix) && i < len(pair.Key) && prefix[i] == pair.Key[i] {
+			i++
+		}
+		prefix = prefix[:i]
+	}
+	return prefix
+}
+
+// applyKVChanges makes the changes through the transaction endpoint, in
+// batches of check-and-set operations, so nothing is overwritten if a key
+// was modified since it was read. If a batch fails, the batches that were
+// already applied are rolled back, in reverse order.
+func applyKVChanges(kv *api.KV, changes []*kvChange) error {
+	var applied []api.KVTxnOps
+	for start := 0; start < len(changes); start += kvTxnBatchSize {
+		end := start + kvTxnBatchSize
+		if end > len(changes) {
+			end = len(changes)
+		}
+		batch := changes[start:end]
+
+		ops := make(api.KVTxnOps, 0, len(batch))
+		for _, change := range batch {
+			ops = append(ops, kvChangeOp(change))
+		}
+		ok, resp, _, err := kv.Txn(ops, nil)
+		if err == nil && !ok {
+			err = kvTxnError(ops, resp)
+		}
+		if err != nil {
+			if rbErr := rollbackKVChanges(kv, applied); rbErr != nil {
+				return fmt.Errorf("%s; failed rolling back the previous changes: %s", err, rbErr)
+			}
+			return err
+		}
+
+		// The results hold the new index of each key that was set, which
+		// is needed to undo the batch.
+		indexes := make(map[string]uint64)
+		for _, pair := range resp.Results {
+			indexes[pair.Key] = pair.ModifyIndex
+		}
+		undo := make(api.KVTxnOps, 0, len(batch))
+		for _, change := range batch {
+			undo = append(undo, kvUndoOp(change, indexes[change.Key()]))
+		}
+		applied = append(applied, undo)
+	}
+	return nil
+}
+
+// rollbackKVChanges undoes the batches that were applied, in reverse order.
+func rollbackKVChanges(kv *api.KV, applied []api.KVTxnOps) error {
+	for i := len(applied) - 1; i >= 0; i-- {
+		ok, resp, _, err := kv.Txn(applied[i], nil)
+		if err == nil && !ok {
+			err = kvTxnError(applied[i], resp)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// kvChangeOp returns the check-and-set operation that makes the change.
+func kvChangeOp(change *kvChange) *api.KVTxnOp {
+	var index uint64
+	if change.Old != nil {
+		index = change.Old.ModifyIndex
+	}
+	if change.New == nil {
+		return &api.KVTxnOp{Verb: api.KVDeleteCAS, Key: change.Old.Key, Index: index}
+	}
+	return &api.KVTxnOp{
+		Verb:  api.KVCAS,
+		Key:   change.New.Key,
+		Flags: change.New.Flags,
+		Value: change.New.Value,
+		Index: index,
+	}
+}
+
+// kvUndoOp returns the check-and-set operation that undoes the change, given
+// the index it was made at.
+func kvUndoOp(change *kvChange, index uint64) *api.KVTxnOp {
+	if change.Old == nil {
+		return &api.KVTxnOp{Verb: api.KVDeleteCAS, Key: change.New.Key, Index: index}
+	}
+	return &api.KVTxnOp{
+		Verb:  api.KVCAS,
+		Key:   change.Old.Key,
+		Flags: change.Old.Flags,
+		Value: change.Old.Value,
+		Index: index,
+	}
+}
+
+// kvTxnError returns an error describing why a transaction was rolled back.
+func kvTxnError(ops api.KVTxnOps, resp *api.KVTxnResponse) error {
+	if resp == nil || len(resp.Errors) == 0 {
+		return errors.New("Transaction was rolled back")
+	}
+	var msgs []string
+	for _, e := range resp.Errors {
+		key := ""
+		if e.OpIndex >= 0 && e.OpIndex < len(ops) {
+			key = ops[e.OpIndex].Key
+		}
+		msgs = append(msgs, fmt.Sprintf("key %s: %s", key, e.What))
+	}
+	return fmt.Errorf("Transaction was rolled back: %s", strings.Join(msgs, ", "))
+}
@@ -0,0 +1,216 @@
+package command
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/consul/agent"
+	"github.com/hashicorp/consul/api"
+)
+
+func TestKVPrefixRewrite(t *testing.T) {
+	t.Parallel()
+	for _, s := range []string{"nope", ":new/"} {
+		if _, err := parseKVPrefixRewrite(s); err == nil {
+			t.Fatalf("%q: expected an error", s)
+		}
+	}
+
+	rewrite, err := parseKVPrefixRewrite("staging/app/:prod/app/")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	key, err := rewrite.Apply("staging/app/port")
+	if err != nil || key != "prod/app/port" {
+		t.Fatalf("bad: %q %v", key, err)
+	}
+	if _, err := rewrite.Apply("prod/app/port"); err == nil {
+		t.Fatalf("expected an error")
+	}
+
+	// No rewrite leaves the keys as they are.
+	rewrite, err = parseKVPrefixRewrite("")
+	if err != nil || rewrite != nil {
+		t.Fatalf("bad: %#v %v", rewrite, err)
+	}
+	if key, err := rewrite.Apply("foo"); err != nil || key != "foo" {
+		t.Fatalf("bad: %q %v", key, err)
+	}
+}
+
+func TestKVDecodeYAML(t *testing.T) {
+	t.Parallel()
+	kvs := func(pairs []*api.KVPair) map[string]string {
+		out := make(map[string]string)
+		for _, pair := range pairs {
+			out[pair.Key] = fmt.Sprintf("%s|%d", pair.Value, pair.Flags)
+		}
+		return out
+	}
+
+	// A tree of maps.
+	pairs, err := decodeKVYAML([]byte(`
+app:
+  port: 8080
+  debug: false
+  name: web
+  empty:
+  folder: {}
+`))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	expected := map[string]string{
+		"app/port":    "8080|0",
+		"app/debug":   "false|0",
+		"app/name":    "web|0",
+		"app/empty":   "|0",
+		"app/folder/": "|0",
+	}
+	if actual := kvs(pairs); !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("bad: %#v", actual)
+	}
+
+	// A list of exported entries.
+	pairs, err = decodeKVYAML([]byte(`
+- key: foo
+  flags: 42
+  value: YmFy
+`))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if actual := kvs(pairs); !reflect.DeepEqual(actual, map[string]string{"foo": "bar|42"}) {
+		t.Fatalf("bad: %#v", actual)
+	}
+
+	// Lists of values aren't supported in trees.
+	_, err = decodeKVYAML([]byte("app:\n  hosts: [a, b]\n"))
+	if err == nil || !strings.Contains(err.Error(), "app/hosts") {
+		t.Fatalf("bad: %v", err)
+	}
+}
+
+func TestKVDiff(t *testing.T) {
+	t.Parallel()
+	current := api.KVPairs{
+		{Key: "a", Value: []byte("1"), ModifyIndex: 10},
+		{Key: "b", Value: []byte("2"), ModifyIndex: 11},
+		{Key: "c", Value: []byte("3"), Flags: 1, ModifyIndex: 12},
+		{Key: "d", Value: []byte("4"), ModifyIndex: 13},
+	}
+	pairs := []*api.KVPair{
+		{Key: "e", Value: []byte("5")},
+		{Key: "a", Value: []byte("1")},
+		{Key: "b", Value: []byte("changed")},
+		{Key: "c", Value: []byte("3")},
+	}
+
+	summary := func(changes []*kvChange) []string {
+		var out []string
+		for _, change := range changes {
+			switch {
+			case change.Old == nil:
+				out = append(out, "+"+change.Key())
+			case change.New == nil:
+				out = append(out, "-"+change.Key())
+			default:
+				out = append(out, "~"+change.Key())
+			}
+		}
+		return out
+	}
+
+	changes := diffKV(pairs, current, false)
+	if actual := summary(changes); !reflect.DeepEqual(actual, []string{"~b", "~c", "+e"}) {
+		t.Fatalf("bad: %#v", actual)
+	}
+	changes = diffKV(pairs, current, true)
+	if actual := summary(changes); !reflect.DeepEqual(actual, []string{"~b", "~c", "-d", "+e"}) {
+		t.Fatalf("bad: %#v", actual)
+	}
+
+	// The operations check the index the keys were read at.
+	ops := make([]*api.KVTxnOp, 0, len(changes))
+	for _, change := range changes {
+		ops = append(ops, kvChangeOp(change))
+	}
+	if ops[0].Verb != api.KVCAS || ops[0].Index != 11 ||
+		ops[2].Verb != api.KVDeleteCAS || ops[2].Index != 13 ||
+		ops[3].Verb != api.KVCAS || ops[3].Index != 0 {
+		t.Fatalf("bad: %#v", ops)
+	}
+}
+
+func TestKVCommonPrefix(t *testing.T) {
+	t.Parallel()
+	pairs := func(keys ...string) []*api.KVPair {
+		var out []*api.KVPair
+		for _, key := range keys {
+			out = append(out, &api.KVPair{Key: key})
+		}
+		return out
+	}
+	cases := []struct {
+		keys []string
+		want string
+	}{
+		{nil, ""},
+		{[]string{"app/port"}, "app/port"},
+		{[]string{"app/port", "app/peers", "app/host"}, "app/"},
+		{[]string{"app/port", "db/port"}, ""},
+	}
+	for _, tc := range cases {
+		if got := kvCommonPrefix(pairs(tc.keys...)); got != tc.want {
+			t.Errorf("%v: got %q want %q", tc.keys, got, tc.want)
+		}
+	}
+}
+
+func TestKVApplyChanges_Rollback(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t.Name(), ``)
+	defer a.Shutdown()
+	kv := a.Client().KV()
+
+	// Set up a key that will make the second batch fail, since it's added
+	// as if it didn't exist, and one that the first batch deletes.
+	for _, key := range []string{"conflict", "deleted"} {
+		if _, err := kv.Put(&api.KVPair{Key: key, Value: []byte("before")}, nil); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+	deleted, _, err := kv.Get("deleted", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	changes := []*kvChange{{Old: deleted}}
+	for i := 0; i < kvTxnBatchSize+5; i++ {
+		key := fmt.Sprintf("key%03d", i)
+		changes = append(changes, &kvChange{New: &api.KVPair{Key: key, Value: []byte("new")}})
+	}
+	changes = append(changes, &kvChange{New: &api.KVPair{Key: "conflict", Value: []byte("after")}})
+
+	err = applyKVChanges(kv, changes)
+	if err == nil || !strings.Contains(err.Error(), "conflict") {
+		t.Fatalf("bad: %v", err)
+	}
+
+	// Everything is back to how it was.
+	pairs, _, err := kv.List("", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	var keys []string
+	for _, pair := range pairs {
+		keys = append(keys, pair.Key+"="+string(pair.Value))
+	}
+	sort.Strings(keys)
+	if !reflect.DeepEqual(keys, []string{"conflict=before", "deleted=before"}) {
+		t.Fatalf("bad: %#v", keys)
+	}
+}
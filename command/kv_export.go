@@ -21,7 +21,7 @@ func (c *KVExportCommand) Synopsis() string {
 
 func (c *KVExportCommand) Help() string {
 	helpText := `
-Usage: consul kv export [KEY_OR_PREFIX]
+Usage: consul kv export [options] [KEY_OR_PREFIX]
 
   Retrieves key-value pairs for the given prefix from Consul's key-value store,
   and writes a JSON representation to stdout. This can be used with the command
@@ -29,6 +29,11 @@ Usage: consul kv export [KEY_OR_PREFIX]
 
       $ consul kv export vault
 
+  The prefix of the exported keys can be rewritten, for example to import
+  them under another prefix:
+
+      $ consul kv export -prefix-rewrite=staging/app/:prod/app/ staging/app/
+
   For a full list of options and examples, please see the Consul documentation.
 
 ` + c.BaseCommand.Help()
@@ -38,10 +43,19 @@ Usage: consul kv export [KEY_OR_PREFIX]
 
 func (c *KVExportCommand) Run(args []string) int {
 	f := c.BaseCommand.NewFlagSet(c)
+	prefixRewrite := f.String("prefix-rewrite", "",
+		"Rewrites the prefix of the exported keys, given as \"old:new\". All the "+
+			"exported keys must start with the old prefix.")
 	if err := c.BaseCommand.Parse(args); err != nil {
 		return 1
 	}
 
+	rewrite, err := parseKVPrefixRewrite(*prefixRewrite)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
 	key := ""
 	// Check for arg validation
 	args = f.Args()
@@ -80,6 +94,10 @@ func (c *KVExportCommand) Run(args []string) int {
 	exported := make([]*kvExportEntry, len(pairs))
 	for i, pair := range pairs {
 		exported[i] = toExportEntry(pair)
+		if exported[i].Key, err = rewrite.Apply(pair.Key); err != nil {
+			c.UI.Error(err.Error())
+			return 1
+		}
 	}
 
 	marshaled, err := json.MarshalIndent(exported, "", "\t")
@@ -65,3 +65,46 @@ func TestKVExportCommand_Run(t *testing.T) {
 		}
 	}
 }
+
+func TestKVExportCommand_PrefixRewrite(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t.Name(), ``)
+	defer a.Shutdown()
+	client := a.Client()
+
+	for _, k := range []string{"staging/app/a", "staging/app/b"} {
+		pair := &api.KVPair{Key: k, Value: []byte(k)}
+		if _, err := client.KV().Put(pair, nil); err != nil {
+			t.Fatalf("err: %#v", err)
+		}
+	}
+
+	ui := cli.NewMockUi()
+	c := KVExportCommand{
+		BaseCommand: BaseCommand{
+			UI:    ui,
+			Flags: FlagSetHTTP,
+		},
+	}
+	args := []string{
+		"-http-addr=" + a.HTTPAddr(),
+		"-prefix-rewrite=staging/app/:prod/app/",
+		"staging/app/",
+	}
+
+	code := c.Run(args)
+	if code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+
+	var exported []*kvExportEntry
+	if err := json.Unmarshal(ui.OutputWriter.Bytes(), &exported); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(exported) != 2 || exported[0].Key != "prod/app/a" || exported[1].Key != "prod/app/b" {
+		t.Fatalf("bad: %#v", exported)
+	}
+	if exported[0].Value != base64.StdEncoding.EncodeToString([]byte("staging/app/a")) {
+		t.Fatalf("bad: %#v", exported[0])
+	}
+}
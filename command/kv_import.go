@@ -1,13 +1,8 @@
 package command
 
 import (
-	"bytes"
-	"encoding/base64"
-	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"os"
 	"strings"
 
@@ -29,7 +24,7 @@ func (c *KVImportCommand) Synopsis() string {
 
 func (c *KVImportCommand) Help() string {
 	helpText := `
-Usage: consul kv import [DATA]
+Usage: consul kv import [options] [DATA]
 
   Imports key-value pairs to the key-value store from the JSON representation
   generated by the "consul kv export" command.
@@ -46,6 +41,18 @@ Usage: consul kv import [DATA]
   Alternatively the data may be provided as the final parameter to the command,
   though care must be taken with regards to shell escaping.
 
+  The data can also be YAML, or a directory tree where each file is a key:
+
+      $ consul kv import -format=dir ./config
+
+  Only the keys that are added or changed are written, in batches of
+  transactions that fail if a key was modified in the meantime. If a batch
+  fails, the batches already written are rolled back. To move a tree to
+  another prefix, and delete the keys under it that aren't in the data:
+
+      $ consul kv import -prefix-rewrite=staging/app/:prod/app/ \
+          -delete-missing -dry-run @staging.json
+
   For a full list of options and examples, please see the Consul documentation.
 
 ` + c.BaseCommand.Help()
@@ -55,18 +62,53 @@ Usage: consul kv import [DATA]
 
 func (c *KVImportCommand) Run(args []string) int {
 	f := c.BaseCommand.NewFlagSet(c)
+	format := f.String("format", "json", kvInputFormatUsage)
+	prefixRewrite := f.String("prefix-rewrite", "",
+		"Rewrites the prefix of the imported keys, given as \"old:new\". All the "+
+			"keys must start with the old prefix.")
+	prefix := f.String("prefix", "",
+		"Prefix the imported keys must be under, and that is searched for missing "+
+			"keys with -delete-missing. Defaults to the new prefix of -prefix-rewrite.")
+	deleteMissing := f.Bool("delete-missing", false,
+		"Deletes the keys under the prefix that aren't in the data, so the prefix "+
+			"holds exactly the imported keys. Requires a non-empty prefix.")
+	dryRun := f.Bool("dry-run", false,
+		"Shows the keys that would be imported or deleted, without changing them.")
 
 	if err := c.BaseCommand.Parse(args); err != nil {
 		return 1
 	}
 
+	rewrite, err := parseKVPrefixRewrite(*prefixRewrite)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error! %s", err))
+		return 1
+	}
+	if *prefix == "" && rewrite != nil {
+		*prefix = rewrite.to
+	}
+
+	// Deleting the missing keys from the root would wipe everything else in
+	// the KV store.
+	if *deleteMissing && *prefix == "" {
+		c.UI.Error("Error! -delete-missing requires a non-empty -prefix or -prefix-rewrite")
+		return 1
+	}
+
 	// Check for arg validation
-	args = f.Args()
-	data, err := c.dataFromArgs(args)
+	var stdin io.Reader = os.Stdin
+	if c.testStdin != nil {
+		stdin = c.testStdin
+	}
+	pairs, err := readKVPairs(f.Args(), stdin, *format, rewrite)
 	if err != nil {
 		c.UI.Error(fmt.Sprintf("Error! %s", err))
 		return 1
 	}
+	if err := checkKVScope(pairs, *prefix); err != nil {
+		c.UI.Error(fmt.Sprintf("Error! %s", err))
+		return 1
+	}
 
 	// Create and test the HTTP client
 	client, err := c.BaseCommand.HTTPClient()
@@ -75,73 +117,46 @@ func (c *KVImportCommand) Run(args []string) int {
 		return 1
 	}
 
-	var entries []*kvExportEntry
-	if err := json.Unmarshal([]byte(data), &entries); err != nil {
-		c.UI.Error(fmt.Sprintf("Cannot unmarshal data: %s", err))
-		return 1
-	}
-
-	for _, entry := range entries {
-		value, err := base64.StdEncoding.DecodeString(entry.Value)
-		if err != nil {
-			c.UI.Error(fmt.Sprintf("Error base 64 decoding value for key %s: %s", entry.Key, err))
-			return 1
-		}
-
-		pair := &api.KVPair{
-			Key:   entry.Key,
-			Flags: entry.Flags,
-			Value: value,
+	// The whole prefix is only read to find the missing keys. Otherwise
+	// only the imported keys are compared, so reading the prefix they
+	// share is enough.
+	var current api.KVPairs
+	if *deleteMissing || len(pairs) > 0 {
+		listPrefix := *prefix
+		if !*deleteMissing {
+			listPrefix = kvCommonPrefix(pairs)
 		}
-
-		if _, err := client.KV().Put(pair, nil); err != nil {
-			c.UI.Error(fmt.Sprintf("Error! Failed writing data for key %s: %s", pair.Key, err))
+		current, _, err = client.KV().List(listPrefix, nil)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error querying Consul agent: %s", err))
 			return 1
 		}
-
-		c.UI.Info(fmt.Sprintf("Imported: %s", pair.Key))
 	}
-
-	return 0
-}
-
-func (c *KVImportCommand) dataFromArgs(args []string) (string, error) {
-	var stdin io.Reader = os.Stdin
-	if c.testStdin != nil {
-		stdin = c.testStdin
-	}
-
-	switch len(args) {
-	case 0:
-		return "", errors.New("Missing DATA argument")
-	case 1:
-	default:
-		return "", fmt.Errorf("Too many arguments (expected 1, got %d)", len(args))
+	changes := diffKV(pairs, current, *deleteMissing)
+
+	if *dryRun {
+		for _, change := range changes {
+			if change.New == nil {
+				c.UI.Info(fmt.Sprintf("Would delete: %s", change.Key()))
+			} else {
+				c.UI.Info(fmt.Sprintf("Would import: %s", change.Key()))
+			}
+		}
+		return 0
 	}
 
-	data := args[0]
-
-	if len(data) == 0 {
-		return "", errors.New("Empty DATA argument")
+	if err := applyKVChanges(client.KV(), changes); err != nil {
+		c.UI.Error(fmt.Sprintf("Error! Failed writing data: %s", err))
+		return 1
 	}
 
-	switch data[0] {
-	case '@':
-		data, err := ioutil.ReadFile(data[1:])
-		if err != nil {
-			return "", fmt.Errorf("Failed to read file: %s", err)
-		}
-		return string(data), nil
-	case '-':
-		if len(data) > 1 {
-			return data, nil
-		}
-		var b bytes.Buffer
-		if _, err := io.Copy(&b, stdin); err != nil {
-			return "", fmt.Errorf("Failed to read stdin: %s", err)
+	for _, change := range changes {
+		if change.New == nil {
+			c.UI.Info(fmt.Sprintf("Deleted: %s", change.Key()))
+		} else {
+			c.UI.Info(fmt.Sprintf("Imported: %s", change.Key()))
 		}
-		return b.String(), nil
-	default:
-		return data, nil
 	}
+
+	return 0
 }
@@ -1,10 +1,16 @@
 package command
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 
 	"github.com/hashicorp/consul/agent"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/testutil"
 	"github.com/mitchellh/cli"
 )
 
@@ -64,3 +70,177 @@ func TestKVImportCommand_Run(t *testing.T) {
 		t.Fatalf("bad: expected: baz, got %s", pair.Value)
 	}
 }
+
+func TestKVImportCommand_Sync(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t.Name(), ``)
+	defer a.Shutdown()
+	client := a.Client()
+
+	current := map[string]string{
+		"prod/app/port":   "8080",
+		"prod/app/legacy": "old",
+		"other":           "untouched",
+	}
+	for k, v := range current {
+		pair := &api.KVPair{Key: k, Value: []byte(v)}
+		if _, err := client.KV().Put(pair, nil); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	// Values are "9090" and "on".
+	const json = `[
+		{"key": "staging/app/port", "flags": 0, "value": "OTA5MA=="},
+		{"key": "staging/app/feature", "flags": 42, "value": "b24="}
+	]`
+
+	run := func(extra ...string) string {
+		ui := cli.NewMockUi()
+		c := &KVImportCommand{
+			BaseCommand: BaseCommand{
+				UI:    ui,
+				Flags: FlagSetHTTP,
+			},
+			testStdin: strings.NewReader(json),
+		}
+		args := append([]string{
+			"-http-addr=" + a.HTTPAddr(),
+			"-prefix-rewrite=staging/app/:prod/app/",
+			"-delete-missing",
+		}, extra...)
+		args = append(args, "-")
+
+		code := c.Run(args)
+		if code != 0 {
+			t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+		}
+		return ui.OutputWriter.String()
+	}
+
+	// A dry run only shows the changes.
+	output := run("-dry-run")
+	expected := "Would import: prod/app/feature\nWould delete: prod/app/legacy\nWould import: prod/app/port\n"
+	if output != expected {
+		t.Fatalf("bad: %q", output)
+	}
+	pair, _, err := client.KV().Get("prod/app/legacy", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pair == nil {
+		t.Fatalf("should not be deleted by a dry run")
+	}
+
+	output = run()
+	expected = "Imported: prod/app/feature\nDeleted: prod/app/legacy\nImported: prod/app/port\n"
+	if output != expected {
+		t.Fatalf("bad: %q", output)
+	}
+
+	pairs, _, err := client.KV().List("", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual := make(map[string]string)
+	for _, pair := range pairs {
+		actual[pair.Key] = string(pair.Value)
+	}
+	want := map[string]string{
+		"other":            "untouched",
+		"prod/app/feature": "on",
+		"prod/app/port":    "9090",
+	}
+	if !reflect.DeepEqual(actual, want) {
+		t.Fatalf("bad: %#v", actual)
+	}
+	pair, _, err = client.KV().Get("prod/app/feature", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pair.Flags != 42 {
+		t.Fatalf("bad: %#v", pair)
+	}
+
+	// Importing again is a no-op.
+	if output := run(); output != "" {
+		t.Fatalf("bad: %q", output)
+	}
+
+	// Deleting the missing keys needs a prefix, even for empty data.
+	for _, data := range []string{json, "[]"} {
+		ui := cli.NewMockUi()
+		c := &KVImportCommand{
+			BaseCommand: BaseCommand{
+				UI:    ui,
+				Flags: FlagSetHTTP,
+			},
+			testStdin: strings.NewReader(data),
+		}
+		code := c.Run([]string{"-http-addr=" + a.HTTPAddr(), "-delete-missing", "-"})
+		if code != 1 {
+			t.Fatalf("bad: %d. %#v", code, ui.OutputWriter.String())
+		}
+		if !strings.Contains(ui.ErrorWriter.String(), "requires a non-empty") {
+			t.Fatalf("bad: %#v", ui.ErrorWriter.String())
+		}
+	}
+	pair, _, err = client.KV().Get("other", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pair == nil {
+		t.Fatalf("should not be deleted")
+	}
+}
+
+func TestKVImportCommand_Dir(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t.Name(), ``)
+	defer a.Shutdown()
+	client := a.Client()
+
+	dir := testutil.TempDir(t, "kv")
+	defer os.RemoveAll(dir)
+	if err := os.MkdirAll(filepath.Join(dir, "app", "db"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		filepath.Join(dir, "app", "name"):       "web",
+		filepath.Join(dir, "app", "db", "host"): "db.local",
+	}
+	for path, value := range files {
+		if err := ioutil.WriteFile(path, []byte(value), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ui := cli.NewMockUi()
+	c := &KVImportCommand{
+		BaseCommand: BaseCommand{
+			UI:    ui,
+			Flags: FlagSetHTTP,
+		},
+	}
+	args := []string{
+		"-http-addr=" + a.HTTPAddr(),
+		"-format=dir",
+		"-prefix-rewrite=app/:config/",
+		dir,
+	}
+
+	code := c.Run(args)
+	if code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+
+	for key, value := range map[string]string{"config/name": "web", "config/db/host": "db.local"} {
+		pair, _, err := client.KV().Get(key, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pair == nil || string(pair.Value) != value {
+			t.Fatalf("bad: %s: %#v", key, pair)
+		}
+	}
+}
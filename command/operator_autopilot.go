@@ -15,7 +15,8 @@ func (c *OperatorAutopilotCommand) Help() string {
 Usage: consul operator autopilot <subcommand> [options]
 
 The Autopilot operator command is used to interact with Consul's Autopilot
-subsystem. The command can be used to view or modify the current configuration.
+subsystem. The command can be used to view or modify the current configuration,
+and to view the current state of the servers.
 
 `
 
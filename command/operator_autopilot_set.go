@@ -56,13 +56,13 @@ func (c *OperatorAutopilotSetCommand) Run(args []string) int {
 			"servers are running Raft protocol version 3 or higher. Must be a duration "+
 			"value such as `10s`.")
 	f.Var(&redundancyZoneTag, "redundancy-zone-tag",
-		"(Enterprise-only) Controls the node_meta tag name used for separating servers into "+
+		"Controls the node_meta tag name used for separating servers into "+
 			"different redundancy zones.")
 	f.Var(&disableUpgradeMigration, "disable-upgrade-migration",
-		"(Enterprise-only) Controls whether Consul will avoid promoting new servers until "+
+		"Controls whether Consul will avoid promoting new servers until "+
 			"it can perform a migration. Must be one of `true|false`.")
 	f.Var(&upgradeVersionTag, "upgrade-version-tag",
-		"(Enterprise-only) The node_meta tag to use for version info when performing upgrade "+
+		"The node_meta tag to use for version info when performing upgrade "+
 			"migrations. If left blank, the Consul version will be used.")
 
 	if err := c.BaseCommand.Parse(args); err != nil {
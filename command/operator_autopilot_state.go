@@ -0,0 +1,129 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/ryanuber/columnize"
+)
+
+type OperatorAutopilotStateCommand struct {
+	BaseCommand
+}
+
+func (c *OperatorAutopilotStateCommand) Help() string {
+	helpText := `
+Usage: consul operator autopilot state [options]
+
+Displays the health of the servers as seen by Autopilot, along with the state
+of the redundancy zones and of the upgrade migration.
+
+` + c.BaseCommand.Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *OperatorAutopilotStateCommand) Synopsis() string {
+	return "Display the current Autopilot state"
+}
+
+func (c *OperatorAutopilotStateCommand) Run(args []string) int {
+	f := c.BaseCommand.NewFlagSet(c)
+	format := f.String("format", "table", formatUsage)
+
+	if err := c.BaseCommand.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		c.UI.Error(fmt.Sprintf("Failed to parse args: %v", err))
+		return 1
+	}
+	if err := validateFormat(*format); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	// Set up a client.
+	client, err := c.BaseCommand.HTTPClient()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	// Fetch the current state.
+	opts := &api.QueryOptions{
+		AllowStale: c.BaseCommand.HTTPStale(),
+	}
+	health, err := client.Operator().AutopilotServerHealth(opts)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error querying Autopilot state: %s", err))
+		return 1
+	}
+
+	if *format == "json" {
+		if err := outputJSON(c.UI, health); err != nil {
+			c.UI.Error(fmt.Sprintf("Error rendering Autopilot state: %s", err))
+			return 1
+		}
+		return 0
+	}
+
+	c.UI.Output(fmt.Sprintf("Healthy = %v", health.Healthy))
+	c.UI.Output(fmt.Sprintf("FailureTolerance = %d", health.FailureTolerance))
+
+	// The zones and the upgrade refer to the servers by ID.
+	names := make(map[string]string)
+	servers := []string{"Node|ID|Address|Voter|Healthy|Leader|RedundancyZone|UpgradeVersion"}
+	for _, s := range health.Servers {
+		names[s.ID] = s.Name
+		servers = append(servers, fmt.Sprintf("%s|%s|%s|%v|%v|%v|%s|%s",
+			s.Name, s.ID, s.Address, s.Voter, s.Healthy, s.Leader, s.RedundancyZone, s.UpgradeVersion))
+	}
+	serverNames := func(ids []string) string {
+		var out []string
+		for _, id := range ids {
+			if name, ok := names[id]; ok && name != "" {
+				out = append(out, name)
+			} else {
+				out = append(out, id)
+			}
+		}
+		return strings.Join(out, ", ")
+	}
+	c.UI.Output("")
+	c.UI.Output(columnize.SimpleFormat(servers))
+
+	if len(health.RedundancyZones) > 0 {
+		var zoneNames []string
+		for name := range health.RedundancyZones {
+			zoneNames = append(zoneNames, name)
+		}
+		sort.Strings(zoneNames)
+
+		zones := []string{"RedundancyZone|Voters|Servers|FailureTolerance"}
+		for _, name := range zoneNames {
+			zone := health.RedundancyZones[name]
+			zones = append(zones, fmt.Sprintf("%s|%s|%s|%d",
+				name, serverNames(zone.Voters), serverNames(zone.Servers), zone.FailureTolerance))
+		}
+		c.UI.Output("")
+		c.UI.Output(columnize.SimpleFormat(zones))
+	}
+
+	if upgrade := health.Upgrade; upgrade != nil {
+		c.UI.Output("")
+		c.UI.Output(fmt.Sprintf("Upgrade = %s", upgrade.Status))
+		if upgrade.TargetVersion != "" {
+			c.UI.Output(fmt.Sprintf("TargetVersion = %s", upgrade.TargetVersion))
+			c.UI.Output(fmt.Sprintf("TargetVersionVoters = %s", serverNames(upgrade.TargetVersionVoters)))
+			c.UI.Output(fmt.Sprintf("TargetVersionNonVoters = %s", serverNames(upgrade.TargetVersionNonVoters)))
+			c.UI.Output(fmt.Sprintf("OtherVersionVoters = %s", serverNames(upgrade.OtherVersionVoters)))
+			c.UI.Output(fmt.Sprintf("OtherVersionNonVoters = %s", serverNames(upgrade.OtherVersionNonVoters)))
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,81 @@
+package command
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/consul/agent"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/testutil/retry"
+	"github.com/mitchellh/cli"
+)
+
+func TestOperator_Autopilot_State_Implements(t *testing.T) {
+	t.Parallel()
+	var _ cli.Command = &OperatorAutopilotStateCommand{}
+}
+
+func TestOperator_Autopilot_State(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t.Name(), `
+		raft_protocol = 3
+		node_meta {
+			zone = "east1a"
+		}
+		autopilot {
+			redundancy_zone_tag = "zone"
+		}
+	`)
+	defer a.Shutdown()
+
+	retry.Run(t, func(r *retry.R) {
+		ui := cli.NewMockUi()
+		c := OperatorAutopilotStateCommand{
+			BaseCommand: BaseCommand{
+				UI:    ui,
+				Flags: FlagSetHTTP,
+			},
+		}
+		args := []string{"-http-addr=" + a.HTTPAddr()}
+
+		code := c.Run(args)
+		if code != 0 {
+			r.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+		}
+		output := strings.TrimSpace(ui.OutputWriter.String())
+		for _, s := range []string{
+			"Healthy = true",
+			"RedundancyZone",
+			"east1a",
+			"Upgrade = idle",
+			"TargetVersionVoters = " + a.Config.NodeName,
+		} {
+			if !strings.Contains(output, s) {
+				r.Fatalf("bad: %s, missing %q", output, s)
+			}
+		}
+	})
+
+	ui := cli.NewMockUi()
+	c := OperatorAutopilotStateCommand{
+		BaseCommand: BaseCommand{
+			UI:    ui,
+			Flags: FlagSetHTTP,
+		},
+	}
+	args := []string{"-http-addr=" + a.HTTPAddr(), "-format=json"}
+
+	code := c.Run(args)
+	if code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+	var health api.OperatorHealthReply
+	if err := json.Unmarshal(ui.OutputWriter.Bytes(), &health); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	zone, ok := health.RedundancyZones["east1a"]
+	if !ok || len(zone.Voters) != 1 || zone.Voters[0] != string(a.Config.NodeID) {
+		t.Fatalf("bad: %#v", health.RedundancyZones)
+	}
+}
@@ -0,0 +1,203 @@
+package command
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/configutil"
+	"github.com/mitchellh/cli"
+	"github.com/ryanuber/columnize"
+)
+
+// QueryCommand is a Command implementation that just shows help for
+// the subcommands nested below it.
+type QueryCommand struct {
+	BaseCommand
+}
+
+func (c *QueryCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
+
+func (c *QueryCommand) Help() string {
+	helpText := `
+Usage: consul query <subcommand> [options] [args]
+
+  This command has subcommands for managing and running prepared queries.
+  Here are some simple examples, and more detailed examples are available
+  in the subcommands or the documentation.
+
+  Create a query for the passing instances of a service:
+
+      $ consul query create -name=db -service=mysql -only-passing
+
+  Run it:
+
+      $ consul query execute db
+
+  Show what a name resolves to, with any template applied:
+
+      $ consul query explain geo-db-customer-primary
+
+  For more examples, ask for subcommand help or view the documentation.
+
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *QueryCommand) Synopsis() string {
+	return "Interact with prepared queries"
+}
+
+// queryFlags holds the flags for the fields of a prepared query that are
+// shared by the create and update commands.
+type queryFlags struct {
+	name           string
+	session        string
+	service        string
+	near           string
+	onlyPassing    bool
+	tags           []string
+	nodeMeta       map[string]string
+	serviceMeta    map[string]string
+	nearestN       int
+	datacenters    []string
+	dnsTTL         string
+	templateType   string
+	templateRegexp string
+}
+
+// register adds the flags to the flag set.
+func (q *queryFlags) register(f *flag.FlagSet) {
+	f.StringVar(&q.name, "name", "",
+		"Name of the query, which can be used instead of its ID.")
+	f.StringVar(&q.session, "session", "",
+		"Session the query is tied to. The query is deleted when the session "+
+			"is invalidated.")
+	f.StringVar(&q.service, "service", "", "Service to query.")
+	f.StringVar(&q.near, "near", "",
+		"Node to sort the results by distance from. The special \"_agent\" "+
+			"value sorts them by distance from the agent running the query.")
+	f.BoolVar(&q.onlyPassing, "only-passing", false,
+		"Only return instances with passing checks, leaving out warnings.")
+	f.Var((*configutil.AppendSliceValue)(&q.tags), "tag",
+		"Tag the instances must have, or must not have if prefixed with "+
+			"\"!\". This flag may be specified multiple times.")
+	q.nodeMeta = make(map[string]string)
+	f.Var((*configutil.FlagMapValue)(&q.nodeMeta), "node-meta",
+		"Metadata the nodes must have, as a `key=value` pair. This flag may "+
+			"be specified multiple times.")
+	q.serviceMeta = make(map[string]string)
+	f.Var((*configutil.FlagMapValue)(&q.serviceMeta), "service-meta",
+		"Metadata the instances must have, as a `key=value` pair. This flag "+
+			"may be specified multiple times.")
+	f.IntVar(&q.nearestN, "failover-nearest-n", 0,
+		"Number of the nearest remote datacenters to fail over to when there "+
+			"are no healthy instances in the local one.")
+	f.Var((*configutil.AppendSliceValue)(&q.datacenters), "failover-datacenter",
+		"Remote datacenter to fail over to after the nearest ones. This flag "+
+			"may be specified multiple times.")
+	f.StringVar(&q.dnsTTL, "dns-ttl", "",
+		"TTL of the results when the query is served over DNS.")
+	f.StringVar(&q.templateType, "template-type", "",
+		"Type of the template, which makes the query a template. Only "+
+			"\"name_prefix_match\" is supported.")
+	f.StringVar(&q.templateRegexp, "template-regexp", "",
+		"Regular expression the name a template is run with is matched "+
+			"against. Its groups can be used in interpolations.")
+}
+
+// apply sets the fields of the query whose flags were given, and returns
+// whether there were any.
+func (q *queryFlags) apply(f *flag.FlagSet, def *api.PreparedQueryDefinition) bool {
+	var changed bool
+	f.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case "name":
+			def.Name = q.name
+		case "session":
+			def.Session = q.session
+		case "service":
+			def.Service.Service = q.service
+		case "near":
+			def.Service.Near = q.near
+		case "only-passing":
+			def.Service.OnlyPassing = q.onlyPassing
+		case "tag":
+			def.Service.Tags = q.tags
+		case "node-meta":
+			def.Service.NodeMeta = q.nodeMeta
+		case "service-meta":
+			def.Service.ServiceMeta = q.serviceMeta
+		case "failover-nearest-n":
+			def.Service.Failover.NearestN = q.nearestN
+		case "failover-datacenter":
+			def.Service.Failover.Datacenters = q.datacenters
+		case "dns-ttl":
+			def.DNS.TTL = q.dnsTTL
+		case "template-type":
+			def.Template.Type = q.templateType
+		case "template-regexp":
+			def.Template.Regexp = q.templateRegexp
+		default:
+			return
+		}
+		changed = true
+	})
+	return changed
+}
+
+// queryDefinitionFromArg reads the query given as the value of the
+// -definition flag, which is JSON in the format of the HTTP API. It's read
+// from a file when prefixed with "@" and from stdin when it's "-".
+func queryDefinitionFromArg(arg string, stdin io.Reader) (*api.PreparedQueryDefinition, error) {
+	data, err := valueFromArg(arg, stdin)
+	if err != nil {
+		return nil, err
+	}
+	var def api.PreparedQueryDefinition
+	if err := json.Unmarshal([]byte(data), &def); err != nil {
+		return nil, fmt.Errorf("Failed to decode query: %s", err)
+	}
+	return &def, nil
+}
+
+// formatQueryDefinition returns the query as a table of its fields.
+func formatQueryDefinition(def *api.PreparedQueryDefinition) string {
+	result := []string{
+		fmt.Sprintf("ID\x1f%s", def.ID),
+		fmt.Sprintf("Name\x1f%s", def.Name),
+		fmt.Sprintf("Session\x1f%s", def.Session),
+		fmt.Sprintf("Service\x1f%s", def.Service.Service),
+		fmt.Sprintf("Tags\x1f%s", strings.Join(def.Service.Tags, ", ")),
+		fmt.Sprintf("Near\x1f%s", def.Service.Near),
+		fmt.Sprintf("OnlyPassing\x1f%t", def.Service.OnlyPassing),
+		fmt.Sprintf("NodeMeta\x1f%s", formatQueryMeta(def.Service.NodeMeta)),
+		fmt.Sprintf("ServiceMeta\x1f%s", formatQueryMeta(def.Service.ServiceMeta)),
+		fmt.Sprintf("FailoverNearestN\x1f%d", def.Service.Failover.NearestN),
+		fmt.Sprintf("FailoverDatacenters\x1f%s", strings.Join(def.Service.Failover.Datacenters, ", ")),
+		fmt.Sprintf("DNSTTL\x1f%s", def.DNS.TTL),
+		fmt.Sprintf("TemplateType\x1f%s", def.Template.Type),
+		fmt.Sprintf("TemplateRegexp\x1f%s", def.Template.Regexp),
+	}
+
+	// Template regexps can contain the default column delimiter.
+	config := columnize.DefaultConfig()
+	config.Delim = "\x1f"
+	return columnize.Format(result, config)
+}
+
+// formatQueryMeta returns the metadata as key=value pairs sorted by key.
+func formatQueryMeta(meta map[string]string) string {
+	var pairs []string
+	for k, v := range meta {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ", ")
+}
@@ -0,0 +1,119 @@
+package command
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/consul/agent"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/testutil"
+	"github.com/mitchellh/cli"
+)
+
+// testQuery registers an instance of the service with the agent and creates
+// a query for it.
+func testQuery(t *testing.T, a *agent.TestAgent, def *api.PreparedQueryDefinition) string {
+	reg := &api.AgentServiceRegistration{
+		ID:   def.Service.Service + "1",
+		Name: def.Service.Service,
+		Port: 5432,
+		Tags: []string{"primary"},
+	}
+	if err := a.Client().Agent().ServiceRegister(reg); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	id, _, err := a.Client().PreparedQuery().Create(def, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	return id
+}
+
+// testReadQuery reads back the query with the given ID.
+func testReadQuery(t *testing.T, a *agent.TestAgent, id string) *api.PreparedQueryDefinition {
+	defs, _, err := a.Client().PreparedQuery().Get(id, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(defs) != 1 {
+		t.Fatalf("bad: %v", defs)
+	}
+	return defs[0]
+}
+
+func TestQueryCommand_implements(t *testing.T) {
+	t.Parallel()
+	var _ cli.Command = &QueryCommand{}
+}
+
+func TestQueryCommand_noTabs(t *testing.T) {
+	t.Parallel()
+	assertNoTabs(t, new(QueryCommand))
+}
+
+func TestQueryDefinitionFromArg(t *testing.T) {
+	t.Parallel()
+	def := `{"Name": "db", "Service": {"Service": "mysql", "Tags": ["primary"]}}`
+	want := &api.PreparedQueryDefinition{
+		Name: "db",
+		Service: api.ServiceQuery{
+			Service: "mysql",
+			Tags:    []string{"primary"},
+		},
+	}
+
+	f := testutil.TempFile(t, "query")
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(def); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	cases := []struct {
+		arg   string
+		stdin string
+		err   string
+	}{
+		{def, "", ""},
+		{"@" + f.Name(), "", ""},
+		{"-", def, ""},
+		{"@/does/not/exist", "", "Failed to read file"},
+		{`{"Name": `, "", "Failed to decode query"},
+	}
+	for _, tc := range cases {
+		got, err := queryDefinitionFromArg(tc.arg, strings.NewReader(tc.stdin))
+		if tc.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Fatalf("%q: got %v want %q", tc.arg, err, tc.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%q: err: %v", tc.arg, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("%q: got %#v want %#v", tc.arg, got, want)
+		}
+	}
+}
+
+func TestFormatQueryDefinition(t *testing.T) {
+	t.Parallel()
+	out := formatQueryDefinition(&api.PreparedQueryDefinition{
+		Name: "geo-db",
+		Service: api.ServiceQuery{
+			Service:  "mysql",
+			NodeMeta: map[string]string{"rack": "a", "az": "1"},
+		},
+		Template: api.QueryTemplate{
+			Type:   "name_prefix_match",
+			Regexp: "^geo-db-(a|b)$",
+		},
+	})
+	for _, want := range []string{"NodeMeta             az=1, rack=a", "TemplateRegexp       ^geo-db-(a|b)$"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %q: %s", want, out)
+		}
+	}
+}
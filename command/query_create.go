@@ -0,0 +1,94 @@
+package command
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/hashicorp/consul/api"
+)
+
+// QueryCreateCommand is a Command implementation that is used to create a
+// prepared query.
+type QueryCreateCommand struct {
+	BaseCommand
+
+	// testStdin is the input for testing.
+	testStdin io.Reader
+}
+
+func (c *QueryCreateCommand) Help() string {
+	helpText := `
+Usage: consul query create [options]
+
+  Creates a prepared query and prints its ID. The query can be given with
+  flags:
+
+      $ consul query create -name=db -service=mysql -tag=primary -only-passing
+
+  Or as JSON in the format of the HTTP API, inline, read from a file on disk
+  by prefixing with the "@" symbol, or read from stdin using the "-" symbol.
+  Flags override the fields of the definition:
+
+      $ consul query create -definition=@geo-db.json
+
+` + c.BaseCommand.Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *QueryCreateCommand) Run(args []string) int {
+	f := c.BaseCommand.NewFlagSet(c)
+	definition := f.String("definition", "",
+		"Query in JSON format. Prefix with \"@\" to read it from a file, or "+
+			"use \"-\" to read it from stdin.")
+	var qf queryFlags
+	qf.register(f)
+	format := f.String("format", "table", formatUsage)
+	if err := c.BaseCommand.Parse(args); err != nil {
+		return 1
+	}
+	if err := validateFormat(*format); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	if l := len(f.Args()); l > 0 {
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 0, got %d)", l))
+		return 1
+	}
+
+	def := &api.PreparedQueryDefinition{}
+	if *definition != "" {
+		var err error
+		if def, err = queryDefinitionFromArg(*definition, c.testStdin); err != nil {
+			c.UI.Error(fmt.Sprintf("Error reading query: %s", err))
+			return 1
+		}
+	}
+	qf.apply(f, def)
+	if def.Service.Service == "" {
+		c.UI.Error("Must specify -service or a definition with a service")
+		return 1
+	}
+
+	client, err := c.BaseCommand.HTTPClient()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error connecting to Consul agent: %s", err))
+		return 1
+	}
+
+	id, _, err := client.PreparedQuery().Create(def, nil)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error creating query: %s", err))
+		return 1
+	}
+	if err := printID(c.UI, *format, id); err != nil {
+		c.UI.Error(fmt.Sprintf("Error printing query: %s", err))
+		return 1
+	}
+	return 0
+}
+
+func (c *QueryCreateCommand) Synopsis() string {
+	return "Creates a prepared query"
+}
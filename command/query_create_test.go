@@ -0,0 +1,141 @@
+package command
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/consul/agent"
+	"github.com/hashicorp/consul/api"
+	"github.com/mitchellh/cli"
+)
+
+func testQueryCreateCommand(t *testing.T) (*cli.MockUi, *QueryCreateCommand) {
+	ui := cli.NewMockUi()
+	return ui, &QueryCreateCommand{
+		BaseCommand: BaseCommand{
+			UI:    ui,
+			Flags: FlagSetHTTP,
+		},
+	}
+}
+
+func TestQueryCreateCommand_implements(t *testing.T) {
+	t.Parallel()
+	var _ cli.Command = &QueryCreateCommand{}
+}
+
+func TestQueryCreateCommand_noTabs(t *testing.T) {
+	t.Parallel()
+	assertNoTabs(t, new(QueryCreateCommand))
+}
+
+func TestQueryCreateCommand_Validation(t *testing.T) {
+	t.Parallel()
+	ui, c := testQueryCreateCommand(t)
+
+	cases := map[string]struct {
+		args   []string
+		output string
+	}{
+		"no service": {
+			[]string{"-name=db"},
+			"Must specify -service",
+		},
+		"bad definition": {
+			[]string{"-definition={"},
+			"Failed to decode query",
+		},
+		"extra args": {
+			[]string{"-service=mysql", "foo"},
+			"Too many arguments",
+		},
+	}
+
+	for name, tc := range cases {
+		// Ensure our buffer is always clear
+		if ui.ErrorWriter != nil {
+			ui.ErrorWriter.Reset()
+		}
+		if ui.OutputWriter != nil {
+			ui.OutputWriter.Reset()
+		}
+
+		code := c.Run(tc.args)
+		if code == 0 {
+			t.Errorf("%s: expected non-zero exit", name)
+		}
+
+		output := ui.ErrorWriter.String()
+		if !strings.Contains(output, tc.output) {
+			t.Errorf("%s: expected %q to contain %q", name, output, tc.output)
+		}
+	}
+}
+
+func TestQueryCreateCommand_Run(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t.Name(), ``)
+	defer a.Shutdown()
+
+	ui, c := testQueryCreateCommand(t)
+	args := []string{
+		"-http-addr=" + a.HTTPAddr(),
+		"-name=db",
+		"-service=mysql",
+		"-tag=primary",
+		"-only-passing",
+		"-node-meta=rack=a",
+		"-failover-nearest-n=2",
+		"-failover-datacenter=dc2",
+		"-dns-ttl=10s",
+	}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+
+	id := strings.TrimSpace(ui.OutputWriter.String())
+	want := &api.PreparedQueryDefinition{
+		ID:   id,
+		Name: "db",
+		Service: api.ServiceQuery{
+			Service:     "mysql",
+			Tags:        []string{"primary"},
+			OnlyPassing: true,
+			NodeMeta:    map[string]string{"rack": "a"},
+			Failover: api.QueryDatacenterOptions{
+				NearestN:    2,
+				Datacenters: []string{"dc2"},
+			},
+		},
+		DNS: api.QueryDNSOptions{TTL: "10s"},
+	}
+	if got := testReadQuery(t, a, id); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %#v want %#v", got, want)
+	}
+}
+
+func TestQueryCreateCommand_Definition(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t.Name(), ``)
+	defer a.Shutdown()
+
+	// Flags override the definition read from stdin.
+	ui, c := testQueryCreateCommand(t)
+	c.testStdin = strings.NewReader(`{
+		"Name": "geo-db",
+		"Template": {"Type": "name_prefix_match"},
+		"Service": {"Service": "${name.full}", "Tags": ["primary"]}
+	}`)
+	args := []string{"-http-addr=" + a.HTTPAddr(), "-definition=-", "-tag=secondary"}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+
+	id := strings.TrimSpace(ui.OutputWriter.String())
+	def := testReadQuery(t, a, id)
+	if def.Name != "geo-db" || def.Template.Type != "name_prefix_match" ||
+		def.Service.Service != "${name.full}" || !reflect.DeepEqual(def.Service.Tags, []string{"secondary"}) {
+		t.Fatalf("bad: %#v", def)
+	}
+}
@@ -0,0 +1,55 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+// QueryDeleteCommand is a Command implementation that is used to delete a
+// prepared query.
+type QueryDeleteCommand struct {
+	BaseCommand
+}
+
+func (c *QueryDeleteCommand) Help() string {
+	helpText := `
+Usage: consul query delete [options] ID
+
+  Deletes a prepared query:
+
+      $ consul query delete 8f246b77-f3e1-ff88-5b48-8ec93abf3e05
+
+` + c.BaseCommand.Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *QueryDeleteCommand) Run(args []string) int {
+	f := c.BaseCommand.NewFlagSet(c)
+	if err := c.BaseCommand.Parse(args); err != nil {
+		return 1
+	}
+	id, err := idFromArgs(f.Args())
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	client, err := c.BaseCommand.HTTPClient()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error connecting to Consul agent: %s", err))
+		return 1
+	}
+
+	if _, err := client.PreparedQuery().Delete(id, nil); err != nil {
+		c.UI.Error(fmt.Sprintf("Error deleting query %q: %s", id, err))
+		return 1
+	}
+
+	c.UI.Info(fmt.Sprintf("Query %q deleted", id))
+	return 0
+}
+
+func (c *QueryDeleteCommand) Synopsis() string {
+	return "Deletes a prepared query"
+}
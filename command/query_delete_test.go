@@ -0,0 +1,61 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/consul/agent"
+	"github.com/hashicorp/consul/api"
+	"github.com/mitchellh/cli"
+)
+
+func testQueryDeleteCommand(t *testing.T) (*cli.MockUi, *QueryDeleteCommand) {
+	ui := cli.NewMockUi()
+	return ui, &QueryDeleteCommand{
+		BaseCommand: BaseCommand{
+			UI:    ui,
+			Flags: FlagSetHTTP,
+		},
+	}
+}
+
+func TestQueryDeleteCommand_implements(t *testing.T) {
+	t.Parallel()
+	var _ cli.Command = &QueryDeleteCommand{}
+}
+
+func TestQueryDeleteCommand_noTabs(t *testing.T) {
+	t.Parallel()
+	assertNoTabs(t, new(QueryDeleteCommand))
+}
+
+func TestQueryDeleteCommand_Run(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t.Name(), ``)
+	defer a.Shutdown()
+
+	id := testQuery(t, a, &api.PreparedQueryDefinition{
+		Name:    "db",
+		Service: api.ServiceQuery{Service: "mysql"},
+	})
+
+	ui, c := testQueryDeleteCommand(t)
+	if code := c.Run([]string{"-http-addr=" + a.HTTPAddr()}); code != 1 {
+		t.Fatalf("bad: %d", code)
+	}
+	if !strings.Contains(ui.ErrorWriter.String(), "Missing ID argument") {
+		t.Fatalf("bad: %s", ui.ErrorWriter.String())
+	}
+
+	ui, c = testQueryDeleteCommand(t)
+	if code := c.Run([]string{"-http-addr=" + a.HTTPAddr(), id}); code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+	defs, _, err := a.Client().PreparedQuery().List(nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(defs) != 0 {
+		t.Fatalf("bad: %v", defs)
+	}
+}
@@ -0,0 +1,94 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/ryanuber/columnize"
+)
+
+// QueryExecuteCommand is a Command implementation that is used to run a
+// prepared query.
+type QueryExecuteCommand struct {
+	BaseCommand
+}
+
+func (c *QueryExecuteCommand) Help() string {
+	helpText := `
+Usage: consul query execute [options] ID|NAME
+
+  Runs a prepared query by ID or name, and lists the service instances it
+  returns:
+
+      $ consul query execute db
+
+  The results can be sorted by distance from a node, unless the query sets
+  where to sort them from itself:
+
+      $ consul query execute -near=web1 db
+
+` + c.BaseCommand.Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *QueryExecuteCommand) Run(args []string) int {
+	f := c.BaseCommand.NewFlagSet(c)
+	near := f.String("near", "",
+		"Node to sort the results by distance from. The special \"_agent\" "+
+			"value sorts them by distance from the agent.")
+	format := f.String("format", "table", formatUsage)
+	if err := c.BaseCommand.Parse(args); err != nil {
+		return 1
+	}
+	if err := validateFormat(*format); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	id, err := idFromArgs(f.Args())
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	client, err := c.BaseCommand.HTTPClient()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error connecting to Consul agent: %s", err))
+		return 1
+	}
+
+	q := &api.QueryOptions{
+		AllowStale: c.BaseCommand.HTTPStale(),
+		Near:       *near,
+	}
+	results, _, err := client.PreparedQuery().Execute(id, q)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error executing query %q: %s", id, err))
+		return 1
+	}
+
+	if *format == "json" {
+		if err := outputJSON(c.UI, results); err != nil {
+			c.UI.Error(fmt.Sprintf("Error printing results: %s", err))
+			return 1
+		}
+		return 0
+	}
+	result := []string{"Node|Datacenter|Address|ID|Port|Tags"}
+	for _, entry := range results.Nodes {
+		address := entry.Service.Address
+		if address == "" {
+			address = entry.Node.Address
+		}
+		result = append(result, fmt.Sprintf("%s|%s|%s|%s|%d|%s",
+			entry.Node.Node, results.Datacenter, address, entry.Service.ID,
+			entry.Service.Port, strings.Join(entry.Service.Tags, ",")))
+	}
+	c.UI.Output(columnize.SimpleFormat(result))
+	return 0
+}
+
+func (c *QueryExecuteCommand) Synopsis() string {
+	return "Executes a prepared query"
+}
@@ -0,0 +1,73 @@
+package command
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/consul/agent"
+	"github.com/hashicorp/consul/api"
+	"github.com/mitchellh/cli"
+)
+
+func testQueryExecuteCommand(t *testing.T) (*cli.MockUi, *QueryExecuteCommand) {
+	ui := cli.NewMockUi()
+	return ui, &QueryExecuteCommand{
+		BaseCommand: BaseCommand{
+			UI:    ui,
+			Flags: FlagSetHTTP,
+		},
+	}
+}
+
+func TestQueryExecuteCommand_implements(t *testing.T) {
+	t.Parallel()
+	var _ cli.Command = &QueryExecuteCommand{}
+}
+
+func TestQueryExecuteCommand_noTabs(t *testing.T) {
+	t.Parallel()
+	assertNoTabs(t, new(QueryExecuteCommand))
+}
+
+func TestQueryExecuteCommand_Run(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t.Name(), ``)
+	defer a.Shutdown()
+
+	testQuery(t, a, &api.PreparedQueryDefinition{
+		Name:    "db",
+		Service: api.ServiceQuery{Service: "mysql"},
+	})
+
+	ui, c := testQueryExecuteCommand(t)
+	if code := c.Run([]string{"-http-addr=" + a.HTTPAddr(), "-near=_agent", "db"}); code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+	output := ui.OutputWriter.String()
+	for _, want := range []string{a.Config.NodeName, "mysql1", "5432", "primary"} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("missing %q: %s", want, output)
+		}
+	}
+
+	ui, c = testQueryExecuteCommand(t)
+	if code := c.Run([]string{"-http-addr=" + a.HTTPAddr(), "-format=json", "db"}); code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+	var results api.PreparedQueryExecuteResponse
+	if err := json.Unmarshal(ui.OutputWriter.Bytes(), &results); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(results.Nodes) != 1 || results.Nodes[0].Service.ID != "mysql1" {
+		t.Fatalf("bad: %#v", results)
+	}
+
+	ui, c = testQueryExecuteCommand(t)
+	if code := c.Run([]string{"-http-addr=" + a.HTTPAddr(), "nope"}); code != 1 {
+		t.Fatalf("bad: %d", code)
+	}
+	if !strings.Contains(ui.ErrorWriter.String(), "Query not found") {
+		t.Fatalf("bad: %s", ui.ErrorWriter.String())
+	}
+}
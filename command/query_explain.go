@@ -0,0 +1,72 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/consul/api"
+)
+
+// QueryExplainCommand is a Command implementation that is used to show the
+// query an ID or name resolves to.
+type QueryExplainCommand struct {
+	BaseCommand
+}
+
+func (c *QueryExplainCommand) Help() string {
+	helpText := `
+Usage: consul query explain [options] ID|NAME
+
+  Shows the prepared query an ID or name resolves to. When the name matches
+  a template, the query is shown as rendered for that name, which helps
+  debugging templates:
+
+      $ consul query explain geo-db-customer-primary
+
+` + c.BaseCommand.Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *QueryExplainCommand) Run(args []string) int {
+	f := c.BaseCommand.NewFlagSet(c)
+	format := f.String("format", "table", formatUsage)
+	if err := c.BaseCommand.Parse(args); err != nil {
+		return 1
+	}
+	if err := validateFormat(*format); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	id, err := idFromArgs(f.Args())
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	client, err := c.BaseCommand.HTTPClient()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error connecting to Consul agent: %s", err))
+		return 1
+	}
+
+	explain, _, err := client.PreparedQuery().Explain(id, &api.QueryOptions{AllowStale: c.BaseCommand.HTTPStale()})
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error explaining query %q: %s", id, err))
+		return 1
+	}
+
+	if *format == "json" {
+		if err := outputJSON(c.UI, explain.Query); err != nil {
+			c.UI.Error(fmt.Sprintf("Error printing query: %s", err))
+			return 1
+		}
+		return 0
+	}
+	c.UI.Output(formatQueryDefinition(&explain.Query))
+	return 0
+}
+
+func (c *QueryExplainCommand) Synopsis() string {
+	return "Explains a prepared query"
+}
@@ -0,0 +1,50 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/consul/agent"
+	"github.com/hashicorp/consul/api"
+	"github.com/mitchellh/cli"
+)
+
+func testQueryExplainCommand(t *testing.T) (*cli.MockUi, *QueryExplainCommand) {
+	ui := cli.NewMockUi()
+	return ui, &QueryExplainCommand{
+		BaseCommand: BaseCommand{
+			UI:    ui,
+			Flags: FlagSetHTTP,
+		},
+	}
+}
+
+func TestQueryExplainCommand_implements(t *testing.T) {
+	t.Parallel()
+	var _ cli.Command = &QueryExplainCommand{}
+}
+
+func TestQueryExplainCommand_noTabs(t *testing.T) {
+	t.Parallel()
+	assertNoTabs(t, new(QueryExplainCommand))
+}
+
+func TestQueryExplainCommand_Run(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t.Name(), ``)
+	defer a.Shutdown()
+
+	testQuery(t, a, &api.PreparedQueryDefinition{
+		Name:     "geo-db",
+		Template: api.QueryTemplate{Type: "name_prefix_match"},
+		Service:  api.ServiceQuery{Service: "${name.full}"},
+	})
+
+	ui, c := testQueryExplainCommand(t)
+	if code := c.Run([]string{"-http-addr=" + a.HTTPAddr(), "geo-db-customer"}); code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+	if output := ui.OutputWriter.String(); !strings.Contains(output, "geo-db-customer") {
+		t.Fatalf("bad: %s", output)
+	}
+}
@@ -0,0 +1,76 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/ryanuber/columnize"
+)
+
+// QueryListCommand is a Command implementation that is used to list the
+// prepared queries.
+type QueryListCommand struct {
+	BaseCommand
+}
+
+func (c *QueryListCommand) Help() string {
+	helpText := `
+Usage: consul query list [options]
+
+  Lists the prepared queries. This requires a management token when ACLs
+  are enabled:
+
+      $ consul query list
+
+` + c.BaseCommand.Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *QueryListCommand) Run(args []string) int {
+	f := c.BaseCommand.NewFlagSet(c)
+	format := f.String("format", "table", formatUsage)
+	if err := c.BaseCommand.Parse(args); err != nil {
+		return 1
+	}
+	if err := validateFormat(*format); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	if l := len(f.Args()); l > 0 {
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 0, got %d)", l))
+		return 1
+	}
+
+	client, err := c.BaseCommand.HTTPClient()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error connecting to Consul agent: %s", err))
+		return 1
+	}
+
+	defs, _, err := client.PreparedQuery().List(&api.QueryOptions{AllowStale: c.BaseCommand.HTTPStale()})
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error listing queries: %s", err))
+		return 1
+	}
+
+	if *format == "json" {
+		if err := outputJSON(c.UI, defs); err != nil {
+			c.UI.Error(fmt.Sprintf("Error printing queries: %s", err))
+			return 1
+		}
+		return 0
+	}
+	result := []string{"ID|Name|Service|Template"}
+	for _, def := range defs {
+		result = append(result, fmt.Sprintf("%s|%s|%s|%s",
+			def.ID, def.Name, def.Service.Service, def.Template.Type))
+	}
+	c.UI.Output(columnize.SimpleFormat(result))
+	return 0
+}
+
+func (c *QueryListCommand) Synopsis() string {
+	return "Lists prepared queries"
+}
@@ -0,0 +1,52 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/consul/agent"
+	"github.com/hashicorp/consul/api"
+	"github.com/mitchellh/cli"
+)
+
+func testQueryListCommand(t *testing.T) (*cli.MockUi, *QueryListCommand) {
+	ui := cli.NewMockUi()
+	return ui, &QueryListCommand{
+		BaseCommand: BaseCommand{
+			UI:    ui,
+			Flags: FlagSetHTTP,
+		},
+	}
+}
+
+func TestQueryListCommand_implements(t *testing.T) {
+	t.Parallel()
+	var _ cli.Command = &QueryListCommand{}
+}
+
+func TestQueryListCommand_noTabs(t *testing.T) {
+	t.Parallel()
+	assertNoTabs(t, new(QueryListCommand))
+}
+
+func TestQueryListCommand_Run(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t.Name(), ``)
+	defer a.Shutdown()
+
+	id := testQuery(t, a, &api.PreparedQueryDefinition{
+		Name:    "db",
+		Service: api.ServiceQuery{Service: "mysql"},
+	})
+
+	ui, c := testQueryListCommand(t)
+	if code := c.Run([]string{"-http-addr=" + a.HTTPAddr()}); code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+	output := ui.OutputWriter.String()
+	for _, want := range []string{id, "db", "mysql"} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("missing %q: %s", want, output)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/consul/api"
+)
+
+// QueryReadCommand is a Command implementation that is used to show a
+// prepared query.
+type QueryReadCommand struct {
+	BaseCommand
+}
+
+func (c *QueryReadCommand) Help() string {
+	helpText := `
+Usage: consul query read [options] ID
+
+  Shows a prepared query as it was defined. Templates are shown without
+  being rendered, see "consul query explain" for that:
+
+      $ consul query read 8f246b77-f3e1-ff88-5b48-8ec93abf3e05
+
+` + c.BaseCommand.Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *QueryReadCommand) Run(args []string) int {
+	f := c.BaseCommand.NewFlagSet(c)
+	format := f.String("format", "table", formatUsage)
+	if err := c.BaseCommand.Parse(args); err != nil {
+		return 1
+	}
+	if err := validateFormat(*format); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	id, err := idFromArgs(f.Args())
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	client, err := c.BaseCommand.HTTPClient()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error connecting to Consul agent: %s", err))
+		return 1
+	}
+
+	defs, _, err := client.PreparedQuery().Get(id, &api.QueryOptions{AllowStale: c.BaseCommand.HTTPStale()})
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error reading query %q: %s", id, err))
+		return 1
+	}
+	if len(defs) == 0 {
+		c.UI.Error(fmt.Sprintf("Query %q not found", id))
+		return 1
+	}
+
+	if *format == "json" {
+		if err := outputJSON(c.UI, defs[0]); err != nil {
+			c.UI.Error(fmt.Sprintf("Error printing query: %s", err))
+			return 1
+		}
+		return 0
+	}
+	c.UI.Output(formatQueryDefinition(defs[0]))
+	return 0
+}
+
+func (c *QueryReadCommand) Synopsis() string {
+	return "Shows a prepared query"
+}
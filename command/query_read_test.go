@@ -0,0 +1,65 @@
+package command
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/consul/agent"
+	"github.com/hashicorp/consul/api"
+	"github.com/mitchellh/cli"
+)
+
+func testQueryReadCommand(t *testing.T) (*cli.MockUi, *QueryReadCommand) {
+	ui := cli.NewMockUi()
+	return ui, &QueryReadCommand{
+		BaseCommand: BaseCommand{
+			UI:    ui,
+			Flags: FlagSetHTTP,
+		},
+	}
+}
+
+func TestQueryReadCommand_implements(t *testing.T) {
+	t.Parallel()
+	var _ cli.Command = &QueryReadCommand{}
+}
+
+func TestQueryReadCommand_noTabs(t *testing.T) {
+	t.Parallel()
+	assertNoTabs(t, new(QueryReadCommand))
+}
+
+func TestQueryReadCommand_Run(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t.Name(), ``)
+	defer a.Shutdown()
+
+	id := testQuery(t, a, &api.PreparedQueryDefinition{
+		Name:    "db",
+		Service: api.ServiceQuery{Service: "mysql", Tags: []string{"primary"}},
+	})
+
+	ui, c := testQueryReadCommand(t)
+	if code := c.Run([]string{"-http-addr=" + a.HTTPAddr(), id}); code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+	output := ui.OutputWriter.String()
+	for _, want := range []string{id, "db", "mysql", "primary"} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("missing %q: %s", want, output)
+		}
+	}
+
+	ui, c = testQueryReadCommand(t)
+	if code := c.Run([]string{"-http-addr=" + a.HTTPAddr(), "-format=json", id}); code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+	var def api.PreparedQueryDefinition
+	if err := json.Unmarshal(ui.OutputWriter.Bytes(), &def); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if def.ID != id || def.Service.Service != "mysql" {
+		t.Fatalf("bad: %#v", def)
+	}
+}
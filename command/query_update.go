@@ -0,0 +1,92 @@
+package command
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// QueryUpdateCommand is a Command implementation that is used to change an
+// existing prepared query.
+type QueryUpdateCommand struct {
+	BaseCommand
+
+	// testStdin is the input for testing.
+	testStdin io.Reader
+}
+
+func (c *QueryUpdateCommand) Help() string {
+	helpText := `
+Usage: consul query update [options] ID
+
+  Changes an existing prepared query. Only the fields of the given flags are
+  changed:
+
+      $ consul query update -tag=secondary 8f246b77-f3e1-ff88-5b48-8ec93abf3e05
+
+  A definition in JSON format replaces the whole query, before the flags are
+  applied. It can be given inline, read from a file on disk by prefixing
+  with the "@" symbol, or read from stdin using the "-" symbol:
+
+      $ consul query update -definition=@db.json 8f246b77-f3e1-ff88-5b48-8ec93abf3e05
+
+` + c.BaseCommand.Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *QueryUpdateCommand) Run(args []string) int {
+	f := c.BaseCommand.NewFlagSet(c)
+	definition := f.String("definition", "",
+		"Query in JSON format. Prefix with \"@\" to read it from a file, or "+
+			"use \"-\" to read it from stdin.")
+	var qf queryFlags
+	qf.register(f)
+	if err := c.BaseCommand.Parse(args); err != nil {
+		return 1
+	}
+	id, err := idFromArgs(f.Args())
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	client, err := c.BaseCommand.HTTPClient()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error connecting to Consul agent: %s", err))
+		return 1
+	}
+
+	defs, _, err := client.PreparedQuery().Get(id, nil)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error reading query %q: %s", id, err))
+		return 1
+	}
+	if len(defs) == 0 {
+		c.UI.Error(fmt.Sprintf("Query %q not found", id))
+		return 1
+	}
+	def := defs[0]
+	if *definition != "" {
+		if def, err = queryDefinitionFromArg(*definition, c.testStdin); err != nil {
+			c.UI.Error(fmt.Sprintf("Error reading query: %s", err))
+			return 1
+		}
+		def.ID = defs[0].ID
+	}
+	if !qf.apply(f, def) && *definition == "" {
+		c.UI.Error("Must specify -definition or the fields to change")
+		return 1
+	}
+	if _, err := client.PreparedQuery().Update(def, nil); err != nil {
+		c.UI.Error(fmt.Sprintf("Error updating query %q: %s", id, err))
+		return 1
+	}
+
+	c.UI.Info(fmt.Sprintf("Query %q updated", id))
+	return 0
+}
+
+func (c *QueryUpdateCommand) Synopsis() string {
+	return "Updates a prepared query"
+}
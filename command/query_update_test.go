@@ -0,0 +1,75 @@
+package command
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/consul/agent"
+	"github.com/hashicorp/consul/api"
+	"github.com/mitchellh/cli"
+)
+
+func testQueryUpdateCommand(t *testing.T) (*cli.MockUi, *QueryUpdateCommand) {
+	ui := cli.NewMockUi()
+	return ui, &QueryUpdateCommand{
+		BaseCommand: BaseCommand{
+			UI:    ui,
+			Flags: FlagSetHTTP,
+		},
+	}
+}
+
+func TestQueryUpdateCommand_implements(t *testing.T) {
+	t.Parallel()
+	var _ cli.Command = &QueryUpdateCommand{}
+}
+
+func TestQueryUpdateCommand_noTabs(t *testing.T) {
+	t.Parallel()
+	assertNoTabs(t, new(QueryUpdateCommand))
+}
+
+func TestQueryUpdateCommand_Run(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t.Name(), ``)
+	defer a.Shutdown()
+
+	id := testQuery(t, a, &api.PreparedQueryDefinition{
+		Name:    "db",
+		Service: api.ServiceQuery{Service: "mysql", Tags: []string{"primary"}},
+	})
+
+	ui, c := testQueryUpdateCommand(t)
+	if code := c.Run([]string{"-http-addr=" + a.HTTPAddr(), id}); code != 1 {
+		t.Fatalf("bad: %d", code)
+	}
+	if !strings.Contains(ui.ErrorWriter.String(), "Must specify -definition or the fields") {
+		t.Fatalf("bad: %s", ui.ErrorWriter.String())
+	}
+
+	// Only the given fields change.
+	ui, c = testQueryUpdateCommand(t)
+	if code := c.Run([]string{"-http-addr=" + a.HTTPAddr(), "-only-passing", id}); code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+	def := testReadQuery(t, a, id)
+	if def.Name != "db" || !def.Service.OnlyPassing || !reflect.DeepEqual(def.Service.Tags, []string{"primary"}) {
+		t.Fatalf("bad: %#v", def)
+	}
+
+	// A definition replaces the whole query.
+	ui, c = testQueryUpdateCommand(t)
+	c.testStdin = strings.NewReader(`{"Service": {"Service": "postgres"}}`)
+	if code := c.Run([]string{"-http-addr=" + a.HTTPAddr(), "-definition=-", "-name=pg", id}); code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+	want := &api.PreparedQueryDefinition{
+		ID:      id,
+		Name:    "pg",
+		Service: api.ServiceQuery{Service: "postgres"},
+	}
+	if got := testReadQuery(t, a, id); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %#v want %#v", got, want)
+	}
+}
@@ -3,9 +3,12 @@ package command
 import (
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/lib"
 	"github.com/hashicorp/serf/coordinate"
+	"github.com/ryanuber/columnize"
 )
 
 // RTTCommand is a Command implementation that allows users to query the
@@ -17,6 +20,7 @@ type RTTCommand struct {
 func (c *RTTCommand) Help() string {
 	helpText := `
 Usage: consul rtt [options] node1 [node2]
+       consul rtt -matrix [options] [node ...]
 
   Estimates the round trip time between two nodes using Consul's network
   coordinate model of the cluster.
@@ -34,22 +38,35 @@ Usage: consul rtt [options] node1 [node2]
   because they are maintained by independent Serf gossip areas, so they are
   not compatible.
 
+  With the -matrix option, the servers estimate the round trip times between
+  each pair of the given nodes, up to 256 of them, and show them as a table.
+  Nodes whose coordinates are inaccurate or haven't been updated recently are
+  reported. If the -wan option is also given, then the estimated round trip
+  times from the datacenter to each datacenter are shown instead.
+
 ` + c.BaseCommand.Help()
 
 	return strings.TrimSpace(helpText)
 }
 
 func (c *RTTCommand) Run(args []string) int {
-	var wan bool
+	var wan, matrix bool
 
 	f := c.BaseCommand.NewFlagSet(c)
 
 	f.BoolVar(&wan, "wan", false, "Use WAN coordinates instead of LAN coordinates.")
+	f.BoolVar(&matrix, "matrix", false, "Show the estimated round trip times "+
+		"between all the given nodes. With -wan, show them from the datacenter to "+
+		"each datacenter instead.")
 
 	if err := c.BaseCommand.Parse(args); err != nil {
 		return 1
 	}
 
+	if matrix {
+		return c.runMatrix(f.Args(), wan)
+	}
+
 	// They must provide at least one node.
 	nodes := f.Args()
 	if len(nodes) < 1 || len(nodes) > 2 {
@@ -177,11 +194,108 @@ func (c *RTTCommand) Run(args []string) int {
 SHOW_RTT:
 
 	// Report the round trip time.
-	dist := fmt.Sprintf("%.3f ms", coord1.DistanceTo(coord2).Seconds()*1000.0)
+	dist := formatRTT(coord1.DistanceTo(coord2))
 	c.UI.Output(fmt.Sprintf("Estimated %s <-> %s rtt: %s (using %s coordinates)", nodes[0], nodes[1], dist, source))
 	return 0
 }
 
+// runMatrix shows the round trip times estimated by the servers between the
+// given nodes, or between the datacenters with wan.
+func (c *RTTCommand) runMatrix(nodes []string, wan bool) int {
+	if wan && len(nodes) > 0 {
+		c.UI.Error("Node names can't be given with -matrix and -wan")
+		return 1
+	}
+	if !wan && len(nodes) == 0 {
+		c.UI.Error("Node names are required with -matrix unless -wan is given")
+		return 1
+	}
+
+	// Create and test the HTTP client.
+	client, err := c.BaseCommand.HTTPClient()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error connecting to Consul agent: %s", err))
+		return 1
+	}
+
+	matrix, _, err := client.Coordinate().Matrix(nodes, &api.QueryOptions{
+		AllowStale: c.BaseCommand.HTTPStale(),
+	})
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error getting round trip times: %s", err))
+		return 1
+	}
+
+	if wan {
+		result := []string{"Datacenter|RTT"}
+		for _, dc := range matrix.Datacenters {
+			result = append(result, fmt.Sprintf("%s|%s", dc.To, formatRTT(dc.RTT)))
+		}
+		c.UI.Output(columnize.SimpleFormat(result))
+		return 0
+	}
+
+	// Lay out the nodes in the order they came back in, and index the
+	// round trip times in both directions.
+	var order []string
+	seen := make(map[string]bool)
+	for _, status := range matrix.Nodes {
+		if !seen[status.Node] {
+			seen[status.Node] = true
+			order = append(order, status.Node)
+		}
+	}
+	for _, node := range nodes {
+		if !seen[node] {
+			c.UI.Error(fmt.Sprintf("Could not find a coordinate for node %q", node))
+			return 1
+		}
+	}
+	rtts := make(map[string]time.Duration)
+	for _, rtt := range matrix.RTTs {
+		rtts[rtt.From+"|"+rtt.To] = rtt.RTT
+		rtts[rtt.To+"|"+rtt.From] = rtt.RTT
+	}
+
+	result := []string{"Node|" + strings.Join(order, "|")}
+	for _, from := range order {
+		row := []string{from}
+		for _, to := range order {
+			rtt, ok := rtts[from+"|"+to]
+			if !ok {
+				row = append(row, "-")
+				continue
+			}
+			row = append(row, formatRTT(rtt))
+		}
+		result = append(result, strings.Join(row, "|"))
+	}
+	c.UI.Output(columnize.SimpleFormat(result))
+
+	// Point out the coordinates that the estimates shouldn't be trusted
+	// for.
+	for _, status := range matrix.Nodes {
+		name := status.Node
+		if status.Segment != "" {
+			name = fmt.Sprintf("%s (segment %q)", status.Node, status.Segment)
+		}
+		if status.Inaccurate {
+			c.UI.Warn(fmt.Sprintf("Warning: The coordinate of node %s is inaccurate (error %.3f)",
+				name, status.Error))
+		}
+		if status.Stale {
+			c.UI.Warn(fmt.Sprintf("Warning: The coordinate of node %s was last updated at %s",
+				name, status.Updated.Format(time.RFC3339)))
+		}
+	}
+	return 0
+}
+
+// formatRTT formats a round trip time the same way for all the outputs.
+func formatRTT(rtt time.Duration) string {
+	return fmt.Sprintf("%.3f ms", rtt.Seconds()*1000.0)
+}
+
 func (c *RTTCommand) Synopsis() string {
 	return "Estimates network round trip time between nodes"
 }
@@ -219,3 +219,86 @@ func TestRTTCommand_Run_WAN(t *testing.T) {
 		}
 	}
 }
+
+func TestRTTCommand_Run_Matrix(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t.Name(), `
+		consul = {
+			coordinate = {
+				update_period = "10ms"
+			}
+		}
+	`)
+	defer a.Shutdown()
+
+	// Inject some known coordinates.
+	c1 := coordinate.NewCoordinate(coordinate.DefaultConfig())
+	c2 := c1.Clone()
+	c2.Vec[0] = 0.123
+	dist_str := fmt.Sprintf("%.3f ms", c1.DistanceTo(c2).Seconds()*1000.0)
+	{
+		req := structs.RegisterRequest{
+			Datacenter: a.Config.Datacenter,
+			Node:       "dogs",
+			Address:    "127.0.0.2",
+		}
+		var reply struct{}
+		if err := a.RPC("Catalog.Register", &req, &reply); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+	for node, coord := range map[string]*coordinate.Coordinate{a.Config.NodeName: c1, "dogs": c2} {
+		req := structs.CoordinateUpdateRequest{
+			Datacenter: a.Config.Datacenter,
+			Node:       node,
+			Coord:      coord,
+		}
+		var reply struct{}
+		if err := a.RPC("Coordinate.Update", &req, &reply); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+
+	// Wait for the updates to get flushed to the data store.
+	retry.Run(t, func(r *retry.R) {
+		ui, c := testRTTCommand(t)
+		code := c.Run([]string{"-http-addr=" + a.HTTPAddr(), "-matrix", "dogs", a.Config.NodeName})
+		if code != 0 {
+			r.Fatalf("bad: %d: %#v", code, ui.ErrorWriter.String())
+		}
+
+		// The nodes are laid out in the given order, and the new
+		// coordinates are flagged as inaccurate.
+		output := ui.OutputWriter.String()
+		header := strings.SplitN(output, "\n", 2)[0]
+		dogs := strings.Index(header, "dogs")
+		if dogs < 0 || dogs > strings.Index(header, a.Config.NodeName) ||
+			strings.Count(output, dist_str) != 2 {
+			r.Fatalf("bad: %#v", output)
+		}
+		if !strings.Contains(ui.ErrorWriter.String(), `node dogs is inaccurate`) {
+			r.Fatalf("bad: %#v", ui.ErrorWriter.String())
+		}
+	})
+
+	// Show the datacenters.
+	{
+		ui, c := testRTTCommand(t)
+		code := c.Run([]string{"-http-addr=" + a.HTTPAddr(), "-matrix", "-wan"})
+		if code != 0 {
+			t.Fatalf("bad: %d: %#v", code, ui.ErrorWriter.String())
+		}
+		if !strings.Contains(ui.OutputWriter.String(), a.Config.Datacenter) {
+			t.Fatalf("bad: %#v", ui.OutputWriter.String())
+		}
+	}
+
+	// Try an unknown node, no nodes, and nodes with -wan.
+	for _, args := range [][]string{{"-matrix", "nope"}, {"-matrix"}, {"-matrix", "-wan", "dogs"}} {
+		ui, c := testRTTCommand(t)
+		code := c.Run(append([]string{"-http-addr=" + a.HTTPAddr()}, args...))
+		if code != 1 {
+			t.Fatalf("bad: %d: %#v", code, ui.ErrorWriter.String())
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+// ServicesCommand is a Command implementation that just shows help for
+// the subcommands nested below it.
+type ServicesCommand struct {
+	BaseCommand
+}
+
+func (c *ServicesCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
+
+func (c *ServicesCommand) Help() string {
+	helpText := `
+Usage: consul services <subcommand> [options] [args]
+
+  This command has subcommands for registering and deregistering services
+  with the local agent. Here are some simple examples, and more detailed
+  examples are available in the subcommands or the documentation.
+
+  Register the services of a definition file:
+
+      $ consul services register web.hcl
+
+  Register a service with just flags:
+
+      $ consul services register -name=web -port=80 -tag=v1
+
+  Deregister the service:
+
+      $ consul services deregister -id=web
+
+  For more examples, ask for subcommand help or view the documentation.
+
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *ServicesCommand) Synopsis() string {
+	return "Interact with services"
+}
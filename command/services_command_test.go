@@ -0,0 +1,17 @@
+package command
+
+import (
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestServicesCommand_implements(t *testing.T) {
+	t.Parallel()
+	var _ cli.Command = &ServicesCommand{}
+}
+
+func TestServicesCommand_noTabs(t *testing.T) {
+	t.Parallel()
+	assertNoTabs(t, new(ServicesCommand))
+}
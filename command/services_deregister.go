@@ -0,0 +1,91 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ServicesDeregisterCommand is a Command implementation that is used to
+// deregister services from the local agent.
+type ServicesDeregisterCommand struct {
+	BaseCommand
+}
+
+func (c *ServicesDeregisterCommand) Help() string {
+	helpText := `
+Usage: consul services deregister [options] [FILE...]
+
+  Deregisters a service from the local agent, along with its checks:
+
+      $ consul services deregister -id=web
+
+  The services of definition files can also be deregistered, which uses the
+  IDs of the services, or their names if they don't have one:
+
+      $ consul services deregister web.hcl
+
+  Only services registered through the API can be deregistered this way.
+  Services in the agent's configuration come back when it's reloaded.
+
+` + c.BaseCommand.Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *ServicesDeregisterCommand) Run(args []string) int {
+	f := c.BaseCommand.NewFlagSet(c)
+	id := f.String("id", "", "ID of the service to deregister.")
+	if err := c.BaseCommand.Parse(args); err != nil {
+		return 1
+	}
+
+	var ids []string
+	files := f.Args()
+	switch {
+	case *id != "" && len(files) > 0:
+		c.UI.Error("Cannot specify both -id and definition files")
+		return 1
+
+	case *id != "":
+		ids = append(ids, *id)
+
+	case len(files) > 0:
+		for _, file := range files {
+			defs, err := servicesFromFile(file)
+			if err != nil {
+				c.UI.Error(fmt.Sprintf("Error reading %q: %s", file, err))
+				return 1
+			}
+			for _, def := range defs {
+				if def.ID != "" {
+					ids = append(ids, def.ID)
+				} else {
+					ids = append(ids, def.Name)
+				}
+			}
+		}
+
+	default:
+		c.UI.Error("Must specify -id or definition files")
+		return 1
+	}
+
+	client, err := c.BaseCommand.HTTPClient()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error connecting to Consul agent: %s", err))
+		return 1
+	}
+
+	for _, id := range ids {
+		if err := client.Agent().ServiceDeregister(id); err != nil {
+			c.UI.Error(fmt.Sprintf("Error deregistering service %q: %s", id, err))
+			return 1
+		}
+		c.UI.Info(fmt.Sprintf("Deregistered service: %s", id))
+	}
+	return 0
+}
+
+func (c *ServicesDeregisterCommand) Synopsis() string {
+	return "Deregisters services from the local agent"
+}
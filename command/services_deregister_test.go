@@ -0,0 +1,115 @@
+package command
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/consul/agent"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/testutil"
+	"github.com/mitchellh/cli"
+)
+
+func testServicesDeregisterCommand(t *testing.T) (*cli.MockUi, *ServicesDeregisterCommand) {
+	ui := cli.NewMockUi()
+	return ui, &ServicesDeregisterCommand{
+		BaseCommand: BaseCommand{
+			UI:    ui,
+			Flags: FlagSetClientHTTP,
+		},
+	}
+}
+
+func TestServicesDeregisterCommand_implements(t *testing.T) {
+	t.Parallel()
+	var _ cli.Command = &ServicesDeregisterCommand{}
+}
+
+func TestServicesDeregisterCommand_noTabs(t *testing.T) {
+	t.Parallel()
+	assertNoTabs(t, new(ServicesDeregisterCommand))
+}
+
+func TestServicesDeregisterCommand_Validation(t *testing.T) {
+	t.Parallel()
+	ui, c := testServicesDeregisterCommand(t)
+
+	cases := map[string]struct {
+		args   []string
+		output string
+	}{
+		"no service": {
+			[]string{},
+			"Must specify -id or definition files",
+		},
+		"-id and file": {
+			[]string{"-id=web", "web.hcl"},
+			"Cannot specify both",
+		},
+	}
+
+	for name, tc := range cases {
+		// Ensure our buffer is always clear
+		if ui.ErrorWriter != nil {
+			ui.ErrorWriter.Reset()
+		}
+		if ui.OutputWriter != nil {
+			ui.OutputWriter.Reset()
+		}
+
+		code := c.Run(tc.args)
+		if code == 0 {
+			t.Errorf("%s: expected non-zero exit", name)
+		}
+
+		output := ui.ErrorWriter.String()
+		if !strings.Contains(output, tc.output) {
+			t.Errorf("%s: expected %q to contain %q", name, output, tc.output)
+		}
+	}
+}
+
+func TestServicesDeregisterCommand_Run(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t.Name(), ``)
+	defer a.Shutdown()
+
+	for _, svc := range []*api.AgentServiceRegistration{
+		{ID: "web1", Name: "web"},
+		{Name: "db"},
+	} {
+		if err := a.Client().Agent().ServiceRegister(svc); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	ui, c := testServicesDeregisterCommand(t)
+	if code := c.Run([]string{"-http-addr=" + a.HTTPAddr(), "-id=web1"}); code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+	if !strings.Contains(ui.OutputWriter.String(), "Deregistered service: web1") {
+		t.Fatalf("bad: %s", ui.OutputWriter.String())
+	}
+
+	// Services without an ID in the file are deregistered by name.
+	dir := testutil.TempDir(t, "services")
+	defer os.RemoveAll(dir)
+	file := testServiceFile(t, dir, ".hcl", `service { name = "db" }`)
+
+	ui, c = testServicesDeregisterCommand(t)
+	if code := c.Run([]string{"-http-addr=" + a.HTTPAddr(), file}); code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+
+	services, err := a.Client().Agent().Services()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, ok := services["web1"]; ok {
+		t.Fatalf("bad: %v", services)
+	}
+	if _, ok := services["db"]; ok {
+		t.Fatalf("bad: %v", services)
+	}
+}
@@ -0,0 +1,195 @@
+package command
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/consul/agent/config"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/configutil"
+)
+
+// ServicesRegisterCommand is a Command implementation that is used to
+// register services with the local agent.
+type ServicesRegisterCommand struct {
+	BaseCommand
+}
+
+func (c *ServicesRegisterCommand) Help() string {
+	helpText := `
+Usage: consul services register [options] [FILE...]
+
+  Registers the services of the given definition files with the local agent.
+  The files use the same format as service definitions in the agent's
+  configuration, and are read as JSON unless they have a ".hcl" suffix. Any
+  checks in the definitions are registered with the services:
+
+      $ consul services register web.hcl
+
+  A single service can also be registered with just flags instead of a file:
+
+      $ consul services register -name=web -port=80 -tag=v1 -tag=blue
+
+  Services registered this way are not persisted in the configuration, so
+  they are lost when the agent's state is removed.
+
+` + c.BaseCommand.Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *ServicesRegisterCommand) Run(args []string) int {
+	f := c.BaseCommand.NewFlagSet(c)
+	id := f.String("id", "",
+		"ID of the service. If unspecified, the name is used.")
+	name := f.String("name", "",
+		"Name of the service, to register it without a definition file.")
+	address := f.String("address", "",
+		"Address of the service. If unspecified, the address of the node is "+
+			"used.")
+	port := f.Int("port", 0, "Port of the service.")
+	var tags []string
+	f.Var((*configutil.AppendSliceValue)(&tags), "tag",
+		"Tag of the service. This flag may be specified multiple times.")
+	meta := make(map[string]string)
+	f.Var((*configutil.FlagMapValue)(&meta), "meta",
+		"Metadata of the service as a `key=value` pair. This flag may be "+
+			"specified multiple times.")
+	if err := c.BaseCommand.Parse(args); err != nil {
+		return 1
+	}
+
+	var services []*api.AgentServiceRegistration
+	files := f.Args()
+	switch {
+	case *name != "" && len(files) > 0:
+		c.UI.Error("Cannot specify both -name and definition files")
+		return 1
+
+	case *name != "":
+		services = append(services, &api.AgentServiceRegistration{
+			ID:      *id,
+			Name:    *name,
+			Address: *address,
+			Port:    *port,
+			Tags:    tags,
+			Meta:    meta,
+		})
+
+	case len(files) > 0:
+		if *id != "" || *address != "" || *port != 0 || len(tags) > 0 || len(meta) > 0 {
+			c.UI.Error("Service flags can only be used with -name")
+			return 1
+		}
+		for _, file := range files {
+			defs, err := servicesFromFile(file)
+			if err != nil {
+				c.UI.Error(fmt.Sprintf("Error reading %q: %s", file, err))
+				return 1
+			}
+			if len(defs) == 0 {
+				c.UI.Error(fmt.Sprintf("No services in %q", file))
+				return 1
+			}
+			for _, def := range defs {
+				services = append(services, serviceToAgentService(def))
+			}
+		}
+
+	default:
+		c.UI.Error("Must specify -name or definition files")
+		return 1
+	}
+
+	client, err := c.BaseCommand.HTTPClient()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error connecting to Consul agent: %s", err))
+		return 1
+	}
+
+	for _, svc := range services {
+		if err := client.Agent().ServiceRegister(svc); err != nil {
+			c.UI.Error(fmt.Sprintf("Error registering service %q: %s", svc.Name, err))
+			return 1
+		}
+		c.UI.Info(fmt.Sprintf("Registered service: %s", svc.Name))
+	}
+	return 0
+}
+
+func (c *ServicesRegisterCommand) Synopsis() string {
+	return "Registers services with the local agent"
+}
+
+// servicesFromFile reads the service definitions of a file the way the agent
+// reads its configuration files.
+func servicesFromFile(path string) ([]*structs.ServiceDefinition, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	format := "json"
+	if strings.HasSuffix(path, ".hcl") {
+		format = "hcl"
+	}
+	return config.ParseServices(string(data), format)
+}
+
+// serviceToAgentService converts a service definition to the registration
+// the agent API takes.
+func serviceToAgentService(svc *structs.ServiceDefinition) *api.AgentServiceRegistration {
+	reg := &api.AgentServiceRegistration{
+		ID:                svc.ID,
+		Name:              svc.Name,
+		Tags:              svc.Tags,
+		Port:              svc.Port,
+		Address:           svc.Address,
+		EnableTagOverride: svc.EnableTagOverride,
+		Meta:              svc.Meta,
+	}
+	if svc.Weights != nil {
+		reg.Weights = &api.AgentWeights{
+			Passing: svc.Weights.Passing,
+			Warning: svc.Weights.Warning,
+		}
+	}
+	for _, chk := range svc.CheckTypes() {
+		reg.Checks = append(reg.Checks, &api.AgentServiceCheck{
+			CheckID:                        string(chk.CheckID),
+			Name:                           chk.Name,
+			Script:                         chk.Script,
+			ScriptArgs:                     chk.ScriptArgs,
+			DockerContainerID:              chk.DockerContainerID,
+			Shell:                          chk.Shell,
+			Interval:                       durationString(chk.Interval),
+			Timeout:                        durationString(chk.Timeout),
+			TTL:                            durationString(chk.TTL),
+			HTTP:                           chk.HTTP,
+			Header:                         chk.Header,
+			Method:                         chk.Method,
+			TCP:                            chk.TCP,
+			GRPC:                           chk.GRPC,
+			GRPCUseTLS:                     chk.GRPCUseTLS,
+			Status:                         chk.Status,
+			Notes:                          chk.Notes,
+			TLSSkipVerify:                  chk.TLSSkipVerify,
+			DeregisterCriticalServiceAfter: durationString(chk.DeregisterCriticalServiceAfter),
+			SuccessBeforePassing:           chk.SuccessBeforePassing,
+			FailuresBeforeCritical:         chk.FailuresBeforeCritical,
+			FlapHalfLife:                   durationString(chk.FlapHalfLife),
+			FlapSuppressWindow:             durationString(chk.FlapSuppressWindow),
+		})
+	}
+	return reg
+}
+
+// durationString formats the duration for the API, leaving out zero.
+func durationString(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	return d.String()
+}
@@ -0,0 +1,200 @@
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/consul/agent"
+	"github.com/hashicorp/consul/testutil"
+	"github.com/mitchellh/cli"
+)
+
+func testServicesRegisterCommand(t *testing.T) (*cli.MockUi, *ServicesRegisterCommand) {
+	ui := cli.NewMockUi()
+	return ui, &ServicesRegisterCommand{
+		BaseCommand: BaseCommand{
+			UI:    ui,
+			Flags: FlagSetClientHTTP,
+		},
+	}
+}
+
+// testServiceFile writes a service definition file with the given suffix.
+func testServiceFile(t *testing.T, dir, suffix, content string) string {
+	path := filepath.Join(dir, "service"+suffix)
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	return path
+}
+
+func TestServicesRegisterCommand_implements(t *testing.T) {
+	t.Parallel()
+	var _ cli.Command = &ServicesRegisterCommand{}
+}
+
+func TestServicesRegisterCommand_noTabs(t *testing.T) {
+	t.Parallel()
+	assertNoTabs(t, new(ServicesRegisterCommand))
+}
+
+func TestServicesRegisterCommand_Validation(t *testing.T) {
+	t.Parallel()
+	ui, c := testServicesRegisterCommand(t)
+
+	cases := map[string]struct {
+		args   []string
+		output string
+	}{
+		"no service": {
+			[]string{},
+			"Must specify -name or definition files",
+		},
+		"-name and file": {
+			[]string{"-name=web", "web.hcl"},
+			"Cannot specify both",
+		},
+		"flags and file": {
+			[]string{"-port=80", "web.hcl"},
+			"can only be used with -name",
+		},
+		"missing file": {
+			[]string{"/does/not/exist.hcl"},
+			"Error reading",
+		},
+	}
+
+	for name, tc := range cases {
+		// Ensure our buffer is always clear
+		if ui.ErrorWriter != nil {
+			ui.ErrorWriter.Reset()
+		}
+		if ui.OutputWriter != nil {
+			ui.OutputWriter.Reset()
+		}
+
+		code := c.Run(tc.args)
+		if code == 0 {
+			t.Errorf("%s: expected non-zero exit", name)
+		}
+
+		output := ui.ErrorWriter.String()
+		if !strings.Contains(output, tc.output) {
+			t.Errorf("%s: expected %q to contain %q", name, output, tc.output)
+		}
+	}
+}
+
+func TestServicesRegisterCommand_Flags(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t.Name(), ``)
+	defer a.Shutdown()
+
+	ui, c := testServicesRegisterCommand(t)
+	args := []string{
+		"-http-addr=" + a.HTTPAddr(),
+		"-name=web",
+		"-port=80",
+		"-tag=v1",
+		"-tag=blue",
+		"-meta=env=prod",
+	}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+	if !strings.Contains(ui.OutputWriter.String(), "Registered service: web") {
+		t.Fatalf("bad: %s", ui.OutputWriter.String())
+	}
+
+	services, err := a.Client().Agent().Services()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	svc, ok := services["web"]
+	if !ok {
+		t.Fatalf("missing service: %v", services)
+	}
+	if svc.Port != 80 || !reflect.DeepEqual(svc.Tags, []string{"v1", "blue"}) ||
+		!reflect.DeepEqual(svc.Meta, map[string]string{"env": "prod"}) {
+		t.Fatalf("bad: %#v", svc)
+	}
+}
+
+func TestServicesRegisterCommand_File(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t.Name(), ``)
+	defer a.Shutdown()
+
+	dir := testutil.TempDir(t, "services")
+	defer os.RemoveAll(dir)
+	hclFile := testServiceFile(t, dir, ".hcl", `
+		service {
+			id = "web1"
+			name = "web"
+			port = 80
+			check {
+				id = "web-ttl"
+				ttl = "10s"
+			}
+		}
+	`)
+	jsonFile := testServiceFile(t, dir, ".json", `{
+		"services": [
+			{"name": "db", "port": 5432, "checks": [{"ttl": "30s"}]},
+			{"name": "cache"}
+		]
+	}`)
+
+	ui, c := testServicesRegisterCommand(t)
+	if code := c.Run([]string{"-http-addr=" + a.HTTPAddr(), hclFile, jsonFile}); code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+
+	services, err := a.Client().Agent().Services()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for _, id := range []string{"web1", "db", "cache"} {
+		if _, ok := services[id]; !ok {
+			t.Fatalf("missing service %q: %v", id, services)
+		}
+	}
+
+	checks, err := a.Client().Agent().Checks()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if chk, ok := checks["web-ttl"]; !ok || chk.ServiceID != "web1" {
+		t.Fatalf("bad: %v", checks)
+	}
+	if chk, ok := checks["service:db"]; !ok || chk.ServiceID != "db" {
+		t.Fatalf("bad: %v", checks)
+	}
+}
+
+func TestServicesRegisterCommand_ACL(t *testing.T) {
+	t.Parallel()
+	a := testACLAgent(t)
+	defer a.Shutdown()
+
+	token := testACLToken(t, a, "web", `service "web" { policy = "write" }`)
+
+	ui, c := testServicesRegisterCommand(t)
+	args := []string{"-http-addr=" + a.HTTPAddr(), "-token=" + token, "-name=db"}
+	if code := c.Run(args); code != 1 {
+		t.Fatalf("bad: %d", code)
+	}
+	if !strings.Contains(ui.ErrorWriter.String(), "Permission denied") {
+		t.Fatalf("bad: %s", ui.ErrorWriter.String())
+	}
+
+	ui, c = testServicesRegisterCommand(t)
+	args = []string{"-http-addr=" + a.HTTPAddr(), "-token=" + token, "-name=web"}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+}
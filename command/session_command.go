@@ -0,0 +1,79 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/mitchellh/cli"
+	"github.com/ryanuber/columnize"
+)
+
+// SessionCommand is a Command implementation that just shows help for
+// the subcommands nested below it.
+type SessionCommand struct {
+	BaseCommand
+}
+
+func (c *SessionCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
+
+func (c *SessionCommand) Help() string {
+	helpText := `
+Usage: consul session <subcommand> [options] [args]
+
+  This command has subcommands for managing sessions, which are used to
+  build locks and leader election on top of the KV store. Here are some
+  simple examples, and more detailed examples are available in the
+  subcommands or the documentation.
+
+  List the sessions of a node:
+
+      $ consul session list -node=web1
+
+  Show a session:
+
+      $ consul session info adf4238a-882b-9ddc-4a9d-5b6758e4159e
+
+  Destroy a session, releasing the locks it holds:
+
+      $ consul session destroy adf4238a-882b-9ddc-4a9d-5b6758e4159e
+
+  For more examples, ask for subcommand help or view the documentation.
+
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *SessionCommand) Synopsis() string {
+	return "Interact with sessions"
+}
+
+// validateSessionBehavior returns an error if the session behavior isn't
+// valid.
+func validateSessionBehavior(behavior string) error {
+	switch behavior {
+	case api.SessionBehaviorRelease, api.SessionBehaviorDelete:
+		return nil
+	default:
+		return fmt.Errorf("Invalid behavior %q, must be %q or %q", behavior,
+			api.SessionBehaviorRelease, api.SessionBehaviorDelete)
+	}
+}
+
+// formatSessionEntry returns the session as a table of its fields.
+func formatSessionEntry(entry *api.SessionEntry) string {
+	result := []string{
+		fmt.Sprintf("ID|%s", entry.ID),
+		fmt.Sprintf("Name|%s", entry.Name),
+		fmt.Sprintf("Node|%s", entry.Node),
+		fmt.Sprintf("Checks|%s", strings.Join(entry.Checks, ", ")),
+		fmt.Sprintf("Behavior|%s", entry.Behavior),
+		fmt.Sprintf("TTL|%s", entry.TTL),
+		fmt.Sprintf("LockDelay|%s", entry.LockDelay),
+		fmt.Sprintf("CreateIndex|%d", entry.CreateIndex),
+		fmt.Sprintf("ModifyIndex|%d", entry.ModifyIndex),
+	}
+	return columnize.SimpleFormat(result)
+}
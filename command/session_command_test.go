@@ -0,0 +1,40 @@
+package command
+
+import (
+	"testing"
+
+	"github.com/hashicorp/consul/agent"
+	"github.com/hashicorp/consul/api"
+	"github.com/mitchellh/cli"
+)
+
+// testSession creates a session on the agent's node.
+func testSession(t *testing.T, a *agent.TestAgent, name string) string {
+	id, _, err := a.Client().Session().Create(&api.SessionEntry{Name: name}, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	return id
+}
+
+func TestSessionCommand_implements(t *testing.T) {
+	t.Parallel()
+	var _ cli.Command = &SessionCommand{}
+}
+
+func TestSessionCommand_noTabs(t *testing.T) {
+	t.Parallel()
+	assertNoTabs(t, new(SessionCommand))
+}
+
+func TestValidateSessionBehavior(t *testing.T) {
+	t.Parallel()
+	for _, b := range []string{api.SessionBehaviorRelease, api.SessionBehaviorDelete} {
+		if err := validateSessionBehavior(b); err != nil {
+			t.Fatalf("%s: err: %v", b, err)
+		}
+	}
+	if err := validateSessionBehavior("nope"); err == nil {
+		t.Fatalf("should fail")
+	}
+}
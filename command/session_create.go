@@ -0,0 +1,101 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/configutil"
+)
+
+// SessionCreateCommand is a Command implementation that is used to create a
+// session.
+type SessionCreateCommand struct {
+	BaseCommand
+}
+
+func (c *SessionCreateCommand) Help() string {
+	helpText := `
+Usage: consul session create [options]
+
+  Creates a session and prints its ID. Unless checks are given, the session
+  is tied to the serfHealth check of the node:
+
+      $ consul session create -name=deploy
+
+  To create a session that's also invalidated unless it's renewed:
+
+      $ consul session create -name=deploy -ttl=30s
+
+` + c.BaseCommand.Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *SessionCreateCommand) Run(args []string) int {
+	f := c.BaseCommand.NewFlagSet(c)
+	name := f.String("name", "", "Human readable name of the session.")
+	node := f.String("node", "",
+		"Node the session belongs to. If unspecified, the node of the agent "+
+			"is used.")
+	var checks []string
+	f.Var((*configutil.AppendSliceValue)(&checks), "check",
+		"ID of a check the session is tied to. This flag may be specified "+
+			"multiple times.")
+	behavior := f.String("behavior", api.SessionBehaviorRelease,
+		"What happens to the locks of the session when it's invalidated, "+
+			"which can be \"release\" or \"delete\". The default value is "+
+			"\"release\".")
+	ttl := f.Duration("ttl", 0,
+		"Time after which the session is invalidated unless it's renewed.")
+	lockDelay := f.Duration("lock-delay", 0,
+		"Time for which the locks of the session can't be acquired after it's "+
+			"invalidated. If unspecified, the server default of 15s is used.")
+	format := f.String("format", "table", formatUsage)
+	if err := c.BaseCommand.Parse(args); err != nil {
+		return 1
+	}
+	if err := validateFormat(*format); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	if err := validateSessionBehavior(*behavior); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	if l := len(f.Args()); l > 0 {
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 0, got %d)", l))
+		return 1
+	}
+
+	client, err := c.BaseCommand.HTTPClient()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error connecting to Consul agent: %s", err))
+		return 1
+	}
+
+	entry := &api.SessionEntry{
+		Name:      *name,
+		Node:      *node,
+		Checks:    checks,
+		Behavior:  *behavior,
+		LockDelay: *lockDelay,
+	}
+	if *ttl > 0 {
+		entry.TTL = ttl.String()
+	}
+	id, _, err := client.Session().Create(entry, nil)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error creating session: %s", err))
+		return 1
+	}
+	if err := printID(c.UI, *format, id); err != nil {
+		c.UI.Error(fmt.Sprintf("Error printing session: %s", err))
+		return 1
+	}
+	return 0
+}
+
+func (c *SessionCreateCommand) Synopsis() string {
+	return "Creates a session"
+}
@@ -0,0 +1,99 @@
+package command
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/agent"
+	"github.com/hashicorp/consul/api"
+	"github.com/mitchellh/cli"
+)
+
+func testSessionCreateCommand(t *testing.T) (*cli.MockUi, *SessionCreateCommand) {
+	ui := cli.NewMockUi()
+	return ui, &SessionCreateCommand{
+		BaseCommand: BaseCommand{
+			UI:    ui,
+			Flags: FlagSetHTTP,
+		},
+	}
+}
+
+func TestSessionCreateCommand_implements(t *testing.T) {
+	t.Parallel()
+	var _ cli.Command = &SessionCreateCommand{}
+}
+
+func TestSessionCreateCommand_noTabs(t *testing.T) {
+	t.Parallel()
+	assertNoTabs(t, new(SessionCreateCommand))
+}
+
+func TestSessionCreateCommand_Validation(t *testing.T) {
+	t.Parallel()
+	ui, c := testSessionCreateCommand(t)
+
+	cases := map[string]struct {
+		args   []string
+		output string
+	}{
+		"bad behavior": {
+			[]string{"-behavior=nope"},
+			"Invalid behavior",
+		},
+		"extra args": {
+			[]string{"foo"},
+			"Too many arguments",
+		},
+	}
+
+	for name, tc := range cases {
+		// Ensure our buffer is always clear
+		if ui.ErrorWriter != nil {
+			ui.ErrorWriter.Reset()
+		}
+		if ui.OutputWriter != nil {
+			ui.OutputWriter.Reset()
+		}
+
+		code := c.Run(tc.args)
+		if code == 0 {
+			t.Errorf("%s: expected non-zero exit", name)
+		}
+
+		output := ui.ErrorWriter.String()
+		if !strings.Contains(output, tc.output) {
+			t.Errorf("%s: expected %q to contain %q", name, output, tc.output)
+		}
+	}
+}
+
+func TestSessionCreateCommand_Run(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t.Name(), ``)
+	defer a.Shutdown()
+
+	ui, c := testSessionCreateCommand(t)
+	args := []string{
+		"-http-addr=" + a.HTTPAddr(),
+		"-name=deploy",
+		"-ttl=30s",
+		"-behavior=delete",
+		"-lock-delay=5s",
+	}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+
+	id := strings.TrimSpace(ui.OutputWriter.String())
+	entry, _, err := a.Client().Session().Info(id, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if entry == nil || entry.Name != "deploy" || entry.TTL != "30s" ||
+		entry.Behavior != api.SessionBehaviorDelete || entry.LockDelay != 5*time.Second ||
+		len(entry.Checks) != 1 || entry.Checks[0] != "serfHealth" {
+		t.Fatalf("bad: %#v", entry)
+	}
+}
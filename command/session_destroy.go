@@ -0,0 +1,56 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SessionDestroyCommand is a Command implementation that is used to destroy
+// a session.
+type SessionDestroyCommand struct {
+	BaseCommand
+}
+
+func (c *SessionDestroyCommand) Help() string {
+	helpText := `
+Usage: consul session destroy [options] ID
+
+  Destroys a session. Its locks are released or deleted, depending on the
+  behavior of the session:
+
+      $ consul session destroy adf4238a-882b-9ddc-4a9d-5b6758e4159e
+
+` + c.BaseCommand.Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *SessionDestroyCommand) Run(args []string) int {
+	f := c.BaseCommand.NewFlagSet(c)
+	if err := c.BaseCommand.Parse(args); err != nil {
+		return 1
+	}
+	id, err := idFromArgs(f.Args())
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	client, err := c.BaseCommand.HTTPClient()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error connecting to Consul agent: %s", err))
+		return 1
+	}
+
+	if _, err := client.Session().Destroy(id, nil); err != nil {
+		c.UI.Error(fmt.Sprintf("Error destroying session %q: %s", id, err))
+		return 1
+	}
+
+	c.UI.Info(fmt.Sprintf("Session %q destroyed", id))
+	return 0
+}
+
+func (c *SessionDestroyCommand) Synopsis() string {
+	return "Destroys a session"
+}
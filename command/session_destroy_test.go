@@ -0,0 +1,57 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/consul/agent"
+	"github.com/mitchellh/cli"
+)
+
+func testSessionDestroyCommand(t *testing.T) (*cli.MockUi, *SessionDestroyCommand) {
+	ui := cli.NewMockUi()
+	return ui, &SessionDestroyCommand{
+		BaseCommand: BaseCommand{
+			UI:    ui,
+			Flags: FlagSetHTTP,
+		},
+	}
+}
+
+func TestSessionDestroyCommand_implements(t *testing.T) {
+	t.Parallel()
+	var _ cli.Command = &SessionDestroyCommand{}
+}
+
+func TestSessionDestroyCommand_noTabs(t *testing.T) {
+	t.Parallel()
+	assertNoTabs(t, new(SessionDestroyCommand))
+}
+
+func TestSessionDestroyCommand_Run(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t.Name(), ``)
+	defer a.Shutdown()
+
+	id := testSession(t, a, "deploy")
+
+	ui, c := testSessionDestroyCommand(t)
+	if code := c.Run([]string{"-http-addr=" + a.HTTPAddr()}); code != 1 {
+		t.Fatalf("bad: %d", code)
+	}
+	if !strings.Contains(ui.ErrorWriter.String(), "Missing ID argument") {
+		t.Fatalf("bad: %s", ui.ErrorWriter.String())
+	}
+
+	ui, c = testSessionDestroyCommand(t)
+	if code := c.Run([]string{"-http-addr=" + a.HTTPAddr(), id}); code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+	entry, _, err := a.Client().Session().Info(id, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if entry != nil {
+		t.Fatalf("bad: %#v", entry)
+	}
+}
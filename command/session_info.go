@@ -0,0 +1,74 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/consul/api"
+)
+
+// SessionInfoCommand is a Command implementation that is used to show a
+// session.
+type SessionInfoCommand struct {
+	BaseCommand
+}
+
+func (c *SessionInfoCommand) Help() string {
+	helpText := `
+Usage: consul session info [options] ID
+
+  Shows a session, including the checks it's tied to:
+
+      $ consul session info adf4238a-882b-9ddc-4a9d-5b6758e4159e
+
+` + c.BaseCommand.Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *SessionInfoCommand) Run(args []string) int {
+	f := c.BaseCommand.NewFlagSet(c)
+	format := f.String("format", "table", formatUsage)
+	if err := c.BaseCommand.Parse(args); err != nil {
+		return 1
+	}
+	if err := validateFormat(*format); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	id, err := idFromArgs(f.Args())
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	client, err := c.BaseCommand.HTTPClient()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error connecting to Consul agent: %s", err))
+		return 1
+	}
+
+	entry, _, err := client.Session().Info(id, &api.QueryOptions{AllowStale: c.BaseCommand.HTTPStale()})
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error reading session %q: %s", id, err))
+		return 1
+	}
+	if entry == nil {
+		c.UI.Error(fmt.Sprintf("Session %q not found", id))
+		return 1
+	}
+
+	if *format == "json" {
+		if err := outputJSON(c.UI, entry); err != nil {
+			c.UI.Error(fmt.Sprintf("Error printing session: %s", err))
+			return 1
+		}
+		return 0
+	}
+	c.UI.Output(formatSessionEntry(entry))
+	return 0
+}
+
+func (c *SessionInfoCommand) Synopsis() string {
+	return "Shows a session"
+}
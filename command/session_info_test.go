@@ -0,0 +1,56 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/consul/agent"
+	"github.com/mitchellh/cli"
+)
+
+func testSessionInfoCommand(t *testing.T) (*cli.MockUi, *SessionInfoCommand) {
+	ui := cli.NewMockUi()
+	return ui, &SessionInfoCommand{
+		BaseCommand: BaseCommand{
+			UI:    ui,
+			Flags: FlagSetHTTP,
+		},
+	}
+}
+
+func TestSessionInfoCommand_implements(t *testing.T) {
+	t.Parallel()
+	var _ cli.Command = &SessionInfoCommand{}
+}
+
+func TestSessionInfoCommand_noTabs(t *testing.T) {
+	t.Parallel()
+	assertNoTabs(t, new(SessionInfoCommand))
+}
+
+func TestSessionInfoCommand_Run(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t.Name(), ``)
+	defer a.Shutdown()
+
+	id := testSession(t, a, "deploy")
+
+	ui, c := testSessionInfoCommand(t)
+	if code := c.Run([]string{"-http-addr=" + a.HTTPAddr(), id}); code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+	output := ui.OutputWriter.String()
+	for _, want := range []string{id, "deploy", a.Config.NodeName, "serfHealth"} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("missing %q: %s", want, output)
+		}
+	}
+
+	ui, c = testSessionInfoCommand(t)
+	if code := c.Run([]string{"-http-addr=" + a.HTTPAddr(), "8f246b77-f3e1-ff88-5b48-8ec93abf3e05"}); code != 1 {
+		t.Fatalf("bad: %d", code)
+	}
+	if !strings.Contains(ui.ErrorWriter.String(), "not found") {
+		t.Fatalf("bad: %s", ui.ErrorWriter.String())
+	}
+}
@@ -0,0 +1,82 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/ryanuber/columnize"
+)
+
+// SessionListCommand is a Command implementation that is used to list the
+// sessions.
+type SessionListCommand struct {
+	BaseCommand
+}
+
+func (c *SessionListCommand) Help() string {
+	helpText := `
+Usage: consul session list [options]
+
+  Lists the sessions, optionally only the ones of a node:
+
+      $ consul session list -node=web1
+
+` + c.BaseCommand.Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *SessionListCommand) Run(args []string) int {
+	f := c.BaseCommand.NewFlagSet(c)
+	node := f.String("node", "", "Only list the sessions of the given node.")
+	format := f.String("format", "table", formatUsage)
+	if err := c.BaseCommand.Parse(args); err != nil {
+		return 1
+	}
+	if err := validateFormat(*format); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	if l := len(f.Args()); l > 0 {
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 0, got %d)", l))
+		return 1
+	}
+
+	client, err := c.BaseCommand.HTTPClient()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error connecting to Consul agent: %s", err))
+		return 1
+	}
+
+	q := &api.QueryOptions{AllowStale: c.BaseCommand.HTTPStale()}
+	var entries []*api.SessionEntry
+	if *node != "" {
+		entries, _, err = client.Session().Node(*node, q)
+	} else {
+		entries, _, err = client.Session().List(q)
+	}
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error listing sessions: %s", err))
+		return 1
+	}
+
+	if *format == "json" {
+		if err := outputJSON(c.UI, entries); err != nil {
+			c.UI.Error(fmt.Sprintf("Error printing sessions: %s", err))
+			return 1
+		}
+		return 0
+	}
+	result := []string{"ID|Name|Node|Behavior|TTL"}
+	for _, entry := range entries {
+		result = append(result, fmt.Sprintf("%s|%s|%s|%s|%s",
+			entry.ID, entry.Name, entry.Node, entry.Behavior, entry.TTL))
+	}
+	c.UI.Output(columnize.SimpleFormat(result))
+	return 0
+}
+
+func (c *SessionListCommand) Synopsis() string {
+	return "Lists sessions"
+}
@@ -0,0 +1,63 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/consul/agent"
+	"github.com/mitchellh/cli"
+)
+
+func testSessionListCommand(t *testing.T) (*cli.MockUi, *SessionListCommand) {
+	ui := cli.NewMockUi()
+	return ui, &SessionListCommand{
+		BaseCommand: BaseCommand{
+			UI:    ui,
+			Flags: FlagSetHTTP,
+		},
+	}
+}
+
+func TestSessionListCommand_implements(t *testing.T) {
+	t.Parallel()
+	var _ cli.Command = &SessionListCommand{}
+}
+
+func TestSessionListCommand_noTabs(t *testing.T) {
+	t.Parallel()
+	assertNoTabs(t, new(SessionListCommand))
+}
+
+func TestSessionListCommand_Run(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t.Name(), ``)
+	defer a.Shutdown()
+
+	id := testSession(t, a, "deploy")
+
+	ui, c := testSessionListCommand(t)
+	if code := c.Run([]string{"-http-addr=" + a.HTTPAddr()}); code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+	output := ui.OutputWriter.String()
+	if !strings.Contains(output, id) || !strings.Contains(output, "deploy") {
+		t.Fatalf("bad: %s", output)
+	}
+
+	// Filter by node.
+	ui, c = testSessionListCommand(t)
+	if code := c.Run([]string{"-http-addr=" + a.HTTPAddr(), "-node=" + a.Config.NodeName}); code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+	if output := ui.OutputWriter.String(); !strings.Contains(output, id) {
+		t.Fatalf("bad: %s", output)
+	}
+
+	ui, c = testSessionListCommand(t)
+	if code := c.Run([]string{"-http-addr=" + a.HTTPAddr(), "-node=nope", "-format=json"}); code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+	if output := strings.TrimSpace(ui.OutputWriter.String()); output != "[]" {
+		t.Fatalf("bad: %s", output)
+	}
+}
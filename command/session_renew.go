@@ -0,0 +1,61 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SessionRenewCommand is a Command implementation that is used to renew the
+// TTL of a session.
+type SessionRenewCommand struct {
+	BaseCommand
+}
+
+func (c *SessionRenewCommand) Help() string {
+	helpText := `
+Usage: consul session renew [options] ID
+
+  Renews the TTL of a session, which keeps it from being invalidated for
+  another TTL:
+
+      $ consul session renew adf4238a-882b-9ddc-4a9d-5b6758e4159e
+
+` + c.BaseCommand.Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *SessionRenewCommand) Run(args []string) int {
+	f := c.BaseCommand.NewFlagSet(c)
+	if err := c.BaseCommand.Parse(args); err != nil {
+		return 1
+	}
+	id, err := idFromArgs(f.Args())
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	client, err := c.BaseCommand.HTTPClient()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error connecting to Consul agent: %s", err))
+		return 1
+	}
+
+	entry, _, err := client.Session().Renew(id, nil)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error renewing session %q: %s", id, err))
+		return 1
+	}
+	if entry == nil {
+		c.UI.Error(fmt.Sprintf("Session %q not found", id))
+		return 1
+	}
+
+	c.UI.Info(fmt.Sprintf("Session %q renewed", id))
+	return 0
+}
+
+func (c *SessionRenewCommand) Synopsis() string {
+	return "Renews a session"
+}
@@ -0,0 +1,57 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/consul/agent"
+	"github.com/hashicorp/consul/api"
+	"github.com/mitchellh/cli"
+)
+
+func testSessionRenewCommand(t *testing.T) (*cli.MockUi, *SessionRenewCommand) {
+	ui := cli.NewMockUi()
+	return ui, &SessionRenewCommand{
+		BaseCommand: BaseCommand{
+			UI:    ui,
+			Flags: FlagSetHTTP,
+		},
+	}
+}
+
+func TestSessionRenewCommand_implements(t *testing.T) {
+	t.Parallel()
+	var _ cli.Command = &SessionRenewCommand{}
+}
+
+func TestSessionRenewCommand_noTabs(t *testing.T) {
+	t.Parallel()
+	assertNoTabs(t, new(SessionRenewCommand))
+}
+
+func TestSessionRenewCommand_Run(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t.Name(), ``)
+	defer a.Shutdown()
+
+	id, _, err := a.Client().Session().Create(&api.SessionEntry{TTL: "30s"}, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	ui, c := testSessionRenewCommand(t)
+	if code := c.Run([]string{"-http-addr=" + a.HTTPAddr(), id}); code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+	if !strings.Contains(ui.OutputWriter.String(), "renewed") {
+		t.Fatalf("bad: %s", ui.OutputWriter.String())
+	}
+
+	ui, c = testSessionRenewCommand(t)
+	if code := c.Run([]string{"-http-addr=" + a.HTTPAddr(), "8f246b77-f3e1-ff88-5b48-8ec93abf3e05"}); code != 1 {
+		t.Fatalf("bad: %d", code)
+	}
+	if !strings.Contains(ui.ErrorWriter.String(), "not found") {
+		t.Fatalf("bad: %s", ui.ErrorWriter.String())
+	}
+}
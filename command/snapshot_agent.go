@@ -0,0 +1,382 @@
+package command
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/snapshot"
+)
+
+const (
+	// snapshotAgentAliveTTL is the TTL of the check that shows the snapshot
+	// agent is running. It is refreshed at a third of this interval.
+	snapshotAgentAliveTTL = 30 * time.Second
+
+	// snapshotAgentRetryTime is how long the agent waits before trying to
+	// get leadership again after giving it up or losing it, which gives
+	// other agents a chance to take over.
+	snapshotAgentRetryTime = 10 * time.Second
+)
+
+// SnapshotAgentCommand is a Command implementation that runs a daemon which
+// periodically saves snapshots of the state of the Consul servers.
+type SnapshotAgentCommand struct {
+	BaseCommand
+
+	ShutdownCh <-chan struct{}
+
+	client         *api.Client
+	store          snapshot.Store
+	retain         int
+	service        string
+	aliveCheckID   string
+	leaderCheckID  string
+	leaderCheckTTL time.Duration
+}
+
+func (c *SnapshotAgentCommand) Help() string {
+	helpText := `
+Usage: consul snapshot agent [options]
+
+  Starts a process that periodically takes snapshots of the state of the Consul
+  servers and saves them to a local directory, along with a SHA256 sum of each
+  snapshot. Each snapshot is verified after it's taken, and the oldest
+  snapshots are deleted so that at most -retain snapshots are kept.
+
+  Several agents can be run for high availability. They use a lock in the KV
+  store so that only one of them takes snapshots at a time. Each agent
+  registers itself as a service with the local Consul agent, with a check
+  that shows it is alive. The agent holding the lock also registers a check
+  that shows whether snapshots are being saved.
+
+  If ACLs are enabled, a management token must be supplied in order to perform
+  snapshot operations.
+
+  To save a snapshot every hour into /var/lib/consul-snapshot:
+
+    $ consul snapshot agent -local-path=/var/lib/consul-snapshot
+
+  To take a single snapshot and exit, for example from a batch job:
+
+    $ consul snapshot agent -interval=0
+
+  For a full list of options and examples, please see the Consul documentation.
+
+` + c.BaseCommand.Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *SnapshotAgentCommand) Run(args []string) int {
+	var interval time.Duration
+	var lockKey string
+	var maxFailures int
+	var deregisterAfter time.Duration
+	var localPath string
+
+	f := c.BaseCommand.NewFlagSet(c)
+	f.DurationVar(&interval, "interval", time.Hour,
+		"Interval at which to take snapshots, as a duration like \"30m\". If "+
+			"this is 0, a single snapshot is taken and the agent exits without "+
+			"taking the lock or registering itself. Defaults to 1h.")
+	f.StringVar(&lockKey, "lock-key", "consul-snapshot/lock",
+		"Key used to make sure only one agent takes snapshots at a time. All "+
+			"agents must use the same key. Defaults to \"consul-snapshot/lock\".")
+	f.IntVar(&maxFailures, "max-failures", 3,
+		"Number of snapshot failures in a row after which the agent gives up "+
+			"the lock, giving another agent a chance to take over. Defaults to 3.")
+	f.IntVar(&c.retain, "retain", 30,
+		"Number of snapshots to keep. The oldest snapshots are deleted after "+
+			"each snapshot is taken. If this is 0, snapshots are never deleted. "+
+			"Defaults to 30.")
+	f.StringVar(&c.service, "service", "consul-snapshot",
+		"Service name the agent registers itself with. Defaults to "+
+			"\"consul-snapshot\".")
+	f.DurationVar(&deregisterAfter, "deregister-after", 72*time.Hour,
+		"Duration after which the agent's service is deregistered if it is "+
+			"critical. If this is 0, the service is never deregistered. "+
+			"Defaults to 72h.")
+	f.StringVar(&localPath, "local-path", ".",
+		"Directory to save snapshots in. Defaults to the current directory.")
+
+	if err := c.BaseCommand.Parse(args); err != nil {
+		return 1
+	}
+
+	if len(f.Args()) > 0 {
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 0, got %d)", len(f.Args())))
+		return 1
+	}
+	if interval < 0 {
+		c.UI.Error("Interval must not be negative")
+		return 1
+	}
+	if c.retain < 0 {
+		c.UI.Error("Retain must not be negative")
+		return 1
+	}
+	if maxFailures < 1 {
+		c.UI.Error("Max failures must be at least 1")
+		return 1
+	}
+	if deregisterAfter < 0 {
+		c.UI.Error("Deregister after must not be negative")
+		return 1
+	}
+
+	// Create and test the HTTP client
+	client, err := c.BaseCommand.HTTPClient()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error connecting to Consul agent: %s", err))
+		return 1
+	}
+	c.client = client
+
+	c.store, err = snapshot.NewLocalStore(localPath)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error setting up snapshot storage: %s", err))
+		return 1
+	}
+
+	// In one-shot mode there's no need for leader election or health
+	// checks.
+	if interval == 0 {
+		if err := c.snapshot(); err != nil {
+			c.UI.Error(err.Error())
+			return 1
+		}
+		return 0
+	}
+
+	c.aliveCheckID = c.service + ":alive"
+	c.leaderCheckID = c.service + ":saving"
+	c.leaderCheckTTL = 2 * interval
+	if err := c.register(deregisterAfter); err != nil {
+		c.UI.Error(fmt.Sprintf("Error registering snapshot agent: %s", err))
+		return 1
+	}
+	defer func() {
+		if err := client.Agent().ServiceDeregister(c.service); err != nil {
+			c.UI.Error(fmt.Sprintf("Error deregistering snapshot agent: %s", err))
+		}
+	}()
+
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+	go c.heartbeat(doneCh)
+
+	lock, err := client.LockOpts(&api.LockOptions{
+		Key:              lockKey,
+		SessionName:      "Consul Snapshot Agent",
+		MonitorRetries:   defaultMonitorRetry,
+		MonitorRetryTime: defaultMonitorRetryTime,
+	})
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Lock setup failed: %s", err))
+		return 1
+	}
+
+	c.UI.Info("Snapshot agent running")
+	for {
+		c.UI.Info("Waiting to obtain leadership...")
+		lockCh, err := lock.Lock(c.ShutdownCh)
+		if lockCh == nil {
+			if err == nil {
+				return 0
+			}
+
+			c.UI.Error(fmt.Sprintf("Lock acquisition failed: %s", err))
+			if !c.wait(snapshotAgentRetryTime) {
+				return 0
+			}
+			continue
+		}
+		c.UI.Info("Obtained leadership")
+
+		shutdown := c.lead(lockCh, interval, maxFailures)
+		if err := lock.Unlock(); err != nil && err != api.ErrLockNotHeld {
+			c.UI.Error(fmt.Sprintf("Lock release failed: %s", err))
+		}
+		if err := client.Agent().CheckDeregister(c.leaderCheckID); err != nil {
+			c.UI.Error(fmt.Sprintf("Error deregistering check: %s", err))
+		}
+		if shutdown || !c.wait(snapshotAgentRetryTime) {
+			return 0
+		}
+	}
+}
+
+// register adds the agent's service to the local Consul agent along with the
+// check that shows the agent is alive.
+func (c *SnapshotAgentCommand) register(deregisterAfter time.Duration) error {
+	agent := c.client.Agent()
+	if err := agent.ServiceRegister(&api.AgentServiceRegistration{
+		ID:   c.service,
+		Name: c.service,
+	}); err != nil {
+		return err
+	}
+
+	check := &api.AgentCheckRegistration{
+		ID:        c.aliveCheckID,
+		Name:      "Consul Snapshot Agent Alive",
+		ServiceID: c.service,
+		AgentServiceCheck: api.AgentServiceCheck{
+			TTL:    snapshotAgentAliveTTL.String(),
+			Status: api.HealthPassing,
+		},
+	}
+	if deregisterAfter > 0 {
+		check.DeregisterCriticalServiceAfter = deregisterAfter.String()
+	}
+	return agent.CheckRegister(check)
+}
+
+// heartbeat keeps the alive check passing until doneCh is closed.
+func (c *SnapshotAgentCommand) heartbeat(doneCh <-chan struct{}) {
+	ticker := time.NewTicker(snapshotAgentAliveTTL / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := c.client.Agent().PassTTL(c.aliveCheckID, ""); err != nil {
+				c.UI.Error(fmt.Sprintf("Error updating check: %s", err))
+			}
+		case <-doneCh:
+			return
+		}
+	}
+}
+
+// lead takes snapshots while the lock is held. It returns when the lock is
+// lost, when too many snapshots fail in a row, or on shutdown, in which case
+// it returns true.
+func (c *SnapshotAgentCommand) lead(lockCh <-chan struct{}, interval time.Duration, maxFailures int) bool {
+	if err := c.client.Agent().CheckRegister(&api.AgentCheckRegistration{
+		ID:        c.leaderCheckID,
+		Name:      "Consul Snapshot Agent Saving Snapshots",
+		ServiceID: c.service,
+		AgentServiceCheck: api.AgentServiceCheck{
+			TTL: c.leaderCheckTTL.String(),
+		},
+	}); err != nil {
+		c.UI.Error(fmt.Sprintf("Error registering check: %s", err))
+	}
+
+	var failures int
+	for {
+		if err := c.snapshot(); err != nil {
+			failures++
+			c.UI.Error(err.Error())
+			if err := c.client.Agent().FailTTL(c.leaderCheckID, err.Error()); err != nil {
+				c.UI.Error(fmt.Sprintf("Error updating check: %s", err))
+			}
+			if failures >= maxFailures {
+				c.UI.Error(fmt.Sprintf("Giving up leadership after %d failed snapshots", failures))
+				return false
+			}
+		} else {
+			failures = 0
+			if err := c.client.Agent().PassTTL(c.leaderCheckID, ""); err != nil {
+				c.UI.Error(fmt.Sprintf("Error updating check: %s", err))
+			}
+		}
+
+		select {
+		case <-time.After(interval):
+		case <-lockCh:
+			c.UI.Error("Lost leadership")
+			return false
+		case <-c.ShutdownCh:
+			return true
+		}
+	}
+}
+
+// wait sleeps for the given duration, returning false if a shutdown was
+// requested in the meantime.
+func (c *SnapshotAgentCommand) wait(d time.Duration) bool {
+	select {
+	case <-time.After(d):
+		return true
+	case <-c.ShutdownCh:
+		return false
+	}
+}
+
+// snapshot takes a snapshot, verifies it, saves it to the store and then
+// deletes the oldest snapshots beyond the retention count.
+func (c *SnapshotAgentCommand) snapshot() error {
+	id := strconv.FormatInt(time.Now().UnixNano(), 10)
+
+	snap, _, err := c.client.Snapshot().Save(&api.QueryOptions{
+		AllowStale: c.BaseCommand.HTTPStale(),
+	})
+	if err != nil {
+		return fmt.Errorf("Error saving snapshot: %s", err)
+	}
+	defer snap.Close()
+
+	// Spool the snapshot to a scratch file so that it can be verified
+	// before it's stored, and sum it along the way.
+	f, err := ioutil.TempFile("", "consul-snapshot")
+	if err != nil {
+		return fmt.Errorf("Error creating snapshot file: %s", err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(f, hash), snap); err != nil {
+		return fmt.Errorf("Error writing snapshot file: %s", err)
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("Error rewinding snapshot file: %s", err)
+	}
+	if _, err := snapshot.Verify(f); err != nil {
+		return fmt.Errorf("Error verifying snapshot file: %s", err)
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("Error rewinding snapshot file: %s", err)
+	}
+	if err := c.store.Save(id, f, hash.Sum(nil)); err != nil {
+		return fmt.Errorf("Error storing snapshot: %s", err)
+	}
+	c.UI.Info(fmt.Sprintf("Saved snapshot %s", id))
+
+	return c.prune()
+}
+
+// prune deletes the oldest snapshots so that at most the configured number of
+// snapshots are kept.
+func (c *SnapshotAgentCommand) prune() error {
+	if c.retain == 0 {
+		return nil
+	}
+
+	ids, err := c.store.List()
+	if err != nil {
+		return fmt.Errorf("Error listing snapshots: %s", err)
+	}
+	for len(ids) > c.retain {
+		if err := c.store.Delete(ids[0]); err != nil {
+			return fmt.Errorf("Error deleting snapshot %s: %s", ids[0], err)
+		}
+		c.UI.Info(fmt.Sprintf("Deleted snapshot %s", ids[0]))
+		ids = ids[1:]
+	}
+	return nil
+}
+
+func (c *SnapshotAgentCommand) Synopsis() string {
+	return "Periodically saves snapshots of Consul server state"
+}
@@ -0,0 +1,240 @@
+package command
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/consul/agent"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/snapshot"
+	"github.com/hashicorp/consul/testutil"
+	"github.com/hashicorp/consul/testutil/retry"
+	"github.com/mitchellh/cli"
+)
+
+func testSnapshotAgentCommand(t *testing.T) (*cli.MockUi, *SnapshotAgentCommand) {
+	ui := cli.NewMockUi()
+	return ui, &SnapshotAgentCommand{
+		BaseCommand: BaseCommand{
+			UI:    ui,
+			Flags: FlagSetHTTP,
+		},
+	}
+}
+
+// checkSnapshotFiles makes sure each snapshot in the directory has a matching
+// sum file and is a valid archive, returning the number of snapshots.
+func checkSnapshotFiles(t *testing.T, dir string) int {
+	files, err := filepath.Glob(filepath.Join(dir, "consul-*.snap"))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		line, err := ioutil.ReadFile(file + ".sha256")
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		want := fmt.Sprintf("%x  %s\n", sha256.Sum256(data), filepath.Base(file))
+		if string(line) != want {
+			t.Fatalf("got %q want %q", line, want)
+		}
+
+		f, err := os.Open(file)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		_, err = snapshot.Verify(f)
+		f.Close()
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+	return len(files)
+}
+
+func TestSnapshotAgentCommand_implements(t *testing.T) {
+	t.Parallel()
+	var _ cli.Command = &SnapshotAgentCommand{}
+}
+
+func TestSnapshotAgentCommand_noTabs(t *testing.T) {
+	t.Parallel()
+	assertNoTabs(t, new(SnapshotAgentCommand))
+}
+
+func TestSnapshotAgentCommand_Validation(t *testing.T) {
+	t.Parallel()
+	ui, c := testSnapshotAgentCommand(t)
+
+	cases := map[string]struct {
+		args   []string
+		output string
+	}{
+		"extra args": {
+			[]string{"foo"},
+			"Too many arguments",
+		},
+		"negative interval": {
+			[]string{"-interval=-1s"},
+			"Interval must not be negative",
+		},
+		"negative retain": {
+			[]string{"-retain=-1"},
+			"Retain must not be negative",
+		},
+		"no failures": {
+			[]string{"-max-failures=0"},
+			"Max failures must be at least 1",
+		},
+		"negative deregister": {
+			[]string{"-deregister-after=-1s"},
+			"Deregister after must not be negative",
+		},
+	}
+
+	for name, tc := range cases {
+		// Ensure our buffer is always clear
+		if ui.ErrorWriter != nil {
+			ui.ErrorWriter.Reset()
+		}
+		if ui.OutputWriter != nil {
+			ui.OutputWriter.Reset()
+		}
+
+		code := c.Run(tc.args)
+		if code == 0 {
+			t.Errorf("%s: expected non-zero exit", name)
+		}
+
+		output := ui.ErrorWriter.String()
+		if !strings.Contains(output, tc.output) {
+			t.Errorf("%s: expected %q to contain %q", name, output, tc.output)
+		}
+	}
+}
+
+func TestSnapshotAgentCommand_OneShot(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t.Name(), ``)
+	defer a.Shutdown()
+
+	dir := testutil.TempDir(t, "snapshot")
+	defer os.RemoveAll(dir)
+
+	args := []string{
+		"-http-addr=" + a.HTTPAddr(),
+		"-interval=0",
+		"-retain=2",
+		"-local-path=" + dir,
+	}
+
+	// Run enough times to make retention kick in.
+	for i := 0; i < 3; i++ {
+		ui, c := testSnapshotAgentCommand(t)
+		if code := c.Run(args); code != 0 {
+			t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+		}
+		if !strings.Contains(ui.OutputWriter.String(), "Saved snapshot") {
+			t.Fatalf("bad: %#v", ui.OutputWriter.String())
+		}
+	}
+
+	if n := checkSnapshotFiles(t, dir); n != 2 {
+		t.Fatalf("got %d snapshots want 2", n)
+	}
+
+	// One-shot mode shouldn't register anything.
+	services, err := a.Client().Agent().Services()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, ok := services["consul-snapshot"]; ok {
+		t.Fatalf("bad: %v", services)
+	}
+}
+
+func TestSnapshotAgentCommand_Daemon(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t.Name(), ``)
+	defer a.Shutdown()
+	client := a.Client()
+
+	dir := testutil.TempDir(t, "snapshot")
+	defer os.RemoveAll(dir)
+
+	shutdownCh := make(chan struct{})
+	ui, c := testSnapshotAgentCommand(t)
+	c.ShutdownCh = shutdownCh
+
+	args := []string{
+		"-http-addr=" + a.HTTPAddr(),
+		"-interval=200ms",
+		"-retain=2",
+		"-local-path=" + dir,
+	}
+	codeCh := make(chan int, 1)
+	go func() {
+		codeCh <- c.Run(args)
+	}()
+
+	// Wait for the agent to lead and run long enough to prune.
+	retry.Run(t, func(r *retry.R) {
+		checks, err := client.Agent().Checks()
+		if err != nil {
+			r.Fatal(err)
+		}
+		for _, id := range []string{"consul-snapshot:alive", "consul-snapshot:saving"} {
+			check, ok := checks[id]
+			if !ok {
+				r.Fatalf("missing check %q", id)
+			}
+			if check.Status != api.HealthPassing || check.ServiceID != "consul-snapshot" {
+				r.Fatalf("bad: %#v", check)
+			}
+		}
+		if !strings.Contains(ui.OutputWriter.String(), "Deleted snapshot") {
+			r.Fatal("no snapshots deleted yet")
+		}
+	})
+	if n := checkSnapshotFiles(t, dir); n != 2 {
+		t.Fatalf("got %d snapshots want 2", n)
+	}
+
+	// The lock should be held.
+	pair, _, err := client.KV().Get("consul-snapshot/lock", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if pair == nil || pair.Session == "" {
+		t.Fatalf("bad: %#v", pair)
+	}
+
+	// Shut down and make sure everything gets cleaned up.
+	shutdownCh <- struct{}{}
+	if code := <-codeCh; code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+	services, err := client.Agent().Services()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, ok := services["consul-snapshot"]; ok {
+		t.Fatalf("bad: %v", services)
+	}
+	pair, _, err = client.KV().Get("consul-snapshot/lock", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if pair != nil && pair.Session != "" {
+		t.Fatalf("bad: %#v", pair)
+	}
+}
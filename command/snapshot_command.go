@@ -40,6 +40,14 @@ Usage: consul snapshot <subcommand> [options] [args]
 
       $ consul snapshot inspect backup.snap
 
+  Decode the records of a snapshot as JSON:
+
+      $ consul snapshot export backup.snap
+
+  Run a daemon that saves a snapshot every hour:
+
+      $ consul snapshot agent
+
 
   For more examples, ask for subcommand help or view the documentation.
 
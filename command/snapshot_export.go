@@ -0,0 +1,204 @@
+package command
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/snapshot"
+)
+
+// SnapshotExportCommand is a Command implementation that is used to decode
+// the records of a snapshot file without restoring it.
+type SnapshotExportCommand struct {
+	BaseCommand
+}
+
+func (c *SnapshotExportCommand) Help() string {
+	helpText := `
+Usage: consul snapshot export [options] FILE
+
+  Decodes the state in a snapshot file on disk, without restoring it into a
+  cluster. The snapshot is verified first.
+
+  By default, each record is written as a line of JSON tagged with its type.
+  To only write the KV entries and the sessions of the file "backup.snap":
+
+    $ consul snapshot export -type=KV,Session backup.snap
+
+  The KV entries can also be written in the format used by "consul kv import",
+  to restore a subset of the keys into a cluster:
+
+    $ consul snapshot export -format=kv -prefix=config/ backup.snap > config.json
+    $ consul kv import @config.json
+
+  For a full list of options and examples, please see the Consul documentation.
+
+` + c.BaseCommand.Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *SnapshotExportCommand) Run(args []string) int {
+	flagSet := c.BaseCommand.NewFlagSet(c)
+	format := flagSet.String("format", "json",
+		"How to write the records. This is either \"json\", which writes each "+
+			"record as a line of JSON tagged with its type, or \"kv\", which only "+
+			"writes the KV entries in the format used by \"consul kv import\". The "+
+			"default value is \"json\".")
+	types := flagSet.String("type", "",
+		"A comma-separated list of the record types to write, such as \"KV\", "+
+			"\"Node\", \"Service\", \"Check\" or \"Session\". This is only used with "+
+			"the \"json\" format. All the types are written by default.")
+	prefix := flagSet.String("prefix", "",
+		"Only write the KV entries and tombstones whose key starts with the "+
+			"given prefix.")
+
+	if err := c.BaseCommand.Parse(args); err != nil {
+		return 1
+	}
+	if *format != "json" && *format != "kv" {
+		c.UI.Error(fmt.Sprintf("Invalid format %q, must be \"json\" or \"kv\"", *format))
+		return 1
+	}
+	include, err := parseSnapshotTypes(*types)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	var file string
+
+	args = flagSet.Args()
+	switch len(args) {
+	case 0:
+		c.UI.Error("Missing FILE argument")
+		return 1
+	case 1:
+		file = args[0]
+	default:
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 1, got %d)", len(args)))
+		return 1
+	}
+
+	// Open the file.
+	f, err := os.Open(file)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error opening snapshot file: %s", err))
+		return 1
+	}
+	defer f.Close()
+
+	// Verify the whole archive before writing anything out of it, since the
+	// records are decoded as they are read.
+	if _, err := snapshot.Verify(f); err != nil {
+		c.UI.Error(fmt.Sprintf("Error verifying snapshot: %s", err))
+		return 1
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		c.UI.Error(fmt.Sprintf("Error reading snapshot file: %s", err))
+		return 1
+	}
+
+	// Records of the KV and tombstone types are filtered by key.
+	keep := func(name string, record interface{}) bool {
+		if entry, ok := record.(*structs.DirEntry); ok && !strings.HasPrefix(entry.Key, *prefix) {
+			return false
+		}
+		return include == nil || include[strings.ToLower(name)]
+	}
+
+	if *format == "kv" {
+		var exported []*kvExportEntry
+		_, err := readSnapshotRecords(f, func(name string, record interface{}, size int) error {
+			if name == "KV" && keep(name, record) {
+				exported = append(exported, dirEntryToExportEntry(record.(*structs.DirEntry)))
+			}
+			return nil
+		})
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error reading snapshot: %s", err))
+			return 1
+		}
+
+		if exported == nil {
+			exported = []*kvExportEntry{}
+		}
+		marshaled, err := json.MarshalIndent(exported, "", "\t")
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error exporting KV data: %s", err))
+			return 1
+		}
+		c.UI.Info(string(marshaled))
+		return 0
+	}
+
+	_, err = readSnapshotRecords(f, func(name string, record interface{}, size int) error {
+		if !keep(name, record) {
+			return nil
+		}
+		line, err := json.Marshal(&snapshotRecord{Type: name, Record: record})
+		if err != nil {
+			return err
+		}
+		c.UI.Output(string(line))
+		return nil
+	})
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error reading snapshot: %s", err))
+		return 1
+	}
+
+	return 0
+}
+
+func (c *SnapshotExportCommand) Synopsis() string {
+	return "Decodes the records of a Consul snapshot file"
+}
+
+// snapshotRecord is a record of a snapshot tagged with its type. The record
+// is left out for the types that aren't known.
+type snapshotRecord struct {
+	Type   string
+	Record interface{} `json:",omitempty"`
+}
+
+// parseSnapshotTypes parses a comma-separated list of record type names into
+// a set of lower case names. An empty list returns a nil set.
+func parseSnapshotTypes(types string) (map[string]bool, error) {
+	if types == "" {
+		return nil, nil
+	}
+
+	known := make(map[string]bool)
+	for _, name := range snapshotCatalogNames {
+		known[strings.ToLower(name)] = true
+	}
+	for _, name := range snapshotTypeNames {
+		known[strings.ToLower(name)] = true
+	}
+
+	include := make(map[string]bool)
+	for _, name := range strings.Split(types, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !known[name] {
+			return nil, fmt.Errorf("Unknown record type %q", name)
+		}
+		include[name] = true
+	}
+	return include, nil
+}
+
+// dirEntryToExportEntry converts a KV entry of a snapshot into the format used
+// by "consul kv export".
+func dirEntryToExportEntry(entry *structs.DirEntry) *kvExportEntry {
+	return &kvExportEntry{
+		Key:   entry.Key,
+		Flags: entry.Flags,
+		Value: base64.StdEncoding.EncodeToString(entry.Value),
+	}
+}
@@ -0,0 +1,215 @@
+package command
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/consul/agent"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/testutil"
+	"github.com/mitchellh/cli"
+)
+
+func testSnapshotExportCommand(t *testing.T) (*cli.MockUi, *SnapshotExportCommand) {
+	ui := cli.NewMockUi()
+	return ui, &SnapshotExportCommand{
+		BaseCommand: BaseCommand{
+			UI:    ui,
+			Flags: FlagSetNone,
+		},
+	}
+}
+
+func TestSnapshotExportCommand_implements(t *testing.T) {
+	t.Parallel()
+	var _ cli.Command = &SnapshotExportCommand{}
+}
+
+func TestSnapshotExportCommand_noTabs(t *testing.T) {
+	t.Parallel()
+	assertNoTabs(t, new(SnapshotExportCommand))
+}
+
+func TestSnapshotExportCommand_Validation(t *testing.T) {
+	t.Parallel()
+	ui, c := testSnapshotExportCommand(t)
+
+	cases := map[string]struct {
+		args   []string
+		output string
+	}{
+		"no file": {
+			[]string{},
+			"Missing FILE argument",
+		},
+		"extra args": {
+			[]string{"foo", "bar", "baz"},
+			"Too many arguments",
+		},
+		"bad format": {
+			[]string{"-format=table", "foo"},
+			"Invalid format",
+		},
+		"bad type": {
+			[]string{"-type=KV,nope", "foo"},
+			"Unknown record type",
+		},
+		"missing file": {
+			[]string{"/does/not/exist.snap"},
+			"Error opening snapshot file",
+		},
+	}
+
+	for name, tc := range cases {
+		// Ensure our buffer is always clear
+		if ui.ErrorWriter != nil {
+			ui.ErrorWriter.Reset()
+		}
+		if ui.OutputWriter != nil {
+			ui.OutputWriter.Reset()
+		}
+
+		code := c.Run(tc.args)
+		if code == 0 {
+			t.Errorf("%s: expected non-zero exit", name)
+		}
+
+		output := ui.ErrorWriter.String()
+		if !strings.Contains(output, tc.output) {
+			t.Errorf("%s: expected %q to contain %q", name, output, tc.output)
+		}
+	}
+}
+
+func TestSnapshotExportCommand_Run(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t.Name(), ``)
+	defer a.Shutdown()
+	client := a.Client()
+
+	for _, key := range []string{"foo/a", "foo/b", "zip"} {
+		pair := &api.KVPair{Key: key, Flags: 42, Value: []byte(key)}
+		if _, err := client.KV().Put(pair, nil); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	dir := testutil.TempDir(t, "snapshot")
+	defer os.RemoveAll(dir)
+
+	file := path.Join(dir, "backup.tgz")
+
+	// Save a snapshot of the current Consul state
+	f, err := os.Create(file)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	snap, _, err := client.Snapshot().Save(nil)
+	if err != nil {
+		f.Close()
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := io.Copy(f, snap); err != nil {
+		f.Close()
+		t.Fatalf("err: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Export all the records.
+	ui, c := testSnapshotExportCommand(t)
+	code := c.Run([]string{file})
+	if code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+
+	counts := make(map[string]int)
+	for _, line := range strings.Split(strings.TrimSpace(ui.OutputWriter.String()), "\n") {
+		var record struct {
+			Type   string
+			Record map[string]interface{}
+		}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		counts[record.Type]++
+	}
+	if counts["KV"] != 3 || counts["Node"] != 1 || counts["Service"] != 1 {
+		t.Fatalf("bad: %v", counts)
+	}
+
+	// Only export the KV records under a prefix.
+	ui, c = testSnapshotExportCommand(t)
+	code = c.Run([]string{"-type=kv", "-prefix=foo/", file})
+	if code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+	lines := strings.Split(strings.TrimSpace(ui.OutputWriter.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("bad: %#v", lines)
+	}
+	for _, line := range lines {
+		if !strings.HasPrefix(line, `{"Type":"KV"`) || !strings.Contains(line, `"Key":"foo/`) {
+			t.Fatalf("bad: %#v", line)
+		}
+	}
+
+	// Export the KV entries in the kv import format.
+	ui, c = testSnapshotExportCommand(t)
+	code = c.Run([]string{"-format=kv", "-prefix=foo/", file})
+	if code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+	var entries []*kvExportEntry
+	if err := json.Unmarshal(ui.OutputWriter.Bytes(), &entries); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	expected := []*kvExportEntry{
+		{Key: "foo/a", Flags: 42, Value: "Zm9vL2E="},
+		{Key: "foo/b", Flags: 42, Value: "Zm9vL2I="},
+	}
+	if len(entries) != len(expected) {
+		t.Fatalf("bad: %#v", entries)
+	}
+	for i, entry := range entries {
+		if *entry != *expected[i] {
+			t.Fatalf("bad: %#v", entry)
+		}
+	}
+}
+
+func TestSnapshotExportCommand_Corrupt(t *testing.T) {
+	t.Parallel()
+	dir := testutil.TempDir(t, "snapshot")
+	defer os.RemoveAll(dir)
+
+	file := path.Join(dir, "backup.tgz")
+	f, err := os.Create(file)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := f.Write([]byte("not a snapshot")); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	ui, c := testSnapshotExportCommand(t)
+	code := c.Run([]string{file})
+	if code == 0 {
+		t.Fatalf("expected non-zero exit")
+	}
+	if !strings.Contains(ui.ErrorWriter.String(), "Error verifying snapshot") {
+		t.Fatalf("bad: %#v", ui.ErrorWriter.String())
+	}
+	if ui.OutputWriter.String() != "" {
+		t.Fatalf("bad: %#v", ui.OutputWriter.String())
+	}
+}
@@ -3,11 +3,17 @@ package command
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"os"
+	"sort"
 	"strings"
 	"text/tabwriter"
 
+	"github.com/hashicorp/consul/agent/consul"
+	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/snapshot"
+	"github.com/hashicorp/go-msgpack/codec"
+	"github.com/hashicorp/raft"
 )
 
 // SnapshotInspectCommand is a Command implementation that is used to display
@@ -20,24 +26,47 @@ func (c *SnapshotInspectCommand) Help() string {
 	helpText := `
 Usage: consul snapshot inspect [options] FILE
 
-  Displays information about a snapshot file on disk.
+  Displays information about a snapshot file on disk, along with the number
+  of records of each type it holds and their size.
 
   To inspect the file "backup.snap":
 
     $ consul snapshot inspect backup.snap
 
+  To also break down the size of the KV entries by key prefix, two levels
+  deep under "config/":
+
+    $ consul snapshot inspect -kvdetails -kvdepth=3 -kvfilter=config/ backup.snap
+
   For a full list of options and examples, please see the Consul documentation.
-`
+
+` + c.BaseCommand.Help()
 
 	return strings.TrimSpace(helpText)
 }
 
 func (c *SnapshotInspectCommand) Run(args []string) int {
 	flagSet := c.BaseCommand.NewFlagSet(c)
+	kvDetails := flagSet.Bool("kvdetails", false,
+		"Break down the size of the KV entries by key prefix.")
+	kvDepth := flagSet.Int("kvdepth", 2,
+		"The number of key segments, separated by \"/\", making up the prefixes "+
+			"of the KV breakdown. The default value is 2.")
+	kvFilter := flagSet.String("kvfilter", "",
+		"Only include the keys starting with the given prefix in the KV breakdown.")
+	format := flagSet.String("format", "table", formatUsage)
 
 	if err := c.BaseCommand.Parse(args); err != nil {
 		return 1
 	}
+	if err := validateFormat(*format); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	if *kvDepth < 1 {
+		c.UI.Error("The -kvdepth flag must be at least 1")
+		return 1
+	}
 
 	var file string
 
@@ -61,20 +90,47 @@ func (c *SnapshotInspectCommand) Run(args []string) int {
 	}
 	defer f.Close()
 
-	meta, err := snapshot.Verify(f)
+	var kv *snapshotKVOptions
+	if *kvDetails {
+		kv = &snapshotKVOptions{depth: *kvDepth, filter: *kvFilter}
+	}
+	info, err := inspectSnapshot(f, kv)
 	if err != nil {
 		c.UI.Error(fmt.Sprintf("Error verifying snapshot: %s", err))
+		return 1
+	}
+
+	if *format == "json" {
+		if err := outputJSON(c.UI, info); err != nil {
+			c.UI.Error(fmt.Sprintf("Error rendering snapshot info: %s", err))
+			return 1
+		}
+		return 0
 	}
 
 	var b bytes.Buffer
 	tw := tabwriter.NewWriter(&b, 0, 2, 6, ' ', 0)
-	fmt.Fprintf(tw, "ID\t%s\n", meta.ID)
-	fmt.Fprintf(tw, "Size\t%d\n", meta.Size)
-	fmt.Fprintf(tw, "Index\t%d\n", meta.Index)
-	fmt.Fprintf(tw, "Term\t%d\n", meta.Term)
-	fmt.Fprintf(tw, "Version\t%d\n", meta.Version)
+	fmt.Fprintf(tw, "ID\t%s\n", info.Meta.ID)
+	fmt.Fprintf(tw, "Size\t%d\n", info.Meta.Size)
+	fmt.Fprintf(tw, "Index\t%d\n", info.Meta.Index)
+	fmt.Fprintf(tw, "Term\t%d\n", info.Meta.Term)
+	fmt.Fprintf(tw, "Version\t%d\n", info.Meta.Version)
+	fmt.Fprintf(tw, "\n")
+	fmt.Fprintf(tw, "Type\tCount\tSize\n")
+	for _, s := range info.Stats {
+		fmt.Fprintf(tw, "%s\t%d\t%d\n", s.Name, s.Count, s.Size)
+	}
+	fmt.Fprintf(tw, "Total\t\t%d\n", info.TotalSize)
+	if kv != nil {
+		fmt.Fprintf(tw, "\n")
+		fmt.Fprintf(tw, "Key Prefix\tCount\tSize\n")
+		for _, s := range info.KVStats {
+			fmt.Fprintf(tw, "%s\t%d\t%d\n", s.Name, s.Count, s.Size)
+		}
+	}
 	if err = tw.Flush(); err != nil {
 		c.UI.Error(fmt.Sprintf("Error rendering snapshot info: %s", err))
+		return 1
 	}
 
 	c.UI.Info(b.String())
@@ -85,3 +141,223 @@ func (c *SnapshotInspectCommand) Run(args []string) int {
 func (c *SnapshotInspectCommand) Synopsis() string {
 	return "Displays information about a Consul snapshot file"
 }
+
+// snapshotInfo is what's displayed about a snapshot.
+type snapshotInfo struct {
+	Meta      snapshotMeta
+	Stats     []*snapshotStats
+	TotalSize int
+	KVStats   []*snapshotStats `json:",omitempty"`
+}
+
+// snapshotMeta is the metadata of a snapshot.
+type snapshotMeta struct {
+	ID      string
+	Size    int64
+	Index   uint64
+	Term    uint64
+	Version int
+}
+
+// snapshotStats is the number of records of a type, or of KV entries under a
+// prefix, and their total size in bytes as encoded in the snapshot.
+type snapshotStats struct {
+	Name  string
+	Count int
+	Size  int
+}
+
+// snapshotKVOptions configures the breakdown of the KV entries by prefix.
+type snapshotKVOptions struct {
+	// depth is the number of key segments in the prefixes.
+	depth int
+
+	// filter is the prefix of the keys to include.
+	filter string
+}
+
+// snapshotTypeNames has the names of the record types in a snapshot. Catalog
+// registrations are further split into nodes, services and checks, see
+// snapshotCatalogNames.
+var snapshotTypeNames = map[structs.MessageType]string{
+	structs.KVSRequestType:            "KV",
+	structs.TombstoneRequestType:      "Tombstone",
+	structs.SessionRequestType:        "Session",
+	structs.ACLRequestType:            "ACL",
+	structs.ACLBootstrapRequestType:   "ACL Bootstrap",
+	structs.ACLPolicyRequestType:      "ACL Policy",
+	structs.CoordinateBatchUpdateType: "Coordinate",
+	structs.PreparedQueryRequestType:  "Prepared Query",
+	structs.AutopilotRequestType:      "Autopilot",
+}
+
+// snapshotCatalogNames has the names of the catalog registration records.
+var snapshotCatalogNames = []string{"Node", "Service", "Check"}
+
+// inspectSnapshot verifies the snapshot archive and decodes the state in it
+// to count its records. The KV entries are broken down by prefix if kv isn't
+// nil.
+func inspectSnapshot(in io.Reader, kv *snapshotKVOptions) (*snapshotInfo, error) {
+	stats := make(map[string]*snapshotStats)
+	kvStats := make(map[string]*snapshotStats)
+	add := func(m map[string]*snapshotStats, name string, size int) {
+		s, ok := m[name]
+		if !ok {
+			s = &snapshotStats{Name: name}
+			m[name] = s
+		}
+		s.Count++
+		s.Size += size
+	}
+
+	meta, err := readSnapshotRecords(in, func(name string, record interface{}, size int) error {
+		add(stats, name, size)
+		if entry, ok := record.(*structs.DirEntry); ok && kv != nil && name == "KV" &&
+			strings.HasPrefix(entry.Key, kv.filter) {
+			add(kvStats, snapshotKVPrefix(entry.Key, kv.depth), size)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	info := &snapshotInfo{
+		Meta: snapshotMeta{
+			ID:      meta.ID,
+			Size:    meta.Size,
+			Index:   meta.Index,
+			Term:    meta.Term,
+			Version: int(meta.Version),
+		},
+		Stats:   sortSnapshotStats(stats),
+		KVStats: sortSnapshotStats(kvStats),
+	}
+	for _, s := range info.Stats {
+		info.TotalSize += s.Size
+	}
+	return info, nil
+}
+
+// readSnapshotRecords reads the snapshot archive and decodes the state in it
+// as it's read, calling the handler with the type name, the decoded record
+// and the encoded size of each record. The archive is only known to be
+// intact once this returns, so it should be verified first if the records
+// are used for more than statistics.
+func readSnapshotRecords(in io.Reader, handler func(name string, record interface{}, size int) error) (*raft.SnapshotMeta, error) {
+	pr, pw := io.Pipe()
+	errCh := make(chan error, 1)
+	go func() {
+		cr := &countingReader{r: pr}
+		err := consul.ReadSnapshot(cr, func(header *consul.SnapshotHeader, msgType structs.MessageType, dec *codec.Decoder) error {
+			// The message type has already been read.
+			start := cr.n - 1
+
+			name, record, err := decodeSnapshotRecord(msgType, dec)
+			if err != nil {
+				return err
+			}
+			return handler(name, record, cr.n-start)
+		})
+		pr.CloseWithError(err)
+		errCh <- err
+	}()
+
+	meta, err := snapshot.Read(in, pw)
+	pw.CloseWithError(err)
+	decodeErr := <-errCh
+	if err != nil {
+		return nil, err
+	}
+	if decodeErr != nil {
+		return nil, fmt.Errorf("failed to decode snapshot state: %v", decodeErr)
+	}
+	return meta, nil
+}
+
+// decodeSnapshotRecord decodes a record of the given type, and returns the
+// name of its type along with the record. The records of unknown types are
+// skipped and returned as nil.
+func decodeSnapshotRecord(msgType structs.MessageType, dec *codec.Decoder) (string, interface{}, error) {
+	var record interface{}
+	switch msgType {
+	case structs.RegisterRequestType:
+		var req structs.RegisterRequest
+		if err := dec.Decode(&req); err != nil {
+			return "", nil, err
+		}
+		switch {
+		case req.Service != nil:
+			return "Service", &req, nil
+		case req.Check != nil:
+			return "Check", &req, nil
+		default:
+			return "Node", &req, nil
+		}
+
+	case structs.KVSRequestType, structs.TombstoneRequestType:
+		record = &structs.DirEntry{}
+	case structs.SessionRequestType:
+		record = &structs.Session{}
+	case structs.ACLRequestType:
+		record = &structs.ACL{}
+	case structs.ACLBootstrapRequestType:
+		record = &structs.ACLBootstrap{}
+	case structs.ACLPolicyRequestType:
+		record = &structs.ACLPolicy{}
+	case structs.CoordinateBatchUpdateType:
+		record = &structs.Coordinates{}
+	case structs.PreparedQueryRequestType:
+		record = &structs.PreparedQuery{}
+	case structs.AutopilotRequestType:
+		record = &structs.AutopilotConfig{}
+	default:
+		var skip interface{}
+		if err := dec.Decode(&skip); err != nil {
+			return "", nil, err
+		}
+		return fmt.Sprintf("Unknown (%d)", msgType), nil, nil
+	}
+
+	if err := dec.Decode(record); err != nil {
+		return "", nil, err
+	}
+	return snapshotTypeNames[msgType], record, nil
+}
+
+// snapshotKVPrefix returns the prefix of the key made of its first depth
+// segments. Keys with fewer segments are their own prefix.
+func snapshotKVPrefix(key string, depth int) string {
+	parts := strings.SplitN(key, "/", depth+1)
+	if len(parts) <= depth {
+		return key
+	}
+	return strings.Join(parts[:depth], "/") + "/"
+}
+
+// sortSnapshotStats returns the stats from the largest to the smallest.
+func sortSnapshotStats(m map[string]*snapshotStats) []*snapshotStats {
+	stats := make([]*snapshotStats, 0, len(m))
+	for _, s := range m {
+		stats = append(stats, s)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Size != stats[j].Size {
+			return stats[i].Size > stats[j].Size
+		}
+		return stats[i].Name < stats[j].Name
+	})
+	return stats
+}
+
+// countingReader counts the bytes read from the reader.
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += n
+	return n, err
+}
@@ -1,6 +1,7 @@
 package command
 
 import (
+	"encoding/json"
 	"io"
 	"os"
 	"path"
@@ -8,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/hashicorp/consul/agent"
+	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/testutil"
 	"github.com/mitchellh/cli"
 )
@@ -48,6 +50,14 @@ func TestSnapshotInspectCommand_Validation(t *testing.T) {
 			[]string{"foo", "bar", "baz"},
 			"Too many arguments",
 		},
+		"bad format": {
+			[]string{"-format=xml", "foo"},
+			"Invalid format",
+		},
+		"bad kvdepth": {
+			[]string{"-kvdepth=0", "foo"},
+			"must be at least 1",
+		},
 	}
 
 	for name, tc := range cases {
@@ -77,6 +87,14 @@ func TestSnapshotInspectCommand_Run(t *testing.T) {
 	defer a.Shutdown()
 	client := a.Client()
 
+	// Write some keys to break down by prefix.
+	for _, key := range []string{"foo/bar/a", "foo/bar/b", "foo/baz/c", "zip"} {
+		pair := &api.KVPair{Key: key, Value: []byte("hello")}
+		if _, err := client.KV().Put(pair, nil); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
 	dir := testutil.TempDir(t, "snapshot")
 	defer os.RemoveAll(dir)
 
@@ -117,9 +135,86 @@ func TestSnapshotInspectCommand_Run(t *testing.T) {
 		"Index",
 		"Term",
 		"Version",
+		"Node",
+		"Service",
+		"KV",
+		"Total",
 	} {
 		if !strings.Contains(output, key) {
 			t.Fatalf("bad %#v, missing %q", output, key)
 		}
 	}
+	if strings.Contains(output, "Key Prefix") {
+		t.Fatalf("bad %#v, should not break down the KV entries", output)
+	}
+
+	// Break down the KV entries by prefix.
+	ui, c = testSnapshotInspectCommand(t)
+	args = []string{"-kvdetails", "-kvfilter=foo/", file}
+
+	code = c.Run(args)
+	if code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+
+	output = ui.OutputWriter.String()
+	for _, key := range []string{"Key Prefix", "foo/bar/", "foo/baz/"} {
+		if !strings.Contains(output, key) {
+			t.Fatalf("bad %#v, missing %q", output, key)
+		}
+	}
+	if strings.Contains(output, "zip") {
+		t.Fatalf("bad %#v, should filter out zip", output)
+	}
+
+	// Get the info as JSON.
+	ui, c = testSnapshotInspectCommand(t)
+	args = []string{"-format=json", "-kvdetails", "-kvdepth=1", file}
+
+	code = c.Run(args)
+	if code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+
+	var info snapshotInfo
+	if err := json.Unmarshal(ui.OutputWriter.Bytes(), &info); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if info.Meta.Index == 0 || info.TotalSize == 0 {
+		t.Fatalf("bad: %#v", info)
+	}
+	counts := make(map[string]int)
+	for _, s := range info.Stats {
+		counts[s.Name] = s.Count
+	}
+	if counts["KV"] != 4 {
+		t.Fatalf("bad: %v", counts)
+	}
+	kvCounts := make(map[string]int)
+	for _, s := range info.KVStats {
+		kvCounts[s.Name] = s.Count
+	}
+	if kvCounts["foo/"] != 3 || kvCounts["zip"] != 1 {
+		t.Fatalf("bad: %v", kvCounts)
+	}
+}
+
+func TestSnapshotInspectCommand_kvPrefix(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		key    string
+		depth  int
+		prefix string
+	}{
+		{"foo/bar/baz", 1, "foo/"},
+		{"foo/bar/baz", 2, "foo/bar/"},
+		{"foo/bar/baz", 3, "foo/bar/baz"},
+		{"foo/bar/", 2, "foo/bar/"},
+		{"foo", 2, "foo"},
+	}
+	for _, tc := range cases {
+		if got := snapshotKVPrefix(tc.key, tc.depth); got != tc.prefix {
+			t.Errorf("%q at depth %d: got %q want %q", tc.key, tc.depth, got, tc.prefix)
+		}
+	}
 }
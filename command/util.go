@@ -0,0 +1,84 @@
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+// formatUsage is the usage of the -format flag of the commands that print
+// API objects.
+const formatUsage = "Output format, which can be \"table\" or \"json\". " +
+	"The default value is \"table\"."
+
+// validateFormat returns an error if the -format flag isn't valid.
+func validateFormat(format string) error {
+	switch format {
+	case "table", "json":
+		return nil
+	default:
+		return fmt.Errorf("Invalid format %q, must be \"table\" or \"json\"", format)
+	}
+}
+
+// outputJSON prints the value as indented JSON.
+func outputJSON(ui cli.Ui, v interface{}) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	ui.Output(string(b))
+	return nil
+}
+
+// idFromArgs returns the ID, which must be the only argument.
+func idFromArgs(args []string) (string, error) {
+	switch len(args) {
+	case 0:
+		return "", fmt.Errorf("Missing ID argument")
+	case 1:
+		return args[0], nil
+	default:
+		return "", fmt.Errorf("Too many arguments (expected 1, got %d)", len(args))
+	}
+}
+
+// printID prints the ID of a created object in the given format.
+func printID(ui cli.Ui, format, id string) error {
+	if format == "json" {
+		return outputJSON(ui, struct{ ID string }{id})
+	}
+	ui.Output(id)
+	return nil
+}
+
+// valueFromArg returns the value of a flag that is read from a file when
+// prefixed with "@" and from stdin when it's "-". Other values are returned
+// as they are.
+func valueFromArg(arg string, stdin io.Reader) (string, error) {
+	switch {
+	case strings.HasPrefix(arg, "@"):
+		data, err := ioutil.ReadFile(arg[1:])
+		if err != nil {
+			return "", fmt.Errorf("Failed to read file: %s", err)
+		}
+		return string(data), nil
+	case arg == "-":
+		if stdin == nil {
+			stdin = os.Stdin
+		}
+		var b bytes.Buffer
+		if _, err := io.Copy(&b, stdin); err != nil {
+			return "", fmt.Errorf("Failed to read stdin: %s", err)
+		}
+		return b.String(), nil
+	default:
+		return arg, nil
+	}
+}
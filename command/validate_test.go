@@ -58,6 +58,35 @@ func TestValidateCommandSucceedOnMinimalConfigFile(t *testing.T) {
 	}
 }
 
+func TestValidateCommandSucceedOnGRPCCheck(t *testing.T) {
+	td := testutil.TempDir(t, "consul")
+	defer os.RemoveAll(td)
+
+	fp := filepath.Join(td, "config.json")
+	err := ioutil.WriteFile(fp, []byte(`{
+		"bind_addr":"10.0.0.1",
+		"data_dir":"`+td+`",
+		"check": {
+			"name": "grpc",
+			"grpc": "127.0.0.1:12345/my.Service",
+			"grpc_use_tls": true,
+			"tls_skip_verify": true,
+			"interval": "10s"
+		}
+	}`), 0644)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	_, cmd := testValidateCommand(t)
+
+	args := []string{fp}
+
+	if code := cmd.Run(args); code != 0 {
+		t.Fatalf("bad: %d", code)
+	}
+}
+
 func TestValidateCommandSucceedOnMinimalConfigDir(t *testing.T) {
 	td := testutil.TempDir(t, "consul")
 	defer os.RemoveAll(td)
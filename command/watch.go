@@ -25,8 +25,10 @@ func (c *WatchCommand) Help() string {
 Usage: consul watch [options] [child...]
 
   Watches for changes in a given data view from Consul. If a child process
-  is specified, it will be invoked with the latest results on changes. Otherwise,
-  the latest values are dumped to stdout and the watch terminates.
+  is specified, it will be invoked with the latest results on changes. If the
+  child is a single http:// or https:// URL, the latest results are POSTed to
+  it instead. Otherwise, the latest values are dumped to stdout and the watch
+  terminates.
 
   Providing the watch type is required, and other parameters may be required
   or supported depending on the watch type.
@@ -152,6 +154,21 @@ func (c *WatchCommand) Run(args []string) int {
 			}
 			c.UI.Output(string(buf))
 		}
+	} else if len(f.Args()) == 1 && isHTTPHandlerTarget(f.Args()[0]) {
+		config, err := watch.ParseHTTPHandlerConfig(map[string]interface{}{
+			"path": f.Args()[0],
+		})
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("%s", err))
+			return 1
+		}
+		wp.Handler = func(idx uint64, data interface{}) {
+			if _, err := agent.PostWatchData(config, idx, data); err != nil {
+				c.UI.Error(fmt.Sprintf("Error executing handler: %s", err))
+				wp.Stop()
+				errExit = 1
+			}
+		}
 	} else {
 		wp.Handler = func(idx uint64, data interface{}) {
 			doneCh := make(chan struct{})
@@ -223,6 +240,12 @@ func (c *WatchCommand) Run(args []string) int {
 	return errExit
 }
 
+// isHTTPHandlerTarget returns true if the child argument is a URL which the
+// watch data should be sent to rather than a process to run.
+func isHTTPHandlerTarget(arg string) bool {
+	return strings.HasPrefix(arg, "http://") || strings.HasPrefix(arg, "https://")
+}
+
 func (c *WatchCommand) Synopsis() string {
 	return "Watch for changes in Consul"
 }
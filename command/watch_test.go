@@ -1,6 +1,9 @@
 package command
 
 import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 
@@ -36,3 +39,39 @@ func TestWatchCommandRun(t *testing.T) {
 		t.Fatalf("bad: %#v", ui.OutputWriter.String())
 	}
 }
+
+func TestWatchCommandRun_HTTPHandler(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t.Name(), ``)
+	defer a.Shutdown()
+
+	bodyCh := make(chan []byte, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		bodyCh <- body
+
+		// Reject the request so that the watch stops.
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	ui := cli.NewMockUi()
+	c := &WatchCommand{
+		BaseCommand: BaseCommand{
+			UI:    ui,
+			Flags: FlagSetHTTP,
+		},
+	}
+	args := []string{"-http-addr=" + a.HTTPAddr(), "-type=nodes", server.URL}
+
+	code := c.Run(args)
+	if code != 1 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+	if !strings.Contains(ui.ErrorWriter.String(), "unexpected response code 400") {
+		t.Fatalf("bad: %#v", ui.ErrorWriter.String())
+	}
+	if body := <-bodyCh; !strings.Contains(string(body), a.Config.NodeName) {
+		t.Fatalf("bad: %s", body)
+	}
+}
@@ -0,0 +1,333 @@
+package lib
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/armon/go-metrics"
+)
+
+const (
+	// PrometheusContentType is the content type of the Prometheus text
+	// exposition format written by PrometheusSink.
+	PrometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+	// prometheusMaxSamples is the number of most recent samples a summary
+	// keeps around to compute its quantiles.
+	prometheusMaxSamples = 1024
+)
+
+// prometheusQuantiles are the quantiles reported for every summary.
+var prometheusQuantiles = []float64{0.5, 0.9, 0.99}
+
+// PrometheusSink is a metrics.MetricSink which aggregates gauges, counters
+// and samples so they can be scraped by Prometheus in its text exposition
+// format. Metrics which haven't been updated within the retention time are
+// dropped.
+type PrometheusSink struct {
+	retention time.Duration
+	hostname  string
+	labels    []metrics.Label
+
+	lock      sync.Mutex
+	gauges    map[string]*prometheusGauge
+	counters  map[string]*prometheusCounter
+	summaries map[string]*prometheusSummary
+}
+
+type prometheusSeries struct {
+	name    string
+	labels  []metrics.Label
+	updated time.Time
+}
+
+type prometheusGauge struct {
+	prometheusSeries
+	value float64
+}
+
+type prometheusCounter struct {
+	prometheusSeries
+	value float64
+}
+
+type prometheusSummary struct {
+	prometheusSeries
+	count   uint64
+	sum     float64
+	samples []float64
+	next    int
+}
+
+// NewPrometheusSink returns a sink which retains metrics for the given
+// duration. Every metric is labeled with the given hostname and datacenter.
+// Since go-metrics prepends the hostname to the key of gauges when hostname
+// prefixing is enabled, the hostname is also stripped from those keys so that
+// it is only reported as a label.
+func NewPrometheusSink(retention time.Duration, hostname, datacenter string) *PrometheusSink {
+	var labels []metrics.Label
+	if datacenter != "" {
+		labels = append(labels, metrics.Label{Name: "datacenter", Value: datacenter})
+	}
+	if hostname != "" {
+		labels = append(labels, metrics.Label{Name: "host", Value: hostname})
+	}
+	return &PrometheusSink{
+		retention: retention,
+		hostname:  hostname,
+		labels:    labels,
+		gauges:    make(map[string]*prometheusGauge),
+		counters:  make(map[string]*prometheusCounter),
+		summaries: make(map[string]*prometheusSummary),
+	}
+}
+
+func (p *PrometheusSink) SetGauge(key []string, val float32) {
+	p.SetGaugeWithLabels(key, val, nil)
+}
+
+func (p *PrometheusSink) SetGaugeWithLabels(key []string, val float32, labels []metrics.Label) {
+	if p.hostname != "" && len(key) > 1 && key[1] == p.hostname {
+		key = append([]string{key[0]}, key[2:]...)
+	}
+	name, labels := p.series(key, labels)
+	id := prometheusSeriesID(name, labels)
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	g, ok := p.gauges[id]
+	if !ok {
+		g = &prometheusGauge{prometheusSeries: prometheusSeries{name: name, labels: labels}}
+		p.gauges[id] = g
+	}
+	g.value = float64(val)
+	g.updated = time.Now()
+}
+
+// EmitKey is a no-op since key/value pairs have no Prometheus equivalent.
+func (p *PrometheusSink) EmitKey(key []string, val float32) {
+}
+
+func (p *PrometheusSink) IncrCounter(key []string, val float32) {
+	p.IncrCounterWithLabels(key, val, nil)
+}
+
+func (p *PrometheusSink) IncrCounterWithLabels(key []string, val float32, labels []metrics.Label) {
+	name, labels := p.series(key, labels)
+	id := prometheusSeriesID(name, labels)
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	c, ok := p.counters[id]
+	if !ok {
+		c = &prometheusCounter{prometheusSeries: prometheusSeries{name: name, labels: labels}}
+		p.counters[id] = c
+	}
+	c.value += float64(val)
+	c.updated = time.Now()
+}
+
+func (p *PrometheusSink) AddSample(key []string, val float32) {
+	p.AddSampleWithLabels(key, val, nil)
+}
+
+func (p *PrometheusSink) AddSampleWithLabels(key []string, val float32, labels []metrics.Label) {
+	name, labels := p.series(key, labels)
+	id := prometheusSeriesID(name, labels)
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	s, ok := p.summaries[id]
+	if !ok {
+		s = &prometheusSummary{prometheusSeries: prometheusSeries{name: name, labels: labels}}
+		p.summaries[id] = s
+	}
+	s.count++
+	s.sum += float64(val)
+	if len(s.samples) < prometheusMaxSamples {
+		s.samples = append(s.samples, float64(val))
+	} else {
+		s.samples[s.next] = float64(val)
+		s.next = (s.next + 1) % prometheusMaxSamples
+	}
+	s.updated = time.Now()
+}
+
+// series returns the sanitized metric name for the key along with the full,
+// sorted set of labels for it, including the sink's own labels unless they
+// were already given.
+func (p *PrometheusSink) series(key []string, labels []metrics.Label) (string, []metrics.Label) {
+	all := make([]metrics.Label, 0, len(labels)+len(p.labels))
+	seen := make(map[string]bool)
+	for _, l := range labels {
+		name := prometheusName(l.Name)
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		all = append(all, metrics.Label{Name: name, Value: l.Value})
+	}
+	for _, l := range p.labels {
+		if !seen[l.Name] {
+			all = append(all, l)
+		}
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Name < all[j].Name })
+	return prometheusName(strings.Join(key, "_")), all
+}
+
+// WriteTo writes all retained metrics to w in the Prometheus text exposition
+// format, pruning any which have expired.
+func (p *PrometheusSink) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+	p.lock.Lock()
+	p.prune()
+	families := make(map[string][]string)
+	types := make(map[string]string)
+	add := func(name, typ, lines string) {
+		if _, ok := types[name]; !ok {
+			types[name] = typ
+		}
+		families[name] = append(families[name], lines)
+	}
+	for _, g := range p.gauges {
+		add(g.name, "gauge", prometheusLine(g.name, g.labels, g.value))
+	}
+	for _, c := range p.counters {
+		add(c.name, "counter", prometheusLine(c.name, c.labels, c.value))
+	}
+	for _, s := range p.summaries {
+		add(s.name, "summary", s.lines())
+	}
+	p.lock.Unlock()
+
+	names := make([]string, 0, len(families))
+	for name := range families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		lines := families[name]
+		sort.Strings(lines)
+		fmt.Fprintf(&buf, "# TYPE %s %s\n", name, types[name])
+		for _, l := range lines {
+			buf.WriteString(l)
+		}
+	}
+	return buf.WriteTo(w)
+}
+
+// prune removes all metrics which haven't been updated within the retention
+// time. The lock must be held by the caller.
+func (p *PrometheusSink) prune() {
+	if p.retention <= 0 {
+		return
+	}
+	cutoff := time.Now().Add(-p.retention)
+	for id, g := range p.gauges {
+		if g.updated.Before(cutoff) {
+			delete(p.gauges, id)
+		}
+	}
+	for id, c := range p.counters {
+		if c.updated.Before(cutoff) {
+			delete(p.counters, id)
+		}
+	}
+	for id, s := range p.summaries {
+		if s.updated.Before(cutoff) {
+			delete(p.summaries, id)
+		}
+	}
+}
+
+// lines renders the quantiles, sum and count of the summary.
+func (s *prometheusSummary) lines() string {
+	sorted := make([]float64, len(s.samples))
+	copy(sorted, s.samples)
+	sort.Float64s(sorted)
+
+	var buf bytes.Buffer
+	for _, q := range prometheusQuantiles {
+		v := math.NaN()
+		if n := len(sorted); n > 0 {
+			v = sorted[int(q*float64(n-1)+0.5)]
+		}
+		labels := append(append([]metrics.Label{}, s.labels...), metrics.Label{Name: "quantile", Value: strconv.FormatFloat(q, 'g', -1, 64)})
+		buf.WriteString(prometheusLine(s.name, labels, v))
+	}
+	buf.WriteString(prometheusLine(s.name+"_sum", s.labels, s.sum))
+	buf.WriteString(prometheusLine(s.name+"_count", s.labels, float64(s.count)))
+	return buf.String()
+}
+
+// prometheusLine renders a single sample line.
+func prometheusLine(name string, labels []metrics.Label, value float64) string {
+	var buf bytes.Buffer
+	buf.WriteString(name)
+	if len(labels) > 0 {
+		buf.WriteByte('{')
+		for i, l := range labels {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			fmt.Fprintf(&buf, "%s=\"%s\"", l.Name, prometheusEscaper.Replace(l.Value))
+		}
+		buf.WriteByte('}')
+	}
+	buf.WriteByte(' ')
+	buf.WriteString(prometheusValue(value))
+	buf.WriteByte('\n')
+	return buf.String()
+}
+
+// prometheusEscaper escapes backslashes, double quotes and newlines, which
+// are the only characters the exposition format escapes in label values.
+var prometheusEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// prometheusValue formats a sample value, including the special values the
+// exposition format knows about.
+func prometheusValue(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+// prometheusName replaces all characters which aren't valid in a metric or
+// label name with underscores.
+func prometheusName(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '_', c == ':':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
+
+// prometheusSeriesID returns a unique identifier for the series.
+func prometheusSeriesID(name string, labels []metrics.Label) string {
+	var buf bytes.Buffer
+	buf.WriteString(name)
+	for _, l := range labels {
+		buf.WriteString("\x00" + l.Name + "\x00" + l.Value)
+	}
+	return buf.String()
+}
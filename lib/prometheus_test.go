@@ -0,0 +1,60 @@
+package lib
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/armon/go-metrics"
+)
+
+func TestPrometheusSink(t *testing.T) {
+	s := NewPrometheusSink(time.Minute, "node1", "dc1")
+
+	// Gauges have the hostname stripped from their key.
+	s.SetGauge([]string{"consul", "node1", "runtime", "num_goroutines"}, 42)
+	s.IncrCounter([]string{"consul", "raft", "apply"}, 1)
+	s.IncrCounter([]string{"consul", "raft", "apply"}, 2)
+	s.IncrCounterWithLabels([]string{"consul", "rpc", "request"}, 1, []metrics.Label{{Name: "host", Value: "other"}, {Name: "method-name", Value: "a\"b"}})
+	for i := 1; i <= 10; i++ {
+		s.AddSample([]string{"consul", "fsm", "register"}, float32(i))
+	}
+	s.EmitKey([]string{"consul", "ignored"}, 1)
+
+	var buf bytes.Buffer
+	if _, err := s.WriteTo(&buf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	want := `# TYPE consul_fsm_register summary
+consul_fsm_register{datacenter="dc1",host="node1",quantile="0.5"} 6
+consul_fsm_register{datacenter="dc1",host="node1",quantile="0.9"} 9
+consul_fsm_register{datacenter="dc1",host="node1",quantile="0.99"} 10
+consul_fsm_register_sum{datacenter="dc1",host="node1"} 55
+consul_fsm_register_count{datacenter="dc1",host="node1"} 10
+# TYPE consul_raft_apply counter
+consul_raft_apply{datacenter="dc1",host="node1"} 3
+# TYPE consul_rpc_request counter
+consul_rpc_request{datacenter="dc1",host="other",method_name="a\"b"} 1
+# TYPE consul_runtime_num_goroutines gauge
+consul_runtime_num_goroutines{datacenter="dc1",host="node1"} 42
+`
+	if got := buf.String(); got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestPrometheusSink_Retention(t *testing.T) {
+	s := NewPrometheusSink(50*time.Millisecond, "", "dc1")
+	s.SetGauge([]string{"consul", "old"}, 1)
+	time.Sleep(100 * time.Millisecond)
+	s.SetGauge([]string{"consul", "new"}, 2)
+
+	var buf bytes.Buffer
+	if _, err := s.WriteTo(&buf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if got := buf.String(); strings.Contains(got, "consul_old") || !strings.Contains(got, `consul_new{datacenter="dc1"} 2`) {
+		t.Fatalf("bad: %s", got)
+	}
+}
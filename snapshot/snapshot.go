@@ -126,6 +126,14 @@ func (s *Snapshot) Close() error {
 
 // Verify takes the snapshot from the reader and verifies its contents.
 func Verify(in io.Reader) (*raft.SnapshotMeta, error) {
+	// Read the archive, throwing away the snapshot data.
+	return Read(in, ioutil.Discard)
+}
+
+// Read takes the snapshot from the reader, verifies its contents and writes
+// the snapshot data to the given writer as it's read. The data is only known
+// to be intact once this returns without an error.
+func Read(in io.Reader, snap io.Writer) (*raft.SnapshotMeta, error) {
 	// Wrap the reader in a gzip decompressor.
 	decomp, err := gzip.NewReader(in)
 	if err != nil {
@@ -133,9 +141,9 @@ func Verify(in io.Reader) (*raft.SnapshotMeta, error) {
 	}
 	defer decomp.Close()
 
-	// Read the archive, throwing away the snapshot data.
+	// Read the archive.
 	var metadata raft.SnapshotMeta
-	if err := read(decomp, &metadata, ioutil.Discard); err != nil {
+	if err := read(decomp, &metadata, snap); err != nil {
 		return nil, fmt.Errorf("failed to read snapshot file: %v", err)
 	}
 	return &metadata, nil
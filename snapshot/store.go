@@ -0,0 +1,128 @@
+package snapshot
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Store is where the snapshot agent keeps the snapshots it takes. Snapshots
+// are identified by an ID which sorts in the order they were taken, so the
+// agent can find the oldest ones when enforcing retention. Other backends only
+// need to implement this interface in order to be used by the agent.
+type Store interface {
+	// Save stores a verified snapshot archive under the given ID, along
+	// with the SHA256 sum of its contents.
+	Save(id string, archive io.Reader, sum []byte) error
+
+	// List returns the IDs of the stored snapshots, oldest first.
+	List() ([]string, error)
+
+	// Delete removes the snapshot with the given ID along with its sum.
+	Delete(id string) error
+}
+
+const (
+	// localStorePrefix and localStoreSuffix make up the file names used by
+	// LocalStore around the snapshot ID.
+	localStorePrefix = "consul-"
+	localStoreSuffix = ".snap"
+
+	// localStoreSumSuffix is appended to the snapshot file name to get the
+	// name of the file holding its SHA256 sum.
+	localStoreSumSuffix = ".sha256"
+)
+
+// LocalStore keeps snapshots as files in a local directory. Each snapshot is
+// written to "consul-<id>.snap" next to a "consul-<id>.snap.sha256" file in
+// the format used by sha256sum, so archives can be checked with
+// "sha256sum -c" before being restored.
+type LocalStore struct {
+	path string
+}
+
+// NewLocalStore returns a store that keeps snapshots in the given directory,
+// creating it if needed.
+func NewLocalStore(path string) (*LocalStore, error) {
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create snapshot directory: %v", err)
+	}
+	return &LocalStore{path: path}, nil
+}
+
+// file returns the path of the archive for the given snapshot ID.
+func (s *LocalStore) file(id string) string {
+	return filepath.Join(s.path, localStorePrefix+id+localStoreSuffix)
+}
+
+// Save writes the archive to a temporary file first and renames it into place
+// once it's complete, so a partial snapshot is never left behind under a
+// valid name.
+func (s *LocalStore) Save(id string, archive io.Reader, sum []byte) error {
+	file := s.file(id)
+	tmp := file + ".tmp"
+
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot file: %v", err)
+	}
+	if _, err := io.Copy(f, archive); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write snapshot file: %v", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("failed to sync snapshot file: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to close snapshot file: %v", err)
+	}
+
+	line := fmt.Sprintf("%x  %s\n", sum, filepath.Base(file))
+	if err := ioutil.WriteFile(file+localStoreSumSuffix, []byte(line), 0644); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write snapshot sum file: %v", err)
+	}
+	if err := os.Rename(tmp, file); err != nil {
+		os.Remove(tmp)
+		os.Remove(file + localStoreSumSuffix)
+		return fmt.Errorf("failed to rename snapshot file: %v", err)
+	}
+	return nil
+}
+
+// List returns the IDs of the snapshots in the directory, oldest first.
+func (s *LocalStore) List() ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(s.path, localStorePrefix+"*"+localStoreSuffix))
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for _, file := range files {
+		id := strings.TrimPrefix(filepath.Base(file), localStorePrefix)
+		ids = append(ids, strings.TrimSuffix(id, localStoreSuffix))
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// Delete removes the snapshot and its sum file. A missing sum file isn't an
+// error, since it may have been removed by hand.
+func (s *LocalStore) Delete(id string) error {
+	file := s.file(id)
+	if err := os.Remove(file); err != nil {
+		return err
+	}
+	if err := os.Remove(file + localStoreSumSuffix); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
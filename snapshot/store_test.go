@@ -0,0 +1,97 @@
+package snapshot
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/consul/testutil"
+)
+
+func TestLocalStore(t *testing.T) {
+	dir := testutil.TempDir(t, "snapshot")
+	defer os.RemoveAll(dir)
+
+	store, err := NewLocalStore(filepath.Join(dir, "nested"))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Nothing stored yet.
+	ids, err := store.List()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(ids) != 0 {
+		t.Fatalf("bad: %v", ids)
+	}
+
+	// Save a few snapshots out of order.
+	for _, id := range []string{"300", "100", "200"} {
+		data := []byte("snapshot " + id)
+		sum := sha256.Sum256(data)
+		if err := store.Save(id, bytes.NewReader(data), sum[:]); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	// They should come back oldest first.
+	ids, err = store.List()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if want := []string{"100", "200", "300"}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("got %v want %v", ids, want)
+	}
+
+	// Check the archive and the sum file.
+	file := filepath.Join(dir, "nested", "consul-200.snap")
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if string(data) != "snapshot 200" {
+		t.Fatalf("bad: %q", data)
+	}
+	line, err := ioutil.ReadFile(file + ".sha256")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	sum := sha256.Sum256(data)
+	if want := fmt.Sprintf("%x  consul-200.snap\n", sum); string(line) != want {
+		t.Fatalf("got %q want %q", line, want)
+	}
+
+	// Delete one and make sure both files are gone.
+	if err := store.Delete("200"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for _, f := range []string{file, file + ".sha256"} {
+		if _, err := os.Stat(f); !os.IsNotExist(err) {
+			t.Fatalf("%s should be gone: %v", f, err)
+		}
+	}
+	ids, err = store.List()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if want := []string{"100", "300"}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("got %v want %v", ids, want)
+	}
+
+	// A missing sum file is fine, but a missing snapshot isn't.
+	if err := os.Remove(filepath.Join(dir, "nested", "consul-100.snap.sha256")); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := store.Delete("100"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := store.Delete("100"); err == nil {
+		t.Fatalf("should fail")
+	}
+}
@@ -0,0 +1,278 @@
+package tlsutil
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// CertStore holds the certificate, key and CA certificates loaded from the
+// files of a Config. The files can be loaded again while the agent is
+// running, and the TLS configs built from a Config with the store use the
+// new material for the handshakes that follow, so certificates can be
+// rotated without a restart.
+type CertStore struct {
+	l sync.RWMutex
+
+	// files has the paths of the files the material was loaded from.
+	files Config
+
+	// cert is the certificate with its key, along with its parsed leaf. It's
+	// nil if no certificate was configured.
+	cert *tls.Certificate
+
+	// caPool has the CA certificates. It's nil if no CAs were configured.
+	caPool *x509.CertPool
+
+	// caExpiry is the earliest expiry of the CA certificates.
+	caExpiry time.Time
+
+	// modTimes has the modification times of the files when they were
+	// loaded, to detect changes.
+	modTimes map[string]time.Time
+}
+
+// NewCertStore returns a store with the material loaded from the files of
+// the given config.
+func NewCertStore(c *Config) (*CertStore, error) {
+	s := &CertStore{}
+	if err := s.Update(c); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Update loads the material from the files of the given config, which may be
+// different from the ones loaded so far. The material in use is only
+// replaced if all the files could be loaded.
+func (s *CertStore) Update(c *Config) error {
+	files := Config{
+		CAFile:   c.CAFile,
+		CAPath:   c.CAPath,
+		CertFile: c.CertFile,
+		KeyFile:  c.KeyFile,
+	}
+
+	modTimes, err := fileModTimes(&files)
+	if err != nil {
+		return err
+	}
+
+	cert, err := files.KeyPair()
+	if err != nil {
+		return err
+	}
+	if cert != nil {
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			return fmt.Errorf("Failed to parse certificate: %v", err)
+		}
+		cert.Leaf = leaf
+	}
+
+	cas, err := loadCAs(&files)
+	if err != nil {
+		return err
+	}
+	var caPool *x509.CertPool
+	var caExpiry time.Time
+	if len(cas) > 0 {
+		caPool = x509.NewCertPool()
+		for _, ca := range cas {
+			caPool.AddCert(ca)
+			if caExpiry.IsZero() || ca.NotAfter.Before(caExpiry) {
+				caExpiry = ca.NotAfter
+			}
+		}
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+	s.files = files
+	s.cert = cert
+	s.caPool = caPool
+	s.caExpiry = caExpiry
+	s.modTimes = modTimes
+	return nil
+}
+
+// Reload loads the material again from the same files.
+func (s *CertStore) Reload() error {
+	s.l.RLock()
+	files := s.files
+	s.l.RUnlock()
+	return s.Update(&files)
+}
+
+// Changed returns whether any of the files changed on disk since they were
+// loaded. Files that can't be read count as changed, so the error comes up
+// when they are loaded.
+func (s *CertStore) Changed() bool {
+	s.l.RLock()
+	files, old := s.files, s.modTimes
+	s.l.RUnlock()
+
+	modTimes, err := fileModTimes(&files)
+	if err != nil || len(modTimes) != len(old) {
+		return true
+	}
+	for path, t := range modTimes {
+		if !old[path].Equal(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// CertExpiry returns when the certificate expires, or the zero time if no
+// certificate was configured.
+func (s *CertStore) CertExpiry() time.Time {
+	s.l.RLock()
+	defer s.l.RUnlock()
+	if s.cert == nil {
+		return time.Time{}
+	}
+	return s.cert.Leaf.NotAfter
+}
+
+// CAExpiry returns when the first of the CA certificates expires, or the
+// zero time if no CAs were configured.
+func (s *CertStore) CAExpiry() time.Time {
+	s.l.RLock()
+	defer s.l.RUnlock()
+	return s.caExpiry
+}
+
+// certificate returns the certificate, or nil if there isn't one.
+func (s *CertStore) certificate() *tls.Certificate {
+	s.l.RLock()
+	defer s.l.RUnlock()
+	return s.cert
+}
+
+// CAPool returns the pool of CA certificates, or nil if there are none.
+func (s *CertStore) CAPool() *x509.CertPool {
+	s.l.RLock()
+	defer s.l.RUnlock()
+	return s.caPool
+}
+
+// GetCertificate returns the certificate for incoming connections. It's
+// meant to be used as the tls.Config callback of the same name.
+func (s *CertStore) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return s.certificate(), nil
+}
+
+// GetClientCertificate returns the certificate for outgoing connections. It's
+// meant to be used as the tls.Config callback of the same name. No
+// certificate is sent if there isn't one.
+func (s *CertStore) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	if cert := s.certificate(); cert != nil {
+		return cert, nil
+	}
+	return &tls.Certificate{}, nil
+}
+
+// loadCAs parses the CA certificates of the config. Like the rest of the TLS
+// setup, the CA file takes precedence over the CA path.
+func loadCAs(c *Config) ([]*x509.Certificate, error) {
+	switch {
+	case c.CAFile != "":
+		cas, err := parseCerts(c.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		if len(cas) == 0 {
+			return nil, fmt.Errorf("Failed to parse any CA certificates")
+		}
+		return cas, nil
+
+	case c.CAPath != "":
+		var cas []*x509.Certificate
+		err := filepath.Walk(c.CAPath, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				return nil
+			}
+			certs, err := parseCerts(path)
+			if err != nil {
+				return err
+			}
+			if len(certs) == 0 {
+				return fmt.Errorf("Error loading %s, no certificates found", path)
+			}
+			cas = append(cas, certs...)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return cas, nil
+
+	default:
+		return nil, nil
+	}
+}
+
+// parseCerts parses the PEM encoded certificates in the file.
+func parseCerts(path string) ([]*x509.Certificate, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read CA file: %v", err)
+	}
+
+	var certs []*x509.Certificate
+	for len(data) > 0 {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" || len(block.Headers) != 0 {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			continue
+		}
+		certs = append(certs, cert)
+	}
+	return certs, nil
+}
+
+// fileModTimes returns the modification times of the files of the config,
+// including the ones in the CA path.
+func fileModTimes(c *Config) (map[string]time.Time, error) {
+	modTimes := make(map[string]time.Time)
+	for _, path := range []string{c.CAFile, c.CertFile, c.KeyFile} {
+		if path == "" {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to check TLS file: %v", err)
+		}
+		modTimes[path] = info.ModTime()
+	}
+	if c.CAPath != "" && c.CAFile == "" {
+		err := filepath.Walk(c.CAPath, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return fmt.Errorf("Failed to check TLS file: %v", err)
+			}
+			modTimes[path] = info.ModTime()
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return modTimes, nil
+}
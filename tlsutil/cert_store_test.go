@@ -0,0 +1,241 @@
+package tlsutil
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testWriteCerts writes a new CA and a certificate signed by it to the
+// ca.pem, cert.pem and key.pem files in the directory. The certificate
+// expires after the given time and has the given serial number.
+func testWriteCerts(t *testing.T, dir string, serial int64, expiry time.Time) {
+	writePEM := func(name, typ string, der []byte) {
+		data := pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der})
+		if err := ioutil.WriteFile(filepath.Join(dir, name), data, 0600); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              expiry.Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "server.dc1.consul"},
+		DNSNames:     []string{"server.dc1.consul"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     expiry,
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	writePEM("ca.pem", "CERTIFICATE", caDER)
+	writePEM("cert.pem", "CERTIFICATE", der)
+	writePEM("key.pem", "EC PRIVATE KEY", keyDER)
+}
+
+// testCertFilesConfig returns a config with the files written by
+// testWriteCerts.
+func testCertFilesConfig(dir string) *Config {
+	return &Config{
+		CAFile:   filepath.Join(dir, "ca.pem"),
+		CertFile: filepath.Join(dir, "cert.pem"),
+		KeyFile:  filepath.Join(dir, "key.pem"),
+	}
+}
+
+func TestCertStore(t *testing.T) {
+	dir := testTempDir(t)
+	defer os.RemoveAll(dir)
+
+	expiry := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+	testWriteCerts(t, dir, 1, expiry)
+
+	s, err := NewCertStore(testCertFilesConfig(dir))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if got := s.CertExpiry(); !got.Equal(expiry) {
+		t.Fatalf("got %v want %v", got, expiry)
+	}
+	if got, want := s.CAExpiry(), expiry.Add(time.Hour); !got.Equal(want) {
+		t.Fatalf("got %v want %v", got, want)
+	}
+	if len(s.CAPool().Subjects()) != 1 {
+		t.Fatalf("expected a CA")
+	}
+	if s.Changed() {
+		t.Fatalf("should not be changed")
+	}
+
+	// Rotate the certificates.
+	expiry = expiry.Add(24 * time.Hour)
+	testWriteCerts(t, dir, 2, expiry)
+	future := time.Now().Add(time.Minute)
+	for _, name := range []string{"ca.pem", "cert.pem", "key.pem"} {
+		if err := os.Chtimes(filepath.Join(dir, name), future, future); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+	if !s.Changed() {
+		t.Fatalf("should be changed")
+	}
+	if err := s.Reload(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if s.Changed() {
+		t.Fatalf("should not be changed")
+	}
+	if got := s.CertExpiry(); !got.Equal(expiry) {
+		t.Fatalf("got %v want %v", got, expiry)
+	}
+	cert, err := s.GetCertificate(nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if cert.Leaf.SerialNumber.Int64() != 2 {
+		t.Fatalf("bad: %v", cert.Leaf.SerialNumber)
+	}
+
+	// A failed update keeps the loaded material.
+	bad := testCertFilesConfig(dir)
+	bad.KeyFile = filepath.Join(dir, "nope.pem")
+	if err := s.Update(bad); err == nil {
+		t.Fatalf("should fail")
+	}
+	if got := s.CertExpiry(); !got.Equal(expiry) {
+		t.Fatalf("got %v want %v", got, expiry)
+	}
+
+	// Updating to no files drops the certificate and CAs.
+	if err := s.Update(&Config{}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !s.CertExpiry().IsZero() || !s.CAExpiry().IsZero() || s.CAPool() != nil {
+		t.Fatalf("should not have certificates")
+	}
+	cert, err = s.GetClientCertificate(nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(cert.Certificate) != 0 {
+		t.Fatalf("should not send a certificate")
+	}
+}
+
+func TestCertStore_CAPath(t *testing.T) {
+	s, err := NewCertStore(&Config{CAPath: "../test/ca_path"})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(s.CAPool().Subjects()) != 2 {
+		t.Fatalf("expected two CAs")
+	}
+
+	if _, err := NewCertStore(&Config{CAFile: "../test/key/ourdomain.key"}); err == nil {
+		t.Fatalf("should fail")
+	}
+}
+
+func TestCertStore_Handshake(t *testing.T) {
+	dir := testTempDir(t)
+	defer os.RemoveAll(dir)
+	testWriteCerts(t, dir, 1, time.Now().Add(24*time.Hour))
+
+	s, err := NewCertStore(testCertFilesConfig(dir))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	config := testCertFilesConfig(dir)
+	config.VerifyIncoming = true
+	config.VerifyServerHostname = true
+	config.Domain = "consul"
+	config.CertStore = s
+
+	wrap, err := config.OutgoingTLSWrapper()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	handshake := func() int64 {
+		client, errc := startTLSServer(config)
+		if client == nil {
+			t.Fatalf("startTLSServer err: %v", <-errc)
+		}
+		tlsClient, err := wrap("dc1", client)
+		if err != nil {
+			t.Fatalf("wrapTLS err: %v", err)
+		}
+		defer tlsClient.Close()
+		conn := tlsClient.(*tls.Conn)
+		if err := conn.Handshake(); err != nil {
+			t.Fatalf("handshake err: %v", err)
+		}
+		if err := <-errc; err != nil {
+			t.Fatalf("server: %v", err)
+		}
+		return conn.ConnectionState().PeerCertificates[0].SerialNumber.Int64()
+	}
+	if got, want := handshake(), int64(1); got != want {
+		t.Fatalf("got serial %d want %d", got, want)
+	}
+
+	// Both sides pick up a new CA and certificate for new connections
+	// without building new configs.
+	testWriteCerts(t, dir, 2, time.Now().Add(24*time.Hour))
+	if err := s.Reload(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if got, want := handshake(), int64(2); got != want {
+		t.Fatalf("got serial %d want %d", got, want)
+	}
+}
+
+func testTempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "tlsutil")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	return dir
+}
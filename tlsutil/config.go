@@ -87,6 +87,12 @@ type Config struct {
 	// PreferServerCipherSuites specifies whether to prefer the server's ciphersuite
 	// over the client ciphersuites.
 	PreferServerCipherSuites bool
+
+	// CertStore, if set, provides the certificate and CA certificates
+	// instead of the files above, which are then only loaded by the store.
+	// The TLS configs pick up the material the store has at the time of
+	// each handshake, so it can be reloaded without building new configs.
+	CertStore *CertStore
 }
 
 // AppendCA opens and parses the CA file and adds the certificates to
@@ -158,21 +164,28 @@ func (c *Config) OutgoingTLSConfig() (*tls.Config, error) {
 		return nil, fmt.Errorf("VerifyOutgoing set, and no CA certificate provided!")
 	}
 
-	// Parse the CA certs if any
-	rootConfig := &rootcerts.Config{
-		CAFile: c.CAFile,
-		CAPath: c.CAPath,
-	}
-	if err := rootcerts.ConfigureTLS(tlsConfig, rootConfig); err != nil {
-		return nil, err
-	}
+	if c.CertStore != nil {
+		// The CAs are refreshed for each connection by the wrapper, and
+		// the cert is asked for during the handshake.
+		tlsConfig.RootCAs = c.CertStore.CAPool()
+		tlsConfig.GetClientCertificate = c.CertStore.GetClientCertificate
+	} else {
+		// Parse the CA certs if any
+		rootConfig := &rootcerts.Config{
+			CAFile: c.CAFile,
+			CAPath: c.CAPath,
+		}
+		if err := rootcerts.ConfigureTLS(tlsConfig, rootConfig); err != nil {
+			return nil, err
+		}
 
-	// Add cert/key
-	cert, err := c.KeyPair()
-	if err != nil {
-		return nil, err
-	} else if cert != nil {
-		tlsConfig.Certificates = []tls.Certificate{*cert}
+		// Add cert/key
+		cert, err := c.KeyPair()
+		if err != nil {
+			return nil, err
+		} else if cert != nil {
+			tlsConfig.Certificates = []tls.Certificate{*cert}
+		}
 	}
 
 	// Check if a minimum TLS version was set
@@ -187,39 +200,6 @@ func (c *Config) OutgoingTLSConfig() (*tls.Config, error) {
 	return tlsConfig, nil
 }
 
-// Clone returns a copy of c. Only the exported fields are copied. This
-// was copied from https://golang.org/src/crypto/tls/common.go since that
-// isn't exported and Go 1.7's vet uncovered an unsafe copy of a mutex in
-// here.
-//
-// TODO (slackpad) - This can be removed once we move to Go 1.8, see
-// https://github.com/golang/go/commit/d24f446 for details.
-func clone(c *tls.Config) *tls.Config {
-	return &tls.Config{
-		Rand:                        c.Rand,
-		Time:                        c.Time,
-		Certificates:                c.Certificates,
-		NameToCertificate:           c.NameToCertificate,
-		GetCertificate:              c.GetCertificate,
-		RootCAs:                     c.RootCAs,
-		NextProtos:                  c.NextProtos,
-		ServerName:                  c.ServerName,
-		ClientAuth:                  c.ClientAuth,
-		ClientCAs:                   c.ClientCAs,
-		InsecureSkipVerify:          c.InsecureSkipVerify,
-		CipherSuites:                c.CipherSuites,
-		PreferServerCipherSuites:    c.PreferServerCipherSuites,
-		SessionTicketsDisabled:      c.SessionTicketsDisabled,
-		SessionTicketKey:            c.SessionTicketKey,
-		ClientSessionCache:          c.ClientSessionCache,
-		MinVersion:                  c.MinVersion,
-		MaxVersion:                  c.MaxVersion,
-		CurvePreferences:            c.CurvePreferences,
-		DynamicRecordSizingDisabled: c.DynamicRecordSizingDisabled,
-		Renegotiation:               c.Renegotiation,
-	}
-}
-
 // OutgoingTLSWrapper returns a a DCWrapper based on the OutgoingTLS
 // configuration. If hostname verification is on, the wrapper
 // will properly generate the dynamic server name for verification.
@@ -238,17 +218,22 @@ func (c *Config) OutgoingTLSWrapper() (DCWrapper, error) {
 	// Strip the trailing '.' from the domain if any
 	domain := strings.TrimSuffix(c.Domain, ".")
 
-	wrapper := func(dc string, c net.Conn) (net.Conn, error) {
-		return WrapTLSClient(c, tlsConfig)
-	}
-
-	// Generate the wrapper based on hostname verification
-	if c.VerifyServerHostname {
-		wrapper = func(dc string, conn net.Conn) (net.Conn, error) {
-			conf := clone(tlsConfig)
+	// Generate the wrapper based on hostname verification, and pick up
+	// the current CAs of the cert store if there is one.
+	store := c.CertStore
+	verifyServerHostname := c.VerifyServerHostname
+	wrapper := func(dc string, conn net.Conn) (net.Conn, error) {
+		conf := tlsConfig
+		if store != nil || verifyServerHostname {
+			conf = tlsConfig.Clone()
+		}
+		if store != nil {
+			conf.RootCAs = store.CAPool()
+		}
+		if verifyServerHostname {
 			conf.ServerName = "server." + dc + "." + domain
-			return WrapTLSClient(conn, conf)
 		}
+		return WrapTLSClient(conn, conf)
 	}
 
 	return wrapper, nil
@@ -338,27 +323,39 @@ func (c *Config) IncomingTLSConfig() (*tls.Config, error) {
 		tlsConfig.PreferServerCipherSuites = true
 	}
 
-	// Parse the CA certs if any
-	if c.CAFile != "" {
-		pool, err := rootcerts.LoadCAFile(c.CAFile)
-		if err != nil {
-			return nil, err
+	var hasCert bool
+	if c.CertStore != nil {
+		// The cert is asked for during the handshake, and the CAs are
+		// refreshed for each connection below.
+		if pool := c.CertStore.CAPool(); pool != nil {
+			tlsConfig.ClientCAs = pool
+		}
+		tlsConfig.GetCertificate = c.CertStore.GetCertificate
+		hasCert = c.CertStore.certificate() != nil
+	} else {
+		// Parse the CA certs if any
+		if c.CAFile != "" {
+			pool, err := rootcerts.LoadCAFile(c.CAFile)
+			if err != nil {
+				return nil, err
+			}
+			tlsConfig.ClientCAs = pool
+		} else if c.CAPath != "" {
+			pool, err := rootcerts.LoadCAPath(c.CAPath)
+			if err != nil {
+				return nil, err
+			}
+			tlsConfig.ClientCAs = pool
 		}
-		tlsConfig.ClientCAs = pool
-	} else if c.CAPath != "" {
-		pool, err := rootcerts.LoadCAPath(c.CAPath)
+
+		// Add cert/key
+		cert, err := c.KeyPair()
 		if err != nil {
 			return nil, err
+		} else if cert != nil {
+			tlsConfig.Certificates = []tls.Certificate{*cert}
 		}
-		tlsConfig.ClientCAs = pool
-	}
-
-	// Add cert/key
-	cert, err := c.KeyPair()
-	if err != nil {
-		return nil, err
-	} else if cert != nil {
-		tlsConfig.Certificates = []tls.Certificate{*cert}
+		hasCert = cert != nil
 	}
 
 	// Check if we require verification
@@ -367,7 +364,7 @@ func (c *Config) IncomingTLSConfig() (*tls.Config, error) {
 		if c.CAFile == "" && c.CAPath == "" {
 			return nil, fmt.Errorf("VerifyIncoming set, and no CA certificate provided!")
 		}
-		if cert == nil {
+		if !hasCert {
 			return nil, fmt.Errorf("VerifyIncoming set, and no Cert/Key pair provided!")
 		}
 	}
@@ -380,6 +377,18 @@ func (c *Config) IncomingTLSConfig() (*tls.Config, error) {
 		}
 		tlsConfig.MinVersion = tlsvers
 	}
+
+	// Verify clients with the current CAs of the cert store.
+	if store := c.CertStore; store != nil {
+		base := tlsConfig.Clone()
+		tlsConfig.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			conf := base.Clone()
+			if pool := store.CAPool(); pool != nil {
+				conf.ClientCAs = pool
+			}
+			return conf, nil
+		}
+	}
 	return tlsConfig, nil
 }
 
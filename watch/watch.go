@@ -4,9 +4,26 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net/url"
+	"strings"
 	"sync"
+	"time"
 
 	consulapi "github.com/hashicorp/consul/api"
+	"github.com/mitchellh/mapstructure"
+)
+
+const (
+	// HandlerTypeScript runs a script or subprocess with the watch data on
+	// stdin. This is the default handler type.
+	HandlerTypeScript = "script"
+
+	// HandlerTypeHTTP sends the watch data to an HTTP endpoint.
+	HandlerTypeHTTP = "http"
+
+	// defaultHTTPHandlerTimeout is the timeout of an HTTP handler request
+	// if none is configured.
+	defaultHTTPHandlerTimeout = 10 * time.Second
 )
 
 // Plan is the parsed version of a watch specification. A watch provides
@@ -19,6 +36,12 @@ type Plan struct {
 	Type       string
 	Exempt     map[string]interface{}
 
+	// HandlerType is the type of handler which should be used for the
+	// plan, and HTTPHandlerConfig is its configuration if that is
+	// HandlerTypeHTTP.
+	HandlerType       string
+	HTTPHandlerConfig *HTTPHandlerConfig
+
 	Watcher   WatcherFunc
 	Handler   HandlerFunc
 	LogOutput io.Writer
@@ -34,6 +57,17 @@ type Plan struct {
 	cancelFunc context.CancelFunc
 }
 
+// HTTPHandlerConfig configures a handler which sends the watch data to an
+// HTTP endpoint.
+type HTTPHandlerConfig struct {
+	Path          string              `mapstructure:"path"`
+	Method        string              `mapstructure:"method"`
+	Timeout       time.Duration       `mapstructure:"-"`
+	TimeoutRaw    string              `mapstructure:"timeout"`
+	Header        map[string][]string `mapstructure:"header"`
+	TLSSkipVerify bool                `mapstructure:"tls_skip_verify"`
+}
+
 // WatcherFunc is used to watch for a diff
 type WatcherFunc func(*Plan) (uint64, interface{}, error)
 
@@ -62,6 +96,25 @@ func ParseExempt(params map[string]interface{}, exempt []string) (*Plan, error)
 	if err := assignValue(params, "type", &plan.Type); err != nil {
 		return nil, err
 	}
+	if err := assignValue(params, "handler_type", &plan.HandlerType); err != nil {
+		return nil, err
+	}
+
+	// Parse the handler configuration
+	switch plan.HandlerType {
+	case "":
+		plan.HandlerType = HandlerTypeScript
+	case HandlerTypeScript:
+	case HandlerTypeHTTP:
+		config, err := ParseHTTPHandlerConfig(params["http_handler_config"])
+		if err != nil {
+			return nil, err
+		}
+		plan.HTTPHandlerConfig = config
+		delete(params, "http_handler_config")
+	default:
+		return nil, fmt.Errorf("Unsupported handler type: %s", plan.HandlerType)
+	}
 
 	// Ensure there is a watch type
 	if plan.Type == "" {
@@ -104,6 +157,56 @@ func ParseExempt(params map[string]interface{}, exempt []string) (*Plan, error)
 	return plan, nil
 }
 
+// ParseHTTPHandlerConfig decodes and validates the configuration of an HTTP
+// handler, filling in the defaults for the method and timeout.
+func ParseHTTPHandlerConfig(raw interface{}) (*HTTPHandlerConfig, error) {
+	if raw == nil {
+		return nil, fmt.Errorf("Handler type 'http' requires 'http_handler_config' to be set")
+	}
+
+	var config HTTPHandlerConfig
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		ErrorUnused:      true,
+		WeaklyTypedInput: true,
+		Result:           &config,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := decoder.Decode(raw); err != nil {
+		return nil, fmt.Errorf("Failed to parse http_handler_config: %v", err)
+	}
+
+	if config.Path == "" {
+		return nil, fmt.Errorf("http_handler_config requires 'path' to be set")
+	}
+	u, err := url.Parse(config.Path)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid http_handler_config path %q: %v", config.Path, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("Invalid http_handler_config path %q: must be an http or https URL", config.Path)
+	}
+
+	config.Method = strings.ToUpper(config.Method)
+	if config.Method == "" {
+		config.Method = "POST"
+	}
+
+	config.Timeout = defaultHTTPHandlerTimeout
+	if config.TimeoutRaw != "" {
+		timeout, err := time.ParseDuration(config.TimeoutRaw)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid http_handler_config timeout %q: %v", config.TimeoutRaw, err)
+		}
+		if timeout <= 0 {
+			return nil, fmt.Errorf("Invalid http_handler_config timeout %q: must be positive", config.TimeoutRaw)
+		}
+		config.Timeout = timeout
+	}
+	return &config, nil
+}
+
 // assignValue is used to extract a value ensuring it is a string
 func assignValue(params map[string]interface{}, name string, out *string) error {
 	if raw, ok := params[name]; ok {
@@ -3,6 +3,8 @@ package watch
 import (
 	"bytes"
 	"encoding/json"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -38,6 +40,43 @@ func TestParse_exempt(t *testing.T) {
 	}
 }
 
+func TestParse_httpHandler(t *testing.T) {
+	params := makeParams(t, `{"type":"key", "key":"foo", "handler_type": "http",
+		"http_handler_config": {"path": "http://example.com/hook", "header": {"X-Foo": ["bar"]}}}`)
+	p, err := Parse(params)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if p.HandlerType != HandlerTypeHTTP {
+		t.Fatalf("bad: %#v", p)
+	}
+	expected := &HTTPHandlerConfig{
+		Path:    "http://example.com/hook",
+		Method:  "POST",
+		Timeout: defaultHTTPHandlerTimeout,
+		Header:  map[string][]string{"X-Foo": []string{"bar"}},
+	}
+	if !reflect.DeepEqual(p.HTTPHandlerConfig, expected) {
+		t.Fatalf("bad: %#v", p.HTTPHandlerConfig)
+	}
+}
+
+func TestParse_httpHandler_bad(t *testing.T) {
+	cases := map[string]string{
+		`{"type":"key", "key":"foo", "handler_type": "email"}`:                                                           "Unsupported handler type: email",
+		`{"type":"key", "key":"foo", "handler_type": "http"}`:                                                            "requires 'http_handler_config'",
+		`{"type":"key", "key":"foo", "handler_type": "http", "http_handler_config": {"path": "foo"}}`:                    "must be an http or https URL",
+		`{"type":"key", "key":"foo", "handler_type": "http", "http_handler_config": {"path": "http://a", "x": "y"}}`:     "invalid keys: x",
+		`{"type":"key", "key":"foo", "handler_type": "http", "http_handler_config": {"path": "http://a", "timeout": 1}}`: "Invalid http_handler_config timeout",
+	}
+	for in, want := range cases {
+		_, err := Parse(makeParams(t, in))
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("%s: got %v want %q", in, err, want)
+		}
+	}
+}
+
 func makeParams(t *testing.T, s string) map[string]interface{} {
 	var out map[string]interface{}
 	dec := json.NewDecoder(bytes.NewReader([]byte(s)))